@@ -0,0 +1,44 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"context"
+	"net/url"
+
+	v1 "github.com/loadimpact/k6/api/v1"
+)
+
+// Scenarios returns all scenarios of the current test run.
+func (c *Client) Scenarios(ctx context.Context) (ret []v1.Scenario, err error) {
+	return ret, c.Call(ctx, "GET", &url.URL{Path: "/v1/scenarios"}, nil, &ret)
+}
+
+// Scenario returns the scenario with the given name.
+func (c *Client) Scenario(ctx context.Context, name string) (ret v1.Scenario, err error) {
+	return ret, c.Call(ctx, "GET", &url.URL{Path: "/v1/scenarios/" + url.PathEscape(name)}, nil, &ret)
+}
+
+// SetScenario tries to stop or change the config of the given scenario and
+// returns its new state if it was successful.
+func (c *Client) SetScenario(ctx context.Context, patch v1.Scenario) (ret v1.Scenario, err error) {
+	return ret, c.Call(ctx, "PATCH", &url.URL{Path: "/v1/scenarios/" + url.PathEscape(patch.Name)}, patch, &ret)
+}
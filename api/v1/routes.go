@@ -38,6 +38,10 @@ func NewHandler() http.Handler {
 	router.GET("/v1/groups", HandleGetGroups)
 	router.GET("/v1/groups/:id", HandleGetGroup)
 
+	router.GET("/v1/scenarios", HandleGetScenarios)
+	router.GET("/v1/scenarios/:name", HandleGetScenario)
+	router.PATCH("/v1/scenarios/:name", HandlePatchScenario)
+
 	router.POST("/v1/setup", HandleRunSetup)
 	router.PUT("/v1/setup", HandleSetSetupData)
 	router.GET("/v1/setup", HandleGetSetupData)
@@ -0,0 +1,93 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package v1
+
+import (
+	"encoding/json"
+
+	"github.com/loadimpact/k6/core"
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/ui/pb"
+)
+
+// Scenario is the REST API representation of a single running scenario.
+type Scenario struct {
+	Name        string                 `json:"-" yaml:"name"`
+	Executor    string                 `json:"executor" yaml:"executor"`
+	Description string                 `json:"description" yaml:"description"`
+	Status      string                 `json:"status" yaml:"status"`
+	Progress    float64                `json:"progress" yaml:"progress"`
+	Stopped     bool                   `json:"stopped" yaml:"stopped"`
+	Config      map[string]interface{} `json:"config" yaml:"config"`
+}
+
+// NewScenario returns the current state of the supplied executor.
+func NewScenario(engine *core.Engine, executor lib.Executor) (Scenario, error) {
+	config := executor.GetConfig()
+	status, progress := executor.GetProgress().GetStatus()
+	scenario := Scenario{
+		Name:        config.GetName(),
+		Executor:    config.GetType(),
+		Description: config.GetDescription(engine.ExecutionScheduler.GetState().ExecutionTuple),
+		Status:      getScenarioStatus(status),
+		Progress:    progress,
+		Stopped:     status == pb.Interrupted,
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return scenario, err
+	}
+	return scenario, json.Unmarshal(data, &scenario.Config)
+}
+
+func getScenarioStatus(status pb.Status) string {
+	switch status {
+	case pb.Running:
+		return "running"
+	case pb.Waiting:
+		return "waiting"
+	case pb.Stopping:
+		return "stopping"
+	case pb.Interrupted:
+		return "interrupted"
+	case pb.Done:
+		return "done"
+	default:
+		return "not started"
+	}
+}
+
+// GetName returns the JSON API resource type name.
+func (s Scenario) GetName() string {
+	return "scenario"
+}
+
+// GetID returns the scenario name, which is unique.
+func (s Scenario) GetID() string {
+	return s.Name
+}
+
+// SetID sets the scenario name.
+func (s *Scenario) SetID(id string) error {
+	s.Name = id
+	return nil
+}
@@ -0,0 +1,163 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/manyminds/api2go/jsonapi"
+
+	"github.com/loadimpact/k6/api/common"
+	"github.com/loadimpact/k6/core"
+	"github.com/loadimpact/k6/lib"
+)
+
+// HandleGetScenarios returns all scenarios that have work in the current test run.
+func HandleGetScenarios(rw http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	engine := common.GetEngine(r.Context())
+
+	scenarios := make([]Scenario, 0)
+	for _, executor := range engine.ExecutionScheduler.GetExecutors() {
+		scenario, err := NewScenario(engine, executor)
+		if err != nil {
+			apiError(rw, "Encoding error", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		scenarios = append(scenarios, scenario)
+	}
+
+	data, err := jsonapi.Marshal(scenarios)
+	if err != nil {
+		apiError(rw, "Encoding error", err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, _ = rw.Write(data)
+}
+
+func getExecutor(engine *core.Engine, name string) lib.Executor {
+	for _, executor := range engine.ExecutionScheduler.GetExecutors() {
+		if executor.GetConfig().GetName() == name {
+			return executor
+		}
+	}
+	return nil
+}
+
+func writeScenario(rw http.ResponseWriter, engine *core.Engine, executor lib.Executor) {
+	scenario, err := NewScenario(engine, executor)
+	if err != nil {
+		apiError(rw, "Encoding error", err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data, err := jsonapi.Marshal(scenario)
+	if err != nil {
+		apiError(rw, "Encoding error", err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, _ = rw.Write(data)
+}
+
+// HandleGetScenario returns a single scenario by its name.
+func HandleGetScenario(rw http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	engine := common.GetEngine(r.Context())
+
+	executor := getExecutor(engine, p.ByName("name"))
+	if executor == nil {
+		apiError(rw, "Not Found", "No scenario with that name was found", http.StatusNotFound)
+		return
+	}
+	writeScenario(rw, engine, executor)
+}
+
+// HandlePatchScenario stops a single scenario or changes its config. Only the
+// supplied config options are changed, the rest keep their current values.
+// Which options can be changed depends on the executor type.
+func HandlePatchScenario(rw http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	engine := common.GetEngine(r.Context())
+
+	executor := getExecutor(engine, p.ByName("name"))
+	if executor == nil {
+		apiError(rw, "Not Found", "No scenario with that name was found", http.StatusNotFound)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		apiError(rw, "Couldn't read request", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var patch Scenario
+	if err = jsonapi.Unmarshal(body, &patch); err != nil {
+		apiError(rw, "Invalid data", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	config := executor.GetConfig()
+	if patch.Stopped {
+		if err = engine.ExecutionScheduler.StopExecutor(config.GetName()); err != nil {
+			apiError(rw, "Stop error", err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else if len(patch.Config) > 0 {
+		newConfig, err := getPatchedExecutorConfig(config, patch.Config)
+		if err != nil {
+			apiError(rw, "Invalid config", err.Error(), http.StatusBadRequest)
+			return
+		}
+		err = engine.ExecutionScheduler.UpdateExecutorConfig(r.Context(), config.GetName(), newConfig)
+		if err != nil {
+			apiError(rw, "Config update error", err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	writeScenario(rw, engine, executor)
+}
+
+// getPatchedExecutorConfig overwrites the options of the current config with
+// the ones from the patch and parses the result as a new executor config.
+func getPatchedExecutorConfig(
+	config lib.ExecutorConfig, patch map[string]interface{},
+) (lib.ExecutorConfig, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	options := make(map[string]interface{})
+	if err = json.Unmarshal(data, &options); err != nil {
+		return nil, err
+	}
+	for key, value := range patch {
+		if key == "executor" && value != config.GetType() {
+			return nil, fmt.Errorf("the executor type of a running scenario cannot be changed")
+		}
+		options[key] = value
+	}
+	if data, err = json.Marshal(options); err != nil {
+		return nil, err
+	}
+	return lib.GetParsedExecutorConfig(config.GetName(), config.GetType(), data)
+}
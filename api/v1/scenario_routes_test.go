@@ -0,0 +1,177 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/manyminds/api2go/jsonapi"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loadimpact/k6/core"
+	"github.com/loadimpact/k6/core/local"
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/testutils"
+	"github.com/loadimpact/k6/lib/testutils/minirunner"
+)
+
+func getScenariosTestEngine(t *testing.T, ctx context.Context) *core.Engine {
+	logger := logrus.New()
+	logger.SetOutput(testutils.NewTestOutput(t))
+
+	scenarios := lib.ScenarioConfigs{}
+	err := json.Unmarshal([]byte(`{
+		"car": {"executor": "constant-arrival-rate", "rate": 10, "duration": "2s", "preAllocatedVUs": 2, "maxVUs": 2},
+		"cvus": {"executor": "constant-vus", "vus": 2, "duration": "2s"},
+		"rvus": {"executor": "ramping-vus", "startVUs": 1, "stages": [{"duration": "2s", "target": 1}]},
+		"later": {"executor": "per-vu-iterations", "vus": 1, "iterations": 1, "startTime": "1m"}
+	}`), &scenarios)
+	require.NoError(t, err)
+	options := lib.Options{Scenarios: scenarios}
+
+	execScheduler, err := local.NewExecutionScheduler(&minirunner.MiniRunner{Options: options}, logger)
+	require.NoError(t, err)
+	engine, err := core.NewEngine(execScheduler, options, lib.RuntimeOptions{}, nil, logger)
+	require.NoError(t, err)
+	run, _, err := engine.Init(ctx, ctx)
+	require.NoError(t, err)
+
+	go func() { _ = run() }()
+	// wait for the executors to start to avoid a potential data race below
+	time.Sleep(100 * time.Millisecond)
+	return engine
+}
+
+func TestGetScenarios(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	engine := getScenariosTestEngine(t, ctx)
+
+	rw := httptest.NewRecorder()
+	NewHandler().ServeHTTP(rw, newRequestWithEngine(engine, "GET", "/v1/scenarios", nil))
+	res := rw.Result()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var scenarios []Scenario
+	require.NoError(t, jsonapi.Unmarshal(rw.Body.Bytes(), &scenarios))
+	require.Len(t, scenarios, 4)
+	byName := make(map[string]Scenario, len(scenarios))
+	for _, s := range scenarios {
+		byName[s.Name] = s
+	}
+	assert.Equal(t, "constant-arrival-rate", byName["car"].Executor)
+	assert.Equal(t, "running", byName["car"].Status)
+	assert.Equal(t, "2s", byName["cvus"].Config["duration"])
+	assert.Equal(t, "waiting", byName["later"].Status)
+
+	t.Run("single", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		NewHandler().ServeHTTP(rw, newRequestWithEngine(engine, "GET", "/v1/scenarios/cvus", nil))
+		res := rw.Result()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var scenario Scenario
+		require.NoError(t, jsonapi.Unmarshal(rw.Body.Bytes(), &scenario))
+		assert.Equal(t, "cvus", scenario.Name)
+		assert.Equal(t, "constant-vus", scenario.Executor)
+		assert.Equal(t, float64(2), scenario.Config["vus"])
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		NewHandler().ServeHTTP(rw, newRequestWithEngine(engine, "GET", "/v1/scenarios/nope", nil))
+		assert.Equal(t, http.StatusNotFound, rw.Result().StatusCode)
+	})
+}
+
+func TestPatchScenario(t *testing.T) {
+	testdata := map[string]struct {
+		StatusCode int
+		Scenario   Scenario
+		Check      func(t *testing.T, engine *core.Engine, s Scenario)
+	}{
+		"nothing": {200, Scenario{Name: "cvus"}, nil},
+		"longer duration": {200, Scenario{Name: "car", Config: map[string]interface{}{"duration": "5s"}},
+			func(t *testing.T, _ *core.Engine, s Scenario) { assert.Equal(t, "5s", s.Config["duration"]) }},
+		"shorter than elapsed": {400, Scenario{Name: "cvus", Config: map[string]interface{}{"duration": "1ms"}}, nil},
+		"not updatable option": {400, Scenario{Name: "cvus", Config: map[string]interface{}{"vus": 1}}, nil},
+		"too many vus":         {400, Scenario{Name: "car", Config: map[string]interface{}{"rate": 1000}}, nil},
+		"changed executor": {400, Scenario{
+			Name: "cvus", Config: map[string]interface{}{"executor": "shared-iterations"},
+		}, nil},
+		"more stages": {200, Scenario{Name: "rvus", Config: map[string]interface{}{
+			"stages": []map[string]interface{}{{"duration": "2s", "target": 1}, {"duration": "1s", "target": 1}},
+		}}, func(t *testing.T, _ *core.Engine, s Scenario) { assert.Len(t, s.Config["stages"], 2) }},
+		"graceful ramp down": {200, Scenario{Name: "rvus", Config: map[string]interface{}{"gracefulRampDown": "5s"}},
+			func(t *testing.T, _ *core.Engine, s Scenario) { assert.Equal(t, "5s", s.Config["gracefulRampDown"]) }},
+		"shorter current stage": {400, Scenario{Name: "rvus", Config: map[string]interface{}{
+			"stages": []map[string]interface{}{{"duration": "1ms", "target": 1}},
+		}}, nil},
+		"too many ramping vus": {400, Scenario{Name: "rvus", Config: map[string]interface{}{
+			"stages": []map[string]interface{}{{"duration": "2s", "target": 1}, {"duration": "1s", "target": 100}},
+		}}, nil},
+		"not updatable ramping option": {400, Scenario{
+			Name: "rvus", Config: map[string]interface{}{"startVUs": 2},
+		}, nil},
+		"unknown scenario": {404, Scenario{Name: "nope", Stopped: true}, nil},
+		"stopped": {200, Scenario{Name: "later", Stopped: true}, func(t *testing.T, engine *core.Engine, _ Scenario) {
+			time.Sleep(100 * time.Millisecond)
+			s, err := NewScenario(engine, getExecutor(engine, "later"))
+			require.NoError(t, err)
+			assert.Equal(t, "interrupted", s.Status)
+			assert.True(t, s.Stopped)
+		}},
+	}
+
+	for name, indata := range testdata {
+		indata := indata
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+			engine := getScenariosTestEngine(t, ctx)
+
+			body, err := jsonapi.Marshal(indata.Scenario)
+			require.NoError(t, err)
+
+			rw := httptest.NewRecorder()
+			NewHandler().ServeHTTP(rw, newRequestWithEngine(
+				engine, "PATCH", "/v1/scenarios/"+indata.Scenario.Name, bytes.NewReader(body),
+			))
+			res := rw.Result()
+			require.Equal(t, indata.StatusCode, res.StatusCode, rw.Body.String())
+			if indata.Check == nil {
+				return
+			}
+
+			var scenario Scenario
+			require.NoError(t, jsonapi.Unmarshal(rw.Body.Bytes(), &scenario))
+			indata.Check(t, engine, scenario)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package cmd
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/loadimpact/k6/core/distributed"
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/loader"
+)
+
+func getAgentCmd(ctx context.Context, logger *logrus.Logger) *cobra.Command {
+	var coordinatorAddress, agentName string
+
+	agentCmd := &cobra.Command{
+		Use:   "agent",
+		Short: "Execute a part of a distributed load test",
+		Long: `Execute a part of a distributed load test.
+
+The agent connects to a coordinator started with the "k6 coordinator" command,
+receives the test run from it and executes its own share of the VUs and
+iterations. All metrics are sent to the coordinator, so no outputs, thresholds
+or end-of-test summary are handled by the agent. If the coordinator requires a
+token, the same one has to be given with --token or the K6_COORDINATOR_TOKEN
+environment variable.`,
+		Example: `
+  # Connect to a coordinator running on another machine.
+  K6_COORDINATOR_TOKEN=some-secret-token k6 agent --coordinator-address coordinator.example.com:6566`[1:],
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			osEnvironment := buildEnvMap(os.Environ())
+			runtimeOptions, err := getRuntimeOptions(cmd.Flags(), osEnvironment)
+			if err != nil {
+				return err
+			}
+			token, err := getCoordinatorToken(cmd.Flags(), osEnvironment)
+			if err != nil {
+				return err
+			}
+			if agentName == "" {
+				agentName, _ = os.Hostname()
+			}
+
+			agent, err := distributed.NewAgent(coordinatorAddress, token, agentName, logger)
+			if err != nil {
+				return err
+			}
+
+			agentCtx, agentCancel := context.WithCancel(ctx)
+			defer agentCancel()
+
+			sigC := make(chan os.Signal, 1)
+			signal.Notify(sigC, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+			defer signal.Stop(sigC)
+			go func() {
+				sig := <-sigC
+				logger.WithField("sig", sig).Debug("Stopping the agent in response to signal...")
+				agentCancel()
+
+				sig = <-sigC
+				logger.WithField("sig", sig).Error("Aborting k6 in response to signal")
+				os.Exit(externalAbortErrorCode)
+			}()
+
+			newAgentRunner := func(archive []byte) (lib.Runner, error) {
+				src := &loader.SourceData{Data: archive, URL: &url.URL{Path: "/archive.tar"}}
+				return newRunner(logger, src, typeArchive, nil, runtimeOptions)
+			}
+			return agent.Run(agentCtx, newAgentRunner, runtimeOptions)
+		},
+	}
+
+	agentCmd.Flags().SortFlags = false
+	agentCmd.Flags().AddFlagSet(agentCmdFlagSet())
+	agentCmd.Flags().StringVar(&coordinatorAddress, "coordinator-address", defaultCoordinatorAddress,
+		"address of the coordinator")
+	agentCmd.Flags().StringVar(&agentName, "name", "", "name of the agent, the hostname by default")
+	agentCmd.Flags().String("token", "", "`token` to authenticate with the coordinator, "+
+		"can also be set with the "+coordinatorTokenEnvVar+" environment variable")
+
+	return agentCmd
+}
+
+func agentCmdFlagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.SortFlags = false
+	flags.AddFlagSet(runtimeOptionFlagSet(false))
+	return flags
+}
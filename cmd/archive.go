@@ -21,13 +21,18 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
+	"sort"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/types"
 	"github.com/loadimpact/k6/loader"
 )
 
@@ -55,13 +60,16 @@ An archive is a fully self-contained test run, and can be executed identically e
 				return err
 			}
 			filename := args[0]
-			filesystems := loader.CreateFilesystems()
-			src, err := loader.ReadSource(logger, filename, pwd, filesystems, os.Stdin)
+			runtimeOptions, err := getRuntimeOptions(cmd.Flags(), buildEnvMap(os.Environ()))
 			if err != nil {
 				return err
 			}
 
-			runtimeOptions, err := getRuntimeOptions(cmd.Flags(), buildEnvMap(os.Environ()))
+			filesystems, err := createFilesystems(runtimeOptions, false)
+			if err != nil {
+				return err
+			}
+			src, err := loader.ReadSource(logger, filename, pwd, filesystems, os.Stdin)
 			if err != nil {
 				return err
 			}
@@ -89,6 +97,11 @@ An archive is a fully self-contained test run, and can be executed identically e
 				return err
 			}
 
+			if err = checkProxyCredentials(conf.Options); err != nil {
+				logger.WithError(err).Warn("The archive doesn't contain all of the proxy credentials, the global ones " +
+					"have to be given again with the --proxy flag or the K6_PROXY environment variable when running it")
+			}
+
 			// Archive.
 			arc := r.MakeArchive()
 			f, err := os.Create(archiveOut)
@@ -114,3 +127,29 @@ func archiveCmdFlagSet() *pflag.FlagSet {
 	flags.StringVarP(&archiveOut, "archive-out", "O", archiveOut, "archive output filename")
 	return flags
 }
+
+// checkProxyCredentials returns an error if any of the proxies in the options
+// has credentials, since they are left out of the marshalled options and thus
+// out of archives. Only the credentials of the global proxies can be given
+// again when running an archive, the scenario ones are lost.
+func checkProxyCredentials(options lib.Options) error {
+	var where []string
+	if options.Proxy.HasCredentials() {
+		where = append(where, "the global proxy")
+	}
+	names := make([]string, 0, len(options.Scenarios))
+	for name := range options.Scenarios {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		conf, ok := options.Scenarios[name].(interface{ GetProxy() types.NullProxyPool })
+		if ok && conf.GetProxy().HasCredentials() {
+			where = append(where, fmt.Sprintf("the proxy of scenario '%s'", name))
+		}
+	}
+	if len(where) == 0 {
+		return nil
+	}
+	return fmt.Errorf("the credentials of %s are left out of archives", strings.Join(where, ", "))
+}
@@ -98,14 +98,17 @@ This will execute the test on the k6 cloud service. Use "k6 login cloud" to auth
 			}
 
 			filename := args[0]
-			filesystems := loader.CreateFilesystems()
-			src, err := loader.ReadSource(logger, filename, pwd, filesystems, os.Stdin)
+			osEnvironment := buildEnvMap(os.Environ())
+			runtimeOptions, err := getRuntimeOptions(cmd.Flags(), osEnvironment)
 			if err != nil {
 				return err
 			}
 
-			osEnvironment := buildEnvMap(os.Environ())
-			runtimeOptions, err := getRuntimeOptions(cmd.Flags(), osEnvironment)
+			filesystems, err := createFilesystems(runtimeOptions, false)
+			if err != nil {
+				return err
+			}
+			src, err := loader.ReadSource(logger, filename, pwd, filesystems, os.Stdin)
 			if err != nil {
 				return err
 			}
@@ -149,6 +152,10 @@ This will execute the test on the k6 cloud service. Use "k6 login cloud" to auth
 				return errors.New("Not logged in, please use `k6 login cloud`.") //nolint:golint
 			}
 
+			if err = checkProxyCredentials(conf.Options); err != nil {
+				logger.WithError(err).Warn("The proxy credentials aren't sent to the cloud")
+			}
+
 			modifyAndPrintBar(progressBar, pb.WithConstProgress(0, "Building the archive"))
 			arc := r.MakeArchive()
 			// TODO: Fix this
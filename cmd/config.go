@@ -257,6 +257,14 @@ func consolidateErrorMessage(errList []error, title string) error {
 }
 
 func validateScenarioConfig(conf lib.ExecutorConfig, isExecutable func(string) bool) error {
+	if weightedExec := conf.GetWeightedExec(); weightedExec != nil {
+		for _, e := range weightedExec {
+			if !isExecutable(e.Fn) {
+				return fmt.Errorf("executor %s: function '%s' not found in exports", conf.GetName(), e.Fn)
+			}
+		}
+		return nil
+	}
 	execFn := conf.GetExec()
 	if !isExecutable(execFn) {
 		return fmt.Errorf("executor %s: function '%s' not found in exports", conf.GetName(), execFn)
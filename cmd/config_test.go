@@ -154,7 +154,7 @@ func TestDeriveAndValidateConfig(t *testing.T) {
 			"executor default: function 'default' not found in exports"},
 		{"nonDefaultOK", Config{Options: lib.Options{Scenarios: lib.ScenarioConfigs{
 			"per_vu_iters": executor.PerVUIterationsConfig{BaseConfig: executor.BaseConfig{
-				Name: "per_vu_iters", Type: "per-vu-iterations", Exec: null.StringFrom("nonDefault")},
+				Name: "per_vu_iters", Type: "per-vu-iterations", Exec: executor.NewExecConfig("nonDefault")},
 				VUs:         null.IntFrom(1),
 				Iterations:  null.IntFrom(1),
 				MaxDuration: types.NullDurationFrom(time.Second),
@@ -162,13 +162,24 @@ func TestDeriveAndValidateConfig(t *testing.T) {
 		},
 		{"nonDefaultErr", Config{Options: lib.Options{Scenarios: lib.ScenarioConfigs{
 			"per_vu_iters": executor.PerVUIterationsConfig{BaseConfig: executor.BaseConfig{
-				Name: "per_vu_iters", Type: "per-vu-iterations", Exec: null.StringFrom("nonDefaultErr")},
+				Name: "per_vu_iters", Type: "per-vu-iterations", Exec: executor.NewExecConfig("nonDefaultErr")},
 				VUs:         null.IntFrom(1),
 				Iterations:  null.IntFrom(1),
 				MaxDuration: types.NullDurationFrom(time.Second),
 			}}}}, false,
 			"executor per_vu_iters: function 'nonDefaultErr' not found in exports",
 		},
+		{"weightedErr", Config{Options: lib.Options{Scenarios: lib.ScenarioConfigs{
+			"per_vu_iters": executor.PerVUIterationsConfig{BaseConfig: executor.BaseConfig{
+				Name: "per_vu_iters", Type: "per-vu-iterations", Exec: executor.ExecConfig{
+					Weighted: lib.WeightedExecs{{Fn: "browse", Weight: 3}, {Fn: "search", Weight: 1}},
+				}},
+				VUs:         null.IntFrom(1),
+				Iterations:  null.IntFrom(1),
+				MaxDuration: types.NullDurationFrom(time.Second),
+			}}}}, false,
+			"executor per_vu_iters: function 'browse' not found in exports",
+		},
 	}
 
 	for _, tc := range testCases {
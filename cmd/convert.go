@@ -21,20 +21,35 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"path/filepath"
+	"strings"
 
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"gopkg.in/guregu/null.v3"
 
+	"github.com/loadimpact/k6/converter/curl"
 	"github.com/loadimpact/k6/converter/har"
+	"github.com/loadimpact/k6/converter/openapi"
+	"github.com/loadimpact/k6/converter/postman"
 	"github.com/loadimpact/k6/lib"
 )
 
+// the input formats of k6 convert
+const (
+	convertFormatHAR     = "har"
+	convertFormatOpenAPI = "openapi"
+	convertFormatPostman = "postman"
+	convertFormatCurl    = "curl"
+)
+
 // TODO: fix this... or remove k6 convert
-//nolint: gochecknoglobals
+// nolint: gochecknoglobals
 var (
 	convertOutput       string
 	optionsFilePath     string
@@ -47,18 +62,42 @@ var (
 	nobatch             bool
 	only                []string
 	skip                []string
+	convertFormat       string
+	baseURL             string
+	environmentFilePath string
 )
 
 //nolint:funlen,gocognit
 func getConvertCmd() *cobra.Command {
 	convertCmd := &cobra.Command{
 		Use:   "convert",
-		Short: "Convert a HAR file to a k6 script",
-		Long:  "Convert a HAR (HTTP Archive) file to a k6 script",
+		Short: "Convert a HAR file, an OpenAPI specification, a Postman collection or curl commands to a k6 script",
+		Long: `Convert a HAR (HTTP Archive) file, an OpenAPI 3 or Swagger 2.0 specification,
+a Postman collection v2.1 or curl command lines to a k6 script.
+
+A HAR file is converted to a script that repeats the recorded requests, while
+a specification is converted to a script with a request for each operation,
+grouped by tags. A Postman collection is converted to a script with a group for
+each folder, and with its variables, authentication and the scripts that can be
+translated to k6. Curl commands are read from a file, one per line, or given as
+the argument. The format is detected from the content of the file, unless it's
+given with --from.`,
 		Example: `
   # Convert a HAR file to a k6 script.
   k6 convert -O har-session.js session.har
 
+  # Convert an OpenAPI specification to a k6 script with status code checks.
+  k6 convert --enable-status-code-checks -O api.js openapi.yaml
+
+  # Convert an OpenAPI specification, with the load test options in a JSON file.
+  k6 convert --options load.json --base-url https://staging.example.com -O api.js openapi.json
+
+  # Convert a Postman collection with the values of an environment.
+  k6 convert --environment staging.postman_environment.json -O api.js api.postman_collection.json
+
+  # Convert curl commands to a k6 script.
+  k6 convert --from curl -O curl.js "curl -X POST -d name=k6 https://example.com/items"
+
   # Convert a HAR file to a k6 script creating requests only for the given domain/s.
   k6 convert -O har-session.js --only yourdomain.com,additionaldomain.com session.har
 
@@ -69,66 +108,49 @@ func getConvertCmd() *cobra.Command {
   k6 run har-session.js`[1:],
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Parse the HAR file
-			filePath, err := filepath.Abs(args[0])
-			if err != nil {
-				return err
-			}
-			r, err := defaultFs.Open(filePath)
-			if err != nil {
-				return err
-			}
-			h, err := har.Decode(r)
-			if err != nil {
-				return err
-			}
-			if err = r.Close(); err != nil {
-				return err
-			}
-
-			// recordings include redirections as separate requests, and we dont want to trigger them twice
-			options := lib.Options{MaxRedirects: null.IntFrom(0)}
-
-			if optionsFilePath != "" {
-				optionsFileContents, err := ioutil.ReadFile(optionsFilePath) //nolint:gosec,govet
+			var data []byte
+			if isCurlCommand(args[0]) {
+				data = []byte(args[0])
+			} else {
+				filePath, err := filepath.Abs(args[0])
 				if err != nil {
 					return err
 				}
-				var injectedOptions lib.Options
-				if err := json.Unmarshal(optionsFileContents, &injectedOptions); err != nil {
+				data, err = afero.ReadFile(defaultFs, filePath)
+				if err != nil {
 					return err
 				}
-				options = options.Apply(injectedOptions)
 			}
 
-			// TODO: refactor...
-			script, err := har.Convert(h, options, minSleep, maxSleep, enableChecks,
-				returnOnFailedCheck, threshold, nobatch, correlate, only, skip)
+			format := convertFormat
+			if format == "" {
+				format = detectConvertFormat(data)
+			}
+
+			injectedOptions, err := readInjectedOptions()
 			if err != nil {
 				return err
 			}
 
-			// Write script content to stdout or file
-			if convertOutput == "" || convertOutput == "-" { //nolint:nestif
-				if _, err := io.WriteString(defaultWriter, script); err != nil {
-					return err
-				}
-			} else {
-				f, err := defaultFs.Create(convertOutput)
-				if err != nil {
-					return err
-				}
-				if _, err := f.WriteString(script); err != nil {
-					return err
-				}
-				if err := f.Sync(); err != nil {
-					return err
-				}
-				if err := f.Close(); err != nil {
-					return err
-				}
+			var script string
+			switch format {
+			case convertFormatHAR:
+				script, err = convertHAR(data, injectedOptions)
+			case convertFormatOpenAPI:
+				script, err = convertOpenAPI(data, injectedOptions)
+			case convertFormatPostman:
+				script, err = convertPostman(data, injectedOptions)
+			case convertFormatCurl:
+				script, err = convertCurl(data, injectedOptions)
+			default:
+				return fmt.Errorf("unsupported format %q, use %q, %q, %q or %q", format,
+					convertFormatHAR, convertFormatOpenAPI, convertFormatPostman, convertFormatCurl)
+			}
+			if err != nil {
+				return err
 			}
-			return nil
+
+			return writeScript(script)
 		},
 	}
 
@@ -141,14 +163,160 @@ func getConvertCmd() *cobra.Command {
 		&optionsFilePath, "options", "", optionsFilePath,
 		"path to a JSON file with options that would be injected in the output script",
 	)
+	convertCmd.Flags().StringVarP(&convertFormat, "from", "", "",
+		"the `format` of the input, \"har\", \"openapi\", \"postman\" or \"curl\" (detected by default)")
+	convertCmd.Flags().StringVarP(&baseURL, "base-url", "", "", "the base URL of the requests, instead of the first server of an OpenAPI specification")
+	convertCmd.Flags().StringVarP(&environmentFilePath, "environment", "", "",
+		"path to a Postman environment file with the values of the variables of a collection")
 	convertCmd.Flags().StringSliceVarP(&only, "only", "", []string{}, "include only requests from the given domains")
 	convertCmd.Flags().StringSliceVarP(&skip, "skip", "", []string{}, "skip requests from the given domains")
 	convertCmd.Flags().UintVarP(&threshold, "batch-threshold", "", 500, "batch request idle time threshold (see example)")
 	convertCmd.Flags().BoolVarP(&nobatch, "no-batch", "", false, "don't generate batch calls")
 	convertCmd.Flags().BoolVarP(&enableChecks, "enable-status-code-checks", "", false, "add a status code check for each HTTP response")
 	convertCmd.Flags().BoolVarP(&returnOnFailedCheck, "return-on-failed-check", "", false, "return from iteration if we get an unexpected response status code")
-	convertCmd.Flags().BoolVarP(&correlate, "correlate", "", false, "detect values in responses being used in subsequent requests and try adapt the script accordingly (only redirects and JSON values for now)")
+	convertCmd.Flags().BoolVarP(&correlate, "correlate", "", false, "detect values in responses being used in subsequent requests and try adapt the script accordingly")
 	convertCmd.Flags().UintVarP(&minSleep, "min-sleep", "", 20, "the minimum amount of seconds to sleep after each iteration")
 	convertCmd.Flags().UintVarP(&maxSleep, "max-sleep", "", 40, "the maximum amount of seconds to sleep after each iteration")
 	return convertCmd
 }
+
+// isCurlCommand returns whether the argument of k6 convert is a curl command
+// instead of the path of a file
+func isCurlCommand(arg string) bool {
+	arg = strings.TrimSpace(arg)
+	return strings.HasPrefix(arg, "curl ") || strings.HasPrefix(arg, "curl\t") || strings.HasPrefix(arg, "curl\n")
+}
+
+// detectConvertFormat returns the format of the input of k6 convert
+func detectConvertFormat(data []byte) string {
+	switch {
+	case isCurlCommand(string(data)):
+		return convertFormatCurl
+	case postman.IsCollection(data):
+		return convertFormatPostman
+	case openapi.IsSpec(data):
+		return convertFormatOpenAPI
+	default:
+		return convertFormatHAR
+	}
+}
+
+// readInjectedOptions reads the options given with --options, if any
+func readInjectedOptions() (*lib.Options, error) {
+	if optionsFilePath == "" {
+		return nil, nil
+	}
+	optionsFileContents, err := ioutil.ReadFile(optionsFilePath) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	injectedOptions := &lib.Options{}
+	if err := json.Unmarshal(optionsFileContents, injectedOptions); err != nil {
+		return nil, err
+	}
+	return injectedOptions, nil
+}
+
+// writeScript writes a converted script to the --output file, or to stdout
+func writeScript(script string) error {
+	if convertOutput == "" || convertOutput == "-" {
+		_, err := io.WriteString(defaultWriter, script)
+		return err
+	}
+	f, err := defaultFs.Create(convertOutput)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(script); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func convertHAR(data []byte, injectedOptions *lib.Options) (string, error) {
+	h, err := har.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	return convertHARLog(h, injectedOptions)
+}
+
+// convertHARLog converts a decoded HAR, from a file or from k6 record
+func convertHARLog(h har.HAR, injectedOptions *lib.Options) (string, error) {
+	// recordings include redirections as separate requests, and we dont want to trigger them twice
+	options := lib.Options{MaxRedirects: null.IntFrom(0)}
+	if injectedOptions != nil {
+		options = options.Apply(*injectedOptions)
+	}
+
+	// TODO: refactor...
+	return har.Convert(h, options, minSleep, maxSleep, enableChecks,
+		returnOnFailedCheck, threshold, nobatch, correlate, only, skip)
+}
+
+func convertOpenAPI(data []byte, injectedOptions *lib.Options) (string, error) {
+	spec, err := openapi.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	// a single iteration is a smoke test of the API, the load has to come from the options
+	options := lib.Options{VUs: null.IntFrom(1), Iterations: null.IntFrom(1)}
+	if injectedOptions != nil {
+		options = *injectedOptions
+	}
+
+	opts := openapi.Options{BaseURL: baseURL, NoBatch: nobatch}
+	opts.Options, opts.MinSleep, opts.MaxSleep = options, minSleep, maxSleep
+	opts.EnableChecks, opts.ReturnOnFailedCheck = enableChecks, returnOnFailedCheck
+	return openapi.Convert(spec, opts)
+}
+
+func convertPostman(data []byte, injectedOptions *lib.Options) (string, error) {
+	collection, err := postman.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	var env *postman.Environment
+	if environmentFilePath != "" {
+		envData, err := afero.ReadFile(defaultFs, environmentFilePath)
+		if err != nil {
+			return "", err
+		}
+		if env, err = postman.DecodeEnvironment(bytes.NewReader(envData)); err != nil {
+			return "", err
+		}
+	}
+
+	// like in a run of the collection in Postman
+	options := lib.Options{VUs: null.IntFrom(1), Iterations: null.IntFrom(1)}
+	if injectedOptions != nil {
+		options = *injectedOptions
+	}
+
+	opts := postman.Options{Environment: env}
+	opts.Options, opts.MinSleep, opts.MaxSleep = options, minSleep, maxSleep
+	opts.EnableChecks, opts.ReturnOnFailedCheck = enableChecks, returnOnFailedCheck
+	return postman.Convert(collection, opts)
+}
+
+func convertCurl(data []byte, injectedOptions *lib.Options) (string, error) {
+	commands, err := curl.Parse(string(data))
+	if err != nil {
+		return "", err
+	}
+
+	options := lib.Options{VUs: null.IntFrom(1), Iterations: null.IntFrom(1)}
+	if injectedOptions != nil {
+		options = *injectedOptions
+	}
+
+	var opts curl.Options
+	opts.Options, opts.MinSleep, opts.MaxSleep = options, minSleep, maxSleep
+	opts.EnableChecks, opts.ReturnOnFailedCheck = enableChecks, returnOnFailedCheck
+	return curl.Convert(commands, opts)
+}
@@ -208,6 +208,104 @@ func TestIntegrationConvertCmd(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, testHARConvertResult, string(output))
 	})
+	t.Run("OpenAPI", func(t *testing.T) {
+		specFile, err := filepath.Abs("openapi.yaml")
+		require.NoError(t, err)
+		defaultFs = afero.NewMemMapFs()
+		err = afero.WriteFile(defaultFs, specFile, []byte(`
+openapi: 3.0.3
+info:
+  title: Example
+  version: "1.0"
+servers:
+  - url: https://api.example.com
+paths:
+  /items:
+    get:
+      tags: [items]
+      responses:
+        "200":
+          description: OK
+`), 0o644)
+		require.NoError(t, err)
+
+		buf := &bytes.Buffer{}
+		defaultWriter = buf
+
+		convertCmd := getConvertCmd()
+		assert.NoError(t, convertCmd.Flags().Set("enable-status-code-checks", "true"))
+		assert.NoError(t, convertCmd.Flags().Set("base-url", "http://localhost"))
+		err = convertCmd.RunE(convertCmd, []string{specFile})
+		assert.NoError(t, convertCmd.Flags().Set("enable-status-code-checks", "false"))
+		assert.NoError(t, convertCmd.Flags().Set("base-url", ""))
+		require.NoError(t, err)
+
+		result := buf.String()
+		assert.Contains(t, result, "export let options = {\n    vus: 1,\n    iterations: 1,\n};\n")
+		assert.Contains(t, result, `const BASE_URL = __ENV.BASE_URL || "http://localhost";`)
+		assert.Contains(t, result, `group("items", function() {`)
+		assert.Contains(t, result, `check(res[0], {"status is 200": (r) => r.status === 200 });`)
+
+		assert.NoError(t, convertCmd.Flags().Set("from", "har"))
+		err = convertCmd.RunE(convertCmd, []string{specFile})
+		assert.NoError(t, convertCmd.Flags().Set("from", ""))
+		assert.Error(t, err) // YAML isn't a valid HAR file
+
+		assert.NoError(t, convertCmd.Flags().Set("from", "pdf"))
+		err = convertCmd.RunE(convertCmd, []string{specFile})
+		assert.NoError(t, convertCmd.Flags().Set("from", ""))
+		assert.EqualError(t, err, `unsupported format "pdf", use "har", "openapi", "postman" or "curl"`)
+	})
+	t.Run("Postman", func(t *testing.T) {
+		collectionFile, err := filepath.Abs("collection.json")
+		require.NoError(t, err)
+		environmentFile, err := filepath.Abs("environment.json")
+		require.NoError(t, err)
+		defaultFs = afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(defaultFs, collectionFile, []byte(`{
+			"info": {
+				"name": "Example",
+				"schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+			},
+			"item": [{
+				"name": "Items",
+				"item": [{"name": "List items", "request": {"method": "GET", "url": "{{baseUrl}}/items"}}]
+			}]
+		}`), 0o644))
+		require.NoError(t, afero.WriteFile(defaultFs, environmentFile, []byte(`{
+			"name": "Local",
+			"values": [{"key": "baseUrl", "value": "http://localhost", "enabled": true}]
+		}`), 0o644))
+
+		buf := &bytes.Buffer{}
+		defaultWriter = buf
+
+		convertCmd := getConvertCmd()
+		assert.NoError(t, convertCmd.Flags().Set("environment", environmentFile))
+		err = convertCmd.RunE(convertCmd, []string{collectionFile})
+		assert.NoError(t, convertCmd.Flags().Set("environment", ""))
+		require.NoError(t, err)
+
+		result := buf.String()
+		assert.Contains(t, result, "// Collection: Example\n// Environment: Local\n")
+		assert.Contains(t, result, `"baseUrl": __ENV["baseUrl"] || "http://localhost",`)
+		assert.Contains(t, result, "\tgroup(\"Items\", function() {\n\t\t// List items\n\t\tres = http.get(vars[\"baseUrl\"] + \"/items\");\n")
+	})
+	t.Run("Curl", func(t *testing.T) {
+		defaultFs = afero.NewMemMapFs()
+		buf := &bytes.Buffer{}
+		defaultWriter = buf
+
+		convertCmd := getConvertCmd()
+		assert.NoError(t, convertCmd.Flags().Set("from", "curl"))
+		err := convertCmd.RunE(convertCmd, []string{"curl -X POST -d name=k6 http://localhost/items"})
+		assert.NoError(t, convertCmd.Flags().Set("from", ""))
+		require.NoError(t, err)
+
+		result := buf.String()
+		assert.Contains(t, result, "export let options = {\n    vus: 1,\n    iterations: 1,\n};\n")
+		assert.Contains(t, result, "\t// curl -X POST -d name=k6 http://localhost/items\n\tres = http.post(\"http://localhost/items\", \"name=k6\", {")
+	})
 	// TODO: test options injection; right now that's difficult because when there are multiple
 	// options, they can be emitted in different order in the JSON
 }
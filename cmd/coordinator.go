@@ -0,0 +1,287 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/loadimpact/k6/api"
+	"github.com/loadimpact/k6/core"
+	"github.com/loadimpact/k6/core/distributed"
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/consts"
+	"github.com/loadimpact/k6/loader"
+	"github.com/loadimpact/k6/ui/pb"
+)
+
+const (
+	defaultCoordinatorAddress = "localhost:6566"
+	// The environment variable with the token shared by the coordinator and
+	// the agents, an alternative to the --token flag.
+	coordinatorTokenEnvVar = "K6_COORDINATOR_TOKEN"
+)
+
+// getCoordinatorToken returns the value of the --token flag, or the one of the
+// K6_COORDINATOR_TOKEN environment variable if the flag wasn't specified.
+func getCoordinatorToken(flags *pflag.FlagSet, environment map[string]string) (string, error) {
+	if flags.Changed("token") {
+		return flags.GetString("token")
+	}
+	return environment[coordinatorTokenEnvVar], nil
+}
+
+//nolint:funlen,gocognit
+func getCoordinatorCmd(ctx context.Context, logger *logrus.Logger) *cobra.Command {
+	var (
+		agentsCount        int
+		coordinatorAddress string
+	)
+
+	coordinatorCmd := &cobra.Command{
+		Use:   "coordinator",
+		Short: "Start a distributed load test",
+		Long: `Start a distributed load test.
+
+The coordinator waits for the specified number of agents to connect, splits the
+test run between them and executes it. The metrics of all agents are collected
+by the coordinator, which also evaluates the thresholds and shows the end-of-test
+summary. The setup() and teardown() functions are executed by the coordinator.
+
+Agents are started with the "k6 agent" command. They receive the whole test
+run, including its environment variables, so they have to authenticate with
+the token given with --token or the K6_COORDINATOR_TOKEN environment variable.`,
+		Example: `
+  # Split a test run with 100 VUs between 4 agents, 25 VUs each, waiting for
+  # agents on all network interfaces.
+  export K6_COORDINATOR_TOKEN=some-secret-token
+  k6 coordinator --coordinator-address 0.0.0.0:6566 --agents 4 -u 100 -d 10m script.js
+
+  # On each of the 4 agent machines:
+  export K6_COORDINATOR_TOKEN=some-secret-token
+  k6 agent --coordinator-address coordinator.example.com:6566`[1:],
+		Args: exactArgsWithMsg(1, "arg should either be \"-\", if reading script from stdin, or a path to a script file"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, _ = BannerColor.Fprintf(stdout, "\n%s\n\n", consts.Banner())
+
+			logger.Debug("Initializing the runner...")
+			pwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			filename := args[0]
+			filesystems := loader.CreateFilesystems()
+			src, err := loader.ReadSource(logger, filename, pwd, filesystems, os.Stdin)
+			if err != nil {
+				return err
+			}
+
+			osEnvironment := buildEnvMap(os.Environ())
+			runtimeOptions, err := getRuntimeOptions(cmd.Flags(), osEnvironment)
+			if err != nil {
+				return err
+			}
+			token, err := getCoordinatorToken(cmd.Flags(), osEnvironment)
+			if err != nil {
+				return err
+			}
+			if token == "" {
+				logger.Warnf("No token was specified with --token or %s, so any agent that can connect "+
+					"to the coordinator gets the whole test run", coordinatorTokenEnvVar)
+			}
+
+			initRunner, err := newRunner(logger, src, runType, filesystems, runtimeOptions)
+			if err != nil {
+				return err
+			}
+
+			logger.Debug("Getting the script options...")
+			cliConf, err := getConfig(cmd.Flags())
+			if err != nil {
+				return err
+			}
+			conf, err := getConsolidatedConfig(afero.NewOsFs(), cliConf, initRunner)
+			if err != nil {
+				return err
+			}
+			conf, cerr := deriveAndValidateConfig(conf, initRunner.IsExecutable)
+			if cerr != nil {
+				return ExitCode{error: cerr, Code: invalidConfigErrorCode}
+			}
+			if err = initRunner.SetOptions(conf.Options); err != nil {
+				return err
+			}
+
+			// The agents get the whole test run as an archive, with all of
+			// its options already consolidated, so they can't get any proxy
+			// credentials.
+			if err = checkProxyCredentials(conf.Options); err != nil {
+				return fmt.Errorf("%w, so they can't be sent to the agents", err)
+			}
+			archive := &bytes.Buffer{}
+			if err = initRunner.MakeArchive().Write(archive); err != nil {
+				return err
+			}
+
+			globalCtx, globalCancel := context.WithCancel(ctx)
+			defer globalCancel()
+			runCtx, runCancel := context.WithCancel(globalCtx)
+			defer runCancel()
+
+			logger.Debug("Initializing the coordinator...")
+			coordinator, err := distributed.NewCoordinator(initRunner, archive.Bytes(), agentsCount, token, logger)
+			if err != nil {
+				return err
+			}
+
+			progressCtx, progressCancel := context.WithCancel(globalCtx)
+			defer progressCancel()
+			initBar := coordinator.GetInitProgressBar()
+			progressBarWG := &sync.WaitGroup{}
+			progressBarWG.Add(1)
+			go func() {
+				showProgress(progressCtx, conf, []*pb.ProgressBar{initBar}, logger)
+				progressBarWG.Done()
+			}()
+
+			executionPlan := coordinator.GetExecutionPlan()
+			outputs, err := createOutputs(conf.Out, src, conf, runtimeOptions, executionPlan, osEnvironment, logger)
+			if err != nil {
+				return err
+			}
+
+			initBar.Modify(pb.WithConstProgress(0, "Init engine"))
+			engine, err := core.NewEngine(coordinator, conf.Options, runtimeOptions, outputs, logger)
+			if err != nil {
+				return err
+			}
+
+			if address != "" {
+				go func() {
+					logger.Debugf("Starting the REST API server on %s", address)
+					if aerr := api.ListenAndServe(address, engine, logger); aerr != nil {
+						if cmd.Flags().Lookup("address").Changed {
+							logger.WithError(aerr).Error("Error from API server")
+							os.Exit(cannotStartRESTAPIErrorCode)
+						} else {
+							logger.WithError(aerr).Warn("Error from API server")
+						}
+					}
+				}()
+			}
+
+			go func() {
+				logger.Debugf("Waiting for agents on %s", coordinatorAddress)
+				if cerr := http.ListenAndServe(coordinatorAddress, coordinator); cerr != nil { //nolint:gosec
+					logger.WithError(cerr).Error("Error from the coordinator server")
+					os.Exit(cannotStartCoordinatorErrorCode)
+				}
+			}()
+
+			initBar.Modify(pb.WithConstProgress(0, "Starting outputs"))
+			if err = engine.StartOutputs(); err != nil {
+				return err
+			}
+			defer engine.StopOutputs()
+
+			printExecutionDescription(
+				"distributed", filename, "", conf, coordinator.GetState().ExecutionTuple,
+				executionPlan, outputs)
+
+			sigC := make(chan os.Signal, 1)
+			signal.Notify(sigC, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+			defer signal.Stop(sigC)
+			go func() {
+				sig := <-sigC
+				logger.WithField("sig", sig).Debug("Stopping k6 in response to signal...")
+				runCancel() // stops the agents as well
+
+				sig = <-sigC
+				logger.WithField("sig", sig).Error("Aborting k6 in response to signal")
+				globalCancel()
+				os.Exit(externalAbortErrorCode)
+			}()
+
+			engineRun, engineWait, err := engine.Init(globalCtx, runCtx)
+			if err != nil {
+				return getExitCodeFromEngine(err)
+			}
+
+			if err := engineRun(); err != nil {
+				return getExitCodeFromEngine(err)
+			}
+			runCancel()
+			logger.Debug("Engine run terminated cleanly")
+
+			progressCancel()
+			progressBarWG.Wait()
+
+			executionState := coordinator.GetState()
+			if executionState.GetFullIterationCount() == 0 {
+				logger.Warn("No script iterations finished, consider making the test duration longer")
+			}
+
+			if !runtimeOptions.NoSummary.Bool {
+				summaryResult, err := initRunner.HandleSummary(globalCtx, &lib.Summary{
+					Metrics:         engine.Metrics,
+					RootGroup:       initRunner.GetDefaultGroup(),
+					TestRunDuration: executionState.GetCurrentTestRunDuration(),
+				})
+				if err == nil {
+					err = handleSummaryResult(afero.NewOsFs(), stdout, stderr, summaryResult)
+				}
+				if err != nil {
+					logger.WithError(err).Error("failed to handle the end-of-test summary")
+				}
+			}
+
+			globalCancel()
+			logger.Debug("Waiting for engine processes to finish...")
+			engineWait()
+			if engine.IsTainted() {
+				return ExitCode{error: errors.New("some thresholds have failed"), Code: thresholdHaveFailedErrorCode}
+			}
+			return nil
+		},
+	}
+
+	coordinatorCmd.Flags().SortFlags = false
+	coordinatorCmd.Flags().AddFlagSet(runCmdFlagSet())
+	coordinatorCmd.Flags().IntVar(&agentsCount, "agents", 1, "number of agents that will execute the test run")
+	coordinatorCmd.Flags().StringVar(&coordinatorAddress, "coordinator-address", defaultCoordinatorAddress,
+		"address on which the coordinator waits for agents")
+	coordinatorCmd.Flags().String("token", "", "`token` the agents have to authenticate with, "+
+		"can also be set with the "+coordinatorTokenEnvVar+" environment variable")
+
+	return coordinatorCmd
+}
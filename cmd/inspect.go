@@ -46,7 +46,15 @@ func getInspectCmd(logger logrus.FieldLogger) *cobra.Command {
 			if err != nil {
 				return err
 			}
-			filesystems := loader.CreateFilesystems()
+			runtimeOptions, err := getRuntimeOptions(cmd.Flags(), buildEnvMap(os.Environ()))
+			if err != nil {
+				return err
+			}
+
+			filesystems, err := createFilesystems(runtimeOptions, false)
+			if err != nil {
+				return err
+			}
 			src, err := loader.ReadSource(logger, args[0], pwd, filesystems, os.Stdin)
 			if err != nil {
 				return err
@@ -57,11 +65,6 @@ func getInspectCmd(logger logrus.FieldLogger) *cobra.Command {
 				typ = detectType(src.Data)
 			}
 
-			runtimeOptions, err := getRuntimeOptions(cmd.Flags(), buildEnvMap(os.Environ()))
-			if err != nil {
-				return err
-			}
-
 			var (
 				opts lib.Options
 				b    *js.Bundle
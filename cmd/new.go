@@ -0,0 +1,117 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/loadimpact/k6/scaffold"
+)
+
+func getNewCmd() *cobra.Command {
+	var (
+		templateName string
+		templateDirs []string
+		url          string
+		force        bool
+		list         bool
+	)
+
+	newCmd := &cobra.Command{
+		Use:   "new [file]",
+		Short: "Create a new script or project from a template",
+		Long: `Create a new script or project from a template.
+
+The built-in templates can be extended or replaced with custom templates from
+directories or .tar, .tar.gz, .tgz and .zip archives, given with --templates or
+the K6_TEMPLATES environment variable. Every .js file in them is a script
+template, and every subdirectory is a project template. Templates use the Go
+text/template syntax, with {{.Name}}, {{.ScriptName}} and {{.URL}} available.`,
+		Example: `
+  # Create script.js from the basic template.
+  k6 new
+
+  # Create a script with several scenarios, testing the given URL.
+  k6 new --template scenarios --url https://example.com load-test.js
+
+  # Create a project directory from a team template.
+  k6 new --templates ./k6-templates.tar.gz --template api my-service
+
+  # List the available templates.
+  k6 new --list`[1:],
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registry := scaffold.NewRegistry()
+			dirs := templateDirs
+			if envDirs := buildEnvMap(os.Environ())["K6_TEMPLATES"]; envDirs != "" {
+				dirs = append(filepath.SplitList(envDirs), dirs...)
+			}
+			for _, dir := range dirs {
+				if err := registry.Load(defaultFs, dir); err != nil {
+					return fmt.Errorf("couldn't load the templates in %s: %w", dir, err)
+				}
+			}
+
+			if list {
+				w := tabwriter.NewWriter(defaultWriter, 0, 0, 2, ' ', 0)
+				for _, t := range registry.List() {
+					if _, err := fmt.Fprintf(w, "%s\t%s\n", t.Name, t.Description); err != nil {
+						return err
+					}
+				}
+				return w.Flush()
+			}
+
+			t, err := registry.Get(templateName)
+			if err != nil {
+				return err
+			}
+			target := t.DefaultTarget()
+			if len(args) > 0 {
+				target = args[0]
+			}
+			paths, err := t.Generate(defaultFs, target, t.NewData(target, url), force)
+			if err != nil {
+				return err
+			}
+			for _, path := range paths {
+				if _, err := fmt.Fprintf(defaultWriter, "Created %s\n", path); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+
+	newCmd.Flags().SortFlags = false
+	newCmd.Flags().StringVarP(&templateName, "template", "t", "basic", "the `name` of the template")
+	newCmd.Flags().StringArrayVar(&templateDirs, "templates", nil,
+		"a directory or an archive with custom templates, can be used more than once")
+	newCmd.Flags().StringVar(&url, "url", "", "the URL of the system under test (default depends on the template)")
+	newCmd.Flags().BoolVarP(&force, "force", "f", false, "overwrite existing files")
+	newCmd.Flags().BoolVar(&list, "list", false, "list the available templates and exit")
+	return newCmd
+}
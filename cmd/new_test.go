@@ -0,0 +1,116 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loadimpact/k6/js"
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/testutils"
+	"github.com/loadimpact/k6/loader"
+	"github.com/loadimpact/k6/scaffold"
+)
+
+func TestNewCmd(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(testutils.NewTestOutput(t))
+
+	oldFs, oldWriter := defaultFs, defaultWriter
+	defer func() { defaultFs, defaultWriter = oldFs, oldWriter }()
+	defaultFs = afero.NewMemMapFs()
+	buf := &bytes.Buffer{}
+	defaultWriter = buf
+
+	runNew := func(args []string, flags map[string]string) error {
+		buf.Reset()
+		newCmd := getNewCmd()
+		for name, value := range flags {
+			require.NoError(t, newCmd.Flags().Set(name, value))
+		}
+		return newCmd.RunE(newCmd, args)
+	}
+
+	// all the built-in templates should generate valid scripts
+	for _, tmpl := range scaffold.NewRegistry().List() {
+		tmpl := tmpl
+		t.Run(tmpl.Name, func(t *testing.T) {
+			target := filepath.Join("/builtin", tmpl.Name, tmpl.DefaultTarget())
+			require.NoError(t, runNew([]string{target}, map[string]string{"template": tmpl.Name}))
+			script := target
+			if tmpl.IsProject() {
+				script = filepath.Join(target, "script.js")
+			}
+			assert.Contains(t, buf.String(), "Created "+script+"\n")
+
+			filesystems := map[string]afero.Fs{"file": defaultFs, "https": afero.NewMemMapFs()}
+			src, err := loader.ReadSource(logger, script, "/", filesystems, nil)
+			require.NoError(t, err)
+			b, err := js.NewBundle(logger, src, filesystems, lib.RuntimeOptions{})
+			require.NoError(t, err)
+			assert.NotEmpty(t, b.Options.Thresholds)
+		})
+	}
+
+	t.Run("Existing", func(t *testing.T) {
+		require.NoError(t, runNew([]string{"/existing.js"}, nil))
+		err := runNew([]string{"/existing.js"}, map[string]string{"url": "https://example.com"})
+		assert.EqualError(t, err, "/existing.js already exists, use --force to overwrite it")
+		require.NoError(t, runNew([]string{"/existing.js"}, map[string]string{"url": "https://example.com", "force": "true"}))
+		data, err := afero.ReadFile(defaultFs, "/existing.js")
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `http.get("https://example.com")`)
+		assert.Contains(t, string(data), `"existing-summary.json"`)
+	})
+
+	t.Run("CustomTemplates", func(t *testing.T) {
+		require.NoError(t, afero.WriteFile(defaultFs, "/templates/smoke.js",
+			[]byte(`export default function () { console.log("{{.Name}} {{.URL}}"); }`), 0o644))
+		require.NoError(t, afero.WriteFile(defaultFs, "/templates/service/main.js",
+			[]byte(`import "./lib/{{.Name}}.js";`), 0o644))
+
+		require.NoError(t, runNew(nil, map[string]string{"templates": "/templates", "list": "true"}))
+		assert.Contains(t, buf.String(), "basic ")
+		assert.Contains(t, buf.String(), "smoke ")
+		assert.Contains(t, buf.String(), "custom project from /templates/service\n")
+
+		require.NoError(t, runNew([]string{"/custom/smoke.js"}, map[string]string{"templates": "/templates", "template": "smoke"}))
+		data, err := afero.ReadFile(defaultFs, "/custom/smoke.js")
+		require.NoError(t, err)
+		assert.Equal(t, `export default function () { console.log("smoke https://test.k6.io"); }`, string(data))
+
+		require.NoError(t, runNew(nil, map[string]string{"templates": "/templates", "template": "service"}))
+		assert.Equal(t, "Created service/main.js\n", buf.String())
+		data, err = afero.ReadFile(defaultFs, "service/main.js")
+		require.NoError(t, err)
+		assert.Equal(t, `import "./lib/service.js";`, string(data))
+
+		err = runNew(nil, map[string]string{"template": "smoke"})
+		assert.EqualError(t, err, `unknown template "smoke", available templates are: basic, browser, grpc, scenarios, websocket`)
+	})
+}
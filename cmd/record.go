@@ -0,0 +1,251 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/loadimpact/k6/converter/har"
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/recorder"
+)
+
+// the validity of the CA generated by k6 record
+const recordCAValidity = 10 * 365 * 24 * time.Hour
+
+//nolint:funlen
+func getRecordCmd(ctx context.Context, logger *logrus.Logger) *cobra.Command {
+	var (
+		listenAddress string
+		caCertPath    string
+		caKeyPath     string
+		harOutput     string
+		pageIdleTime  time.Duration
+		insecure      bool
+	)
+
+	recordCmd := &cobra.Command{
+		Use:   "record",
+		Short: "Record HTTP traffic through a proxy to a k6 script or a HAR file",
+		Long: `Record HTTP traffic through a proxy to a k6 script or a HAR file.
+
+A local HTTP and HTTPS forward proxy is started, which records the requests of
+the browsers, mobile apps or any other clients that are configured to use it.
+The TLS connections are intercepted with certificates issued by a CA that's
+generated the first time, so its certificate has to be trusted by the clients.
+The certificate can be downloaded from http://<proxy address>/k6-ca.pem.
+
+When the recording is stopped with Ctrl+C, the requests are converted to a
+script like with "k6 convert", with a group for each page. A new page starts
+when no requests are made for --page-idle-time. The recording can also be
+saved as a HAR file with --har, and it's always saved as one if it can't be
+converted, so it can be converted again with "k6 convert".`,
+		Example: `
+  # Record to a k6 script, using the proxy at localhost:6580.
+  k6 record -O session.js
+
+  # Record the traffic of a mobile device in the same network.
+  k6 record --listen 0.0.0.0:6580 --correlate --no-batch -O app.js
+
+  # Record only the requests to the given domains, and save a HAR file too.
+  k6 record --only api.example.com --har session.har -O session.js`[1:],
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// the flags are checked before recording, so that no recording is lost
+			if err := validateHARConvertFlags(); err != nil {
+				return err
+			}
+			injectedOptions, err := readInjectedOptions()
+			if err != nil {
+				return err
+			}
+			ca, err := loadRecordCA(caCertPath, caKeyPath, logger)
+			if err != nil {
+				return err
+			}
+
+			rec := recorder.New(ca, logger)
+			if insecure {
+				rec.Transport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+			}
+			listener, err := net.Listen("tcp", listenAddress)
+			if err != nil {
+				return err
+			}
+			srv := &http.Server{Handler: rec} //nolint:gosec
+			go func() {
+				if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					logger.WithError(err).Error("The recording proxy failed")
+				}
+			}()
+			logger.Infof("Recording through the proxy at %s, stop the recording with Ctrl+C", listener.Addr())
+
+			recordCtx, recordCancel := context.WithCancel(ctx)
+			defer recordCancel()
+			sigC := make(chan os.Signal, 1)
+			signal.Notify(sigC, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+			defer signal.Stop(sigC)
+			go func() {
+				select {
+				case sig := <-sigC:
+					logger.WithField("sig", sig).Debug("Stopping the recording in response to signal...")
+					recordCancel()
+				case <-recordCtx.Done():
+				}
+			}()
+			<-recordCtx.Done()
+
+			shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer shutdownCancel()
+			if err := srv.Shutdown(shutdownCtx); err != nil {
+				logger.WithError(err).Debug("The recording proxy didn't stop gracefully")
+			}
+
+			h := rec.HAR(pageIdleTime)
+			if len(h.Log.Entries) == 0 {
+				return errors.New("no requests were recorded")
+			}
+			logger.Infof("Recorded %d requests in %d pages", len(h.Log.Entries), len(h.Log.Pages))
+			return saveRecording(h, harOutput, injectedOptions)
+		},
+	}
+
+	caDir := filepath.Dir(defaultConfigFilePath)
+	recordCmd.Flags().SortFlags = false
+	recordCmd.Flags().StringVar(&listenAddress, "listen", "localhost:6580", "the `address` of the proxy")
+	recordCmd.Flags().StringVar(&caCertPath, "ca-cert", filepath.Join(caDir, "record-ca.pem"),
+		"the certificate of the CA of the TLS interception, generated if it doesn't exist")
+	recordCmd.Flags().StringVar(&caKeyPath, "ca-key", filepath.Join(caDir, "record-ca-key.pem"),
+		"the private key of the CA of the TLS interception, generated if it doesn't exist")
+	recordCmd.Flags().BoolVar(&insecure, "insecure-skip-tls-verify", false,
+		"don't verify the certificates of the recorded servers")
+	recordCmd.Flags().DurationVar(&pageIdleTime, "page-idle-time", 5*time.Second,
+		"the time without requests that starts a new page, 0 to record a single page")
+	recordCmd.Flags().StringVar(&harOutput, "har", "",
+		"HAR output filename, the script is only written if --output is also given")
+	recordCmd.Flags().StringVarP(&convertOutput, "output", "O", convertOutput,
+		"k6 script output filename (stdout by default)")
+	recordCmd.Flags().StringVarP(&optionsFilePath, "options", "", optionsFilePath,
+		"path to a JSON file with options that would be injected in the output script")
+	recordCmd.Flags().StringSliceVarP(&only, "only", "", []string{}, "include only requests from the given domains")
+	recordCmd.Flags().StringSliceVarP(&skip, "skip", "", []string{}, "skip requests from the given domains")
+	recordCmd.Flags().UintVarP(&threshold, "batch-threshold", "", 500, "batch request idle time threshold")
+	recordCmd.Flags().BoolVarP(&nobatch, "no-batch", "", false, "don't generate batch calls")
+	recordCmd.Flags().BoolVarP(&enableChecks, "enable-status-code-checks", "", false, "add a status code check for each HTTP response")
+	recordCmd.Flags().BoolVarP(&returnOnFailedCheck, "return-on-failed-check", "", false, "return from iteration if we get an unexpected response status code")
+	recordCmd.Flags().BoolVarP(&correlate, "correlate", "", false, "detect values in responses being used in subsequent requests and try adapt the script accordingly")
+	recordCmd.Flags().UintVarP(&minSleep, "min-sleep", "", 20, "the minimum amount of seconds to sleep after each iteration")
+	recordCmd.Flags().UintVarP(&maxSleep, "max-sleep", "", 40, "the maximum amount of seconds to sleep after each iteration")
+	return recordCmd
+}
+
+// loadRecordCA loads the CA of k6 record, which is generated the first time
+func loadRecordCA(certPath, keyPath string, logger logrus.FieldLogger) (*recorder.CA, error) {
+	certPEM, err := afero.ReadFile(defaultFs, certPath)
+	if os.IsNotExist(err) {
+		var keyPEM []byte
+		if certPEM, keyPEM, err = recorder.GenerateCA("k6 record CA", recordCAValidity); err != nil {
+			return nil, err
+		}
+		if err = defaultFs.MkdirAll(filepath.Dir(certPath), 0o755); err != nil {
+			return nil, err
+		}
+		if err = defaultFs.MkdirAll(filepath.Dir(keyPath), 0o700); err != nil {
+			return nil, err
+		}
+		if err = afero.WriteFile(defaultFs, keyPath, keyPEM, 0o600); err != nil {
+			return nil, err
+		}
+		if err = afero.WriteFile(defaultFs, certPath, certPEM, 0o644); err != nil {
+			return nil, err
+		}
+		logger.Warnf("Generated a new CA at %s, which has to be trusted by the recorded clients", certPath)
+		return recorder.LoadCA(certPEM, keyPEM)
+	}
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := afero.ReadFile(defaultFs, keyPath)
+	if err != nil {
+		return nil, err
+	}
+	return recorder.LoadCA(certPEM, keyPEM)
+}
+
+// validateHARConvertFlags returns an error for the combinations of the flags
+// of k6 convert that the conversion of a HAR file doesn't support
+func validateHARConvertFlags() error {
+	if returnOnFailedCheck && !enableChecks {
+		return errors.New("return on failed check requires --enable-status-code-checks")
+	}
+	if correlate && !nobatch {
+		return errors.New("correlation requires --no-batch")
+	}
+	return nil
+}
+
+// saveRecording writes the recording to the HAR file, if there's one, and
+// converts it to a script, unless only the HAR file was asked for. If the
+// conversion fails, the recording is still saved in a HAR file.
+func saveRecording(h har.HAR, harOutput string, injectedOptions *lib.Options) error {
+	if harOutput != "" {
+		if err := writeHAR(h, harOutput); err != nil {
+			return err
+		}
+		if convertOutput == "" {
+			return nil
+		}
+	}
+	script, err := convertHARLog(h, injectedOptions)
+	if err != nil {
+		if harOutput == "" {
+			harOutput = fmt.Sprintf("k6-record-%s.har", time.Now().Format("20060102-150405"))
+			if writeErr := writeHAR(h, harOutput); writeErr != nil {
+				return fmt.Errorf("couldn't convert the recording (%v), nor save it: %w", err, writeErr)
+			}
+		}
+		return fmt.Errorf("couldn't convert the recording, it was saved in %s: %w", harOutput, err)
+	}
+	return writeScript(script)
+}
+
+func writeHAR(h har.HAR, path string) error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return afero.WriteFile(defaultFs, path, append(data, '\n'), 0o644)
+}
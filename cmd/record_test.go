@@ -0,0 +1,174 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loadimpact/k6/converter/har"
+	"github.com/loadimpact/k6/lib/testutils"
+)
+
+func TestRecordCmd(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(testutils.NewTestOutput(t))
+
+	oldFs, oldOutput := defaultFs, convertOutput
+	defer func() { defaultFs, convertOutput = oldFs, oldOutput }()
+	defaultFs = afero.NewMemMapFs()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = fmt.Fprintf(w, "path %s", req.URL.Path)
+	}))
+	defer srv.Close()
+
+	// the address of the proxy, which has to be known before it's started
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	proxyAddress := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	recordCmd := getRecordCmd(ctx, logger)
+	for name, value := range map[string]string{
+		"listen":  proxyAddress,
+		"ca-cert": "/k6/ca.pem",
+		"ca-key":  "/k6/ca-key.pem",
+		"har":     "/session.har",
+		"output":  "/session.js",
+	} {
+		require.NoError(t, recordCmd.Flags().Set(name, value))
+	}
+	result := make(chan error, 1)
+	go func() { result <- recordCmd.RunE(recordCmd, nil) }()
+
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(&url.URL{Host: proxyAddress})}}
+	var res *http.Response
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		if res, err = client.Get(srv.URL + "/recorded"); err == nil { //nolint:bodyclose
+			break
+		}
+	}
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	assert.Equal(t, "path /recorded", string(body))
+
+	cancel()
+	require.NoError(t, <-result)
+
+	for _, path := range []string{"/k6/ca.pem", "/k6/ca-key.pem"} {
+		exists, err := afero.Exists(defaultFs, path)
+		require.NoError(t, err)
+		assert.True(t, exists, path)
+	}
+	harFile, err := defaultFs.Open("/session.har")
+	require.NoError(t, err)
+	h, err := har.Decode(harFile)
+	require.NoError(t, err)
+	require.Len(t, h.Log.Entries, 1)
+	assert.Equal(t, srv.URL+"/recorded", h.Log.Entries[0].Request.URL)
+	script, err := afero.ReadFile(defaultFs, "/session.js")
+	require.NoError(t, err)
+	assert.Contains(t, string(script), fmt.Sprintf("%q", srv.URL+"/recorded"))
+}
+
+func TestRecordCmdInvalidFlags(t *testing.T) {
+	oldFs := defaultFs
+	defer func() { defaultFs = oldFs }()
+	defaultFs = afero.NewMemMapFs()
+
+	recordCmd := getRecordCmd(context.Background(), logrus.New())
+	require.NoError(t, recordCmd.Flags().Set("ca-cert", "/k6/ca.pem"))
+	require.NoError(t, recordCmd.Flags().Set("correlate", "true"))
+	defer func() { correlate = false }()
+
+	// the flags are rejected before anything is recorded
+	err := recordCmd.RunE(recordCmd, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "correlation requires --no-batch")
+	exists, err := afero.Exists(defaultFs, "/k6/ca.pem")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestSaveRecording(t *testing.T) {
+	oldFs, oldOutput := defaultFs, convertOutput
+	defer func() { defaultFs, convertOutput = oldFs, oldOutput }()
+	defaultFs = afero.NewMemMapFs()
+	convertOutput = "/session.js"
+
+	h := har.HAR{Log: &har.Log{
+		Creator: &har.Creator{Name: "k6"},
+		Entries: []*har.Entry{{
+			Request:  &har.Request{Method: "GET", URL: "https://example.com/"},
+			Response: &har.Response{Status: 200, Content: &har.Content{}},
+		}},
+	}}
+
+	t.Run("ConversionFailed", func(t *testing.T) {
+		// a conversion error, which the flags are usually checked for
+		correlate = true
+		defer func() { correlate = false }()
+
+		err := saveRecording(h, "", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "correlation requires --no-batch")
+		assert.Contains(t, err.Error(), "it was saved in k6-record-")
+
+		files, err := afero.Glob(defaultFs, "k6-record-*.har")
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		harFile, err := defaultFs.Open(files[0])
+		require.NoError(t, err)
+		saved, err := har.Decode(harFile)
+		require.NoError(t, err)
+		require.Len(t, saved.Log.Entries, 1)
+		assert.Equal(t, "https://example.com/", saved.Log.Entries[0].Request.URL)
+	})
+
+	t.Run("ConversionFailedWithHAR", func(t *testing.T) {
+		correlate = true
+		defer func() { correlate = false }()
+
+		err := saveRecording(h, "/session.har", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "it was saved in /session.har")
+		exists, err := afero.Exists(defaultFs, "/session.har")
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+}
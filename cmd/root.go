@@ -181,17 +181,23 @@ func Execute() {
 	loginCmd := getLoginCmd()
 	loginCmd.AddCommand(getLoginCloudCommand(logger), getLoginInfluxDBCommand(logger))
 	c.cmd.AddCommand(
+		getAgentCmd(ctx, logger),
 		getArchiveCmd(logger),
 		getCloudCmd(ctx, logger),
 		getConvertCmd(),
+		getCoordinatorCmd(ctx, logger),
 		getInspectCmd(logger),
 		loginCmd,
+		getNewCmd(),
+		getRecordCmd(ctx, logger),
 		getPauseCmd(ctx),
 		getResumeCmd(ctx),
 		getScaleCmd(ctx),
+		getScenariosCmd(ctx),
 		getRunCmd(ctx, logger),
 		getStatsCmd(ctx),
 		getStatusCmd(ctx),
+		getVendorCmd(logger),
 		getVersionCmd(),
 	)
 
@@ -55,17 +55,19 @@ const (
 	typeJS      = "js"
 	typeArchive = "archive"
 
-	thresholdHaveFailedErrorCode = 99
-	setupTimeoutErrorCode        = 100
-	teardownTimeoutErrorCode     = 101
-	genericTimeoutErrorCode      = 102
-	genericEngineErrorCode       = 103
-	invalidConfigErrorCode       = 104
-	externalAbortErrorCode       = 105
-	cannotStartRESTAPIErrorCode  = 106
+	thresholdHaveFailedErrorCode    = 99
+	setupTimeoutErrorCode           = 100
+	teardownTimeoutErrorCode        = 101
+	genericTimeoutErrorCode         = 102
+	genericEngineErrorCode          = 103
+	invalidConfigErrorCode          = 104
+	externalAbortErrorCode          = 105
+	cannotStartRESTAPIErrorCode     = 106
+	cannotStartCoordinatorErrorCode = 107
 )
 
 // TODO: fix this, global variables are not very testable...
+//
 //nolint:gochecknoglobals
 var runType = os.Getenv("K6_TYPE")
 
@@ -110,14 +112,17 @@ a commandline interface for interacting with it.`,
 				return err
 			}
 			filename := args[0]
-			filesystems := loader.CreateFilesystems()
-			src, err := loader.ReadSource(logger, filename, pwd, filesystems, os.Stdin)
+			osEnvironment := buildEnvMap(os.Environ())
+			runtimeOptions, err := getRuntimeOptions(cmd.Flags(), osEnvironment)
 			if err != nil {
 				return err
 			}
 
-			osEnvironment := buildEnvMap(os.Environ())
-			runtimeOptions, err := getRuntimeOptions(cmd.Flags(), osEnvironment)
+			filesystems, err := createFilesystems(runtimeOptions, false)
+			if err != nil {
+				return err
+			}
+			src, err := loader.ReadSource(logger, filename, pwd, filesystems, os.Stdin)
 			if err != nil {
 				return err
 			}
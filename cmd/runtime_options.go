@@ -22,6 +22,7 @@ package cmd
 
 import (
 	"fmt"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -66,6 +67,11 @@ extended: base + Babel with ES2015 preset + core.js v2,
           slower and memory consuming but with greater JS support
 `)
 	flags.StringArrayP("env", "e", nil, "add/override environment variable with `VAR=value`")
+	flags.String("import-map", "", "`path` or URL of an import map for resolving bare module specifiers")
+	flags.Bool("node-modules", false, "resolve bare module specifiers from node_modules directories")
+	flags.Bool("offline", false, "load remote modules only from the module cache, without network access")
+	flags.String("lockfile", "", "`path` of the lockfile with the integrity hashes of remote modules (default \""+defaultLockfile+"\")")
+	flags.String("module-cache", "", "`directory` of the remote module cache (default is k6/modules in the user cache directory)")
 	flags.Bool("no-thresholds", false, "don't run thresholds")
 	flags.Bool("no-summary", false, "don't show the summary at the end of the test")
 	flags.String(
@@ -101,6 +107,11 @@ func getRuntimeOptions(flags *pflag.FlagSet, environment map[string]string) (lib
 		NoThresholds:         getNullBool(flags, "no-thresholds"),
 		NoSummary:            getNullBool(flags, "no-summary"),
 		SummaryExport:        getNullString(flags, "summary-export"),
+		ImportMap:            getNullString(flags, "import-map"),
+		NodeModules:          getNullBool(flags, "node-modules"),
+		Offline:              getNullBool(flags, "offline"),
+		Lockfile:             getNullString(flags, "lockfile"),
+		ModuleCache:          getNullString(flags, "module-cache"),
 		Env:                  make(map[string]string),
 	}
 
@@ -131,6 +142,37 @@ func getRuntimeOptions(flags *pflag.FlagSet, environment map[string]string) (lib
 		}
 	}
 
+	if envVar, ok := environment["K6_IMPORT_MAP"]; ok {
+		if !opts.ImportMap.Valid {
+			opts.ImportMap = null.StringFrom(envVar)
+		}
+	}
+	if opts.ImportMap.String != "" && !strings.Contains(opts.ImportMap.String, "://") {
+		// local import maps are relative to the current directory, not to the script
+		importMap, err := filepath.Abs(opts.ImportMap.String)
+		if err != nil {
+			return opts, err
+		}
+		opts.ImportMap = null.StringFrom(importMap)
+	}
+	if err := saveBoolFromEnv(environment, "K6_NODE_MODULES", &opts.NodeModules); err != nil {
+		return opts, err
+	}
+
+	if err := saveBoolFromEnv(environment, "K6_OFFLINE", &opts.Offline); err != nil {
+		return opts, err
+	}
+	if envVar, ok := environment["K6_LOCKFILE"]; ok {
+		if !opts.Lockfile.Valid {
+			opts.Lockfile = null.StringFrom(envVar)
+		}
+	}
+	if envVar, ok := environment["K6_MODULE_CACHE"]; ok {
+		if !opts.ModuleCache.Valid {
+			opts.ModuleCache = null.StringFrom(envVar)
+		}
+	}
+
 	if opts.IncludeSystemEnvVars.Bool { // If enabled, gather the actual system environment variables
 		opts.Env = environment
 	}
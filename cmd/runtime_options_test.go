@@ -24,6 +24,8 @@ import (
 	"bytes"
 	"fmt"
 	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/spf13/afero"
@@ -273,6 +275,61 @@ var runtimeOptionsTestCases = map[string]runtimeOptionsTestCase{ //nolint:gochec
 			SummaryExport:        null.NewString("bar", true),
 		},
 	},
+	"node modules from env": {
+		useSysEnv: false,
+		systemEnv: map[string]string{"K6_NODE_MODULES": "true"},
+		expRTOpts: lib.RuntimeOptions{
+			IncludeSystemEnvVars: null.NewBool(false, false),
+			CompatibilityMode:    defaultCompatMode,
+			Env:                  map[string]string{},
+			NodeModules:          null.NewBool(true, true),
+		},
+	},
+	"node modules from env overwritten by CLI": {
+		useSysEnv: false,
+		systemEnv: map[string]string{"K6_NODE_MODULES": "true"},
+		cliFlags:  []string{"--node-modules=false"},
+		expRTOpts: lib.RuntimeOptions{
+			IncludeSystemEnvVars: null.NewBool(false, false),
+			CompatibilityMode:    defaultCompatMode,
+			Env:                  map[string]string{},
+			NodeModules:          null.NewBool(false, true),
+		},
+	},
+	"node modules env var error": {
+		useSysEnv: false,
+		systemEnv: map[string]string{"K6_NODE_MODULES": "yes please"},
+		expErr:    true,
+	},
+	"offline from env": {
+		useSysEnv: false,
+		systemEnv: map[string]string{"K6_OFFLINE": "true", "K6_LOCKFILE": "modules.lock"},
+		expRTOpts: lib.RuntimeOptions{
+			IncludeSystemEnvVars: null.NewBool(false, false),
+			CompatibilityMode:    defaultCompatMode,
+			Env:                  map[string]string{},
+			Offline:              null.NewBool(true, true),
+			Lockfile:             null.NewString("modules.lock", true),
+		},
+	},
+	"offline from env overwritten by CLI": {
+		useSysEnv: false,
+		systemEnv: map[string]string{"K6_OFFLINE": "true", "K6_MODULE_CACHE": "/tmp/env-cache"},
+		cliFlags:  []string{"--offline=false", "--module-cache", "/tmp/cli-cache", "--lockfile", ""},
+		expRTOpts: lib.RuntimeOptions{
+			IncludeSystemEnvVars: null.NewBool(false, false),
+			CompatibilityMode:    defaultCompatMode,
+			Env:                  map[string]string{},
+			Offline:              null.NewBool(false, true),
+			Lockfile:             null.NewString("", true),
+			ModuleCache:          null.NewString("/tmp/cli-cache", true),
+		},
+	},
+	"offline env var error": {
+		useSysEnv: false,
+		systemEnv: map[string]string{"K6_OFFLINE": "no network"},
+		expErr:    true,
+	},
 	"env var error detected even when CLI flags overwrite 1": {
 		useSysEnv: false,
 		systemEnv: map[string]string{"K6_NO_THRESHOLDS": "boo"},
@@ -363,3 +420,31 @@ func TestRuntimeOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestRuntimeOptionsImportMap(t *testing.T) {
+	t.Parallel()
+	pwd, err := os.Getwd()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		cliFlags  []string
+		systemEnv map[string]string
+		expected  string
+	}{
+		{nil, nil, ""},
+		{[]string{"--import-map", "importmap.json"}, nil, filepath.Join(pwd, "importmap.json")},
+		{nil, map[string]string{"K6_IMPORT_MAP": "maps/importmap.json"}, filepath.Join(pwd, "maps", "importmap.json")},
+		{
+			[]string{"--import-map", "https://example.com/importmap.json"},
+			map[string]string{"K6_IMPORT_MAP": "importmap.json"},
+			"https://example.com/importmap.json",
+		},
+	}
+	for _, tc := range testCases {
+		flags := runtimeOptionFlagSet(false)
+		require.NoError(t, flags.Parse(tc.cliFlags))
+		rtOpts, err := getRuntimeOptions(flags, tc.systemEnv)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, rtOpts.ImportMap.String)
+	}
+}
@@ -0,0 +1,159 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	v1 "github.com/loadimpact/k6/api/v1"
+	"github.com/loadimpact/k6/api/v1/client"
+	"github.com/loadimpact/k6/ui"
+)
+
+func getScenariosCmd(ctx context.Context) *cobra.Command {
+	// scenariosCmd represents the scenarios command
+	scenariosCmd := &cobra.Command{
+		Use:   "scenarios [name]",
+		Short: "Show the scenarios of a running test",
+		Long: `Show the scenarios of a running test, or a single one if a name is specified.
+
+  Use the global --address flag to specify the URL to the API server.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := client.New(address)
+			if err != nil {
+				return err
+			}
+			if len(args) == 1 {
+				scenario, err := c.Scenario(ctx, args[0])
+				if err != nil {
+					return err
+				}
+				ui.Dump(stdout, scenario)
+				return nil
+			}
+			scenarios, err := c.Scenarios(ctx)
+			if err != nil {
+				return err
+			}
+			ui.Dump(stdout, scenarios)
+			return nil
+		},
+	}
+
+	scenariosCmd.AddCommand(getScenariosStopCmd(ctx), getScenariosUpdateCmd(ctx))
+	return scenariosCmd
+}
+
+func getScenariosStopCmd(ctx context.Context) *cobra.Command {
+	return &cobra.Command{
+		Use:   "stop name",
+		Short: "Stop a single scenario of a running test",
+		Long: `Stop a single scenario of a running test, interrupting any of its running iterations.
+
+  Use the global --address flag to specify the URL to the API server.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := client.New(address)
+			if err != nil {
+				return err
+			}
+			scenario, err := c.SetScenario(ctx, v1.Scenario{Name: args[0], Stopped: true})
+			if err != nil {
+				return err
+			}
+			ui.Dump(stdout, scenario)
+			return nil
+		},
+	}
+}
+
+func getScenariosUpdateCmd(ctx context.Context) *cobra.Command {
+	updateCmd := &cobra.Command{
+		Use:   "update name",
+		Short: "Change the config of a single scenario of a running test",
+		Long: `Change the config of a single scenario of a running test.
+
+  Which options can be changed depends on the scenario executor. Values are
+  parsed as JSON, or used as plain strings if they aren't valid JSON.
+
+  Use the global --address flag to specify the URL to the API server.`,
+		Example: `
+  # Extend the duration of a constant-arrival-rate scenario
+  k6 scenarios update my_scenario --set duration=10m
+
+  # Add a new stage to a ramping-arrival-rate scenario
+  k6 scenarios update my_scenario --set 'stages=[{"duration":"1m","target":100},{"duration":"5m","target":200}]'
+
+  # Extend the last stage of a ramping-vus scenario and change its gracefulRampDown
+  k6 scenarios update my_scenario --set 'stages=[{"duration":"1m","target":10},{"duration":"10m","target":10}]' \
+    --set gracefulRampDown=1m`[1:],
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options, err := cmd.Flags().GetStringArray("set")
+			if err != nil {
+				return err
+			}
+			config, err := parseScenarioOptions(options)
+			if err != nil {
+				return err
+			}
+
+			c, err := client.New(address)
+			if err != nil {
+				return err
+			}
+			scenario, err := c.SetScenario(ctx, v1.Scenario{Name: args[0], Config: config})
+			if err != nil {
+				return err
+			}
+			ui.Dump(stdout, scenario)
+			return nil
+		},
+	}
+
+	updateCmd.Flags().StringArray("set", nil, "change a scenario `option=value`, can be used multiple times")
+	return updateCmd
+}
+
+func parseScenarioOptions(options []string) (map[string]interface{}, error) {
+	if len(options) == 0 {
+		return nil, fmt.Errorf("specify at least one option to change with --set")
+	}
+	config := make(map[string]interface{}, len(options))
+	for _, option := range options {
+		kv := strings.SplitN(option, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid option '%s', it should be in the 'option=value' format", option)
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(kv[1]), &value); err != nil {
+			value = kv[1]
+		}
+		config[kv[0]] = value
+	}
+	return config, nil
+}
@@ -0,0 +1,66 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/executor"
+	"github.com/loadimpact/k6/lib/types"
+)
+
+func TestParseScenarioOptions(t *testing.T) {
+	t.Parallel()
+	t.Run("RampingVUs", func(t *testing.T) {
+		t.Parallel()
+		config, err := parseScenarioOptions([]string{
+			`stages=[{"duration":"1m","target":10},{"duration":"5m","target":20}]`,
+			"gracefulRampDown=10s",
+		})
+		require.NoError(t, err)
+		rawJSON, err := json.Marshal(config)
+		require.NoError(t, err)
+
+		parsed, err := lib.GetParsedExecutorConfig("test", "ramping-vus", rawJSON)
+		require.NoError(t, err)
+		rampingVUsConfig, ok := parsed.(executor.RampingVUsConfig)
+		require.True(t, ok)
+		assert.Equal(t, types.NullDurationFrom(10*time.Second), rampingVUsConfig.GracefulRampDown)
+		assert.Equal(t, []executor.Stage{
+			{Duration: types.NullDurationFrom(time.Minute), Target: null.IntFrom(10)},
+			{Duration: types.NullDurationFrom(5 * time.Minute), Target: null.IntFrom(20)},
+		}, rampingVUsConfig.Stages)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		t.Parallel()
+		for _, options := range [][]string{nil, {"duration"}, {"=10s"}} {
+			_, err := parseScenarioOptions(options)
+			assert.Error(t, err, options)
+		}
+	})
+}
@@ -0,0 +1,163 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/loadimpact/k6/js"
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/loader"
+)
+
+const defaultLockfile = "k6.lock"
+
+func getVendorCmd(logger *logrus.Logger) *cobra.Command {
+	vendorCmd := &cobra.Command{
+		Use:   "vendor",
+		Short: "Lock and cache the remote modules of a script",
+		Long: `Lock and cache the remote modules of a script.
+
+Fetches all remote modules imported by the script, stores them in the module
+cache and records their integrity hashes in the lockfile. Test runs fail if a
+remote module doesn't match the lockfile, and with --offline they load remote
+modules only from the module cache, without any network access.`,
+		Example: `
+  # Refresh the lockfile and the module cache.
+  k6 vendor script.js
+
+  # Run the script without fetching remote modules over the network.
+  k6 run --offline script.js`[1:],
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			runtimeOptions, err := getRuntimeOptions(cmd.Flags(), buildEnvMap(os.Environ()))
+			if err != nil {
+				return err
+			}
+			if runtimeOptions.Offline.Bool {
+				return errors.New("remote modules can't be vendored in offline mode")
+			}
+			lockfilePath := getLockfilePath(runtimeOptions)
+			if lockfilePath == "" {
+				return errors.New("a lockfile path is required")
+			}
+
+			pwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			filesystems, err := createFilesystems(runtimeOptions, true)
+			if err != nil {
+				return err
+			}
+			src, err := loader.ReadSource(logger, args[0], pwd, filesystems, os.Stdin)
+			if err != nil {
+				return err
+			}
+			if detectType(src.Data) != typeJS {
+				return errors.New("only scripts can be vendored, archives already include their remote modules")
+			}
+			// Running the init context of the script loads all of its modules
+			if _, err = js.NewBundle(logger, src, filesystems, runtimeOptions); err != nil {
+				return err
+			}
+
+			lockfile := filesystems["https"].(*loader.RemoteFs).Lockfile
+			if err = lockfile.Write(defaultFs, lockfilePath); err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(defaultWriter, "Locked %d remote modules in %s\n", len(lockfile.Modules), lockfilePath)
+			return err
+		},
+	}
+
+	vendorCmd.Flags().SortFlags = false
+	vendorCmd.Flags().AddFlagSet(runtimeOptionFlagSet(false))
+
+	return vendorCmd
+}
+
+// getLockfilePath returns the path of the lockfile, which is empty if it was
+// explicitly disabled.
+func getLockfilePath(rtOpts lib.RuntimeOptions) string {
+	if !rtOpts.Lockfile.Valid {
+		return defaultLockfile
+	}
+	return rtOpts.Lockfile.String
+}
+
+// createFilesystems returns the filesystems for loading the scripts, with the
+// lockfile, the module cache and the offline mode set up for remote modules.
+// When refresh is true, all remote modules are fetched again and recorded in
+// a new lockfile. The module cache is only used with a lockfile, in the offline
+// mode or when it's set explicitly, so other runs don't write to the disk.
+func createFilesystems(rtOpts lib.RuntimeOptions, refresh bool) (map[string]afero.Fs, error) {
+	filesystems := loader.CreateFilesystems()
+	remoteFs := &loader.RemoteFs{
+		Fs:      filesystems["https"],
+		Offline: rtOpts.Offline.Bool,
+		Refresh: refresh,
+	}
+	filesystems["https"] = remoteFs
+
+	lockfilePath := getLockfilePath(rtOpts)
+	switch {
+	case refresh:
+		remoteFs.Lockfile = loader.NewLockfile()
+	case lockfilePath != "":
+		lockfile, err := loader.ReadLockfile(defaultFs, lockfilePath)
+		switch {
+		case err == nil:
+			remoteFs.Lockfile = lockfile
+		case !errors.Is(err, os.ErrNotExist) || rtOpts.Lockfile.Valid:
+			// only the default lockfile is optional
+			return nil, err
+		}
+	}
+
+	cacheDir := rtOpts.ModuleCache.String
+	if remoteFs.Lockfile == nil && !rtOpts.Offline.Bool && cacheDir == "" {
+		return filesystems, nil
+	}
+	if cacheDir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			if rtOpts.Offline.Bool {
+				return nil, fmt.Errorf("couldn't find the module cache, set it with --module-cache: %w", err)
+			}
+			return filesystems, nil
+		}
+		cacheDir = filepath.Join(userCacheDir, "k6", "modules")
+	}
+	cacheDir, err := filepath.Abs(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	remoteFs.Cache = afero.NewBasePathFs(defaultFs, cacheDir)
+	return filesystems, nil
+}
@@ -0,0 +1,137 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/loadimpact/k6/js"
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/testutils"
+	"github.com/loadimpact/k6/lib/testutils/httpmultibin"
+	"github.com/loadimpact/k6/loader"
+)
+
+func TestVendorCmd(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(testutils.NewTestOutput(t))
+	tb := httpmultibin.NewHTTPMultiBin(t)
+	oldHTTPTransport := http.DefaultTransport
+	http.DefaultTransport = tb.HTTPTransport
+	defer func() {
+		tb.Cleanup()
+		http.DefaultTransport = oldHTTPTransport
+	}()
+
+	moduleSrc := "export const value = 1;"
+	tb.Mux.HandleFunc("/module.js", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(moduleSrc))
+	})
+	moduleURL := tb.Replacer.Replace("HTTPSBIN_URL/module.js")
+
+	dir, err := ioutil.TempDir("", "k6-vendor")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	script := filepath.Join(dir, "script.js")
+	require.NoError(t, ioutil.WriteFile(script, []byte(`
+import { value } from "`+moduleURL+`";
+export default function () { return value; }
+`), 0o644))
+
+	oldFs, oldWriter := defaultFs, defaultWriter
+	defer func() { defaultFs, defaultWriter = oldFs, oldWriter }()
+	defaultFs = afero.NewMemMapFs()
+	buf := &bytes.Buffer{}
+	defaultWriter = buf
+
+	vendorCmd := getVendorCmd(logger)
+	require.NoError(t, vendorCmd.Flags().Set("lockfile", "/k6.lock"))
+	require.NoError(t, vendorCmd.Flags().Set("module-cache", "/cache"))
+	require.NoError(t, vendorCmd.RunE(vendorCmd, []string{script}))
+	assert.Equal(t, "Locked 1 remote modules in /k6.lock\n", buf.String())
+
+	lockfile, err := loader.ReadLockfile(defaultFs, "/k6.lock")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{moduleURL: loader.Integrity([]byte(moduleSrc))}, lockfile.Modules)
+
+	loadScript := func(rtOpts lib.RuntimeOptions) error {
+		rtOpts.Lockfile = null.StringFrom("/k6.lock")
+		rtOpts.ModuleCache = null.StringFrom("/cache")
+		filesystems, err := createFilesystems(rtOpts, false)
+		if err != nil {
+			return err
+		}
+		src, err := loader.ReadSource(logger, script, dir, filesystems, nil)
+		if err != nil {
+			return err
+		}
+		_, err = js.NewBundle(logger, src, filesystems, rtOpts)
+		return err
+	}
+
+	tb.Cleanup() // locked modules should be loaded from the cache, without network access
+	assert.NoError(t, loadScript(lib.RuntimeOptions{}))
+	assert.NoError(t, loadScript(lib.RuntimeOptions{Offline: null.BoolFrom(true)}))
+
+	require.NoError(t, afero.Walk(defaultFs, "/cache", func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		return afero.WriteFile(defaultFs, path, []byte("export const value = 2;"), 0o644)
+	}))
+	err = loadScript(lib.RuntimeOptions{Offline: null.BoolFrom(true)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "isn't in the module cache")
+
+	t.Run("MissingLockfile", func(t *testing.T) {
+		_, err := createFilesystems(lib.RuntimeOptions{Lockfile: null.StringFrom("/missing.lock")}, false)
+		assert.True(t, os.IsNotExist(err))
+
+		filesystems, err := createFilesystems(lib.RuntimeOptions{ModuleCache: null.StringFrom("/cache")}, false)
+		require.NoError(t, err)
+		assert.Nil(t, filesystems["https"].(*loader.RemoteFs).Lockfile)
+		assert.NotNil(t, filesystems["https"].(*loader.RemoteFs).Cache)
+	})
+
+	t.Run("NoModuleCache", func(t *testing.T) {
+		// without a lockfile, the offline mode or an explicit module cache,
+		// remote modules aren't written to the disk
+		filesystems, err := createFilesystems(lib.RuntimeOptions{}, false)
+		require.NoError(t, err)
+		assert.Nil(t, filesystems["https"].(*loader.RemoteFs).Lockfile)
+		assert.Nil(t, filesystems["https"].(*loader.RemoteFs).Cache)
+
+		filesystems, err = createFilesystems(lib.RuntimeOptions{Lockfile: null.StringFrom("/k6.lock")}, false)
+		require.NoError(t, err)
+		assert.NotNil(t, filesystems["https"].(*loader.RemoteFs).Cache)
+	})
+}
@@ -0,0 +1,242 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package curl converts curl command lines to k6 scripts.
+package curl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/loadimpact/k6/converter/internal/printer"
+)
+
+// Options are the options of the conversion. The checks are for successful
+// status codes.
+type Options struct {
+	printer.ScriptOptions
+}
+
+// converter holds the state of a single conversion
+type converter struct {
+	// files are the constants with the files opened in the init context, by their path
+	files     map[string]string
+	fileOrder []string
+}
+
+// file returns the constant with the content of a file, which is opened in the init context
+func (c *converter) file(path string) string {
+	name, ok := c.files[path]
+	if !ok {
+		name = fmt.Sprintf("FILE_%d", len(c.fileOrder))
+		c.files[path] = name
+		c.fileOrder = append(c.fileOrder, path)
+	}
+	return name
+}
+
+// Convert generates a k6 script that makes the requests of the curl commands
+// one after another.
+func Convert(commands []*Command, opts Options) (result string, convertErr error) {
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+
+	c := &converter{files: make(map[string]string)}
+	var iteration bytes.Buffer
+	for i, cmd := range commands {
+		if cmd.Insecure && !opts.Options.InsecureSkipTLSVerify.Valid {
+			opts.Options.InsecureSkipTLSVerify = null.BoolFrom(true)
+		}
+		if i > 0 {
+			printer.Fprint(&iteration, "\n")
+		}
+		if err := c.writeRequest(&iteration, cmd, opts); err != nil {
+			return "", errors.Wrapf(err, "couldn't convert the curl command #%d", i+1)
+		}
+	}
+
+	var b bytes.Buffer
+	w := bufio.NewWriter(&b)
+
+	if opts.EnableChecks {
+		printer.Fprint(w, "import { check, sleep } from 'k6';\n")
+	} else {
+		printer.Fprint(w, "import { sleep } from 'k6';\n")
+	}
+	printer.Fprint(w, "import http from 'k6/http';\n")
+
+	printer.Fprint(w, "\nexport let options = {\n")
+	opts.Options.ForEachSpecified("json", func(key string, val interface{}) {
+		if valJSON, err := json.MarshalIndent(val, "    ", "    "); err != nil {
+			convertErr = err
+		} else {
+			printer.Fprintf(w, "    %s: %s,\n", key, valJSON)
+		}
+	})
+	if convertErr != nil {
+		return "", convertErr
+	}
+	printer.Fprint(w, "};\n")
+
+	if len(c.fileOrder) > 0 {
+		printer.Fprint(w, "\n")
+		for _, path := range c.fileOrder {
+			printer.Fprintf(w, "const %s = open(%q, \"b\");\n", c.files[path], path)
+		}
+	}
+
+	printer.Fprint(w, "\nexport default function() {\n")
+	printer.Fprint(w, "\tlet res;\n\n")
+	printer.Fprint(w, iteration.String())
+	printer.Fprintf(w, "\n\t// Random sleep between %ds and %ds\n", opts.MinSleep, opts.MaxSleep)
+	printer.Fprintf(w, "\tsleep(Math.floor(Math.random()*%d+%d));\n", opts.MaxSleep-opts.MinSleep, opts.MinSleep)
+	printer.Fprint(w, "}\n")
+
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+//nolint:funlen
+func (c *converter) writeRequest(w io.Writer, cmd *Command, opts Options) error {
+	u, err := url.Parse(cmd.URL)
+	if err != nil {
+		return err
+	}
+	if cmd.User != "" {
+		// k6 takes the credentials of all authentication methods from the URL
+		credentials := strings.SplitN(cmd.User, ":", 2)
+		if len(credentials) == 2 {
+			u.User = url.UserPassword(credentials[0], credentials[1])
+		} else {
+			u.User = url.User(credentials[0])
+		}
+	}
+
+	var body string
+	hasContentType := false
+	for _, h := range cmd.Headers {
+		hasContentType = hasContentType || strings.EqualFold(h[0], "Content-Type")
+	}
+	headers := make([]string, 0, len(cmd.Headers)+1)
+	switch {
+	case len(cmd.Form) > 0:
+		if len(cmd.Data) > 0 || cmd.DataFile != "" {
+			return errors.New("form fields can't be combined with data")
+		}
+		fields := make([]string, len(cmd.Form))
+		for i, f := range cmd.Form {
+			if f.File == "" {
+				fields[i] = fmt.Sprintf("%q: %q", f.Name, f.Value)
+				continue
+			}
+			args := []string{c.file(f.File), strconv.Quote(f.FileName)}
+			if f.ContentType != "" {
+				args = append(args, strconv.Quote(f.ContentType))
+			}
+			fields[i] = fmt.Sprintf("%q: http.file(%s)", f.Name, strings.Join(args, ", "))
+		}
+		body = "{ " + strings.Join(fields, ", ") + " }"
+	case cmd.Get && cmd.DataFile != "":
+		return errors.New("data from a file can't be sent in the query string")
+	case cmd.Get && len(cmd.Data) > 0:
+		if u.RawQuery != "" {
+			u.RawQuery += "&"
+		}
+		u.RawQuery += strings.Join(cmd.Data, "&")
+	case cmd.DataFile != "" || len(cmd.Data) > 0:
+		if cmd.DataFile != "" {
+			body = c.file(cmd.DataFile)
+		} else {
+			body = strconv.Quote(strings.Join(cmd.Data, "&"))
+		}
+		if !hasContentType {
+			// the default of curl, that k6 doesn't add to string bodies
+			headers = append(headers, fmt.Sprintf("%q: %q", "Content-Type", "application/x-www-form-urlencoded"))
+		}
+	}
+	for _, h := range cmd.Headers {
+		headers = append(headers, fmt.Sprintf("%q: %q", h[0], h[1]))
+	}
+
+	var params []string
+	if len(headers) > 0 {
+		params = append(params, fmt.Sprintf("\"headers\": {\n\t\t\t%s\n\t\t}", strings.Join(headers, ",\n\t\t\t")))
+	}
+	if len(cmd.Cookies) > 0 {
+		cookies := make([]string, len(cmd.Cookies))
+		for i, cookie := range cmd.Cookies {
+			cookies[i] = fmt.Sprintf("%q: %q", cookie[0], cookie[1])
+		}
+		params = append(params, fmt.Sprintf("\"cookies\": {\n\t\t\t%s\n\t\t}", strings.Join(cookies, ",\n\t\t\t")))
+	}
+	if cmd.Auth != "" {
+		params = append(params, fmt.Sprintf("\"auth\": %q", cmd.Auth))
+	}
+	if cmd.Timeout > 0 {
+		params = append(params, fmt.Sprintf("\"timeout\": %q", cmd.Timeout.String()))
+	}
+
+	method := strings.ToLower(cmd.Method)
+	args := []string{strconv.Quote(u.String())}
+	switch {
+	case (cmd.Method == "GET" || cmd.Method == "HEAD") && body == "":
+	case cmd.Method == "POST" || cmd.Method == "PUT" || cmd.Method == "PATCH" ||
+		cmd.Method == "DELETE" || cmd.Method == "OPTIONS":
+		args = append(args, body)
+	default:
+		method = "request"
+		args = []string{strconv.Quote(cmd.Method), args[0], body}
+	}
+	if method == "delete" {
+		method = "del"
+	}
+	if len(args) > 1 && args[len(args)-1] == "" {
+		args[len(args)-1] = "null"
+	}
+	if len(params) > 0 {
+		args = append(args, "{\n\t\t"+strings.Join(params, ",\n\t\t")+"\n\t}")
+	}
+
+	for _, line := range strings.Split(cmd.Source, "\n") {
+		printer.Fprintf(w, "\t// %s\n", strings.TrimSpace(line))
+	}
+	printer.Fprintf(w, "\tres = http.%s(%s);\n", method, strings.Join(args, ", "))
+	if opts.EnableChecks {
+		const check = `check(res, {"status is 2XX": (r) => r.status >= 200 && r.status < 300 })`
+		if opts.ReturnOnFailedCheck {
+			printer.Fprintf(w, "\tif (!%s) { return };\n", check)
+		} else {
+			printer.Fprintf(w, "\t%s;\n", check)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,446 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package curl
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Command is a parsed curl command line.
+type Command struct {
+	// Source is the command line, as it was written
+	Source  string
+	Method  string
+	URL     string
+	Headers [][2]string
+	// Data are the -d, --data-* and --json values, in order
+	Data []string
+	// DataFile is the path of the file with the data, as in -d @path
+	DataFile string
+	// Form are the -F fields, in order
+	Form []FormField
+	// Get sends the data in the query string, with -G
+	Get bool
+	// User are the credentials of -u
+	User string
+	// Auth is "digest" or "ntlm" when those authentications are used instead of basic
+	Auth    string
+	Cookies [][2]string
+	// Insecure skips the TLS verification, with -k
+	Insecure bool
+	Timeout  time.Duration
+}
+
+// FormField is a field of a multipart form.
+type FormField struct {
+	Name  string
+	Value string
+	// File is the path of the file that's sent as the value, as in -F name=@path
+	File        string
+	FileName    string
+	ContentType string
+}
+
+// errUnterminated is returned for command lines with a quote that isn't closed
+var errUnterminated = errors.New("unterminated quote") //nolint:gochecknoglobals
+
+// options are the curl options with a value, by their long and short names
+//
+//nolint:gochecknoglobals
+var options = map[string]string{
+	"-X": "--request", "-H": "--header", "-d": "--data", "-F": "--form", "-u": "--user",
+	"-b": "--cookie", "-A": "--user-agent", "-e": "--referer", "-m": "--max-time",
+	"-o": "--output", "-w": "--write-out", "-x": "--proxy", "-c": "--cookie-jar",
+	"--request": "", "--header": "", "--data": "", "--data-raw": "", "--data-binary": "",
+	"--data-ascii": "", "--data-urlencode": "", "--json": "", "--form": "", "--form-string": "",
+	"--user": "", "--cookie": "", "--user-agent": "", "--referer": "", "--max-time": "",
+	"--url": "", "--oauth2-bearer": "", "--output": "", "--write-out": "", "--proxy": "",
+	"--cookie-jar": "", "--connect-timeout": "", "--retry": "", "--resolve": "",
+}
+
+// flags are the curl options without a value, by their long and short names
+//
+//nolint:gochecknoglobals
+var flags = map[string]string{
+	"-G": "--get", "-I": "--head", "-k": "--insecure", "-L": "--location", "-s": "--silent",
+	"-S": "--show-error", "-v": "--verbose", "-i": "--include", "-f": "--fail", "-N": "--no-buffer",
+	"--get": "", "--head": "", "--insecure": "", "--location": "", "--silent": "", "--show-error": "",
+	"--verbose": "", "--include": "", "--fail": "", "--no-buffer": "", "--compressed": "",
+	"--digest": "", "--ntlm": "", "--basic": "", "--http1.1": "", "--http2": "", "--globoff": "",
+}
+
+// Parse parses the curl commands in the text, one per line. Lines ending
+// with a backslash continue on the next line.
+func Parse(text string) ([]*Command, error) {
+	var commands []*Command
+	var source []string
+	for _, line := range strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n") {
+		if len(source) == 0 && (strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#")) {
+			continue
+		}
+		source = append(source, line)
+		if strings.HasSuffix(line, "\\") {
+			continue
+		}
+		cmd, err := ParseCommand(strings.Join(source, "\n"))
+		if errors.Cause(err) == errUnterminated {
+			// a quoted value with line breaks
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, cmd)
+		source = nil
+	}
+	if len(source) > 0 {
+		cmd, err := ParseCommand(strings.Join(source, "\n"))
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, cmd)
+	}
+	if len(commands) == 0 {
+		return nil, errors.New("there are no curl commands")
+	}
+	return commands, nil
+}
+
+// ParseCommand parses a single curl command line.
+//
+//nolint:funlen,gocognit,gocyclo
+func ParseCommand(source string) (*Command, error) {
+	words, err := split(source)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 || words[0] != "curl" {
+		return nil, errors.Errorf("%q isn't a curl command", strings.TrimSpace(source))
+	}
+
+	cmd := &Command{Source: strings.TrimSpace(source)}
+	head := false
+	for i := 1; i < len(words); i++ {
+		word := words[i]
+		if !strings.HasPrefix(word, "-") || word == "-" {
+			if cmd.URL != "" {
+				return nil, errors.Errorf("only one URL per curl command is supported, %q and %q were given", cmd.URL, word)
+			}
+			cmd.URL = word
+			continue
+		}
+
+		name, value, hasValue := word, "", false
+		if !strings.HasPrefix(word, "--") && len(word) > 2 {
+			// short options can have their values right after them, or be grouped flags
+			if _, ok := options[word[:2]]; ok {
+				name, value, hasValue = word[:2], word[2:], true
+			} else {
+				for _, r := range word[1:] {
+					if !cmd.flag("-"+string(r), &head) {
+						return nil, errors.Errorf("unsupported curl option %s", word)
+					}
+				}
+				continue
+			}
+		}
+		if cmd.flag(name, &head) {
+			continue
+		}
+		if long := options[name]; long != "" {
+			name = long
+		}
+		if _, ok := options[name]; !ok {
+			return nil, errors.Errorf("unsupported curl option %s", word)
+		}
+		if !hasValue {
+			if i+1 >= len(words) {
+				return nil, errors.Errorf("the curl option %s needs a value", word)
+			}
+			i++
+			value = words[i]
+		}
+
+		switch name {
+		case "--request":
+			cmd.Method = strings.ToUpper(value)
+		case "--url":
+			cmd.URL = value
+		case "--header":
+			header := strings.SplitN(value, ":", 2)
+			if len(header) != 2 {
+				return nil, errors.Errorf("invalid header %q", value)
+			}
+			cmd.Headers = append(cmd.Headers, [2]string{strings.TrimSpace(header[0]), strings.TrimSpace(header[1])})
+		case "--user-agent":
+			cmd.Headers = append(cmd.Headers, [2]string{"User-Agent", value})
+		case "--referer":
+			cmd.Headers = append(cmd.Headers, [2]string{"Referer", value})
+		case "--oauth2-bearer":
+			cmd.Headers = append(cmd.Headers, [2]string{"Authorization", "Bearer " + value})
+		case "--data", "--data-ascii", "--data-binary", "--data-raw", "--json":
+			if strings.HasPrefix(value, "@") && name != "--data-raw" {
+				if cmd.DataFile != "" || len(cmd.Data) > 0 {
+					return nil, errors.New("data from a file can't be combined with other data")
+				}
+				cmd.DataFile = value[1:]
+			} else {
+				if cmd.DataFile != "" {
+					return nil, errors.New("data from a file can't be combined with other data")
+				}
+				if name == "--data" || name == "--data-ascii" {
+					// curl strips the line breaks of the data
+					value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+				}
+				cmd.Data = append(cmd.Data, value)
+			}
+			if name == "--json" {
+				cmd.Headers = append(cmd.Headers,
+					[2]string{"Content-Type", "application/json"}, [2]string{"Accept", "application/json"})
+			}
+		case "--data-urlencode":
+			data, err := urlencode(value)
+			if err != nil {
+				return nil, err
+			}
+			cmd.Data = append(cmd.Data, data)
+		case "--form", "--form-string":
+			field, err := formField(value, name == "--form-string")
+			if err != nil {
+				return nil, err
+			}
+			cmd.Form = append(cmd.Form, field)
+		case "--user":
+			cmd.User = value
+		case "--cookie":
+			if !strings.Contains(value, "=") {
+				return nil, errors.Errorf("reading cookies from the file %s isn't supported", value)
+			}
+			for _, cookie := range strings.Split(value, ";") {
+				c := strings.SplitN(strings.TrimSpace(cookie), "=", 2)
+				if len(c) == 2 {
+					cmd.Cookies = append(cmd.Cookies, [2]string{c[0], c[1]})
+				}
+			}
+		case "--max-time":
+			d, err := time.ParseDuration(value + "s")
+			if err != nil {
+				return nil, errors.Errorf("invalid maximum time %q", value)
+			}
+			cmd.Timeout = d
+		}
+	}
+
+	if cmd.URL == "" {
+		return nil, errors.Errorf("the curl command %q has no URL", cmd.Source)
+	}
+	if !strings.Contains(cmd.URL, "://") {
+		// curl defaults to HTTP
+		cmd.URL = "http://" + cmd.URL
+	}
+	if cmd.Method == "" {
+		switch {
+		case head:
+			cmd.Method = "HEAD"
+		case cmd.Get:
+			cmd.Method = "GET"
+		case len(cmd.Data) > 0 || cmd.DataFile != "" || len(cmd.Form) > 0:
+			cmd.Method = "POST"
+		default:
+			cmd.Method = "GET"
+		}
+	}
+	return cmd, nil
+}
+
+// flag sets the curl option without a value, if it is one
+func (cmd *Command) flag(name string, head *bool) bool {
+	if long := flags[name]; long != "" {
+		name = long
+	}
+	if _, ok := flags[name]; !ok {
+		return false
+	}
+	switch name {
+	case "--get":
+		cmd.Get = true
+	case "--head":
+		*head = true
+	case "--insecure":
+		cmd.Insecure = true
+	case "--digest", "--ntlm":
+		cmd.Auth = name[2:]
+	case "--basic":
+		cmd.Auth = ""
+	}
+	return true
+}
+
+// formField parses the value of -F
+func formField(value string, literal bool) (FormField, error) {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 {
+		return FormField{}, errors.Errorf("invalid form field %q", value)
+	}
+	field := FormField{Name: parts[0], Value: parts[1]}
+	if literal || !strings.HasPrefix(field.Value, "@") {
+		if !literal && strings.HasPrefix(field.Value, "<") {
+			return FormField{}, errors.Errorf("reading the value of the form field %q from a file isn't supported", field.Name)
+		}
+		return field, nil
+	}
+	attributes := strings.Split(field.Value[1:], ";")
+	field.File, field.Value = attributes[0], ""
+	for _, attribute := range attributes[1:] {
+		switch kv := strings.SplitN(attribute, "=", 2); {
+		case len(kv) != 2:
+		case kv[0] == "type":
+			field.ContentType = kv[1]
+		case kv[0] == "filename":
+			field.FileName = strings.Trim(kv[1], `"`)
+		}
+	}
+	if field.FileName == "" {
+		field.FileName = field.File[strings.LastIndexAny(field.File, `/\`)+1:]
+	}
+	return field, nil
+}
+
+// urlencode returns the data of a --data-urlencode value
+func urlencode(value string) (string, error) {
+	if i := strings.IndexAny(value, "=@"); i != -1 {
+		if value[i] == '@' {
+			return "", errors.Errorf("reading the data of --data-urlencode from a file isn't supported")
+		}
+		if i == 0 {
+			return url.QueryEscape(value[1:]), nil
+		}
+		return value[:i] + "=" + url.QueryEscape(value[i+1:]), nil
+	}
+	return url.QueryEscape(value), nil
+}
+
+// split splits a command line into words, like a POSIX shell would do it
+//
+//nolint:gocognit
+func split(source string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	runes := []rune(source)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\':
+			if i+1 < len(runes) {
+				i++
+				if runes[i] == '\n' {
+					// a line continuation
+					continue
+				}
+				word.WriteRune(runes[i])
+				inWord = true
+			}
+		case r == '\'':
+			end := indexRune(runes, i+1, '\'')
+			if end == -1 {
+				return nil, errUnterminated
+			}
+			word.WriteString(string(runes[i+1 : end]))
+			inWord = true
+			i = end
+		case r == '$' && i+1 < len(runes) && runes[i+1] == '\'':
+			// ANSI-C quoting, as in $'\n'
+			value, end, err := ansiC(runes, i+2)
+			if err != nil {
+				return nil, err
+			}
+			word.WriteString(value)
+			inWord = true
+			i = end
+		case r == '"':
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("\"\\$`\n", runes[i+1]) {
+					i++
+					if runes[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, errUnterminated
+			}
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+func indexRune(runes []rune, from int, r rune) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// ansiC returns the value of an ANSI-C quoted string that starts at from,
+// and the index of its closing quote
+func ansiC(runes []rune, from int) (string, int, error) {
+	var value strings.Builder
+	escapes := map[rune]rune{'n': '\n', 't': '\t', 'r': '\r', '\\': '\\', '\'': '\'', '"': '"'}
+	for i := from; i < len(runes); i++ {
+		switch {
+		case runes[i] == '\'':
+			return value.String(), i, nil
+		case runes[i] == '\\' && i+1 < len(runes):
+			i++
+			if escaped, ok := escapes[runes[i]]; ok {
+				value.WriteRune(escaped)
+			} else {
+				value.WriteRune('\\')
+				value.WriteRune(runes[i])
+			}
+		default:
+			value.WriteRune(runes[i])
+		}
+	}
+	return "", 0, errUnterminated
+}
@@ -0,0 +1,121 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package curl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		source string
+		words  []string
+	}{
+		{`curl https://example.com`, []string{"curl", "https://example.com"}},
+		{`curl -H 'X-A: "1"' "a \"b\" \$c" d\ e`, []string{"curl", "-H", `X-A: "1"`, `a "b" $c`, "d e"}},
+		{"curl \\\n  -d 'a\nb'", []string{"curl", "-d", "a\nb"}},
+		{`curl $'line\nbreak \'q\''`, []string{"curl", "line\nbreak 'q'"}},
+	}
+	for _, tc := range testCases {
+		words, err := split(tc.source)
+		require.NoError(t, err, tc.source)
+		assert.Equal(t, tc.words, words, tc.source)
+	}
+	_, err := split(`curl 'unterminated`)
+	assert.Equal(t, errUnterminated, err)
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	commands, err := Parse(`
+# the login
+curl -X post 'https://example.com/login' \
+  -H 'Content-Type: application/json' \
+  --data-raw '{
+  "user": "admin"
+}' -sSL
+
+curl -G https://example.com/search -d q=k6 --data-urlencode 'tag=load test' -b 'a=1; b=2' -m 2.5
+curl -u admin:secret --digest -F name=k6 -F 'logo=@/tmp/logo.png;type=image/png' example.com/upload
+curl -I -k https://example.com --oauth2-bearer token -A k6
+curl --json @body.json https://example.com/items
+`)
+	require.NoError(t, err)
+	require.Len(t, commands, 5)
+
+	login := commands[0]
+	assert.Equal(t, "POST", login.Method)
+	assert.Equal(t, "https://example.com/login", login.URL)
+	assert.Equal(t, [][2]string{{"Content-Type", "application/json"}}, login.Headers)
+	assert.Equal(t, []string{"{\n  \"user\": \"admin\"\n}"}, login.Data)
+	assert.Contains(t, login.Source, "curl -X post")
+
+	search := commands[1]
+	assert.Equal(t, "GET", search.Method)
+	assert.True(t, search.Get)
+	assert.Equal(t, []string{"q=k6", "tag=load+test"}, search.Data)
+	assert.Equal(t, [][2]string{{"a", "1"}, {"b", "2"}}, search.Cookies)
+	assert.Equal(t, 2500*time.Millisecond, search.Timeout)
+
+	upload := commands[2]
+	assert.Equal(t, "POST", upload.Method)
+	assert.Equal(t, "http://example.com/upload", upload.URL)
+	assert.Equal(t, "admin:secret", upload.User)
+	assert.Equal(t, "digest", upload.Auth)
+	assert.Equal(t, []FormField{
+		{Name: "name", Value: "k6"},
+		{Name: "logo", File: "/tmp/logo.png", FileName: "logo.png", ContentType: "image/png"},
+	}, upload.Form)
+
+	head := commands[3]
+	assert.Equal(t, "HEAD", head.Method)
+	assert.True(t, head.Insecure)
+	assert.Equal(t, [][2]string{{"Authorization", "Bearer token"}, {"User-Agent", "k6"}}, head.Headers)
+
+	items := commands[4]
+	assert.Equal(t, "POST", items.Method)
+	assert.Equal(t, "body.json", items.DataFile)
+	assert.Equal(t, [][2]string{{"Content-Type", "application/json"}, {"Accept", "application/json"}}, items.Headers)
+
+	for source, message := range map[string]string{
+		"":                                "there are no curl commands",
+		"wget https://example.com":        `"wget https://example.com" isn't a curl command`,
+		"curl --foo https://example.com":  "unsupported curl option --foo",
+		"curl -sZ https://example.com":    "unsupported curl option -sZ",
+		"curl https://example.com -H":     "the curl option -H needs a value",
+		"curl -X POST":                    `the curl command "curl -X POST" has no URL`,
+		"curl a.com b.com":                `only one URL per curl command is supported, "a.com" and "b.com" were given`,
+		"curl -b cookies.txt a.com":       "reading cookies from the file cookies.txt isn't supported",
+		"curl -d @a.json -d b=1 a.com":    "data from a file can't be combined with other data",
+		"curl 'https://example.com":       "unterminated quote",
+		"curl -F 'a=<file.txt' a.com":     `reading the value of the form field "a" from a file isn't supported`,
+		"curl --data-urlencode a@f a.com": "reading the data of --data-urlencode from a file isn't supported",
+	} {
+		_, err := Parse(source)
+		assert.EqualError(t, err, message, source)
+	}
+}
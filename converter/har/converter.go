@@ -25,39 +25,16 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/url"
 	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
-	"github.com/tidwall/pretty"
 
+	"github.com/loadimpact/k6/converter/internal/printer"
 	"github.com/loadimpact/k6/lib"
 )
 
-// fprint panics when where's an error writing to the supplied io.Writer
-// since this will be used on in-memory expandable buffers, that should
-// happen only when we run out of memory...
-func fprint(w io.Writer, a ...interface{}) int {
-	n, err := fmt.Fprint(w, a...)
-	if err != nil {
-		panic(err.Error())
-	}
-	return n
-}
-
-// fprintf panics when where's an error writing to the supplied io.Writer
-// since this will be used on in-memory expandable buffers, that should
-// happen only when we run out of memory...
-func fprintf(w io.Writer, format string, a ...interface{}) int {
-	n, err := fmt.Fprintf(w, format, a...)
-	if err != nil {
-		panic(err.Error())
-	}
-	return n
-}
-
 // TODO: refactor this to have fewer parameters... or just refactor in general...
 func Convert(h HAR, options lib.Options, minSleep, maxSleep uint, enableChecks bool, returnOnFailedCheck bool, batchTime uint, nobatch bool, correlate bool, only, skip []string) (result string, convertErr error) {
 	var b bytes.Buffer
@@ -75,37 +52,6 @@ func Convert(h HAR, options lib.Options, minSleep, maxSleep uint, enableChecks b
 		return "", errors.Errorf("invalid HAR file supplied, the 'log' property is missing")
 	}
 
-	if enableChecks {
-		fprint(w, "import { group, check, sleep } from 'k6';\n")
-	} else {
-		fprint(w, "import { group, sleep } from 'k6';\n")
-	}
-	fprint(w, "import http from 'k6/http';\n\n")
-
-	fprintf(w, "// Version: %v\n", h.Log.Version)
-	fprintf(w, "// Creator: %v\n", h.Log.Creator.Name)
-	if h.Log.Browser != nil {
-		fprintf(w, "// Browser: %v\n", h.Log.Browser.Name)
-	}
-	if h.Log.Comment != "" {
-		fprintf(w, "// %v\n", h.Log.Comment)
-	}
-
-	fprint(w, "\nexport let options = {\n")
-	options.ForEachSpecified("json", func(key string, val interface{}) {
-		if valJSON, err := json.MarshalIndent(val, "    ", "    "); err != nil {
-			convertErr = err
-		} else {
-			fprintf(w, "    %s: %s,\n", key, valJSON)
-		}
-	})
-	if convertErr != nil {
-		return "", convertErr
-	}
-	fprint(w, "};\n\n")
-
-	fprint(w, "export default function() {\n\n")
-
 	pages := h.Log.Pages
 	sort.Sort(PageByStarted(pages))
 
@@ -145,25 +91,84 @@ func Convert(h HAR, options lib.Options, minSleep, maxSleep uint, enableChecks b
 		}
 	}
 
+	scriptGroupNames := make([]string, len(pages))
 	for i, page := range pages {
-
-		entries := pageEntries[page.ID]
-
-		scriptGroupName := page.ID + " - " + page.Title
+		sort.Sort(EntryByStarted(pageEntries[page.ID]))
+		scriptGroupNames[i] = page.ID + " - " + page.Title
 		if page.ID == "" {
 			// Temporary fix for https://github.com/loadimpact/k6/issues/793
 			// I can't just remove the group() call since all of the subsequent code indentation is hardcoded...
-			scriptGroupName = page.Title
+			scriptGroupNames[i] = page.Title
 		}
-		fprintf(w, "\tgroup(%q, function() {\n", scriptGroupName)
+	}
 
-		sort.Sort(EntryByStarted(entries))
+	// the values of the responses used in later requests are found in the order of the script
+	var corr *correlator
+	if correlate {
+		var entries []*Entry
+		var labels []string
+		for i, page := range pages {
+			for entryIndex, e := range pageEntries[page.ID] {
+				entries = append(entries, e)
+				label := fmt.Sprintf("request #%d", entryIndex)
+				if len(pages) > 1 {
+					label += fmt.Sprintf(" of %q", scriptGroupNames[i])
+				}
+				labels = append(labels, label)
+			}
+		}
+		corr = newCorrelator()
+		corr.analyze(entries, labels)
+	}
 
-		if nobatch {
-			var recordedRedirectURL string
-			previousResponse := map[string]interface{}{}
+	if enableChecks {
+		printer.Fprint(w, "import { group, check, sleep } from 'k6';\n")
+	} else {
+		printer.Fprint(w, "import { group, sleep } from 'k6';\n")
+	}
+	printer.Fprint(w, "import http from 'k6/http';\n\n")
 
-			fprint(w, "\t\tlet res, redirectUrl, json;\n")
+	printer.Fprintf(w, "// Version: %v\n", h.Log.Version)
+	printer.Fprintf(w, "// Creator: %v\n", h.Log.Creator.Name)
+	if h.Log.Browser != nil {
+		printer.Fprintf(w, "// Browser: %v\n", h.Log.Browser.Name)
+	}
+	if h.Log.Comment != "" {
+		printer.Fprintf(w, "// %v\n", h.Log.Comment)
+	}
+	if report := corr.report(); len(report) > 0 {
+		printer.Fprint(w, "//\n// Correlated values:\n")
+		for _, line := range report {
+			printer.Fprintf(w, "// - %s\n", line)
+		}
+	}
+
+	printer.Fprint(w, "\nexport let options = {\n")
+	options.ForEachSpecified("json", func(key string, val interface{}) {
+		if valJSON, err := json.MarshalIndent(val, "    ", "    "); err != nil {
+			convertErr = err
+		} else {
+			printer.Fprintf(w, "    %s: %s,\n", key, valJSON)
+		}
+	})
+	if convertErr != nil {
+		return "", convertErr
+	}
+	printer.Fprint(w, "};\n\n")
+
+	printer.Fprint(w, "export default function() {\n\n")
+	if corr != nil && len(corr.correlations) > 0 {
+		printer.Fprint(w, "\tconst vars = {};\n\n")
+	}
+
+	for i, page := range pages {
+
+		entries := pageEntries[page.ID]
+
+		printer.Fprintf(w, "\tgroup(%q, function() {\n", scriptGroupNames[i])
+
+		if nobatch {
+			printer.Fprint(w, "\t\tlet res;\n")
 
 			for entryIndex, e := range entries {
 
@@ -171,128 +176,89 @@ func Convert(h HAR, options lib.Options, minSleep, maxSleep uint, enableChecks b
 				var cookies []string
 				var body string
 
-				fprintf(w, "\t\t// Request #%d\n", entryIndex)
+				printer.Fprintf(w, "\t\t// Request #%d\n", entryIndex)
 
 				if e.Request.PostData != nil {
 					body = e.Request.PostData.Text
 				}
 
 				for _, c := range e.Request.Cookies {
-					cookies = append(cookies, fmt.Sprintf(`%q: %q`, c.Name, c.Value))
+					if corr.sendsCookie(e, c.Name) {
+						continue
+					}
+					cookies = append(cookies, fmt.Sprintf(`%q: %s`, c.Name, corr.expr(e, "cookie "+c.Name, c.Value)))
 				}
 				if len(cookies) > 0 {
 					params = append(params, fmt.Sprintf("\"cookies\": {\n\t\t\t\t%s\n\t\t\t}", strings.Join(cookies, ",\n\t\t\t\t\t")))
 				}
 
-				if headers := buildK6Headers(e.Request.Headers); len(headers) > 0 {
+				if headers := correlatedK6Headers(corr, e, e.Request.Headers); len(headers) > 0 {
 					params = append(params, fmt.Sprintf("\"headers\": {\n\t\t\t\t\t%s\n\t\t\t\t}", strings.Join(headers, ",\n\t\t\t\t\t")))
 				}
 
-				fprintf(w, "\t\tres = http.%s(", strings.ToLower(e.Request.Method))
-
-				if correlate && recordedRedirectURL != "" {
-					if recordedRedirectURL != e.Request.URL {
-						return "", errors.Errorf("The har file contained a redirect but the next request did not match that redirect. Possibly a misbehaving client or concurrent requests?")
-					}
-					fprintf(w, "redirectUrl")
-					recordedRedirectURL = ""
-				} else {
-					fprintf(w, "%q", e.Request.URL)
-				}
+				printer.Fprintf(w, "\t\tres = http.%s(", strings.ToLower(e.Request.Method))
+				printer.Fprint(w, corr.expr(e, "URL", e.Request.URL))
 
 				if e.Request.Method != "GET" {
-					if correlate && e.Request.PostData != nil && strings.Contains(e.Request.PostData.MimeType, "json") {
-						requestMap := map[string]interface{}{}
-
-						escapedPostdata := strings.Replace(e.Request.PostData.Text, "$", "\\$", -1)
-
-						if err := json.Unmarshal([]byte(escapedPostdata), &requestMap); err != nil {
-							return "", err
-						}
-
-						if len(previousResponse) != 0 {
-							traverseMaps(requestMap, previousResponse, nil)
-						}
-						requestText, err := json.Marshal(requestMap)
-						if err == nil {
-							prettyJSONString := string(pretty.PrettyOptions(requestText, &pretty.Options{Width: 999999, Prefix: "\t\t\t", Indent: "\t", SortKeys: true})[:])
-							fprintf(w, ",\n\t\t\t`%s`", strings.TrimSpace(prettyJSONString))
-						} else {
-							return "", err
-						}
-
-					} else {
-						fprintf(w, ",\n\t\t%q", body)
+					// the bodies of correlated requests are aligned with their params
+					bodyIndent := "\t\t"
+					if corr != nil {
+						bodyIndent = "\t\t\t"
 					}
+					printer.Fprintf(w, ",\n%s%s", bodyIndent, corr.expr(e, "body", body))
 				}
 
 				if len(params) > 0 {
-					fprintf(w, ",\n\t\t\t{\n\t\t\t\t%s\n\t\t\t}", strings.Join(params, ",\n\t\t\t"))
+					printer.Fprintf(w, ",\n\t\t\t{\n\t\t\t\t%s\n\t\t\t}", strings.Join(params, ",\n\t\t\t"))
 				}
 
-				fprintf(w, "\n\t\t)\n")
+				printer.Fprintf(w, "\n\t\t)\n")
 
 				if e.Response != nil {
 					// the response is nil if there is a failed request in the recording, or if responses were not recorded
 					if enableChecks {
 						if e.Response.Status > 0 {
 							if returnOnFailedCheck {
-								fprintf(w, "\t\tif (!check(res, {\"status is %v\": (r) => r.status === %v })) { return };\n", e.Response.Status, e.Response.Status)
+								printer.Fprintf(w, "\t\tif (!check(res, {\"status is %v\": (r) => r.status === %v })) { return };\n", e.Response.Status, e.Response.Status)
 							} else {
-								fprintf(w, "\t\tcheck(res, {\"status is %v\": (r) => r.status === %v });\n", e.Response.Status, e.Response.Status)
+								printer.Fprintf(w, "\t\tcheck(res, {\"status is %v\": (r) => r.status === %v });\n", e.Response.Status, e.Response.Status)
 							}
 						}
 					}
 
-					if e.Response.Headers != nil {
-						for _, header := range e.Response.Headers {
-							if header.Name == "Location" {
-								fprintf(w, "\t\tredirectUrl = res.headers.Location;\n")
-								recordedRedirectURL = header.Value
-								break
-							}
-						}
-					}
-
-					responseMimeType := e.Response.Content.MimeType
-					if correlate &&
-						strings.Index(responseMimeType, "application/") == 0 &&
-						strings.Index(responseMimeType, "json") == len(responseMimeType)-4 {
-						if err := json.Unmarshal([]byte(e.Response.Content.Text), &previousResponse); err != nil {
-							return "", err
-						}
-						fprint(w, "\t\tjson = JSON.parse(res.body);\n")
+					for _, extraction := range corr.extractions(e) {
+						printer.Fprintf(w, "\t\t%s\n", extraction)
 					}
 				}
 			}
 		} else {
 			batches := SplitEntriesInBatches(entries, batchTime)
 
-			fprint(w, "\t\tlet req, res;\n")
+			printer.Fprint(w, "\t\tlet req, res;\n")
 
 			for j, batchEntries := range batches {
 
-				fprint(w, "\t\treq = [")
+				printer.Fprint(w, "\t\treq = [")
 				for k, e := range batchEntries {
 					r, err := buildK6RequestObject(e.Request)
 					if err != nil {
 						return "", err
 					}
-					fprintf(w, "%v", r)
+					printer.Fprintf(w, "%v", r)
 					if k != len(batchEntries)-1 {
-						fprint(w, ",")
+						printer.Fprint(w, ",")
 					}
 				}
-				fprint(w, "];\n")
-				fprint(w, "\t\tres = http.batch(req);\n")
+				printer.Fprint(w, "];\n")
+				printer.Fprint(w, "\t\tres = http.batch(req);\n")
 
 				if enableChecks {
 					for k, e := range batchEntries {
 						if e.Response.Status > 0 {
 							if returnOnFailedCheck {
-								fprintf(w, "\t\tif (!check(res, {\"status is %v\": (r) => r.status === %v })) { return };\n", e.Response.Status, e.Response.Status)
+								printer.Fprintf(w, "\t\tif (!check(res, {\"status is %v\": (r) => r.status === %v })) { return };\n", e.Response.Status, e.Response.Status)
 							} else {
-								fprintf(w, "\t\tcheck(res[%v], {\"status is %v\": (r) => r.status === %v });\n", k, e.Response.Status, e.Response.Status)
+								printer.Fprintf(w, "\t\tcheck(res[%v], {\"status is %v\": (r) => r.status === %v });\n", k, e.Response.Status, e.Response.Status)
 							}
 						}
 					}
@@ -302,14 +268,14 @@ func Convert(h HAR, options lib.Options, minSleep, maxSleep uint, enableChecks b
 					lastBatchEntry := batchEntries[len(batchEntries)-1]
 					firstBatchEntry := batches[j+1][0]
 					t := firstBatchEntry.StartedDateTime.Sub(lastBatchEntry.StartedDateTime).Seconds()
-					fprintf(w, "\t\tsleep(%.2f);\n", t)
+					printer.Fprintf(w, "\t\tsleep(%.2f);\n", t)
 				}
 			}
 
 			if i == len(pages)-1 {
 				// Last page; add random sleep time at the group completion
-				fprintf(w, "\t\t// Random sleep between %ds and %ds\n", minSleep, maxSleep)
-				fprintf(w, "\t\tsleep(Math.floor(Math.random()*%d+%d));\n", maxSleep-minSleep, minSleep)
+				printer.Fprintf(w, "\t\t// Random sleep between %ds and %ds\n", minSleep, maxSleep)
+				printer.Fprintf(w, "\t\tsleep(Math.floor(Math.random()*%d+%d));\n", maxSleep-minSleep, minSleep)
 			} else {
 				// Add sleep time at the end of the group
 				nextPage := pages[i+1]
@@ -321,14 +287,14 @@ func Convert(h HAR, options lib.Options, minSleep, maxSleep uint, enableChecks b
 						sleepTime = t
 					}
 				}
-				fprintf(w, "\t\tsleep(%.2f);\n", sleepTime)
+				printer.Fprintf(w, "\t\tsleep(%.2f);\n", sleepTime)
 			}
 		}
 
-		fprint(w, "\t});\n")
+		printer.Fprint(w, "\t});\n")
 	}
 
-	fprint(w, "\n}\n")
+	printer.Fprint(w, "\n}\n")
 	if err := w.Flush(); err != nil {
 		return "", err
 	}
@@ -339,22 +305,22 @@ func buildK6RequestObject(req *Request) (string, error) {
 	var b bytes.Buffer
 	w := bufio.NewWriter(&b)
 
-	fprint(w, "{\n")
+	printer.Fprint(w, "{\n")
 
 	method := strings.ToLower(req.Method)
 	if method == "delete" {
 		method = "del"
 	}
-	fprintf(w, `"method": %q, "url": %q`, method, req.URL)
+	printer.Fprintf(w, `"method": %q, "url": %q`, method, req.URL)
 
 	if req.PostData != nil && method != "get" {
 		postParams, plainText, err := buildK6Body(req)
 		if err != nil {
 			return "", err
 		} else if len(postParams) > 0 {
-			fprintf(w, `, "body": { %s }`, strings.Join(postParams, ", "))
+			printer.Fprintf(w, `, "body": { %s }`, strings.Join(postParams, ", "))
 		} else if plainText != "" {
-			fprintf(w, `, "body": %q`, plainText)
+			printer.Fprintf(w, `, "body": %q`, plainText)
 		}
 	}
 
@@ -372,10 +338,10 @@ func buildK6RequestObject(req *Request) (string, error) {
 	}
 
 	if len(params) > 0 {
-		fprintf(w, `, "params": { %s }`, strings.Join(params, ", "))
+		printer.Fprintf(w, `, "params": { %s }`, strings.Join(params, ", "))
 	}
 
-	fprint(w, "}")
+	printer.Fprint(w, "}")
 	if err := w.Flush(); err != nil {
 		return "", err
 	}
@@ -389,6 +355,12 @@ func buildK6RequestObject(req *Request) (string, error) {
 }
 
 func buildK6Headers(headers []Header) []string {
+	return correlatedK6Headers(nil, nil, headers)
+}
+
+// correlatedK6Headers returns the headers of the request of the entry, with
+// the values correlated by c, if it isn't nil
+func correlatedK6Headers(c *correlator, e *Entry, headers []Header) []string {
 	var h []string
 	if len(headers) > 0 {
 		ignored := map[string]bool{"cookie": true, "content-length": true}
@@ -398,7 +370,7 @@ func buildK6Headers(headers []Header) []string {
 			// Avoid SPDY's, duplicated or ignored headers
 			if !isIgnored && name[0] != ':' {
 				ignored[name] = true
-				h = append(h, fmt.Sprintf("%q: %q", header.Name, header.Value))
+				h = append(h, fmt.Sprintf("%q: %s", header.Name, c.expr(e, "header "+header.Name, header.Value)))
 			}
 		}
 	}
@@ -423,66 +395,3 @@ func buildK6Body(req *Request) ([]string, string, error) {
 	}
 	return postParams, req.PostData.Text, nil
 }
-
-func traverseMaps(request map[string]interface{}, response map[string]interface{}, path []interface{}) {
-	if response == nil {
-		// previous call reached a leaf in the response map so there's no point continuing
-		return
-	}
-	for key, val := range request {
-		responseVal := response[key]
-		if responseVal == nil {
-			// no corresponding value in response map (and the type conversion below would fail so we need an early exit)
-			continue
-		}
-		newPath := append(path, key)
-		switch concreteVal := val.(type) {
-		case map[string]interface{}:
-			traverseMaps(concreteVal, responseVal.(map[string]interface{}), newPath)
-		case []interface{}:
-			traverseArrays(concreteVal, responseVal.([]interface{}), newPath)
-		default:
-			if responseVal == val {
-				request[key] = jsObjectPath(newPath)
-			}
-		}
-	}
-}
-
-func traverseArrays(requestArray []interface{}, responseArray []interface{}, path []interface{}) {
-	for i, val := range requestArray {
-		newPath := append(path, i)
-		if len(responseArray) <= i {
-			// requestArray had more entries than responseArray
-			break
-		}
-		responseVal := responseArray[i]
-		switch concreteVal := val.(type) {
-		case map[string]interface{}:
-			traverseMaps(concreteVal, responseVal.(map[string]interface{}), newPath)
-		case []interface{}:
-			traverseArrays(concreteVal, responseVal.([]interface{}), newPath)
-		case string:
-			if responseVal == val {
-				requestArray[i] = jsObjectPath(newPath)
-			}
-		default:
-			panic(jsObjectPath(newPath))
-		}
-	}
-}
-
-func jsObjectPath(path []interface{}) string {
-	s := "${json"
-	for _, val := range path {
-		// this may cause issues with non-array keys with numeric values. test this later.
-		switch concreteVal := val.(type) {
-		case int:
-			s = s + "[" + fmt.Sprint(concreteVal) + "]"
-		case string:
-			s = s + "." + concreteVal
-		}
-	}
-	s = s + "}"
-	return s
-}
@@ -0,0 +1,612 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package har
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// minCorrelatedLength is the minimum length of the values that are
+// correlated, shorter ones are too likely to appear by chance
+const minCorrelatedLength = 4
+
+// correlation is a dynamic value of a response that's used in later requests
+type correlation struct {
+	// name is the key of the value in the vars object of the script
+	name  string
+	value string
+	// key is the name of the value in the response, e.g. its JSON key
+	key string
+	// entry is the entry with the response that has the value
+	entry *Entry
+	// source describes where the value is in the response
+	source string
+	// extract is the JS expression that extracts the value from res
+	extract string
+	// uses are the parts of the later requests where the value is used
+	uses []use
+}
+
+// use is a part of a request where a correlated value is used
+type use struct {
+	part  string
+	entry *Entry
+}
+
+// correlator finds the values of the responses of a recording that are used
+// in later requests, and replaces them with the values of the responses of
+// the script
+type correlator struct {
+	// labels are the names of the entries in the script, as "request #0 of group"
+	labels map[*Entry]string
+	// candidates are the values of the responses so far, by value
+	candidates map[string]*correlation
+	// sent are the parts of the requests so far, whose values aren't dynamic
+	sent []string
+	// cookies are the cookies set by the responses so far
+	cookies map[string]bool
+	// jarCookies are the cookies of the requests that the cookie jar of k6
+	// sends, because a previous response set them
+	jarCookies map[*Entry]map[string]bool
+	// occurrences are the correlated values in the parts of the requests
+	occurrences  map[*Entry]map[string][]occurrence
+	names        map[string]bool
+	correlations []*correlation
+}
+
+func newCorrelator() *correlator {
+	return &correlator{
+		labels:      make(map[*Entry]string),
+		candidates:  make(map[string]*correlation),
+		cookies:     make(map[string]bool),
+		jarCookies:  make(map[*Entry]map[string]bool),
+		occurrences: make(map[*Entry]map[string][]occurrence),
+		names:       make(map[string]bool),
+	}
+}
+
+// analyze finds the correlated values of the entries, in the order of the script
+func (c *correlator) analyze(entries []*Entry, labels []string) {
+	for i, e := range entries {
+		c.labels[e] = labels[i]
+	}
+	for _, e := range entries {
+		c.findUses(e)
+		// values are only dynamic if they weren't sent before they were received
+		for _, part := range requestParts(e.Request) {
+			c.sent = append(c.sent, part)
+		}
+		c.addCandidates(e)
+	}
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// requestParts returns the parts of a request where correlated values can be used
+func requestParts(req *Request) map[string]string {
+	parts := map[string]string{"URL": req.URL}
+	// the same headers as the ones of the script
+	ignored := map[string]bool{"cookie": true, "content-length": true}
+	for _, h := range req.Headers {
+		name := strings.ToLower(h.Name)
+		if name != "" && !ignored[name] && name[0] != ':' {
+			ignored[name] = true
+			parts["header "+h.Name] = h.Value
+		}
+	}
+	for _, cookie := range req.Cookies {
+		parts["cookie "+cookie.Name] = cookie.Value
+	}
+	if req.PostData != nil {
+		parts["body"] = req.PostData.Text
+	}
+	return parts
+}
+
+// findUses records where the candidates are used in the request
+func (c *correlator) findUses(e *Entry) {
+	parts := requestParts(e.Request)
+	names := make([]string, 0, len(parts))
+	for name := range parts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		part := parts[name]
+		if cookie := strings.TrimPrefix(name, "cookie "); cookie != name && c.cookies[cookie+"="+part] {
+			if c.jarCookies[e] == nil {
+				c.jarCookies[e] = make(map[string]bool)
+			}
+			c.jarCookies[e][cookie] = true
+			continue
+		}
+		occurrences := c.find(part)
+		if len(occurrences) == 0 {
+			continue
+		}
+		if c.occurrences[e] == nil {
+			c.occurrences[e] = make(map[string][]occurrence)
+		}
+		c.occurrences[e][name] = occurrences
+		for _, o := range occurrences {
+			if len(o.candidate.uses) == 0 {
+				c.correlations = append(c.correlations, o.candidate)
+				o.candidate.name = c.uniqueName(o.candidate.name)
+			}
+			if n := len(o.candidate.uses); n == 0 || o.candidate.uses[n-1] != (use{name, e}) {
+				o.candidate.uses = append(o.candidate.uses, use{name, e})
+			}
+		}
+	}
+}
+
+func (c *correlator) uniqueName(name string) string {
+	unique := name
+	for i := 2; c.names[unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", name, i)
+	}
+	c.names[unique] = true
+	return unique
+}
+
+// occurrence is a candidate found in a text
+type occurrence struct {
+	start, end int
+	candidate  *correlation
+	// encoded is set when the value is URL-encoded in the text
+	encoded bool
+}
+
+// isWordRune returns whether the rune is part of a word, as opposed to a
+// delimiter such as punctuation or whitespace
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// atBoundaries returns whether the match of form at text[start:end] isn't
+// part of a longer word, e.g. 1000 in 10001, so that only whole tokens match
+func atBoundaries(text, form string, start, end int) bool {
+	if start > 0 {
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		first, _ := utf8.DecodeRuneInString(form)
+		if isWordRune(before) && isWordRune(first) {
+			return false
+		}
+	}
+	if end < len(text) {
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		last, _ := utf8.DecodeLastRuneInString(form)
+		if isWordRune(after) && isWordRune(last) {
+			return false
+		}
+	}
+	return true
+}
+
+// indexToken returns the index of the first match of form in text from start
+// on that's at boundaries, or -1 if there isn't one
+func indexToken(text, form string, start int) int {
+	for start <= len(text) {
+		j := strings.Index(text[start:], form)
+		if j == -1 {
+			return -1
+		}
+		if atBoundaries(text, form, start+j, start+j+len(form)) {
+			return start + j
+		}
+		start += j + 1
+	}
+	return -1
+}
+
+// keyOf returns the key that the value at text[start:] is assigned to, as in
+// "key": value, "key": "value" or key=value, if there is one
+func keyOf(text string, start int) (string, bool) {
+	before := strings.TrimRightFunc(strings.TrimSuffix(text[:start], `"`), unicode.IsSpace)
+	if !strings.HasSuffix(before, ":") && !strings.HasSuffix(before, "=") {
+		return "", false
+	}
+	before = strings.TrimRightFunc(before[:len(before)-1], unicode.IsSpace)
+	if quoted := strings.TrimSuffix(before, `"`); quoted != before {
+		return quoted[strings.LastIndex(quoted, `"`)+1:], true
+	}
+	key := before[strings.LastIndexAny(before, "?&;,{ \t\r\n")+1:]
+	if unescaped, err := url.QueryUnescape(key); err == nil {
+		key = unescaped
+	}
+	return key, true
+}
+
+// find returns the occurrences of the candidates in the text, preferring
+// the longest ones when they overlap. Numbers are too likely to be equal by
+// chance, e.g. a price and a subtotal, so they are skipped where they are
+// the value of a different key than the one they had in the response.
+func (c *correlator) find(text string) []occurrence {
+	var all []occurrence
+	for value, candidate := range c.candidates {
+		forms := []string{value}
+		if encoded := url.QueryEscape(value); encoded != value {
+			forms = append(forms, encoded)
+		}
+		isNumber := numberRe.MatchString(value)
+		for i, form := range forms {
+			for start := indexToken(text, form, 0); start != -1; start = indexToken(text, form, start+len(form)) {
+				if key, ok := keyOf(text, start); isNumber && ok && key != candidate.key {
+					continue
+				}
+				all = append(all, occurrence{start, start + len(form), candidate, i == 1})
+			}
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].start != all[j].start {
+			return all[i].start < all[j].start
+		}
+		return all[i].end > all[j].end
+	})
+	var result []occurrence
+	end := 0
+	for _, o := range all {
+		if o.start >= end {
+			result = append(result, o)
+			end = o.end
+		}
+	}
+	return result
+}
+
+// expr returns the JS expression of a part of the request of the entry, with
+// the correlated values replaced, which is a template literal when there are any
+func (c *correlator) expr(e *Entry, part, text string) string {
+	var occurrences []occurrence
+	if c != nil {
+		occurrences = c.occurrences[e][part]
+	}
+	if len(occurrences) == 0 {
+		return strconv.Quote(text)
+	}
+	var b strings.Builder
+	b.WriteString("`")
+	last := 0
+	for _, o := range occurrences {
+		b.WriteString(templateEscape(text[last:o.start]))
+		if o.encoded {
+			fmt.Fprintf(&b, "${encodeURIComponent(vars[%q])}", o.candidate.name)
+		} else {
+			fmt.Fprintf(&b, "${vars[%q]}", o.candidate.name)
+		}
+		last = o.end
+	}
+	b.WriteString(templateEscape(text[last:]))
+	b.WriteString("`")
+	return b.String()
+}
+
+// templateEscape escapes text for a JS template literal
+func templateEscape(text string) string {
+	var b strings.Builder
+	for i, r := range text {
+		switch {
+		case r == '`' || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r == '$' && strings.HasPrefix(text[i:], "${"):
+			b.WriteString("\\$")
+		case r == '\n':
+			b.WriteString("\\n")
+		case r == '\r':
+			b.WriteString("\\r")
+		case r == '\t':
+			b.WriteString("\\t")
+		case r < 0x20 || r == 0x2028 || r == 0x2029:
+			fmt.Fprintf(&b, "\\u%04x", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// sendsCookie returns whether the cookie of the request of the entry is sent
+// by the cookie jar of k6, because a previous response set it
+func (c *correlator) sendsCookie(e *Entry, name string) bool {
+	return c != nil && c.jarCookies[e][name]
+}
+
+// extractions returns the code that extracts the correlated values of the
+// response of the entry
+func (c *correlator) extractions(e *Entry) []string {
+	if c == nil {
+		return nil
+	}
+	var result []string
+	for _, corr := range c.correlations {
+		if corr.entry == e {
+			result = append(result, fmt.Sprintf("vars[%q] = %s;", corr.name, corr.extract))
+		}
+	}
+	return result
+}
+
+// describeUses describes the uses of a correlated value, with the requests
+// grouped by the part where it's used
+func (c *correlator) describeUses(uses []use) string {
+	var parts []string
+	requests := make(map[string][]string)
+	for _, u := range uses {
+		if _, ok := requests[u.part]; !ok {
+			parts = append(parts, u.part)
+		}
+		requests[u.part] = appendUnique(requests[u.part], c.labels[u.entry])
+	}
+	descriptions := make([]string, len(parts))
+	for i, part := range parts {
+		descriptions[i] = fmt.Sprintf("the %s of %s", part, strings.Join(requests[part], ", "))
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+// report describes the correlated values, for a comment at the start of the script
+func (c *correlator) report() []string {
+	if c == nil {
+		return nil
+	}
+	var result []string
+	for _, corr := range c.correlations {
+		result = append(result, fmt.Sprintf("vars[%q]: %s of the response to %s, used in %s",
+			corr.name, corr.source, c.labels[corr.entry], c.describeUses(corr.uses)))
+	}
+	var jarCookies []string
+	for _, cookies := range c.jarCookies {
+		for name := range cookies {
+			jarCookies = appendUnique(jarCookies, name)
+		}
+	}
+	if len(jarCookies) > 0 {
+		sort.Strings(jarCookies)
+		noun := "cookie"
+		if len(jarCookies) > 1 {
+			noun = "cookies"
+		}
+		result = append(result, fmt.Sprintf("the cookie jar sends the %s %s set by previous responses",
+			noun, strings.Join(jarCookies, ", ")))
+	}
+	return result
+}
+
+//nolint:gochecknoglobals
+var (
+	// ignoredResponseHeaders are the response headers without values that can be correlated
+	ignoredResponseHeaders = map[string]bool{
+		"Accept-Ranges": true, "Access-Control-Allow-Credentials": true, "Access-Control-Allow-Headers": true,
+		"Access-Control-Allow-Methods": true, "Access-Control-Allow-Origin": true, "Access-Control-Expose-Headers": true,
+		"Access-Control-Max-Age": true, "Age": true, "Alt-Svc": true, "Cache-Control": true, "Connection": true,
+		"Content-Encoding": true, "Content-Language": true, "Content-Length": true, "Content-Security-Policy": true,
+		"Content-Type": true, "Date": true, "Etag": true, "Expires": true, "Keep-Alive": true, "Last-Modified": true,
+		"Link": true, "Location": true, "Nel": true, "P3p": true, "Pragma": true, "Referrer-Policy": true,
+		"Report-To": true, "Server": true, "Server-Timing": true, "Set-Cookie": true,
+		"Strict-Transport-Security": true, "Transfer-Encoding": true, "Vary": true, "Via": true,
+		"X-Content-Type-Options": true, "X-Frame-Options": true, "X-Powered-By": true, "X-Xss-Protection": true,
+	}
+
+	nonNameRe = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+	// staticRe matches the values that look like the constants of an API,
+	// such as enum values and field names, instead of dynamic ones
+	staticRe = regexp.MustCompile(`^(?:[a-z]+(?:[._-][a-z]+)*|[A-Z]+(?:[._-][A-Z]+)*)$`)
+
+	// numberRe matches the numeric values, such as amounts and numeric IDs
+	numberRe = regexp.MustCompile(`^-?[0-9]+(?:\.[0-9]+)?$`)
+)
+
+// variableName returns the name of a correlated value, from the name of its source
+func variableName(name string) string {
+	name = strings.Trim(nonNameRe.ReplaceAllString(name, "_"), "_")
+	if name == "" {
+		return "value"
+	}
+	return strings.ToLower(name)
+}
+
+// addCandidate adds a value of a response, unless it was sent before or
+// another response had it before, or it looks static, e.g. "credit_card" or
+// "customer.type", since those are the same every time
+func (c *correlator) addCandidate(e *Entry, value, name, source, extract string) {
+	if len(value) < minCorrelatedLength || staticRe.MatchString(value) {
+		return
+	}
+	if _, ok := c.candidates[value]; ok {
+		return
+	}
+	for _, part := range c.sent {
+		if indexToken(part, value, 0) != -1 {
+			return
+		}
+	}
+	c.candidates[value] = &correlation{
+		name:    variableName(name),
+		value:   value,
+		key:     name,
+		entry:   e,
+		source:  source,
+		extract: extract,
+	}
+}
+
+// addCandidates adds the values of the response of the entry
+func (c *correlator) addCandidates(e *Entry) {
+	res := e.Response
+	if res == nil {
+		return
+	}
+
+	// redirects, and the parameters in them, as in OAuth flows
+	location := res.RedirectURL
+	for _, h := range res.Headers {
+		if strings.EqualFold(h.Name, "Location") {
+			location = h.Value
+		}
+	}
+	if u, err := url.Parse(location); err == nil && u.IsAbs() {
+		c.addCandidate(e, location, "location", "the Location header", "res.headers.Location")
+		for _, param := range strings.Split(u.RawQuery, "&") {
+			kv := strings.SplitN(param, "=", 2)
+			if len(kv) == 2 {
+				c.addCandidate(e, kv[1], kv[0], fmt.Sprintf("the %q parameter of the Location header", kv[0]),
+					fmt.Sprintf("res.headers.Location.match(/[?&]%s=([^&#]*)/)[1]", regexp.QuoteMeta(kv[0])))
+			}
+		}
+	}
+
+	for _, h := range res.Headers {
+		name := textproto.CanonicalMIMEHeaderKey(h.Name)
+		if h.Name == "" || h.Name[0] == ':' || ignoredResponseHeaders[name] {
+			continue
+		}
+		c.addCandidate(e, h.Value, name, fmt.Sprintf("the %s header", name), fmt.Sprintf("res.headers[%q]", name))
+	}
+
+	cookies := res.Cookies
+	if len(cookies) == 0 {
+		header := http.Header{}
+		for _, h := range res.Headers {
+			if strings.EqualFold(h.Name, "Set-Cookie") {
+				header.Add("Set-Cookie", h.Value)
+			}
+		}
+		for _, cookie := range (&http.Response{Header: header}).Cookies() {
+			cookies = append(cookies, Cookie{Name: cookie.Name, Value: cookie.Value})
+		}
+	}
+	for _, cookie := range cookies {
+		c.cookies[cookie.Name+"="+cookie.Value] = true
+		c.addCandidate(e, cookie.Value, cookie.Name, fmt.Sprintf("the %s cookie", cookie.Name),
+			fmt.Sprintf("res.cookies[%q][0].value", cookie.Name))
+	}
+
+	if res.Content == nil || res.Content.Text == "" {
+		return
+	}
+	text := res.Content.Text
+	if res.Content.Encoding == "base64" {
+		data, err := base64.StdEncoding.DecodeString(text)
+		if err != nil {
+			return
+		}
+		text = string(data)
+	}
+	mimeType := strings.ToLower(res.Content.MimeType)
+	switch {
+	case strings.Contains(mimeType, "json"):
+		var body interface{}
+		if err := json.Unmarshal([]byte(text), &body); err == nil {
+			c.addJSONCandidates(e, body, nil)
+		}
+	case strings.Contains(mimeType, "html"):
+		c.addHTMLCandidates(e, text)
+	}
+}
+
+// addJSONCandidates adds the leaf values of a JSON body, with their gjson paths
+func (c *correlator) addJSONCandidates(e *Entry, value interface{}, path []string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			c.addJSONCandidates(e, v[key], append(append([]string{}, path...), key))
+		}
+	case []interface{}:
+		for i, item := range v {
+			c.addJSONCandidates(e, item, append(append([]string{}, path...), strconv.Itoa(i)))
+		}
+	case string, float64:
+		if len(path) == 0 {
+			return
+		}
+		escaped := make([]string, len(path))
+		for i, key := range path {
+			escaped[i] = strings.NewReplacer(`\`, `\\`, ".", `\.`, "*", `\*`, "?", `\?`, "|", `\|`, "#", `\#`).Replace(key)
+		}
+		gjsonPath := strings.Join(escaped, ".")
+		formatted := fmt.Sprint(v)
+		if f, ok := v.(float64); ok {
+			formatted = strconv.FormatFloat(f, 'f', -1, 64)
+		}
+		name := path[len(path)-1]
+		for i := len(path) - 1; i > 0 && isIndex(name); i-- {
+			name = path[i-1]
+		}
+		c.addCandidate(e, formatted, name, fmt.Sprintf("the JSON value %s", gjsonPath),
+			fmt.Sprintf("res.json(%q)", gjsonPath))
+	}
+}
+
+func isIndex(key string) bool {
+	_, err := strconv.Atoi(key)
+	return err == nil
+}
+
+// addHTMLCandidates adds the values of the hidden form fields and the meta
+// tags of an HTML body, which are where CSRF tokens usually are
+func (c *correlator) addHTMLCandidates(e *Entry, text string) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(text))
+	if err != nil {
+		return
+	}
+	doc.Find(`input[type="hidden"][name]`).Each(func(_ int, s *goquery.Selection) {
+		name, _ := s.Attr("name")
+		value, _ := s.Attr("value")
+		selector := fmt.Sprintf("input[name=%q]", name)
+		c.addCandidate(e, value, name, fmt.Sprintf("the hidden form field %s", name),
+			fmt.Sprintf("res.html().find(%q).first().attr(\"value\")", selector))
+	})
+	doc.Find(`meta[name][content]`).Each(func(_ int, s *goquery.Selection) {
+		name, _ := s.Attr("name")
+		value, _ := s.Attr("content")
+		selector := fmt.Sprintf("meta[name=%q]", name)
+		c.addCandidate(e, value, name, fmt.Sprintf("the %s meta tag", name),
+			fmt.Sprintf("res.html().find(%q).first().attr(\"content\")", selector))
+	})
+}
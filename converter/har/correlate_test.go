@@ -0,0 +1,210 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package har
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loadimpact/k6/js"
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/testutils"
+	"github.com/loadimpact/k6/loader"
+)
+
+func correlateScript(t *testing.T, entries ...*Entry) string {
+	t.Helper()
+	script, err := Convert(HAR{Log: &Log{Creator: &Creator{Name: "test"}, Entries: entries}}, lib.Options{}, 0, 0, false, false, 0, true, true, nil, nil)
+	require.NoError(t, err)
+	_, err = js.New(testutils.NewLogger(t), &loader.SourceData{
+		URL:  &url.URL{Path: "/script.js", Scheme: "file"},
+		Data: []byte(script),
+	}, nil, lib.RuntimeOptions{})
+	require.NoError(t, err, script)
+	return script
+}
+
+func entry(method, u string, headers []Header, body string, res *Response) *Entry {
+	e := &Entry{Request: &Request{Method: method, URL: u, Headers: headers}, Response: res}
+	if body != "" {
+		e.Request.PostData = &PostData{MimeType: "application/x-www-form-urlencoded", Text: body}
+	}
+	if e.Response == nil {
+		e.Response = &Response{Status: 200, Content: &Content{}}
+	}
+	return e
+}
+
+func TestCorrelate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Location", func(t *testing.T) {
+		t.Parallel()
+		script := correlateScript(t,
+			entry("POST", "https://example.com/login", nil, "user=admin", &Response{
+				Status:  302,
+				Headers: []Header{{"Location", "https://example.com/home?session=4f2a9c81"}},
+				Content: &Content{},
+			}),
+			entry("GET", "https://example.com/home?session=4f2a9c81", nil, "", nil),
+			entry("GET", "https://example.com/profile?session=4f2a9c81&tab=info", nil, "", nil),
+		)
+		assert.Contains(t, script, `vars["location"] = res.headers.Location;`)
+		assert.Contains(t, script, `vars["session"] = res.headers.Location.match(/[?&]session=([^&#]*)/)[1];`)
+		assert.Contains(t, script, "res = http.get(`${vars[\"location\"]}`")
+		assert.Contains(t, script, "res = http.get(`https://example.com/profile?session=${vars[\"session\"]}&tab=info`")
+		assert.Contains(t, script, "// Correlated values:\n")
+	})
+
+	t.Run("HeaderAndJSON", func(t *testing.T) {
+		t.Parallel()
+		script := correlateScript(t,
+			entry("POST", "https://example.com/auth", nil, "", &Response{
+				Status: 200,
+				Content: &Content{
+					MimeType: "application/json",
+					Text:     `{"data": {"token": "eyJhbGciOi.xyz", "items": [{"id": "a1b2c3d4"}]}}`,
+				},
+			}),
+			entry("GET", "https://example.com/items/a1b2c3d4",
+				[]Header{{"Authorization", "Bearer eyJhbGciOi.xyz"}}, "", nil),
+		)
+		assert.Contains(t, script, `vars["token"] = res.json("data.token");`)
+		assert.Contains(t, script, `vars["id"] = res.json("data.items.0.id");`)
+		assert.Contains(t, script, "\"Authorization\": `Bearer ${vars[\"token\"]}`")
+		assert.Contains(t, script, "res = http.get(`https://example.com/items/${vars[\"id\"]}`")
+	})
+
+	t.Run("HiddenFormField", func(t *testing.T) {
+		t.Parallel()
+		script := correlateScript(t,
+			entry("GET", "https://example.com/form", nil, "", &Response{
+				Status: 200,
+				Content: &Content{
+					MimeType: "text/html",
+					Text: `<html><head><meta name="csrf-token" content="k9Xq7Lm2"></head><body><form>` +
+						`<input type="hidden" name="csrfmiddlewaretoken" value="Zk8/3p+q=="></form></body></html>`,
+				},
+			}),
+			entry("POST", "https://example.com/form", []Header{{"X-CSRF-Token", "k9Xq7Lm2"}},
+				"csrfmiddlewaretoken=Zk8%2F3p%2Bq%3D%3D&name=test", nil),
+		)
+		assert.Contains(t, script,
+			`vars["csrfmiddlewaretoken"] = res.html().find("input[name=\"csrfmiddlewaretoken\"]").first().attr("value");`)
+		assert.Contains(t, script,
+			`vars["csrf_token"] = res.html().find("meta[name=\"csrf-token\"]").first().attr("content");`)
+		assert.Contains(t, script, "\"X-CSRF-Token\": `${vars[\"csrf_token\"]}`")
+		assert.Contains(t, script, "${encodeURIComponent(vars[\"csrfmiddlewaretoken\"])}&name=test")
+	})
+
+	t.Run("Cookies", func(t *testing.T) {
+		t.Parallel()
+		script := correlateScript(t,
+			entry("GET", "https://example.com/", nil, "", &Response{
+				Status:  200,
+				Headers: []Header{{"Set-Cookie", "sid=8d7f6e5c; Path=/"}},
+				Content: &Content{},
+			}),
+			&Entry{
+				Request: &Request{
+					Method:  "GET",
+					URL:     "https://example.com/account",
+					Cookies: []Cookie{{Name: "sid", Value: "8d7f6e5c"}, {Name: "theme", Value: "dark"}},
+				},
+				Response: &Response{Status: 200, Content: &Content{}},
+			},
+		)
+		// the cookie jar already sends the cookies set by the responses
+		assert.NotContains(t, script, `vars["sid"]`)
+		assert.NotContains(t, script, `"sid": "8d7f6e5c"`)
+		assert.Contains(t, script, `"theme": "dark"`)
+		assert.Contains(t, script, "the cookie jar sends the cookie sid")
+	})
+
+	t.Run("StaticValues", func(t *testing.T) {
+		t.Parallel()
+		script := correlateScript(t,
+			entry("POST", "https://example.com/search", nil, "q=shoes", &Response{
+				Status:  200,
+				Content: &Content{MimeType: "application/json", Text: `{"q": "shoes", "page": true}`},
+			}),
+			entry("GET", "https://example.com/search?q=shoes", nil, "", nil),
+		)
+		// values that were already sent aren't correlated
+		assert.NotContains(t, script, "vars[")
+		assert.NotContains(t, script, "Correlated values")
+	})
+
+	t.Run("Boundaries", func(t *testing.T) {
+		t.Parallel()
+		script := correlateScript(t,
+			entry("GET", "https://example.com/orders", nil, "", &Response{
+				Status:  200,
+				Content: &Content{MimeType: "application/json", Text: `{"id": 1000, "ref": "ab12"}`},
+			}),
+			entry("GET", "https://example.com/orders/10001?ref=xab12", nil, "", nil),
+			entry("GET", "https://example.com/orders/1000?ref=ab12", nil, "", nil),
+		)
+		// values are only replaced when they aren't part of a longer token
+		assert.Contains(t, script, "res = http.get(\"https://example.com/orders/10001?ref=xab12\"")
+		assert.Contains(t, script, "res = http.get(`https://example.com/orders/${vars[\"id\"]}?ref=${vars[\"ref\"]}`")
+	})
+
+	t.Run("NumbersByKey", func(t *testing.T) {
+		t.Parallel()
+		script := correlateScript(t,
+			entry("GET", "https://example.com/cart", nil, "", &Response{
+				Status:  200,
+				Content: &Content{MimeType: "application/json", Text: `{"order_id": 73916, "total": 15072}`},
+			}),
+			entry("POST", "https://example.com/orders/73916", nil, `{"subtotal": 15072, "total": 15072}`, nil),
+		)
+		// numbers are only replaced where they aren't the values of other keys
+		assert.Contains(t, script, "res = http.post(`https://example.com/orders/${vars[\"order_id\"]}`")
+		assert.Contains(t, script, "`{\"subtotal\": 15072, \"total\": ${vars[\"total\"]}}`")
+	})
+
+	t.Run("EnumValues", func(t *testing.T) {
+		t.Parallel()
+		script := correlateScript(t,
+			entry("GET", "https://example.com/checkout", nil, "", &Response{
+				Status: 200,
+				Content: &Content{MimeType: "application/json", Text: `{"type": "credit_card", ` +
+					`"cards": ["VISA", "MASTER"], "required_fields": ["customer.given_name"], "token": "f3a9c2e1"}`},
+			}),
+			entry("POST", "https://example.com/checkout", nil,
+				"type=credit_card&card=VISA&fields=customer.given_name&token=f3a9c2e1", nil),
+		)
+		// constants of the API aren't correlated, only the dynamic values
+		assert.Contains(t, script, `vars["token"] = res.json("token");`)
+		assert.NotContains(t, script, `vars["type"]`)
+		assert.NotContains(t, script, `vars["cards"]`)
+		assert.NotContains(t, script, `vars["required_fields"]`)
+	})
+}
+
+func TestTemplateEscape(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "a\\`b\\\\c\\${d}\\n\\u2028", templateEscape("a`b\\c${d}\n "))
+}
@@ -0,0 +1,78 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package printer contains the helpers that the converters use to write the
+// generated scripts.
+package printer
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/loadimpact/k6/lib"
+)
+
+// ScriptOptions are the options of the generated scripts that all of the
+// converters have in common, they are embedded in the options of each one.
+type ScriptOptions struct {
+	// Options are the k6 options of the generated script
+	Options lib.Options
+	// MinSleep and MaxSleep are the bounds of the random sleep after each iteration, in seconds
+	MinSleep, MaxSleep uint
+	// EnableChecks adds checks for the expected status codes of the requests
+	EnableChecks bool
+	// ReturnOnFailedCheck returns from the iteration if a check fails
+	ReturnOnFailedCheck bool
+}
+
+// Validate returns an error if the options contradict each other.
+func (o ScriptOptions) Validate() error {
+	if o.ReturnOnFailedCheck && !o.EnableChecks {
+		return errors.Errorf("return on failed check requires --enable-status-code-checks")
+	}
+	if o.MaxSleep < o.MinSleep {
+		return errors.Errorf("the maximum sleep can't be less than the minimum sleep")
+	}
+	return nil
+}
+
+// Fprint panics when there's an error writing to the supplied io.Writer,
+// since this will be used on in-memory expandable buffers, that should
+// happen only when we run out of memory...
+func Fprint(w io.Writer, a ...interface{}) int {
+	n, err := fmt.Fprint(w, a...)
+	if err != nil {
+		panic(err.Error())
+	}
+	return n
+}
+
+// Fprintf panics when there's an error writing to the supplied io.Writer,
+// since this will be used on in-memory expandable buffers, that should
+// happen only when we run out of memory...
+func Fprintf(w io.Writer, format string, a ...interface{}) int {
+	n, err := fmt.Fprintf(w, format, a...)
+	if err != nil {
+		panic(err.Error())
+	}
+	return n
+}
@@ -0,0 +1,701 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package openapi converts OpenAPI 3 and Swagger 2.0 specifications to k6 scripts.
+package openapi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/loadimpact/k6/converter/internal/printer"
+)
+
+// Options are the options of the conversion. The checks are for the documented
+// status codes of the operations.
+type Options struct {
+	printer.ScriptOptions
+	// BaseURL replaces the URL of the first server in the specification
+	BaseURL string
+	// NoBatch makes the requests of every group one after another instead of with http.batch()
+	NoBatch bool
+}
+
+// methods are the HTTP methods of the operations, in the order that they're
+// requested in, so resources are created before they are read, updated and deleted
+//
+//nolint:gochecknoglobals
+var methods = []string{"POST", "GET", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+
+func (item *PathItem) operation(method string) *Operation {
+	switch method {
+	case "GET":
+		return item.Get
+	case "PUT":
+		return item.Put
+	case "POST":
+		return item.Post
+	case "DELETE":
+		return item.Delete
+	case "OPTIONS":
+		return item.Options
+	case "HEAD":
+		return item.Head
+	case "PATCH":
+		return item.Patch
+	default:
+		return nil
+	}
+}
+
+// request is a request of the generated script, with JS expressions as values
+type request struct {
+	comment string
+	method  string
+	url     string
+	body    string
+	headers []string
+	cookies []string
+	check   *statusCheck
+}
+
+// statusCheck is the check for the documented status codes of an operation
+type statusCheck struct {
+	name      string
+	condition string
+}
+
+// group holds the requests of the operations with the same tag
+type group struct {
+	name     string
+	requests []*request
+}
+
+// converter holds the state of a single conversion
+type converter struct {
+	resolver
+	opts Options
+	// schemes are the used security schemes, by their constant prefix
+	schemes     map[string]*SecurityScheme
+	schemeNames map[string]string
+}
+
+// Convert generates a k6 script with a request for each operation in the
+// specification, grouped by their first tags.
+func Convert(spec *Spec, opts Options) (result string, convertErr error) {
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+
+	c := &converter{
+		resolver:    resolver{spec: spec},
+		opts:        opts,
+		schemes:     make(map[string]*SecurityScheme),
+		schemeNames: make(map[string]string),
+	}
+	groups, err := c.groups()
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	w := bufio.NewWriter(&b)
+
+	if opts.EnableChecks {
+		printer.Fprint(w, "import { group, check, sleep } from 'k6';\n")
+	} else {
+		printer.Fprint(w, "import { group, sleep } from 'k6';\n")
+	}
+	printer.Fprint(w, "import http from 'k6/http';\n")
+	for _, scheme := range c.schemes {
+		if scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "basic") {
+			printer.Fprint(w, "import encoding from 'k6/encoding';\n")
+			break
+		}
+	}
+	printer.Fprint(w, "\n")
+
+	printer.Fprintf(w, "// Title: %v\n", spec.Info.Title)
+	printer.Fprintf(w, "// Version: %v\n", spec.Info.Version)
+	printer.Fprintf(w, "// OpenAPI: %v\n", spec.OpenAPI)
+
+	printer.Fprint(w, "\nexport let options = {\n")
+	opts.Options.ForEachSpecified("json", func(key string, val interface{}) {
+		if valJSON, err := json.MarshalIndent(val, "    ", "    "); err != nil {
+			convertErr = err
+		} else {
+			printer.Fprintf(w, "    %s: %s,\n", key, valJSON)
+		}
+	})
+	if convertErr != nil {
+		return "", convertErr
+	}
+	printer.Fprint(w, "};\n\n")
+
+	printer.Fprintf(w, "const BASE_URL = __ENV.BASE_URL || %q;\n", c.baseURL())
+	c.writeCredentials(w)
+
+	printer.Fprint(w, "\nexport default function() {\n\n")
+	for _, g := range groups {
+		printer.Fprintf(w, "\tgroup(%q, function() {\n", g.name)
+		if opts.NoBatch {
+			c.writeRequests(w, g.requests)
+		} else {
+			c.writeBatch(w, g.requests)
+		}
+		printer.Fprint(w, "\t});\n")
+	}
+	printer.Fprintf(w, "\n\t// Random sleep between %ds and %ds\n", opts.MinSleep, opts.MaxSleep)
+	printer.Fprintf(w, "\tsleep(Math.floor(Math.random()*%d+%d));\n", opts.MaxSleep-opts.MinSleep, opts.MinSleep)
+	printer.Fprint(w, "}\n")
+
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// baseURL returns the URL of the first server, with the default values of its variables
+func (c *converter) baseURL() string {
+	if c.opts.BaseURL != "" {
+		return strings.TrimSuffix(c.opts.BaseURL, "/")
+	}
+	if len(c.spec.Servers) == 0 {
+		return ""
+	}
+	server := c.spec.Servers[0]
+	u := server.URL
+	for name, variable := range server.Variables {
+		u = strings.Replace(u, "{"+name+"}", variable.Default, -1)
+	}
+	return strings.TrimSuffix(u, "/")
+}
+
+// groups returns the requests of all operations grouped by their first tag,
+// in the order of the tags in the specification
+func (c *converter) groups() ([]*group, error) {
+	paths := make([]string, 0, len(c.spec.Paths))
+	for path := range c.spec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	untagged := c.spec.Info.Title
+	if untagged == "" {
+		untagged = "default"
+	}
+	var groups []*group
+	groupsByName := make(map[string]*group)
+	getGroup := func(name string) *group {
+		g, ok := groupsByName[name]
+		if !ok {
+			g = &group{name: name}
+			groupsByName[name] = g
+			groups = append(groups, g)
+		}
+		return g
+	}
+	for _, tag := range c.spec.Tags {
+		getGroup(tag.Name)
+	}
+
+	for _, path := range paths {
+		item := c.spec.Paths[path]
+		if item.Ref != "" {
+			return nil, errors.Errorf("the path %s has an unsupported reference %s", path, item.Ref)
+		}
+		for _, method := range methods {
+			op := item.operation(method)
+			if op == nil {
+				continue
+			}
+			r, err := c.request(method, path, &item, op)
+			if err != nil {
+				return nil, errors.Wrapf(err, "couldn't convert %s %s", method, path)
+			}
+			name := untagged
+			if len(op.Tags) > 0 {
+				name = op.Tags[0]
+			}
+			g := getGroup(name)
+			g.requests = append(g.requests, r)
+		}
+	}
+
+	// tags without operations
+	result := groups[:0]
+	for _, g := range groups {
+		if len(g.requests) > 0 {
+			result = append(result, g)
+		}
+	}
+	return result, nil
+}
+
+// parameters returns the parameters of the path item and of the operation,
+// which override the ones of the path item
+func (c *converter) parameters(item *PathItem, op *Operation) []*Parameter {
+	var result []*Parameter
+	index := make(map[string]int)
+	for _, p := range append(append([]*Parameter{}, item.Parameters...), op.Parameters...) {
+		if p = c.parameter(p); p == nil {
+			continue
+		}
+		key := p.In + ":" + p.Name
+		if i, ok := index[key]; ok {
+			result[i] = p
+			continue
+		}
+		index[key] = len(result)
+		result = append(result, p)
+	}
+	return result
+}
+
+// parameterValue returns an example value of the parameter
+func (c *converter) parameterValue(p *Parameter) interface{} {
+	if value, ok := c.firstExample(p.Example, p.Examples); ok {
+		return value
+	}
+	if p.Schema == nil && len(p.Content) > 0 {
+		// parameters with a content only have a single media type
+		var mediaType *MediaType
+		for _, m := range p.Content {
+			mediaType = m
+		}
+		if mediaType == nil {
+			return nil
+		}
+		if value, ok := c.firstExample(mediaType.Example, mediaType.Examples); ok {
+			return value
+		}
+		return c.exampleValue(mediaType.Schema)
+	}
+	return c.exampleValue(p.Schema)
+}
+
+// formatValue formats a parameter value for a URL or a header
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}, map[string]interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+//nolint:gochecknoglobals
+var pathParameterRe = regexp.MustCompile(`{([^}]+)}`)
+
+func (c *converter) request(method, path string, item *PathItem, op *Operation) (*request, error) {
+	r := &request{method: method, comment: method + " " + path}
+	if op.OperationID != "" {
+		r.comment += " (" + op.OperationID + ")"
+	}
+	if op.Summary != "" {
+		r.comment += " - " + op.Summary
+	}
+
+	pathValues := make(map[string]string)
+	query := url.Values{}
+	var queryNames []string
+	for _, p := range c.parameters(item, op) {
+		// optional parameters are only sent if they have examples
+		if !p.Required && p.In != "path" && p.Example == nil && len(p.Examples) == 0 &&
+			(c.schema(p.Schema) == nil || c.schema(p.Schema).Example == nil) {
+			continue
+		}
+		value := c.parameterValue(p)
+		switch p.In {
+		case "path":
+			pathValues[p.Name] = url.PathEscape(formatValue(value))
+		case "query":
+			if _, ok := query[p.Name]; !ok {
+				queryNames = append(queryNames, p.Name)
+			}
+			if values, ok := value.([]interface{}); ok {
+				for _, v := range values {
+					query.Add(p.Name, formatValue(v))
+				}
+			} else {
+				query.Add(p.Name, formatValue(value))
+			}
+		case "header":
+			r.headers = append(r.headers, fmt.Sprintf("%q: %q", p.Name, formatValue(value)))
+		case "cookie":
+			r.cookies = append(r.cookies, fmt.Sprintf("%q: %q", p.Name, formatValue(value)))
+		}
+	}
+
+	u := pathParameterRe.ReplaceAllStringFunc(path, func(match string) string {
+		if value, ok := pathValues[match[1:len(match)-1]]; ok {
+			return value
+		}
+		return match
+	})
+	var rawQuery []string
+	for _, name := range queryNames {
+		for _, value := range query[name] {
+			rawQuery = append(rawQuery, url.QueryEscape(name)+"="+url.QueryEscape(value))
+		}
+	}
+	if len(rawQuery) > 0 {
+		u += "?" + strings.Join(rawQuery, "&")
+	}
+	r.url = fmt.Sprintf("BASE_URL + %q", u)
+
+	if err := c.addBody(r, op); err != nil {
+		return nil, err
+	}
+	c.addSecurity(r, op)
+	if c.opts.EnableChecks {
+		r.check = statusCheckFor(op)
+	}
+	return r, nil
+}
+
+// jsonContentType returns whether the content type is JSON, including the
+// ones with a +json suffix
+func jsonContentType(contentType string) bool {
+	contentType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	return contentType == "application/json" || strings.HasSuffix(contentType, "+json")
+}
+
+func (c *converter) addBody(r *request, op *Operation) error {
+	body := c.requestBody(op.RequestBody)
+	if body == nil || len(body.Content) == 0 {
+		return nil
+	}
+	contentTypes := make([]string, 0, len(body.Content))
+	for contentType := range body.Content {
+		contentTypes = append(contentTypes, contentType)
+	}
+	// JSON first, then forms, then the rest alphabetically
+	rank := func(contentType string) int {
+		switch {
+		case jsonContentType(contentType):
+			return 0
+		case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+			return 1
+		case strings.HasPrefix(contentType, "multipart/form-data"):
+			return 3
+		default:
+			return 2
+		}
+	}
+	sort.Slice(contentTypes, func(i, j int) bool {
+		if rank(contentTypes[i]) != rank(contentTypes[j]) {
+			return rank(contentTypes[i]) < rank(contentTypes[j])
+		}
+		return contentTypes[i] < contentTypes[j]
+	})
+	contentType := contentTypes[0]
+	mediaType := body.Content[contentType]
+	if mediaType == nil {
+		mediaType = &MediaType{}
+	}
+	value, ok := c.firstExample(mediaType.Example, mediaType.Examples)
+	if !ok {
+		value = c.exampleValue(mediaType.Schema)
+	}
+
+	switch rank(contentType) {
+	case 0:
+		// the JSON is indented when the request is written
+		data, err := json.MarshalIndent(value, "", "\t")
+		if err != nil {
+			return err
+		}
+		r.body = "JSON.stringify(" + string(data) + ")"
+	case 1:
+		// k6 URL-encodes object bodies
+		fields, _ := value.(map[string]interface{})
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		params := make([]string, len(names))
+		for i, name := range names {
+			params[i] = fmt.Sprintf("%q: %q", name, formatValue(fields[name]))
+		}
+		r.body = "{ " + strings.Join(params, ", ") + " }"
+		return nil
+	case 3:
+		r.comment += "\n// TODO: multipart/form-data request bodies aren't generated yet"
+		return nil
+	default:
+		if s, ok := value.(string); ok {
+			r.body = strconv.Quote(s)
+		} else {
+			data, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			r.body = strconv.Quote(string(data))
+		}
+	}
+	r.headers = append([]string{fmt.Sprintf("%q: %q", "Content-Type", contentType)}, r.headers...)
+	return nil
+}
+
+//nolint:gochecknoglobals
+var nonIdentifierRe = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// credential returns the name of the constant with a credential of the
+// security scheme, which is read from the environment variable with the same name
+func (c *converter) credential(schemeName string, scheme *SecurityScheme, suffix string) string {
+	prefix, ok := c.schemeNames[schemeName]
+	if !ok {
+		prefix = strings.Trim(strings.ToUpper(nonIdentifierRe.ReplaceAllString(schemeName, "_")), "_")
+		if prefix == "" || (prefix[0] >= '0' && prefix[0] <= '9') {
+			prefix = "AUTH_" + prefix
+		}
+		for c.schemes[prefix] != nil {
+			prefix += "_"
+		}
+		c.schemeNames[schemeName] = prefix
+		c.schemes[prefix] = scheme
+	}
+	return prefix + "_" + suffix
+}
+
+// addSecurity adds the credentials of the first security requirement of the operation
+func (c *converter) addSecurity(r *request, op *Operation) {
+	requirements := c.spec.Security
+	if op.Security != nil {
+		requirements = *op.Security
+	}
+	if len(requirements) == 0 {
+		return
+	}
+	names := make([]string, 0, len(requirements[0]))
+	for name := range requirements[0] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		scheme := c.securityScheme(name)
+		if scheme == nil {
+			continue
+		}
+		switch {
+		case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "basic"):
+			r.headers = append(r.headers, fmt.Sprintf(`"Authorization": "Basic " + encoding.b64encode(%s + ":" + %s)`,
+				c.credential(name, scheme, "USERNAME"), c.credential(name, scheme, "PASSWORD")))
+		case scheme.Type == "http":
+			authScheme := scheme.Scheme
+			if strings.EqualFold(authScheme, "bearer") || authScheme == "" {
+				authScheme = "Bearer"
+			}
+			r.headers = append(r.headers, fmt.Sprintf(`"Authorization": %q + %s`,
+				authScheme+" ", c.credential(name, scheme, "TOKEN")))
+		case scheme.Type == "oauth2" || scheme.Type == "openIdConnect":
+			r.headers = append(r.headers, fmt.Sprintf(`"Authorization": "Bearer " + %s`,
+				c.credential(name, scheme, "TOKEN")))
+		case scheme.Type == "apiKey":
+			key := c.credential(name, scheme, "API_KEY")
+			switch scheme.In {
+			case "header":
+				r.headers = append(r.headers, fmt.Sprintf("%q: %s", scheme.Name, key))
+			case "cookie":
+				r.cookies = append(r.cookies, fmt.Sprintf("%q: %s", scheme.Name, key))
+			case "query":
+				separator := "?"
+				if strings.Contains(r.url, "?") {
+					separator = "&"
+				}
+				r.url += fmt.Sprintf(" + %q + encodeURIComponent(%s)", separator+url.QueryEscape(scheme.Name)+"=", key)
+			}
+		}
+	}
+}
+
+// writeCredentials declares the constants with the credentials of the used
+// security schemes
+func (c *converter) writeCredentials(w io.Writer) {
+	names := make([]string, 0, len(c.schemeNames))
+	for name := range c.schemeNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prefix := c.schemeNames[name]
+		scheme := c.schemes[prefix]
+		var suffixes []string
+		switch {
+		case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "basic"):
+			suffixes = []string{"USERNAME", "PASSWORD"}
+		case scheme.Type == "apiKey":
+			suffixes = []string{"API_KEY"}
+		default:
+			suffixes = []string{"TOKEN"}
+		}
+		printer.Fprintf(w, "// %s (%s)\n", name, scheme.Type)
+		for _, suffix := range suffixes {
+			constant := prefix + "_" + suffix
+			printer.Fprintf(w, "const %s = __ENV.%s || \"\";\n", constant, constant)
+		}
+	}
+}
+
+// statusCheckFor returns the check for the documented successful and
+// redirection status codes of the operation, or nil if there are none
+func statusCheckFor(op *Operation) *statusCheck {
+	var codes []int
+	var ranges []int
+	for status := range op.Responses {
+		if len(status) == 3 && strings.HasSuffix(strings.ToUpper(status), "XX") && status[0] >= '1' && status[0] <= '3' {
+			ranges = append(ranges, int(status[0]-'0'))
+			continue
+		}
+		if code, err := strconv.Atoi(status); err == nil && code < 400 {
+			codes = append(codes, code)
+		}
+	}
+	if len(codes) == 0 && len(ranges) == 0 {
+		return nil
+	}
+	sort.Ints(codes)
+	sort.Ints(ranges)
+
+	var names, conditions []string
+	for _, code := range codes {
+		names = append(names, strconv.Itoa(code))
+	}
+	switch len(codes) {
+	case 0:
+	case 1:
+		conditions = append(conditions, fmt.Sprintf("r.status === %d", codes[0]))
+	default:
+		conditions = append(conditions, fmt.Sprintf("[%s].indexOf(r.status) !== -1", strings.Join(names, ", ")))
+	}
+	for _, r := range ranges {
+		names = append(names, fmt.Sprintf("%dXX", r))
+		conditions = append(conditions, fmt.Sprintf("(r.status >= %d && r.status < %d)", r*100, r*100+100))
+	}
+	return &statusCheck{
+		name:      "status is " + strings.Join(names, " or "),
+		condition: strings.Join(conditions, " || "),
+	}
+}
+
+func (c *converter) writeCheck(w io.Writer, res string, check *statusCheck) {
+	if check == nil {
+		return
+	}
+	if c.opts.ReturnOnFailedCheck {
+		printer.Fprintf(w, "\t\tif (!check(%s, {%q: (r) => %s })) { return };\n", res, check.name, check.condition)
+	} else {
+		printer.Fprintf(w, "\t\tcheck(%s, {%q: (r) => %s });\n", res, check.name, check.condition)
+	}
+}
+
+func writeComment(w io.Writer, indent, comment string) {
+	for _, line := range strings.Split(comment, "\n") {
+		if !strings.HasPrefix(line, "//") {
+			line = "// " + line
+		}
+		printer.Fprintf(w, "%s%s\n", indent, line)
+	}
+}
+
+// params returns the params object of the request, or an empty string
+func (r *request) params(indent string) string {
+	var params []string
+	if len(r.headers) > 0 {
+		params = append(params, fmt.Sprintf("\"headers\": {\n%s\t\t%s\n%s\t}",
+			indent, strings.Join(r.headers, ",\n"+indent+"\t\t"), indent))
+	}
+	if len(r.cookies) > 0 {
+		params = append(params, fmt.Sprintf("\"cookies\": {\n%s\t\t%s\n%s\t}",
+			indent, strings.Join(r.cookies, ",\n"+indent+"\t\t"), indent))
+	}
+	if len(params) == 0 {
+		return ""
+	}
+	return "{\n" + indent + "\t" + strings.Join(params, ",\n"+indent+"\t") + "\n" + indent + "}"
+}
+
+func (c *converter) writeRequests(w io.Writer, requests []*request) {
+	printer.Fprint(w, "\t\tlet res;\n")
+	for _, r := range requests {
+		writeComment(w, "\t\t", r.comment)
+		method := strings.ToLower(r.method)
+		if method == "delete" {
+			method = "del"
+		}
+		args := []string{r.url}
+		if r.method != "GET" && r.method != "HEAD" {
+			body := strings.Replace(r.body, "\n", "\n\t\t", -1)
+			if body == "" {
+				body = "null"
+			}
+			args = append(args, body)
+		}
+		if params := r.params("\t\t"); params != "" {
+			args = append(args, params)
+		}
+		printer.Fprintf(w, "\t\tres = http.%s(%s);\n", method, strings.Join(args, ", "))
+		c.writeCheck(w, "res", r.check)
+	}
+}
+
+func (c *converter) writeBatch(w io.Writer, requests []*request) {
+	printer.Fprint(w, "\t\tlet req, res;\n")
+	printer.Fprint(w, "\t\treq = [")
+	for i, r := range requests {
+		if i > 0 {
+			printer.Fprint(w, ",")
+		}
+		printer.Fprint(w, "\n")
+		writeComment(w, "\t\t\t", r.comment)
+		printer.Fprintf(w, "\t\t\t{\n\t\t\t\t\"method\": %q,\n\t\t\t\t\"url\": %s", r.method, r.url)
+		if r.body != "" {
+			printer.Fprintf(w, ",\n\t\t\t\t\"body\": %s", strings.Replace(r.body, "\n", "\n\t\t\t\t", -1))
+		}
+		if params := r.params("\t\t\t\t"); params != "" {
+			printer.Fprintf(w, ",\n\t\t\t\t\"params\": %s", params)
+		}
+		printer.Fprint(w, "\n\t\t\t}")
+	}
+	printer.Fprint(w, "\n\t\t];\n")
+	printer.Fprint(w, "\t\tres = http.batch(req);\n")
+	for i, r := range requests {
+		c.writeCheck(w, fmt.Sprintf("res[%d]", i), r.check)
+	}
+}
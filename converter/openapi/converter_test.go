@@ -0,0 +1,331 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package openapi
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/loadimpact/k6/converter/internal/printer"
+	"github.com/loadimpact/k6/js"
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/testutils"
+	"github.com/loadimpact/k6/loader"
+)
+
+const testSpec = `
+openapi: 3.0.0
+info:
+  title: Petstore
+  version: 1.0.0
+servers:
+  - url: https://{env}.example.com/v1/
+    variables:
+      env:
+        default: api
+security:
+  - bearerAuth: []
+tags:
+  - name: pets
+  - name: unused
+paths:
+  /pets:
+    get:
+      tags: [pets]
+      summary: List all pets
+      operationId: listPets
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+            example: 10
+        - name: offset
+          in: query
+          schema:
+            type: integer
+        - name: tags
+          in: query
+          required: true
+          schema:
+            type: array
+            items:
+              type: string
+              enum: [dog, cat]
+      responses:
+        "200":
+          description: A list of pets
+        default:
+          description: An error
+    post:
+      tags: [pets]
+      operationId: createPet
+      requestBody:
+        $ref: "#/components/requestBodies/Pet"
+      responses:
+        "201":
+          description: Created
+        "2XX":
+          description: Other success
+  /pets/{petId}:
+    parameters:
+      - $ref: "#/components/parameters/petId"
+    get:
+      tags: [pets]
+      operationId: showPetById
+      parameters:
+        - name: X-Request-ID
+          in: header
+          required: true
+          schema:
+            type: string
+            format: uuid
+      responses:
+        "200":
+          description: A pet
+    delete:
+      tags: [pets]
+      security:
+        - basicAuth: []
+      responses:
+        "204":
+          description: Deleted
+  /login:
+    post:
+      security: []
+      requestBody:
+        content:
+          application/x-www-form-urlencoded:
+            schema:
+              type: object
+              properties:
+                username:
+                  type: string
+                  example: admin
+                password:
+                  type: string
+                  format: password
+      responses:
+        "302":
+          description: Redirect
+  /health:
+    get:
+      security:
+        - apiKey: []
+      responses:
+        "200":
+          description: OK
+components:
+  parameters:
+    petId:
+      name: petId
+      in: path
+      required: true
+      schema:
+        type: integer
+        minimum: 42
+  requestBodies:
+    Pet:
+      required: true
+      content:
+        application/xml:
+          schema:
+            $ref: "#/components/schemas/Pet"
+        application/json:
+          schema:
+            $ref: "#/components/schemas/Pet"
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        id:
+          type: integer
+          readOnly: true
+        name:
+          type: string
+          example: Rex
+        born:
+          type: string
+          format: date
+        owner:
+          $ref: "#/components/schemas/Owner"
+    Owner:
+      allOf:
+        - type: object
+          properties:
+            email:
+              type: string
+              format: email
+        - type: object
+          properties:
+            pets:
+              type: array
+              items:
+                $ref: "#/components/schemas/Pet"
+  securitySchemes:
+    bearerAuth:
+      type: http
+      scheme: bearer
+    basicAuth:
+      type: http
+      scheme: basic
+    apiKey:
+      type: apiKey
+      in: query
+      name: api_key
+`
+
+func checkScript(t *testing.T, script string) {
+	_, err := js.New(testutils.NewLogger(t), &loader.SourceData{
+		URL:  &url.URL{Path: "/script.js", Scheme: "file"},
+		Data: []byte(script),
+	}, nil, lib.RuntimeOptions{})
+	assert.NoError(t, err, script)
+}
+
+func TestConvert(t *testing.T) {
+	t.Parallel()
+	spec, err := Decode(strings.NewReader(testSpec))
+	require.NoError(t, err)
+
+	t.Run("NoBatch", func(t *testing.T) {
+		t.Parallel()
+		script, err := Convert(spec, Options{
+			ScriptOptions: printer.ScriptOptions{
+				Options:      lib.Options{VUs: null.IntFrom(1), Iterations: null.IntFrom(1)},
+				MinSleep:     1,
+				MaxSleep:     2,
+				EnableChecks: true,
+			},
+			NoBatch: true,
+		})
+		require.NoError(t, err)
+		checkScript(t, script)
+
+		for _, expected := range []string{
+			"import { group, check, sleep } from 'k6';\n",
+			"import encoding from 'k6/encoding';\n",
+			"    vus: 1,\n    iterations: 1,\n",
+			`const BASE_URL = __ENV.BASE_URL || "https://api.example.com/v1";`,
+			`const BEARERAUTH_TOKEN = __ENV.BEARERAUTH_TOKEN || "";`,
+			`const BASICAUTH_USERNAME = __ENV.BASICAUTH_USERNAME || "";`,
+			`const APIKEY_API_KEY = __ENV.APIKEY_API_KEY || "";`,
+			// groups per tag, with the untagged operations in a group named after the API
+			`group("pets", function() {`,
+			`group("Petstore", function() {`,
+			// parameters with examples and required ones, but not optional ones without examples
+			"// GET /pets (listPets) - List all pets\n\t\tres = http.get(BASE_URL + \"/pets?limit=10&tags=dog\", {",
+			// path parameters from references
+			`http.get(BASE_URL + "/pets/42", {`,
+			`"X-Request-ID": "00000000-0000-0000-0000-000000000000",`,
+			// JSON bodies without read-only properties and recursion
+			"res = http.post(BASE_URL + \"/pets\", JSON.stringify({\n\t\t\t\"born\": \"2021-01-01\",\n" +
+				"\t\t\t\"name\": \"Rex\",\n\t\t\t\"owner\": {\n\t\t\t\t\"email\": \"user@example.com\",\n" +
+				"\t\t\t\t\"pets\": []\n\t\t\t}\n\t\t}), {",
+			`"Content-Type": "application/json",`,
+			`res = http.post(BASE_URL + "/login", { "password": "password", "username": "admin" });`,
+			// security schemes
+			`"Authorization": "Bearer " + BEARERAUTH_TOKEN`,
+			`"Authorization": "Basic " + encoding.b64encode(BASICAUTH_USERNAME + ":" + BASICAUTH_PASSWORD)`,
+			`res = http.get(BASE_URL + "/health" + "?api_key=" + encodeURIComponent(APIKEY_API_KEY));`,
+			`res = http.del(BASE_URL + "/pets/42", null, {`,
+			// checks
+			`check(res, {"status is 201 or 2XX": (r) => r.status === 201 || (r.status >= 200 && r.status < 300) });`,
+			`check(res, {"status is 302": (r) => r.status === 302 });`,
+			"sleep(Math.floor(Math.random()*1+1));",
+		} {
+			assert.Contains(t, script, expected)
+		}
+		assert.NotContains(t, script, "offset")
+		assert.NotContains(t, script, "unused")
+		assert.NotContains(t, script, `"id"`)
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		t.Parallel()
+		script, err := Convert(spec, Options{
+			ScriptOptions: printer.ScriptOptions{MaxSleep: 5, EnableChecks: true, ReturnOnFailedCheck: true},
+			BaseURL:       "http://localhost:8080/",
+		})
+		require.NoError(t, err)
+		checkScript(t, script)
+		for _, expected := range []string{
+			`const BASE_URL = __ENV.BASE_URL || "http://localhost:8080";`,
+			"\t\tres = http.batch(req);\n",
+			"\t\t\t// DELETE /pets/{petId}\n\t\t\t{\n\t\t\t\t\"method\": \"DELETE\",\n\t\t\t\t\"url\": BASE_URL + \"/pets/42\",",
+			`if (!check(res[3], {"status is 204": (r) => r.status === 204 })) { return };`,
+		} {
+			assert.Contains(t, script, expected)
+		}
+	})
+
+	t.Run("NoChecks", func(t *testing.T) {
+		t.Parallel()
+		script, err := Convert(spec, Options{})
+		require.NoError(t, err)
+		checkScript(t, script)
+		assert.Contains(t, script, "import { group, sleep } from 'k6';\n")
+		assert.NotContains(t, script, "check(")
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := Convert(spec, Options{ScriptOptions: printer.ScriptOptions{ReturnOnFailedCheck: true}})
+		assert.EqualError(t, err, "return on failed check requires --enable-status-code-checks")
+		_, err = Convert(spec, Options{ScriptOptions: printer.ScriptOptions{MinSleep: 2, MaxSleep: 1}})
+		assert.EqualError(t, err, "the maximum sleep can't be less than the minimum sleep")
+	})
+}
+
+func TestStatusCheckFor(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		statuses  []string
+		name      string
+		condition string
+	}{
+		{[]string{"200", "404", "default"}, "status is 200", "r.status === 200"},
+		{[]string{"201", "200", "302"}, "status is 200 or 201 or 302", "[200, 201, 302].indexOf(r.status) !== -1"},
+		{[]string{"2XX", "3xx"}, "status is 2XX or 3XX", "(r.status >= 200 && r.status < 300) || (r.status >= 300 && r.status < 400)"},
+		{[]string{"4XX", "default"}, "", ""},
+	}
+	for _, tc := range testCases {
+		op := &Operation{Responses: make(map[string]*Response)}
+		for _, status := range tc.statuses {
+			op.Responses[status] = &Response{}
+		}
+		check := statusCheckFor(op)
+		if tc.name == "" {
+			assert.Nil(t, check, tc.statuses)
+			continue
+		}
+		if assert.NotNil(t, check, tc.statuses) {
+			assert.Equal(t, tc.name, check.name)
+			assert.Equal(t, tc.condition, check.condition)
+		}
+	}
+}
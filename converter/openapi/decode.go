@@ -0,0 +1,89 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package openapi
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+type specVersion struct {
+	OpenAPI string `json:"openapi"`
+	Swagger string `json:"swagger"`
+}
+
+func decodeVersion(data []byte) ([]byte, specVersion, error) {
+	var version specVersion
+	// YAML is a superset of JSON, so this works for both
+	data, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, version, err
+	}
+	if err := json.Unmarshal(data, &version); err != nil {
+		return nil, version, err
+	}
+	return data, version, nil
+}
+
+// IsSpec returns whether the data is an OpenAPI or a Swagger specification, in
+// JSON or YAML.
+func IsSpec(data []byte) bool {
+	_, version, err := decodeVersion(data)
+	return err == nil && (version.OpenAPI != "" || version.Swagger != "")
+}
+
+// Decode reads an OpenAPI 3 or a Swagger 2.0 specification, in JSON or YAML.
+func Decode(r io.Reader) (*Spec, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data, version, err := decodeVersion(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid OpenAPI specification")
+	}
+
+	switch {
+	case strings.HasPrefix(version.OpenAPI, "3."):
+		var spec Spec
+		if err := json.Unmarshal(data, &spec); err != nil {
+			return nil, errors.Wrap(err, "invalid OpenAPI specification")
+		}
+		return &spec, nil
+	case version.Swagger == "2.0":
+		var s swagger
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, errors.Wrap(err, "invalid Swagger specification")
+		}
+		return s.toSpec(), nil
+	case version.OpenAPI != "":
+		return nil, errors.Errorf("unsupported OpenAPI version %s, only 3.x is supported", version.OpenAPI)
+	case version.Swagger != "":
+		return nil, errors.Errorf("unsupported Swagger version %s, only 2.0 is supported", version.Swagger)
+	default:
+		return nil, errors.New("invalid OpenAPI specification, the 'openapi' property is missing")
+	}
+}
@@ -0,0 +1,140 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package openapi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loadimpact/k6/converter/internal/printer"
+)
+
+const testSwagger = `{
+	"swagger": "2.0",
+	"info": {"title": "Users", "version": "2.1"},
+	"host": "users.example.com",
+	"basePath": "/api",
+	"schemes": ["http"],
+	"consumes": ["application/json"],
+	"securityDefinitions": {
+		"basic": {"type": "basic"},
+		"key": {"type": "apiKey", "in": "header", "name": "X-API-Key"}
+	},
+	"security": [{"key": []}],
+	"parameters": {
+		"userId": {"name": "userId", "in": "path", "required": true, "type": "integer", "x-example": 7}
+	},
+	"paths": {
+		"/users": {
+			"post": {
+				"tags": ["users"],
+				"parameters": [{"name": "user", "in": "body", "required": true, "schema": {"$ref": "#/definitions/User"}}],
+				"responses": {"201": {"description": "Created", "schema": {"$ref": "#/definitions/User"}}}
+			}
+		},
+		"/users/{userId}/avatar": {
+			"parameters": [{"$ref": "#/parameters/userId"}],
+			"put": {
+				"tags": ["users"],
+				"security": [{"basic": []}],
+				"parameters": [
+					{"name": "size", "in": "query", "type": "array", "items": {"type": "integer"}, "x-example": [16, 32]},
+					{"name": "url", "in": "formData", "type": "string", "format": "uri", "required": true}
+				],
+				"responses": {"204": {"description": "Updated"}}
+			}
+		}
+	},
+	"definitions": {
+		"User": {
+			"type": "object",
+			"properties": {
+				"name": {"type": "string", "example": "jane"},
+				"friends": {"type": "array", "items": {"$ref": "#/definitions/User"}}
+			}
+		}
+	}
+}`
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+	t.Run("OpenAPI", func(t *testing.T) {
+		t.Parallel()
+		spec, err := Decode(strings.NewReader(testSpec))
+		require.NoError(t, err)
+		assert.Equal(t, "3.0.0", spec.OpenAPI)
+		assert.Equal(t, "Petstore", spec.Info.Title)
+		assert.Len(t, spec.Paths, 4)
+		assert.Equal(t, "listPets", spec.Paths["/pets"].Get.OperationID)
+		assert.Contains(t, spec.Paths["/pets"].Get.Responses, "200")
+	})
+	t.Run("Swagger", func(t *testing.T) {
+		t.Parallel()
+		spec, err := Decode(strings.NewReader(testSwagger))
+		require.NoError(t, err)
+		assert.Equal(t, []Server{{URL: "http://users.example.com/api"}}, spec.Servers)
+		assert.Equal(t, &SecurityScheme{Type: "http", Scheme: "basic"}, spec.Components.SecuritySchemes["basic"])
+
+		post := spec.Paths["/users"].Post
+		require.NotNil(t, post.RequestBody)
+		require.Contains(t, post.RequestBody.Content, "application/json")
+		assert.Equal(t, "#/definitions/User", post.RequestBody.Content["application/json"].Schema.Ref)
+
+		script, err := Convert(spec, Options{ScriptOptions: printer.ScriptOptions{EnableChecks: true}, NoBatch: true})
+		require.NoError(t, err)
+		checkScript(t, script)
+		for _, expected := range []string{
+			`const BASE_URL = __ENV.BASE_URL || "http://users.example.com/api";`,
+			"res = http.post(BASE_URL + \"/users\", JSON.stringify({\n\t\t\t\"friends\": [],\n\t\t\t\"name\": \"jane\"\n\t\t}), {",
+			`"X-API-Key": KEY_API_KEY`,
+			`res = http.put(BASE_URL + "/users/7/avatar?size=16&size=32", { "url": "https://example.com" }, {`,
+			`"Authorization": "Basic " + encoding.b64encode(BASIC_USERNAME + ":" + BASIC_PASSWORD)`,
+			`check(res, {"status is 204": (r) => r.status === 204 });`,
+		} {
+			assert.Contains(t, script, expected)
+		}
+	})
+	t.Run("Errors", func(t *testing.T) {
+		t.Parallel()
+		testCases := map[string]string{
+			`{"openapi": "2.5"}`:                "unsupported OpenAPI version 2.5, only 3.x is supported",
+			`swagger: "1.2"`:                    "unsupported Swagger version 1.2, only 2.0 is supported",
+			`{"log": {}}`:                       "invalid OpenAPI specification, the 'openapi' property is missing",
+			`{"openapi": "3.0.0", "paths": []}`: "invalid OpenAPI specification: json: cannot unmarshal array into Go struct field Spec.paths of type map[string]openapi.PathItem",
+		}
+		for src, expErr := range testCases {
+			_, err := Decode(strings.NewReader(src))
+			assert.EqualError(t, err, expErr, src)
+		}
+	})
+}
+
+func TestIsSpec(t *testing.T) {
+	t.Parallel()
+	assert.True(t, IsSpec([]byte(testSpec)))
+	assert.True(t, IsSpec([]byte(testSwagger)))
+	assert.False(t, IsSpec([]byte(`{"log": {"entries": []}}`)))
+	assert.False(t, IsSpec([]byte("curl https://example.com")))
+	assert.False(t, IsSpec([]byte("{")))
+}
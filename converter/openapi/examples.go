@@ -0,0 +1,219 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package openapi
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxExampleDepth limits the nesting of the generated examples
+const maxExampleDepth = 8
+
+// resolver follows the local references of a specification
+type resolver struct {
+	spec *Spec
+}
+
+func (r resolver) schema(s *Schema) *Schema {
+	for i := 0; s != nil && s.Ref != ""; i++ {
+		if i > maxExampleDepth {
+			return nil
+		}
+		s = r.schemaRef(s.Ref)
+	}
+	return s
+}
+
+// schemaRef returns the schema that the reference points to, which can be
+// another reference
+func (r resolver) schemaRef(ref string) *Schema {
+	name := localRef(ref, "components/schemas")
+	if name == "" {
+		// Swagger 2.0 schemas
+		name = localRef(ref, "definitions")
+	}
+	return r.spec.Components.Schemas[name]
+}
+
+func (r resolver) parameter(p *Parameter) *Parameter {
+	if p == nil || p.Ref == "" {
+		return p
+	}
+	return r.spec.Components.Parameters[localRef(p.Ref, "components/parameters")]
+}
+
+func (r resolver) requestBody(b *RequestBody) *RequestBody {
+	if b == nil || b.Ref == "" {
+		return b
+	}
+	return r.spec.Components.RequestBodies[localRef(b.Ref, "components/requestBodies")]
+}
+
+func (r resolver) example(e *Example) *Example {
+	if e == nil || e.Ref == "" {
+		return e
+	}
+	return r.spec.Components.Examples[localRef(e.Ref, "components/examples")]
+}
+
+func (r resolver) securityScheme(name string) *SecurityScheme {
+	s := r.spec.Components.SecuritySchemes[name]
+	if s != nil && s.Ref != "" {
+		s = r.spec.Components.SecuritySchemes[localRef(s.Ref, "components/securitySchemes")]
+	}
+	return s
+}
+
+// firstExample returns the example or the first of the named examples, sorted
+// by name
+func (r resolver) firstExample(example interface{}, examples map[string]*Example) (interface{}, bool) {
+	if example != nil {
+		return example, true
+	}
+	names := make([]string, 0, len(examples))
+	for name := range examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if e := r.example(examples[name]); e != nil && e.Value != nil {
+			return e.Value, true
+		}
+	}
+	return nil, false
+}
+
+// exampleValue generates an example value for the schema, preferring the
+// examples, defaults and enums in it
+func (r resolver) exampleValue(s *Schema) interface{} {
+	return r.generate(s, make(map[string]bool), 0)
+}
+
+// generate returns the example value of a schema, or nil for the schemas
+// that are being generated higher up in the value, which recursive schemas
+// would make infinite
+func (r resolver) generate(s *Schema, visiting map[string]bool, depth int) interface{} {
+	for s != nil && s.Ref != "" {
+		if visiting[s.Ref] {
+			return nil
+		}
+		visiting[s.Ref] = true
+		defer delete(visiting, s.Ref)
+		s = r.schemaRef(s.Ref)
+	}
+	if s == nil || depth > maxExampleDepth {
+		return nil
+	}
+	switch {
+	case s.Example != nil:
+		return s.Example
+	case s.Default != nil:
+		return s.Default
+	case len(s.Enum) > 0:
+		return s.Enum[0]
+	case len(s.AllOf) > 0:
+		merged := make(map[string]interface{})
+		for _, sub := range s.AllOf {
+			if value, ok := r.generate(sub, visiting, depth+1).(map[string]interface{}); ok {
+				for k, v := range value {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	case len(s.OneOf) > 0:
+		return r.generate(s.OneOf[0], visiting, depth+1)
+	case len(s.AnyOf) > 0:
+		return r.generate(s.AnyOf[0], visiting, depth+1)
+	}
+
+	switch s.Type {
+	case "array":
+		item := r.generate(s.Items, visiting, depth+1)
+		if item == nil {
+			return []interface{}{}
+		}
+		return []interface{}{item}
+	case "integer":
+		if s.Minimum != nil {
+			return int64(*s.Minimum)
+		}
+		return 1
+	case "number":
+		if s.Minimum != nil {
+			return *s.Minimum
+		}
+		return 1.5
+	case "boolean":
+		return true
+	case "string":
+		return exampleString(s.Format)
+	case "object", "":
+		if len(s.Properties) == 0 {
+			if s.Type == "" {
+				return nil
+			}
+			return map[string]interface{}{}
+		}
+		value := make(map[string]interface{}, len(s.Properties))
+		for name, property := range s.Properties {
+			// read-only properties aren't sent in requests
+			if resolved := r.schema(property); resolved != nil && !resolved.ReadOnly {
+				if v := r.generate(property, visiting, depth+1); v != nil {
+					value[name] = v
+				}
+			}
+		}
+		return value
+	default:
+		return nil
+	}
+}
+
+func exampleString(format string) string {
+	switch strings.ToLower(format) {
+	case "date":
+		return "2021-01-01"
+	case "date-time":
+		return "2021-01-01T00:00:00Z"
+	case "time":
+		return "00:00:00"
+	case "email":
+		return "user@example.com"
+	case "uuid":
+		return "00000000-0000-0000-0000-000000000000"
+	case "uri", "url":
+		return "https://example.com"
+	case "hostname":
+		return "example.com"
+	case "ipv4":
+		return "127.0.0.1"
+	case "ipv6":
+		return "::1"
+	case "byte":
+		return "ZXhhbXBsZQ=="
+	case "password":
+		return "password"
+	default:
+		return "string"
+	}
+}
@@ -0,0 +1,77 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExampleValue(t *testing.T) {
+	t.Parallel()
+	minimum := 5.0
+	r := resolver{spec: &Spec{Components: Components{Schemas: map[string]*Schema{
+		"Node": {Type: "object", Properties: map[string]*Schema{
+			"value":    {Type: "integer", Minimum: &minimum},
+			"children": {Type: "array", Items: &Schema{Ref: "#/components/schemas/Node"}},
+			"parent":   {Ref: "#/components/schemas/Node"},
+		}},
+		"Alias": {Ref: "#/components/schemas/Node"},
+		"Loop":  {Ref: "#/components/schemas/Loop"},
+	}}}}
+
+	testCases := []struct {
+		schema   *Schema
+		expected interface{}
+	}{
+		{nil, nil},
+		{&Schema{Type: "string", Example: "example", Default: "default"}, "example"},
+		{&Schema{Type: "string", Default: "default", Enum: []interface{}{"a", "b"}}, "default"},
+		{&Schema{Type: "string", Enum: []interface{}{"a", "b"}}, "a"},
+		{&Schema{Type: "string", Format: "date-time"}, "2021-01-01T00:00:00Z"},
+		{&Schema{Type: "string"}, "string"},
+		{&Schema{Type: "integer"}, 1},
+		{&Schema{Type: "number"}, 1.5},
+		{&Schema{Type: "boolean"}, true},
+		{&Schema{Type: "array"}, []interface{}{}},
+		{&Schema{Type: "object"}, map[string]interface{}{}},
+		{&Schema{OneOf: []*Schema{{Type: "boolean"}, {Type: "string"}}}, true},
+		{&Schema{AnyOf: []*Schema{{Type: "string"}}}, "string"},
+		{
+			&Schema{AllOf: []*Schema{
+				{Properties: map[string]*Schema{"a": {Type: "integer"}}},
+				{Properties: map[string]*Schema{"b": {Type: "string"}, "id": {Type: "integer", ReadOnly: true}}},
+			}},
+			map[string]interface{}{"a": 1, "b": "string"},
+		},
+		{
+			&Schema{Ref: "#/components/schemas/Alias"},
+			map[string]interface{}{"value": int64(5), "children": []interface{}{}},
+		},
+		{&Schema{Ref: "#/components/schemas/Loop"}, nil},
+		{&Schema{Ref: "#/components/schemas/Missing"}, nil},
+		{&Schema{Ref: "other.yaml#/Pet"}, nil},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, r.exampleValue(tc.schema), "%#v", tc.schema)
+	}
+}
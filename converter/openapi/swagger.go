@@ -0,0 +1,242 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package openapi
+
+import "strings"
+
+// swagger is a Swagger 2.0 specification, which is converted to the
+// equivalent OpenAPI 3 one
+type swagger struct {
+	Info                Info                              `json:"info"`
+	Host                string                            `json:"host"`
+	BasePath            string                            `json:"basePath"`
+	Schemes             []string                          `json:"schemes"`
+	Consumes            []string                          `json:"consumes"`
+	Produces            []string                          `json:"produces"`
+	Paths               map[string]swaggerPathItem        `json:"paths"`
+	Definitions         map[string]*Schema                `json:"definitions"`
+	Parameters          map[string]*swaggerParameter      `json:"parameters"`
+	Responses           map[string]*swaggerResponse       `json:"responses"`
+	SecurityDefinitions map[string]*swaggerSecurityScheme `json:"securityDefinitions"`
+	Security            []SecurityRequirement             `json:"security"`
+	Tags                []Tag                             `json:"tags"`
+}
+
+type swaggerPathItem struct {
+	Parameters []*swaggerParameter `json:"parameters"`
+	Get        *swaggerOperation   `json:"get"`
+	Put        *swaggerOperation   `json:"put"`
+	Post       *swaggerOperation   `json:"post"`
+	Delete     *swaggerOperation   `json:"delete"`
+	Options    *swaggerOperation   `json:"options"`
+	Head       *swaggerOperation   `json:"head"`
+	Patch      *swaggerOperation   `json:"patch"`
+}
+
+type swaggerOperation struct {
+	Tags        []string                    `json:"tags"`
+	Summary     string                      `json:"summary"`
+	OperationID string                      `json:"operationId"`
+	Consumes    []string                    `json:"consumes"`
+	Produces    []string                    `json:"produces"`
+	Parameters  []*swaggerParameter         `json:"parameters"`
+	Responses   map[string]*swaggerResponse `json:"responses"`
+	Deprecated  bool                        `json:"deprecated"`
+	Security    *[]SecurityRequirement      `json:"security"`
+}
+
+type swaggerParameter struct {
+	Ref      string        `json:"$ref"`
+	Name     string        `json:"name"`
+	In       string        `json:"in"`
+	Required bool          `json:"required"`
+	Schema   *Schema       `json:"schema"`
+	Type     string        `json:"type"`
+	Format   string        `json:"format"`
+	Items    *Schema       `json:"items"`
+	Enum     []interface{} `json:"enum"`
+	Default  interface{}   `json:"default"`
+	Example  interface{}   `json:"x-example"`
+}
+
+type swaggerResponse struct {
+	Ref         string                 `json:"$ref"`
+	Description string                 `json:"description"`
+	Schema      *Schema                `json:"schema"`
+	Examples    map[string]interface{} `json:"examples"`
+}
+
+type swaggerSecurityScheme struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+	In   string `json:"in"`
+}
+
+const swaggerRefPrefix = "#/"
+
+// localRef returns the name of a local reference to the given section, or
+// an empty string
+func localRef(ref, section string) string {
+	prefix := swaggerRefPrefix + section + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return ""
+	}
+	return ref[len(prefix):]
+}
+
+func (s *swagger) toSpec() *Spec {
+	spec := &Spec{
+		OpenAPI:  "3.0.0",
+		Info:     s.Info,
+		Paths:    make(map[string]PathItem, len(s.Paths)),
+		Security: s.Security,
+		Tags:     s.Tags,
+		Components: Components{
+			// the schema references to #/definitions are resolved as well
+			Schemas:         s.Definitions,
+			SecuritySchemes: make(map[string]*SecurityScheme, len(s.SecurityDefinitions)),
+		},
+	}
+
+	if s.Host != "" || s.BasePath != "" {
+		scheme := "https"
+		if len(s.Schemes) > 0 {
+			scheme = s.Schemes[0]
+		}
+		url := s.BasePath
+		if s.Host != "" {
+			url = scheme + "://" + s.Host + url
+		}
+		spec.Servers = []Server{{URL: url}}
+	}
+
+	for name, scheme := range s.SecurityDefinitions {
+		converted := &SecurityScheme{Type: scheme.Type, Name: scheme.Name, In: scheme.In}
+		if scheme.Type == "basic" {
+			converted.Type, converted.Scheme = "http", "basic"
+		}
+		spec.Components.SecuritySchemes[name] = converted
+	}
+
+	for path, item := range s.Paths {
+		converted := PathItem{}
+		for _, p := range item.Parameters {
+			if p = s.parameter(p); p != nil && p.In != "body" && p.In != "formData" {
+				converted.Parameters = append(converted.Parameters, p.toParameter())
+			}
+		}
+		operations := []struct {
+			from *swaggerOperation
+			to   **Operation
+		}{
+			{item.Get, &converted.Get}, {item.Put, &converted.Put}, {item.Post, &converted.Post},
+			{item.Delete, &converted.Delete}, {item.Options, &converted.Options},
+			{item.Head, &converted.Head}, {item.Patch, &converted.Patch},
+		}
+		for _, op := range operations {
+			if op.from != nil {
+				*op.to = s.toOperation(op.from, item.Parameters)
+			}
+		}
+		spec.Paths[path] = converted
+	}
+	return spec
+}
+
+func (s *swagger) parameter(p *swaggerParameter) *swaggerParameter {
+	if p == nil || p.Ref == "" {
+		return p
+	}
+	return s.Parameters[localRef(p.Ref, "parameters")]
+}
+
+func (p *swaggerParameter) toParameter() *Parameter {
+	schema := p.Schema
+	if schema == nil {
+		schema = &Schema{Type: p.Type, Format: p.Format, Items: p.Items, Enum: p.Enum, Default: p.Default}
+	}
+	return &Parameter{Name: p.Name, In: p.In, Required: p.Required, Schema: schema, Example: p.Example}
+}
+
+func firstOr(values []string, def string) string {
+	if len(values) > 0 {
+		return values[0]
+	}
+	return def
+}
+
+func (s *swagger) toOperation(op *swaggerOperation, pathParameters []*swaggerParameter) *Operation {
+	converted := &Operation{
+		Tags:        op.Tags,
+		Summary:     op.Summary,
+		OperationID: op.OperationID,
+		Deprecated:  op.Deprecated,
+		Security:    op.Security,
+		Responses:   make(map[string]*Response, len(op.Responses)),
+	}
+
+	consumes := firstOr(op.Consumes, firstOr(s.Consumes, "application/json"))
+	var form *Schema
+	for _, p := range append(append([]*swaggerParameter{}, pathParameters...), op.Parameters...) {
+		p = s.parameter(p)
+		switch {
+		case p == nil:
+		case p.In == "body":
+			converted.RequestBody = &RequestBody{
+				Required: p.Required,
+				Content:  map[string]*MediaType{consumes: {Schema: p.Schema}},
+			}
+		case p.In == "formData":
+			if form == nil {
+				form = &Schema{Type: "object", Properties: make(map[string]*Schema)}
+			}
+			form.Properties[p.Name] = p.toParameter().Schema
+			if p.Required {
+				form.Required = append(form.Required, p.Name)
+			}
+		default:
+			converted.Parameters = append(converted.Parameters, p.toParameter())
+		}
+	}
+	if form != nil {
+		mimeType := "application/x-www-form-urlencoded"
+		if strings.HasPrefix(consumes, "multipart/form-data") {
+			mimeType = consumes
+		}
+		converted.RequestBody = &RequestBody{Content: map[string]*MediaType{mimeType: {Schema: form}}}
+	}
+
+	produces := firstOr(op.Produces, firstOr(s.Produces, "application/json"))
+	for status, r := range op.Responses {
+		if r != nil && r.Ref != "" {
+			r = s.Responses[localRef(r.Ref, "responses")]
+		}
+		if r == nil {
+			continue
+		}
+		response := &Response{Description: r.Description}
+		if r.Schema != nil {
+			response.Content = map[string]*MediaType{produces: {Schema: r.Schema, Example: r.Examples[produces]}}
+		}
+		converted.Responses[status] = response
+	}
+	return converted
+}
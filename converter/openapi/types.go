@@ -0,0 +1,169 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package openapi
+
+// Spec is the top level object of an OpenAPI 3 specification. Swagger 2.0
+// specifications are converted to it by Decode.
+type Spec struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components,omitempty"`
+	// Security holds the default security requirements of all operations
+	Security []SecurityRequirement `json:"security,omitempty"`
+	Tags     []Tag                 `json:"tags,omitempty"`
+}
+
+// Info is the metadata of the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is a base URL of the API, which can contain {variables}.
+type Server struct {
+	URL       string                    `json:"url"`
+	Variables map[string]ServerVariable `json:"variables,omitempty"`
+}
+
+// ServerVariable is a variable in a server URL.
+type ServerVariable struct {
+	Default string   `json:"default"`
+	Enum    []string `json:"enum,omitempty"`
+}
+
+// Tag is the metadata of a tag, which operations are grouped by.
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations of a path.
+type PathItem struct {
+	Ref        string       `json:"$ref,omitempty"`
+	Parameters []*Parameter `json:"parameters,omitempty"`
+	Get        *Operation   `json:"get,omitempty"`
+	Put        *Operation   `json:"put,omitempty"`
+	Post       *Operation   `json:"post,omitempty"`
+	Delete     *Operation   `json:"delete,omitempty"`
+	Options    *Operation   `json:"options,omitempty"`
+	Head       *Operation   `json:"head,omitempty"`
+	Patch      *Operation   `json:"patch,omitempty"`
+}
+
+// Operation is a single API operation on a path.
+type Operation struct {
+	Tags        []string             `json:"tags,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	OperationID string               `json:"operationId,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+	// Security overrides the default security requirements if it isn't nil
+	Security *[]SecurityRequirement `json:"security,omitempty"`
+}
+
+// Parameter is a path, query, header or cookie parameter of an operation.
+type Parameter struct {
+	Ref      string                `json:"$ref,omitempty"`
+	Name     string                `json:"name"`
+	In       string                `json:"in"`
+	Required bool                  `json:"required,omitempty"`
+	Schema   *Schema               `json:"schema,omitempty"`
+	Example  interface{}           `json:"example,omitempty"`
+	Examples map[string]*Example   `json:"examples,omitempty"`
+	Content  map[string]*MediaType `json:"content,omitempty"`
+}
+
+// RequestBody is the request body of an operation.
+type RequestBody struct {
+	Ref      string                `json:"$ref,omitempty"`
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// MediaType is the schema and the examples of a body with a specific content type.
+type MediaType struct {
+	Schema   *Schema             `json:"schema,omitempty"`
+	Example  interface{}         `json:"example,omitempty"`
+	Examples map[string]*Example `json:"examples,omitempty"`
+}
+
+// Example is a named example value.
+type Example struct {
+	Ref   string      `json:"$ref,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Response is a documented response of an operation.
+type Response struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// Schema is the subset of a JSON schema that's needed to generate examples.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Example              interface{}        `json:"example,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	ReadOnly             bool               `json:"readOnly,omitempty"`
+}
+
+// Components holds the reusable objects of the specification.
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	Parameters      map[string]*Parameter      `json:"parameters,omitempty"`
+	RequestBodies   map[string]*RequestBody    `json:"requestBodies,omitempty"`
+	Responses       map[string]*Response       `json:"responses,omitempty"`
+	Examples        map[string]*Example        `json:"examples,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme is an authentication method of the API.
+type SecurityScheme struct {
+	Ref string `json:"$ref,omitempty"`
+	// Type is "apiKey", "http", "oauth2" or "openIdConnect"
+	Type string `json:"type"`
+	// Scheme is the HTTP authentication scheme of the "http" type, e.g. "basic" or "bearer"
+	Scheme string `json:"scheme,omitempty"`
+	// Name and In are the name and location of the "apiKey" type
+	Name string `json:"name,omitempty"`
+	In   string `json:"in,omitempty"`
+}
+
+// SecurityRequirement maps the names of the security schemes that are all
+// required to their scopes.
+type SecurityRequirement map[string][]string
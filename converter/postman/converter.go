@@ -0,0 +1,608 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package postman converts Postman collections to k6 scripts.
+package postman
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/loadimpact/k6/converter/internal/printer"
+)
+
+// Options are the options of the conversion. The checks are for the status
+// codes of the saved examples of the requests.
+type Options struct {
+	printer.ScriptOptions
+	// Environment has values for the variables, which override the ones of the collection
+	Environment *Environment
+}
+
+// scope is the collection or a folder, whose auth and scripts are inherited by their items
+type scope struct {
+	auth  *Auth
+	event []*Event
+}
+
+// converter holds the state of a single conversion
+type converter struct {
+	opts    Options
+	scripts scriptTranslator
+	// files are the constants with the files opened in the init context, by their path
+	files     map[string]string
+	fileOrder []string
+	// usesEncoding and usesUUID are set when the script needs k6/encoding and the UUID helper
+	usesEncoding, usesUUID bool
+}
+
+// Convert generates a k6 script that makes the requests of the collection in
+// order, with a group for each folder.
+func Convert(collection *Collection, opts Options) (result string, convertErr error) {
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+
+	c := &converter{
+		opts:    opts,
+		scripts: scriptTranslator{res: "res", returnOnFailedCheck: opts.ReturnOnFailedCheck},
+		files:   make(map[string]string),
+	}
+
+	// the iteration is written first, to know what the script needs
+	var iteration bytes.Buffer
+	if err := c.writeItems(&iteration, collection.Item, []scope{{auth: collection.Auth, event: collection.Event}}, 1); err != nil {
+		return "", err
+	}
+	variables := c.variables(collection)
+
+	var b bytes.Buffer
+	w := bufio.NewWriter(&b)
+
+	if c.scripts.usesCheck {
+		printer.Fprint(w, "import { group, check, sleep } from 'k6';\n")
+	} else {
+		printer.Fprint(w, "import { group, sleep } from 'k6';\n")
+	}
+	printer.Fprint(w, "import http from 'k6/http';\n")
+	if c.usesEncoding {
+		printer.Fprint(w, "import encoding from 'k6/encoding';\n")
+	}
+	printer.Fprint(w, "\n")
+
+	printer.Fprintf(w, "// Collection: %v\n", collection.Info.Name)
+	if opts.Environment != nil {
+		printer.Fprintf(w, "// Environment: %v\n", opts.Environment.Name)
+	}
+
+	printer.Fprint(w, "\nexport let options = {\n")
+	opts.Options.ForEachSpecified("json", func(key string, val interface{}) {
+		if valJSON, err := json.MarshalIndent(val, "    ", "    "); err != nil {
+			convertErr = err
+		} else {
+			printer.Fprintf(w, "    %s: %s,\n", key, valJSON)
+		}
+	})
+	if convertErr != nil {
+		return "", convertErr
+	}
+	printer.Fprint(w, "};\n")
+
+	if len(variables) > 0 || c.scripts.usesVars {
+		printer.Fprint(w, "\n// The variables of the collection, which can be overridden with environment variables\n")
+		printer.Fprint(w, "const vars = {\n")
+		for _, v := range variables {
+			printer.Fprintf(w, "    %q: __ENV[%q] || %q,\n", v[0], v[0], v[1])
+		}
+		printer.Fprint(w, "};\n")
+	}
+	if len(c.fileOrder) > 0 {
+		printer.Fprint(w, "\n")
+		for _, path := range c.fileOrder {
+			printer.Fprintf(w, "const %s = open(%q, \"b\");\n", c.files[path], path)
+		}
+	}
+	if c.usesUUID {
+		printer.Fprint(w, "\nfunction uuidv4() {\n")
+		printer.Fprint(w, "\treturn \"xxxxxxxx-xxxx-4xxx-yxxx-xxxxxxxxxxxx\".replace(/[xy]/g, function(c) {\n")
+		printer.Fprint(w, "\t\tconst r = Math.random() * 16 | 0;\n")
+		printer.Fprint(w, "\t\treturn (c === \"x\" ? r : (r & 0x3 | 0x8)).toString(16);\n")
+		printer.Fprint(w, "\t});\n")
+		printer.Fprint(w, "}\n")
+	}
+
+	printer.Fprint(w, "\nexport default function() {\n")
+	printer.Fprint(w, "\tlet res;\n\n")
+	printer.Fprint(w, iteration.String())
+	printer.Fprintf(w, "\n\t// Random sleep between %ds and %ds\n", opts.MinSleep, opts.MaxSleep)
+	printer.Fprintf(w, "\tsleep(Math.floor(Math.random()*%d+%d));\n", opts.MaxSleep-opts.MinSleep, opts.MinSleep)
+	printer.Fprint(w, "}\n")
+
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// variables returns the names and values of the variables of the collection,
+// with the values of the environment
+func (c *converter) variables(collection *Collection) [][2]string {
+	var result [][2]string
+	index := make(map[string]int)
+	set := func(key string, value interface{}) {
+		if i, ok := index[key]; ok {
+			result[i][1] = formatValue(value)
+			return
+		}
+		index[key] = len(result)
+		result = append(result, [2]string{key, formatValue(value)})
+	}
+	for _, v := range collection.Variable {
+		if !v.Disabled && v.Key != "" {
+			set(v.Key, v.Value)
+		}
+	}
+	if c.opts.Environment != nil {
+		for _, v := range c.opts.Environment.Values {
+			if (v.Enabled == nil || *v.Enabled) && v.Key != "" {
+				set(v.Key, v.Value)
+			}
+		}
+	}
+	return result
+}
+
+// formatValue formats a variable value, which are usually strings
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}, map[string]interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+//nolint:gochecknoglobals
+var (
+	variableRe = regexp.MustCompile(`{{\s*([^{}]+?)\s*}}`)
+
+	// dynamicVariables are the expressions of the dynamic variables of Postman
+	dynamicVariables = map[string]string{
+		"$timestamp":    "Math.floor(Date.now() / 1000)",
+		"$isoTimestamp": "new Date().toISOString()",
+		"$randomInt":    "Math.floor(Math.random() * 1001)",
+	}
+)
+
+// expr returns the JS expression of a string with {{variables}}
+func (c *converter) expr(s string) string {
+	matches := variableRe.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return strconv.Quote(s)
+	}
+	var parts []string
+	last := 0
+	for _, match := range matches {
+		if match[0] > last {
+			parts = append(parts, strconv.Quote(s[last:match[0]]))
+		}
+		name := s[match[2]:match[3]]
+		switch {
+		case name == "$guid" || name == "$randomUUID":
+			c.usesUUID = true
+			parts = append(parts, "uuidv4()")
+		case dynamicVariables[name] != "":
+			parts = append(parts, "("+dynamicVariables[name]+")")
+		default:
+			c.scripts.usesVars = true
+			parts = append(parts, fmt.Sprintf("vars[%q]", name))
+		}
+		last = match[1]
+	}
+	if last < len(s) {
+		parts = append(parts, strconv.Quote(s[last:]))
+	}
+	if len(parts) == 1 && !strings.HasPrefix(parts[0], `"`) {
+		// a single variable has to be a string too
+		return "String(" + parts[0] + ")"
+	}
+	if !strings.HasPrefix(parts[0], `"`) && !strings.HasPrefix(parts[1], `"`) {
+		parts = append([]string{`""`}, parts...)
+	}
+	return strings.Join(parts, " + ")
+}
+
+// file returns the constant with the content of a file, which is opened in the init context
+func (c *converter) file(path string) string {
+	name, ok := c.files[path]
+	if !ok {
+		name = fmt.Sprintf("FILE_%d", len(c.fileOrder))
+		c.files[path] = name
+		c.fileOrder = append(c.fileOrder, path)
+	}
+	return name
+}
+
+func writeIndented(w io.Writer, indent string, code string) {
+	for _, line := range strings.Split(code, "\n") {
+		if strings.TrimSpace(line) == "" {
+			printer.Fprint(w, "\n")
+			continue
+		}
+		printer.Fprintf(w, "%s%s\n", indent, line)
+	}
+}
+
+func (c *converter) writeItems(w io.Writer, items []*Item, scopes []scope, depth int) error {
+	indent := strings.Repeat("\t", depth)
+	for _, item := range items {
+		if !item.IsFolder() {
+			if item.Request == nil {
+				continue
+			}
+			if err := c.writeRequest(w, item, scopes, depth); err != nil {
+				return errors.Wrapf(err, "couldn't convert the request %q", item.Name)
+			}
+			continue
+		}
+		printer.Fprintf(w, "%sgroup(%q, function() {\n", indent, item.Name)
+		folderScopes := append(append([]scope{}, scopes...), scope{auth: item.Auth, event: item.Event})
+		if err := c.writeItems(w, item.Item, folderScopes, depth+1); err != nil {
+			return err
+		}
+		printer.Fprintf(w, "%s});\n", indent)
+	}
+	return nil
+}
+
+// writeScripts writes the scripts of the scopes and the item that listen to the event
+func (c *converter) writeScripts(w io.Writer, indent, listen string, scopes []scope, item *Item) {
+	events := make([]*Event, 0)
+	for _, s := range scopes {
+		events = append(events, s.event...)
+	}
+	events = append(events, item.Event...)
+	for _, e := range events {
+		if e.Disabled || e.Listen != listen {
+			continue
+		}
+		lines := c.scripts.translate(e.Script.Exec)
+		if len(lines) == 0 {
+			continue
+		}
+		// a block keeps the declarations of the scripts of different requests apart
+		printer.Fprintf(w, "%s{\n", indent)
+		writeIndented(w, indent+"\t", strings.Join(lines, "\n"))
+		printer.Fprintf(w, "%s}\n", indent)
+	}
+}
+
+// request is a request of the generated script, with JS expressions as values
+type request struct {
+	method  string
+	url     string
+	body    string
+	headers []string
+	todos   []string
+}
+
+func (c *converter) writeRequest(w io.Writer, item *Item, scopes []scope, depth int) error {
+	indent := strings.Repeat("\t", depth)
+	r, err := c.request(item, scopes)
+	if err != nil {
+		return err
+	}
+
+	printer.Fprintf(w, "%s// %s\n", indent, item.Name)
+	for _, todo := range r.todos {
+		printer.Fprintf(w, "%s// TODO: %s\n", indent, todo)
+	}
+	c.writeScripts(w, indent, "prerequest", scopes, item)
+
+	var params string
+	if len(r.headers) > 0 {
+		params = fmt.Sprintf("{\n%s\t\"headers\": {\n%s\t\t%s\n%s\t}\n%s}",
+			indent, indent, strings.Join(r.headers, ",\n"+indent+"\t\t"), indent, indent)
+	}
+	var args []string
+	method := strings.ToLower(r.method)
+	switch {
+	case (r.method == "GET" || r.method == "HEAD") && r.body == "":
+		args = []string{r.url}
+	case r.method == "POST" || r.method == "PUT" || r.method == "PATCH" || r.method == "DELETE" || r.method == "OPTIONS":
+		args = []string{r.url, r.body}
+	default:
+		method = "request"
+		args = []string{strconv.Quote(r.method), r.url, r.body}
+	}
+	if method == "delete" {
+		method = "del"
+	}
+	if len(args) > 1 && args[len(args)-1] == "" {
+		args[len(args)-1] = "null"
+	}
+	if params != "" {
+		args = append(args, params)
+	}
+	printer.Fprintf(w, "%sres = http.%s(%s);\n", indent, method, strings.Join(args, ", "))
+
+	if c.opts.EnableChecks {
+		c.writeExampleCheck(w, indent, item.Response)
+	}
+	c.writeScripts(w, indent, "test", scopes, item)
+	return nil
+}
+
+// writeExampleCheck writes a check for the status codes of the saved examples of a request
+func (c *converter) writeExampleCheck(w io.Writer, indent string, examples []*Response) {
+	var codes []int
+	seen := make(map[int]bool)
+	for _, example := range examples {
+		if example.Code > 0 && !seen[example.Code] {
+			seen[example.Code] = true
+			codes = append(codes, example.Code)
+		}
+	}
+	if len(codes) == 0 {
+		return
+	}
+	sort.Ints(codes)
+
+	names := make([]string, len(codes))
+	for i, code := range codes {
+		names[i] = strconv.Itoa(code)
+	}
+	name := "status is " + strings.Join(names, " or ")
+	condition := fmt.Sprintf("r.status === %d", codes[0])
+	if len(codes) > 1 {
+		condition = fmt.Sprintf("[%s].indexOf(r.status) !== -1", strings.Join(names, ", "))
+	}
+
+	c.scripts.usesCheck = true
+	if c.opts.ReturnOnFailedCheck {
+		printer.Fprintf(w, "%sif (!check(res, {%q: (r) => %s })) { return };\n", indent, name, condition)
+	} else {
+		printer.Fprintf(w, "%scheck(res, {%q: (r) => %s });\n", indent, name, condition)
+	}
+}
+
+//nolint:gochecknoglobals
+var (
+	pathVariableRe = regexp.MustCompile(`/:([A-Za-z0-9_\-]+)`)
+	schemeRe       = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.\-]*://`)
+
+	// languageContentTypes are the content types that Postman sets for the languages of raw bodies
+	languageContentTypes = map[string]string{
+		"json":       "application/json",
+		"xml":        "application/xml",
+		"html":       "text/html",
+		"javascript": "application/javascript",
+		"text":       "text/plain",
+	}
+)
+
+func (c *converter) request(item *Item, scopes []scope) (*request, error) {
+	req := item.Request
+	r := &request{method: strings.ToUpper(req.Method)}
+
+	u := req.URL.Raw
+	if u == "" {
+		return nil, errors.New("the request has no URL")
+	}
+	if !schemeRe.MatchString(u) && !strings.HasPrefix(u, "{{") {
+		// Postman defaults to HTTP
+		u = "http://" + u
+	}
+	pathVariables := make(map[string]string)
+	for _, v := range req.URL.Variable {
+		pathVariables[v.Key] = v.Value
+	}
+	path := u
+	query := ""
+	if i := strings.IndexAny(u, "?#"); i != -1 {
+		path, query = u[:i], u[i:]
+	}
+	path = pathVariableRe.ReplaceAllStringFunc(path, func(match string) string {
+		if value, ok := pathVariables[match[2:]]; ok {
+			return "/" + value
+		}
+		return match
+	})
+	r.url = c.expr(path + query)
+
+	hasContentType := false
+	for _, h := range req.Header {
+		if h.Disabled || h.Key == "" {
+			continue
+		}
+		if strings.EqualFold(h.Key, "Content-Type") {
+			hasContentType = true
+		}
+		r.headers = append(r.headers, fmt.Sprintf("%q: %s", h.Key, c.expr(h.Value)))
+	}
+
+	contentType, err := c.addBody(r, req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" && !hasContentType {
+		r.headers = append(r.headers, fmt.Sprintf("%q: %q", "Content-Type", contentType))
+	}
+
+	auth := req.Auth
+	for i := len(scopes) - 1; auth == nil && i >= 0; i-- {
+		auth = scopes[i].auth
+	}
+	c.addAuth(r, auth)
+	return r, nil
+}
+
+// addBody sets the body of the request, and returns the content type that
+// Postman would send with it
+func (c *converter) addBody(r *request, body *Body) (string, error) {
+	if body == nil || body.Disabled {
+		return "", nil
+	}
+	switch body.Mode {
+	case "raw":
+		if body.Raw == "" {
+			return "", nil
+		}
+		r.body = c.expr(body.Raw)
+		if body.Options != nil {
+			return languageContentTypes[body.Options.Raw.Language], nil
+		}
+		return "text/plain", nil
+	case "urlencoded":
+		var fields []string
+		for _, f := range body.URLEncoded {
+			if !f.Disabled {
+				fields = append(fields, fmt.Sprintf("%q: %s", f.Key, c.expr(f.Value)))
+			}
+		}
+		if len(fields) > 0 {
+			r.body = "{ " + strings.Join(fields, ", ") + " }"
+		}
+		return "", nil
+	case "formdata":
+		var fields []string
+		hasFiles := false
+		for _, f := range body.FormData {
+			if f.Disabled {
+				continue
+			}
+			if f.Type != "file" {
+				fields = append(fields, fmt.Sprintf("%q: %s", f.Key, c.expr(f.Value)))
+				continue
+			}
+			var sources []string
+			switch src := f.Src.(type) {
+			case string:
+				sources = []string{src}
+			case []interface{}:
+				for _, s := range src {
+					if s, ok := s.(string); ok {
+						sources = append(sources, s)
+					}
+				}
+			}
+			if len(sources) == 0 {
+				r.todos = append(r.todos, fmt.Sprintf("the file of the form field %q isn't set", f.Key))
+				continue
+			}
+			if len(sources) > 1 {
+				r.todos = append(r.todos, fmt.Sprintf("only the first file of the form field %q is sent", f.Key))
+			}
+			hasFiles = true
+			name := sources[0][strings.LastIndexAny(sources[0], `/\`)+1:]
+			fields = append(fields, fmt.Sprintf("%q: http.file(%s, %q)", f.Key, c.file(sources[0]), name))
+		}
+		if len(fields) > 0 {
+			r.body = "{ " + strings.Join(fields, ", ") + " }"
+			if !hasFiles {
+				r.todos = append(r.todos, "k6 only sends form data as multipart/form-data when it has files")
+			}
+		}
+		return "", nil
+	case "file":
+		if body.File == nil || body.File.Src == "" {
+			r.todos = append(r.todos, "the file of the body isn't set")
+			return "", nil
+		}
+		r.body = c.file(body.File.Src)
+		return "", nil
+	case "graphql":
+		if body.GraphQL == nil {
+			return "", nil
+		}
+		fields := []string{fmt.Sprintf("\"query\": %s", c.expr(body.GraphQL.Query))}
+		if strings.TrimSpace(body.GraphQL.Variables) != "" {
+			fields = append(fields, fmt.Sprintf("\"variables\": JSON.parse(%s)", c.expr(body.GraphQL.Variables)))
+		}
+		r.body = "JSON.stringify({ " + strings.Join(fields, ", ") + " })"
+		return "application/json", nil
+	case "":
+		return "", nil
+	default:
+		return "", errors.Errorf("unsupported body mode %q", body.Mode)
+	}
+}
+
+// authValue returns the value of a parameter of an authentication method
+func authValue(params []*AuthParam, key string) string {
+	for _, p := range params {
+		if p.Key == key {
+			return formatValue(p.Value)
+		}
+	}
+	return ""
+}
+
+// authParam returns the expression of the value of a parameter of an authentication method
+func (c *converter) authParam(params []*AuthParam, key string) string {
+	return c.expr(authValue(params, key))
+}
+
+// addAuth adds the credentials of the authentication to the request
+func (c *converter) addAuth(r *request, auth *Auth) {
+	if auth == nil {
+		return
+	}
+	switch auth.Type {
+	case "noauth", "":
+	case "basic":
+		c.usesEncoding = true
+		r.headers = append(r.headers, fmt.Sprintf(`"Authorization": "Basic " + encoding.b64encode(%s + ":" + %s)`,
+			c.authParam(auth.Basic, "username"), c.authParam(auth.Basic, "password")))
+	case "bearer":
+		r.headers = append(r.headers, fmt.Sprintf(`"Authorization": "Bearer " + %s`, c.authParam(auth.Bearer, "token")))
+	case "apikey":
+		key := authValue(auth.APIKey, "key")
+		value := c.authParam(auth.APIKey, "value")
+		if authValue(auth.APIKey, "in") == "query" {
+			separator := "?"
+			if strings.Contains(r.url, "?") {
+				separator = "&"
+			}
+			r.url += fmt.Sprintf(" + %q + encodeURIComponent(%s)", separator+url.QueryEscape(key)+"=", value)
+		} else {
+			r.headers = append(r.headers, fmt.Sprintf("%q: %s", key, value))
+		}
+	default:
+		r.todos = append(r.todos, fmt.Sprintf("the %s authentication isn't supported", auth.Type))
+	}
+}
@@ -0,0 +1,265 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package postman
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/loadimpact/k6/converter/internal/printer"
+	"github.com/loadimpact/k6/js"
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/testutils"
+	"github.com/loadimpact/k6/loader"
+)
+
+const testCollection = `{
+	"info": {
+		"name": "Shop",
+		"schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+	},
+	"auth": {
+		"type": "bearer",
+		"bearer": [{"key": "token", "value": "{{token}}", "type": "string"}]
+	},
+	"variable": [
+		{"key": "baseUrl", "value": "https://shop.example.com"},
+		{"key": "token", "value": ""}
+	],
+	"item": [
+		{
+			"name": "Login",
+			"event": [{
+				"listen": "test",
+				"script": {
+					"type": "text/javascript",
+					"exec": [
+						"pm.test(\"logged in\", function () {",
+						"    pm.response.to.have.status(200);",
+						"});",
+						"pm.environment.set(\"token\", pm.response.json().token);"
+					]
+				}
+			}],
+			"request": {
+				"auth": {"type": "noauth"},
+				"method": "POST",
+				"header": [{"key": "X-Disabled", "value": "1", "disabled": true}],
+				"body": {
+					"mode": "raw",
+					"raw": "{\"user\": \"{{user}}\"}",
+					"options": {"raw": {"language": "json"}}
+				},
+				"url": {"raw": "{{baseUrl}}/login", "host": ["{{baseUrl}}"], "path": ["login"]}
+			},
+			"response": [{"name": "OK", "code": 200, "header": "Content-Type: application/json"}]
+		},
+		{
+			"name": "Products",
+			"event": [{
+				"listen": "prerequest",
+				"script": {"exec": "pm.variables.set(\"page\", 2);\nconsole.log(pm.info.requestName);"}
+			}],
+			"item": [
+				{
+					"name": "Get product",
+					"request": {
+						"method": "GET",
+						"header": [{"key": "X-Request-ID", "value": "{{$guid}}"}],
+						"url": {
+							"raw": "{{baseUrl}}/products/:id?page={{page}}",
+							"variable": [{"key": "id", "value": "42"}]
+						}
+					},
+					"response": [
+						{"name": "Found", "code": 200},
+						{"name": "Missing", "code": 404}
+					]
+				},
+				{
+					"name": "Upload image",
+					"request": {
+						"auth": {
+							"type": "basic",
+							"basic": [
+								{"key": "username", "value": "admin"},
+								{"key": "password", "value": "{{password}}"}
+							]
+						},
+						"method": "PUT",
+						"body": {
+							"mode": "formdata",
+							"formdata": [
+								{"key": "title", "value": "Shoe", "type": "text"},
+								{"key": "image", "src": "/tmp/shoe.png", "type": "file"}
+							]
+						},
+						"url": "shop.example.com/products/42/image"
+					}
+				}
+			]
+		},
+		{
+			"name": "Search",
+			"request": {
+				"auth": {
+					"type": "apikey",
+					"apikey": [
+						{"key": "key", "value": "api_key"},
+						{"key": "value", "value": "{{apiKey}}"},
+						{"key": "in", "value": "query"}
+					]
+				},
+				"method": "POST",
+				"body": {
+					"mode": "graphql",
+					"graphql": {"query": "{ products { id } }", "variables": "{\"first\": 10}"}
+				},
+				"url": "{{baseUrl}}/graphql"
+			}
+		},
+		{
+			"name": "Ping",
+			"request": "https://shop.example.com/ping"
+		}
+	]
+}`
+
+const testEnvironment = `{
+	"name": "Staging",
+	"values": [
+		{"key": "baseUrl", "value": "https://staging.example.com", "enabled": true},
+		{"key": "user", "value": "tester", "enabled": true},
+		{"key": "unused", "value": "x", "enabled": false}
+	]
+}`
+
+func checkScript(t *testing.T, script string) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/tmp/shoe.png", []byte("image"), 0o644))
+	_, err := js.New(testutils.NewLogger(t), &loader.SourceData{
+		URL:  &url.URL{Path: "/script.js", Scheme: "file"},
+		Data: []byte(script),
+	}, map[string]afero.Fs{"file": fs}, lib.RuntimeOptions{})
+	assert.NoError(t, err, script)
+}
+
+func TestConvert(t *testing.T) {
+	t.Parallel()
+	collection, err := Decode(strings.NewReader(testCollection))
+	require.NoError(t, err)
+	env, err := DecodeEnvironment(strings.NewReader(testEnvironment))
+	require.NoError(t, err)
+
+	t.Run("Checks", func(t *testing.T) {
+		t.Parallel()
+		script, err := Convert(collection, Options{
+			ScriptOptions: printer.ScriptOptions{
+				Options:      lib.Options{VUs: null.IntFrom(1), Iterations: null.IntFrom(1)},
+				MinSleep:     1,
+				MaxSleep:     2,
+				EnableChecks: true,
+			},
+			Environment: env,
+		})
+		require.NoError(t, err)
+		checkScript(t, script)
+
+		for _, expected := range []string{
+			"import { group, check, sleep } from 'k6';\n",
+			"import encoding from 'k6/encoding';\n",
+			"// Collection: Shop\n// Environment: Staging\n",
+			"    vus: 1,\n    iterations: 1,\n",
+			// the environment overrides the collection variables
+			`"baseUrl": __ENV["baseUrl"] || "https://staging.example.com",`,
+			`"user": __ENV["user"] || "tester",`,
+			`const FILE_0 = open("/tmp/shoe.png", "b");`,
+			"function uuidv4() {",
+			// requests with the inherited auth and the scripts
+			"\t// Login\n\tres = http.post(vars[\"baseUrl\"] + \"/login\", \"{\\\"user\\\": \\\"\" + vars[\"user\"] + \"\\\"}\", {\n" +
+				"\t\t\"headers\": {\n\t\t\t\"Content-Type\": \"application/json\"\n\t\t}\n\t});\n",
+			"\tcheck(res, {\"status is 200\": (r) => r.status === 200 });\n" +
+				"\t{\n\t\tcheck(res, {\"logged in\": (r) => r.status === 200 });\n" +
+				"\t\tvars[\"token\"] = res.json().token;\n\t}\n",
+			"\tgroup(\"Products\", function() {\n\t\t// Get product\n\t\t{\n\t\t\tvars[\"page\"] = 2;\n" +
+				"\t\t\t// TODO: translate this part of the Postman script to k6\n\t\t\t// console.log(pm.info.requestName);\n\t\t}\n",
+			"res = http.get(vars[\"baseUrl\"] + \"/products/42?page=\" + vars[\"page\"], {",
+			`"X-Request-ID": String(uuidv4()),`,
+			`"Authorization": "Bearer " + String(vars["token"])`,
+			`check(res, {"status is 200 or 404": (r) => [200, 404].indexOf(r.status) !== -1 });`,
+			"res = http.put(\"http://shop.example.com/products/42/image\", " +
+				"{ \"title\": \"Shoe\", \"image\": http.file(FILE_0, \"shoe.png\") }, {",
+			`"Authorization": "Basic " + encoding.b64encode("admin" + ":" + String(vars["password"]))`,
+			"res = http.post(vars[\"baseUrl\"] + \"/graphql\" + \"?api_key=\" + encodeURIComponent(String(vars[\"apiKey\"])), " +
+				"JSON.stringify({ \"query\": \"{ products { id } }\", \"variables\": JSON.parse(\"{\\\"first\\\": 10}\") }), {",
+			"\t// Ping\n\tres = http.get(\"https://shop.example.com/ping\", {",
+			"sleep(Math.floor(Math.random()*1+1));",
+		} {
+			assert.Contains(t, script, expected)
+		}
+		assert.NotContains(t, script, "X-Disabled")
+		assert.NotContains(t, script, "unused")
+	})
+
+	t.Run("NoChecks", func(t *testing.T) {
+		t.Parallel()
+		script, err := Convert(collection, Options{})
+		require.NoError(t, err)
+		checkScript(t, script)
+		assert.Contains(t, script, `"baseUrl": __ENV["baseUrl"] || "https://shop.example.com",`)
+		// the tests of the scripts are still translated
+		assert.Contains(t, script, "import { group, check, sleep } from 'k6';\n")
+		assert.NotContains(t, script, "status is 200 or 404")
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := Convert(collection, Options{ScriptOptions: printer.ScriptOptions{ReturnOnFailedCheck: true}})
+		assert.EqualError(t, err, "return on failed check requires --enable-status-code-checks")
+		_, err = Convert(collection, Options{ScriptOptions: printer.ScriptOptions{MinSleep: 2, MaxSleep: 1}})
+		assert.EqualError(t, err, "the maximum sleep can't be less than the minimum sleep")
+		_, err = Convert(&Collection{Item: []*Item{{Name: "Empty", Request: &Request{Method: "GET"}}}}, Options{})
+		assert.EqualError(t, err, `couldn't convert the request "Empty": the request has no URL`)
+	})
+}
+
+func TestExpr(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		value, expr string
+	}{
+		{"plain", `"plain"`},
+		{"{{host}}", `String(vars["host"])`},
+		{"{{ host }}/path", `vars["host"] + "/path"`},
+		{"{{scheme}}{{host}}", `"" + vars["scheme"] + vars["host"]`},
+		{"t={{$timestamp}}", `"t=" + (Math.floor(Date.now() / 1000))`},
+	}
+	for _, tc := range testCases {
+		c := &converter{}
+		assert.Equal(t, tc.expr, c.expr(tc.value), tc.value)
+	}
+}
@@ -0,0 +1,82 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package postman
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	schemaPrefix = "https://schema.getpostman.com/json/collection/"
+	schemaV21    = schemaPrefix + "v2.1.0/"
+)
+
+type collectionInfo struct {
+	Info *struct {
+		Schema string `json:"schema"`
+	} `json:"info"`
+}
+
+// IsCollection returns whether the data is a Postman collection, of any version.
+func IsCollection(data []byte) bool {
+	var c collectionInfo
+	return json.Unmarshal(data, &c) == nil && c.Info != nil &&
+		strings.HasPrefix(strings.Replace(c.Info.Schema, "http://", "https://", 1), schemaPrefix)
+}
+
+// Decode reads a Postman collection v2.1.
+func Decode(r io.Reader) (*Collection, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var info collectionInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, errors.Wrap(err, "invalid Postman collection")
+	}
+	if info.Info == nil {
+		return nil, errors.New("invalid Postman collection, the 'info' property is missing")
+	}
+	schema := strings.Replace(info.Info.Schema, "http://", "https://", 1)
+	if !strings.HasPrefix(schema, schemaV21) {
+		return nil, errors.Errorf("unsupported Postman collection schema %s, only v2.1.0 is supported", info.Info.Schema)
+	}
+
+	var c Collection
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, errors.Wrap(err, "invalid Postman collection")
+	}
+	return &c, nil
+}
+
+// DecodeEnvironment reads an exported Postman environment.
+func DecodeEnvironment(r io.Reader) (*Environment, error) {
+	var env Environment
+	if err := json.NewDecoder(r).Decode(&env); err != nil {
+		return nil, errors.Wrap(err, "invalid Postman environment")
+	}
+	return &env, nil
+}
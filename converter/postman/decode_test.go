@@ -0,0 +1,62 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package postman
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, IsCollection([]byte(testCollection)))
+	assert.True(t, IsCollection([]byte(`{"info": {"schema": "http://schema.getpostman.com/json/collection/v2.0.0/collection.json"}}`)))
+	assert.False(t, IsCollection([]byte(`{"log": {"version": "1.2"}}`)))
+	assert.False(t, IsCollection([]byte(`openapi: 3.0.0`)))
+
+	c, err := Decode(strings.NewReader(testCollection))
+	require.NoError(t, err)
+	assert.Equal(t, "Shop", c.Info.Name)
+	require.Len(t, c.Item, 4)
+	assert.False(t, c.Item[0].IsFolder())
+	assert.Equal(t, "{{baseUrl}}/login", c.Item[0].Request.URL.Raw)
+	assert.Nil(t, c.Item[0].Response[0].Header)
+	assert.Len(t, c.Item[0].Event[0].Script.Exec, 4)
+	assert.True(t, c.Item[1].IsFolder())
+	assert.Len(t, c.Item[1].Event[0].Script.Exec, 2)
+	assert.Equal(t, "shop.example.com/products/42/image", c.Item[1].Item[1].Request.URL.Raw)
+	assert.Equal(t, &Request{Method: "GET", URL: URL{Raw: "https://shop.example.com/ping"}}, c.Item[3].Request)
+
+	_, err = Decode(strings.NewReader(`{"info": {"schema": "https://schema.getpostman.com/json/collection/v2.0.0/collection.json"}}`))
+	assert.EqualError(t, err, "unsupported Postman collection schema "+
+		"https://schema.getpostman.com/json/collection/v2.0.0/collection.json, only v2.1.0 is supported")
+	_, err = Decode(strings.NewReader(`{"item": []}`))
+	assert.EqualError(t, err, "invalid Postman collection, the 'info' property is missing")
+
+	env, err := DecodeEnvironment(strings.NewReader(testEnvironment))
+	require.NoError(t, err)
+	assert.Equal(t, "Staging", env.Name)
+	assert.Len(t, env.Values, 3)
+}
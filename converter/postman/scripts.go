@@ -0,0 +1,248 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package postman
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Postman scripts are JavaScript, but they use the pm API of the Postman
+// sandbox. Only the common uses of it, reading and writing variables,
+// reading the response and simple tests, are translated to k6; the rest of
+// the statements are left as TODO comments.
+
+const jsString = `("(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*')`
+
+//nolint:gochecknoglobals
+var (
+	testRe = regexp.MustCompile(`(?s)pm\.test\(\s*` + jsString +
+		`\s*,\s*(?:function\s*\(\s*\)|\(\s*\)\s*=>)\s*\{(.*?)\}\s*\)\s*;?`)
+
+	setterRe = regexp.MustCompile(`^(\s*)(?:pm\.(?:environment|variables|collectionVariables|globals)\.set|` +
+		`postman\.set(?:Environment|Global)Variable)\(\s*` + jsString + `\s*,\s*(.+?)\s*\)\s*;?\s*$`)
+	unsetRe = regexp.MustCompile(`^(\s*)(?:pm\.(?:environment|variables|collectionVariables|globals)\.unset|` +
+		`postman\.clear(?:Environment|Global)Variable)\(\s*` + jsString + `\s*\)\s*;?\s*$`)
+	getterRe = regexp.MustCompile(`(?:pm\.(?:environment|variables|collectionVariables|globals)\.get|` +
+		`postman\.get(?:Environment|Global)Variable)\(\s*` + jsString + `\s*\)`)
+	responseHeaderRe = regexp.MustCompile(`(?:pm\.response\.headers\.get|postman\.getResponseHeader)\(\s*` +
+		jsString + `\s*\)`)
+
+	// assertions are the statements of tests that can be turned into the condition of a check
+	assertions = []struct {
+		re        *regexp.Regexp
+		condition string
+	}{
+		{regexp.MustCompile(`^pm\.response\.to\.have\.status\((\d+)\)$`), "r.status === $1"},
+		{regexp.MustCompile(`^pm\.expect\(pm\.response\.code\)\.to\.(?:eql|equal|eq|be\.equal)\((\d+)\)$`), "r.status === $1"},
+		{regexp.MustCompile(`^pm\.expect\(pm\.response\.code\)\.to\.be\.oneOf\(\[([\d,\s]+)\]\)$`), "[$1].indexOf(r.status) !== -1"},
+		{regexp.MustCompile(`^pm\.response\.to\.be\.ok$`), "r.status === 200"},
+		{regexp.MustCompile(`^pm\.response\.to\.be\.success$`), "r.status >= 200 && r.status < 300"},
+		{regexp.MustCompile(`^pm\.response\.to\.have\.header\(` + jsString + `\)$`), "r.headers[$1] !== undefined"},
+		{regexp.MustCompile(`^pm\.expect\(pm\.response\.responseTime\)\.to\.be\.below\((\d+)\)$`), "r.timings.duration < $1"},
+		{regexp.MustCompile(`^pm\.response\.to\.have\.body\(` + jsString + `\)$`), "r.body === $1"},
+		{regexp.MustCompile(`^pm\.expect\(pm\.response\.text\(\)\)\.to\.include\(` + jsString + `\)$`), "r.body.indexOf($1) !== -1"},
+	}
+)
+
+// scriptTranslator translates the Postman scripts of a conversion
+type scriptTranslator struct {
+	// res is the name of the variable with the response
+	res                 string
+	returnOnFailedCheck bool
+	// usesVars and usesCheck are set when the translated code does
+	usesVars, usesCheck bool
+}
+
+// translate returns the k6 code of a Postman script
+func (t *scriptTranslator) translate(source []string) []string {
+	script := strings.Join(source, "\n")
+	var lines []string
+	last := 0
+	for _, match := range testRe.FindAllStringSubmatchIndex(script, -1) {
+		// the line breaks around tests don't make lines of their own
+		before := strings.TrimSuffix(script[last:match[0]], "\n")
+		if last > 0 {
+			before = strings.TrimPrefix(before, "\n")
+		}
+		if last == 0 || before != "" {
+			lines = append(lines, t.statements(before)...)
+		}
+		lines = append(lines, t.test(script[match[0]:match[1]], script[match[2]:match[3]], script[match[4]:match[5]]))
+		last = match[1]
+	}
+	if last > 0 {
+		lines = append(lines, t.statements(strings.TrimPrefix(script[last:], "\n"))...)
+	} else {
+		lines = append(lines, t.statements(script)...)
+	}
+
+	// without blank lines at the start and the end
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// test returns a check with the assertions of a test, or a TODO comment if
+// any of them can't be translated
+func (t *scriptTranslator) test(source, name, body string) string {
+	var conditions []string
+	for _, statement := range strings.FieldsFunc(body, func(r rune) bool { return r == ';' || r == '\n' }) {
+		statement = strings.TrimSpace(statement)
+		if statement == "" {
+			continue
+		}
+		condition := ""
+		for _, a := range assertions {
+			if a.re.MatchString(statement) {
+				condition = a.re.ReplaceAllString(statement, a.condition)
+				break
+			}
+		}
+		if condition == "" {
+			return todo(source)
+		}
+		conditions = append(conditions, condition)
+	}
+	if len(conditions) == 0 {
+		return todo(source)
+	}
+
+	t.usesCheck = true
+	if len(conditions) > 1 {
+		for i, condition := range conditions {
+			conditions[i] = "(" + condition + ")"
+		}
+	}
+	check := fmt.Sprintf("check(%s, {%s: (r) => %s })", t.res, name, strings.Join(conditions, " && "))
+	if t.returnOnFailedCheck {
+		return "if (!" + check + ") { return };"
+	}
+	return check + ";"
+}
+
+// statements translates the statements outside of tests, which are grouped
+// so that statements spanning multiple lines are left as a whole in a TODO
+// comment when they can't be translated
+func (t *scriptTranslator) statements(source string) []string {
+	var result, statement []string
+	translatable := true
+	depth := 0
+	for _, line := range strings.Split(source, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		translated, ok := t.statement(line)
+		translatable = translatable && ok
+		statement = append(statement, translated)
+		depth += nesting(line)
+		if depth > 0 {
+			continue
+		}
+		if translatable && depth == 0 {
+			result = append(result, statement...)
+		} else {
+			result = append(result, todo(strings.Join(statement, "\n")))
+		}
+		statement, translatable, depth = nil, true, 0
+	}
+	if len(statement) > 0 {
+		result = append(result, todo(strings.Join(statement, "\n")))
+	}
+	return result
+}
+
+// statement translates a line of a script, returning false when there are
+// uses of the Postman API left in it
+func (t *scriptTranslator) statement(line string) (string, bool) {
+	if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "//") {
+		return line, true
+	}
+	translated := line
+	if setterRe.MatchString(translated) {
+		translated = setterRe.ReplaceAllString(translated, "${1}vars[$2] = $3;")
+		t.usesVars = true
+	}
+	if unsetRe.MatchString(translated) {
+		translated = unsetRe.ReplaceAllString(translated, "${1}delete vars[$2];")
+		t.usesVars = true
+	}
+	if getterRe.MatchString(translated) {
+		translated = getterRe.ReplaceAllString(translated, "vars[$1]")
+		t.usesVars = true
+	}
+	translated = responseHeaderRe.ReplaceAllString(translated, t.res+".headers[$1]")
+	translated = strings.NewReplacer(
+		"pm.response.json()", t.res+".json()",
+		"pm.response.text()", t.res+".body",
+		"pm.response.code", t.res+".status",
+		"pm.response.responseTime", t.res+".timings.duration",
+		"JSON.parse(responseBody)", t.res+".json()",
+		"responseCode.code", t.res+".status",
+	).Replace(translated)
+	if strings.Contains(translated, "pm.") || strings.Contains(translated, "postman.") ||
+		strings.Contains(translated, "responseBody") || strings.Contains(translated, "tests[") {
+		return line, false
+	}
+	return translated, true
+}
+
+// nesting returns how many more brackets are opened than closed in a line,
+// outside of strings
+func nesting(line string) int {
+	depth := 0
+	var quote, previous rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case quote == 0 && previous == '/' && r == '/':
+			return depth
+		case escaped:
+			escaped = false
+		case quote != 0:
+			if r == '\\' {
+				escaped = true
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'' || r == '`':
+			quote = r
+		case r == '(' || r == '{' || r == '[':
+			depth++
+		case r == ')' || r == '}' || r == ']':
+			depth--
+		}
+		previous = r
+	}
+	return depth
+}
+
+// todo comments the source out, for it to be translated by hand
+func todo(source string) string {
+	lines := strings.Split(source, "\n")
+	for i, line := range lines {
+		lines[i] = "// " + line
+	}
+	return "// TODO: translate this part of the Postman script to k6\n" + strings.Join(lines, "\n")
+}
@@ -0,0 +1,85 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package postman
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslateScript(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name, source, result string
+	}{
+		{
+			"Variables",
+			"pm.environment.set('id', pm.response.json().id);\nconst id = pm.variables.get(\"id\");\npm.globals.unset(\"old\")",
+			"vars['id'] = res.json().id;\nconst id = vars[\"id\"];\ndelete vars[\"old\"];",
+		},
+		{
+			"LegacyAPI",
+			"var data = JSON.parse(responseBody);\npostman.setEnvironmentVariable(\"code\", responseCode.code);",
+			"var data = res.json();\nvars[\"code\"] = res.status;",
+		},
+		{
+			"Tests",
+			"pm.test('ok', () => {\n  pm.response.to.have.status(201);\n  pm.response.to.have.header(\"Location\");\n});",
+			"check(res, {'ok': (r) => (r.status === 201) && (r.headers[\"Location\"] !== undefined) });",
+		},
+		{
+			"UnsupportedTest",
+			"pm.test(\"schema\", function () { pm.response.to.have.jsonSchema(schema); });",
+			"// TODO: translate this part of the Postman script to k6\n" +
+				"// pm.test(\"schema\", function () { pm.response.to.have.jsonSchema(schema); });",
+		},
+		{
+			"MultilineStatement",
+			"pm.sendRequest({\n  url: \"https://example.com\"\n}, function () {});\nlet a = 1; // (",
+			"// TODO: translate this part of the Postman script to k6\n" +
+				"// pm.sendRequest({\n//   url: \"https://example.com\"\n// }, function () {});\nlet a = 1; // (",
+		},
+		{
+			"Headers",
+			"\n\nconst type = pm.response.headers.get(\"Content-Type\");\n\n",
+			"const type = res.headers[\"Content-Type\"];",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			translator := &scriptTranslator{res: "res"}
+			assert.Equal(t, tc.result, strings.Join(translator.translate(strings.Split(tc.source, "\n")), "\n"))
+		})
+	}
+
+	t.Run("ReturnOnFailedCheck", func(t *testing.T) {
+		t.Parallel()
+		translator := &scriptTranslator{res: "res", returnOnFailedCheck: true}
+		lines := translator.translate([]string{`pm.test("ok", function() { pm.response.to.be.ok; });`})
+		assert.Equal(t, []string{`if (!check(res, {"ok": (r) => r.status === 200 })) { return };`}, lines)
+		assert.True(t, translator.usesCheck)
+		assert.False(t, translator.usesVars)
+	})
+}
@@ -0,0 +1,273 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package postman
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Collection is the top level object of a Postman collection v2.1.
+type Collection struct {
+	Info     Info        `json:"info"`
+	Item     []*Item     `json:"item"`
+	Event    []*Event    `json:"event,omitempty"`
+	Variable []*Variable `json:"variable,omitempty"`
+	Auth     *Auth       `json:"auth,omitempty"`
+}
+
+// Info is the metadata of the collection.
+type Info struct {
+	Name        string      `json:"name"`
+	PostmanID   string      `json:"_postman_id,omitempty"`
+	Description Description `json:"description,omitempty"`
+	Schema      string      `json:"schema"`
+}
+
+// Item is either a request or a folder, which has items of its own.
+type Item struct {
+	Name        string      `json:"name"`
+	Description Description `json:"description,omitempty"`
+	Item        []*Item     `json:"item,omitempty"`
+	Request     *Request    `json:"request,omitempty"`
+	Response    []*Response `json:"response,omitempty"`
+	Event       []*Event    `json:"event,omitempty"`
+	Variable    []*Variable `json:"variable,omitempty"`
+	Auth        *Auth       `json:"auth,omitempty"`
+}
+
+// IsFolder returns whether the item is a folder.
+func (item *Item) IsFolder() bool {
+	return item.Request == nil && item.Item != nil
+}
+
+// Request is an HTTP request. A request can also be just its URL, in which
+// case it's a GET request.
+type Request struct {
+	Method      string      `json:"method"`
+	URL         URL         `json:"url"`
+	Header      []*KeyValue `json:"header,omitempty"`
+	Body        *Body       `json:"body,omitempty"`
+	Auth        *Auth       `json:"auth,omitempty"`
+	Description Description `json:"description,omitempty"`
+}
+
+// UnmarshalJSON supports requests that are only a URL string.
+func (r *Request) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		*r = Request{Method: "GET", URL: URL{Raw: raw}}
+		return nil
+	}
+	type request Request
+	var req request
+	if err := json.Unmarshal(data, &req); err != nil {
+		return err
+	}
+	*r = Request(req)
+	if r.Method == "" {
+		r.Method = "GET"
+	}
+	return nil
+}
+
+// URL is the URL of a request, which can be a string or an object.
+type URL struct {
+	Raw      string      `json:"raw"`
+	Query    []*KeyValue `json:"query,omitempty"`
+	Variable []*KeyValue `json:"variable,omitempty"`
+}
+
+// UnmarshalJSON supports URLs that are strings.
+func (u *URL) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		*u = URL{Raw: raw}
+		return nil
+	}
+	type url URL
+	var v url
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*u = URL(v)
+	return nil
+}
+
+// KeyValue is a header, a query parameter, a form field or a path variable.
+type KeyValue struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled,omitempty"`
+	// Type is "text" or "file" for form fields
+	Type string `json:"type,omitempty"`
+	// Src is the path of the file of a form field
+	Src interface{} `json:"src,omitempty"`
+}
+
+// Body is the body of a request.
+type Body struct {
+	// Mode is one of raw, urlencoded, formdata, file or graphql
+	Mode       string       `json:"mode"`
+	Raw        string       `json:"raw,omitempty"`
+	URLEncoded []*KeyValue  `json:"urlencoded,omitempty"`
+	FormData   []*KeyValue  `json:"formdata,omitempty"`
+	File       *BodyFile    `json:"file,omitempty"`
+	GraphQL    *GraphQL     `json:"graphql,omitempty"`
+	Options    *BodyOptions `json:"options,omitempty"`
+	Disabled   bool         `json:"disabled,omitempty"`
+}
+
+// BodyFile is a file sent as the body of a request.
+type BodyFile struct {
+	Src string `json:"src"`
+}
+
+// GraphQL is a GraphQL query sent as the body of a request.
+type GraphQL struct {
+	Query     string `json:"query"`
+	Variables string `json:"variables,omitempty"`
+}
+
+// BodyOptions holds the language of raw bodies.
+type BodyOptions struct {
+	Raw struct {
+		Language string `json:"language"`
+	} `json:"raw"`
+}
+
+// Response is a saved example response of a request.
+type Response struct {
+	Name   string      `json:"name"`
+	Status string      `json:"status,omitempty"`
+	Code   int         `json:"code,omitempty"`
+	Header []*KeyValue `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// UnmarshalJSON ignores headers that are a single string, which older
+// versions of Postman saved.
+func (r *Response) UnmarshalJSON(data []byte) error {
+	type plain Response
+	type response struct {
+		plain
+		Header json.RawMessage `json:"header,omitempty"`
+	}
+	var v response
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*r = Response(v.plain)
+	r.Header = nil
+	if len(v.Header) > 0 && v.Header[0] == '[' {
+		return json.Unmarshal(v.Header, &r.Header)
+	}
+	return nil
+}
+
+// Event is a script that's run before a request or after its response.
+type Event struct {
+	// Listen is "prerequest" or "test"
+	Listen   string `json:"listen"`
+	Script   Script `json:"script"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+// Script is the source of an event.
+type Script struct {
+	Type string `json:"type,omitempty"`
+	Exec Source `json:"exec"`
+}
+
+// Source is the source of a script, which is saved as a string or as an
+// array of lines.
+type Source []string
+
+// UnmarshalJSON supports sources that are strings.
+func (s *Source) UnmarshalJSON(data []byte) error {
+	var source string
+	if err := json.Unmarshal(data, &source); err == nil {
+		*s = strings.Split(source, "\n")
+		return nil
+	}
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err != nil {
+		return err
+	}
+	*s = lines
+	return nil
+}
+
+// Variable is a variable of a collection or a folder.
+type Variable struct {
+	Key      string      `json:"key"`
+	Value    interface{} `json:"value"`
+	Disabled bool        `json:"disabled,omitempty"`
+}
+
+// Auth is the authentication of a collection, a folder or a request, which
+// is inherited by their requests.
+type Auth struct {
+	// Type is one of noauth, basic, bearer, apikey, digest, oauth2, ...
+	Type   string       `json:"type"`
+	Basic  []*AuthParam `json:"basic,omitempty"`
+	Bearer []*AuthParam `json:"bearer,omitempty"`
+	APIKey []*AuthParam `json:"apikey,omitempty"`
+	Digest []*AuthParam `json:"digest,omitempty"`
+}
+
+// AuthParam is a parameter of an authentication method.
+type AuthParam struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+	Type  string      `json:"type,omitempty"`
+}
+
+// Description is the description of an item, which is a string or an object
+// with its content.
+type Description string
+
+// UnmarshalJSON supports descriptions that are objects.
+func (d *Description) UnmarshalJSON(data []byte) error {
+	var description string
+	if err := json.Unmarshal(data, &description); err == nil {
+		*d = Description(description)
+		return nil
+	}
+	var v struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*d = Description(v.Content)
+	return nil
+}
+
+// Environment is an exported Postman environment.
+type Environment struct {
+	Name   string `json:"name"`
+	Values []struct {
+		Key     string      `json:"key"`
+		Value   interface{} `json:"value"`
+		Enabled *bool       `json:"enabled,omitempty"`
+	} `json:"values"`
+}
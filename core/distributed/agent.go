@@ -0,0 +1,287 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package distributed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/loadimpact/k6/core"
+	"github.com/loadimpact/k6/core/local"
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/output"
+)
+
+// How long to wait between attempts to register with the coordinator.
+const registerRetryInterval = time.Second
+
+// RunnerFactory creates a new lib.Runner from the test archive the agent
+// received from the coordinator.
+type RunnerFactory func(archive []byte) (lib.Runner, error)
+
+// Agent executes a single execution segment of a distributed test run, as
+// instructed by the coordinator.
+type Agent struct {
+	client *Client
+	name   string
+	logger *logrus.Logger
+}
+
+// NewAgent returns a new agent that will connect to the coordinator with the
+// given address, authenticate with the given token and identify itself with
+// the given name.
+func NewAgent(coordinatorAddress, token, name string, logger *logrus.Logger) (*Agent, error) {
+	client, err := NewClient(coordinatorAddress, token)
+	if err != nil {
+		return nil, err
+	}
+	return &Agent{client: client, name: name, logger: logger}, nil
+}
+
+// register tries to register with the coordinator until it succeeds, the
+// coordinator rejects the token or the context is done.
+func (a *Agent) register(ctx context.Context, logger logrus.FieldLogger) (RegisterResponse, error) {
+	for {
+		resp, err := a.client.Register(ctx, a.name)
+		if err == nil || errors.Is(err, errUnauthorized) {
+			return resp, err
+		}
+		logger.WithError(err).Debug("Couldn't register with the coordinator, retrying...")
+		select {
+		case <-ctx.Done():
+			return resp, ctx.Err()
+		case <-time.After(registerRetryInterval):
+		}
+	}
+}
+
+// initRunner creates the runner from the archive and restricts it to the
+// execution segment the coordinator assigned to this agent. The setup() and
+// teardown() functions are executed only by the coordinator.
+func initRunner(resp RegisterResponse, newRunner RunnerFactory) (lib.Runner, error) {
+	runner, err := newRunner(resp.Archive)
+	if err != nil {
+		return nil, err
+	}
+
+	segment := new(lib.ExecutionSegment)
+	if err = segment.UnmarshalText([]byte(resp.ExecutionSegment)); err != nil {
+		return nil, err
+	}
+	sequence, err := lib.NewExecutionSegmentSequenceFromString(resp.ExecutionSegmentSequence)
+	if err != nil {
+		return nil, err
+	}
+
+	options := runner.GetOptions()
+	options.ExecutionSegment = segment
+	options.ExecutionSegmentSequence = &sequence
+	options.NoSetup = null.BoolFrom(true)
+	options.NoTeardown = null.BoolFrom(true)
+	options.Paused = null.BoolFrom(false)
+	if err = runner.SetOptions(options); err != nil {
+		return nil, err
+	}
+	return runner, nil
+}
+
+// Run connects to the coordinator, initializes the VUs for the assigned
+// execution segment and then executes the commands of the coordinator until
+// the test run is finished. Only the initialization errors are returned, the
+// errors during the test run itself are reported to the coordinator.
+//
+//nolint:funlen
+func (a *Agent) Run(ctx context.Context, newRunner RunnerFactory, rtOpts lib.RuntimeOptions) error {
+	logger := a.logger.WithField("component", "agent")
+	logger.Debugf("Connecting to the coordinator at %s...", a.client.Address())
+	resp, err := a.register(ctx, logger)
+	if err != nil {
+		return err
+	}
+	agentID := resp.AgentID
+	logger = logger.WithField("segment", resp.ExecutionSegment)
+	logger.Info("Registered with the coordinator, initializing...")
+
+	// The thresholds and the summary are handled by the coordinator
+	rtOpts.NoThresholds = null.BoolFrom(true)
+	rtOpts.NoSummary = null.BoolFrom(true)
+
+	globalCtx, globalCancel := context.WithCancel(ctx)
+	defer globalCancel()
+	runCtx, runCancel := context.WithCancel(globalCtx)
+	defer runCancel()
+
+	engine, err := a.initEngine(globalCtx, runCtx, resp, newRunner, rtOpts)
+	if err != nil {
+		_ = a.client.Ready(ctx, agentID, err)
+		return err
+	}
+	defer engine.StopOutputs()
+
+	engineRun, engineWait, err := engine.Init(globalCtx, runCtx)
+	if rerr := a.client.Ready(ctx, agentID, err); rerr != nil && err == nil {
+		err = rerr
+	}
+	if err != nil {
+		return err
+	}
+	logger.Info("Initialized, waiting for the test run to start...")
+
+	var (
+		runOnce sync.Once
+		runDone = make(chan error, 1)
+	)
+	startRun := func(setupData []byte) {
+		runOnce.Do(func() {
+			engine.ExecutionScheduler.GetRunner().SetSetupData(setupData)
+			go func() {
+				runDone <- engineRun()
+			}()
+		})
+	}
+
+	commands := make(chan Command)
+	go a.pollCommands(globalCtx, agentID, commands, logger)
+
+	var runErr error
+loop:
+	for {
+		select {
+		case cmd := <-commands:
+			var cmdErr error
+			if cmd.Type == CommandStop {
+				// Stop the run, even if it hasn't started yet
+				runCancel()
+				startRun(nil)
+			} else {
+				cmdErr = a.executeCommand(runCtx, engine.ExecutionScheduler, cmd, startRun)
+			}
+			if cmdErr != nil {
+				logger.WithError(cmdErr).Warnf("Error executing the %s command", cmd.Type)
+			}
+			if err := a.client.Ack(globalCtx, agentID, cmd.Seq, cmdErr); err != nil {
+				logger.WithError(err).Warn("Couldn't acknowledge a command")
+			}
+		case runErr = <-runDone:
+			break loop
+		case <-ctx.Done():
+			runErr = ctx.Err()
+			runCancel()
+			<-runDone
+			break loop
+		}
+	}
+
+	logger.WithError(runErr).Info("Test run finished")
+	runCancel()
+	globalCancel()
+	engineWait()
+	engine.StopOutputs() // send the final metrics before reporting that we're done
+
+	if err := a.client.Done(context.Background(), agentID, runErr); err != nil {
+		logger.WithError(err).Warn("Couldn't report to the coordinator that the test run is done")
+	}
+	return nil
+}
+
+// initEngine creates the runner, the local execution scheduler and the engine,
+// with the coordinator as its only output.
+func (a *Agent) initEngine(
+	globalCtx, runCtx context.Context, resp RegisterResponse, newRunner RunnerFactory, rtOpts lib.RuntimeOptions,
+) (*core.Engine, error) {
+	runner, err := initRunner(resp, newRunner)
+	if err != nil {
+		return nil, err
+	}
+	execScheduler, err := local.NewExecutionScheduler(runner, a.logger)
+	if err != nil {
+		return nil, err
+	}
+	out := newMetricsOutput(a.client, resp.AgentID, execScheduler.GetState(), a.logger.WithField("component", "agent"))
+	engine, err := core.NewEngine(execScheduler, runner.GetOptions(), rtOpts, []output.Output{out}, a.logger)
+	if err != nil {
+		return nil, err
+	}
+	if err = engine.StartOutputs(); err != nil {
+		return nil, err
+	}
+	return engine, nil
+}
+
+// pollCommands continuously fetches new commands from the coordinator.
+func (a *Agent) pollCommands(ctx context.Context, agentID int, out chan<- Command, logger logrus.FieldLogger) {
+	next := 0
+	for {
+		commands, err := a.client.Commands(ctx, agentID, next)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.WithError(err).Warn("Couldn't fetch the commands from the coordinator")
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(registerRetryInterval):
+			}
+			continue
+		}
+		for _, cmd := range commands {
+			if cmd.Seq < next {
+				continue
+			}
+			next = cmd.Seq + 1
+			select {
+			case out <- cmd:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// executeCommand executes all commands besides CommandStop.
+func (a *Agent) executeCommand(
+	ctx context.Context, execScheduler lib.ExecutionScheduler, cmd Command, startRun func([]byte),
+) error {
+	switch cmd.Type {
+	case CommandStart:
+		startRun(cmd.SetupData)
+		return nil
+	case CommandPause, CommandResume:
+		return execScheduler.SetPaused(cmd.Type == CommandPause)
+	case CommandStopScenario:
+		return execScheduler.StopExecutor(cmd.Scenario)
+	case CommandUpdateScenario:
+		config, err := lib.GetParsedExecutorConfig(cmd.Scenario, cmd.ScenarioType, cmd.Config)
+		if err != nil {
+			return err
+		}
+		return execScheduler.UpdateExecutorConfig(ctx, cmd.Scenario, config)
+	default:
+		return fmt.Errorf("unknown command type '%s'", cmd.Type)
+	}
+}
@@ -0,0 +1,147 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package distributed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// errUnauthorized is returned when the coordinator rejects the token.
+var errUnauthorized = errors.New("the coordinator rejected the token")
+
+// Client is used by agents to make requests to the coordinator.
+type Client struct {
+	baseURL    *url.URL
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient returns a new client for the coordinator listening on the given
+// host:port address, which authenticates with the given token, if it isn't
+// empty.
+func NewClient(address, token string) (*Client, error) {
+	baseURL, err := url.Parse("http://" + address)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{baseURL: baseURL, token: token, httpClient: http.DefaultClient}, nil
+}
+
+// Address returns the address of the coordinator.
+func (c *Client) Address() string {
+	return c.baseURL.Host
+}
+
+func (c *Client) call(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	var bodyData []byte
+	if body != nil {
+		var err error
+		if bodyData, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	u := c.baseURL.ResolveReference(&url.URL{Path: path, RawQuery: query.Encode()})
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(bodyData))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode == http.StatusUnauthorized {
+		return errUnauthorized
+	}
+	if res.StatusCode >= 400 {
+		return fmt.Errorf("coordinator error (%d): %s", res.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if out != nil {
+		return json.Unmarshal(data, out)
+	}
+	return nil
+}
+
+func agentPath(agentID int, action string) string {
+	return "/v1/agents/" + strconv.Itoa(agentID) + "/" + action
+}
+
+// Register tries to register a new agent with the coordinator.
+func (c *Client) Register(ctx context.Context, name string) (resp RegisterResponse, err error) {
+	return resp, c.call(ctx, http.MethodPost, "/v1/agents", nil, RegisterRequest{Name: name}, &resp)
+}
+
+// Ready notifies the coordinator that the agent has initialized its VUs, or
+// that it failed to do so, if initErr isn't nil.
+func (c *Client) Ready(ctx context.Context, agentID int, initErr error) error {
+	return c.call(ctx, http.MethodPost, agentPath(agentID, "ready"), nil, Result{Error: errorString(initErr)}, nil)
+}
+
+// Commands waits for any commands starting with the given sequence number.
+// It may return an empty list if there were no new commands for a while.
+func (c *Client) Commands(ctx context.Context, agentID, from int) (commands []Command, err error) {
+	query := url.Values{"from": []string{strconv.Itoa(from)}}
+	return commands, c.call(ctx, http.MethodGet, agentPath(agentID, "commands"), query, nil, &commands)
+}
+
+// Ack notifies the coordinator about the result of a command.
+func (c *Client) Ack(ctx context.Context, agentID, seq int, cmdErr error) error {
+	return c.call(ctx, http.MethodPost, agentPath(agentID, "ack"), nil, Ack{Seq: seq, Error: errorString(cmdErr)}, nil)
+}
+
+// PushMetrics sends metric samples and execution counters to the coordinator.
+func (c *Client) PushMetrics(ctx context.Context, agentID int, push MetricsPush) error {
+	return c.call(ctx, http.MethodPost, agentPath(agentID, "metrics"), nil, push, nil)
+}
+
+// Done notifies the coordinator that the agent has finished its part of the
+// test run, with an error if runErr isn't nil.
+func (c *Client) Done(ctx context.Context, agentID int, runErr error) error {
+	return c.call(ctx, http.MethodPost, agentPath(agentID, "done"), nil, Result{Error: errorString(runErr)}, nil)
+}
+
+// getResultError converts the error string of a result back into an error.
+func getResultError(msg string) error {
+	if msg == "" {
+		return nil
+	}
+	return errors.New(msg)
+}
@@ -0,0 +1,761 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package distributed
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/sirupsen/logrus"
+
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/metrics"
+	"github.com/loadimpact/k6/stats"
+	"github.com/loadimpact/k6/ui/pb"
+)
+
+const (
+	// How long a commands request from an agent can wait for new commands.
+	commandsPollTimeout = 10 * time.Second
+	// How long the coordinator waits for all agents to acknowledge a command.
+	commandAckTimeout = 30 * time.Second
+	// After how long without any requests an agent is considered dead.
+	agentTimeout = 3 * commandsPollTimeout
+)
+
+// Coordinator is an ExecutionScheduler that doesn't run any VUs by itself.
+// Instead, it waits for the configured number of agents to connect, gives each
+// of them the test archive and a different execution segment, keeps them in
+// sync and funnels all of their metric samples to the local Engine. That way,
+// the thresholds and the end-of-test summary are calculated for the whole
+// distributed test run.
+//
+// The Coordinator is also the http.Handler for the requests of the agents,
+// which have to be authenticated with the shared token, if there is one.
+type Coordinator struct {
+	runner        lib.Runner
+	options       lib.Options
+	archive       []byte
+	token         string
+	sequence      lib.ExecutionSegmentSequence
+	executionPlan []lib.ExecutionStep
+	state         *lib.ExecutionState
+	logger        *logrus.Entry
+	initProgress  *pb.ProgressBar
+	router        *httprouter.Router
+
+	mutex       sync.Mutex
+	agents      []*agentInfo
+	readyCount  int
+	doneCount   int
+	allReady    chan struct{}
+	allDone     chan struct{}
+	commands    []Command
+	newCommands chan struct{} // closed and replaced every time a command is added
+	pendingAcks map[int]*pendingAck
+	metrics     map[string]*stats.Metric
+	samplesCtx  context.Context
+	samplesOut  chan<- stats.SampleContainer
+}
+
+// agentInfo keeps track of a single registered agent.
+type agentInfo struct {
+	id       int
+	name     string
+	segment  *lib.ExecutionSegment
+	lastSeen time.Time
+	ready    bool
+	done     bool
+	err      error
+	counters MetricsPush // the execution counters from the last push, without samples
+}
+
+// pendingAck keeps track of the agents that still haven't acknowledged a
+// command.
+type pendingAck struct {
+	waiting map[int]bool
+	err     error
+	done    chan struct{}
+}
+
+func (pa *pendingAck) remove(agentID int, err error) {
+	if !pa.waiting[agentID] {
+		return
+	}
+	delete(pa.waiting, agentID)
+	if err != nil && pa.err == nil {
+		pa.err = err
+	}
+	if len(pa.waiting) == 0 {
+		close(pa.done)
+	}
+}
+
+// Check to see if we implement the lib.ExecutionScheduler interface
+var _ lib.ExecutionScheduler = &Coordinator{}
+
+// NewCoordinator creates a new coordinator that will split the test run of the
+// given runner between the specified number of agents. The archive should
+// contain the test run of the runner, with all of its options consolidated.
+// If the token isn't empty, the agents have to send it with every request.
+func NewCoordinator(
+	runner lib.Runner, archive []byte, agentsCount int, token string, logger *logrus.Logger,
+) (*Coordinator, error) {
+	if agentsCount < 1 {
+		return nil, fmt.Errorf("the number of agents should be at least 1, but is %d", agentsCount)
+	}
+	options := runner.GetOptions()
+	if options.ExecutionSegment != nil || options.ExecutionSegmentSequence != nil {
+		return nil, errors.New("execution segments can't be manually specified for distributed test runs")
+	}
+
+	sequence, err := getEvenSegmentSequence(agentsCount)
+	if err != nil {
+		return nil, err
+	}
+	et, err := lib.NewExecutionTuple(nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	executionPlan := options.Scenarios.GetFullExecutionRequirements(et)
+	state := lib.NewExecutionState(
+		options, et, lib.GetMaxPlannedVUs(executionPlan), lib.GetMaxPossibleVUs(executionPlan),
+	)
+	if options.Paused.Bool {
+		if err := state.Pause(); err != nil {
+			return nil, err
+		}
+	}
+
+	c := &Coordinator{
+		runner:        runner,
+		options:       options,
+		archive:       archive,
+		token:         token,
+		sequence:      sequence,
+		executionPlan: executionPlan,
+		state:         state,
+		logger:        logger.WithField("component", "coordinator"),
+		initProgress:  pb.New(pb.WithConstLeft("Init")),
+		allReady:      make(chan struct{}),
+		allDone:       make(chan struct{}),
+		newCommands:   make(chan struct{}),
+		pendingAcks:   make(map[int]*pendingAck),
+		metrics:       make(map[string]*stats.Metric),
+	}
+
+	router := httprouter.New()
+	router.POST("/v1/agents", c.handleRegister)
+	router.POST("/v1/agents/:id/ready", c.withAgent(c.handleReady))
+	router.GET("/v1/agents/:id/commands", c.withAgent(c.handleCommands))
+	router.POST("/v1/agents/:id/ack", c.withAgent(c.handleAck))
+	router.POST("/v1/agents/:id/metrics", c.withAgent(c.handleMetrics))
+	router.POST("/v1/agents/:id/done", c.withAgent(c.handleDone))
+	c.router = router
+
+	return c, nil
+}
+
+// getEvenSegmentSequence returns a sequence of the given number of equal
+// execution segments.
+func getEvenSegmentSequence(count int) (lib.ExecutionSegmentSequence, error) {
+	segments := make([]*lib.ExecutionSegment, count)
+	for i := 0; i < count; i++ {
+		segment, err := lib.NewExecutionSegment(big.NewRat(int64(i), int64(count)), big.NewRat(int64(i+1), int64(count)))
+		if err != nil {
+			return nil, err
+		}
+		segments[i] = segment
+	}
+	return lib.NewExecutionSegmentSequence(segments...)
+}
+
+// ServeHTTP handles the requests of the agents, after checking their token.
+func (c *Coordinator) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if c.token != "" {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(c.token)) != 1 {
+			http.Error(rw, "invalid or missing token", http.StatusUnauthorized)
+			return
+		}
+	}
+	c.router.ServeHTTP(rw, r)
+}
+
+// GetRunner returns the wrapped lib.Runner instance. It's used only for
+// setup(), teardown() and the end-of-test summary, the VUs are all executed by
+// the agents.
+func (c *Coordinator) GetRunner() lib.Runner {
+	return c.runner
+}
+
+// GetState returns the current execution state, aggregated from the states of
+// all agents.
+func (c *Coordinator) GetState() *lib.ExecutionState {
+	return c.state
+}
+
+// GetExecutors returns nil, since all executors are run by the agents.
+func (c *Coordinator) GetExecutors() []lib.Executor {
+	return nil
+}
+
+// GetExecutionPlan returns the execution plan of the whole test run.
+func (c *Coordinator) GetExecutionPlan() []lib.ExecutionStep {
+	return c.executionPlan
+}
+
+// GetInitProgressBar returns the progress bar of the coordinator, which shows
+// the state of the agents and, after the test has started, the run stats.
+func (c *Coordinator) GetInitProgressBar() *pb.ProgressBar {
+	return c.initProgress
+}
+
+func (c *Coordinator) getAgentsProgress() (float64, []string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	total := len(c.sequence)
+	return float64(c.readyCount) / float64(total), []string{
+		fmt.Sprintf("%d/%d agents connected, %d/%d ready", len(c.agents), total, c.readyCount, total),
+	}
+}
+
+func (c *Coordinator) getRunStats() string {
+	c.mutex.Lock()
+	running := len(c.agents) - c.doneCount
+	c.mutex.Unlock()
+
+	status := "running"
+	if c.state.IsPaused() {
+		status = "paused"
+	}
+	if c.state.HasEnded() {
+		status = "ended"
+	}
+
+	vusFmt := pb.GetFixedLengthIntFormat(int64(c.state.GetInitializedVUsCount()))
+	return fmt.Sprintf(
+		"%s, %d agents, "+vusFmt+"/"+vusFmt+" VUs, %d complete and %d interrupted iterations",
+		status, running, c.state.GetCurrentlyActiveVUsCount(), c.state.GetInitializedVUsCount(),
+		c.state.GetFullIterationCount(), c.state.GetPartialIterationCount(),
+	)
+}
+
+// Init waits for all agents to connect and initialize their VUs.
+func (c *Coordinator) Init(ctx context.Context, samplesOut chan<- stats.SampleContainer) error {
+	c.mutex.Lock()
+	c.samplesCtx, c.samplesOut = ctx, samplesOut
+	c.mutex.Unlock()
+
+	c.state.SetExecutionStatus(lib.ExecutionStatusInitVUs)
+	c.initProgress.Modify(pb.WithProgress(c.getAgentsProgress))
+	c.logger.Debugf("Waiting for %d agents to connect...", len(c.sequence))
+
+	watchdogCtx, cancelWatchdog := context.WithCancel(ctx)
+	defer cancelWatchdog()
+	go c.watchAgents(watchdogCtx, false)
+
+	select {
+	case <-c.allReady:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, agent := range c.agents {
+		if agent.err != nil {
+			return fmt.Errorf("agent %s failed: %w", agent.name, agent.err)
+		}
+	}
+	c.state.SetExecutionStatus(lib.ExecutionStatusInitDone)
+	c.logger.Debug("All agents are ready")
+	return nil
+}
+
+// Run starts the test run on all agents and waits for them to finish it. If
+// the runCtx is cancelled, for example because a threshold was crossed, all
+// agents are stopped.
+func (c *Coordinator) Run(globalCtx, runCtx context.Context, samplesOut chan<- stats.SampleContainer) error {
+	// The samples that arrive after the run context is done should still be
+	// processed, so they are a part of the summary.
+	c.mutex.Lock()
+	c.samplesCtx, c.samplesOut = globalCtx, samplesOut
+	c.mutex.Unlock()
+
+	logger := c.logger.WithField("phase", "coordinator-run")
+	if c.state.IsPaused() {
+		logger.Debug("Execution is paused, waiting for resume or interrupt...")
+		c.state.SetExecutionStatus(lib.ExecutionStatusPausedBeforeRun)
+		c.initProgress.Modify(pb.WithConstProgress(1, "paused"))
+		select {
+		case <-c.state.ResumeNotify():
+			// continue
+		case <-runCtx.Done():
+			c.sendCommand(Command{Type: CommandStop})
+			return nil
+		}
+	}
+
+	c.state.MarkStarted()
+	defer c.state.MarkEnded()
+
+	watchdogCtx, cancelWatchdog := context.WithCancel(globalCtx)
+	defer cancelWatchdog()
+	go c.watchAgents(watchdogCtx, true)
+
+	if !c.options.NoSetup.Bool {
+		logger.Debug("Running setup()")
+		c.state.SetExecutionStatus(lib.ExecutionStatusSetup)
+		c.initProgress.Modify(pb.WithConstProgress(1, "setup()"))
+		if err := c.runner.Setup(runCtx, samplesOut); err != nil {
+			logger.WithField("error", err).Debug("setup() aborted by error")
+			c.sendCommand(Command{Type: CommandStop})
+			return err
+		}
+	}
+
+	c.initProgress.Modify(pb.WithHijack(c.getRunStats))
+	c.state.SetExecutionStatus(lib.ExecutionStatusRunning)
+	logger.Debug("Starting the test run on all agents...")
+	if err := c.broadcast(runCtx, Command{Type: CommandStart, SetupData: c.runner.GetSetupData()}); err != nil {
+		c.sendCommand(Command{Type: CommandStop})
+		return err
+	}
+
+	select {
+	case <-c.allDone:
+	case <-runCtx.Done():
+		logger.Debug("Test run was interrupted, stopping all agents...")
+		c.sendCommand(Command{Type: CommandStop})
+		select {
+		case <-c.allDone:
+		case <-globalCtx.Done():
+			return globalCtx.Err()
+		}
+	}
+
+	var firstErr error
+	c.mutex.Lock()
+	for _, agent := range c.agents {
+		if agent.err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("agent %s failed: %w", agent.name, agent.err)
+		}
+	}
+	c.mutex.Unlock()
+
+	if !c.options.NoTeardown.Bool {
+		logger.Debug("Running teardown()")
+		c.state.SetExecutionStatus(lib.ExecutionStatusTeardown)
+		if err := c.runner.Teardown(globalCtx, samplesOut); err != nil {
+			logger.WithField("error", err).Debug("teardown() aborted by error")
+			return err
+		}
+	}
+
+	return firstErr
+}
+
+// SetPaused pauses or resumes the test run on all agents. See the
+// lib.ExecutionScheduler interface for the caveats.
+func (c *Coordinator) SetPaused(pause bool) error {
+	if !c.state.HasStarted() && c.state.IsPaused() {
+		if pause {
+			return fmt.Errorf("execution is already paused")
+		}
+		c.logger.Debug("Starting execution")
+		return c.state.Resume()
+	}
+
+	cmd := Command{Type: CommandResume}
+	if pause {
+		cmd.Type = CommandPause
+	}
+	if err := c.broadcast(context.Background(), cmd); err != nil {
+		return err
+	}
+	if pause {
+		return c.state.Pause()
+	}
+	return c.state.Resume()
+}
+
+// StopExecutor stops the scenario with the given name on all agents.
+func (c *Coordinator) StopExecutor(name string) error {
+	if _, ok := c.options.Scenarios[name]; !ok {
+		return fmt.Errorf("no scenario with the name '%s' is running", name)
+	}
+	return c.broadcast(context.Background(), Command{Type: CommandStopScenario, Scenario: name})
+}
+
+// UpdateExecutorConfig sends the new config of the scenario with the given
+// name to all agents, each of which validates and applies it for its own
+// execution segment.
+func (c *Coordinator) UpdateExecutorConfig(ctx context.Context, name string, newConfig lib.ExecutorConfig) error {
+	if _, ok := c.options.Scenarios[name]; !ok {
+		return fmt.Errorf("no scenario with the name '%s' is running", name)
+	}
+	config, err := json.Marshal(newConfig)
+	if err != nil {
+		return err
+	}
+	return c.broadcast(ctx, Command{
+		Type: CommandUpdateScenario, Scenario: name, ScenarioType: newConfig.GetType(), Config: config,
+	})
+}
+
+// sendCommand adds a new command for all agents that are still running and
+// returns the tracker for their acknowledgements.
+func (c *Coordinator) sendCommand(cmd Command) *pendingAck {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	cmd.Seq = len(c.commands)
+	ack := &pendingAck{waiting: make(map[int]bool), done: make(chan struct{})}
+	for _, agent := range c.agents {
+		if !agent.done {
+			ack.waiting[agent.id] = true
+		}
+	}
+	if len(ack.waiting) == 0 {
+		close(ack.done)
+	}
+	c.pendingAcks[cmd.Seq] = ack
+	c.commands = append(c.commands, cmd)
+	close(c.newCommands)
+	c.newCommands = make(chan struct{})
+	return ack
+}
+
+// broadcast sends the command to all agents and waits for all of them to
+// execute it, returning the first error any of them encountered.
+func (c *Coordinator) broadcast(ctx context.Context, cmd Command) error {
+	ack := c.sendCommand(cmd)
+	timer := time.NewTimer(commandAckTimeout)
+	defer timer.Stop()
+	select {
+	case <-ack.done:
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		return ack.err
+	case <-timer.C:
+		return fmt.Errorf("timed out waiting for the agents to execute the %s command", cmd.Type)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// watchAgents periodically checks for agents that stopped making requests and
+// marks them as failed.
+func (c *Coordinator) watchAgents(ctx context.Context, running bool) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		c.mutex.Lock()
+		for _, agent := range c.agents {
+			if agent.done || time.Since(agent.lastSeen) < agentTimeout {
+				continue
+			}
+			err := fmt.Errorf("stopped responding for more than %s", agentTimeout)
+			c.logger.WithField("agent", agent.name).WithError(err).Error("Agent failure")
+			if !agent.ready {
+				c.markReady(agent, err)
+			}
+			if running {
+				c.markDone(agent, err)
+			}
+		}
+		c.mutex.Unlock()
+	}
+}
+
+// markReady should be called with the mutex held.
+func (c *Coordinator) markReady(agent *agentInfo, err error) {
+	if agent.ready {
+		return
+	}
+	agent.ready = true
+	if err != nil && agent.err == nil {
+		agent.err = err
+	}
+	c.readyCount++
+	if c.readyCount == len(c.sequence) {
+		close(c.allReady)
+	}
+}
+
+// markDone should be called with the mutex held.
+func (c *Coordinator) markDone(agent *agentInfo, err error) {
+	if agent.done {
+		return
+	}
+	agent.done = true
+	if err != nil && agent.err == nil {
+		agent.err = err
+	}
+	for _, ack := range c.pendingAcks {
+		ack.remove(agent.id, nil)
+	}
+	// The agent won't send any more counter updates
+	c.state.ModCurrentlyActiveVUsCount(-agent.counters.ActiveVUs)
+	agent.counters.ActiveVUs = 0
+
+	c.doneCount++
+	if c.doneCount == len(c.sequence) {
+		close(c.allDone)
+	}
+}
+
+func (c *Coordinator) handleRegister(rw http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var req RegisterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if len(c.agents) >= len(c.sequence) {
+		http.Error(rw, fmt.Sprintf("all %d agents have already connected", len(c.sequence)), http.StatusConflict)
+		return
+	}
+	agent := &agentInfo{
+		id:       len(c.agents),
+		name:     req.Name,
+		segment:  c.sequence[len(c.agents)],
+		lastSeen: time.Now(),
+	}
+	if agent.name == "" {
+		agent.name = strconv.Itoa(agent.id)
+	}
+	c.agents = append(c.agents, agent)
+	c.logger.WithFields(logrus.Fields{"agent": agent.name, "segment": agent.segment}).Info("Agent connected")
+
+	writeJSON(rw, RegisterResponse{
+		AgentID:                  agent.id,
+		Archive:                  c.archive,
+		ExecutionSegment:         agent.segment.String(),
+		ExecutionSegmentSequence: c.sequence.String(),
+	})
+}
+
+type agentHandler func(rw http.ResponseWriter, r *http.Request, agent *agentInfo)
+
+// withAgent finds the agent from the request URL and marks it as seen.
+func (c *Coordinator) withAgent(handler agentHandler) httprouter.Handle {
+	return func(rw http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		id, err := strconv.Atoi(p.ByName("id"))
+		c.mutex.Lock()
+		if err != nil || id < 0 || id >= len(c.agents) {
+			c.mutex.Unlock()
+			http.Error(rw, "unknown agent", http.StatusNotFound)
+			return
+		}
+		agent := c.agents[id]
+		agent.lastSeen = time.Now()
+		c.mutex.Unlock()
+		handler(rw, r, agent)
+	}
+}
+
+func (c *Coordinator) handleReady(rw http.ResponseWriter, r *http.Request, agent *agentInfo) {
+	var result Result
+	if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.markReady(agent, getResultError(result.Error))
+	c.logger.WithField("agent", agent.name).WithError(agent.err).Debug("Agent is ready")
+}
+
+func (c *Coordinator) handleCommands(rw http.ResponseWriter, r *http.Request, agent *agentInfo) {
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil || from < 0 {
+		http.Error(rw, "invalid from parameter", http.StatusBadRequest)
+		return
+	}
+
+	timer := time.NewTimer(commandsPollTimeout)
+	defer timer.Stop()
+	for {
+		c.mutex.Lock()
+		newCommands := c.newCommands
+		var commands []Command
+		if from < len(c.commands) {
+			commands = append(commands, c.commands[from:]...)
+		}
+		agent.lastSeen = time.Now()
+		c.mutex.Unlock()
+
+		if len(commands) > 0 {
+			writeJSON(rw, commands)
+			return
+		}
+		select {
+		case <-newCommands:
+		case <-timer.C:
+			writeJSON(rw, []Command{})
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (c *Coordinator) handleAck(rw http.ResponseWriter, r *http.Request, agent *agentInfo) {
+	var ack Ack
+	if err := json.NewDecoder(r.Body).Decode(&ack); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if pending, ok := c.pendingAcks[ack.Seq]; ok {
+		var err error
+		if ack.Error != "" {
+			err = fmt.Errorf("agent %s: %s", agent.name, ack.Error)
+		}
+		pending.remove(agent.id, err)
+	}
+}
+
+func (c *Coordinator) handleMetrics(rw http.ResponseWriter, r *http.Request, agent *agentInfo) {
+	var push MetricsPush
+	if err := json.NewDecoder(r.Body).Decode(&push); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.mutex.Lock()
+	if !agent.done {
+		c.updateCounters(agent, push)
+	}
+	samples := make(stats.Samples, len(push.Samples))
+	for i, s := range push.Samples {
+		metric, ok := c.metrics[s.Metric]
+		if !ok {
+			metric = stats.New(s.Metric, s.Type, s.Contains)
+			c.metrics[s.Metric] = metric
+		}
+		samples[i] = stats.Sample{
+			Metric: metric, Time: s.Time, Value: s.Value, Tags: stats.IntoSampleTags(&s.Tags),
+		}
+	}
+	ctx, out := c.samplesCtx, c.samplesOut
+	c.mutex.Unlock()
+
+	c.updateChecks(samples)
+	if len(samples) > 0 && out != nil {
+		stats.PushIfNotDone(ctx, out, samples)
+	}
+}
+
+// updateCounters applies the difference between the new and the previously
+// pushed execution counters of the agent to the global execution state. It
+// should be called with the mutex held.
+func (c *Coordinator) updateCounters(agent *agentInfo, push MetricsPush) {
+	c.state.ModCurrentlyActiveVUsCount(push.ActiveVUs - agent.counters.ActiveVUs)
+	c.state.ModInitializedVUsCount(push.InitializedVUs - agent.counters.InitializedVUs)
+	if push.FullIterations > agent.counters.FullIterations {
+		c.state.AddFullIterations(push.FullIterations - agent.counters.FullIterations)
+	}
+	if push.InterruptedIterations > agent.counters.InterruptedIterations {
+		c.state.AddInterruptedIterations(push.InterruptedIterations - agent.counters.InterruptedIterations)
+	}
+	push.Samples = nil
+	agent.counters = push
+}
+
+// updateChecks counts the passes and fails of the checks executed by the
+// agents in the group tree of the local runner, since it's used by the
+// end-of-test summary.
+func (c *Coordinator) updateChecks(samples stats.Samples) {
+	root := c.runner.GetDefaultGroup()
+	if root == nil {
+		return
+	}
+	for _, s := range samples {
+		if s.Metric.Name != metrics.Checks.Name {
+			continue
+		}
+		groupPath, _ := s.Tags.Get("group")
+		checkName, ok := s.Tags.Get("check")
+		if !ok {
+			continue
+		}
+		group := root
+		var err error
+		for _, name := range strings.Split(groupPath, lib.GroupSeparator)[1:] {
+			if group, err = group.Group(name); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			continue
+		}
+		check, err := group.Check(checkName)
+		if err != nil {
+			continue
+		}
+		if s.Value != 0 {
+			atomic.AddInt64(&check.Passes, 1)
+		} else {
+			atomic.AddInt64(&check.Fails, 1)
+		}
+	}
+}
+
+func (c *Coordinator) handleDone(rw http.ResponseWriter, r *http.Request, agent *agentInfo) {
+	var result Result
+	if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.markDone(agent, getResultError(result.Error))
+	c.logger.WithField("agent", agent.name).WithError(agent.err).Info("Agent finished")
+}
+
+func writeJSON(rw http.ResponseWriter, data interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(data); err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,253 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package distributed
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/loadimpact/k6/core"
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/executor"
+	"github.com/loadimpact/k6/lib/metrics"
+	"github.com/loadimpact/k6/lib/testutils"
+	"github.com/loadimpact/k6/lib/testutils/minirunner"
+	"github.com/loadimpact/k6/lib/types"
+	"github.com/loadimpact/k6/stats"
+)
+
+func getTestOptions(t *testing.T) lib.Options {
+	options, err := executor.DeriveScenariosFromShortcuts(lib.Options{
+		MetricSamplesBufferSize: null.IntFrom(200),
+		VUs:                     null.IntFrom(4),
+		Iterations:              null.IntFrom(20),
+	})
+	require.NoError(t, err)
+	require.Empty(t, options.Validate())
+	return options
+}
+
+func TestGetEvenSegmentSequence(t *testing.T) {
+	t.Parallel()
+	sequence, err := getEvenSegmentSequence(3)
+	require.NoError(t, err)
+	assert.Equal(t, "0,1/3,2/3,1", sequence.String())
+}
+
+func TestNewCoordinatorErrors(t *testing.T) {
+	t.Parallel()
+	logger := logrus.New()
+	logger.SetOutput(testutils.NewTestOutput(t))
+
+	_, err := NewCoordinator(&minirunner.MiniRunner{Options: getTestOptions(t)}, nil, 0, "", logger)
+	assert.EqualError(t, err, "the number of agents should be at least 1, but is 0")
+
+	options := getTestOptions(t)
+	segment, err := lib.NewExecutionSegmentFromString("0:1/2")
+	require.NoError(t, err)
+	options.ExecutionSegment = segment
+	_, err = NewCoordinator(&minirunner.MiniRunner{Options: options}, nil, 2, "", logger)
+	assert.EqualError(t, err, "execution segments can't be manually specified for distributed test runs")
+}
+
+//nolint:funlen
+func TestDistributedRun(t *testing.T) {
+	t.Parallel()
+	const agentsCount = 2
+	logger := logrus.New()
+	logger.SetOutput(testutils.NewTestOutput(t))
+	options := getTestOptions(t)
+
+	rootGroup, err := lib.NewGroup("", nil)
+	require.NoError(t, err)
+	coordRunner := &minirunner.MiniRunner{
+		Options: options,
+		Group:   rootGroup,
+		SetupFn: func(ctx context.Context, out chan<- stats.SampleContainer) ([]byte, error) {
+			return []byte(`"setup data"`), nil
+		},
+	}
+	coordinator, err := NewCoordinator(coordRunner, []byte("archive"), agentsCount, "secret", logger)
+	require.NoError(t, err)
+	srv := httptest.NewServer(coordinator)
+	defer srv.Close()
+	address := strings.TrimPrefix(srv.URL, "http://")
+
+	testCounter := stats.New("test_counter", stats.Counter)
+	var setupDataErrors, agentIterations int64
+	agentsWG := sync.WaitGroup{}
+	for i := 0; i < agentsCount; i++ {
+		agent, err := NewAgent(address, "secret", "", logger)
+		require.NoError(t, err)
+		agentsWG.Add(1)
+		go func() {
+			defer agentsWG.Done()
+			newRunner := func(archive []byte) (lib.Runner, error) {
+				assert.Equal(t, "archive", string(archive))
+				runner := &minirunner.MiniRunner{Options: options}
+				runner.Fn = func(ctx context.Context, out chan<- stats.SampleContainer) error {
+					if string(runner.GetSetupData()) != `"setup data"` {
+						atomic.AddInt64(&setupDataErrors, 1)
+					}
+					atomic.AddInt64(&agentIterations, 1)
+					tags := stats.IntoSampleTags(&map[string]string{"group": "", "check": "ok"})
+					stats.PushIfNotDone(ctx, out, stats.Samples{
+						{Metric: testCounter, Time: time.Now(), Value: 1},
+						{Metric: metrics.Checks, Time: time.Now(), Value: 1, Tags: tags},
+					})
+					return nil
+				}
+				return runner, nil
+			}
+			assert.NoError(t, agent.Run(context.Background(), newRunner, lib.RuntimeOptions{}))
+		}()
+	}
+
+	engine, err := core.NewEngine(coordinator, options, lib.RuntimeOptions{}, nil, logger)
+	require.NoError(t, err)
+	globalCtx, globalCancel := context.WithCancel(context.Background())
+	defer globalCancel()
+	runCtx, runCancel := context.WithCancel(globalCtx)
+	defer runCancel()
+
+	run, wait, err := engine.Init(globalCtx, runCtx)
+	require.NoError(t, err)
+	require.NoError(t, run())
+	runCancel()
+	globalCancel()
+	wait()
+	agentsWG.Wait()
+
+	state := coordinator.GetState()
+	assert.Equal(t, uint64(20), state.GetFullIterationCount())
+	assert.Equal(t, int64(0), state.GetCurrentlyActiveVUsCount())
+	assert.Equal(t, int64(4), state.GetInitializedVUsCount())
+	assert.Equal(t, int64(20), atomic.LoadInt64(&agentIterations))
+	assert.Equal(t, int64(0), atomic.LoadInt64(&setupDataErrors))
+
+	require.Contains(t, engine.Metrics, "test_counter")
+	assert.Equal(t, float64(20), engine.Metrics["test_counter"].Sink.(*stats.CounterSink).Value)
+	check, err := coordRunner.Group.Check("ok")
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), check.Passes)
+	assert.Equal(t, int64(0), check.Fails)
+}
+
+func TestDistributedRunInterrupted(t *testing.T) {
+	t.Parallel()
+	logger := logrus.New()
+	logger.SetOutput(testutils.NewTestOutput(t))
+	options, err := executor.DeriveScenariosFromShortcuts(lib.Options{
+		VUs:      null.IntFrom(2),
+		Duration: types.NullDurationFrom(time.Hour),
+	})
+	require.NoError(t, err)
+
+	coordinator, err := NewCoordinator(&minirunner.MiniRunner{Options: options}, nil, 2, "", logger)
+	require.NoError(t, err)
+	srv := httptest.NewServer(coordinator)
+	defer srv.Close()
+	address := strings.TrimPrefix(srv.URL, "http://")
+
+	agentsWG := sync.WaitGroup{}
+	for i := 0; i < 2; i++ {
+		agent, err := NewAgent(address, "", "", logger)
+		require.NoError(t, err)
+		agentsWG.Add(1)
+		go func() {
+			defer agentsWG.Done()
+			newRunner := func([]byte) (lib.Runner, error) {
+				return &minirunner.MiniRunner{Options: options, Fn: func(ctx context.Context, _ chan<- stats.SampleContainer) error {
+					select {
+					case <-ctx.Done():
+					case <-time.After(10 * time.Millisecond):
+					}
+					return nil
+				}}, nil
+			}
+			assert.NoError(t, agent.Run(context.Background(), newRunner, lib.RuntimeOptions{}))
+		}()
+	}
+
+	samples := make(chan stats.SampleContainer, 1000)
+	go func() {
+		for range samples {
+		}
+	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, coordinator.Init(ctx, samples))
+	runCtx, runCancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer runCancel()
+
+	start := time.Now()
+	require.NoError(t, coordinator.Run(ctx, runCtx, samples))
+	assert.True(t, time.Since(start) < 5*time.Second)
+	agentsWG.Wait()
+	assert.True(t, coordinator.GetState().GetFullIterationCount() > 0)
+}
+
+func TestCoordinatorToken(t *testing.T) {
+	t.Parallel()
+	logger := logrus.New()
+	logger.SetOutput(testutils.NewTestOutput(t))
+	coordinator, err := NewCoordinator(
+		&minirunner.MiniRunner{Options: getTestOptions(t)}, []byte("archive"), 1, "secret", logger,
+	)
+	require.NoError(t, err)
+	srv := httptest.NewServer(coordinator)
+	defer srv.Close()
+	address := strings.TrimPrefix(srv.URL, "http://")
+
+	for _, token := range []string{"", "wrong"} {
+		agent, err := NewAgent(address, token, "", logger)
+		require.NoError(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err = agent.Run(ctx, nil, lib.RuntimeOptions{})
+		cancel()
+		assert.True(t, errors.Is(err, errUnauthorized), err)
+	}
+
+	for _, path := range []string{"/v1/agents", "/v1/agents/0/ready", "/v1/agents/0/ack", "/v1/agents/0/done"} {
+		res, err := http.Post(srv.URL+path, "application/json", strings.NewReader("{}"))
+		require.NoError(t, err)
+		_ = res.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode, path)
+	}
+
+	client, err := NewClient(address, "secret")
+	require.NoError(t, err)
+	resp, err := client.Register(context.Background(), "agent")
+	require.NoError(t, err)
+	assert.Equal(t, "archive", string(resp.Archive))
+}
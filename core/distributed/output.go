@@ -0,0 +1,103 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package distributed
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/metrics"
+	"github.com/loadimpact/k6/output"
+)
+
+const metricsPushInterval = 200 * time.Millisecond
+
+// metricsOutput is the output of every agent, it periodically sends all of the
+// metric samples, together with the current execution counters, to the
+// coordinator.
+type metricsOutput struct {
+	output.SampleBuffer
+
+	client  *Client
+	agentID int
+	state   *lib.ExecutionState
+	logger  logrus.FieldLogger
+	flusher *output.PeriodicFlusher
+}
+
+var _ output.Output = &metricsOutput{}
+
+func newMetricsOutput(client *Client, agentID int, state *lib.ExecutionState, logger logrus.FieldLogger) *metricsOutput {
+	return &metricsOutput{client: client, agentID: agentID, state: state, logger: logger}
+}
+
+func (mo *metricsOutput) Description() string {
+	return "coordinator (" + mo.client.Address() + ")"
+}
+
+func (mo *metricsOutput) Start() error {
+	flusher, err := output.NewPeriodicFlusher(metricsPushInterval, mo.flush)
+	if err != nil {
+		return err
+	}
+	mo.flusher = flusher
+	return nil
+}
+
+// Stop flushes any remaining samples and the final execution counters.
+func (mo *metricsOutput) Stop() error {
+	mo.flusher.Stop()
+	return nil
+}
+
+func (mo *metricsOutput) flush() {
+	var samples []Sample
+	for _, sc := range mo.GetBufferedSamples() {
+		for _, s := range sc.GetSamples() {
+			// The coordinator emits these itself, based on the aggregated counters
+			if s.Metric == metrics.VUs || s.Metric == metrics.VUsMax {
+				continue
+			}
+			samples = append(samples, Sample{
+				Metric:   s.Metric.Name,
+				Type:     s.Metric.Type,
+				Contains: s.Metric.Contains,
+				Time:     s.Time,
+				Value:    s.Value,
+				Tags:     s.Tags.CloneTags(),
+			})
+		}
+	}
+
+	push := MetricsPush{
+		Samples:               samples,
+		ActiveVUs:             mo.state.GetCurrentlyActiveVUsCount(),
+		InitializedVUs:        mo.state.GetInitializedVUsCount(),
+		FullIterations:        mo.state.GetFullIterationCount(),
+		InterruptedIterations: mo.state.GetPartialIterationCount(),
+	}
+	if err := mo.client.PushMetrics(context.Background(), mo.agentID, push); err != nil {
+		mo.logger.WithError(err).Error("Couldn't push the metrics to the coordinator")
+	}
+}
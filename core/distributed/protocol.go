@@ -0,0 +1,116 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package distributed contains the coordinator and the agent of distributed
+// k6 test runs. The coordinator splits the test run into as many execution
+// segments as there are agents, and each agent executes its own segment of the
+// test on a separate machine. The coordinator and the agents communicate with
+// a simple JSON-over-HTTP protocol, where the agents are always the ones that
+// make the requests.
+package distributed
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/loadimpact/k6/stats"
+)
+
+// RegisterRequest is sent by an agent when it connects to the coordinator.
+type RegisterRequest struct {
+	Name string `json:"name"`
+}
+
+// RegisterResponse contains everything an agent needs to initialize its part
+// of the test run.
+type RegisterResponse struct {
+	AgentID                  int    `json:"agentID"`
+	Archive                  []byte `json:"archive"`
+	ExecutionSegment         string `json:"executionSegment"`
+	ExecutionSegmentSequence string `json:"executionSegmentSequence"`
+}
+
+// CommandType is the type of the commands the coordinator sends to agents.
+type CommandType string
+
+// All of the different command types.
+const (
+	CommandStart          CommandType = "start"
+	CommandPause          CommandType = "pause"
+	CommandResume         CommandType = "resume"
+	CommandStop           CommandType = "stop"
+	CommandStopScenario   CommandType = "stop-scenario"
+	CommandUpdateScenario CommandType = "update-scenario"
+)
+
+// Command is an instruction from the coordinator that all agents should
+// execute. Commands are numbered sequentially and every agent executes all of
+// them in the same order.
+type Command struct {
+	Seq  int         `json:"seq"`
+	Type CommandType `json:"type"`
+
+	// Only for CommandStart
+	SetupData json.RawMessage `json:"setupData,omitempty"`
+
+	// Only for CommandStopScenario and CommandUpdateScenario
+	Scenario     string          `json:"scenario,omitempty"`
+	ScenarioType string          `json:"scenarioType,omitempty"`
+	Config       json.RawMessage `json:"config,omitempty"`
+}
+
+// Ack is sent by an agent after it has executed a command.
+type Ack struct {
+	Seq   int    `json:"seq"`
+	Error string `json:"error,omitempty"`
+}
+
+// Result is sent by an agent when it has finished initializing and when it
+// has finished executing its part of the test run.
+type Result struct {
+	Error string `json:"error,omitempty"`
+}
+
+// Sample is the serializable version of a single stats.Sample.
+type Sample struct {
+	Metric   string            `json:"metric"`
+	Type     stats.MetricType  `json:"type"`
+	Contains stats.ValueType   `json:"contains"`
+	Time     time.Time         `json:"time"`
+	Value    float64           `json:"value"`
+	Tags     map[string]string `json:"tags,omitempty"`
+}
+
+// MetricsPush is periodically sent by every agent. Besides the metric samples
+// since the last push, it contains the current execution counters of the agent.
+type MetricsPush struct {
+	Samples               []Sample `json:"samples"`
+	ActiveVUs             int64    `json:"activeVUs"`
+	InitializedVUs        int64    `json:"initializedVUs"`
+	FullIterations        uint64   `json:"fullIterations"`
+	InterruptedIterations uint64   `json:"interruptedIterations"`
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -24,6 +24,7 @@ import (
 	"context"
 	"fmt"
 	"runtime"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -44,9 +45,21 @@ type ExecutionScheduler struct {
 	executorConfigs []lib.ExecutorConfig // sorted by (startTime, ID)
 	executors       []lib.Executor       // sorted by (startTime, ID), excludes executors with no work
 	executionPlan   []lib.ExecutionStep
-	maxDuration     time.Duration // cached value derived from the execution plan
-	maxPossibleVUs  uint64        // cached value derived from the execution plan
+	maxDuration     *int64 // cached value derived from the execution plan, accessed atomically
+	maxPossibleVUs  uint64 // cached value derived from the execution plan
 	state           *lib.ExecutionState
+
+	// Used for stopping single executors, see StopExecutor()
+	executorStops map[string]*executorStop
+	// Serializes live executor config updates, see UpdateExecutorConfig()
+	configUpdateLock sync.Mutex
+}
+
+// executorStop is used to signal to runExecutor() that a single executor
+// should be stopped.
+type executorStop struct {
+	once sync.Once
+	ch   chan struct{}
 }
 
 // Check to see if we implement the lib.ExecutionScheduler interface
@@ -68,9 +81,11 @@ func NewExecutionScheduler(runner lib.Runner, logger *logrus.Logger) (*Execution
 
 	executionState := lib.NewExecutionState(options, et, maxPlannedVUs, maxPossibleVUs)
 	maxDuration, _ := lib.GetEndOffset(executionPlan) // we don't care if the end offset is final
+	maxDurationNs := int64(maxDuration)
 
 	executorConfigs := options.Scenarios.GetSortedConfigs()
 	executors := make([]lib.Executor, 0, len(executorConfigs))
+	executorStops := make(map[string]*executorStop, len(executorConfigs))
 	// Only take executors which have work.
 	for _, sc := range executorConfigs {
 		if !sc.HasWork(et) {
@@ -88,6 +103,7 @@ func NewExecutionScheduler(runner lib.Runner, logger *logrus.Logger) (*Execution
 			return nil, err
 		}
 		executors = append(executors, s)
+		executorStops[sc.GetName()] = &executorStop{ch: make(chan struct{})}
 	}
 
 	if options.Paused.Bool {
@@ -105,9 +121,10 @@ func NewExecutionScheduler(runner lib.Runner, logger *logrus.Logger) (*Execution
 		executors:       executors,
 		executorConfigs: executorConfigs,
 		executionPlan:   executionPlan,
-		maxDuration:     maxDuration,
+		maxDuration:     &maxDurationNs,
 		maxPossibleVUs:  maxPossibleVUs,
 		state:           executionState,
+		executorStops:   executorStops,
 	}, nil
 }
 
@@ -177,7 +194,8 @@ func (e *ExecutionScheduler) getRunStats() string {
 	}
 	if e.state.HasStarted() {
 		dur := e.state.GetCurrentTestRunDuration()
-		status = fmt.Sprintf("%s (%s)", status, pb.GetFixedLengthDuration(dur, e.maxDuration))
+		maxDuration := time.Duration(atomic.LoadInt64(e.maxDuration))
+		status = fmt.Sprintf("%s (%s)", status, pb.GetFixedLengthDuration(dur, maxDuration))
 	}
 
 	vusFmt := pb.GetFixedLengthIntFormat(int64(e.maxPossibleVUs))
@@ -299,6 +317,19 @@ func (e *ExecutionScheduler) runExecutor(
 	})
 	executorProgress := executor.GetProgress()
 
+	// Each executor gets its own sub-context, so it can be stopped separately
+	// from the rest of the test run.
+	runCtx, cancel := context.WithCancel(runCtx)
+	defer cancel()
+	go func() {
+		select {
+		case <-e.executorStops[executorConfig.GetName()].ch:
+			executorLogger.Debugf("Stopping executor")
+			cancel()
+		case <-runCtx.Done():
+		}
+	}()
+
 	// Check if we have to wait before starting the actual executor execution
 	if executorStartTime > 0 {
 		startTime := time.Now()
@@ -313,6 +344,7 @@ func (e *ExecutionScheduler) runExecutor(
 		executorLogger.Debugf("Waiting for executor start time...")
 		select {
 		case <-runCtx.Done():
+			executorProgress.Modify(pb.WithStatus(pb.Interrupted), pb.WithConstProgress(0, "stopped"))
 			runResults <- nil // no error since executor hasn't started yet
 			return
 		case <-time.After(executorStartTime):
@@ -440,3 +472,80 @@ func (e *ExecutionScheduler) SetPaused(pause bool) error {
 	}
 	return e.state.Resume()
 }
+
+// StopExecutor interrupts the executor with the given scenario name. The rest
+// of the executors, and the test run as a whole, aren't affected. Any currently
+// running iterations of the executor are interrupted, the same way they would
+// be if the whole test run was interrupted.
+func (e *ExecutionScheduler) StopExecutor(name string) error {
+	stop, ok := e.executorStops[name]
+	if !ok {
+		return fmt.Errorf("no scenario with the name '%s' is running", name)
+	}
+	stop.once.Do(func() {
+		e.logger.WithField("scenario", name).Debug("Stopping a single scenario...")
+		close(stop.ch)
+	})
+	return nil
+}
+
+// UpdateExecutorConfig checks if the supplied new config for the executor with
+// the given scenario name would need more planned VUs than were initialized in
+// the beginning of the test run and, if not, passes it to the executor to be
+// applied in real time.
+func (e *ExecutionScheduler) UpdateExecutorConfig(
+	ctx context.Context, name string, newConfig lib.ExecutorConfig,
+) error {
+	e.configUpdateLock.Lock()
+	defer e.configUpdateLock.Unlock()
+
+	var updatedExecutor lib.Executor
+	newConfigs := make(lib.ScenarioConfigs, len(e.executorConfigs))
+	for _, conf := range e.executorConfigs {
+		newConfigs[conf.GetName()] = conf
+	}
+	for _, exec := range e.executors {
+		conf := exec.GetConfig()
+		if conf.GetName() == name {
+			updatedExecutor = exec
+			conf = newConfig
+		}
+		newConfigs[conf.GetName()] = conf
+	}
+	if updatedExecutor == nil {
+		return fmt.Errorf("no scenario with the name '%s' is running", name)
+	}
+	liveUpdatableExecutor, ok := updatedExecutor.(lib.LiveUpdatableExecutor)
+	if !ok {
+		return fmt.Errorf(
+			"%s executor '%s' doesn't support live config updates",
+			newConfig.GetType(), name,
+		)
+	}
+
+	newPlan := newConfigs.GetFullExecutionRequirements(e.state.ExecutionTuple)
+	// The externally-controlled executor (the only non-distributable one)
+	// initializes any VUs above its starting maxVUs by itself, so it doesn't
+	// need to fit in the original execution plan.
+	if newConfig.IsDistributable() {
+		if newVUs, oldVUs := lib.GetMaxPlannedVUs(newPlan), lib.GetMaxPlannedVUs(e.executionPlan); newVUs > oldVUs {
+			return fmt.Errorf(
+				"the new config for scenario '%s' would need %d planned VUs at the same time, but only %d were initialized",
+				name, newVUs, oldVUs,
+			)
+		}
+		if newVUs, oldVUs := lib.GetMaxPossibleVUs(newPlan), lib.GetMaxPossibleVUs(e.executionPlan); newVUs > oldVUs {
+			return fmt.Errorf(
+				"the new config for scenario '%s' would need up to %d VUs at the same time, but only %d are allowed",
+				name, newVUs, oldVUs,
+			)
+		}
+	}
+
+	if err := liveUpdatableExecutor.UpdateConfig(ctx, newConfig); err != nil {
+		return err
+	}
+	newMaxDuration, _ := lib.GetEndOffset(newPlan)
+	atomic.StoreInt64(e.maxDuration, int64(newMaxDuration))
+	return nil
+}
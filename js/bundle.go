@@ -24,6 +24,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/url"
+	"path/filepath"
 	"runtime"
 
 	"github.com/dop251/goja"
@@ -78,7 +79,7 @@ func NewBundle(
 
 	// Compile sources, both ES5 and ES6 are supported.
 	code := string(src.Data)
-	c := compiler.New(logger)
+	c := newCompiler(logger, filesystems)
 	pgm, _, err := c.Compile(code, src.URL.String(), "", "", true, compatMode)
 	if err != nil {
 		return nil, err
@@ -95,6 +96,9 @@ func NewBundle(
 		CompatibilityMode: compatMode,
 		exports:           make(map[string]goja.Callable),
 	}
+	if err = configureModuleResolution(logger, bundle.BaseInitContext, rtOpts); err != nil {
+		return nil, err
+	}
 	if err = bundle.instantiate(logger, rt, bundle.BaseInitContext, 0); err != nil {
 		return nil, err
 	}
@@ -118,12 +122,19 @@ func NewBundleFromArchive(logger logrus.FieldLogger, arc *lib.Archive, rtOpts li
 		// whatever value is in the archive
 		rtOpts.CompatibilityMode = null.StringFrom(arc.CompatibilityMode)
 	}
+	// the same goes for the module resolution settings
+	if !rtOpts.ImportMap.Valid && arc.ImportMap != "" {
+		rtOpts.ImportMap = null.StringFrom(arc.ImportMap)
+	}
+	if !rtOpts.NodeModules.Valid {
+		rtOpts.NodeModules = null.BoolFrom(arc.NodeModules)
+	}
 	compatMode, err := lib.ValidateCompatibilityMode(rtOpts.CompatibilityMode.String)
 	if err != nil {
 		return nil, err
 	}
 
-	c := compiler.New(logger)
+	c := newCompiler(logger, arc.Filesystems)
 	pgm, _, err := c.Compile(string(arc.Data), arc.FilenameURL.String(), "", "", true, compatMode)
 	if err != nil {
 		return nil, err
@@ -153,6 +164,9 @@ func NewBundleFromArchive(logger logrus.FieldLogger, arc *lib.Archive, rtOpts li
 		exports:           make(map[string]goja.Callable),
 	}
 
+	if err = configureModuleResolution(logger, initctx, rtOpts); err != nil {
+		return nil, err
+	}
 	if err = bundle.instantiate(logger, rt, bundle.BaseInitContext, 0); err != nil {
 		return nil, err
 	}
@@ -167,6 +181,76 @@ func NewBundleFromArchive(logger logrus.FieldLogger, arc *lib.Archive, rtOpts li
 	return bundle, nil
 }
 
+// configureModuleResolution loads the import map and enables the node_modules
+// lookup for the given init context, according to the runtime options. The
+// import map is loaded through the init context's filesystems, so archives
+// include it as well.
+func configureModuleResolution(logger logrus.FieldLogger, init *InitContext, rtOpts lib.RuntimeOptions) error {
+	init.nodeModules = rtOpts.NodeModules.Bool
+	if rtOpts.ImportMap.String == "" {
+		return nil
+	}
+	specifier := filepath.ToSlash(rtOpts.ImportMap.String)
+	mapURL, err := loader.Resolve(init.pwd, specifier)
+	if err != nil {
+		return err
+	}
+	data, err := loader.Load(logger, init.filesystems, mapURL, specifier)
+	if err != nil {
+		return err
+	}
+	init.importMap, err = loader.ParseImportMap(data.Data, data.URL)
+	return err
+}
+
+// newCompiler returns a compiler that loads the source maps of the scripts
+// from the given filesystems, so they are also included in archives.
+func newCompiler(logger logrus.FieldLogger, filesystems map[string]afero.Fs) *compiler.Compiler {
+	c := compiler.New(logger)
+	c.SourceMapLoader = func(mapURL *url.URL) ([]byte, error) {
+		data, err := loader.Load(logger, filesystems, mapURL, mapURL.String())
+		if err != nil {
+			return nil, err
+		}
+		return data.Data, nil
+	}
+	return c
+}
+
+// sourceMappedException is a JS exception with the positions in its stack
+// trace pointing to the original sources of the scripts with source maps.
+type sourceMappedException struct {
+	*goja.Exception
+	compiler *compiler.Compiler
+}
+
+func (e sourceMappedException) Error() string {
+	return e.compiler.MapPositions(e.Exception.Error())
+}
+
+func (e sourceMappedException) String() string {
+	return e.compiler.MapPositions(e.Exception.String())
+}
+
+func (e sourceMappedException) Unwrap() error {
+	return e.Exception
+}
+
+// mapException maps the stack trace of the given error back to the original
+// sources, if it is a JS exception.
+func (b *Bundle) mapException(err error) error {
+	if exception, ok := err.(*goja.Exception); ok {
+		return sourceMappedException{Exception: exception, compiler: b.BaseInitContext.compiler}
+	}
+	return err
+}
+
+// mapPositions maps the file positions in the given text, e.g. a logged stack
+// trace, back to the original sources.
+func (b *Bundle) mapPositions(text string) string {
+	return b.BaseInitContext.compiler.MapPositions(text)
+}
+
 func (b *Bundle) makeArchive() *lib.Archive {
 	arc := &lib.Archive{
 		Type:              "js",
@@ -177,9 +261,13 @@ func (b *Bundle) makeArchive() *lib.Archive {
 		PwdURL:            b.BaseInitContext.pwd,
 		Env:               make(map[string]string, len(b.RuntimeOptions.Env)),
 		CompatibilityMode: b.CompatibilityMode.String(),
+		NodeModules:       b.BaseInitContext.nodeModules,
 		K6Version:         consts.Version,
 		Goos:              runtime.GOOS,
 	}
+	if b.BaseInitContext.importMap != nil {
+		arc.ImportMap = b.BaseInitContext.importMap.URL.String()
+	}
 	// Copy env so changes in the archive are not reflected in the source Bundle
 	for k, v := range b.RuntimeOptions.Env {
 		arc.Env[k] = v
@@ -293,7 +381,9 @@ func (b *Bundle) instantiate(logger logrus.FieldLogger, rt *goja.Runtime, init *
 	}
 	rt.Set("__ENV", env)
 	rt.Set("__VU", vuID)
-	rt.Set("console", common.Bind(rt, newConsole(logger), init.ctxPtr))
+	c := newConsole(logger)
+	c.mapPositions = init.compiler.MapPositions
+	rt.Set("console", common.Bind(rt, c, init.ctxPtr))
 
 	if init.compatibilityMode == lib.CompatibilityModeExtended {
 		rt.Set("global", rt.GlobalObject())
@@ -311,6 +401,9 @@ func (b *Bundle) instantiate(logger logrus.FieldLogger, rt *goja.Runtime, init *
 	*init.ctxPtr = common.WithRuntime(ctx, rt)
 	unbindInit := common.BindToGlobal(rt, common.Bind(rt, init, init.ctxPtr))
 	if _, err := rt.RunProgram(b.Program); err != nil {
+		if exception, ok := err.(*goja.Exception); ok {
+			return sourceMappedException{Exception: exception, compiler: init.compiler}
+		}
 		return err
 	}
 	unbindInit()
@@ -134,6 +134,14 @@ func TestNewBundle(t *testing.T) {
 				`module.exports.default = function() {};`, rtOpts)
 			assert.NoError(t, err)
 		})
+		t.Run("Base/ok/Modules", func(t *testing.T) {
+			rtOpts := lib.RuntimeOptions{
+				CompatibilityMode: null.StringFrom(lib.CompatibilityModeBase.String()),
+			}
+			_, err := getSimpleBundle(t, "/script.js",
+				`export var options = { vus: 1 }; export default function() {};`, rtOpts)
+			assert.NoError(t, err)
+		})
 		t.Run("Base/err", func(t *testing.T) {
 			testCases := []struct {
 				name       string
@@ -145,10 +153,11 @@ func TestNewBundle(t *testing.T) {
 					"InvalidCompat", "es1", `export default function() {};`,
 					`invalid compatibility mode "es1". Use: "extended", "base"`,
 				},
-				// ES2015 modules are not supported
+				// ES2015 modules are supported, but not the rest of ES2015
 				{
-					"Modules", "base", `export default function() {};`,
-					"file:///script.js: Line 1:1 Unexpected reserved word",
+					"ModulesWithES6", "base", `export default function() {};
+let a = 1;`,
+					"file:///script.js: Line 2:5 Unexpected identifier (and 1 more errors)",
 				},
 				// Arrow functions are not supported
 				{
@@ -467,7 +476,7 @@ func TestNewBundleFromArchive(t *testing.T) {
 
 		checkArchive(t, arc, lib.RuntimeOptions{}, "") // default options
 		checkArchive(t, arc, extCompatModeRtOpts, "")
-		checkArchive(t, arc, baseCompatModeRtOpts, "Unexpected reserved word")
+		checkArchive(t, arc, baseCompatModeRtOpts, "Unexpected identifier")
 	})
 
 	t.Run("es6_script_explicit", func(t *testing.T) {
@@ -478,7 +487,7 @@ func TestNewBundleFromArchive(t *testing.T) {
 
 		checkArchive(t, arc, lib.RuntimeOptions{}, "")
 		checkArchive(t, arc, extCompatModeRtOpts, "")
-		checkArchive(t, arc, baseCompatModeRtOpts, "Unexpected reserved word")
+		checkArchive(t, arc, baseCompatModeRtOpts, "Unexpected identifier")
 	})
 
 	t.Run("es5_script_with_extended", func(t *testing.T) {
@@ -517,7 +526,7 @@ func TestNewBundleFromArchive(t *testing.T) {
 		arc.CompatibilityMode = "blah"                                           // intentionally break the archive
 		checkArchive(t, arc, lib.RuntimeOptions{}, "invalid compatibility mode") // fails when it uses the archive one
 		checkArchive(t, arc, extCompatModeRtOpts, "")                            // works when I force the compat mode
-		checkArchive(t, arc, baseCompatModeRtOpts, "Unexpected reserved word")   // failes because of ES6
+		checkArchive(t, arc, baseCompatModeRtOpts, "Unexpected identifier")      // fails because of ES6
 	})
 
 	t.Run("script_options_dont_overwrite_metadata", func(t *testing.T) {
@@ -887,3 +896,218 @@ func TestBundleMakeArchive(t *testing.T) {
 		})
 	}
 }
+
+func TestBundleTypeScript(t *testing.T) {
+	t.Parallel()
+	libSrc := `export interface Greeting {
+	name: string;
+	punctuation?: string;
+}
+
+export function greet({ name, punctuation = "!" }: Greeting): string {
+	return "hello " + name + punctuation;
+}
+
+export function fail(reason: string): never {
+	throw new Error(reason);
+}
+`
+	scriptSrc := `import { greet, fail, Greeting } from "./lib/greet";
+import type { Options } from "./lib/types.ts";
+
+export const options: Options = { vus: 5 };
+
+export default function (): string {
+	const greeting: Greeting = { name: "world" };
+	if (__ENV.FAIL) {
+		fail("failed");
+	}
+	return greet(greeting);
+}
+`
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/path/to/lib/greet.ts", []byte(libSrc), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/path/to/lib/types.ts",
+		[]byte(`export type Options = { vus?: number };`), 0o644))
+
+	checkBundle := func(t *testing.T, b *Bundle) {
+		assert.Equal(t, lib.Options{VUs: null.IntFrom(5)}, b.Options)
+		bi, err := b.Instantiate(testutils.NewLogger(t), 0)
+		require.NoError(t, err)
+		v, err := bi.exports[consts.DefaultFn](goja.Undefined())
+		require.NoError(t, err)
+		assert.Equal(t, "hello world!", v.Export())
+	}
+
+	b, err := getSimpleBundle(t, "/path/to/script.ts", scriptSrc, fs)
+	require.NoError(t, err)
+	checkBundle(t, b)
+
+	t.Run("Exception", func(t *testing.T) {
+		t.Parallel()
+		b, err := getSimpleBundle(t, "/path/to/script.ts", scriptSrc, fs,
+			lib.RuntimeOptions{Env: map[string]string{"FAIL": "1"}})
+		require.NoError(t, err)
+		bi, err := b.Instantiate(testutils.NewLogger(t), 0)
+		require.NoError(t, err)
+		_, err = bi.exports[consts.DefaultFn](goja.Undefined())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed")
+		assert.Contains(t, err.Error(), "file:///path/to/lib/greet.ts:12:")
+	})
+
+	t.Run("Archive", func(t *testing.T) {
+		t.Parallel()
+		arc := b.makeArchive()
+		assert.Equal(t, scriptSrc, string(arc.Data))
+		data, err := afero.ReadFile(arc.Filesystems["file"], "/path/to/lib/greet.ts")
+		require.NoError(t, err)
+		assert.Equal(t, libSrc, string(data))
+
+		b, err := NewBundleFromArchive(testutils.NewLogger(t), arc, lib.RuntimeOptions{})
+		require.NoError(t, err)
+		checkBundle(t, b)
+	})
+}
+
+func TestBundleTypeScriptEnums(t *testing.T) {
+	t.Parallel()
+	// the exports after an enum, which is lowered to an IIFE, are still exported
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/path/to/lib/colors.ts", []byte(`enum Shade { Light, Dark }
+export enum Color { Red = "red", Green = "green" }
+export const z = 5;
+export function paint(color: Color, shade: Shade = Shade.Dark): string {
+	return color + "-" + Shade[shade];
+}
+`), 0o644))
+	b, err := getSimpleBundle(t, "/path/to/script.ts", `import { Color, z, paint } from "./lib/colors.ts";
+
+export const options = { vus: z };
+
+export default function (): string {
+	return paint(Color.Green) + "-" + z;
+}
+`, fs)
+	require.NoError(t, err)
+	assert.Equal(t, lib.Options{VUs: null.IntFrom(5)}, b.Options)
+	bi, err := b.Instantiate(testutils.NewLogger(t), 0)
+	require.NoError(t, err)
+	v, err := bi.exports[consts.DefaultFn](goja.Undefined())
+	require.NoError(t, err)
+	assert.Equal(t, "green-Dark-5", v.Export())
+}
+
+func TestBundleModuleResolution(t *testing.T) {
+	t.Parallel()
+	scriptSrc := `import { greet } from "greeter";
+import { exclaim } from "punctuation/exclaim.js";
+import { upper } from "@strings/upper";
+
+export default function () {
+	return exclaim(upper(greet("world")));
+}
+`
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"/path/to/importmap.json": `{
+			"imports": {
+				"greeter": "./lib/greeter.js",
+				"punctuation/": "./vendor/punctuation/"
+			}
+		}`,
+		"/path/to/lib/greeter.js":                        `export function greet(name) { return "hello " + name; }`,
+		"/path/to/vendor/punctuation/exclaim.js":         `export function exclaim(s) { return s + "!"; }`,
+		"/path/node_modules/@strings/upper/package.json": `{"main": "index.cjs", "module": "esm/index"}`,
+		"/path/node_modules/@strings/upper/esm/index.js": `export function upper(s) { return s.toUpperCase(); }`,
+		"/path/node_modules/@strings/upper/index.cjs":    `exports.upper = function () { throw new Error("wrong entry point"); };`,
+	}
+	for name, data := range files {
+		require.NoError(t, afero.WriteFile(fs, name, []byte(data), 0o644))
+	}
+
+	checkBundle := func(t *testing.T, b *Bundle) {
+		bi, err := b.Instantiate(testutils.NewLogger(t), 0)
+		require.NoError(t, err)
+		v, err := bi.exports[consts.DefaultFn](goja.Undefined())
+		require.NoError(t, err)
+		assert.Equal(t, "HELLO WORLD!", v.Export())
+	}
+
+	rtOpts := lib.RuntimeOptions{
+		ImportMap:   null.StringFrom("/path/to/importmap.json"),
+		NodeModules: null.BoolFrom(true),
+	}
+	b, err := getSimpleBundle(t, "/path/to/script.js", scriptSrc, fs, rtOpts)
+	require.NoError(t, err)
+	checkBundle(t, b)
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+		_, err := getSimpleBundle(t, "/path/to/script.js", scriptSrc, fs)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "greeter")
+	})
+
+	t.Run("InvalidImportMap", func(t *testing.T) {
+		t.Parallel()
+		_, err := getSimpleBundle(t, "/path/to/script.js", scriptSrc, fs,
+			lib.RuntimeOptions{ImportMap: null.StringFrom("/path/to/lib/greeter.js")})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "couldn't parse the import map")
+	})
+
+	t.Run("Archive", func(t *testing.T) {
+		t.Parallel()
+		arc := b.makeArchive()
+		assert.Equal(t, "file:///path/to/importmap.json", arc.ImportMap)
+		assert.True(t, arc.NodeModules)
+		for _, name := range []string{
+			"/path/to/importmap.json",
+			"/path/to/lib/greeter.js",
+			"/path/to/vendor/punctuation/exclaim.js",
+			"/path/node_modules/@strings/upper/package.json",
+			"/path/node_modules/@strings/upper/esm/index.js",
+		} {
+			data, err := afero.ReadFile(arc.Filesystems["file"], name)
+			require.NoError(t, err, name)
+			assert.Equal(t, files[name], string(data), name)
+		}
+		b, err := NewBundleFromArchive(testutils.NewLogger(t), arc, lib.RuntimeOptions{})
+		require.NoError(t, err)
+		checkBundle(t, b)
+	})
+}
+
+func TestBundleESModules(t *testing.T) {
+	t.Parallel()
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/even.js", []byte(`
+import { isOdd } from "./odd.js";
+export var calls = 0;
+export function isEven(n) { calls++; return n === 0 ? true : isOdd(n - 1); }
+`), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/odd.js", []byte(`
+import { isEven } from "./even.js";
+export function isOdd(n) { return n === 0 ? false : isEven(n - 1); }
+`), 0o644))
+	rtOpts := lib.RuntimeOptions{CompatibilityMode: null.StringFrom(lib.CompatibilityModeBase.String())}
+	b, err := getSimpleBundle(t, "/script.js", `
+import { isEven, calls } from "./even.js";
+export var options = { vus: 2 };
+export default function () {
+	return [isEven(4), isEven(3), calls].join(",");
+}
+`, fs, rtOpts)
+	require.NoError(t, err)
+	assert.Equal(t, null.IntFrom(2), b.Options.VUs)
+
+	// every VU has its own module records
+	for i := int64(0); i < 2; i++ {
+		bi, err := b.Instantiate(testutils.NewLogger(t), i)
+		require.NoError(t, err)
+		v, err := bi.exports[consts.DefaultFn](goja.Undefined())
+		require.NoError(t, err)
+		assert.Equal(t, "true,false,5", v.Export())
+	}
+}
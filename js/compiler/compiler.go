@@ -23,11 +23,13 @@
 package compiler
 
 import (
+	"strings"
 	"sync"
 	"time"
 
 	rice "github.com/GeertJohan/go.rice"
 	"github.com/dop251/goja"
+	"github.com/dop251/goja/ast"
 	"github.com/dop251/goja/parser"
 	"github.com/mitchellh/mapstructure"
 	"github.com/sirupsen/logrus"
@@ -85,6 +87,11 @@ var (
 // A Compiler compiles JavaScript source code (ES5.1 or ES6) into a goja.Program
 type Compiler struct {
 	logger logrus.FieldLogger
+
+	// SourceMapLoader loads the source maps that the compiled scripts reference
+	// in external files; if it isn't set, only inline source maps are used.
+	SourceMapLoader SourceMapLoader
+	sourceMaps      sourceMaps
 }
 
 // New returns a new Compiler
@@ -94,31 +101,78 @@ func New(logger logrus.FieldLogger) *Compiler {
 
 // Transform the given code into ES5
 func (c *Compiler) Transform(src, filename string) (code string, srcmap *SourceMap, err error) {
+	return c.transform(src, filename, true)
+}
+
+// transform the given code into ES5, along with the ES module syntax into
+// CommonJS if modules is true, for the modules that aren't compiled natively.
+func (c *Compiler) transform(src, filename string, modules bool) (code string, srcmap *SourceMap, err error) {
 	var b *babel
 	if b, err = newBabel(); err != nil {
 		return
 	}
 
-	return b.Transform(c.logger, src, filename)
+	return b.Transform(c.logger, src, filename, modules)
 }
 
-// Compile the program in the given CompatibilityMode, wrapping it between pre and post code
+// Compile the program in the given CompatibilityMode, wrapping it between pre and post code.
+// TypeScript sources, as determined by the filename, have their types stripped first, and
+// the source map that the program references, if any, is loaded for MapPositions.
+//
+// ES modules are compiled natively in both modes, so Babel is only needed for the syntax
+// that goja doesn't support. If a module can't be parsed natively, it's transformed to
+// CommonJS by Babel in the extended mode, as before.
 func (c *Compiler) Compile(src, filename, pre, post string,
 	strict bool, compatMode lib.CompatibilityMode) (*goja.Program, string, error) {
-	code := pre + src + post
-	ast, err := parser.ParseFile(nil, filename, code, 0, parser.WithDisableSourceMaps)
+	code := src
+	if IsTypeScript(filename) {
+		var err error
+		startTime := time.Now()
+		if code, err = stripTypes(src, filename); err != nil {
+			return nil, src, err
+		}
+		c.logger.WithField("t", time.Since(startTime)).Debug("TypeScript: Stripped types")
+	}
+	lineOffset := strings.Count(pre, "\n")
+	module, err := parseModule(code)
 	if err != nil {
-		if compatMode == lib.CompatibilityModeExtended {
-			code, _, err = c.Transform(src, filename)
-			if err != nil {
-				return nil, code, err
-			}
-			// the compatibility mode "decreases" here as we shouldn't transform twice
-			return c.Compile(code, filename, pre, post, strict, lib.CompatibilityModeBase)
+		c.logger.WithError(err).WithField("filename", filename).Debug("Couldn't parse the ES module natively")
+	}
+	if module != nil {
+		// the prologue goes on the last line of pre, if there is one, so no positions change
+		wrapped := strings.HasSuffix(pre, "\n")
+		code = module.render(wrapped)
+		if wrapped {
+			pre = pre[:len(pre)-1]
+		}
+	}
+	pgm, code, err := c.compile(code, filename, pre, post, strict, compatMode, module)
+	if err != nil {
+		return pgm, code, err
+	}
+	if err := c.loadSourceMap(src, filename, lineOffset); err != nil {
+		c.logger.WithError(err).Warnf("Stack traces in %s won't point to the original sources", filename)
+	}
+	return pgm, code, nil
+}
+
+func (c *Compiler) compile(src, filename, pre, post string,
+	strict bool, compatMode lib.CompatibilityMode, module *esModule) (*goja.Program, string, error) {
+	code := src
+	var err error
+	if module != nil {
+		// the references to the imports can only be rewritten once goja can parse the module
+		code, err = module.rewriteImports(src)
+	}
+	var pgm *goja.Program
+	if err == nil {
+		code = pre + code + post
+		var program *ast.Program
+		program, err = parser.ParseFile(nil, filename, code, 0, parser.WithDisableSourceMaps)
+		if err == nil {
+			pgm, err = goja.CompileAST(program, strict)
 		}
-		return nil, code, err
 	}
-	pgm, err := goja.CompileAST(ast, strict)
 	// Parsing only checks the syntax, not whether what the syntax expresses
 	// is actually supported (sometimes).
 	//
@@ -127,18 +181,15 @@ func (c *Compiler) Compile(src, filename, pre, post string,
 	// while parsing. Even now code such as `let [x] = [2]` doesn't return an
 	// error on the parsing stage but instead in the compilation in base mode.
 	//
-	// So, because of this, if there is an error during compilation, it still might
-	// be worth it to transform the code and try again.
-	if err != nil {
-		if compatMode == lib.CompatibilityModeExtended {
-			code, _, err = c.Transform(src, filename)
-			if err != nil {
-				return nil, code, err
-			}
-			// the compatibility mode "decreases" here as we shouldn't transform twice
-			return c.Compile(code, filename, pre, post, strict, lib.CompatibilityModeBase)
+	// So, because of this, if there is an error during parsing or compilation,
+	// it still might be worth it to transform the code and try again.
+	if err != nil && compatMode == lib.CompatibilityModeExtended {
+		code, _, err = c.transform(src, filename, module == nil)
+		if err != nil {
+			return nil, code, err
 		}
-		return nil, code, err
+		// the compatibility mode "decreases" here as we shouldn't transform twice
+		return c.compile(code, filename, pre, post, strict, lib.CompatibilityModeBase, module)
 	}
 	return pgm, code, err
 }
@@ -175,8 +226,9 @@ func newBabel() (*babel, error) {
 }
 
 // Transform the given code into ES5, while synchronizing to ensure only a single
-// bundle instance / Goja VM is in use at a time.
-func (b *babel) Transform(logger logrus.FieldLogger, src, filename string) (string, *SourceMap, error) {
+// bundle instance / Goja VM is in use at a time. The ES module syntax is only
+// transformed if modules is true.
+func (b *babel) Transform(logger logrus.FieldLogger, src, filename string, modules bool) (string, *SourceMap, error) {
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
 	opts := make(map[string]interface{})
@@ -184,6 +236,19 @@ func (b *babel) Transform(logger logrus.FieldLogger, src, filename string) (stri
 		opts[k] = v
 	}
 	opts["filename"] = filename
+	var plugins []interface{}
+	if IsTypeScript(filename) {
+		// TypeScript classes commonly declare their fields, which remain after
+		// the types are stripped, so they need to be transformed as well
+		plugins = append(plugins, "transform-class-properties")
+	}
+	for _, plugin := range DefaultOpts["plugins"].([]interface{}) {
+		if p, ok := plugin.([]interface{}); ok && p[0] == "transform-es2015-modules-commonjs" && !modules {
+			continue
+		}
+		plugins = append(plugins, plugin)
+	}
+	opts["plugins"] = plugins
 
 	startTime := time.Now()
 	v, err := b.transform(b.this, b.vm.ToValue(src), b.vm.ToValue(opts))
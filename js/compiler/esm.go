@@ -0,0 +1,652 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package compiler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/parser"
+)
+
+// esModule is the module record of an ES module, which is compiled natively
+// instead of being transformed to CommonJS by Babel.
+//
+// The import and export declarations are replaced by a prologue, inserted
+// before the first line, which defines the exports as getters of the local
+// bindings, so they are live, and requires the imported modules, in order,
+// before the rest of the module is evaluated. The exports are defined before
+// anything is required, so cyclic imports see the bindings of the modules that
+// are still being evaluated, like they would with ES modules. The references to
+// the imported bindings are then replaced by accesses to the exports of the
+// imported modules, once the module is valid JavaScript that goja can parse.
+type esModule struct {
+	p        *tsParser
+	requests []*esModuleRequest
+	// the local import bindings and the expressions that they are replaced by
+	bindings map[string]string
+	exports  []esExport
+	stars    []*esModuleRequest
+	// the names of the exports, for detecting duplicates
+	exportNames map[string]bool
+}
+
+// esModuleRequest is a module imported by an ES module
+type esModuleRequest struct {
+	specifier  string // the string literal in the source
+	local      string // the variable with the exports of the module
+	hasDefault bool
+}
+
+type esExport struct {
+	name  string // the string literal of the exported name
+	value string // the expression that returns the exported value
+}
+
+const esModulePrefix = "__k6_"
+
+// parseModule parses the import and export declarations of the given
+// JavaScript source, and returns nil if it isn't an ES module.
+func parseModule(src string) (m *esModule, err error) {
+	p := &tsParser{
+		s:          tsScanner{src: src},
+		typeNames:  make(map[string]bool),
+		valueNames: make(map[string]bool),
+	}
+	m = &esModule{p: p, bindings: make(map[string]string), exportNames: make(map[string]bool)}
+	defer func() {
+		if r := recover(); r != nil {
+			syntaxErr, ok := r.(*tsSyntaxError)
+			if !ok {
+				panic(r)
+			}
+			line, col := tsPosition(src, syntaxErr.pos)
+			m, err = nil, fmt.Errorf("%s (%d:%d)", syntaxErr.msg, line, col)
+		}
+	}()
+
+	isModule := false
+	p.next()
+	for p.tok.kind != tsEOF {
+		next := p.peek()
+		switch {
+		case p.isK("import") && (next.kind != tsPunct || next.value != "(" && next.value != "."):
+			m.parseImport()
+			isModule = true
+		case p.isK("export"):
+			m.parseExport()
+			isModule = true
+		default:
+			mark := len(p.edits)
+			p.parseStatement()
+			// the source is JavaScript, so nothing is a type that should be erased
+			p.edits = p.edits[:mark]
+		}
+	}
+	if !isModule {
+		return nil, nil
+	}
+	return m, nil
+}
+
+// request returns the module request for the given specifier literal
+func (m *esModule) request(specifier string) *esModuleRequest {
+	for _, r := range m.requests {
+		if r.specifier == specifier {
+			return r
+		}
+	}
+	r := &esModuleRequest{specifier: specifier, local: fmt.Sprintf("%simport_%d", esModulePrefix, len(m.requests))}
+	m.requests = append(m.requests, r)
+	return r
+}
+
+// expectSpecifier consumes the module specifier after from
+func (m *esModule) expectSpecifier() *esModuleRequest {
+	p := m.p
+	if p.tok.kind != tsString {
+		p.unexpected()
+	}
+	r := m.request(p.s.src[p.tok.start:p.tok.end])
+	p.next()
+	p.parseImportAttributes()
+	p.consumeSemicolon()
+	return r
+}
+
+// expectModuleExportName consumes an identifier or a string literal, and
+// returns it as a string literal
+func (m *esModule) expectModuleExportName() string {
+	p := m.p
+	var name string
+	switch p.tok.kind {
+	case tsIdent:
+		name = strconv.Quote(p.tok.value)
+	case tsString:
+		name = p.s.src[p.tok.start:p.tok.end]
+	default:
+		p.unexpected()
+	}
+	p.next()
+	return name
+}
+
+func (m *esModule) bind(local, value string) {
+	if _, ok := m.bindings[local]; ok {
+		m.p.fail("Identifier '%s' has already been declared", local)
+	}
+	m.bindings[local] = value
+}
+
+func (m *esModule) export(name, value string) {
+	if m.exportNames[name] {
+		m.p.fail("Duplicate export of %s", name)
+	}
+	m.exportNames[name] = true
+	m.exports = append(m.exports, esExport{name: name, value: value})
+}
+
+// member returns the expression that accesses the given exported name, as a
+// string literal, of the object
+func member(object, name string) string {
+	if unquoted, err := strconv.Unquote(name); err == nil && isIdentifierName(unquoted) {
+		return object + "." + unquoted
+	}
+	return object + "[" + name + "]"
+}
+
+func isIdentifierName(name string) bool {
+	if name == "" || !isTSIdentStart(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isTSIdentPart(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseImport parses an import declaration and replaces it with a variable
+// declaration of its bindings, so that transformations that rename variables,
+// like the Babel block scoping, know about them.
+func (m *esModule) parseImport() {
+	p := m.p
+	start := p.tok.start
+	p.expectK("import")
+	if p.tok.kind == tsString {
+		m.expectSpecifier()
+		p.erase(start, p.prevEnd, "")
+		return
+	}
+
+	type binding struct{ local, imported string }
+	var bindings []binding
+	if p.tok.kind == tsIdent && !p.isK("from") || p.isK("from") && p.peek().kind == tsIdent {
+		bindings = append(bindings, binding{local: p.expectIdent(), imported: `"default"`})
+		if p.isP(",") {
+			p.next()
+		}
+	}
+	switch {
+	case p.isP("*"):
+		p.next()
+		p.expectK("as")
+		bindings = append(bindings, binding{local: p.expectIdent()})
+	case p.isP("{"):
+		p.next()
+		for !p.isP("}") {
+			imported := m.expectModuleExportName()
+			local := p.tok.value
+			if p.isK("as") {
+				p.next()
+				local = p.expectIdent()
+			} else if unquoted, _ := strconv.Unquote(imported); isIdentifierName(unquoted) {
+				local = unquoted
+			} else {
+				p.unexpected()
+			}
+			bindings = append(bindings, binding{local: local, imported: imported})
+			if !p.isP("}") {
+				p.expectP(",")
+			}
+		}
+		p.next()
+	}
+	p.expectK("from")
+	r := m.expectSpecifier()
+
+	locals := make([]string, len(bindings))
+	for i, b := range bindings {
+		locals[i] = b.local
+		switch b.imported {
+		case "":
+			m.bind(b.local, r.local)
+		case `"default"`:
+			r.hasDefault = true
+			m.bind(b.local, r.local+"_default.default")
+		default:
+			m.bind(b.local, member(r.local, b.imported))
+		}
+	}
+	p.erase(start, p.prevEnd, "var "+strings.Join(locals, ", ")+";")
+}
+
+//nolint:funlen
+func (m *esModule) parseExport() {
+	p := m.p
+	start := p.tok.start
+	exportKeyword := p.tok
+	p.expectK("export")
+	switch {
+	case p.isP("*"):
+		p.next()
+		if p.isK("as") {
+			p.next()
+			name := m.expectModuleExportName()
+			p.expectK("from")
+			m.export(name, m.expectSpecifier().local)
+		} else {
+			p.expectK("from")
+			m.stars = append(m.stars, m.expectSpecifier())
+		}
+		p.erase(start, p.prevEnd, "")
+	case p.isP("{"):
+		type specifier struct{ local, name string }
+		var specifiers []specifier
+		p.next()
+		for !p.isP("}") {
+			local := m.expectModuleExportName()
+			name := local
+			if p.isK("as") {
+				p.next()
+				name = m.expectModuleExportName()
+			}
+			specifiers = append(specifiers, specifier{local: local, name: name})
+			if !p.isP("}") {
+				p.expectP(",")
+			}
+		}
+		p.next()
+		if p.isK("from") {
+			p.next()
+			r := m.expectSpecifier()
+			for _, s := range specifiers {
+				if s.local == `"default"` {
+					r.hasDefault = true
+					m.export(s.name, r.local+"_default.default")
+				} else {
+					m.export(s.name, member(r.local, s.local))
+				}
+			}
+		} else {
+			p.consumeSemicolon()
+			for _, s := range specifiers {
+				local, _ := strconv.Unquote(s.local)
+				if !isIdentifierName(local) {
+					p.fail("%s isn't a local binding that can be exported", s.local)
+				}
+				m.export(s.name, local)
+			}
+		}
+		p.erase(start, p.prevEnd, "")
+	case p.isK("default"):
+		defaultKeyword := p.tok
+		p.next()
+		next := p.peek()
+		mark := len(p.edits)
+		declared := len(p.declaredValues)
+		switch {
+		case p.isK("function") || p.isK("async") && next.kind == tsIdent && next.value == "function":
+			// the end of `function` or `function*`, where the name of anonymous functions goes
+			nameEnd, afterFunction := p.tok.end, next
+			if p.isK("async") {
+				nameEnd, afterFunction = next.end, p.peek2()
+			}
+			if afterFunction.kind == tsPunct && afterFunction.value == "*" {
+				nameEnd = afterFunction.end
+			}
+			p.parseFunctionDeclaration(p.tok.start)
+			p.edits = p.edits[:mark]
+			p.erase(start, defaultKeyword.end, "")
+			if len(p.declaredValues) > declared {
+				m.export(`"default"`, p.declaredValues[declared])
+			} else {
+				// anonymous default functions are declarations as well, so they are hoisted
+				m.export(`"default"`, esModulePrefix+"default")
+				p.erase(nameEnd, nameEnd, " "+esModulePrefix+"default")
+			}
+		case p.isK("class") && next.kind == tsIdent && next.value != "extends":
+			p.parseClass(p.tok.start, true)
+			p.edits = p.edits[:mark]
+			m.export(`"default"`, p.declaredValues[declared])
+			p.erase(start, defaultKeyword.end, "")
+		default:
+			p.parseAssignment(false)
+			p.consumeSemicolon()
+			p.edits = p.edits[:mark]
+			m.export(`"default"`, esModulePrefix+"default")
+			p.erase(start, defaultKeyword.end, "var "+esModulePrefix+"default =")
+		}
+	default:
+		mark := len(p.edits)
+		declared := len(p.declaredValues)
+		switch {
+		case p.isK("var") || p.isK("let") || p.isK("const"):
+			p.parseVarDeclarations(false)
+			p.consumeSemicolon()
+		case p.isK("function") || p.isK("async"):
+			p.parseFunctionDeclaration(p.tok.start)
+		case p.isK("class"):
+			p.parseClass(p.tok.start, true)
+		default:
+			p.unexpected()
+		}
+		p.edits = p.edits[:mark]
+		for _, name := range p.declaredValues[declared:] {
+			m.export(strconv.Quote(name), name)
+		}
+		p.erase(exportKeyword.start, exportKeyword.end, "")
+	}
+}
+
+// render returns the source of the module with the prologue and without the
+// import and export declarations. The prologue is on a line of its own if
+// newline is true, otherwise it's in front of the first line.
+func (m *esModule) render(newline bool) string {
+	var b strings.Builder
+	b.WriteString(`"use strict"; Object.defineProperty(exports, "__esModule", { value: true }); `)
+	if len(m.exports) > 0 {
+		b.WriteString("Object.defineProperties(exports, { ")
+		for _, e := range m.exports {
+			fmt.Fprintf(&b, "%s: { enumerable: true, get: function () { return %s; } }, ", e.name, e.value)
+		}
+		b.WriteString("}); ")
+	}
+	for _, r := range m.requests {
+		fmt.Fprintf(&b, "var %s = require(%s); ", r.local, r.specifier)
+		if r.hasDefault {
+			fmt.Fprintf(&b, `var %[1]s_default = %[1]s && %[1]s.__esModule ? %[1]s : { "default": %[1]s }; `, r.local)
+		}
+	}
+	for _, r := range m.stars {
+		fmt.Fprintf(&b, `Object.keys(%[1]s).forEach(function (name) { `+
+			`if (name !== "default" && name !== "__esModule" && !Object.prototype.hasOwnProperty.call(exports, name)) `+
+			`{ Object.defineProperty(exports, name, { enumerable: true, get: function () { return %[1]s[name]; } }); } }); `,
+			r.local)
+	}
+	if newline {
+		b.WriteByte('\n')
+	}
+	return b.String() + m.p.render(0, len(m.p.s.src))
+}
+
+// rewriteImports replaces the references to the import bindings in the
+// rendered module, after it has been transformed to JavaScript that goja can
+// parse, and removes the placeholder declarations of the bindings.
+func (m *esModule) rewriteImports(code string) (string, error) {
+	if len(m.bindings) == 0 {
+		return code, nil
+	}
+	program, err := parser.ParseFile(nil, "", code, 0, parser.WithDisableSourceMaps)
+	if err != nil {
+		return code, err
+	}
+	r := &esImportRewriter{code: code, bindings: m.bindings}
+	for _, statement := range program.Body {
+		if r.isPlaceholder(statement) {
+			r.edits = append(r.edits, tsEdit{start: int(statement.Idx0()) - 1, end: int(statement.Idx1()) - 1})
+			continue
+		}
+		r.walkStatement(statement)
+	}
+	r.walkDeclarations(program.DeclarationList)
+	p := &tsParser{s: tsScanner{src: code}, edits: r.edits}
+	return p.render(0, len(code)), nil
+}
+
+// esImportRewriter replaces the references to import bindings that aren't
+// shadowed by the local variables of the functions that they are in.
+type esImportRewriter struct {
+	code     string
+	bindings map[string]string
+	scopes   []map[string]bool
+	edits    []tsEdit
+}
+
+// isPlaceholder returns whether the statement is a placeholder declaration of
+// import bindings
+func (r *esImportRewriter) isPlaceholder(statement ast.Statement) bool {
+	vars, ok := statement.(*ast.VariableStatement)
+	if !ok {
+		return false
+	}
+	for _, v := range vars.List {
+		v, ok := v.(*ast.VariableExpression)
+		if !ok || v.Initializer != nil {
+			return false
+		}
+		if _, ok := r.bindings[string(v.Name)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// binding returns the replacement of the identifier, if it's an import binding
+func (r *esImportRewriter) binding(name string) (string, bool) {
+	value, ok := r.bindings[name]
+	if !ok {
+		return "", false
+	}
+	for _, scope := range r.scopes {
+		if scope[name] {
+			return "", false
+		}
+	}
+	return value, true
+}
+
+func (r *esImportRewriter) replace(idx int, name, text string) {
+	start := idx - 1
+	r.edits = append(r.edits, tsEdit{start: start, end: start + len(name), text: text})
+}
+
+func (r *esImportRewriter) walkDeclarations(declarations []ast.Declaration) {
+	for _, d := range declarations {
+		if f, ok := d.(*ast.FunctionDeclaration); ok {
+			r.walkFunction(f.Function)
+		}
+	}
+}
+
+func (r *esImportRewriter) walkFunction(f *ast.FunctionLiteral) {
+	scope := map[string]bool{"arguments": true}
+	if f.Name != nil {
+		scope[string(f.Name.Name)] = true
+	}
+	for _, param := range f.ParameterList.List {
+		scope[string(param.Name)] = true
+	}
+	for _, d := range f.DeclarationList {
+		switch d := d.(type) {
+		case *ast.FunctionDeclaration:
+			scope[string(d.Function.Name.Name)] = true
+		case *ast.VariableDeclaration:
+			for _, v := range d.List {
+				scope[string(v.Name)] = true
+			}
+		}
+	}
+	r.scopes = append(r.scopes, scope)
+	r.walkStatement(f.Body)
+	r.walkDeclarations(f.DeclarationList)
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+//nolint:gocyclo,cyclop
+func (r *esImportRewriter) walkStatement(statement ast.Statement) {
+	switch s := statement.(type) {
+	case *ast.BlockStatement:
+		r.walkStatements(s.List)
+	case *ast.CaseStatement:
+		r.walkExpression(s.Test)
+		r.walkStatements(s.Consequent)
+	case *ast.CatchStatement:
+		r.scopes = append(r.scopes, map[string]bool{string(s.Parameter.Name): true})
+		r.walkStatement(s.Body)
+		r.scopes = r.scopes[:len(r.scopes)-1]
+	case *ast.DoWhileStatement:
+		r.walkStatement(s.Body)
+		r.walkExpression(s.Test)
+	case *ast.ExpressionStatement:
+		r.walkExpression(s.Expression)
+	case *ast.ForInStatement:
+		r.walkExpression(s.Into)
+		r.walkExpression(s.Source)
+		r.walkStatement(s.Body)
+	case *ast.ForOfStatement:
+		r.walkExpression(s.Into)
+		r.walkExpression(s.Source)
+		r.walkStatement(s.Body)
+	case *ast.ForStatement:
+		r.walkExpression(s.Initializer)
+		r.walkExpression(s.Test)
+		r.walkExpression(s.Update)
+		r.walkStatement(s.Body)
+	case *ast.IfStatement:
+		r.walkExpression(s.Test)
+		r.walkStatement(s.Consequent)
+		r.walkStatement(s.Alternate)
+	case *ast.LabelledStatement:
+		r.walkStatement(s.Statement)
+	case *ast.ReturnStatement:
+		r.walkExpression(s.Argument)
+	case *ast.SwitchStatement:
+		r.walkExpression(s.Discriminant)
+		for _, c := range s.Body {
+			r.walkStatement(c)
+		}
+	case *ast.ThrowStatement:
+		r.walkExpression(s.Argument)
+	case *ast.TryStatement:
+		r.walkStatement(s.Body)
+		if s.Catch != nil {
+			r.walkStatement(s.Catch)
+		}
+		r.walkStatement(s.Finally)
+	case *ast.VariableStatement:
+		r.walkExpressions(s.List)
+	case *ast.WhileStatement:
+		r.walkExpression(s.Test)
+		r.walkStatement(s.Body)
+	case *ast.WithStatement:
+		r.walkExpression(s.Object)
+		r.walkStatement(s.Body)
+	}
+}
+
+func (r *esImportRewriter) walkStatements(statements []ast.Statement) {
+	for _, s := range statements {
+		r.walkStatement(s)
+	}
+}
+
+//nolint:gocyclo,cyclop
+func (r *esImportRewriter) walkExpression(expression ast.Expression) {
+	switch e := expression.(type) {
+	case *ast.Identifier:
+		if value, ok := r.binding(string(e.Name)); ok {
+			r.replace(int(e.Idx), string(e.Name), value)
+		}
+	case *ast.ArrayLiteral:
+		r.walkExpressions(e.Value)
+	case *ast.AssignExpression:
+		r.walkExpression(e.Left)
+		r.walkExpression(e.Right)
+	case *ast.BinaryExpression:
+		r.walkExpression(e.Left)
+		r.walkExpression(e.Right)
+	case *ast.BracketExpression:
+		r.walkExpression(e.Left)
+		r.walkExpression(e.Member)
+	case *ast.CallExpression:
+		if callee, ok := e.Callee.(*ast.Identifier); ok {
+			// imported functions are called without a this value, like they would be as variables
+			if value, ok := r.binding(string(callee.Name)); ok {
+				r.replace(int(callee.Idx), string(callee.Name), "(0, "+value+")")
+			}
+		} else {
+			r.walkExpression(e.Callee)
+		}
+		r.walkExpressions(e.ArgumentList)
+	case *ast.ConditionalExpression:
+		r.walkExpression(e.Test)
+		r.walkExpression(e.Consequent)
+		r.walkExpression(e.Alternate)
+	case *ast.DotExpression:
+		r.walkExpression(e.Left)
+	case *ast.FunctionLiteral:
+		r.walkFunction(e)
+	case *ast.NewExpression:
+		r.walkExpression(e.Callee)
+		r.walkExpressions(e.ArgumentList)
+	case *ast.ObjectLiteral:
+		for _, property := range e.Value {
+			r.walkProperty(property)
+		}
+	case *ast.SequenceExpression:
+		r.walkExpressions(e.Sequence)
+	case *ast.UnaryExpression:
+		r.walkExpression(e.Operand)
+	case *ast.VariableExpression:
+		r.walkExpression(e.Initializer)
+	}
+}
+
+func (r *esImportRewriter) walkExpressions(expressions []ast.Expression) {
+	for _, e := range expressions {
+		r.walkExpression(e)
+	}
+}
+
+func (r *esImportRewriter) walkProperty(property ast.Property) {
+	value, ok := property.Value.(*ast.Identifier)
+	if !ok {
+		r.walkExpression(property.Value)
+		return
+	}
+	key, ok := property.Key.(*ast.StringLiteral)
+	if !ok {
+		r.walkExpression(property.Value)
+		return
+	}
+	// the value of a shorthand property points to the token after it
+	if c := r.code[value.Idx-1]; c != ',' && c != '}' {
+		r.walkExpression(property.Value)
+		return
+	}
+	if replacement, ok := r.binding(string(value.Name)); ok {
+		r.replace(int(key.Idx), key.Literal, key.Literal+": "+replacement)
+	}
+}
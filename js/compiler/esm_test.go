@@ -0,0 +1,310 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package compiler
+
+import (
+	"testing"
+
+	"github.com/dop251/goja"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/testutils"
+)
+
+// runModules compiles and runs the "main" module, which can import the
+// other ones with a minimal require() that supports cycles the way the init
+// context does
+func runModules(t *testing.T, compatMode lib.CompatibilityMode, modules map[string]string) (*goja.Object, error) {
+	c := New(testutils.NewLogger(t))
+	rt := goja.New()
+	cache := make(map[string]*goja.Object)
+	var load func(name string) (*goja.Object, error)
+	load = func(name string) (*goja.Object, error) {
+		if module, ok := cache[name]; ok {
+			return module.Get("exports").ToObject(rt), nil
+		}
+		src, ok := modules[name]
+		if !ok {
+			return nil, assert.AnError
+		}
+		pgm, _, err := c.Compile(src, name, "(function(module, exports){\n", "\n})\n", true, compatMode)
+		if err != nil {
+			return nil, err
+		}
+		module := rt.NewObject()
+		exports := rt.NewObject()
+		_ = module.Set("exports", exports)
+		cache[name] = module
+		f, err := rt.RunProgram(pgm)
+		if err != nil {
+			return nil, err
+		}
+		fn, _ := goja.AssertFunction(f)
+		if _, err := fn(exports, module, exports); err != nil {
+			return nil, err
+		}
+		return module.Get("exports").ToObject(rt), nil
+	}
+	rt.Set("require", func(name string) *goja.Object {
+		exports, err := load(name)
+		if err != nil {
+			panic(rt.NewGoError(err))
+		}
+		return exports
+	})
+	return load("main")
+}
+
+func TestParseModule(t *testing.T) {
+	t.Parallel()
+	t.Run("NotAModule", func(t *testing.T) {
+		t.Parallel()
+		for _, src := range []string{
+			``,
+			`var a = 1; module.exports.default = function() {};`,
+			`var o = { import: 1, export: 2 }; o.import + o.export;`,
+			`require("k6/http");`,
+		} {
+			m, err := parseModule(src)
+			assert.NoError(t, err, src)
+			assert.Nil(t, m, src)
+		}
+	})
+	t.Run("Requests", func(t *testing.T) {
+		t.Parallel()
+		m, err := parseModule(`
+			import http from "k6/http";
+			import { check, sleep as pause } from "k6";
+			import * as utils from "./utils.js";
+			import "./side-effect.js";
+			export { b } from "./utils.js";
+			export * from "./more.js";
+		`)
+		require.NoError(t, err)
+		require.NotNil(t, m)
+		specifiers := make([]string, len(m.requests))
+		for i, r := range m.requests {
+			specifiers[i] = r.specifier
+		}
+		assert.Equal(t, []string{`"k6/http"`, `"k6"`, `"./utils.js"`, `"./side-effect.js"`, `"./more.js"`}, specifiers)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		t.Parallel()
+		for src, expErr := range map[string]string{
+			`export var a = 1; export { a };`:     "Duplicate export",
+			`export default 1; export default 2;`: "Duplicate export",
+			`import { a from "b";`:                "",
+			`import a "b";`:                       "",
+			`export * as from "a";`:               "",
+		} {
+			_, err := parseModule(src)
+			if assert.Error(t, err, src) && expErr != "" {
+				assert.Contains(t, err.Error(), expErr, src)
+			}
+		}
+	})
+}
+
+func TestCompileModules(t *testing.T) {
+	t.Parallel()
+	for _, compatMode := range []lib.CompatibilityMode{lib.CompatibilityModeBase, lib.CompatibilityModeExtended} {
+		compatMode := compatMode
+		t.Run(compatMode.String(), func(t *testing.T) {
+			t.Parallel()
+			t.Run("Exports", func(t *testing.T) {
+				t.Parallel()
+				exports, err := runModules(t, compatMode, map[string]string{"main": `
+					export var a = 1, b = 2;
+					export function f() { return "f"; }
+					var c = 3;
+					export { c, c as d, c as "e-f" };
+					export default function () { return "default"; }
+				`})
+				require.NoError(t, err)
+				assert.True(t, exports.Get("__esModule").ToBoolean())
+				assert.EqualValues(t, 1, exports.Get("a").Export())
+				assert.EqualValues(t, 2, exports.Get("b").Export())
+				assert.EqualValues(t, 3, exports.Get("c").Export())
+				assert.EqualValues(t, 3, exports.Get("d").Export())
+				assert.EqualValues(t, 3, exports.Get("e-f").Export())
+				for _, name := range []string{"f", "default"} {
+					fn, ok := goja.AssertFunction(exports.Get(name))
+					require.True(t, ok, name)
+					v, err := fn(goja.Undefined())
+					require.NoError(t, err)
+					assert.Equal(t, name, v.Export())
+				}
+			})
+			t.Run("ExportsAfterParentheses", func(t *testing.T) {
+				t.Parallel()
+				// parentheses that aren't arrow functions mustn't hide the later declarations
+				for name, src := range map[string]string{
+					"Expression": `var x = (1 + 2) * 3;`,
+					"IIFE":       `(function () {})();`,
+					"Call":       `var y = Math.max(1, (2));`,
+				} {
+					exports, err := runModules(t, compatMode, map[string]string{"main": src + `
+						export var options = { vus: 5 };
+						export function setup() { return "setup"; }
+					`})
+					require.NoError(t, err, name)
+					assert.EqualValues(t, 5, exports.Get("options").ToObject(nil).Get("vus").Export(), name)
+					_, ok := goja.AssertFunction(exports.Get("setup"))
+					assert.True(t, ok, name)
+				}
+			})
+			t.Run("DefaultExpression", func(t *testing.T) {
+				t.Parallel()
+				exports, err := runModules(t, compatMode, map[string]string{"main": `
+					export default { vus: 10 };
+				`})
+				require.NoError(t, err)
+				assert.EqualValues(t, 10, exports.Get("default").ToObject(nil).Get("vus").Export())
+			})
+			t.Run("LiveBindings", func(t *testing.T) {
+				t.Parallel()
+				exports, err := runModules(t, compatMode, map[string]string{
+					"main": `
+						import { counter, increment } from "counter";
+						import * as ns from "counter";
+						var before = counter;
+						increment();
+						export var result = [before, counter, ns.counter, { counter }.counter].join(",");
+					`,
+					"counter": `
+						export var counter = 0;
+						export function increment() { counter++; }
+					`,
+				})
+				require.NoError(t, err)
+				assert.Equal(t, "0,1,1,1", exports.Get("result").Export())
+			})
+			t.Run("Cycles", func(t *testing.T) {
+				t.Parallel()
+				exports, err := runModules(t, compatMode, map[string]string{
+					"main": `
+						import { isEven } from "even";
+						export var result = [isEven(4), isEven(7)].join(",");
+					`,
+					"even": `
+						import { isOdd } from "odd";
+						export function isEven(n) { return n === 0 ? true : isOdd(n - 1); }
+					`,
+					"odd": `
+						import { isEven } from "even";
+						export function isOdd(n) { return n === 0 ? false : isEven(n - 1); }
+					`,
+				})
+				require.NoError(t, err)
+				assert.Equal(t, "true,false", exports.Get("result").Export())
+			})
+			t.Run("DefaultInterop", func(t *testing.T) {
+				t.Parallel()
+				exports, err := runModules(t, compatMode, map[string]string{
+					"main": `
+						import cjs from "cjs";
+						import esm, { named } from "esm";
+						export var result = [cjs.value, esm, named].join(",");
+					`,
+					"cjs": `module.exports = { value: "cjs" };`,
+					"esm": `export default "esm"; export var named = "named";`,
+				})
+				require.NoError(t, err)
+				assert.Equal(t, "cjs,esm,named", exports.Get("result").Export())
+			})
+			t.Run("StarExports", func(t *testing.T) {
+				t.Parallel()
+				exports, err := runModules(t, compatMode, map[string]string{
+					"main": `
+						export * from "lib";
+						export * as all from "lib";
+						export { b as renamed } from "lib";
+						export var a = "main";
+					`,
+					"lib": `export var a = "lib", b = "b"; export default "default";`,
+				})
+				require.NoError(t, err)
+				assert.Equal(t, "main", exports.Get("a").Export())
+				assert.Equal(t, "b", exports.Get("b").Export())
+				assert.Equal(t, "b", exports.Get("renamed").Export())
+				assert.Nil(t, exports.Get("default"))
+				assert.Equal(t, "lib", exports.Get("all").ToObject(nil).Get("a").Export())
+			})
+			t.Run("Shadowing", func(t *testing.T) {
+				t.Parallel()
+				exports, err := runModules(t, compatMode, map[string]string{
+					"main": `
+						import { a, f } from "lib";
+						function g(a) { return a; }
+						function h() { var a = "local"; return a; }
+						function i() { try { throw "caught"; } catch (a) { return a; } }
+						var o = { a: "key" };
+						export var result = [a, f(), g("param"), h(), i(), o.a].join(",");
+					`,
+					"lib": `
+						export var a = "imported";
+						export function f() { return this === undefined ? "unbound" : "bound"; }
+					`,
+				})
+				require.NoError(t, err)
+				assert.Equal(t, "imported,unbound,param,local,caught,key", exports.Get("result").Export())
+			})
+			t.Run("ImportsAreReadOnly", func(t *testing.T) {
+				t.Parallel()
+				_, err := runModules(t, compatMode, map[string]string{
+					"main": `import { a } from "lib"; a = 2;`,
+					"lib":  `export var a = 1;`,
+				})
+				require.Error(t, err)
+			})
+			t.Run("ErrorPositions", func(t *testing.T) {
+				t.Parallel()
+				_, err := runModules(t, compatMode, map[string]string{
+					"main": "import { a } from \"lib\";\n\nthrow new Error(a);\n",
+					"lib":  `export var a = "oops";`,
+				})
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "oops")
+				// the same position as for a CommonJS module, i.e. shifted by the wrapper
+				assert.Contains(t, err.Error(), "main:4:7")
+			})
+		})
+	}
+
+	t.Run("ES6Syntax", func(t *testing.T) {
+		t.Parallel()
+		modules := map[string]string{
+			"main": `
+				import { sum } from "lib";
+				export const result = sum(1, 2, 3);
+			`,
+			"lib": `export const sum = (...values) => values.reduce((a, b) => a + b, 0);`,
+		}
+		exports, err := runModules(t, lib.CompatibilityModeExtended, modules)
+		require.NoError(t, err)
+		assert.EqualValues(t, 6, exports.Get("result").Export())
+
+		_, err = runModules(t, lib.CompatibilityModeBase, modules)
+		require.Error(t, err)
+	})
+}
@@ -20,6 +20,18 @@
 
 package compiler
 
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-sourcemap/sourcemap"
+)
+
 type SourceMap struct {
 	Version    int
 	File       string
@@ -28,3 +40,134 @@ type SourceMap struct {
 	Names      []string
 	Mappings   string
 }
+
+// sourceMappingURLRe matches the comments that reference the source map of a
+// generated script, the last of which is used
+var sourceMappingURLRe = regexp.MustCompile(`(?m)^[ \t]*//[#@][ \t]*sourceMappingURL=(\S+)[ \t]*\r?$`) //nolint:gochecknoglobals
+
+// A SourceMapLoader loads the source map with the given URL, which is resolved
+// relative to the script that references it.
+type SourceMapLoader func(mapURL *url.URL) ([]byte, error)
+
+// sourceMaps keeps the source maps of the compiled scripts, which are used to
+// map positions in the generated code back to the original sources.
+type sourceMaps struct {
+	mu    sync.RWMutex
+	files map[string]*sourceMappedFile
+}
+
+type sourceMappedFile struct {
+	consumer *sourcemap.Consumer
+	// the number of lines that were added before the script when compiling it
+	lineOffset int
+	// matches the positions in the file, e.g. file:///script.js:12:5
+	positionRe *regexp.Regexp
+}
+
+// extractSourceMappingURL returns the source map URL referenced by the given
+// script, if any
+func extractSourceMappingURL(src string) string {
+	matches := sourceMappingURLRe.FindAllStringSubmatch(src, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	return matches[len(matches)-1][1]
+}
+
+// decodeDataURL returns the contents of an inline data: URL
+func decodeDataURL(dataURL string) ([]byte, error) {
+	i := strings.IndexByte(dataURL, ',')
+	if !strings.HasPrefix(dataURL, "data:") || i < 0 {
+		return nil, fmt.Errorf("invalid data URL")
+	}
+	header, data := dataURL[len("data:"):i], dataURL[i+1:]
+	if strings.HasSuffix(header, ";base64") {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	unescaped, err := url.PathUnescape(data)
+	return []byte(unescaped), err
+}
+
+// loadSourceMap loads and parses the source map referenced by the given
+// script, if it references one. Scripts are compiled again for every VU, so
+// the result is kept per filename and the source map is only loaded, and any
+// error returned, the first time.
+func (c *Compiler) loadSourceMap(src, filename string, lineOffset int) error {
+	c.sourceMaps.mu.Lock()
+	defer c.sourceMaps.mu.Unlock()
+	if _, loaded := c.sourceMaps.files[filename]; loaded {
+		return nil
+	}
+	if c.sourceMaps.files == nil {
+		c.sourceMaps.files = make(map[string]*sourceMappedFile)
+	}
+	// scripts without a usable source map are kept as nil
+	file, err := c.parseSourceMap(src, filename, lineOffset)
+	c.sourceMaps.files[filename] = file
+	return err
+}
+
+// parseSourceMap returns the source map referenced by the given script, or
+// nil if it doesn't reference one
+func (c *Compiler) parseSourceMap(src, filename string, lineOffset int) (*sourceMappedFile, error) {
+	ref := extractSourceMappingURL(src)
+	if ref == "" || c.SourceMapLoader == nil && !strings.HasPrefix(ref, "data:") {
+		return nil, nil
+	}
+
+	var (
+		mapURL = filename // inline source maps resolve their sources relative to the script
+		data   []byte
+		err    error
+	)
+	if strings.HasPrefix(ref, "data:") {
+		data, err = decodeDataURL(ref)
+	} else {
+		var base, resolved *url.URL
+		if base, err = url.Parse(filename); err != nil {
+			return nil, err
+		}
+		if resolved, err = base.Parse(ref); err != nil {
+			return nil, err
+		}
+		mapURL = resolved.String()
+		data, err = c.SourceMapLoader(resolved)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't load the source map %s: %w", ref, err)
+	}
+
+	consumer, err := sourcemap.Parse(mapURL, data)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse the source map %s: %w", ref, err)
+	}
+	return &sourceMappedFile{
+		consumer:   consumer,
+		lineOffset: lineOffset,
+		positionRe: regexp.MustCompile(regexp.QuoteMeta(filename) + `:(\d+):(\d+)`),
+	}, nil
+}
+
+// MapPositions rewrites the file:line:column positions in the given text,
+// e.g. the stack trace of an exception, that point to generated scripts with
+// source maps, so that they point to the original sources instead.
+func (c *Compiler) MapPositions(text string) string {
+	c.sourceMaps.mu.RLock()
+	defer c.sourceMaps.mu.RUnlock()
+	for filename, file := range c.sourceMaps.files {
+		if file == nil || !strings.Contains(text, filename) {
+			continue
+		}
+		text = file.positionRe.ReplaceAllStringFunc(text, func(position string) string {
+			groups := file.positionRe.FindStringSubmatch(position)
+			line, _ := strconv.Atoi(groups[1])
+			column, _ := strconv.Atoi(groups[2])
+			source, _, line, column, ok := file.consumer.Source(line-file.lineOffset, column-1)
+			if !ok || source == "" {
+				return position
+			}
+			return fmt.Sprintf("%s:%d:%d", source, line, column+1)
+		})
+	}
+	return text
+}
@@ -0,0 +1,162 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package compiler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/dop251/goja"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/testutils"
+)
+
+// encodeVLQ encodes a source map mapping field
+func encodeVLQ(n int) string {
+	const chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+	v := n << 1
+	if n < 0 {
+		v = (-n << 1) | 1
+	}
+	var b strings.Builder
+	for {
+		digit := v & 31
+		v >>= 5
+		if v > 0 {
+			digit |= 32
+		}
+		b.WriteByte(chars[digit])
+		if v == 0 {
+			return b.String()
+		}
+	}
+}
+
+// makeSourceMap returns a source map that maps the start of each generated
+// line to the given zero-based source index, line and column
+func makeSourceMap(t *testing.T, sources []string, lines [][3]int) []byte {
+	var mappings []string
+	prev := [3]int{}
+	for _, line := range lines {
+		mappings = append(mappings, encodeVLQ(0)+encodeVLQ(line[0]-prev[0])+
+			encodeVLQ(line[1]-prev[1])+encodeVLQ(line[2]-prev[2]))
+		prev = line
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"version":  3,
+		"sources":  sources,
+		"names":    []string{},
+		"mappings": strings.Join(mappings, ";"),
+	})
+	require.NoError(t, err)
+	return data
+}
+
+// the generated script, whose third line maps to line 11 of src/fail.js
+const bundledScript = `var x = 1;
+function fail() {
+throw new Error("boom");
+}
+fail();
+`
+
+func TestSourceMaps(t *testing.T) {
+	t.Parallel()
+	sourceMap := makeSourceMap(t, []string{"../src/main.js", "../src/fail.js"}, [][3]int{
+		{0, 0, 0}, {1, 9, 0}, {1, 10, 4}, {1, 11, 0}, {0, 4, 0}, {0, 5, 0},
+	})
+
+	run := func(t *testing.T, c *Compiler, src, filename, pre, post string) string {
+		pgm, _, err := c.Compile(src, filename, pre, post, true, lib.CompatibilityModeBase)
+		require.NoError(t, err)
+		rt := goja.New()
+		v, err := rt.RunProgram(pgm)
+		if fn, ok := goja.AssertFunction(v); ok {
+			_, err = fn(goja.Undefined())
+		}
+		require.Error(t, err)
+		exception, ok := err.(*goja.Exception)
+		require.True(t, ok)
+		return c.MapPositions(exception.String())
+	}
+
+	t.Run("inline", func(t *testing.T) {
+		t.Parallel()
+		src := bundledScript + "//# sourceMappingURL=data:application/json;charset=utf-8;base64," +
+			base64.StdEncoding.EncodeToString(sourceMap) + "\n"
+		stack := run(t, New(testutils.NewLogger(t)), src, "file:///test/dist/bundle.js", "", "")
+		assert.Contains(t, stack, "at fail (file:///test/src/fail.js:11:5(")
+		assert.Contains(t, stack, "file:///test/src/main.js:5:1(")
+		assert.NotContains(t, stack, "bundle.js")
+	})
+
+	t.Run("external", func(t *testing.T) {
+		t.Parallel()
+		var loaded []string
+		c := New(testutils.NewLogger(t))
+		c.SourceMapLoader = func(mapURL *url.URL) ([]byte, error) {
+			loaded = append(loaded, mapURL.String())
+			return sourceMap, nil
+		}
+		src := bundledScript + "//# sourceMappingURL=bundle.js.map"
+		stack := run(t, c, src, "https://example.com/dist/bundle.js", "(function(){\n", "\n})")
+		assert.Equal(t, []string{"https://example.com/dist/bundle.js.map"}, loaded)
+		assert.Contains(t, stack, "at fail (https://example.com/src/fail.js:11:5(")
+
+		// compiling the script again, e.g. for another VU, reuses the source map
+		stack = run(t, c, src, "https://example.com/dist/bundle.js", "(function(){\n", "\n})")
+		assert.Len(t, loaded, 1)
+		assert.Contains(t, stack, "at fail (https://example.com/src/fail.js:11:5(")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		logger, hook := logtest.NewNullLogger()
+		c := New(logger)
+		c.SourceMapLoader = func(mapURL *url.URL) ([]byte, error) {
+			return nil, fmt.Errorf("no such file")
+		}
+		stack := run(t, c, bundledScript+"//# sourceMappingURL=bundle.js.map", "file:///test/dist/bundle.js", "", "")
+		assert.Contains(t, stack, "at fail (file:///test/dist/bundle.js:3:")
+		// the warning is only logged the first time the script is compiled
+		run(t, c, bundledScript+"//# sourceMappingURL=bundle.js.map", "file:///test/dist/bundle.js", "", "")
+		entries := hook.AllEntries()
+		require.Len(t, entries, 1)
+		assert.Equal(t, logrus.WarnLevel, entries[0].Level)
+		assert.Contains(t, entries[0].Message, "file:///test/dist/bundle.js")
+	})
+
+	t.Run("no source map", func(t *testing.T) {
+		t.Parallel()
+		c := New(testutils.NewLogger(t))
+		stack := run(t, c, bundledScript, "file:///test/bundle.js", "", "")
+		assert.Contains(t, stack, "at fail (file:///test/bundle.js:3:")
+	})
+}
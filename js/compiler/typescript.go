@@ -0,0 +1,2064 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package compiler
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IsTypeScript returns whether the file with the given name or URL should be
+// treated as a TypeScript source.
+func IsTypeScript(filename string) bool {
+	if i := strings.IndexAny(filename, "?#"); i >= 0 {
+		filename = filename[:i]
+	}
+	return path.Ext(filename) == ".ts"
+}
+
+// tsEdit replaces a range of the source with the given text, followed by the
+// line terminators of the range, so that the following lines don't move.
+type tsEdit struct {
+	start, end int
+	text       string
+}
+
+// stripTypes removes the TypeScript type annotations and declarations from
+// the given source, and returns JavaScript that can be compiled further.
+//
+// The types are replaced with whitespace, so the positions of all of the
+// remaining code are the same and errors point to the original lines and
+// columns. Only enums and constructor parameter properties, which are
+// replaced with equivalent code, shift the columns of their lines.
+func stripTypes(src, filename string) (code string, err error) {
+	p := &tsParser{
+		s:          tsScanner{src: src},
+		typeNames:  make(map[string]bool),
+		valueNames: make(map[string]bool),
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			syntaxErr, ok := r.(*tsSyntaxError)
+			if !ok {
+				panic(r)
+			}
+			line, col := tsPosition(src, syntaxErr.pos)
+			err = fmt.Errorf("%s: %s (%d:%d)", filename, syntaxErr.msg, line, col)
+		}
+	}()
+
+	p.next()
+	for p.tok.kind != tsEOF {
+		p.parseStatement()
+	}
+	p.eraseTypeExports()
+	return p.render(0, len(src)), nil
+}
+
+func tsPosition(src string, pos int) (line, col int) {
+	if pos > len(src) {
+		pos = len(src)
+	}
+	before := src[:pos]
+	line = strings.Count(before, "\n") + 1
+	return line, pos - strings.LastIndex(before, "\n")
+}
+
+type tsExportSpecifier struct {
+	name       string
+	start, end int
+}
+
+type tsParser struct {
+	s       tsScanner
+	tok     tsToken
+	prevEnd int
+	edits   []tsEdit
+
+	// the depth of the current block, for tracking the top-level declarations
+	depth int
+	// whether the true branch of a conditional expression is being parsed
+	inConditional bool
+
+	typeNames, valueNames map[string]bool
+	exportSpecifiers      []tsExportSpecifier
+	// the top-level value names in the order of their declarations
+	declaredValues []string
+}
+
+// tsParserState is everything that parsing changes, except for the names of
+// the declarations, which are only added at the top level and not in the
+// speculative parsing of expressions
+type tsParserState struct {
+	pos, prevEnd, edits, exportSpecifiers, declaredValues, depth int
+	tok                                                          tsToken
+	inConditional                                                bool
+}
+
+func (p *tsParser) save() tsParserState {
+	return tsParserState{
+		pos: p.s.pos, prevEnd: p.prevEnd, edits: len(p.edits), exportSpecifiers: len(p.exportSpecifiers),
+		declaredValues: len(p.declaredValues), depth: p.depth, tok: p.tok, inConditional: p.inConditional,
+	}
+}
+
+func (p *tsParser) restore(state tsParserState) {
+	p.s.pos = state.pos
+	p.prevEnd = state.prevEnd
+	p.edits = p.edits[:state.edits]
+	p.exportSpecifiers = p.exportSpecifiers[:state.exportSpecifiers]
+	p.declaredValues = p.declaredValues[:state.declaredValues]
+	p.depth = state.depth
+	p.tok = state.tok
+	p.inConditional = state.inConditional
+}
+
+// try runs the given parsing function, and restores the parser state if it
+// fails
+func (p *tsParser) try(fn func()) (ok bool) {
+	state := p.save()
+	defer func() {
+		if r := recover(); r != nil {
+			if _, isSyntaxErr := r.(*tsSyntaxError); !isSyntaxErr {
+				panic(r)
+			}
+			p.restore(state)
+			ok = false
+		}
+	}()
+	fn()
+	return true
+}
+
+func (p *tsParser) next() {
+	p.prevEnd = p.tok.end
+	p.tok = p.s.scan()
+}
+
+// peek returns the token after the current one
+func (p *tsParser) peek() tsToken {
+	pos := p.s.pos
+	tok := p.s.scan()
+	p.s.pos = pos
+	return tok
+}
+
+// peek2 returns the second token after the current one
+func (p *tsParser) peek2() tsToken {
+	pos := p.s.pos
+	p.s.scan()
+	tok := p.s.scan()
+	p.s.pos = pos
+	return tok
+}
+
+func (p *tsParser) fail(format string, args ...interface{}) {
+	panic(&tsSyntaxError{pos: p.tok.start, msg: fmt.Sprintf(format, args...)})
+}
+
+func (p *tsParser) unexpected() {
+	if p.tok.kind == tsEOF {
+		p.fail("unexpected end of input")
+	}
+	p.fail("unexpected token %s", p.s.src[p.tok.start:p.tok.end])
+}
+
+func (p *tsParser) isP(value string) bool {
+	return p.tok.kind == tsPunct && p.tok.value == value
+}
+
+func (p *tsParser) isK(value string) bool {
+	return p.tok.kind == tsIdent && p.tok.value == value
+}
+
+func (p *tsParser) expectP(value string) {
+	if !p.isP(value) {
+		p.unexpected()
+	}
+	p.next()
+}
+
+func (p *tsParser) expectK(value string) {
+	if !p.isK(value) {
+		p.unexpected()
+	}
+	p.next()
+}
+
+func (p *tsParser) expectIdent() string {
+	if p.tok.kind != tsIdent {
+		p.unexpected()
+	}
+	name := p.tok.value
+	p.next()
+	return name
+}
+
+// expectGreater consumes a closing angle bracket, splitting tokens like >>
+func (p *tsParser) expectGreater() {
+	if p.tok.kind != tsPunct || !strings.HasPrefix(p.tok.value, ">") {
+		p.unexpected()
+	}
+	if p.tok.value == ">" {
+		p.next()
+		return
+	}
+	p.tok.start++
+	p.tok.value = p.tok.value[1:]
+	p.tok.newlineBefore = false
+	p.prevEnd = p.tok.start
+}
+
+func (p *tsParser) consumeSemicolon() {
+	switch {
+	case p.isP(";"):
+		p.next()
+	case p.isP("}") || p.tok.kind == tsEOF || p.tok.newlineBefore:
+	default:
+		p.unexpected()
+	}
+}
+
+// erase replaces the given range with whitespace, and optionally some text
+func (p *tsParser) erase(start, end int, text string) {
+	p.edits = append(p.edits, tsEdit{start: start, end: end, text: text})
+}
+
+// eraseFrom removes any edits after the given mark, and erases the whole
+// range, e.g. for declarations that contain other erased types
+func (p *tsParser) eraseFrom(mark, start, end int, text string) {
+	p.edits = p.edits[:mark]
+	p.erase(start, end, text)
+}
+
+// eraseType erases a type annotation, from the current token to the end of
+// the type
+func (p *tsParser) eraseType(parse func()) {
+	start := p.tok.start
+	mark := len(p.edits)
+	p.next()
+	parse()
+	p.eraseFrom(mark, start, p.prevEnd, "")
+}
+
+// render returns the source in the given range with all of the edits in it
+func (p *tsParser) render(start, end int) string {
+	edits := make([]tsEdit, 0, len(p.edits))
+	for _, e := range p.edits {
+		if e.start >= start && e.end <= end {
+			edits = append(edits, e)
+		}
+	}
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+
+	var b strings.Builder
+	b.Grow(end - start)
+	pos := start
+	for _, e := range edits {
+		if e.start < pos {
+			continue // nested in an already applied edit
+		}
+		b.WriteString(p.s.src[pos:e.start])
+		b.WriteString(e.text)
+		blankLen := 0
+		for i := e.start; i < e.end; i++ {
+			c := p.s.src[i]
+			if c == '\n' || c == '\r' {
+				b.WriteByte(c)
+				blankLen = -1 // the text only replaces the spaces before the first line terminator
+			} else if blankLen >= 0 && blankLen < len(e.text) {
+				blankLen++
+			} else {
+				b.WriteByte(' ')
+			}
+		}
+		pos = e.end
+	}
+	b.WriteString(p.s.src[pos:end])
+	return b.String()
+}
+
+// eraseTypeExports erases the local exports of names that are only types,
+// since they don't exist after the types are erased
+func (p *tsParser) eraseTypeExports() {
+	for _, spec := range p.exportSpecifiers {
+		if p.typeNames[spec.name] && !p.valueNames[spec.name] {
+			p.erase(spec.start, spec.end, "")
+		}
+	}
+}
+
+func (p *tsParser) declareValue(name string) {
+	if p.depth == 0 {
+		p.valueNames[name] = true
+		p.declaredValues = append(p.declaredValues, name)
+	}
+}
+
+func (p *tsParser) declareType(name string) {
+	if p.depth == 0 {
+		p.typeNames[name] = true
+	}
+}
+
+// Statements
+
+//nolint:funlen,gocyclo,cyclop
+func (p *tsParser) parseStatement() {
+	start := p.tok.start
+	switch p.tok.kind {
+	case tsPunct:
+		switch p.tok.value {
+		case "{":
+			p.parseBlock()
+			return
+		case ";":
+			p.next()
+			return
+		case "@":
+			p.fail("decorators are not supported")
+		}
+	case tsIdent:
+		next := p.peek()
+		sameLine := !next.newlineBefore
+		switch p.tok.value {
+		case "var", "const":
+			if p.isK("const") && next.kind == tsIdent && next.value == "enum" {
+				p.next()
+				p.parseEnum(start, false)
+				return
+			}
+			p.parseVarDeclarations(false)
+			p.consumeSemicolon()
+			return
+		case "let":
+			if next.kind == tsIdent || next.kind == tsPunct && (next.value == "[" || next.value == "{") {
+				p.parseVarDeclarations(false)
+				p.consumeSemicolon()
+				return
+			}
+		case "function":
+			p.parseFunctionDeclaration(start)
+			return
+		case "async":
+			if sameLine && next.kind == tsIdent && next.value == "function" {
+				p.parseFunctionDeclaration(start)
+				return
+			}
+		case "class":
+			p.parseClass(start, true)
+			return
+		case "abstract":
+			if sameLine && next.kind == tsIdent && next.value == "class" {
+				p.erase(p.tok.start, p.tok.end, "")
+				p.next()
+				p.parseClass(start, true)
+				return
+			}
+		case "if":
+			p.next()
+			p.parseParenthesized()
+			p.parseStatement()
+			if p.isK("else") {
+				p.next()
+				p.parseStatement()
+			}
+			return
+		case "for":
+			p.parseFor()
+			return
+		case "while", "with":
+			p.next()
+			p.parseParenthesized()
+			p.parseStatement()
+			return
+		case "do":
+			p.next()
+			p.parseStatement()
+			p.expectK("while")
+			p.parseParenthesized()
+			if p.isP(";") {
+				p.next()
+			}
+			return
+		case "return", "throw":
+			p.next()
+			if !p.isP(";") && !p.isP("}") && p.tok.kind != tsEOF && !p.tok.newlineBefore {
+				p.parseExpression(false)
+			}
+			p.consumeSemicolon()
+			return
+		case "break", "continue":
+			p.next()
+			if p.tok.kind == tsIdent && !p.tok.newlineBefore {
+				p.next()
+			}
+			p.consumeSemicolon()
+			return
+		case "debugger":
+			p.next()
+			p.consumeSemicolon()
+			return
+		case "try":
+			p.parseTry()
+			return
+		case "switch":
+			p.parseSwitch()
+			return
+		case "import":
+			if next.kind != tsPunct || next.value != "(" && next.value != "." {
+				p.parseImport()
+				return
+			}
+		case "export":
+			p.parseExport()
+			return
+		case "type":
+			if sameLine && next.kind == tsIdent {
+				p.parseTypeAlias(start)
+				return
+			}
+		case "interface":
+			if sameLine && next.kind == tsIdent {
+				p.parseInterface(start)
+				return
+			}
+		case "enum":
+			if sameLine && next.kind == tsIdent {
+				p.parseEnum(start, false)
+				return
+			}
+		case "declare":
+			if sameLine && next.kind == tsIdent {
+				p.parseDeclare(start)
+				return
+			}
+		case "namespace", "module":
+			if sameLine && (next.kind == tsIdent || next.kind == tsString) {
+				p.fail("TypeScript namespaces are not supported, use modules instead")
+			}
+		default:
+			if next.kind == tsPunct && next.value == ":" {
+				// a labeled statement
+				p.next()
+				p.next()
+				p.parseStatement()
+				return
+			}
+		}
+	}
+
+	p.parseExpression(false)
+	p.consumeSemicolon()
+}
+
+func (p *tsParser) parseBlock() {
+	p.expectP("{")
+	p.depth++
+	for !p.isP("}") {
+		if p.tok.kind == tsEOF {
+			p.unexpected()
+		}
+		p.parseStatement()
+	}
+	p.depth--
+	p.next()
+}
+
+func (p *tsParser) parseParenthesized() {
+	p.expectP("(")
+	inConditional := p.inConditional
+	p.inConditional = false
+	p.parseExpression(false)
+	p.inConditional = inConditional
+	p.expectP(")")
+}
+
+func (p *tsParser) parseFor() {
+	p.expectK("for")
+	if p.isK("await") {
+		p.next()
+	}
+	p.expectP("(")
+	p.depth++
+	defer func() { p.depth-- }()
+	if !p.isP(";") {
+		next := p.peek()
+		if p.isK("var") || p.isK("const") ||
+			p.isK("let") && (next.kind == tsIdent || next.kind == tsPunct && (next.value == "[" || next.value == "{")) {
+			p.parseVarDeclarations(true)
+		} else {
+			p.parseExpression(true)
+		}
+	}
+	switch {
+	case p.isK("of"):
+		p.next()
+		p.parseAssignment(false)
+	case p.isK("in"):
+		p.next()
+		p.parseExpression(false)
+	default:
+		p.expectP(";")
+		if !p.isP(";") {
+			p.parseExpression(false)
+		}
+		p.expectP(";")
+		if !p.isP(")") {
+			p.parseExpression(false)
+		}
+	}
+	p.expectP(")")
+	p.parseStatement()
+}
+
+func (p *tsParser) parseTry() {
+	p.expectK("try")
+	p.parseBlock()
+	if p.isK("catch") {
+		p.next()
+		if p.isP("(") {
+			p.next()
+			p.parseBindingTarget()
+			if p.isP(":") {
+				p.eraseType(p.parseType)
+			}
+			p.expectP(")")
+		}
+		p.parseBlock()
+	}
+	if p.isK("finally") {
+		p.next()
+		p.parseBlock()
+	}
+}
+
+func (p *tsParser) parseSwitch() {
+	p.expectK("switch")
+	p.parseParenthesized()
+	p.expectP("{")
+	p.depth++
+	for !p.isP("}") {
+		switch {
+		case p.isK("case"):
+			p.next()
+			p.parseExpression(false)
+			p.expectP(":")
+		case p.isK("default"):
+			p.next()
+			p.expectP(":")
+		case p.tok.kind == tsEOF:
+			p.unexpected()
+		default:
+			p.parseStatement()
+		}
+	}
+	p.depth--
+	p.next()
+}
+
+func (p *tsParser) parseVarDeclarations(noIn bool) {
+	p.next() // var, let or const
+	for {
+		p.parseBindingTarget()
+		if p.isP("!") {
+			p.erase(p.tok.start, p.tok.end, "")
+			p.next()
+		}
+		if p.isP(":") {
+			p.eraseType(p.parseType)
+		}
+		if p.isP("=") {
+			p.next()
+			p.parseAssignment(noIn)
+		}
+		if !p.isP(",") {
+			return
+		}
+		p.next()
+	}
+}
+
+// parseBindingTarget parses an identifier or a destructuring pattern
+func (p *tsParser) parseBindingTarget() {
+	switch {
+	case p.tok.kind == tsIdent:
+		p.declareValue(p.tok.value)
+		p.next()
+	case p.isP("["):
+		p.next()
+		for !p.isP("]") {
+			if p.isP(",") {
+				p.next()
+				continue
+			}
+			if p.isP("...") {
+				p.next()
+			}
+			p.parseBindingElement()
+			if !p.isP("]") {
+				p.expectP(",")
+			}
+		}
+		p.next()
+	case p.isP("{"):
+		p.next()
+		for !p.isP("}") {
+			if p.isP("...") {
+				p.next()
+				p.parseBindingTarget()
+			} else {
+				shorthand := p.tok.kind == tsIdent
+				if shorthand {
+					p.declareValue(p.tok.value)
+				}
+				p.parsePropertyName()
+				if p.isP(":") {
+					p.next()
+					p.parseBindingElement()
+				} else if !shorthand {
+					p.unexpected()
+				} else if p.isP("=") {
+					p.next()
+					p.parseAssignment(false)
+				}
+			}
+			if !p.isP("}") {
+				p.expectP(",")
+			}
+		}
+		p.next()
+	default:
+		p.unexpected()
+	}
+}
+
+func (p *tsParser) parseBindingElement() {
+	p.parseBindingTarget()
+	if p.isP("=") {
+		p.next()
+		p.parseAssignment(false)
+	}
+}
+
+func (p *tsParser) parsePropertyName() {
+	switch p.tok.kind {
+	case tsIdent, tsString, tsNumber, tsPrivateName:
+		p.next()
+	case tsPunct:
+		if !p.isP("[") {
+			p.unexpected()
+		}
+		p.next()
+		inConditional := p.inConditional
+		p.inConditional = false
+		p.parseAssignment(false)
+		p.inConditional = inConditional
+		p.expectP("]")
+	default:
+		p.unexpected()
+	}
+}
+
+// parseParams parses the parameters of a function, and returns the names of
+// any constructor parameter properties
+func (p *tsParser) parseParams() (properties []string) {
+	p.expectP("(")
+	inConditional := p.inConditional
+	p.inConditional = false
+	p.depth++
+	for !p.isP(")") {
+		start := p.tok.start
+		if p.isP("@") {
+			p.fail("decorators are not supported")
+		}
+		isProperty := false
+		for p.tok.kind == tsIdent && tsParamModifiers[p.tok.value] {
+			next := p.peek()
+			if next.kind != tsIdent && (next.kind != tsPunct || next.value != "{" && next.value != "[") {
+				break
+			}
+			p.erase(p.tok.start, p.tok.end, "")
+			p.next()
+			isProperty = true
+		}
+		if p.isK("this") {
+			if next := p.peek(); next.kind == tsPunct && (next.value == ":" || next.value == "," || next.value == ")") {
+				mark := len(p.edits)
+				p.next()
+				if p.isP(":") {
+					p.next()
+					p.parseType()
+				}
+				if p.isP(",") {
+					p.next()
+				}
+				p.eraseFrom(mark, start, p.prevEnd, "")
+				continue
+			}
+		}
+		if p.isP("...") {
+			p.next()
+		}
+		if isProperty && p.tok.kind == tsIdent {
+			properties = append(properties, p.tok.value)
+		}
+		p.parseBindingTarget()
+		if p.isP("?") {
+			p.erase(p.tok.start, p.tok.end, "")
+			p.next()
+		}
+		if p.isP(":") {
+			p.eraseType(p.parseType)
+		}
+		if p.isP("=") {
+			p.next()
+			p.parseAssignment(false)
+		}
+		if !p.isP(")") {
+			p.expectP(",")
+		}
+	}
+	p.depth--
+	p.next()
+	p.inConditional = inConditional
+	return properties
+}
+
+//nolint:gochecknoglobals
+var tsParamModifiers = map[string]bool{
+	"public": true, "private": true, "protected": true, "readonly": true, "override": true,
+}
+
+// parseFunctionDeclaration parses a function declaration, and erases it if it
+// is an overload signature without a body
+func (p *tsParser) parseFunctionDeclaration(start int) {
+	mark := len(p.edits)
+	if p.isK("async") {
+		p.next()
+	}
+	p.expectK("function")
+	if p.isP("*") {
+		p.next()
+	}
+	if p.tok.kind == tsIdent {
+		p.declareValue(p.tok.value)
+		p.next()
+	}
+	if !p.parseFunctionRest(false, false) {
+		p.consumeSemicolon()
+		p.eraseFrom(mark, start, p.prevEnd, ";")
+	}
+}
+
+// parseFunctionRest parses the type parameters, parameters, return type and
+// body of a function, and returns whether it has a body
+func (p *tsParser) parseFunctionRest(isConstructor, hasSuper bool) bool {
+	if p.isP("<") {
+		p.eraseType(p.parseTypeParamsRest)
+	}
+	properties := p.parseParams()
+	if p.isP(":") {
+		p.eraseType(p.parseReturnType)
+	}
+	if !p.isP("{") {
+		return false
+	}
+	if isConstructor && len(properties) > 0 {
+		p.parseConstructorBody(properties, hasSuper)
+	} else {
+		p.parseFunctionBody()
+	}
+	return true
+}
+
+func (p *tsParser) parseFunctionBody() {
+	inConditional := p.inConditional
+	p.inConditional = false
+	p.parseBlock()
+	p.inConditional = inConditional
+}
+
+// parseConstructorBody parses the body of a constructor and adds the
+// assignments of the parameter properties, after the super() call if the
+// class has a base class
+func (p *tsParser) parseConstructorBody(properties []string, hasSuper bool) {
+	var assignments strings.Builder
+	for _, name := range properties {
+		fmt.Fprintf(&assignments, "this.%s = %s; ", name, name)
+	}
+
+	inConditional := p.inConditional
+	p.inConditional = false
+	p.expectP("{")
+	insertPos := p.prevEnd
+	text := assignments.String()
+	p.depth++
+	superCalled := false
+	for !p.isP("}") {
+		if p.tok.kind == tsEOF {
+			p.unexpected()
+		}
+		isSuperCall := hasSuper && !superCalled && p.isK("super")
+		p.parseStatement()
+		if isSuperCall {
+			superCalled = true
+			insertPos = p.prevEnd
+			if p.s.src[insertPos-1] != ';' {
+				text = "; " + text
+			}
+		}
+	}
+	p.depth--
+	p.next()
+	p.inConditional = inConditional
+	p.erase(insertPos, insertPos, strings.TrimSuffix(text, " "))
+}
+
+//nolint:gochecknoglobals
+var tsClassModifiers = map[string]bool{
+	"static": true, "public": true, "private": true, "protected": true, "readonly": true, "abstract": true,
+	"override": true, "declare": true, "accessor": true, "async": true, "get": true, "set": true,
+}
+
+// the class member modifiers that are removed
+//
+//nolint:gochecknoglobals
+var tsTypeModifiers = map[string]bool{
+	"public": true, "private": true, "protected": true, "readonly": true, "abstract": true,
+	"override": true, "declare": true,
+}
+
+// isModifier returns whether the current identifier is used as a modifier of
+// the following class member or object literal property
+func (p *tsParser) isModifier() bool {
+	next := p.peek()
+	if next.newlineBefore && p.tok.value != "static" && p.tok.value != "get" && p.tok.value != "set" {
+		return false
+	}
+	switch next.kind {
+	case tsIdent, tsString, tsNumber, tsPrivateName:
+		return true
+	case tsPunct:
+		return next.value == "[" || next.value == "*" || next.value == "{" && p.tok.value == "static"
+	default:
+		return false
+	}
+}
+
+func (p *tsParser) parseClass(start int, isStatement bool) {
+	p.expectK("class")
+	if p.tok.kind == tsIdent && !p.isK("extends") && !p.isK("implements") {
+		if isStatement {
+			p.declareValue(p.tok.value)
+		}
+		p.next()
+	}
+	if p.isP("<") {
+		p.eraseType(p.parseTypeParamsRest)
+	}
+	hasSuper := false
+	if p.isK("extends") {
+		hasSuper = true
+		p.next()
+		p.parseLeftHandSide()
+		if p.isP("<") {
+			p.eraseType(p.parseTypeArgsRest)
+		}
+	}
+	if p.isK("implements") {
+		p.eraseType(func() {
+			p.parseType()
+			for p.isP(",") {
+				p.next()
+				p.parseType()
+			}
+		})
+	}
+
+	p.expectP("{")
+	inConditional := p.inConditional
+	p.inConditional = false
+	for !p.isP("}") {
+		if p.tok.kind == tsEOF {
+			p.unexpected()
+		}
+		p.parseClassMember(hasSuper)
+	}
+	p.inConditional = inConditional
+	p.next()
+}
+
+//nolint:funlen,gocyclo,cyclop
+func (p *tsParser) parseClassMember(hasSuper bool) {
+	if p.isP(";") {
+		p.next()
+		return
+	}
+	if p.isP("@") {
+		p.fail("decorators are not supported")
+	}
+
+	start := p.tok.start
+	mark := len(p.edits)
+	eraseWhole := false
+	for p.tok.kind == tsIdent && tsClassModifiers[p.tok.value] && p.isModifier() {
+		if p.isK("static") && p.peek().value == "{" {
+			p.next()
+			p.parseFunctionBody()
+			return
+		}
+		if tsTypeModifiers[p.tok.value] {
+			text := ""
+			if p.tok.start == start {
+				text = ";"
+			}
+			p.erase(p.tok.start, p.tok.end, text)
+			if p.isK("declare") || p.isK("abstract") {
+				eraseWhole = true
+			}
+		}
+		p.next()
+	}
+	if p.isP("*") {
+		p.next()
+	}
+
+	// index signatures
+	if p.isP("[") && p.try(func() {
+		p.next()
+		p.expectIdent()
+		p.expectP(":")
+		p.parseType()
+		p.expectP("]")
+		if p.isP("?") {
+			p.next()
+		}
+		p.expectP(":")
+		p.parseType()
+	}) {
+		p.consumeSemicolon()
+		p.eraseFrom(mark, start, p.prevEnd, ";")
+		return
+	}
+
+	isConstructor := p.isK("constructor")
+	p.parsePropertyName()
+	if p.isP("?") || p.isP("!") {
+		p.erase(p.tok.start, p.tok.end, "")
+		p.next()
+	}
+
+	if p.isP("(") || p.isP("<") {
+		if !p.parseFunctionRest(isConstructor, hasSuper) {
+			// an overload or an abstract method
+			p.consumeSemicolon()
+			p.eraseFrom(mark, start, p.prevEnd, ";")
+			return
+		}
+	} else {
+		if p.isP(":") {
+			p.eraseType(p.parseType)
+		}
+		if p.isP("=") {
+			p.next()
+			p.parseAssignment(false)
+		}
+		p.consumeSemicolon()
+	}
+	if eraseWhole {
+		p.eraseFrom(mark, start, p.prevEnd, ";")
+	}
+}
+
+// TypeScript declarations
+
+func (p *tsParser) parseTypeAlias(start int) {
+	mark := len(p.edits)
+	p.expectK("type")
+	p.declareType(p.expectIdent())
+	if p.isP("<") {
+		p.next()
+		p.parseTypeParamsRest()
+	}
+	p.expectP("=")
+	p.parseType()
+	p.consumeSemicolon()
+	p.eraseFrom(mark, start, p.prevEnd, ";")
+}
+
+func (p *tsParser) parseInterface(start int) {
+	mark := len(p.edits)
+	p.expectK("interface")
+	p.declareType(p.expectIdent())
+	if p.isP("<") {
+		p.next()
+		p.parseTypeParamsRest()
+	}
+	if p.isK("extends") {
+		p.next()
+		p.parseType()
+		for p.isP(",") {
+			p.next()
+			p.parseType()
+		}
+	}
+	p.skipBalanced()
+	p.eraseFrom(mark, start, p.prevEnd, ";")
+}
+
+// parseEnum replaces an enum with the equivalent JavaScript object
+func (p *tsParser) parseEnum(start int, declared bool) {
+	mark := len(p.edits)
+	p.expectK("enum")
+	name := p.expectIdent()
+	p.declareValue(name)
+	p.expectP("{")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "var %s; (function (%s) {", name, name)
+	prevKey := ""
+	for !p.isP("}") {
+		var key string
+		switch p.tok.kind {
+		case tsIdent:
+			key = strconv.Quote(p.tok.value)
+		case tsString:
+			key = p.s.src[p.tok.start:p.tok.end]
+		default:
+			p.unexpected()
+		}
+		member := p.tok
+		p.next()
+
+		var value string
+		if p.isP("=") {
+			p.next()
+			valueStart := p.tok.start
+			p.parseAssignment(false)
+			value = "(" + p.render(valueStart, p.prevEnd) + ")"
+		} else if prevKey == "" {
+			value = "0"
+		} else {
+			value = fmt.Sprintf("%s[%s] + 1", name, prevKey)
+		}
+		if member.kind == tsIdent {
+			fmt.Fprintf(&b, " var %s = %s[%s] = %s;", member.value, name, key, value)
+		} else {
+			fmt.Fprintf(&b, " %s[%s] = %s;", name, key, value)
+		}
+		fmt.Fprintf(&b, " if (typeof %s[%s] !== \"string\") %s[%s[%s]] = %s;", name, key, name, name, key, key)
+		prevKey = key
+
+		if !p.isP("}") {
+			p.expectP(",")
+		}
+	}
+	p.next()
+	fmt.Fprintf(&b, " })(%s || (%s = {}));", name, name)
+
+	if declared {
+		p.eraseFrom(mark, start, p.prevEnd, ";")
+		return
+	}
+	p.eraseFrom(mark, start, p.prevEnd, b.String())
+}
+
+// parseDeclare erases an ambient declaration
+func (p *tsParser) parseDeclare(start int) {
+	mark := len(p.edits)
+	depth := p.depth
+	p.depth++ // the declared names don't exist at runtime
+	defer func() { p.depth = depth }()
+
+	p.expectK("declare")
+	switch {
+	case p.isK("const") && p.peek().value == "enum":
+		p.next()
+		p.parseEnum(start, true)
+	case p.isK("var") || p.isK("let") || p.isK("const"):
+		p.parseVarDeclarations(false)
+		p.consumeSemicolon()
+	case p.isK("function") || p.isK("async"):
+		p.parseFunctionDeclaration(start)
+	case p.isK("abstract"):
+		p.next()
+		p.parseClass(start, true)
+	case p.isK("class"):
+		p.parseClass(start, true)
+	case p.isK("enum"):
+		p.parseEnum(start, true)
+	case p.isK("type"):
+		p.parseTypeAlias(start)
+	case p.isK("interface"):
+		p.parseInterface(start)
+	case p.isK("namespace") || p.isK("module") || p.isK("global"):
+		p.next()
+		for !p.isP("{") && !p.isP(";") && !(p.tok.newlineBefore && p.prevEnd > start) {
+			if p.tok.kind == tsEOF {
+				p.unexpected()
+			}
+			p.next()
+		}
+		if p.isP("{") {
+			p.skipBalanced()
+		} else {
+			p.consumeSemicolon()
+		}
+	default:
+		p.unexpected()
+	}
+	p.eraseFrom(mark, start, p.prevEnd, ";")
+}
+
+// Imports and exports
+
+func (p *tsParser) parseImport() {
+	start := p.tok.start
+	mark := len(p.edits)
+	importKeyword := p.tok
+	p.expectK("import")
+	if p.tok.kind == tsString {
+		p.next()
+		p.parseImportAttributes()
+		p.consumeSemicolon()
+		return
+	}
+
+	typeOnly := false
+	if p.isK("type") {
+		next := p.peek()
+		switch {
+		case next.kind == tsPunct && (next.value == "{" || next.value == "*"):
+			typeOnly = true
+		case next.kind == tsIdent && next.value != "from":
+			typeOnly = true
+		case next.kind == tsIdent && next.value == "from":
+			// `import type from "x"` imports the default export as type
+			typeOnly = p.peek2().kind == tsIdent
+		}
+		if typeOnly {
+			p.next()
+		}
+	}
+
+	if p.tok.kind == tsIdent && !p.isK("from") || p.isK("from") && p.peek().kind == tsIdent {
+		p.declareValue(p.tok.value)
+		p.next()
+		if p.isP("=") {
+			// import x = require("y"), which is the same as a variable declaration
+			p.next()
+			p.parseAssignment(false)
+			p.consumeSemicolon()
+			if typeOnly {
+				p.eraseFrom(mark, start, p.prevEnd, ";")
+			} else {
+				p.erase(importKeyword.start, importKeyword.end, "var")
+			}
+			return
+		}
+		if p.isP(",") {
+			p.next()
+		}
+	}
+	if p.isP("*") {
+		p.next()
+		p.expectK("as")
+		p.declareValue(p.expectIdent())
+	} else if p.isP("{") {
+		p.parseModuleSpecifiers(false, true)
+	}
+	p.expectK("from")
+	if p.tok.kind != tsString {
+		p.unexpected()
+	}
+	p.next()
+	p.parseImportAttributes()
+	p.consumeSemicolon()
+	if typeOnly {
+		p.eraseFrom(mark, start, p.prevEnd, ";")
+	}
+}
+
+func (p *tsParser) parseImportAttributes() {
+	if (p.isK("assert") || p.isK("with")) && !p.tok.newlineBefore && p.peek().value == "{" {
+		p.next()
+		p.skipBalanced()
+	}
+}
+
+// parseModuleSpecifiers parses the braced list of an import or export, and
+// erases the specifiers with a type modifier
+func (p *tsParser) parseModuleSpecifiers(isExport, isImport bool) (specifiers []tsExportSpecifier) {
+	p.expectP("{")
+	for !p.isP("}") {
+		start := p.tok.start
+		isType := false
+		if p.isK("type") {
+			next := p.peek()
+			isType = next.kind == tsIdent && (next.value != "as" || p.peek2().kind == tsIdent) ||
+				next.kind == tsString
+			if isType {
+				p.next()
+			}
+		}
+		local := p.tok.value
+		if p.tok.kind != tsIdent && p.tok.kind != tsString {
+			p.unexpected()
+		}
+		p.next()
+		if p.isK("as") {
+			p.next()
+			if isImport {
+				local = p.tok.value
+			}
+			if p.tok.kind != tsIdent && p.tok.kind != tsString {
+				p.unexpected()
+			}
+			p.next()
+		}
+		end := p.prevEnd
+		if !p.isP("}") {
+			p.expectP(",")
+			end = p.prevEnd
+		}
+		switch {
+		case isType:
+			p.erase(start, end, "")
+		case isImport:
+			p.declareValue(local)
+		case isExport:
+			specifiers = append(specifiers, tsExportSpecifier{name: local, start: start, end: end})
+		}
+	}
+	p.next()
+	return specifiers
+}
+
+//nolint:funlen,gocyclo,cyclop
+func (p *tsParser) parseExport() {
+	start := p.tok.start
+	mark := len(p.edits)
+	p.expectK("export")
+	next := p.peek()
+	switch {
+	case p.isP("="):
+		p.erase(start, p.tok.end, "module.exports =")
+		p.next()
+		p.parseAssignment(false)
+		p.consumeSemicolon()
+	case p.isK("as") && next.kind == tsIdent && next.value == "namespace":
+		p.next()
+		p.next()
+		p.expectIdent()
+		p.consumeSemicolon()
+		p.eraseFrom(mark, start, p.prevEnd, ";")
+	case p.isK("default"):
+		p.next()
+		next = p.peek()
+		switch {
+		case p.isK("interface") && next.kind == tsIdent && !next.newlineBefore:
+			p.parseInterface(start)
+		case p.isK("abstract") && next.kind == tsIdent && next.value == "class":
+			p.erase(p.tok.start, p.tok.end, "")
+			p.next()
+			p.parseClass(start, true)
+		case p.isK("class"):
+			p.parseClass(start, true)
+		case p.isK("function") || p.isK("async") && next.kind == tsIdent && next.value == "function":
+			p.parseFunctionDeclaration(start)
+		default:
+			p.parseAssignment(false)
+			p.consumeSemicolon()
+		}
+	case p.isK("type") && next.kind == tsPunct && (next.value == "{" || next.value == "*"):
+		p.next()
+		p.skipExportClause()
+		p.eraseFrom(mark, start, p.prevEnd, ";")
+	case p.isP("{") || p.isP("*"):
+		p.skipExportClause()
+	case p.isK("import"):
+		// export import x = y.z
+		p.erase(p.tok.start, p.tok.end, "var")
+		p.next()
+		p.declareValue(p.expectIdent())
+		p.expectP("=")
+		p.parseAssignment(false)
+		p.consumeSemicolon()
+	default:
+		p.parseExportedDeclaration(start)
+	}
+}
+
+// skipExportClause parses the rest of export {...} [from "x"] or
+// export * [as x] from "x"
+func (p *tsParser) skipExportClause() {
+	var specifiers []tsExportSpecifier
+	if p.isP("*") {
+		p.next()
+		if p.isK("as") {
+			p.next()
+			p.next()
+		}
+	} else {
+		specifiers = p.parseModuleSpecifiers(true, false)
+	}
+	if p.isK("from") {
+		p.next()
+		if p.tok.kind != tsString {
+			p.unexpected()
+		}
+		p.next()
+		p.parseImportAttributes()
+	} else {
+		p.exportSpecifiers = append(p.exportSpecifiers, specifiers...)
+	}
+	p.consumeSemicolon()
+}
+
+func (p *tsParser) parseExportedDeclaration(start int) {
+	next := p.peek()
+	switch {
+	case p.isK("var") || p.isK("let") || p.isK("const") && next.value != "enum":
+		p.parseVarDeclarations(false)
+		p.consumeSemicolon()
+	case p.isK("const"):
+		// the export keyword is kept in front of the generated variable
+		enumStart := p.tok.start
+		p.next()
+		p.parseEnum(enumStart, false)
+	case p.isK("function") || p.isK("async"):
+		p.parseFunctionDeclaration(start)
+	case p.isK("class"):
+		p.parseClass(start, true)
+	case p.isK("abstract"):
+		p.erase(p.tok.start, p.tok.end, "")
+		p.next()
+		p.parseClass(start, true)
+	case p.isK("type"):
+		p.parseTypeAlias(start)
+	case p.isK("interface"):
+		p.parseInterface(start)
+	case p.isK("enum"):
+		p.parseEnum(p.tok.start, false)
+	case p.isK("declare"):
+		p.parseDeclare(start)
+	case p.isK("namespace") || p.isK("module"):
+		p.fail("TypeScript namespaces are not supported, use modules instead")
+	default:
+		p.unexpected()
+	}
+}
+
+// Expressions
+
+func (p *tsParser) parseExpression(noIn bool) {
+	p.parseAssignment(noIn)
+	for p.isP(",") {
+		p.next()
+		p.parseAssignment(noIn)
+	}
+}
+
+//nolint:gochecknoglobals
+var tsAssignmentOperators = map[string]bool{
+	"=": true, "+=": true, "-=": true, "*=": true, "/=": true, "%=": true, "**=": true, "<<=": true,
+	">>=": true, ">>>=": true, "&=": true, "|=": true, "^=": true, "&&=": true, "||=": true, "??=": true,
+}
+
+func (p *tsParser) parseAssignment(noIn bool) {
+	if p.tryArrowFunction(noIn) {
+		return
+	}
+	p.parseConditional(noIn)
+	if p.tok.kind == tsPunct && tsAssignmentOperators[p.tok.value] {
+		p.next()
+		p.parseAssignment(noIn)
+	}
+}
+
+// tryArrowFunction parses an arrow function, if there is one
+func (p *tsParser) tryArrowFunction(noIn bool) bool {
+	if p.tok.kind == tsIdent {
+		next := p.peek()
+		if next.kind == tsPunct && next.value == "=>" && !next.newlineBefore {
+			p.next()
+			p.next()
+			p.parseArrowBody(noIn)
+			return true
+		}
+		if !p.isK("async") || next.newlineBefore {
+			return false
+		}
+		if next.kind == tsIdent {
+			if after := p.peek2(); after.kind == tsPunct && after.value == "=>" {
+				p.next()
+				p.next()
+				p.next()
+				p.parseArrowBody(noIn)
+				return true
+			}
+			return false
+		}
+		if next.kind != tsPunct || next.value != "(" && next.value != "<" {
+			return false
+		}
+	} else if !p.isP("(") && !p.isP("<") {
+		return false
+	}
+
+	hasReturnType := false
+	head := func() {
+		if p.isK("async") {
+			p.next()
+		}
+		if p.isP("<") {
+			p.eraseType(p.parseTypeParamsRest)
+		}
+		p.parseParams()
+		if p.isP(":") {
+			hasReturnType = true
+			inConditional := p.inConditional
+			p.inConditional = false
+			p.eraseType(p.parseReturnType)
+			p.inConditional = inConditional
+		}
+		if !p.isP("=>") || p.tok.newlineBefore {
+			p.unexpected()
+		}
+		p.next()
+	}
+	if !p.inConditional {
+		if !p.try(head) {
+			return false
+		}
+		p.parseArrowBody(noIn)
+		return true
+	}
+
+	// In the true branch of a conditional expression, `a ? (b) : c => d`
+	// isn't an arrow function with a return type, unless there is another
+	// colon for the conditional after it.
+	return p.try(func() {
+		head()
+		p.parseArrowBody(noIn)
+		if hasReturnType && !p.isP(":") {
+			p.unexpected()
+		}
+	})
+}
+
+func (p *tsParser) parseArrowBody(noIn bool) {
+	if p.isP("{") {
+		p.parseFunctionBody()
+		return
+	}
+	p.parseAssignment(noIn)
+}
+
+func (p *tsParser) parseConditional(noIn bool) {
+	p.parseBinary(noIn)
+	if !p.isP("?") {
+		return
+	}
+	p.next()
+	inConditional := p.inConditional
+	p.inConditional = true
+	p.parseAssignment(false)
+	p.inConditional = inConditional
+	p.expectP(":")
+	p.parseAssignment(noIn)
+}
+
+//nolint:gochecknoglobals
+var tsBinaryOperators = map[string]bool{
+	"+": true, "-": true, "*": true, "/": true, "%": true, "**": true, "<<": true, ">>": true, ">>>": true,
+	"<": true, ">": true, "<=": true, ">=": true, "==": true, "!=": true, "===": true, "!==": true,
+	"&": true, "^": true, "|": true, "&&": true, "||": true, "??": true,
+}
+
+func (p *tsParser) parseBinary(noIn bool) {
+	p.parseUnary()
+	for {
+		switch {
+		case p.tok.kind == tsPunct && tsBinaryOperators[p.tok.value]:
+			p.next()
+			p.parseUnary()
+		case p.isK("instanceof") || p.isK("in") && !noIn:
+			p.next()
+			p.parseUnary()
+		case (p.isK("as") || p.isK("satisfies")) && !p.tok.newlineBefore:
+			p.eraseType(p.parseType)
+		default:
+			return
+		}
+	}
+}
+
+func (p *tsParser) parseUnary() {
+	switch {
+	case p.tok.kind == tsPunct:
+		switch p.tok.value {
+		case "!", "~", "+", "-", "++", "--":
+			p.next()
+			p.parseUnary()
+			return
+		case "<":
+			// a type assertion, e.g. <T>x
+			p.eraseType(p.parseTypeArgsRest)
+			p.parseUnary()
+			return
+		}
+	case p.isK("typeof") || p.isK("void") || p.isK("delete") || p.isK("await"):
+		if next := p.peek(); p.startsExpression(next) {
+			p.next()
+			p.parseUnary()
+			return
+		}
+	case p.isK("yield"):
+		p.next()
+		if p.isP("*") {
+			p.next()
+		}
+		if !p.tok.newlineBefore && p.startsExpression(p.tok) {
+			p.parseAssignment(false)
+		}
+		return
+	}
+	p.parseLeftHandSide()
+	if (p.isP("++") || p.isP("--")) && !p.tok.newlineBefore {
+		p.next()
+	}
+}
+
+// startsExpression returns whether the given token can be the start of an
+// expression
+func (p *tsParser) startsExpression(tok tsToken) bool {
+	switch tok.kind {
+	case tsEOF:
+		return false
+	case tsPunct:
+		switch tok.value {
+		case "(", "[", "{", "!", "~", "+", "-", "++", "--", "/", "/=", "<", "...":
+			return true
+		}
+		return false
+	case tsIdent:
+		switch tok.value {
+		case "in", "of", "instanceof", "as", "satisfies":
+			return false
+		}
+	}
+	return true
+}
+
+func (p *tsParser) parseLeftHandSide() {
+	if p.isK("new") {
+		p.parseNew()
+	} else {
+		p.parsePrimary()
+	}
+	p.parseCallTail(true)
+}
+
+func (p *tsParser) parseNew() {
+	p.expectK("new")
+	if p.isP(".") {
+		p.next()
+		p.expectIdent()
+		return
+	}
+	if p.isK("new") {
+		p.parseNew()
+	} else {
+		p.parsePrimary()
+	}
+	p.parseCallTail(false)
+	if p.isP("<") {
+		p.eraseType(p.parseTypeArgsRest)
+	}
+	if p.isP("(") {
+		p.parseArguments()
+	}
+}
+
+//nolint:cyclop
+func (p *tsParser) parseCallTail(allowCall bool) {
+	for {
+		switch {
+		case p.isP("."):
+			p.next()
+			if p.tok.kind != tsIdent && p.tok.kind != tsPrivateName {
+				p.unexpected()
+			}
+			p.next()
+		case p.isP("?."):
+			p.next()
+			switch {
+			case p.isP("("):
+				p.parseArguments()
+			case p.isP("["):
+				p.parseComputedMember()
+			default:
+				if p.tok.kind != tsIdent && p.tok.kind != tsPrivateName {
+					p.unexpected()
+				}
+				p.next()
+			}
+		case p.isP("["):
+			p.parseComputedMember()
+		case p.isP("(") && allowCall:
+			p.parseArguments()
+		case p.tok.kind == tsTemplate:
+			p.parseTemplate()
+		case p.isP("!") && !p.tok.newlineBefore:
+			// a non-null assertion
+			p.erase(p.tok.start, p.tok.end, "")
+			p.next()
+		case p.isP("<") && allowCall:
+			// type arguments of a call, e.g. f<T>(x)
+			if !p.try(func() {
+				p.eraseType(p.parseTypeArgsRest)
+				if !p.isP("(") && p.tok.kind != tsTemplate {
+					p.unexpected()
+				}
+			}) {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *tsParser) parseComputedMember() {
+	p.expectP("[")
+	inConditional := p.inConditional
+	p.inConditional = false
+	p.parseExpression(false)
+	p.inConditional = inConditional
+	p.expectP("]")
+}
+
+func (p *tsParser) parseArguments() {
+	p.expectP("(")
+	inConditional := p.inConditional
+	p.inConditional = false
+	for !p.isP(")") {
+		if p.isP("...") {
+			p.next()
+		}
+		p.parseAssignment(false)
+		if !p.isP(")") {
+			p.expectP(",")
+		}
+	}
+	p.inConditional = inConditional
+	p.next()
+}
+
+//nolint:cyclop
+func (p *tsParser) parsePrimary() {
+	switch p.tok.kind {
+	case tsIdent:
+		switch {
+		case p.isK("function"), p.isK("async") && p.peek().value == "function" && !p.peek().newlineBefore:
+			if p.isK("async") {
+				p.next()
+			}
+			p.next()
+			if p.isP("*") {
+				p.next()
+			}
+			if p.tok.kind == tsIdent {
+				p.next()
+			}
+			if !p.parseFunctionRest(false, false) {
+				p.unexpected()
+			}
+		case p.isK("class"):
+			p.parseClass(p.tok.start, false)
+		default:
+			p.next()
+		}
+	case tsNumber, tsString, tsPrivateName, tsRegExp:
+		p.next()
+	case tsTemplate:
+		p.parseTemplate()
+	case tsPunct:
+		switch p.tok.value {
+		case "(":
+			p.parseParenthesized()
+		case "[":
+			p.parseArrayLiteral()
+		case "{":
+			p.parseObjectLiteral()
+		case "/", "/=":
+			p.tok = p.s.scanRegExp(p.tok)
+			p.next()
+		case "@":
+			p.fail("decorators are not supported")
+		default:
+			p.unexpected()
+		}
+	default:
+		p.unexpected()
+	}
+}
+
+func (p *tsParser) parseTemplate() {
+	inConditional := p.inConditional
+	p.inConditional = false
+	for !p.tok.templateTail {
+		p.next()
+		p.parseExpression(false)
+		if !p.isP("}") {
+			p.unexpected()
+		}
+		p.tok = p.s.scanTemplate(p.tok.start+1, tsToken{start: p.tok.start})
+	}
+	p.inConditional = inConditional
+	p.next()
+}
+
+func (p *tsParser) parseArrayLiteral() {
+	p.expectP("[")
+	inConditional := p.inConditional
+	p.inConditional = false
+	for !p.isP("]") {
+		if p.isP(",") {
+			p.next()
+			continue
+		}
+		if p.isP("...") {
+			p.next()
+		}
+		p.parseAssignment(false)
+		if !p.isP("]") {
+			p.expectP(",")
+		}
+	}
+	p.inConditional = inConditional
+	p.next()
+}
+
+func (p *tsParser) parseObjectLiteral() {
+	p.expectP("{")
+	inConditional := p.inConditional
+	p.inConditional = false
+	for !p.isP("}") {
+		if p.isP("...") {
+			p.next()
+			p.parseAssignment(false)
+		} else {
+			p.parseObjectProperty()
+		}
+		if !p.isP("}") {
+			p.expectP(",")
+		}
+	}
+	p.inConditional = inConditional
+	p.next()
+}
+
+func (p *tsParser) parseObjectProperty() {
+	isMethod := false
+	for (p.isK("async") || p.isK("get") || p.isK("set")) && p.isModifier() {
+		isMethod = true
+		p.next()
+	}
+	if p.isP("*") {
+		isMethod = true
+		p.next()
+	}
+	p.parsePropertyName()
+	switch {
+	case p.isP("(") || p.isP("<"):
+		if !p.parseFunctionRest(false, false) {
+			p.unexpected()
+		}
+	case isMethod:
+		p.unexpected()
+	case p.isP(":") || p.isP("="):
+		// = is only valid in destructuring assignment patterns
+		p.next()
+		p.parseAssignment(false)
+	}
+}
+
+// Types, which are only parsed to find where they end
+
+func (p *tsParser) parseType() {
+	p.parseUnionType()
+	if p.isK("extends") && !p.tok.newlineBefore {
+		// a conditional type
+		p.next()
+		p.parseUnionType()
+		p.expectP("?")
+		p.parseType()
+		p.expectP(":")
+		p.parseType()
+	}
+}
+
+func (p *tsParser) parseReturnType() {
+	next := p.peek()
+	switch {
+	case p.isK("asserts") && (next.kind == tsIdent) && !next.newlineBefore:
+		p.next()
+		p.next()
+		if p.isK("is") && !p.tok.newlineBefore {
+			p.next()
+			p.parseType()
+		}
+	case p.tok.kind == tsIdent && next.kind == tsIdent && next.value == "is" && !next.newlineBefore:
+		p.next()
+		p.next()
+		p.parseType()
+	default:
+		p.parseType()
+	}
+}
+
+func (p *tsParser) parseUnionType() {
+	if p.isP("|") {
+		p.next()
+	}
+	p.parseIntersectionType()
+	for p.isP("|") {
+		p.next()
+		p.parseIntersectionType()
+	}
+}
+
+func (p *tsParser) parseIntersectionType() {
+	if p.isP("&") {
+		p.next()
+	}
+	p.parseTypeOperator()
+	for p.isP("&") {
+		p.next()
+		p.parseTypeOperator()
+	}
+}
+
+func (p *tsParser) parseTypeOperator() {
+	if p.isK("keyof") || p.isK("unique") || p.isK("readonly") {
+		if next := p.peek(); next.kind != tsPunct || next.value == "(" || next.value == "[" || next.value == "{" {
+			p.next()
+			p.parseTypeOperator()
+			return
+		}
+	}
+	if p.isK("infer") && p.peek().kind == tsIdent {
+		p.next()
+		p.next()
+		return
+	}
+	p.parsePrimaryType()
+	for p.isP("[") && !p.tok.newlineBefore {
+		p.skipBalanced()
+	}
+}
+
+//nolint:cyclop
+func (p *tsParser) parsePrimaryType() {
+	switch p.tok.kind {
+	case tsString, tsNumber:
+		p.next()
+	case tsTemplate:
+		p.parseTemplateType()
+	case tsIdent:
+		switch {
+		case p.isK("typeof"):
+			p.next()
+			if p.isK("import") {
+				p.parseImportType()
+				return
+			}
+			p.parseTypeReference()
+		case p.isK("import"):
+			p.parseImportType()
+		case p.isK("new") || p.isK("abstract") && p.peek().value == "new":
+			if p.isK("abstract") {
+				p.next()
+			}
+			p.next()
+			p.parseFunctionType()
+		default:
+			p.parseTypeReference()
+		}
+	case tsPunct:
+		switch p.tok.value {
+		case "(":
+			if p.try(p.parseFunctionType) {
+				return
+			}
+			p.skipBalanced()
+		case "<":
+			p.parseFunctionType()
+		case "{", "[":
+			p.skipBalanced()
+		case "-":
+			p.next()
+			if p.tok.kind != tsNumber {
+				p.unexpected()
+			}
+			p.next()
+		default:
+			p.unexpected()
+		}
+	default:
+		p.unexpected()
+	}
+}
+
+// parseTypeReference parses a possibly qualified type name with type arguments
+func (p *tsParser) parseTypeReference() {
+	p.expectIdent()
+	for p.isP(".") {
+		p.next()
+		p.expectIdent()
+	}
+	if p.isP("<") && !p.tok.newlineBefore {
+		p.next()
+		p.parseTypeArgsRest()
+	}
+}
+
+func (p *tsParser) parseImportType() {
+	p.expectK("import")
+	p.skipBalanced()
+	for p.isP(".") {
+		p.next()
+		p.expectIdent()
+	}
+	if p.isP("<") {
+		p.next()
+		p.parseTypeArgsRest()
+	}
+}
+
+// parseFunctionType parses the type of a function or a constructor, e.g.
+// <T>(a: T) => T
+func (p *tsParser) parseFunctionType() {
+	if p.isP("<") {
+		p.next()
+		p.parseTypeParamsRest()
+	}
+	if !p.isP("(") {
+		p.unexpected()
+	}
+	p.skipBalanced()
+	p.expectP("=>")
+	p.parseReturnType()
+}
+
+func (p *tsParser) parseTemplateType() {
+	for !p.tok.templateTail {
+		p.next()
+		p.parseType()
+		if !p.isP("}") {
+			p.unexpected()
+		}
+		p.tok = p.s.scanTemplate(p.tok.start+1, tsToken{start: p.tok.start})
+	}
+	p.next()
+}
+
+// parseTypeArgsRest parses type arguments after the opening angle bracket
+func (p *tsParser) parseTypeArgsRest() {
+	for {
+		p.parseType()
+		if !p.isP(",") {
+			break
+		}
+		p.next()
+	}
+	p.expectGreater()
+}
+
+// parseTypeParamsRest parses type parameters after the opening angle bracket
+func (p *tsParser) parseTypeParamsRest() {
+	for {
+		for (p.isK("const") || p.isK("in") || p.isK("out")) && p.peek().kind == tsIdent {
+			p.next()
+		}
+		p.expectIdent()
+		if p.isK("extends") {
+			p.next()
+			p.parseType()
+		}
+		if p.isP("=") {
+			p.next()
+			p.parseType()
+		}
+		if !p.isP(",") {
+			break
+		}
+		p.next()
+		if p.tok.kind == tsPunct && strings.HasPrefix(p.tok.value, ">") {
+			break
+		}
+	}
+	p.expectGreater()
+}
+
+// skipBalanced skips everything between the current opening bracket and the
+// matching closing one, e.g. object and tuple types
+func (p *tsParser) skipBalanced() {
+	closers := map[string]string{"(": ")", "[": "]", "{": "}"}
+	var stack []string
+	for {
+		switch p.tok.kind {
+		case tsEOF:
+			p.unexpected()
+		case tsPunct:
+			if closer, ok := closers[p.tok.value]; ok {
+				stack = append(stack, closer)
+			} else if len(stack) > 0 && p.tok.value == stack[len(stack)-1] {
+				stack = stack[:len(stack)-1]
+				if p.tok.value == "}" && len(stack) > 0 && stack[len(stack)-1] == "${" {
+					// the end of a template substitution
+					stack = stack[:len(stack)-1]
+					p.tok = p.s.scanTemplate(p.tok.start+1, tsToken{start: p.tok.start})
+					if !p.tok.templateTail {
+						stack = append(stack, "${", "}")
+					}
+				}
+			} else if p.isP(")") || p.isP("]") || p.isP("}") {
+				p.unexpected()
+			}
+		case tsTemplate:
+			if !p.tok.templateTail {
+				stack = append(stack, "${", "}")
+			}
+		}
+		if len(stack) == 0 {
+			p.next()
+			return
+		}
+		p.next()
+	}
+}
@@ -0,0 +1,300 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package compiler
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tsTokenKind int
+
+const (
+	tsEOF tsTokenKind = iota
+	tsIdent
+	tsPrivateName
+	tsNumber
+	tsString
+	tsTemplate
+	tsRegExp
+	tsPunct
+)
+
+// tsToken is a single token of a TypeScript source
+type tsToken struct {
+	kind       tsTokenKind
+	value      string // the name of identifiers and the text of punctuators
+	start, end int
+	// whether there is a line terminator between this and the previous token
+	newlineBefore bool
+	// whether a template token ends with a backtick, and not with a substitution
+	templateTail bool
+}
+
+// tsSyntaxError is used for panicking inside of the TypeScript parser
+type tsSyntaxError struct {
+	pos int
+	msg string
+}
+
+// the punctuators, longest first for every starting character
+//
+//nolint:gochecknoglobals
+var tsPunctuators = []string{
+	">>>=", "...", "===", "!==", "**=", "<<=", ">>=", ">>>", "&&=", "||=", "??=",
+	"=>", "==", "!=", "<=", ">=", "&&", "||", "??", "?.", "++", "--", "+=", "-=", "*=", "/=", "%=",
+	"&=", "|=", "^=", "<<", ">>", "**",
+	"{", "}", "(", ")", "[", "]", ";", ",", "<", ">", "+", "-", "*", "/", "%", "&", "|", "^",
+	"!", "~", "?", ":", "=", ".", "@",
+}
+
+// tsScanner splits a TypeScript source into tokens. Regular expressions and
+// template continuations depend on the syntactic context, so they are
+// scanned on demand by the parser.
+type tsScanner struct {
+	src string
+	pos int
+}
+
+func (s *tsScanner) fail(pos int, format string, args ...interface{}) {
+	panic(&tsSyntaxError{pos: pos, msg: fmt.Sprintf(format, args...)})
+}
+
+// skipTrivia skips any whitespace and comments, and returns whether there
+// were any line terminators in them
+func (s *tsScanner) skipTrivia() (newline bool) {
+	if s.pos == 0 && strings.HasPrefix(s.src, "#!") {
+		for s.pos < len(s.src) && s.src[s.pos] != '\n' {
+			s.pos++
+		}
+	}
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case c == '\n' || c == '\r':
+			newline = true
+			s.pos++
+		case c == ' ' || c == '\t' || c == '\v' || c == '\f':
+			s.pos++
+		case c == '/' && strings.HasPrefix(s.src[s.pos:], "//"):
+			for s.pos < len(s.src) && s.src[s.pos] != '\n' && s.src[s.pos] != '\r' {
+				s.pos++
+			}
+		case c == '/' && strings.HasPrefix(s.src[s.pos:], "/*"):
+			end := strings.Index(s.src[s.pos+2:], "*/")
+			if end < 0 {
+				s.fail(s.pos, "unterminated comment")
+			}
+			comment := s.src[s.pos : s.pos+2+end+2]
+			if strings.ContainsAny(comment, "\n\r\u2028\u2029") {
+				newline = true
+			}
+			s.pos += len(comment)
+		case c >= utf8.RuneSelf:
+			r, size := utf8.DecodeRuneInString(s.src[s.pos:])
+			switch {
+			case r == '\u2028' || r == '\u2029':
+				newline = true
+			case r == '\ufeff' || unicode.IsSpace(r):
+			default:
+				return newline
+			}
+			s.pos += size
+		default:
+			return newline
+		}
+	}
+	return newline
+}
+
+func isTSIdentStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '$' || c == '_' || c == '\\' || c >= utf8.RuneSelf
+}
+
+func isTSIdentPart(c byte) bool {
+	return isTSIdentStart(c) || c >= '0' && c <= '9'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// scan returns the next token
+func (s *tsScanner) scan() tsToken {
+	newline := s.skipTrivia()
+	tok := tsToken{start: s.pos, newlineBefore: newline}
+	if s.pos >= len(s.src) {
+		tok.kind = tsEOF
+		tok.end = s.pos
+		return tok
+	}
+
+	c := s.src[s.pos]
+	switch {
+	case isTSIdentStart(c):
+		tok.kind = tsIdent
+		s.scanIdent()
+		tok.value = s.src[tok.start:s.pos]
+	case c == '#' && s.pos+1 < len(s.src) && isTSIdentStart(s.src[s.pos+1]):
+		tok.kind = tsPrivateName
+		s.pos++
+		s.scanIdent()
+		tok.value = s.src[tok.start:s.pos]
+	case isDigit(c) || c == '.' && s.pos+1 < len(s.src) && isDigit(s.src[s.pos+1]):
+		tok.kind = tsNumber
+		s.scanNumber()
+	case c == '"' || c == '\'':
+		tok.kind = tsString
+		s.scanString(c)
+	case c == '`':
+		return s.scanTemplate(tok.start+1, tok)
+	default:
+		tok.kind = tsPunct
+		for _, p := range tsPunctuators {
+			if strings.HasPrefix(s.src[s.pos:], p) {
+				// ?. followed by a digit is a conditional and a number
+				if p == "?." && s.pos+2 < len(s.src) && isDigit(s.src[s.pos+2]) {
+					continue
+				}
+				tok.value = p
+				break
+			}
+		}
+		if tok.value == "" {
+			s.fail(s.pos, "unexpected character %q", c)
+		}
+		s.pos += len(tok.value)
+	}
+	tok.end = s.pos
+	return tok
+}
+
+func (s *tsScanner) scanIdent() {
+	for s.pos < len(s.src) && isTSIdentPart(s.src[s.pos]) {
+		if s.src[s.pos] >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s.src[s.pos:])
+			if r == '\u2028' || r == '\u2029' || r == '\ufeff' || unicode.IsSpace(r) {
+				return
+			}
+			s.pos += size
+			continue
+		}
+		s.pos++
+	}
+}
+
+func (s *tsScanner) scanNumber() {
+	start := s.pos
+	hex := strings.HasPrefix(s.src[s.pos:], "0x") || strings.HasPrefix(s.src[s.pos:], "0X")
+	seenDot := false
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_':
+		case c == '.' && !seenDot && !hex:
+			seenDot = true
+		case (c == '+' || c == '-') && !hex && s.pos > start && (s.src[s.pos-1] == 'e' || s.src[s.pos-1] == 'E'):
+		default:
+			return
+		}
+		s.pos++
+	}
+}
+
+func (s *tsScanner) scanString(quote byte) {
+	start := s.pos
+	s.pos++
+	for s.pos < len(s.src) {
+		switch s.src[s.pos] {
+		case quote:
+			s.pos++
+			return
+		case '\\':
+			s.pos += 2
+		case '\n', '\r':
+			s.fail(start, "unterminated string literal")
+		default:
+			s.pos++
+		}
+	}
+	s.fail(start, "unterminated string literal")
+}
+
+// scanTemplate scans a part of a template literal, starting after the
+// opening backtick or after the closing brace of a substitution
+func (s *tsScanner) scanTemplate(pos int, tok tsToken) tsToken {
+	tok.kind = tsTemplate
+	s.pos = pos
+	for s.pos < len(s.src) {
+		switch s.src[s.pos] {
+		case '`':
+			s.pos++
+			tok.templateTail = true
+			tok.end = s.pos
+			return tok
+		case '\\':
+			s.pos += 2
+		case '$':
+			if s.pos+1 < len(s.src) && s.src[s.pos+1] == '{' {
+				s.pos += 2
+				tok.end = s.pos
+				return tok
+			}
+			s.pos++
+		default:
+			s.pos++
+		}
+	}
+	s.fail(tok.start, "unterminated template literal")
+	return tok
+}
+
+// scanRegExp scans a regular expression literal that starts at the given
+// position
+func (s *tsScanner) scanRegExp(tok tsToken) tsToken {
+	tok.kind = tsRegExp
+	s.pos = tok.start + 1
+	inClass := false
+	for {
+		if s.pos >= len(s.src) || s.src[s.pos] == '\n' || s.src[s.pos] == '\r' {
+			s.fail(tok.start, "unterminated regular expression")
+		}
+		c := s.src[s.pos]
+		s.pos++
+		if c == '\\' {
+			s.pos++
+		} else if c == '[' {
+			inClass = true
+		} else if c == ']' {
+			inClass = false
+		} else if c == '/' && !inClass {
+			break
+		}
+	}
+	for s.pos < len(s.src) && isTSIdentPart(s.src[s.pos]) {
+		s.pos++
+	}
+	tok.end = s.pos
+	tok.value = ""
+	return tok
+}
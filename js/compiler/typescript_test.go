@@ -0,0 +1,197 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package compiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dop251/goja"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/testutils"
+)
+
+func TestIsTypeScript(t *testing.T) {
+	t.Parallel()
+	assert.True(t, IsTypeScript("script.ts"))
+	assert.True(t, IsTypeScript("file:///home/user/lib/util.ts"))
+	assert.True(t, IsTypeScript("https://example.com/lib.ts?version=1"))
+	assert.False(t, IsTypeScript("script.js"))
+	assert.False(t, IsTypeScript("script.d.tsx"))
+	assert.False(t, IsTypeScript("https://example.com/ts"))
+}
+
+// removeWhitespace removes the whitespace left by the erased types
+func removeWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
+
+func TestStripTypes(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name, src, expected string
+	}{
+		{"plain", `let a = 1; function f(x) { return x * 2 }`, `let a = 1; function f(x) { return x * 2 }`},
+		{"variables", `let a: number = 1, b: string[], c!: Map<string, number>;`, `let a = 1, b, c;`},
+		{"function", `function f<T extends object = {}>(a: T, b?: number, ...c: any[]): T | undefined { return a }`,
+			`function f(a, b, ...c) { return a }`},
+		{"this param", `function f(this: Window, a: number) {}`, `function f( a) {}`},
+		{"overloads", "function f(a: string): string;\nfunction f(a: number): number;\nfunction f(a: any) { return a }",
+			"; ; function f(a) { return a }"},
+		{"arrows", `const f = <T,>(a: T): T => a, g = async (b: number): Promise<void> => {}, h = (c) => c;`,
+			`const f = (a) => a, g = async (b) => {}, h = (c) => c;`},
+		{"conditional", `let x = a ? (b) : c => d; let y = a ? (b): number => b : c;`,
+			`let x = a ? (b) : c => d; let y = a ? (b) => b : c;`},
+		{"assertions", `let a = b as unknown as string, c = <number>d, e = f!.g!, h = i satisfies J;`,
+			`let a = b, c = d, e = f.g, h = i;`},
+		{"generic calls", `let a = f<string>("x"), b = c < d, e = g<T>` + "`x`" + `, h = new Map<string, number>();`,
+			`let a = f("x"), b = c < d, e = g` + "`x`" + `, h = new Map();`},
+		{"comparisons", `if (a < b && c > d) { x = a<b>(c) }`, `if (a < b && c > d) { x = a(c) }`},
+		{"interface", "interface A extends B<C> {\n  a: string;\n  b(x: number): void;\n}\nlet a = 1;",
+			"; let a = 1;"},
+		{"type alias", `type A<T> = { [K in keyof T]?: T[K] extends () => infer R ? R : never } | "x"; let a;`,
+			`; let a;`},
+		{"declare", "declare const __ENV: { [key: string]: string };\ndeclare module \"x\" { export const a: number }\nlet a;",
+			"; ; let a;"},
+		{"imports", `import type { A } from "a"; import { type B, C } from "b"; import D, * as E from "d";`,
+			`; import { C } from "b"; import D, * as E from "d";`},
+		{"import require", `import x = require("x");`, `var x = require("x");`},
+		{"exports", "type A = string; const B = 1; export { A, B }; export type { C } from \"c\"; export = B;",
+			"; const B = 1; export { B }; ; module.exports = B;"},
+		{"regexp and templates", "let a = /<T>(x: y)/g.test(`${b as string}:${`${c}`}`), d = e / f / g;",
+			"let a = /<T>(x: y)/g.test(`${b}:${`${c}`}`), d = e / f / g;"},
+		{"class", `abstract class A<T> extends B<T> implements C, D<T> {
+  private readonly a: string = "a";
+  static b?: number;
+  declare c: string;
+  [key: string]: any;
+  protected abstract d(): void;
+  e(x: number): void;
+  e(x: any) {}
+  get f(): number { return 1 }
+  public async *g<U>(u: U): AsyncGenerator<U> {}
+}`, `class A extends B {
+  ; a = "a";
+  static b;
+  ;
+  ;
+  ;
+  ;
+  e(x) {}
+  get f() { return 1 }
+  ; async *g(u) {}
+}`},
+		{"parameter properties", `class A extends B { constructor(private a: string, public readonly b = 1) { super(); f() } }`,
+			`class A extends B { constructor( a, b = 1) { super(); this.a = a; this.b = b; f() } }`},
+		{"enum", `enum A { X, Y = 5, Z } export const enum B { S = "s" }`,
+			`var A; (function (A) { var X = A["X"] = 0; if (typeof A["X"] !== "string") A[A["X"]] = "X";` +
+				` var Y = A["Y"] = (5); if (typeof A["Y"] !== "string") A[A["Y"]] = "Y";` +
+				` var Z = A["Z"] = A["Y"] + 1; if (typeof A["Z"] !== "string") A[A["Z"]] = "Z"; })(A || (A = {}));` +
+				` export var B; (function (B) { var S = B["S"] = ("s"); if (typeof B["S"] !== "string") B[B["S"]] = "S"; })(B || (B = {}));`},
+		{"catch and for", `try {} catch (e: unknown) {} for (const x of y as number[]) {}`,
+			`try {} catch (e) {} for (const x of y) {}`},
+		{"objects", `let o = { a: 1, b<T>(x: T): T { return x }, get c(): number { return 1 }, ...d };`,
+			`let o = { a: 1, b(x) { return x }, get c() { return 1 }, ...d };`},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			code, err := stripTypes(tc.src, "test.ts")
+			require.NoError(t, err)
+			assert.Equal(t, removeWhitespace(tc.expected), removeWhitespace(code))
+			assert.Equal(t, strings.Count(tc.src, "\n"), strings.Count(code, "\n"))
+		})
+	}
+}
+
+func TestStripTypesPositions(t *testing.T) {
+	t.Parallel()
+	src := "interface A {\n  a: string\n}\nfunction f(a: A,\n  b: number): void {\n  return a.a + b;\n}\n"
+	code, err := stripTypes(src, "test.ts")
+	require.NoError(t, err)
+	assert.Equal(t, len(src), len(code))
+	assert.Equal(t, ";            \n           \n \nfunction f(a   ,\n  b        )       {\n  return a.a + b;\n}\n", code)
+}
+
+func TestStripTypesErrors(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]string{
+		"let a: = 1;":                    "test.ts: unexpected token = (1:8)",
+		"namespace A {}":                 "test.ts: TypeScript namespaces are not supported, use modules instead (1:1)",
+		"let a = 1;\n@dec class A {}":    "test.ts: decorators are not supported (2:1)",
+		"let a = 'unterminated\nlet b;":  "test.ts: unterminated string literal (1:9)",
+		"function f(a: Array<number) {}": "test.ts: unexpected token ) (1:27)",
+	}
+	for src, expected := range testCases {
+		_, err := stripTypes(src, "test.ts")
+		assert.EqualError(t, err, expected, src)
+	}
+}
+
+func TestCompileTypeScript(t *testing.T) {
+	t.Parallel()
+	c := New(testutils.NewLogger(t))
+	src := `
+interface Point { x: number; y: number }
+enum Direction { Up = 1, Down }
+class Vector implements Point {
+	static zero: Vector = new Vector(0, 0);
+	private readonly length: number;
+	constructor(public x: number, public y: number) {
+		this.length = Math.sqrt(x * x + y * y);
+	}
+	norm(): number { return this.length; }
+}
+function sum<T extends Point>(...points: T[]): Point {
+	return points.reduce((acc: Point, p: T): Point => ({ x: acc.x + p.x, y: acc.y + p.y }), Vector.zero);
+}
+const total = sum(new Vector(1, 2), new Vector(2, 2) as Point);
+[total.x, total.y, new Vector(3, 4).norm(), Direction.Down, Direction[2]].join(",");
+`
+	pgm, _, err := c.Compile(src, "script.ts", "", "", true, lib.CompatibilityModeExtended)
+	require.NoError(t, err)
+	v, err := goja.New().RunProgram(pgm)
+	require.NoError(t, err)
+	assert.Equal(t, "3,4,5,2,Down", v.Export())
+
+	t.Run("Exception lines", func(t *testing.T) {
+		t.Parallel()
+		src := "type A = {\n  a: string\n};\nclass B {\n  b: number = 1;\n  c(a: A): void {\n    throw new Error(a.a);\n  }\n}\nnew B().c({ a: 'oops' });\n"
+		pgm, _, err := c.Compile(src, "script.ts", "", "", true, lib.CompatibilityModeExtended)
+		require.NoError(t, err)
+		_, err = goja.New().RunProgram(pgm)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "oops")
+		assert.Contains(t, err.Error(), "script.ts:7:")
+	})
+
+	t.Run("Syntax error", func(t *testing.T) {
+		t.Parallel()
+		_, _, err := c.Compile("let a: number = ;", "script.ts", "", "", true, lib.CompatibilityModeExtended)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "script.ts")
+	})
+}
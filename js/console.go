@@ -32,11 +32,14 @@ import (
 // console represents a JS console implemented as a logrus.Logger.
 type console struct {
 	logger logrus.FieldLogger
+	// mapPositions, if set, maps the file positions in the logged messages,
+	// e.g. in stack traces, back to the original sources
+	mapPositions func(string) string
 }
 
 // Creates a console with the standard logrus logger.
 func newConsole(logger logrus.FieldLogger) *console {
-	return &console{logger.WithField("source", "console")}
+	return &console{logger: logger.WithField("source", "console")}
 }
 
 // Creates a console logger with its output set to the file at the provided `filepath`.
@@ -50,7 +53,7 @@ func newFileConsole(filepath string, formatter logrus.Formatter) (*console, erro
 	l.SetOutput(f)
 	l.SetFormatter(formatter)
 
-	return &console{l}, nil
+	return &console{logger: l}, nil
 }
 
 func (c console) log(ctx *context.Context, level logrus.Level, msgobj goja.Value, args ...goja.Value) {
@@ -72,6 +75,9 @@ func (c console) log(ctx *context.Context, level logrus.Level, msgobj goja.Value
 
 		msg = strings.Join(strs, " ")
 	}
+	if c.mapPositions != nil {
+		msg = c.mapPositions(msg)
+	}
 	switch level { //nolint:exhaustive
 	case logrus.DebugLevel:
 		c.logger.Debug(msg)
@@ -48,7 +48,7 @@ func TestConsoleContext(t *testing.T) {
 
 	ctxPtr := new(context.Context)
 	logger, hook := logtest.NewNullLogger()
-	rt.Set("console", common.Bind(rt, &console{logger}, ctxPtr))
+	rt.Set("console", common.Bind(rt, &console{logger: logger}, ctxPtr))
 
 	_, err := rt.RunString(`console.log("a")`)
 	assert.NoError(t, err)
@@ -36,6 +36,7 @@ import (
 	"github.com/loadimpact/k6/js/compiler"
 	"github.com/loadimpact/k6/js/internal/modules"
 	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/fsext"
 	"github.com/loadimpact/k6/loader"
 )
 
@@ -63,6 +64,10 @@ type InitContext struct {
 	filesystems map[string]afero.Fs
 	pwd         *url.URL
 
+	// Module resolution settings for bare specifiers, see lib.RuntimeOptions
+	importMap   *loader.ImportMap
+	nodeModules bool
+
 	// Cache of loaded programs and files.
 	programs map[string]programWithSource
 
@@ -109,6 +114,8 @@ func newBoundInitContext(base *InitContext, ctxPtr *context.Context, rt *goja.Ru
 		filesystems: base.filesystems,
 		pwd:         base.pwd,
 		compiler:    base.compiler,
+		importMap:   base.importMap,
+		nodeModules: base.nodeModules,
 
 		programs:          programs,
 		compatibilityMode: base.compatibilityMode,
@@ -150,7 +157,7 @@ func (i *InitContext) requireModule(name string) (goja.Value, error) {
 func (i *InitContext) requireFile(name string) (goja.Value, error) {
 	// Resolve the file path, push the target directory as pwd to make relative imports work.
 	pwd := i.pwd
-	fileURL, err := loader.Resolve(pwd, name)
+	fileURL, err := i.resolve(pwd, name)
 	if err != nil {
 		return nil, err
 	}
@@ -198,6 +205,52 @@ func (i *InitContext) requireFile(name string) (goja.Value, error) {
 	return pgm.module.Get("exports"), nil
 }
 
+// resolve returns the URL of the module with the given specifier, imported
+// from a module in the given directory. The import map takes precedence over
+// everything else, and then bare specifiers are looked up in node_modules, if
+// that is enabled.
+func (i *InitContext) resolve(pwd *url.URL, specifier string) (*url.URL, error) {
+	if i.importMap != nil {
+		if u := i.importMap.Resolve(specifier, pwd); u != nil {
+			return u, nil
+		}
+	}
+	if i.nodeModules {
+		u, err := loader.ResolveNodeModule(i.filesystems["file"], pwd, specifier)
+		if err != nil {
+			return nil, err
+		}
+		if u != nil {
+			return u, nil
+		}
+	}
+	u, err := loader.Resolve(pwd, specifier)
+	if err != nil {
+		return nil, err
+	}
+	return i.resolveTypeScript(u), nil
+}
+
+// resolveTypeScript resolves local imports without an extension to TypeScript
+// files, the same way the TypeScript compiler does, if there is no file with
+// the exact name.
+func (i *InitContext) resolveTypeScript(fileURL *url.URL) *url.URL {
+	fs := i.filesystems["file"]
+	if fileURL.Scheme != "file" || fs == nil || filepath.Ext(fileURL.Path) != "" {
+		return fileURL
+	}
+	if ok, _ := afero.Exists(fs, filepath.FromSlash(fileURL.Path)); ok {
+		return fileURL
+	}
+	tsPath := fileURL.Path + ".ts"
+	if ok, _ := afero.Exists(fs, filepath.FromSlash(tsPath)); !ok {
+		return fileURL
+	}
+	tsURL := *fileURL
+	tsURL.Path = tsPath
+	return &tsURL
+}
+
 func (i *InitContext) compileImport(src, filename string) (*goja.Program, error) {
 	pgm, _, err := i.compiler.Compile(src, filename,
 		"(function(module, exports){\n", "\n})\n", true, i.compatibilityMode)
@@ -233,6 +286,16 @@ func (i *InitContext) Open(ctx context.Context, filename string, args ...string)
 	} else if isDir {
 		return nil, fmt.Errorf("open() can't be used with directories, path: %q", filename)
 	}
+	if len(args) > 0 && args[0] == "stream" {
+		// The file contents aren't copied in every VU, they are read from the
+		// filesystem when needed, e.g. when the file is uploaded
+		file, err := fsext.NewStreamedFile(fs, filename)
+		if err != nil {
+			return nil, err
+		}
+		return i.runtime.ToValue(file), nil
+	}
+
 	data, err := afero.ReadFile(fs, filename)
 	if err != nil {
 		return nil, err
@@ -41,6 +41,7 @@ import (
 	"github.com/loadimpact/k6/js/common"
 	"github.com/loadimpact/k6/lib"
 	"github.com/loadimpact/k6/lib/consts"
+	"github.com/loadimpact/k6/lib/fsext"
 	"github.com/loadimpact/k6/lib/netext"
 	"github.com/loadimpact/k6/lib/testutils"
 	"github.com/loadimpact/k6/lib/types"
@@ -327,6 +328,28 @@ func TestInitContextOpen(t *testing.T) {
 		_, err := getSimpleBundle(t, "/script.js", `open("/some/dir"); export default function() {}`, fs)
 		assert.Contains(t, err.Error(), fmt.Sprintf("GoError: open() can't be used with directories, path: %q", path))
 	})
+
+	t.Run("Stream", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/path/to/file.bin", []byte("streamed"), 0o644))
+		b, err := getSimpleBundle(t, "/path/to/script.js", `
+			export let file = open("./file.bin", "stream");
+			if (file.size() != 8) { throw new Error("wrong size: " + file.size()); }
+			if (file.path() != "/path/to/file.bin") { throw new Error("wrong path: " + file.path()); }
+			export default function() {}
+		`, fs)
+		require.NoError(t, err)
+		bi, err := b.Instantiate(testutils.NewLogger(t), 0)
+		require.NoError(t, err)
+		file, ok := bi.Runtime.Get("file").Export().(*fsext.StreamedFile)
+		require.True(t, ok)
+		rc, err := file.Open()
+		require.NoError(t, err)
+		defer func() { _ = rc.Close() }()
+		data, err := ioutil.ReadAll(rc)
+		require.NoError(t, err)
+		assert.Equal(t, "streamed", string(data))
+	})
 }
 
 func TestRequestWithBinaryFile(t *testing.T) {
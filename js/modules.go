@@ -30,5 +30,8 @@ import (
 	_ "github.com/loadimpact/k6/js/modules/k6/grpc"
 	_ "github.com/loadimpact/k6/js/modules/k6/http"
 	_ "github.com/loadimpact/k6/js/modules/k6/metrics"
+	_ "github.com/loadimpact/k6/js/modules/k6/net"
+	_ "github.com/loadimpact/k6/js/modules/k6/oauth2"
+	_ "github.com/loadimpact/k6/js/modules/k6/sse"
 	_ "github.com/loadimpact/k6/js/modules/k6/ws"
 )
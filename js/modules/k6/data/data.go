@@ -56,7 +56,9 @@ func (d *data) XSharedArray(ctx context.Context, name string, call goja.Callable
 
 	name = sharedArrayNamePrefix + name
 	value := initEnv.SharedObjects.GetOrCreateShare(name, func() interface{} {
-		return getShareArrayFromCall(common.GetRuntime(ctx), call)
+		array := getShareArrayFromCall(common.GetRuntime(ctx), call)
+		array.name = name
+		return array
 	})
 	array, ok := value.(sharedArray)
 	if !ok { // TODO more info in the error?
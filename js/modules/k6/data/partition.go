@@ -0,0 +1,157 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/dop251/goja"
+
+	"github.com/loadimpact/k6/js/common"
+	"github.com/loadimpact/k6/lib"
+)
+
+// The different strategies for partitioning a SharedArray.
+const (
+	PartitionUniquePerVU        = "unique-per-vu"
+	PartitionUniquePerIteration = "unique-per-iteration"
+	PartitionRoundRobin         = "round-robin"
+)
+
+const partitionedArrayNamePrefix = "k6/data/PartitionedArray."
+
+// partitionedArray gives every VU items from the part of a SharedArray that
+// belongs to the execution segment of the current k6 instance. Since the
+// segments of all instances in a sequence are disjoint, so are the items they
+// use, even in distributed and other segmented test runs.
+type partitionedArray struct {
+	array            wrappedSharedArray
+	strategy         string
+	throwOnExhausted bool
+	counter          *int64 // shared between all VUs of the instance
+
+	// the item of the current iteration, so get() is consistent within it
+	lastIteration int64
+	lastIndex     int64
+}
+
+// XPartitionedArray is a constructor returning an object that partitions the
+// items of the given SharedArray between k6 instances, VUs and iterations,
+// according to the specified strategy:
+//  - unique-per-vu: every VU always gets the same item, different from the
+//    items of all other VUs.
+//  - unique-per-iteration: every iteration of every VU gets a different item.
+//  - round-robin: like unique-per-iteration, but when all items of the
+//    instance are used up, it starts from the first one again.
+//
+// When a unique strategy runs out of items, get() throws an exception, unless
+// the onExhausted option is set to "undefined".
+func (d *data) XPartitionedArray(
+	ctxPtr *context.Context, array goja.Value, strategy string, options goja.Value,
+) (interface{}, error) {
+	if lib.GetState(*ctxPtr) != nil {
+		return nil, errors.New("new PartitionedArray must be called in the init context")
+	}
+	initEnv := common.GetInitEnv(*ctxPtr)
+	if initEnv == nil {
+		return nil, errors.New("missing init environment")
+	}
+	rt := common.GetRuntime(*ctxPtr)
+
+	var sharedArr wrappedSharedArray
+	if array != nil {
+		sharedArr, _ = array.Export().(wrappedSharedArray)
+	}
+	if sharedArr.rt == nil {
+		return nil, errors.New("only a SharedArray can be partitioned")
+	}
+
+	switch strategy {
+	case PartitionUniquePerVU, PartitionUniquePerIteration, PartitionRoundRobin:
+	default:
+		return nil, fmt.Errorf(
+			"unknown partition strategy '%s', it should be one of %s, %s or %s", strategy,
+			PartitionUniquePerVU, PartitionUniquePerIteration, PartitionRoundRobin,
+		)
+	}
+
+	pa := &partitionedArray{array: sharedArr, strategy: strategy, throwOnExhausted: true, lastIteration: -1}
+	if options != nil && !goja.IsUndefined(options) && !goja.IsNull(options) {
+		switch onExhausted := options.ToObject(rt).Get("onExhausted"); {
+		case onExhausted == nil || goja.IsUndefined(onExhausted) || onExhausted.String() == "throw":
+		case onExhausted.String() == "undefined":
+			pa.throwOnExhausted = false
+		default:
+			return nil, fmt.Errorf("invalid onExhausted value '%s', it should be throw or undefined", onExhausted)
+		}
+	}
+
+	counterName := partitionedArrayNamePrefix + sharedArr.name + "." + strategy
+	counter, ok := initEnv.SharedObjects.GetOrCreateShare(counterName, func() interface{} {
+		return new(int64)
+	}).(*int64)
+	if !ok {
+		return nil, errors.New("wrong type of shared object")
+	}
+	pa.counter = counter
+
+	return common.Bind(rt, pa, ctxPtr), nil
+}
+
+// Get returns the item for the current VU and iteration.
+func (pa *partitionedArray) Get(ctx context.Context) (goja.Value, error) {
+	state := lib.GetState(ctx)
+	if state == nil {
+		return nil, errors.New("the items of a PartitionedArray can't be accessed in the init context")
+	}
+
+	start, end := state.Options.ExecutionSegment.ScaleRange(int64(pa.array.Len()))
+	size := end - start
+
+	if pa.lastIteration != state.Iteration || pa.strategy == PartitionUniquePerVU {
+		switch pa.strategy {
+		case PartitionUniquePerVU:
+			pa.lastIndex = state.Vu - 1
+		case PartitionUniquePerIteration:
+			pa.lastIndex = atomic.AddInt64(pa.counter, 1) - 1
+		case PartitionRoundRobin:
+			pa.lastIndex = atomic.AddInt64(pa.counter, 1) - 1
+			if size > 0 {
+				pa.lastIndex %= size
+			}
+		}
+		pa.lastIteration = state.Iteration
+	}
+
+	if pa.lastIndex >= size {
+		if !pa.throwOnExhausted {
+			return goja.Undefined(), nil
+		}
+		return nil, fmt.Errorf(
+			"the SharedArray '%s' has run out of items for the %s strategy, only %d of its items belong to this instance",
+			pa.array.name[len(sharedArrayNamePrefix):], pa.strategy, size,
+		)
+	}
+	return pa.array.Get(int(start + pa.lastIndex)), nil
+}
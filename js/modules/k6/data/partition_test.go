@@ -0,0 +1,139 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package data
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dop251/goja"
+	"github.com/loadimpact/k6/js/common"
+	"github.com/loadimpact/k6/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPartitionRuntime returns a runtime with an initialized SharedArray with
+// the name "shared" and 50 items, and a pointer to the runtime's context.
+func newPartitionRuntime(t *testing.T, initEnv *common.InitEnvironment) (*goja.Runtime, *context.Context) {
+	rt := goja.New()
+	rt.SetFieldNameMapper(common.FieldNameMapper{})
+
+	ctx := common.WithInitEnv(context.Background(), initEnv)
+	ctx = common.WithRuntime(ctx, rt)
+	ctxPtr := &ctx
+	rt.Set("data", common.Bind(rt, new(data), ctxPtr))
+	_, err := rt.RunString("var SharedArray = data.SharedArray; var PartitionedArray = data.PartitionedArray;")
+	require.NoError(t, err)
+	_, err = rt.RunString(makeArrayScript)
+	require.NoError(t, err)
+	return rt, ctxPtr
+}
+
+// setVUState sets the state of a VU in the context, the strategies that depend
+// on the iterations are tested with real VUs in the js package
+func setVUState(ctxPtr *context.Context, segment *lib.ExecutionSegment, vuID int64) {
+	*ctxPtr = lib.WithState(common.WithRuntime(context.Background(), common.GetRuntime(*ctxPtr)), &lib.State{
+		Options: lib.Options{ExecutionSegment: segment},
+		Vu:      vuID,
+	})
+}
+
+func getPartitionedValue(t *testing.T, rt *goja.Runtime) string {
+	v, err := rt.RunString(`var item = partitioned.get(); item === undefined ? "undefined" : item.value`)
+	require.NoError(t, err)
+	return v.String()
+}
+
+func TestPartitionedArrayConstructorExceptions(t *testing.T) {
+	t.Parallel()
+	rt, _ := newPartitionRuntime(t, &common.InitEnvironment{SharedObjects: common.NewSharedObjects()})
+
+	cases := map[string]struct {
+		code, err string
+	}{
+		"not a shared array": {
+			code: `new PartitionedArray([1, 2, 3], "unique-per-vu")`,
+			err:  "only a SharedArray can be partitioned",
+		},
+		"unknown strategy": {
+			code: `new PartitionedArray(array, "random")`,
+			err:  "unknown partition strategy 'random'",
+		},
+		"invalid onExhausted": {
+			code: `new PartitionedArray(array, "round-robin", {onExhausted: "wrap"})`,
+			err:  "invalid onExhausted value 'wrap'",
+		},
+		"valid": {
+			code: `new PartitionedArray(array, "round-robin", {onExhausted: "undefined"})`,
+		},
+	}
+
+	for name, testCase := range cases {
+		name, testCase := name, testCase
+		t.Run(name, func(t *testing.T) {
+			_, err := rt.RunString(testCase.code)
+			if testCase.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), testCase.err)
+		})
+	}
+}
+
+func TestPartitionedArrayInitContext(t *testing.T) {
+	t.Parallel()
+	rt, _ := newPartitionRuntime(t, &common.InitEnvironment{SharedObjects: common.NewSharedObjects()})
+	_, err := rt.RunString(`var partitioned = new PartitionedArray(array, "unique-per-vu"); partitioned.get()`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "can't be accessed in the init context")
+}
+
+func TestPartitionedArrayUniquePerVU(t *testing.T) {
+	t.Parallel()
+	segment, err := lib.NewExecutionSegmentFromString("1/2:1")
+	require.NoError(t, err)
+	initEnv := &common.InitEnvironment{SharedObjects: common.NewSharedObjects()}
+
+	var vus []*goja.Runtime
+	var ctxs []*context.Context
+	for i := 0; i < 2; i++ {
+		rt, ctxPtr := newPartitionRuntime(t, initEnv)
+		_, err = rt.RunString(`var partitioned = new PartitionedArray(array, "unique-per-vu")`)
+		require.NoError(t, err)
+		vus, ctxs = append(vus, rt), append(ctxs, ctxPtr)
+	}
+
+	for iter := 0; iter < 3; iter++ {
+		for i, rt := range vus {
+			setVUState(ctxs[i], segment, int64(i+1))
+			// the second half of the 50 items belongs to the 1/2:1 segment
+			assert.Equal(t, "something"+[]string{"25", "26"}[i], getPartitionedValue(t, rt))
+		}
+	}
+
+	setVUState(ctxs[0], segment, 26)
+	_, err = vus[0].RunString(`partitioned.get()`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the SharedArray 'shared' has run out of items for the unique-per-vu strategy")
+}
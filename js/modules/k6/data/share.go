@@ -28,7 +28,8 @@ import (
 // TODO fix it not working really well with setupData or just make it more broken
 // TODO fix it working with console.log
 type sharedArray struct {
-	arr []string
+	name string
+	arr  []string
 }
 
 type wrappedSharedArray struct {
@@ -0,0 +1,82 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loadimpact/k6/lib/httpcache"
+	"github.com/loadimpact/k6/lib/metrics"
+	"github.com/loadimpact/k6/stats"
+)
+
+func TestHTTPCache(t *testing.T) {
+	t.Parallel()
+	tb, state, samples, rt, _ := newRuntime(t)
+	defer tb.Cleanup()
+	sr := tb.Replacer.Replace
+	state.HTTPCache = httpcache.New(0)
+
+	requests := 0
+	tb.Mux.HandleFunc("/cached", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", `"v1"`)
+		if r.URL.Query().Get("fresh") != "" {
+			w.Header().Set("Cache-Control", "max-age=60")
+		} else {
+			w.Header().Set("Cache-Control", "no-cache")
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = fmt.Fprint(w, "cached content")
+	})
+
+	_, err := rt.RunString(sr(`
+	for (var i = 0; i < 2; i++) {
+		var fresh = http.get("HTTPBIN_URL/cached?fresh=1");
+		var revalidated = http.get("HTTPBIN_URL/cached");
+		if (fresh.status != 200 || fresh.body != "cached content") { throw new Error("wrong fresh response: " + fresh.status); }
+		if (revalidated.status != 200 || revalidated.body != "cached content") {
+			throw new Error("wrong revalidated response: " + revalidated.status);
+		}
+	}
+	`))
+	require.NoError(t, err)
+	assert.Equal(t, 3, requests)
+
+	var cacheTags []string
+	for _, sc := range stats.GetBufferedSamples(samples) {
+		for _, s := range sc.GetSamples() {
+			if s.Metric == metrics.HTTPReqs {
+				tag, _ := s.Tags.Get("cache")
+				cacheTags = append(cacheTags, tag)
+			}
+		}
+	}
+	assert.Equal(t, []string{"miss", "miss", "hit", "revalidated"}, cacheTags)
+}
@@ -0,0 +1,75 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package http
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/loadimpact/k6/lib/metrics"
+	"github.com/loadimpact/k6/stats"
+)
+
+func TestConnectionMetrics(t *testing.T) {
+	t.Parallel()
+	tb, state, samples, rt, _ := newRuntime(t)
+	defer tb.Cleanup()
+
+	getConnSamples := func() (opened float64, reused []float64) {
+		for _, sc := range stats.GetBufferedSamples(samples) {
+			for _, s := range sc.GetSamples() {
+				switch s.Metric {
+				case metrics.HTTPConnsOpened:
+					opened += s.Value
+				case metrics.HTTPReqConnReused:
+					reused = append(reused, s.Value)
+				}
+			}
+		}
+		return opened, reused
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		_, err := rt.RunString(tb.Replacer.Replace(`http.get("HTTPBIN_URL/get");`))
+		require.NoError(t, err)
+		opened, reused := getConnSamples()
+		assert.Equal(t, float64(0), opened)
+		assert.Empty(t, reused)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		state.Options.HTTPTransport.ConnectionMetrics = null.BoolFrom(true)
+		defer func() { state.Options.HTTPTransport.ConnectionMetrics = null.Bool{} }()
+		tb.HTTPTransport.CloseIdleConnections()
+		_, err := rt.RunString(tb.Replacer.Replace(`
+			http.get("HTTPBIN_URL/get");
+			http.get("HTTPBIN_URL/get");
+			http.get("HTTPSBIN_URL/get");
+		`))
+		require.NoError(t, err)
+		opened, reused := getConnSamples()
+		assert.Equal(t, float64(2), opened)
+		assert.Equal(t, []float64{0, 1, 0}, reused)
+	})
+}
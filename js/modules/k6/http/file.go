@@ -27,11 +27,13 @@ import (
 	"time"
 
 	"github.com/loadimpact/k6/js/common"
+	"github.com/loadimpact/k6/lib/fsext"
 )
 
 // FileData represents a binary file requiring multipart request encoding
 type FileData struct {
 	Data        []byte
+	Stream      *fsext.StreamedFile // used instead of Data for files opened with open(path, "stream")
 	Filename    string
 	ContentType string
 }
@@ -55,6 +57,10 @@ func (h *HTTP) File(ctx context.Context, data interface{}, args ...string) FileD
 		}
 	}
 
+	if stream, ok := data.(*fsext.StreamedFile); ok {
+		return FileData{Stream: stream, Filename: fname, ContentType: ct}
+	}
+
 	dt, err := common.ToBytes(data)
 	if err != nil {
 		common.Throw(common.GetRuntime(ctx), err)
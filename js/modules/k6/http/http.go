@@ -101,6 +101,7 @@ type HTTP struct {
 	OCSP_REASON_AA_COMPROMISE          string `js:"OCSP_REASON_AA_COMPROMISE"`
 
 	responseCallback func(int) bool
+	resources        resourceCache
 }
 
 func (*HTTP) XCookieJar(ctx *context.Context) *HTTPCookieJar {
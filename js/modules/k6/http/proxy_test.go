@@ -0,0 +1,160 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package http
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loadimpact/k6/lib/metrics"
+	"github.com/loadimpact/k6/lib/netext/httpext"
+	"github.com/loadimpact/k6/stats"
+)
+
+// testProxy is a minimal HTTP proxy that supports both plain HTTP requests and
+// CONNECT tunnels, and records the requests it received.
+type testProxy struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	requests []string // method and auth header of every request
+}
+
+func newTestProxy(t *testing.T) *testProxy {
+	tp := &testProxy{}
+	tp.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tp.mutex.Lock()
+		tp.requests = append(tp.requests, r.Method+" "+r.Header.Get("Proxy-Authorization"))
+		tp.mutex.Unlock()
+
+		if r.Method != http.MethodConnect {
+			r.RequestURI = ""
+			r.Header.Del("Proxy-Authorization")
+			res, err := http.DefaultTransport.RoundTrip(r)
+			if err != nil {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			defer func() { _ = res.Body.Close() }()
+			for k, v := range res.Header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(res.StatusCode)
+			_, _ = io.Copy(w, res.Body)
+			return
+		}
+
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if !assert.NoError(t, err) {
+			return
+		}
+		go func() {
+			_, _ = io.Copy(target, conn)
+			_ = target.Close()
+		}()
+		go func() {
+			_, _ = io.Copy(conn, target)
+			_ = conn.Close()
+		}()
+	}))
+	return tp
+}
+
+func (tp *testProxy) getRequests() []string {
+	tp.mutex.Lock()
+	defer tp.mutex.Unlock()
+	return append([]string{}, tp.requests...)
+}
+
+func getProxyConnectingSamples(containers []stats.SampleContainer) (result []stats.Sample) {
+	for _, sc := range containers {
+		for _, s := range sc.GetSamples() {
+			if s.Metric == metrics.HTTPReqProxyConnecting {
+				result = append(result, s)
+			}
+		}
+	}
+	return result
+}
+
+func TestRequestProxy(t *testing.T) {
+	t.Parallel()
+	tb, state, samples, rt, _ := newRuntime(t)
+	defer tb.Cleanup()
+	tb.HTTPTransport.Proxy = httpext.ResolveProxy
+	proxy := newTestProxy(t)
+	defer proxy.Close()
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+
+	t.Run("param", func(t *testing.T) {
+		_, err := rt.RunString(tb.Replacer.Replace(`
+			var res = http.get("HTTPBIN_IP_URL/get", {proxy: "http://user:pass@` + proxyURL.Host + `"});
+			if (res.status != 200) { throw new Error("wrong status: " + res.status); }
+			if (res.timings.proxy_connecting < 0) { throw new Error("wrong proxy timing"); }
+		`))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"GET Basic dXNlcjpwYXNz"}, proxy.getRequests())
+		assert.Len(t, getProxyConnectingSamples(stats.GetBufferedSamples(samples)), 1)
+	})
+
+	t.Run("VU proxy with CONNECT", func(t *testing.T) {
+		state.Proxy = proxyURL
+		defer func() { state.Proxy = nil }()
+		_, err := rt.RunString(tb.Replacer.Replace(`
+			var res = http.get("HTTPSBIN_IP_URL/get");
+			if (res.status != 200) { throw new Error("wrong status: " + res.status); }
+		`))
+		require.NoError(t, err)
+		requests := proxy.getRequests()
+		require.Len(t, requests, 2)
+		assert.Equal(t, "CONNECT ", requests[1])
+		proxySamples := getProxyConnectingSamples(stats.GetBufferedSamples(samples))
+		require.Len(t, proxySamples, 1)
+		assert.True(t, proxySamples[0].Value > 0)
+	})
+
+	t.Run("no proxy", func(t *testing.T) {
+		_, err := rt.RunString(tb.Replacer.Replace(`http.get("HTTPBIN_IP_URL/get");`))
+		require.NoError(t, err)
+		assert.Len(t, proxy.getRequests(), 2)
+		assert.Empty(t, getProxyConnectingSamples(stats.GetBufferedSamples(samples)))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := rt.RunString(tb.Replacer.Replace(`http.get("HTTPBIN_IP_URL/get", {proxy: "ftp://proxy"});`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported proxy scheme 'ftp'")
+	})
+}
@@ -23,6 +23,7 @@ package http
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"mime/multipart"
 	"net/http"
@@ -37,6 +38,7 @@ import (
 
 	"github.com/loadimpact/k6/js/common"
 	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/fsext"
 	"github.com/loadimpact/k6/lib/netext/httpext"
 	"github.com/loadimpact/k6/lib/types"
 )
@@ -113,7 +115,20 @@ func (h *HTTP) Request(ctx context.Context, method string, url goja.Value, args
 	if err != nil {
 		return nil, err
 	}
-	return h.responseFromHttpext(resp), nil
+	res := h.responseFromHttpext(resp)
+
+	if params != nil && !goja.IsUndefined(params) && !goja.IsNull(params) {
+		if v := params.ToObject(common.GetRuntime(ctx)).Get("resources"); v != nil && v.ToBoolean() {
+			var resourceParams goja.Value
+			if _, isObject := v.Export().(map[string]interface{}); isObject {
+				resourceParams = v
+			}
+			if res.Resources, err = h.fetchResources(ctx, resp, resourceParams); err != nil {
+				return res, err
+			}
+		}
+	}
+	return res, nil
 }
 
 //TODO break this function up
@@ -140,6 +155,7 @@ func (h *HTTP) parseRequest(
 		Cookies:          make(map[string]*httpext.HTTPRequestCookie),
 		Tags:             make(map[string]string),
 		ResponseCallback: h.responseCallback,
+		Retry:            state.Options.Retry,
 	}
 
 	if state.Options.DiscardResponseBodies.Bool {
@@ -167,6 +183,8 @@ func (h *HTTP) parseRequest(
 		// handling multipart request
 		result.Body = &bytes.Buffer{}
 		mpw := multipart.NewWriter(result.Body)
+		// Streamed files are sent between the parts of the body that are in memory
+		var streamSources []httpext.StreamSource
 
 		// For parameters of type common.FileData, created with open(file, "b"),
 		// we write the file boundary to the body buffer.
@@ -190,6 +208,13 @@ func (h *HTTP) parseRequest(
 					return err
 				}
 
+				if ve.Stream != nil {
+					streamSources = append(streamSources,
+						httpext.BytesSource(append([]byte{}, result.Body.Bytes()...)), ve.Stream)
+					result.Body.Reset()
+					continue
+				}
+
 				if _, err := fw.Write(ve.Data); err != nil {
 					return err
 				}
@@ -208,6 +233,11 @@ func (h *HTTP) parseRequest(
 		if err := mpw.Close(); err != nil {
 			return err
 		}
+		if streamSources != nil {
+			streamSources = append(streamSources, httpext.BytesSource(result.Body.Bytes()))
+			result.StreamBody = httpext.NewStreamBody(streamSources...)
+			result.Body = nil
+		}
 
 		result.Req.Header.Set("Content-Type", mpw.FormDataContentType())
 		return nil
@@ -234,6 +264,8 @@ func (h *HTTP) parseRequest(
 			result.Body = bytes.NewBufferString(data)
 		case []byte:
 			result.Body = bytes.NewBuffer(data)
+		case *fsext.StreamedFile:
+			result.StreamBody = httpext.NewStreamBody(data)
 		default:
 			return nil, fmt.Errorf("unknown request body type %T", body)
 		}
@@ -345,6 +377,32 @@ func (h *HTTP) parseRequest(
 				result.Timeout = t
 			case "throw":
 				result.Throw = params.Get(k).ToBoolean()
+			case "chunked":
+				result.Chunked = params.Get(k).ToBoolean()
+			case "proxy":
+				proxyV := params.Get(k)
+				if goja.IsUndefined(proxyV) || goja.IsNull(proxyV) {
+					continue
+				}
+				proxy, err := types.ParseProxyURL(proxyV.String())
+				if err != nil {
+					return nil, err
+				}
+				result.Proxy = proxy
+			case "retry":
+				retryV := params.Get(k)
+				if goja.IsUndefined(retryV) || goja.IsNull(retryV) {
+					continue
+				}
+				retryJSON, err := json.Marshal(retryV.Export())
+				if err != nil {
+					return nil, err
+				}
+				var retry types.RetryConfig
+				if err := json.Unmarshal(retryJSON, &retry); err != nil {
+					return nil, fmt.Errorf("invalid retry value: %w", err)
+				}
+				result.Retry = result.Retry.Apply(retry)
 			case "responseType":
 				responseType, err := httpext.ResponseTypeString(params.Get(k).String())
 				if err != nil {
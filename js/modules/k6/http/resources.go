@@ -0,0 +1,192 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package http
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/dop251/goja"
+
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/netext/httpext"
+)
+
+// resourceElements are the HTML elements that embed resources which a browser
+// would fetch when loading the page, with the attribute that has their URL.
+//
+//nolint:gochecknoglobals
+var resourceElements = []struct{ selector, attr string }{
+	{"script[src]", "src"},
+	{"link[rel~=stylesheet][href]", "href"},
+	{"link[rel~=icon][href]", "href"},
+	{"link[rel=preload][href]", "href"},
+	{"img[src]", "src"},
+	{"input[type=image][src]", "src"},
+	{"video[src]", "src"},
+	{"video[poster]", "poster"},
+	{"audio[src]", "src"},
+	{"source[src]", "src"},
+	{"track[src]", "src"},
+	{"embed[src]", "src"},
+	{"object[data]", "data"},
+}
+
+// resourceCache keeps track of the resources fetched by a VU during its
+// current iteration, so they aren't fetched again for the other pages.
+type resourceCache struct {
+	iteration int64
+	fetched   map[string]struct{}
+}
+
+// filter returns the URLs that haven't been fetched in the current iteration
+// and marks them as fetched.
+func (c *resourceCache) filter(iteration int64, urls []string) []string {
+	if c.fetched == nil || c.iteration != iteration {
+		c.iteration = iteration
+		c.fetched = make(map[string]struct{})
+	}
+	result := make([]string, 0, len(urls))
+	for _, u := range urls {
+		if _, ok := c.fetched[u]; ok {
+			continue
+		}
+		c.fetched[u] = struct{}{}
+		result = append(result, u)
+	}
+	return result
+}
+
+// extractResourceURLs returns the absolute URLs of the resources embedded in
+// the given HTML document, in document order and without duplicates.
+func extractResourceURLs(body, pageURL string) ([]string, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	if href, ok := doc.Find("base[href]").First().Attr("href"); ok {
+		if baseURL, err := base.Parse(strings.TrimSpace(href)); err == nil {
+			base = baseURL
+		}
+	}
+
+	seen := make(map[string]struct{})
+	var urls []string
+	// The selectors are joined so the elements are matched in document order
+	selectors := make([]string, len(resourceElements))
+	for i, el := range resourceElements {
+		selectors[i] = el.selector
+	}
+	doc.Find(strings.Join(selectors, ", ")).Each(func(_ int, s *goquery.Selection) {
+		for _, el := range resourceElements {
+			if !s.Is(el.selector) {
+				continue
+			}
+			ref, err := base.Parse(strings.TrimSpace(s.AttrOr(el.attr, "")))
+			if err != nil || (ref.Scheme != "http" && ref.Scheme != "https") {
+				continue
+			}
+			ref.Fragment = ""
+			u := ref.String()
+			if _, ok := seen[u]; !ok && u != pageURL {
+				seen[u] = struct{}{}
+				urls = append(urls, u)
+			}
+		}
+	})
+	return urls, nil
+}
+
+// fetchResources fetches the resources embedded in the HTML body of the page
+// response in parallel, within the batch and batchPerHost limits. Resources
+// that were already fetched during the current iteration are skipped. The
+// requests are made with the given params and are tagged with the page URL.
+func (h *HTTP) fetchResources(ctx context.Context, page *httpext.Response, params goja.Value) ([]*Response, error) {
+	state := lib.GetState(ctx)
+	if state == nil {
+		return nil, ErrBatchForbiddenInInitContext
+	}
+
+	var body string
+	switch b := page.Body.(type) {
+	case []byte:
+		body = string(b)
+	case string:
+		body = b
+	default:
+		return nil, errors.New("the response body is needed to find its resources, it can't be discarded")
+	}
+
+	urls, err := extractResourceURLs(body, page.URL)
+	if err != nil {
+		return nil, err
+	}
+	urls = h.resources.filter(state.Iteration, urls)
+
+	batchReqs := make([]httpext.BatchParsedHTTPRequest, len(urls))
+	results := make([]*Response, len(urls))
+	for i, u := range urls {
+		reqURL, err := httpext.NewURL(u, u)
+		if err != nil {
+			return nil, err
+		}
+		parsedReq, err := h.parseRequest(ctx, HTTP_METHOD_GET, reqURL, nil, params)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := parsedReq.Tags["page"]; !ok {
+			parsedReq.Tags["page"] = page.URL
+		}
+		response := new(httpext.Response)
+		batchReqs[i] = httpext.BatchParsedHTTPRequest{ParsedHTTPRequest: parsedReq, Response: response}
+		results[i] = h.responseFromHttpext(response)
+	}
+
+	errs := httpext.MakeBatchRequests(
+		ctx, batchReqs, len(batchReqs),
+		int(state.Options.Batch.Int64), int(state.Options.BatchPerHost.Int64),
+	)
+	for range batchReqs {
+		if e := <-errs; e != nil && err == nil { // Save only the first error
+			err = e
+		}
+	}
+	return results, err
+}
+
+// FetchResources fetches the scripts, stylesheets, images and other resources
+// embedded in the HTML body of the response, like a browser loading the page
+// would. The optional params are used for all of the resource requests.
+func (res *Response) FetchResources(args ...goja.Value) ([]*Response, error) {
+	var params goja.Value
+	if len(args) > 0 {
+		params = args[0]
+	}
+	return res.h.fetchResources(res.GetCtx(), res.Response, params)
+}
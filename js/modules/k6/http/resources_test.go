@@ -0,0 +1,148 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loadimpact/k6/lib/metrics"
+	"github.com/loadimpact/k6/stats"
+)
+
+func TestExtractResourceURLs(t *testing.T) {
+	t.Parallel()
+	body := `<html><head>
+		<base href="/static/">
+		<link rel="stylesheet" href="style.css">
+		<link rel="shortcut icon" href="/favicon.ico">
+		<link rel="canonical" href="/other-page">
+		<script src="app.js#main"></script>
+		<script>var inline = true;</script>
+	</head><body>
+		<img src="logo.png"><img src="logo.png"><img src="data:image/png;base64,AAAA">
+		<a href="/link">not a resource</a>
+		<video src="https://cdn.example.com/video.mp4" poster="poster.jpg"></video>
+	</body></html>`
+
+	urls, err := extractResourceURLs(body, "https://example.com/page")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"https://example.com/static/style.css",
+		"https://example.com/favicon.ico",
+		"https://example.com/static/app.js",
+		"https://example.com/static/logo.png",
+		"https://cdn.example.com/video.mp4",
+		"https://example.com/static/poster.jpg",
+	}, urls)
+}
+
+func TestFetchResources(t *testing.T) {
+	t.Parallel()
+	tb, state, samples, rt, _ := newRuntime(t)
+	defer tb.Cleanup()
+	sr := tb.Replacer.Replace
+
+	var hits int64
+	tb.Mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprint(w, `<html><head>
+			<link rel="stylesheet" href="/assets/style.css">
+			<script src="assets/app.js"></script>
+		</head><body><img src="/assets/logo.png"><img src="/assets/logo.png"></body></html>`)
+	})
+	tb.Mux.HandleFunc("/assets/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&hits, 1)
+		w.Header().Set("X-Requested-With", r.Header.Get("X-Requested-With"))
+		_, _ = fmt.Fprint(w, r.URL.Path)
+	})
+
+	t.Run("param", func(t *testing.T) {
+		_, err := rt.RunString(sr(`
+		var res = http.get("HTTPBIN_URL/page", { resources: true });
+		if (res.resources.length != 3) { throw new Error("wrong number of resources: " + res.resources.length); }
+		var bodies = res.resources.map(function(r) { return r.status + ":" + r.body; }).sort();
+		if (bodies.join("|") != "200:/assets/app.js|200:/assets/logo.png|200:/assets/style.css") {
+			throw new Error("wrong resources: " + bodies.join("|"));
+		}
+		`))
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), atomic.LoadInt64(&hits))
+
+		pages := map[string]int{}
+		for _, sc := range stats.GetBufferedSamples(samples) {
+			for _, s := range sc.GetSamples() {
+				if s.Metric == metrics.HTTPReqs {
+					page, _ := s.Tags.Get("page")
+					pages[page]++
+				}
+			}
+		}
+		assert.Equal(t, map[string]int{"": 1, sr("HTTPBIN_URL/page"): 3}, pages)
+	})
+
+	t.Run("cached in the same iteration", func(t *testing.T) {
+		_, err := rt.RunString(sr(`
+		var res = http.get("HTTPBIN_URL/page");
+		var resources = res.fetchResources();
+		if (resources.length != 0) { throw new Error("resources weren't cached: " + resources.length); }
+		`))
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), atomic.LoadInt64(&hits))
+	})
+
+	t.Run("method with params", func(t *testing.T) {
+		state.Iteration++
+		_, err := rt.RunString(sr(`
+		var res = http.get("HTTPBIN_URL/page");
+		var resources = res.fetchResources({ headers: { "X-Requested-With": "k6" }, tags: { page: "home" } });
+		if (resources.length != 3) { throw new Error("wrong number of resources: " + resources.length); }
+		resources.forEach(function(r) {
+			if (r.headers["X-Requested-With"] != "k6") { throw new Error("params weren't used"); }
+		});
+		`))
+		require.NoError(t, err)
+		assert.Equal(t, int64(6), atomic.LoadInt64(&hits))
+
+		for _, sc := range stats.GetBufferedSamples(samples) {
+			for _, s := range sc.GetSamples() {
+				if s.Metric == metrics.HTTPReqs {
+					if page, ok := s.Tags.Get("page"); ok {
+						assert.Equal(t, "home", page)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("discarded body", func(t *testing.T) {
+		_, err := rt.RunString(sr(`
+		http.get("HTTPBIN_URL/page", { responseType: "none" }).fetchResources();
+		`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the response body is needed to find its resources")
+	})
+}
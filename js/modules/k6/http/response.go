@@ -37,10 +37,14 @@ import (
 type Response struct {
 	*httpext.Response `js:"-"`
 	h                 *HTTP
+
+	// Resources are the responses for the embedded resources of the page,
+	// when it was requested with the resources param.
+	Resources []*Response `json:"resources"`
 }
 
 func (h *HTTP) responseFromHttpext(resp *httpext.Response) *Response {
-	return &Response{Response: resp, h: h}
+	return &Response{Response: resp, h: h, Resources: []*Response{}}
 }
 
 // JSON parses the body of a response as json and returns it to the goja VM
@@ -0,0 +1,142 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package http
+
+import (
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/loadimpact/k6/lib/metrics"
+	"github.com/loadimpact/k6/lib/types"
+	"github.com/loadimpact/k6/stats"
+)
+
+func getAttemptTags(containers []stats.SampleContainer) (result []string) {
+	for _, sc := range containers {
+		for _, s := range sc.GetSamples() {
+			if s.Metric == metrics.HTTPReqs {
+				attempt, _ := s.Tags.Get("attempt")
+				result = append(result, attempt)
+			}
+		}
+	}
+	return result
+}
+
+func TestRequestRetry(t *testing.T) {
+	t.Parallel()
+	tb, state, samples, rt, _ := newRuntime(t)
+	defer tb.Cleanup()
+
+	var failures, requests int32
+	tb.Mux.HandleFunc("/flaky", func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "data", string(body))
+		atomic.AddInt32(&requests, 1)
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+	reset := func(f int32) {
+		atomic.StoreInt32(&failures, f)
+		atomic.StoreInt32(&requests, 0)
+		stats.GetBufferedSamples(samples)
+	}
+
+	t.Run("param", func(t *testing.T) {
+		reset(2)
+		_, err := rt.RunString(tb.Replacer.Replace(`
+			var res = http.post("HTTPBIN_URL/flaky", "data", {retry: {attempts: 3, delay: 1}});
+			if (res.status != 200) { throw new Error("wrong status: " + res.status); }
+			if (res.retries.length != 2) { throw new Error("wrong retries: " + res.retries.length); }
+			if (res.retries[0].status != 503 || res.retries[0].error_code != 1503) {
+				throw new Error("wrong first retry: " + JSON.stringify(res.retries[0]));
+			}
+			if (res.retries[1].attempt != 2) { throw new Error("wrong attempt: " + res.retries[1].attempt); }
+		`))
+		require.NoError(t, err)
+		assert.EqualValues(t, 3, atomic.LoadInt32(&requests))
+		assert.Equal(t, []string{"1", "2", "3"}, getAttemptTags(stats.GetBufferedSamples(samples)))
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		reset(5)
+		_, err := rt.RunString(tb.Replacer.Replace(`
+			var res = http.post("HTTPBIN_URL/flaky", "data", {retry: {attempts: 2, backoff: "constant", delay: "1ms"}});
+			if (res.status != 503) { throw new Error("wrong status: " + res.status); }
+			if (res.retries.length != 1) { throw new Error("wrong retries: " + res.retries.length); }
+		`))
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, atomic.LoadInt32(&requests))
+	})
+
+	t.Run("statuses", func(t *testing.T) {
+		reset(1)
+		_, err := rt.RunString(tb.Replacer.Replace(`
+			var res = http.post("HTTPBIN_URL/flaky", "data", {retry: {attempts: 3, statuses: [500]}});
+			if (res.status != 503) { throw new Error("wrong status: " + res.status); }
+			if (res.retries.length != 0) { throw new Error("wrong retries: " + res.retries.length); }
+		`))
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, atomic.LoadInt32(&requests))
+	})
+
+	t.Run("global option and batch", func(t *testing.T) {
+		reset(1)
+		state.Options.Retry = types.RetryConfig{
+			Attempts: null.IntFrom(2), Delay: types.NullDurationFrom(0), Valid: true,
+		}
+		defer func() { state.Options.Retry = types.RetryConfig{} }()
+		_, err := rt.RunString(tb.Replacer.Replace(`
+			var res = http.batch([["POST", "HTTPBIN_URL/flaky", "data"]])[0];
+			if (res.status != 200) { throw new Error("wrong status: " + res.status); }
+			if (res.retries.length != 1) { throw new Error("wrong retries: " + res.retries.length); }
+		`))
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, atomic.LoadInt32(&requests))
+		assert.Equal(t, []string{"1", "2"}, getAttemptTags(stats.GetBufferedSamples(samples)))
+	})
+
+	t.Run("no retries", func(t *testing.T) {
+		reset(1)
+		_, err := rt.RunString(tb.Replacer.Replace(`
+			var res = http.post("HTTPBIN_URL/flaky", "data");
+			if (res.status != 503) { throw new Error("wrong status: " + res.status); }
+		`))
+		require.NoError(t, err)
+		assert.Equal(t, []string{""}, getAttemptTags(stats.GetBufferedSamples(samples)))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := rt.RunString(tb.Replacer.Replace(`http.get("HTTPBIN_URL/get", {retry: {backoff: "random"}});`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown retry backoff 'random'")
+	})
+}
@@ -0,0 +1,140 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loadimpact/k6/lib/fsext"
+)
+
+func TestRequestStreamedBody(t *testing.T) {
+	t.Parallel()
+	tb, _, _, rt, _ := newRuntime(t)
+	defer tb.Cleanup()
+
+	fileData := bytes.Repeat([]byte("0123456789"), 100000)
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/big.bin", fileData, 0o644))
+	file, err := fsext.NewStreamedFile(fs, "/big.bin")
+	require.NoError(t, err)
+	rt.Set("bigFile", file)
+
+	tb.Mux.HandleFunc("/upload", func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, fileData, body)
+		w.Header().Set("X-Content-Length", strconv.FormatInt(r.ContentLength, 10))
+		w.Header().Set("X-Transfer-Encoding", fmt.Sprint(r.TransferEncoding))
+	})
+	tb.Mux.HandleFunc("/multipart", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseMultipartForm(1024))
+		assert.Equal(t, "bar", r.FormValue("foo"))
+		f, h, err := r.FormFile("file")
+		require.NoError(t, err)
+		defer func() { _ = f.Close() }()
+		assert.Equal(t, "big.bin", h.Filename)
+		assert.Equal(t, "application/x-test", h.Header.Get("Content-Type"))
+		body, err := ioutil.ReadAll(f)
+		require.NoError(t, err)
+		assert.Equal(t, fileData, body)
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	t.Run("body", func(t *testing.T) {
+		_, err := rt.RunString(tb.Replacer.Replace(`
+			if (bigFile.size() != 1000000) { throw new Error("wrong size: " + bigFile.size()); }
+			var res = http.post("HTTPBIN_URL/upload", bigFile);
+			if (res.status != 200) { throw new Error("wrong status: " + res.status); }
+			if (res.headers["X-Content-Length"] != "1000000") {
+				throw new Error("wrong content length: " + res.headers["X-Content-Length"]);
+			}
+		`))
+		require.NoError(t, err)
+	})
+
+	t.Run("chunked", func(t *testing.T) {
+		_, err := rt.RunString(tb.Replacer.Replace(`
+			var res = http.post("HTTPBIN_URL/upload", bigFile, {chunked: true});
+			if (res.status != 200) { throw new Error("wrong status: " + res.status); }
+			if (res.headers["X-Transfer-Encoding"] != "[chunked]") {
+				throw new Error("wrong transfer encoding: " + res.headers["X-Transfer-Encoding"]);
+			}
+		`))
+		require.NoError(t, err)
+	})
+
+	t.Run("multipart", func(t *testing.T) {
+		_, err := rt.RunString(tb.Replacer.Replace(`
+			var res = http.post("HTTPBIN_URL/multipart", {
+				foo: "bar",
+				file: http.file(bigFile, "big.bin", "application/x-test"),
+			});
+			if (res.status != 200) { throw new Error("wrong status: " + res.status); }
+		`))
+		require.NoError(t, err)
+	})
+
+	t.Run("compression", func(t *testing.T) {
+		_, err := rt.RunString(tb.Replacer.Replace(`
+			http.post("HTTPBIN_URL/upload", bigFile, {compression: "gzip"});
+		`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "compression isn't supported for streamed request bodies")
+	})
+}
+
+func TestResponseBodySize(t *testing.T) {
+	t.Parallel()
+	tb, _, _, rt, _ := newRuntime(t)
+	defer tb.Cleanup()
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	_, err := gw.Write(bytes.Repeat([]byte("a"), 10000))
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+	tb.Mux.HandleFunc("/gzipped", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write(gzipped.Bytes())
+	})
+
+	_, err = rt.RunString(tb.Replacer.Replace(`
+		var res = http.get("HTTPBIN_URL/bytes/100000", {responseType: "none"});
+		if (res.body !== null) { throw new Error("body isn't null"); }
+		if (res.body_size != 100000) { throw new Error("wrong body size: " + res.body_size); }
+		res = http.get("HTTPBIN_URL/gzipped", {headers: {"Accept-Encoding": "gzip"}});
+		if (res.body.length != 10000) { throw new Error("wrong body length: " + res.body.length); }
+		if (res.body_size != ` + strconv.Itoa(gzipped.Len()) + `) {
+			throw new Error("wrong gzipped body size: " + res.body_size);
+		}
+	`))
+	require.NoError(t, err)
+}
@@ -0,0 +1,408 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package net
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	gonet "net"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+
+	"github.com/loadimpact/k6/js/common"
+	"github.com/loadimpact/k6/js/internal/modules"
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/metrics"
+	"github.com/loadimpact/k6/lib/types"
+	"github.com/loadimpact/k6/stats"
+)
+
+func init() {
+	modules.Register("k6/net", New())
+}
+
+// ErrNetInInitContext is returned when sockets are used in the init context
+var ErrNetInInitContext = common.NewInitContextError("using sockets in the init context is not supported")
+
+const (
+	defaultConnectTimeout = 60 * time.Second
+	// the maximum amount of data returned by a single read without a size or
+	// delimiter, this is also the largest UDP datagram that can be read
+	maxReadSize = 64 * 1024
+)
+
+// Net is the k6/net module.
+type Net struct{}
+
+// New returns a new Net module instance.
+func New() *Net {
+	return &Net{}
+}
+
+// Socket is an open TCP, TLS or UDP connection.
+type Socket struct {
+	ctx       context.Context
+	state     *lib.State
+	conn      gonet.Conn
+	reader    *bufio.Reader
+	done      chan struct{}
+	closeOnce sync.Once
+
+	tags       map[string]string
+	sampleTags *stats.SampleTags
+}
+
+// Open connects to the given address through the VU dialer, so blacklists,
+// hosts overrides, local IPs and the DNS resolver all apply. The network can
+// be tcp, tls or udp, optionally suffixed with 4 or 6 to force the IP version.
+//
+//nolint:funlen
+func (*Net) Open(ctx context.Context, network, addr string, paramsV goja.Value) (*Socket, error) {
+	rt := common.GetRuntime(ctx)
+	state := lib.GetState(ctx)
+	if state == nil {
+		return nil, ErrNetInInitContext
+	}
+
+	var dialNetwork string
+	isTLS := false
+	switch network {
+	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6":
+		dialNetwork = network
+	case "tls", "tls4", "tls6":
+		dialNetwork, isTLS = "tcp"+network[3:], true
+	default:
+		return nil, fmt.Errorf("unsupported network '%s', it should be tcp, tls or udp", network)
+	}
+
+	tags := state.CloneTags()
+	timeout := defaultConnectTimeout
+	serverName := ""
+	if !isNullish(paramsV) {
+		params := paramsV.ToObject(rt)
+		for _, k := range params.Keys() {
+			v := params.Get(k)
+			if isNullish(v) {
+				continue
+			}
+			switch k {
+			case "timeout":
+				var err error
+				if timeout, err = types.GetDurationValue(v.Export()); err != nil {
+					return nil, fmt.Errorf("invalid timeout value: %w", err)
+				}
+			case "serverName":
+				serverName = v.String()
+			case "tags":
+				tagObj := v.ToObject(rt)
+				for _, key := range tagObj.Keys() {
+					tags[key] = tagObj.Get(key).String()
+				}
+			default:
+				return nil, fmt.Errorf("unknown net.open param '%s'", k)
+			}
+		}
+	}
+
+	enabledTags := state.Options.SystemTags
+	if enabledTags.Has(stats.TagProto) {
+		tags["proto"] = network
+	}
+	if _, ok := tags["name"]; !ok && enabledTags.Has(stats.TagName) {
+		tags["name"] = addr
+	}
+
+	s := &Socket{ctx: ctx, state: state, done: make(chan struct{}), tags: tags}
+
+	dialCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	conn, err := state.Dialer.DialContext(dialCtx, dialNetwork, addr)
+	connectEnd := time.Now()
+	if err != nil {
+		s.pushError(err)
+		return nil, err
+	}
+
+	if enabledTags.Has(stats.TagIP) {
+		if ip, _, err := gonet.SplitHostPort(conn.RemoteAddr().String()); err == nil {
+			tags["ip"] = ip
+		}
+	}
+	s.sampleTags = stats.IntoSampleTags(&tags)
+	samples := []stats.Sample{
+		{Metric: metrics.NetConnecting, Time: start, Tags: s.sampleTags, Value: stats.D(connectEnd.Sub(start))},
+	}
+
+	if isTLS {
+		tlsConfig := &tls.Config{} //nolint:gosec
+		if state.TLSConfig != nil {
+			tlsConfig = state.TLSConfig.Clone()
+		}
+		if serverName == "" {
+			if serverName, _, err = gonet.SplitHostPort(addr); err != nil {
+				serverName = addr
+			}
+		}
+		tlsConfig.ServerName = serverName
+
+		tlsConn := tls.Client(conn, tlsConfig)
+		if deadline, ok := dialCtx.Deadline(); ok {
+			_ = conn.SetDeadline(deadline)
+		}
+		err = tlsConn.Handshake()
+		handshakeEnd := time.Now()
+		_ = conn.SetDeadline(time.Time{})
+		if err != nil {
+			_ = conn.Close()
+			s.pushError(err)
+			return nil, err
+		}
+		samples = append(samples, stats.Sample{
+			Metric: metrics.NetTLSHandshaking, Time: connectEnd, Tags: s.sampleTags,
+			Value: stats.D(handshakeEnd.Sub(connectEnd)),
+		})
+		conn = tlsConn
+	}
+
+	stats.PushIfNotDone(ctx, state.Samples, stats.ConnectedSamples{
+		Samples: samples, Tags: s.sampleTags, Time: start,
+	})
+
+	s.conn = conn
+	s.reader = bufio.NewReaderSize(conn, maxReadSize)
+
+	// Unblock any pending reads or writes when the VU is interrupted
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Close()
+		case <-s.done:
+		}
+	}()
+
+	return s, nil
+}
+
+func isNullish(v goja.Value) bool {
+	return v == nil || goja.IsUndefined(v) || goja.IsNull(v)
+}
+
+// readParams are the optional parameters for Socket.Read
+type readParams struct {
+	timeout   time.Duration
+	delimiter []byte
+	size      int64
+	binary    bool
+}
+
+func parseReadParams(rt *goja.Runtime, paramsV goja.Value) (readParams, error) {
+	var p readParams
+	if isNullish(paramsV) {
+		return p, nil
+	}
+	params := paramsV.ToObject(rt)
+	for _, k := range params.Keys() {
+		v := params.Get(k)
+		if isNullish(v) {
+			continue
+		}
+		switch k {
+		case "timeout":
+			timeout, err := types.GetDurationValue(v.Export())
+			if err != nil {
+				return p, fmt.Errorf("invalid timeout value: %w", err)
+			}
+			p.timeout = timeout
+		case "delimiter":
+			delimiter, err := common.ToBytes(v.Export())
+			if err != nil {
+				return p, fmt.Errorf("invalid delimiter: %w", err)
+			}
+			p.delimiter = delimiter
+		case "size":
+			p.size = v.ToInteger()
+		case "type":
+			switch v.String() {
+			case "text":
+				p.binary = false
+			case "binary":
+				p.binary = true
+			default:
+				return p, fmt.Errorf("invalid read type '%s', it should be text or binary", v.String())
+			}
+		default:
+			return p, fmt.Errorf("unknown read param '%s'", k)
+		}
+	}
+	if p.size < 0 {
+		return p, fmt.Errorf("invalid read size %d", p.size)
+	}
+	if p.size > 0 && len(p.delimiter) > 0 {
+		return p, errors.New("only one of size and delimiter can be specified")
+	}
+	return p, nil
+}
+
+// Read reads data from the socket. By default it returns whatever data is
+// available, or the next datagram for UDP sockets. If a size is specified,
+// exactly that many bytes are read, and if a delimiter is, the data up to and
+// including the delimiter is read. The data is returned as a string, or as a
+// byte array if the type param is binary.
+func (s *Socket) Read(paramsV goja.Value) (interface{}, error) {
+	p, err := parseReadParams(common.GetRuntime(s.ctx), paramsV)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.timeout > 0 {
+		_ = s.conn.SetReadDeadline(time.Now().Add(p.timeout))
+		defer func() { _ = s.conn.SetReadDeadline(time.Time{}) }()
+	}
+
+	var data []byte
+	switch {
+	case len(p.delimiter) > 0:
+		data, err = readUntil(s.reader, p.delimiter)
+	case p.size > 0:
+		data = make([]byte, p.size)
+		var n int
+		n, err = io.ReadFull(s.reader, data)
+		data = data[:n]
+	default:
+		data = make([]byte, maxReadSize)
+		var n int
+		n, err = s.reader.Read(data)
+		data = data[:n]
+	}
+
+	if len(data) > 0 {
+		stats.PushIfNotDone(s.ctx, s.state.Samples, stats.Sample{
+			Metric: metrics.NetBytesReceived, Time: time.Now(), Tags: s.sampleTags, Value: float64(len(data)),
+		})
+	}
+	if err != nil {
+		s.pushError(err)
+		return nil, err
+	}
+	if p.binary {
+		return data, nil
+	}
+	return string(data), nil
+}
+
+// readUntil reads until the given delimiter, which can be longer than a byte
+func readUntil(r *bufio.Reader, delimiter []byte) ([]byte, error) {
+	last := delimiter[len(delimiter)-1]
+	var data []byte
+	for {
+		chunk, err := r.ReadBytes(last)
+		data = append(data, chunk...)
+		if err != nil {
+			return data, err
+		}
+		if len(data) >= len(delimiter) && string(data[len(data)-len(delimiter):]) == string(delimiter) {
+			return data, nil
+		}
+	}
+}
+
+// Write writes the given string or binary data to the socket, within the
+// optional timeout, and returns the number of written bytes. For UDP sockets
+// every write is sent as a separate datagram.
+func (s *Socket) Write(data goja.Value, paramsV goja.Value) (int, error) {
+	rt := common.GetRuntime(s.ctx)
+	if isNullish(data) {
+		return 0, errors.New("no data to write")
+	}
+	b, err := common.ToBytes(data.Export())
+	if err != nil {
+		return 0, err
+	}
+
+	var timeout time.Duration
+	if !isNullish(paramsV) {
+		params := paramsV.ToObject(rt)
+		for _, k := range params.Keys() {
+			switch k {
+			case "timeout":
+				if timeout, err = types.GetDurationValue(params.Get(k).Export()); err != nil {
+					return 0, fmt.Errorf("invalid timeout value: %w", err)
+				}
+			default:
+				return 0, fmt.Errorf("unknown write param '%s'", k)
+			}
+		}
+	}
+	if timeout > 0 {
+		_ = s.conn.SetWriteDeadline(time.Now().Add(timeout))
+		defer func() { _ = s.conn.SetWriteDeadline(time.Time{}) }()
+	}
+
+	n, err := s.conn.Write(b)
+	if n > 0 {
+		stats.PushIfNotDone(s.ctx, s.state.Samples, stats.Sample{
+			Metric: metrics.NetBytesSent, Time: time.Now(), Tags: s.sampleTags, Value: float64(n),
+		})
+	}
+	if err != nil {
+		s.pushError(err)
+		return n, err
+	}
+	return n, nil
+}
+
+// LocalAddr returns the local address of the socket.
+func (s *Socket) LocalAddr() string {
+	return s.conn.LocalAddr().String()
+}
+
+// RemoteAddr returns the remote address of the socket.
+func (s *Socket) RemoteAddr() string {
+	return s.conn.RemoteAddr().String()
+}
+
+// Close closes the socket, it's safe to call it multiple times.
+func (s *Socket) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		_ = s.conn.Close()
+	})
+}
+
+func (s *Socket) pushError(err error) {
+	tags := make(map[string]string, len(s.tags)+1)
+	for k, v := range s.tags {
+		tags[k] = v
+	}
+	if s.state.Options.SystemTags.Has(stats.TagError) {
+		tags["error"] = err.Error()
+	}
+	stats.PushIfNotDone(s.ctx, s.state.Samples, stats.Sample{
+		Metric: metrics.NetErrors, Time: time.Now(), Tags: stats.IntoSampleTags(&tags), Value: 1,
+	})
+}
@@ -0,0 +1,248 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package net
+
+import (
+	"context"
+	gonet "net"
+	"testing"
+
+	"github.com/dop251/goja"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loadimpact/k6/js/common"
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/metrics"
+	"github.com/loadimpact/k6/lib/testutils/httpmultibin"
+	"github.com/loadimpact/k6/stats"
+)
+
+func newRuntime(t *testing.T) (*httpmultibin.HTTPMultiBin, chan stats.SampleContainer, *goja.Runtime) {
+	tb := httpmultibin.NewHTTPMultiBin(t)
+
+	rt := goja.New()
+	rt.SetFieldNameMapper(common.FieldNameMapper{})
+	samples := make(chan stats.SampleContainer, 1000)
+	state := &lib.State{
+		Dialer: tb.Dialer,
+		Options: lib.Options{
+			SystemTags: stats.NewSystemTagSet(stats.TagProto, stats.TagName, stats.TagIP, stats.TagError),
+		},
+		TLSConfig: tb.TLSClientConfig,
+		Samples:   samples,
+		Tags:      map[string]string{},
+	}
+
+	ctx := lib.WithState(tb.Context, state)
+	ctx = common.WithRuntime(ctx, rt)
+	rt.Set("net", common.Bind(rt, New(), &ctx))
+
+	return tb, samples, rt
+}
+
+func metricCounts(samples chan stats.SampleContainer) map[*stats.Metric]float64 {
+	counts := map[*stats.Metric]float64{}
+	for _, sc := range stats.GetBufferedSamples(samples) {
+		for _, s := range sc.GetSamples() {
+			if s.Metric.Type == stats.Counter {
+				counts[s.Metric] += s.Value
+			} else {
+				counts[s.Metric]++
+			}
+		}
+	}
+	return counts
+}
+
+func TestTCP(t *testing.T) {
+	t.Parallel()
+	tb, samples, rt := newRuntime(t)
+	defer tb.Cleanup()
+	sr := tb.Replacer.Replace
+
+	request := sr("GET /get HTTP/1.1\r\nHost: HTTPBIN_DOMAIN\r\nConnection: close\r\n\r\n")
+	_, err := rt.RunString(sr(`
+	var socket = net.open("tcp", "HTTPBIN_DOMAIN:HTTPBIN_PORT", { timeout: "5s" });
+	if (socket.remoteAddr() != "HTTPBIN_IP:HTTPBIN_PORT") { throw new Error("wrong remote address: " + socket.remoteAddr()); }
+	var request = "GET /get HTTP/1.1\r\nHost: HTTPBIN_DOMAIN\r\nConnection: close\r\n\r\n";
+	var written = socket.write(request);
+	if (written != request.length) { throw new Error("wrong written size: " + written); }
+	var status = socket.read({ delimiter: "\r\n", timeout: "5s" });
+	if (status != "HTTP/1.1 200 OK\r\n") { throw new Error("wrong status line: " + status); }
+	var header = socket.read({ size: 4, type: "binary" });
+	if (header.length != 4 || typeof header[0] != "number") { throw new Error("wrong binary data: " + header); }
+	socket.close();
+	socket.close();
+	`))
+	require.NoError(t, err)
+
+	sampleContainers := stats.GetBufferedSamples(samples)
+	var sent, received float64
+	seenConnecting := false
+	for _, sc := range sampleContainers {
+		for _, s := range sc.GetSamples() {
+			tags := s.Tags.CloneTags()
+			assert.Equal(t, "tcp", tags["proto"])
+			assert.Equal(t, sr("HTTPBIN_DOMAIN:HTTPBIN_PORT"), tags["name"])
+			assert.Equal(t, sr("HTTPBIN_IP"), tags["ip"])
+			switch s.Metric {
+			case metrics.NetConnecting:
+				seenConnecting = true
+			case metrics.NetBytesSent:
+				sent += s.Value
+			case metrics.NetBytesReceived:
+				received += s.Value
+			case metrics.NetErrors:
+				t.Errorf("unexpected error sample: %v", tags)
+			}
+		}
+	}
+	assert.True(t, seenConnecting)
+	assert.Equal(t, float64(len(request)), sent)
+	assert.Equal(t, float64(len("HTTP/1.1 200 OK\r\n")+4), received)
+}
+
+func TestTLS(t *testing.T) {
+	t.Parallel()
+	tb, samples, rt := newRuntime(t)
+	defer tb.Cleanup()
+	sr := tb.Replacer.Replace
+
+	_, err := rt.RunString(sr(`
+	var socket = net.open("tls", "HTTPSBIN_DOMAIN:HTTPSBIN_PORT");
+	socket.write("GET /get HTTP/1.1\r\nHost: HTTPSBIN_DOMAIN\r\nConnection: close\r\n\r\n");
+	var status = socket.read({ delimiter: "\r\n" });
+	socket.close();
+	if (status != "HTTP/1.1 200 OK\r\n") { throw new Error("wrong status line: " + status); }
+	`))
+	require.NoError(t, err)
+
+	counts := metricCounts(samples)
+	assert.Equal(t, float64(1), counts[metrics.NetConnecting])
+	assert.Equal(t, float64(1), counts[metrics.NetTLSHandshaking])
+	assert.Zero(t, counts[metrics.NetErrors])
+}
+
+func TestUDP(t *testing.T) {
+	t.Parallel()
+	tb, samples, rt := newRuntime(t)
+	defer tb.Cleanup()
+
+	server, err := gonet.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = server.Close() }()
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, addr, err := server.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = server.WriteTo(buf[:n], addr)
+		}
+	}()
+
+	rt.Set("addr", server.LocalAddr().String())
+	_, err = rt.RunString(`
+	var socket = net.open("udp", addr);
+	socket.write("foo");
+	socket.write("bar");
+	var first = socket.read({ timeout: "5s" }), second = socket.read({ timeout: "5s" });
+	socket.close();
+	if (first != "foo" || second != "bar") { throw new Error("wrong datagrams: " + first + ", " + second); }
+	`)
+	require.NoError(t, err)
+
+	counts := metricCounts(samples)
+	assert.Equal(t, float64(6), counts[metrics.NetBytesSent])
+	assert.Equal(t, float64(6), counts[metrics.NetBytesReceived])
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	tb, samples, rt := newRuntime(t)
+	defer tb.Cleanup()
+	sr := tb.Replacer.Replace
+
+	t.Run("unsupported network", func(t *testing.T) {
+		_, err := rt.RunString(`net.open("sctp", "127.0.0.1:1")`)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported network 'sctp'")
+	})
+
+	t.Run("connection refused", func(t *testing.T) {
+		_, err := rt.RunString(`net.open("tcp", "127.0.0.1:1")`)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "connection refused")
+		assert.Equal(t, float64(1), metricCounts(samples)[metrics.NetErrors])
+	})
+
+	t.Run("read timeout", func(t *testing.T) {
+		_, err := rt.RunString(sr(`
+		var socket = net.open("tcp", "HTTPBIN_DOMAIN:HTTPBIN_PORT");
+		try {
+			socket.read({ timeout: 50 });
+		} finally {
+			socket.close();
+		}
+		`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "i/o timeout")
+		assert.Equal(t, float64(1), metricCounts(samples)[metrics.NetErrors])
+	})
+
+	t.Run("invalid read params", func(t *testing.T) {
+		_, err := rt.RunString(sr(`
+		var socket = net.open("tcp", "HTTPBIN_DOMAIN:HTTPBIN_PORT");
+		try {
+			socket.read({ size: 1, delimiter: "\n" });
+		} finally {
+			socket.close();
+		}
+		`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "only one of size and delimiter can be specified")
+	})
+
+	t.Run("blacklisted", func(t *testing.T) {
+		ipNet, err := lib.ParseCIDR("127.0.0.0/8")
+		require.NoError(t, err)
+		tb.Dialer.Blacklist = []*lib.IPNet{ipNet}
+		defer func() { tb.Dialer.Blacklist = nil }()
+
+		_, err = rt.RunString(sr(`net.open("tcp", "HTTPBIN_DOMAIN:HTTPBIN_PORT")`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is in a blacklisted range")
+	})
+}
+
+func TestOpenInitContext(t *testing.T) {
+	t.Parallel()
+	rt := goja.New()
+	rt.SetFieldNameMapper(common.FieldNameMapper{})
+	ctx := common.WithRuntime(context.Background(), rt)
+	rt.Set("net", common.Bind(rt, New(), &ctx))
+
+	_, err := rt.RunString(`net.open("tcp", "127.0.0.1:1")`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrNetInInitContext.Error())
+}
@@ -0,0 +1,418 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package oauth2
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+
+	"github.com/loadimpact/k6/js/common"
+	"github.com/loadimpact/k6/js/internal/modules"
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/netext/httpext"
+	"github.com/loadimpact/k6/lib/types"
+)
+
+func init() {
+	modules.Register("k6/oauth2", New())
+}
+
+// The supported OAuth2 grants.
+const (
+	GrantClientCredentials = "client_credentials"
+	GrantPassword          = "password"
+	GrantRefreshToken      = "refresh_token"
+)
+
+const (
+	sharedTokenPrefix    = "k6/oauth2/token."
+	defaultRefreshBefore = 30 * time.Second
+	defaultTimeout       = 60 * time.Second
+)
+
+// ErrTokenInInitContext is returned when a token is requested in the init context
+var ErrTokenInInitContext = common.NewInitContextError("fetching OAuth2 tokens in the init context is not supported")
+
+// OAuth2 is the k6/oauth2 module.
+type OAuth2 struct{}
+
+// New returns a new OAuth2 module instance.
+func New() *OAuth2 {
+	return &OAuth2{}
+}
+
+// Config is the configuration of a Client.
+type Config struct {
+	TokenURL     string            `json:"tokenURL"`
+	ClientID     string            `json:"clientID"`
+	ClientSecret string            `json:"clientSecret"`
+	Grant        string            `json:"grant"`
+	Username     string            `json:"username"`
+	Password     string            `json:"password"`
+	RefreshToken string            `json:"refreshToken"`
+	Scopes       []string          `json:"scopes"`
+	Params       map[string]string `json:"params"`
+	// How the client credentials are sent, "header" for HTTP basic auth, or
+	// "body" for including them in the form
+	AuthStyle string `json:"authStyle"`
+	// Whether tokens are cached per "vu", or shared by all VUs of the "instance"
+	Share         string             `json:"share"`
+	RefreshBefore types.NullDuration `json:"refreshBefore"`
+	Timeout       types.NullDuration `json:"timeout"`
+	Tags          map[string]string  `json:"tags"`
+}
+
+// Validate checks the config and sets the default values.
+func (c *Config) Validate() error {
+	if c.TokenURL == "" {
+		return errors.New("the tokenURL is required")
+	}
+	if _, err := url.Parse(c.TokenURL); err != nil {
+		return fmt.Errorf("invalid tokenURL: %w", err)
+	}
+
+	switch c.Grant {
+	case "":
+		c.Grant = GrantClientCredentials
+	case GrantClientCredentials:
+	case GrantPassword:
+		if c.Username == "" {
+			return errors.New("the username is required for the password grant")
+		}
+	case GrantRefreshToken:
+		if c.RefreshToken == "" {
+			return errors.New("the refreshToken is required for the refresh_token grant")
+		}
+	default:
+		return fmt.Errorf("unsupported grant '%s', it should be one of %s, %s or %s",
+			c.Grant, GrantClientCredentials, GrantPassword, GrantRefreshToken)
+	}
+
+	switch c.AuthStyle {
+	case "":
+		c.AuthStyle = "header"
+	case "header", "body":
+	default:
+		return fmt.Errorf("invalid authStyle '%s', it should be header or body", c.AuthStyle)
+	}
+
+	switch c.Share {
+	case "":
+		c.Share = "vu"
+	case "vu", "instance":
+	default:
+		return fmt.Errorf("invalid share value '%s', it should be vu or instance", c.Share)
+	}
+
+	if !c.RefreshBefore.Valid {
+		c.RefreshBefore = types.NullDurationFrom(defaultRefreshBefore)
+	}
+	if !c.Timeout.Valid {
+		c.Timeout = types.NullDurationFrom(defaultTimeout)
+	}
+	return nil
+}
+
+// key identifies the tokens that can be shared between clients, it's a hash of
+// everything that's sent to the token endpoint, so clients with different
+// credentials never share tokens and the secrets aren't kept in the key.
+func (c *Config) key() string {
+	scopes := append([]string{}, c.Scopes...)
+	sort.Strings(scopes)
+	hash := sha256.New()
+	// json.Encoder sorts the keys of the params, so the key is deterministic
+	_ = json.NewEncoder(hash).Encode([]interface{}{
+		c.TokenURL, c.ClientID, c.ClientSecret, c.Grant, c.Username, c.Password,
+		c.RefreshToken, scopes, c.Params, c.AuthStyle,
+	})
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Token is an OAuth2 access token.
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type"`
+	RefreshToken string    `json:"refresh_token"`
+	Scope        string    `json:"scope"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+// tokenSource fetches and caches the token for a config, it's shared by all
+// clients with the same config when tokens are shared by the instance.
+type tokenSource struct {
+	mu    sync.Mutex
+	cfg   Config
+	token *Token
+}
+
+// Client fetches, caches and refreshes OAuth2 tokens.
+type Client struct {
+	source *tokenSource
+}
+
+// XClient is the Client constructor (e.g. `new oauth2.Client({...})`).
+func (*OAuth2) XClient(ctxPtr *context.Context, configV goja.Value) (interface{}, error) {
+	rt := common.GetRuntime(*ctxPtr)
+	cfg, err := parseConfig(configV)
+	if err != nil {
+		return nil, err
+	}
+
+	source := &tokenSource{cfg: cfg}
+	if cfg.Share == "instance" {
+		initEnv := common.GetInitEnv(*ctxPtr)
+		if initEnv == nil || lib.GetState(*ctxPtr) != nil {
+			return nil, errors.New("clients with tokens shared by the instance must be created in the init context")
+		}
+		value := initEnv.SharedObjects.GetOrCreateShare(sharedTokenPrefix+cfg.key(), func() interface{} {
+			return source
+		})
+		var ok bool
+		if source, ok = value.(*tokenSource); !ok {
+			return nil, errors.New("wrong type of shared object")
+		}
+	}
+
+	return common.Bind(rt, &Client{source: source}, ctxPtr), nil
+}
+
+func parseConfig(configV goja.Value) (Config, error) {
+	var cfg Config
+	if configV == nil || goja.IsUndefined(configV) || goja.IsNull(configV) {
+		return cfg, errors.New("the OAuth2 client config is required")
+	}
+	data, err := json.Marshal(configV.Export())
+	if err != nil {
+		return cfg, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return cfg, fmt.Errorf("invalid OAuth2 client config: %w", err)
+	}
+	return cfg, cfg.Validate()
+}
+
+// Token returns a valid access token, fetching a new one or refreshing the
+// cached one if it's about to expire.
+func (c *Client) Token(ctx context.Context) (string, error) {
+	token, err := c.source.get(ctx, false)
+	if err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+// Refresh fetches a new token, even if the cached one is still valid, and
+// returns it.
+func (c *Client) Refresh(ctx context.Context) (*Token, error) {
+	return c.source.get(ctx, true)
+}
+
+// Authorization returns the value of the Authorization header for a valid
+// access token.
+func (c *Client) Authorization(ctx context.Context) (string, error) {
+	token, err := c.source.get(ctx, false)
+	if err != nil {
+		return "", err
+	}
+	tokenType := token.TokenType
+	if tokenType == "" || strings.EqualFold(tokenType, "bearer") {
+		tokenType = "Bearer"
+	}
+	return tokenType + " " + token.AccessToken, nil
+}
+
+// Headers returns the headers for a valid access token, which can be passed
+// directly as the headers param of k6/http requests.
+func (c *Client) Headers(ctx context.Context) (map[string]string, error) {
+	authorization, err := c.Authorization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"Authorization": authorization}, nil
+}
+
+func (ts *tokenSource) get(ctx context.Context, force bool) (*Token, error) {
+	state := lib.GetState(ctx)
+	if state == nil {
+		return nil, ErrTokenInInitContext
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if !force && ts.token != nil &&
+		(ts.token.Expiry.IsZero() || time.Now().Add(time.Duration(ts.cfg.RefreshBefore.Duration)).Before(ts.token.Expiry)) {
+		return ts.token, nil
+	}
+
+	// Use the refresh token if there is one, falling back to the configured
+	// grant if the refresh fails
+	if ts.token != nil && ts.token.RefreshToken != "" {
+		token, err := ts.fetch(ctx, state, GrantRefreshToken, ts.token.RefreshToken)
+		if err == nil {
+			ts.token = token
+			return token, nil
+		}
+		state.Logger.WithError(err).Debug("Refreshing the OAuth2 token failed, requesting a new one")
+	}
+
+	token, err := ts.fetch(ctx, state, ts.cfg.Grant, ts.cfg.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+	ts.token = token
+	return token, nil
+}
+
+// fetch requests a token from the token endpoint with the given grant
+//
+//nolint:funlen
+func (ts *tokenSource) fetch(ctx context.Context, state *lib.State, grant, refreshToken string) (*Token, error) {
+	cfg := ts.cfg
+	form := url.Values{"grant_type": {grant}}
+	switch grant {
+	case GrantPassword:
+		form.Set("username", cfg.Username)
+		form.Set("password", cfg.Password)
+	case GrantRefreshToken:
+		form.Set("refresh_token", refreshToken)
+	}
+	if len(cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(cfg.Scopes, " "))
+	}
+	for k, v := range cfg.Params {
+		form.Set(k, v)
+	}
+	if cfg.AuthStyle == "body" {
+		form.Set("client_id", cfg.ClientID)
+		if cfg.ClientSecret != "" {
+			form.Set("client_secret", cfg.ClientSecret)
+		}
+	}
+
+	reqURL, err := httpext.NewURL(cfg.TokenURL, cfg.TokenURL)
+	if err != nil {
+		return nil, err
+	}
+	req := &http.Request{
+		Method: http.MethodPost,
+		URL:    reqURL.GetURL(),
+		Header: http.Header{
+			"Content-Type": {"application/x-www-form-urlencoded"},
+			"Accept":       {"application/json"},
+		},
+	}
+	if cfg.AuthStyle == "header" && cfg.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+	}
+
+	tags := map[string]string{"oauth2": grant}
+	for k, v := range cfg.Tags {
+		tags[k] = v
+	}
+	resp, err := httpext.MakeRequest(ctx, &httpext.ParsedHTTPRequest{
+		URL:          &reqURL,
+		Req:          req,
+		Body:         bytes.NewBufferString(form.Encode()),
+		Timeout:      time.Duration(cfg.Timeout.Duration),
+		Redirects:    state.Options.MaxRedirects,
+		ResponseType: httpext.ResponseTypeText,
+		ResponseCallback: func(status int) bool {
+			return status >= 200 && status < 400
+		},
+		Cookies: make(map[string]*httpext.HTTPRequestCookie),
+		Tags:    tags,
+		Retry:   state.Options.Retry,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching the OAuth2 token failed: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("fetching the OAuth2 token failed: %s", resp.Error)
+	}
+	return parseTokenResponse(resp.Status, resp.Body)
+}
+
+func parseTokenResponse(status int, body interface{}) (*Token, error) {
+	var data []byte
+	switch b := body.(type) {
+	case string:
+		data = []byte(b)
+	case []byte:
+		data = b
+	}
+
+	var result struct {
+		AccessToken      string      `json:"access_token"`
+		TokenType        string      `json:"token_type"`
+		RefreshToken     string      `json:"refresh_token"`
+		Scope            string      `json:"scope"`
+		ExpiresIn        json.Number `json:"expires_in"`
+		Error            string      `json:"error"`
+		ErrorDescription string      `json:"error_description"`
+	}
+	jsonErr := json.Unmarshal(data, &result)
+
+	if status != http.StatusOK {
+		if result.Error != "" {
+			return nil, fmt.Errorf("the OAuth2 token endpoint returned status %d: %s %s",
+				status, result.Error, result.ErrorDescription)
+		}
+		return nil, fmt.Errorf("the OAuth2 token endpoint returned status %d", status)
+	}
+	if jsonErr != nil {
+		return nil, fmt.Errorf("invalid OAuth2 token response: %w", jsonErr)
+	}
+	if result.AccessToken == "" {
+		return nil, errors.New("the OAuth2 token response doesn't contain an access_token")
+	}
+
+	token := &Token{
+		AccessToken:  result.AccessToken,
+		TokenType:    result.TokenType,
+		RefreshToken: result.RefreshToken,
+		Scope:        result.Scope,
+	}
+	if result.ExpiresIn != "" {
+		expiresIn, err := strconv.ParseFloat(string(result.ExpiresIn), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expires_in value in the OAuth2 token response: %w", err)
+		}
+		token.Expiry = time.Now().Add(time.Duration(expiresIn * float64(time.Second)))
+	}
+	return token, nil
+}
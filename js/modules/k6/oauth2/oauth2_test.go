@@ -0,0 +1,312 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package oauth2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dop251/goja"
+	"github.com/oxtoacart/bpool"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/loadimpact/k6/js/common"
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/testutils/httpmultibin"
+	"github.com/loadimpact/k6/stats"
+)
+
+// newRuntime returns a runtime in the init context, and a function that
+// switches it to a VU context with a new state.
+func newRuntime(
+	tb *httpmultibin.HTTPMultiBin, initEnv *common.InitEnvironment,
+) (*goja.Runtime, func() chan stats.SampleContainer) {
+	rt := goja.New()
+	rt.SetFieldNameMapper(common.FieldNameMapper{})
+
+	ctx := common.WithInitEnv(tb.Context, initEnv)
+	ctx = common.WithRuntime(ctx, rt)
+	ctxPtr := &ctx
+	rt.Set("oauth2", common.Bind(rt, New(), ctxPtr))
+
+	toVUContext := func() chan stats.SampleContainer {
+		samples := make(chan stats.SampleContainer, 1000)
+		state := &lib.State{
+			Options: lib.Options{
+				MaxRedirects: null.IntFrom(10),
+				SystemTags:   &stats.DefaultSystemTagSet,
+			},
+			Logger:    logrus.New(),
+			TLSConfig: tb.TLSClientConfig,
+			Transport: tb.HTTPTransport,
+			BPool:     bpool.NewBufferPool(1),
+			Samples:   samples,
+			Tags:      map[string]string{},
+		}
+		*ctxPtr = common.WithRuntime(lib.WithState(tb.Context, state), rt)
+		return samples
+	}
+	return rt, toVUContext
+}
+
+// tokenHandler returns a token endpoint that issues numbered tokens, and a
+// pointer to the number of issued tokens.
+func tokenHandler(t *testing.T, expiresIn int, refreshToken bool) (http.HandlerFunc, *int64) {
+	var count int64
+	return func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		user, pass, ok := r.BasicAuth()
+		if !ok {
+			user, pass = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if user != "client" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error": "invalid_client", "error_description": "bad credentials"}`))
+			return
+		}
+		n := atomic.AddInt64(&count, 1)
+		resp := map[string]interface{}{
+			"access_token": fmt.Sprintf("%s-%d", r.PostForm.Get("grant_type"), n),
+			"token_type":   "bearer",
+			"expires_in":   expiresIn,
+			"scope":        r.PostForm.Get("scope"),
+		}
+		if refreshToken {
+			resp["refresh_token"] = fmt.Sprintf("refresh-%d", n)
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(resp))
+	}, &count
+}
+
+func TestClientConfig(t *testing.T) {
+	t.Parallel()
+	tb := httpmultibin.NewHTTPMultiBin(t)
+	defer tb.Cleanup()
+	rt, _ := newRuntime(tb, &common.InitEnvironment{SharedObjects: common.NewSharedObjects()})
+
+	cases := map[string]struct {
+		code, err string
+	}{
+		"no config":          {`new oauth2.Client()`, "the OAuth2 client config is required"},
+		"no tokenURL":        {`new oauth2.Client({clientID: "client"})`, "the tokenURL is required"},
+		"unknown field":      {`new oauth2.Client({tokenURL: "http://a", foo: 1})`, "unknown field"},
+		"unknown grant":      {`new oauth2.Client({tokenURL: "http://a", grant: "implicit"})`, "unsupported grant 'implicit'"},
+		"password no user":   {`new oauth2.Client({tokenURL: "http://a", grant: "password"})`, "the username is required"},
+		"refresh no token":   {`new oauth2.Client({tokenURL: "http://a", grant: "refresh_token"})`, "the refreshToken is required"},
+		"invalid authStyle":  {`new oauth2.Client({tokenURL: "http://a", authStyle: "query"})`, "invalid authStyle 'query'"},
+		"invalid share":      {`new oauth2.Client({tokenURL: "http://a", share: "global"})`, "invalid share value 'global'"},
+		"valid":              {`new oauth2.Client({tokenURL: "http://a", refreshBefore: "1m", timeout: 5000})`, ""},
+		"valid shared":       {`new oauth2.Client({tokenURL: "http://a", share: "instance"})`, ""},
+		"token in init":      {`new oauth2.Client({tokenURL: "http://a"}).token()`, "in the init context is not supported"},
+		"valid password":     {`new oauth2.Client({tokenURL: "http://a", grant: "password", username: "u"})`, ""},
+		"valid refresh only": {`new oauth2.Client({tokenURL: "http://a", grant: "refresh_token", refreshToken: "r"})`, ""},
+	}
+
+	for name, testCase := range cases {
+		name, testCase := name, testCase
+		t.Run(name, func(t *testing.T) {
+			_, err := rt.RunString(testCase.code)
+			if testCase.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), testCase.err)
+		})
+	}
+}
+
+func TestClientCredentials(t *testing.T) {
+	t.Parallel()
+	tb := httpmultibin.NewHTTPMultiBin(t)
+	defer tb.Cleanup()
+	rt, toVUContext := newRuntime(tb, &common.InitEnvironment{SharedObjects: common.NewSharedObjects()})
+	sr := tb.Replacer.Replace
+
+	handler, count := tokenHandler(t, 3600, false)
+	tb.Mux.HandleFunc("/oauth/token", handler)
+
+	_, err := rt.RunString(sr(`
+	var header = new oauth2.Client({
+		tokenURL: "HTTPBIN_URL/oauth/token", clientID: "client", clientSecret: "secret",
+		scopes: ["read", "write"], tags: {tag: "value"},
+	});
+	var body = new oauth2.Client({
+		tokenURL: "HTTPBIN_URL/oauth/token", clientID: "client", clientSecret: "secret", authStyle: "body",
+	});
+	`))
+	require.NoError(t, err)
+
+	samples := toVUContext()
+	_, err = rt.RunString(`
+	var token = header.token();
+	if (token !== "client_credentials-1") { throw new Error("wrong token: " + token); }
+	if (header.token() !== token) { throw new Error("the token wasn't cached"); }
+	var auth = header.authorization();
+	if (auth !== "Bearer client_credentials-1") { throw new Error("wrong authorization: " + auth); }
+	var headers = header.headers();
+	if (headers.Authorization !== auth) { throw new Error("wrong headers: " + JSON.stringify(headers)); }
+	if (body.token() !== "client_credentials-2") { throw new Error("wrong body auth token"); }
+	var refreshed = header.refresh();
+	if (refreshed.access_token !== "client_credentials-3") { throw new Error("wrong refreshed token: " + JSON.stringify(refreshed)); }
+	if (refreshed.scope !== "read write") { throw new Error("wrong scope: " + refreshed.scope); }
+	if (header.token() !== "client_credentials-3") { throw new Error("the refreshed token wasn't cached"); }
+	`)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, atomic.LoadInt64(count))
+
+	reqs := 0
+	for _, sc := range stats.GetBufferedSamples(samples) {
+		for _, s := range sc.GetSamples() {
+			if s.Metric.Name != "http_reqs" {
+				continue
+			}
+			reqs++
+			tags := s.Tags.CloneTags()
+			assert.Equal(t, "client_credentials", tags["oauth2"])
+			assert.Equal(t, "POST", tags["method"])
+			if reqs != 2 {
+				assert.Equal(t, "value", tags["tag"])
+			}
+		}
+	}
+	assert.Equal(t, 3, reqs)
+}
+
+func TestRefreshBeforeExpiry(t *testing.T) {
+	t.Parallel()
+	tb := httpmultibin.NewHTTPMultiBin(t)
+	defer tb.Cleanup()
+	rt, toVUContext := newRuntime(tb, &common.InitEnvironment{SharedObjects: common.NewSharedObjects()})
+	sr := tb.Replacer.Replace
+
+	handler, count := tokenHandler(t, 10, true)
+	tb.Mux.HandleFunc("/oauth/token", handler)
+
+	_, err := rt.RunString(sr(`
+	var client = new oauth2.Client({
+		tokenURL: "HTTPBIN_URL/oauth/token", clientID: "client", clientSecret: "secret",
+		grant: "password", username: "user", password: "pass", refreshBefore: "30s",
+	});
+	`))
+	require.NoError(t, err)
+
+	toVUContext()
+	_, err = rt.RunString(`
+	var first = client.token();
+	if (first !== "password-1") { throw new Error("wrong first token: " + first); }
+	var second = client.token();
+	if (second !== "refresh_token-2") { throw new Error("the token wasn't refreshed: " + second); }
+	`)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt64(count))
+}
+
+func TestSharedTokens(t *testing.T) {
+	t.Parallel()
+	tb := httpmultibin.NewHTTPMultiBin(t)
+	defer tb.Cleanup()
+
+	handler, count := tokenHandler(t, 3600, false)
+	tb.Mux.HandleFunc("/oauth/token", handler)
+
+	// the first two VUs share the token, the last one gets its own
+	initEnv := &common.InitEnvironment{SharedObjects: common.NewSharedObjects()}
+	for _, share := range []string{"instance", "instance", "vu"} {
+		rt, toVUContext := newRuntime(tb, initEnv)
+		_, err := rt.RunString(tb.Replacer.Replace(fmt.Sprintf(`
+		var client = new oauth2.Client({
+			tokenURL: "HTTPBIN_URL/oauth/token", clientID: "client", clientSecret: "secret", share: "%s",
+		});
+		`, share)))
+		require.NoError(t, err)
+		toVUContext()
+		_, err = rt.RunString(`client.token()`)
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 2, atomic.LoadInt64(count))
+}
+
+func TestConfigKey(t *testing.T) {
+	t.Parallel()
+	base := Config{
+		TokenURL: "http://a", ClientID: "client", ClientSecret: "secret", Grant: GrantPassword,
+		Username: "user", Password: "pass", Scopes: []string{"read", "write"},
+		Params: map[string]string{"audience": "api", "resource": "x"},
+	}
+	same := base
+	same.Scopes = []string{"write", "read"}
+	same.Params = map[string]string{"resource": "x", "audience": "api"}
+	assert.Equal(t, base.key(), same.key())
+	assert.NotContains(t, base.key(), "secret")
+	assert.NotContains(t, base.key(), "pass")
+
+	for name, change := range map[string]func(*Config){
+		"clientSecret": func(c *Config) { c.ClientSecret = "other" },
+		"password":     func(c *Config) { c.Password = "other" },
+		"params":       func(c *Config) { c.Params = map[string]string{"audience": "other", "resource": "x"} },
+		"authStyle":    func(c *Config) { c.AuthStyle = "body" },
+	} {
+		other := base
+		change(&other)
+		assert.NotEqual(t, base.key(), other.key(), name)
+	}
+}
+
+func TestTokenErrors(t *testing.T) {
+	t.Parallel()
+	tb := httpmultibin.NewHTTPMultiBin(t)
+	defer tb.Cleanup()
+	rt, toVUContext := newRuntime(tb, &common.InitEnvironment{SharedObjects: common.NewSharedObjects()})
+	sr := tb.Replacer.Replace
+
+	handler, _ := tokenHandler(t, 3600, false)
+	tb.Mux.HandleFunc("/oauth/token", handler)
+	tb.Mux.HandleFunc("/oauth/empty", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"token_type": "bearer"}`))
+	})
+
+	_, err := rt.RunString(sr(`
+	var badSecret = new oauth2.Client({tokenURL: "HTTPBIN_URL/oauth/token", clientID: "client", clientSecret: "wrong"});
+	var noToken = new oauth2.Client({tokenURL: "HTTPBIN_URL/oauth/empty", clientID: "client"});
+	var notAllowed = new oauth2.Client({tokenURL: "HTTPBIN_URL/status/405", clientID: "client"});
+	`))
+	require.NoError(t, err)
+	toVUContext()
+
+	cases := map[string]string{
+		`badSecret.token()`:  "returned status 401: invalid_client bad credentials",
+		`noToken.token()`:    "doesn't contain an access_token",
+		`notAllowed.token()`: "returned status 405",
+	}
+	for code, expErr := range cases {
+		_, err := rt.RunString(code)
+		require.Error(t, err, code)
+		assert.Contains(t, err.Error(), expErr, code)
+	}
+}
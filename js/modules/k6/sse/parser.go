@@ -0,0 +1,155 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package sse
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Event is a single event received from an event stream.
+type Event struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data string `json:"data"`
+}
+
+// eventReader parses a text/event-stream body as described in
+// https://html.spec.whatwg.org/multipage/server-sent-events.html#event-stream-interpretation
+type eventReader struct {
+	scanner *bufio.Scanner
+	started bool
+
+	// the last event ID persists across events, and reconnections
+	lastEventID string
+	// the reconnection time in milliseconds, if set by the server
+	retry int64
+}
+
+func newEventReader(r io.Reader, lastEventID string) *eventReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 4096), 1<<24)
+	scanner.Split(scanLines)
+	return &eventReader{scanner: scanner, lastEventID: lastEventID, retry: -1}
+}
+
+// scanLines is a bufio.SplitFunc that splits on CRLF, LF or CR.
+func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		// A CR could be followed by a LF in the next chunk
+		if i+1 == len(data) && !atEOF {
+			return 0, nil, nil
+		}
+		if i+1 < len(data) && data[i+1] == '\n' {
+			return i + 2, data[:i], nil
+		}
+		return i + 1, data[:i], nil
+	}
+	// An incomplete line at the end of the stream is dropped, same as an
+	// incomplete event
+	if atEOF {
+		return len(data), nil, nil
+	}
+	return 0, nil, nil
+}
+
+// Next blocks until a complete event is received and returns it. It returns
+// io.EOF if the stream ends before that.
+func (r *eventReader) Next() (*Event, error) {
+	var (
+		eventType string
+		data      strings.Builder
+		hasData   bool
+	)
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+		if !r.started {
+			line = strings.TrimPrefix(line, "\ufeff")
+			r.started = true
+		}
+
+		if line == "" {
+			if !hasData {
+				eventType = ""
+				continue
+			}
+			if eventType == "" {
+				eventType = "message"
+			}
+			return &Event{
+				ID:   r.lastEventID,
+				Type: eventType,
+				Data: strings.TrimSuffix(data.String(), "\n"),
+			}, nil
+		}
+		if line[0] == ':' {
+			continue // a comment
+		}
+
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "event":
+			eventType = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+			hasData = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				r.lastEventID = value
+			}
+		case "retry":
+			if isDigits(value) {
+				if retry, err := strconv.ParseInt(value, 10, 64); err == nil {
+					r.retry = retry
+				}
+			}
+		}
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package sse
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readEvents(t *testing.T, r *eventReader) []Event {
+	var events []Event
+	for {
+		event, err := r.Next()
+		if err == io.EOF {
+			return events
+		}
+		require.NoError(t, err)
+		events = append(events, *event)
+	}
+}
+
+func TestEventReader(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name, stream string
+		expected     []Event
+	}{
+		{"empty", "", nil},
+		{"simple", "data: foo\n\n", []Event{{Type: "message", Data: "foo"}}},
+		{"bom", "\ufeffdata: foo\n\n", []Event{{Type: "message", Data: "foo"}}},
+		{"multiline", "data: foo\ndata\ndata:bar\n\n", []Event{{Type: "message", Data: "foo\n\nbar"}}},
+		{"crlf", "data: foo\r\n\r\ndata: bar\r\rdata: baz\n\n", []Event{
+			{Type: "message", Data: "foo"}, {Type: "message", Data: "bar"}, {Type: "message", Data: "baz"},
+		}},
+		{"named", "event: update\ndata: foo\n\ndata: bar\n\n", []Event{
+			{Type: "update", Data: "foo"}, {Type: "message", Data: "bar"},
+		}},
+		{"ids", "id: 1\ndata: foo\n\ndata: bar\n\nid\ndata: baz\n\n", []Event{
+			{ID: "1", Type: "message", Data: "foo"}, {ID: "1", Type: "message", Data: "bar"},
+			{Type: "message", Data: "baz"},
+		}},
+		{"comments and unknown fields", ": ping\nfoo: bar\ndata: foo\n\n", []Event{{Type: "message", Data: "foo"}}},
+		{"no data", "event: update\n\nid: 2\n\ndata: foo\n\n", []Event{{ID: "2", Type: "message", Data: "foo"}}},
+		{"incomplete", "data: foo\n\ndata: bar\n", []Event{{Type: "message", Data: "foo"}}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, readEvents(t, newEventReader(strings.NewReader(tc.stream), "")))
+		})
+	}
+
+	t.Run("retry", func(t *testing.T) {
+		t.Parallel()
+		r := newEventReader(strings.NewReader("retry: 1500\ndata: foo\n\nretry: 1s\n\n"), "5")
+		assert.Equal(t, []Event{{ID: "5", Type: "message", Data: "foo"}}, readEvents(t, r))
+		assert.Equal(t, int64(1500), r.retry)
+		assert.Equal(t, "5", r.lastEventID)
+	})
+}
@@ -0,0 +1,502 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package sse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+
+	"github.com/loadimpact/k6/js/common"
+	"github.com/loadimpact/k6/js/internal/modules"
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/metrics"
+	"github.com/loadimpact/k6/lib/netext/httpext"
+	"github.com/loadimpact/k6/stats"
+)
+
+func init() {
+	modules.Register("k6/sse", New())
+}
+
+// ErrSSEInInitContext is returned when server-sent events are used in the init context
+var ErrSSEInInitContext = common.NewInitContextError("using server-sent events in the init context is not supported")
+
+// defaultRetry is the reconnection time used until the server specifies one
+const defaultRetry = 3 * time.Second
+
+// SSE is the k6/sse module.
+type SSE struct{}
+
+// New returns a new SSE module instance.
+func New() *SSE {
+	return &SSE{}
+}
+
+// Client is an event stream client, passed to the setup function of Open.
+type Client struct {
+	ctx           context.Context
+	eventHandlers map[string][]goja.Callable
+	scheduled     chan goja.Callable
+	done          chan struct{}
+	shutdownOnce  sync.Once
+	cancel        context.CancelFunc
+
+	responseLock sync.Mutex
+	response     *Response
+
+	sampleTags    *stats.SampleTags
+	samplesOutput chan<- stats.SampleContainer
+}
+
+// Response describes the HTTP response of the first connection to the event
+// stream. It's passed to the open event handlers and returned by Open.
+type Response struct {
+	URL     string            `json:"url"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers"`
+	Error   string            `json:"error"`
+}
+
+// request holds the parameters for connecting to the event stream
+type request struct {
+	url       string
+	method    string
+	body      string
+	header    http.Header
+	tags      map[string]string
+	reconnect bool
+}
+
+// Open connects to the event stream at the given URL and blocks until the
+// client is closed. Events are dispatched to the handlers registered for their
+// type, which is "message" unless the server specifies one. When the stream
+// ends or the connection fails, the client reconnects after the reconnection
+// time with the ID of the last received event in the Last-Event-ID header.
+//
+//nolint:funlen,gocognit,gocyclo
+func (*SSE) Open(ctx context.Context, url string, args ...goja.Value) (*Response, error) {
+	rt := common.GetRuntime(ctx)
+	state := lib.GetState(ctx)
+	if state == nil {
+		return nil, ErrSSEInInitContext
+	}
+
+	// The params argument is optional
+	var callableV, paramsV goja.Value
+	switch len(args) {
+	case 2:
+		paramsV = args[0]
+		callableV = args[1]
+	case 1:
+		paramsV = goja.Undefined()
+		callableV = args[0]
+	default:
+		return nil, errors.New("invalid number of arguments to sse.open")
+	}
+
+	setupFn, isFunc := goja.AssertFunction(callableV)
+	if !isFunc {
+		return nil, errors.New("last argument to sse.open must be a function")
+	}
+
+	req := request{
+		url:       url,
+		method:    http.MethodGet,
+		header:    http.Header{},
+		tags:      state.CloneTags(),
+		reconnect: true,
+	}
+	if state.Options.UserAgent.Valid {
+		req.header.Set("User-Agent", state.Options.UserAgent.String)
+	}
+
+	if !goja.IsUndefined(paramsV) && !goja.IsNull(paramsV) {
+		params := paramsV.ToObject(rt)
+		for _, k := range params.Keys() {
+			v := params.Get(k)
+			if goja.IsUndefined(v) || goja.IsNull(v) {
+				continue
+			}
+			switch k {
+			case "method":
+				req.method = strings.ToUpper(v.String())
+			case "body":
+				req.body = v.String()
+			case "reconnect":
+				req.reconnect = v.ToBoolean()
+			case "headers":
+				headersObj := v.ToObject(rt)
+				for _, key := range headersObj.Keys() {
+					req.header.Set(key, headersObj.Get(key).String())
+				}
+			case "tags":
+				tagObj := v.ToObject(rt)
+				for _, key := range tagObj.Keys() {
+					req.tags[key] = tagObj.Get(key).String()
+				}
+			default:
+				return nil, fmt.Errorf("unknown sse.open param '%s'", k)
+			}
+		}
+	}
+
+	sampleTags := make(map[string]string, len(req.tags)+1)
+	for k, v := range req.tags {
+		sampleTags[k] = v
+	}
+	if state.Options.SystemTags.Has(stats.TagURL) {
+		sampleTags["url"] = url
+	}
+
+	connCtx, cancel := context.WithCancel(ctx)
+	client := &Client{
+		ctx:           ctx,
+		eventHandlers: make(map[string][]goja.Callable),
+		scheduled:     make(chan goja.Callable),
+		done:          make(chan struct{}),
+		cancel:        cancel,
+		sampleTags:    stats.IntoSampleTags(&sampleTags),
+		samplesOutput: state.Samples,
+	}
+	defer cancel()
+
+	var ended chan struct{}
+	start := time.Now()
+	defer func() {
+		client.Close() // just in case
+		if ended != nil {
+			// Wait for the last request to finish and emit its metrics
+			<-ended
+		}
+		stats.PushIfNotDone(ctx, state.Samples, stats.Sample{
+			Metric: metrics.SSESessionDuration,
+			Tags:   client.sampleTags,
+			Time:   start,
+			Value:  stats.D(time.Since(start)),
+		})
+	}()
+
+	// Run the user-provided set up function, which registers the handlers
+	if _, err := setupFn(goja.Undefined(), rt.ToValue(client)); err != nil {
+		return nil, err
+	}
+
+	openChan := make(chan *Response)
+	eventChan := make(chan *Event)
+	errChan := make(chan error)
+	ended = make(chan struct{})
+	go func() {
+		defer close(ended)
+		client.readLoop(ctx, connCtx, state, req, openChan, eventChan, errChan)
+	}()
+	readLoopEnded := ended
+
+	receivedFirst := false
+	// This is the main control loop. All JS code (including error handlers)
+	// should only be executed by this thread to avoid race conditions
+	for {
+		select {
+		case resp := <-openChan:
+			client.handleEvent("open", rt.ToValue(resp))
+
+		case event := <-eventChan:
+			now := time.Now()
+			samples := []stats.Sample{
+				{Metric: metrics.SSEEventsReceived, Time: now, Tags: client.sampleTags, Value: 1},
+			}
+			if !receivedFirst {
+				receivedFirst = true
+				samples = append(samples, stats.Sample{
+					Metric: metrics.SSETimeToFirstEvent, Time: now, Tags: client.sampleTags,
+					Value: stats.D(now.Sub(start)),
+				})
+			}
+			stats.PushIfNotDone(ctx, state.Samples, stats.ConnectedSamples{
+				Samples: samples, Tags: client.sampleTags, Time: now,
+			})
+			client.handleEvent(event.Type, rt.ToValue(event))
+
+		case err := <-errChan:
+			client.handleEvent("error", rt.ToValue(err))
+
+		case <-readLoopEnded:
+			// The stream was closed by the server and won't be reconnected
+			client.Close()
+			readLoopEnded = nil
+
+		case scheduledFn := <-client.scheduled:
+			if _, err := scheduledFn(goja.Undefined()); err != nil {
+				client.Close()
+				return nil, err
+			}
+
+		case <-ctx.Done():
+			// VU is shutting down during an interrupt
+			client.Close()
+
+		case <-client.done:
+			// This is the final exit point normally triggered by Close
+			return client.getResponse(url), nil
+		}
+	}
+}
+
+// readLoop connects to the event stream and reads events from it, reconnecting
+// if needed, until the connection context is done. The metrics are emitted with
+// the VU context. It never calls into the JS runtime.
+//
+//nolint:funlen,gocognit
+func (c *Client) readLoop(
+	vuCtx, ctx context.Context, state *lib.State, req request,
+	openChan chan<- *Response, eventChan chan<- *Event, errChan chan<- error,
+) {
+	sendErr := func(err error) bool {
+		select {
+		case errChan <- err:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	lastEventID := ""
+	retry := defaultRetry
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			if !req.reconnect {
+				return
+			}
+			select {
+			case <-time.After(retry):
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		var body io.Reader
+		if req.body != "" {
+			body = strings.NewReader(req.body)
+		}
+		httpReq, err := http.NewRequestWithContext(ctx, req.method, req.url, body)
+		if err != nil {
+			c.setResponse(&Response{URL: req.url, Error: err.Error()})
+			sendErr(err)
+			return
+		}
+		httpReq.Header = req.header.Clone()
+		httpReq.Header.Set("Accept", "text/event-stream")
+		httpReq.Header.Set("Cache-Control", "no-cache")
+		if lastEventID != "" {
+			httpReq.Header.Set("Last-Event-ID", lastEventID)
+		}
+
+		res, err := httpext.OpenStream(vuCtx, state, httpReq, req.tags)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.setResponse(&Response{URL: req.url, Error: err.Error()})
+			if !sendErr(err) {
+				return
+			}
+			continue
+		}
+
+		resp := wrapHTTPResponse(res.Response)
+		// Any other response fails the connection without reconnecting
+		if res.StatusCode != http.StatusOK {
+			res.Finish(nil)
+			resp.Error = fmt.Sprintf("unexpected response status %s", res.Status)
+			c.setResponse(resp)
+			sendErr(errors.New(resp.Error))
+			return
+		}
+		if mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type")); mediaType != "text/event-stream" {
+			res.Finish(nil)
+			resp.Error = fmt.Sprintf("unexpected response content type '%s'", res.Header.Get("Content-Type"))
+			c.setResponse(resp)
+			sendErr(errors.New(resp.Error))
+			return
+		}
+
+		c.setResponse(resp)
+		select {
+		case openChan <- resp:
+		case <-ctx.Done():
+			res.Finish(nil)
+			return
+		}
+
+		reader := newEventReader(res.Body, lastEventID)
+		var readErr error
+		for {
+			event, err := reader.Next()
+			lastEventID = reader.lastEventID
+			if reader.retry >= 0 {
+				retry = time.Duration(reader.retry) * time.Millisecond
+			}
+			if err != nil {
+				readErr = err
+				break
+			}
+			select {
+			case eventChan <- event:
+			case <-ctx.Done():
+			}
+		}
+
+		if ctx.Err() != nil {
+			// The client was closed, this isn't a request error
+			res.Finish(nil)
+			return
+		}
+		if errors.Is(readErr, io.EOF) {
+			readErr = nil
+		}
+		res.Finish(readErr)
+		if readErr != nil && !sendErr(readErr) {
+			return
+		}
+	}
+}
+
+func (c *Client) setResponse(resp *Response) {
+	c.responseLock.Lock()
+	defer c.responseLock.Unlock()
+	if c.response == nil {
+		c.response = resp
+	}
+}
+
+func (c *Client) getResponse(url string) *Response {
+	c.responseLock.Lock()
+	defer c.responseLock.Unlock()
+	if c.response == nil {
+		return &Response{URL: url}
+	}
+	return c.response
+}
+
+// On registers a handler for the given event type, or for the open, error and
+// close client events.
+func (c *Client) On(event string, handler goja.Value) {
+	if handler, ok := goja.AssertFunction(handler); ok {
+		c.eventHandlers[event] = append(c.eventHandlers[event], handler)
+	}
+}
+
+func (c *Client) handleEvent(event string, args ...goja.Value) {
+	if handlers, ok := c.eventHandlers[event]; ok {
+		for _, handler := range handlers {
+			if _, err := handler(goja.Undefined(), args...); err != nil {
+				common.Throw(common.GetRuntime(c.ctx), err)
+			}
+		}
+	}
+}
+
+// SetTimeout executes the provided function inside the client's event loop after at least the provided
+// timeout, which is in ms, has elapsed
+func (c *Client) SetTimeout(fn goja.Callable, timeoutMs float64) error {
+	d := time.Duration(timeoutMs * float64(time.Millisecond))
+	if d <= 0 {
+		return fmt.Errorf("setTimeout requires a >0 timeout parameter, received %.2f", timeoutMs)
+	}
+	go func() {
+		select {
+		case <-time.After(d):
+			select {
+			case c.scheduled <- fn:
+			case <-c.done:
+				return
+			}
+
+		case <-c.done:
+			return
+		}
+	}()
+
+	return nil
+}
+
+// SetInterval executes the provided function inside the client's event loop each interval time, which is
+// in ms
+func (c *Client) SetInterval(fn goja.Callable, intervalMs float64) error {
+	d := time.Duration(intervalMs * float64(time.Millisecond))
+	if d <= 0 {
+		return fmt.Errorf("setInterval requires a >0 timeout parameter, received %.2f", intervalMs)
+	}
+	go func() {
+		ticker := time.NewTicker(d)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				select {
+				case c.scheduled <- fn:
+				case <-c.done:
+					return
+				}
+
+			case <-c.done:
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Close closes the event stream and stops any further reconnections.
+func (c *Client) Close() {
+	c.shutdownOnce.Do(func() {
+		// handleEvent can panic on purpose, so make sure that the
+		// connection and the main control loop are always stopped
+		defer func() {
+			c.cancel()
+			close(c.done)
+		}()
+		c.handleEvent("close")
+	})
+}
+
+// Wrap the raw HTTP response to a Response we can pass to the user
+func wrapHTTPResponse(httpResponse *http.Response) *Response {
+	resp := &Response{
+		URL:     httpResponse.Request.URL.String(),
+		Status:  httpResponse.StatusCode,
+		Headers: make(map[string]string, len(httpResponse.Header)),
+	}
+	for k, vs := range httpResponse.Header {
+		resp.Headers[k] = strings.Join(vs, ", ")
+	}
+	return resp
+}
@@ -0,0 +1,251 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package sse
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/dop251/goja"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/loadimpact/k6/js/common"
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/metrics"
+	"github.com/loadimpact/k6/lib/testutils"
+	"github.com/loadimpact/k6/lib/testutils/httpmultibin"
+	"github.com/loadimpact/k6/stats"
+)
+
+func newRuntime(t *testing.T) (*httpmultibin.HTTPMultiBin, chan stats.SampleContainer, *goja.Runtime) {
+	tb := httpmultibin.NewHTTPMultiBin(t)
+
+	root, err := lib.NewGroup("", nil)
+	require.NoError(t, err)
+
+	logger := logrus.New()
+	logger.Level = logrus.DebugLevel
+	logger.Out = testutils.NewTestOutput(t)
+
+	rt := goja.New()
+	rt.SetFieldNameMapper(common.FieldNameMapper{})
+	samples := make(chan stats.SampleContainer, 1000)
+	state := &lib.State{
+		Group:  root,
+		Logger: logger,
+		Options: lib.Options{
+			MaxRedirects: null.IntFrom(10),
+			SystemTags:   stats.NewSystemTagSet(stats.TagURL, stats.TagStatus, stats.TagMethod),
+		},
+		Transport: tb.HTTPTransport,
+		TLSConfig: tb.TLSClientConfig,
+		Samples:   samples,
+		Tags:      map[string]string{"group": root.Path},
+	}
+
+	ctx := lib.WithState(tb.Context, state)
+	ctx = common.WithRuntime(ctx, rt)
+	rt.Set("sse", common.Bind(rt, New(), &ctx))
+
+	return tb, samples, rt
+}
+
+func streamEvents(w http.ResponseWriter, events ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+	for _, event := range events {
+		_, _ = fmt.Fprint(w, event)
+	}
+	w.(http.Flusher).Flush()
+}
+
+func TestOpen(t *testing.T) {
+	t.Parallel()
+	tb, samples, rt := newRuntime(t)
+	defer tb.Cleanup()
+	sr := tb.Replacer.Replace
+
+	tb.Mux.HandleFunc("/sse", func(w http.ResponseWriter, r *http.Request) {
+		streamEvents(w, ": hello\n\n", "data: foo\n\n", "event: update\nid: 1\ndata: bar\ndata: baz\n\n")
+		<-r.Context().Done()
+	})
+
+	_, err := rt.RunString(sr(`
+	var events = [];
+	var opened = 0, closed = 0;
+	var res = sse.open("HTTPBIN_URL/sse", { tags: { tag: "value" } }, function(client) {
+		client.on("open", function(r) {
+			opened++;
+			if (r.status != 200) { throw new Error("unexpected open status: " + r.status); }
+		});
+		client.on("message", function(e) { events.push(e.type + ":" + e.data); });
+		client.on("update", function(e) {
+			events.push(e.type + ":" + e.id + ":" + e.data);
+			client.close();
+		});
+		client.on("close", function() { closed++; });
+	});
+	if (res.status != 200) { throw new Error("unexpected status: " + res.status); }
+	if (opened != 1 || closed != 1) { throw new Error("unexpected open/close events: " + opened + "/" + closed); }
+	if (events.join("|") != "message:foo|update:1:bar\nbaz") { throw new Error("unexpected events: " + events.join("|")); }
+	`))
+	require.NoError(t, err)
+
+	counts := map[*stats.Metric]int{}
+	for _, sample := range stats.GetBufferedSamples(samples) {
+		for _, s := range sample.GetSamples() {
+			counts[s.Metric]++
+			if s.Metric == metrics.SSEEventsReceived {
+				tags := s.Tags.CloneTags()
+				assert.Equal(t, sr("HTTPBIN_URL/sse"), tags["url"])
+				assert.Equal(t, "value", tags["tag"])
+			}
+		}
+	}
+	assert.Equal(t, 2, counts[metrics.SSEEventsReceived])
+	assert.Equal(t, 1, counts[metrics.SSETimeToFirstEvent])
+	assert.Equal(t, 1, counts[metrics.SSESessionDuration])
+	assert.Equal(t, 1, counts[metrics.HTTPReqs])
+}
+
+func TestOpenReconnect(t *testing.T) {
+	t.Parallel()
+	tb, samples, rt := newRuntime(t)
+	defer tb.Cleanup()
+	sr := tb.Replacer.Replace
+
+	tb.Mux.HandleFunc("/sse-reconnect", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Last-Event-ID") {
+		case "":
+			streamEvents(w, "retry: 10\nid: 1\ndata: first\n\n")
+		case "1":
+			streamEvents(w, "data: second\n\n")
+			<-r.Context().Done()
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+
+	_, err := rt.RunString(sr(`
+	var events = [], opened = 0;
+	sse.open("HTTPBIN_URL/sse-reconnect", function(client) {
+		client.on("open", function() { opened++; });
+		client.on("error", function(e) { throw new Error("unexpected error: " + e); });
+		client.on("message", function(e) {
+			events.push(e.id + ":" + e.data);
+			if (events.length == 2) { client.close(); }
+		});
+	});
+	if (opened != 2) { throw new Error("unexpected number of connections: " + opened); }
+	if (events.join("|") != "1:first|1:second") { throw new Error("unexpected events: " + events.join("|")); }
+	`))
+	require.NoError(t, err)
+
+	reqs := 0
+	for _, sample := range stats.GetBufferedSamples(samples) {
+		for _, s := range sample.GetSamples() {
+			if s.Metric == metrics.HTTPReqs {
+				reqs++
+			}
+		}
+	}
+	assert.Equal(t, 2, reqs)
+}
+
+func TestOpenErrors(t *testing.T) {
+	t.Parallel()
+	tb, _, rt := newRuntime(t)
+	defer tb.Cleanup()
+	sr := tb.Replacer.Replace
+
+	tb.Mux.HandleFunc("/not-sse", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "data: foo\n\n")
+	})
+
+	t.Run("status", func(t *testing.T) {
+		_, err := rt.RunString(sr(`
+		var errors = [];
+		var res = sse.open("HTTPBIN_URL/status/404", function(client) {
+			client.on("open", function() { throw new Error("unexpected open"); });
+			client.on("error", function(e) { errors.push(e.error()); });
+		});
+		if (res.status != 404) { throw new Error("unexpected status: " + res.status); }
+		if (errors.length != 1 || errors[0] != "unexpected response status 404 Not Found") {
+			throw new Error("unexpected errors: " + errors.join("|"));
+		}
+		`))
+		assert.NoError(t, err)
+	})
+
+	t.Run("content type", func(t *testing.T) {
+		_, err := rt.RunString(sr(`
+		var errors = [];
+		var res = sse.open("HTTPBIN_URL/not-sse", function(client) {
+			client.on("error", function(e) { errors.push(e.error()); });
+		});
+		if (res.error == "" || errors.length != 1) { throw new Error("expected an error"); }
+		`))
+		assert.NoError(t, err)
+	})
+
+	t.Run("no reconnect", func(t *testing.T) {
+		_, err := rt.RunString(sr(`
+		var errors = 0;
+		var res = sse.open("http://127.0.0.1:1/sse", { reconnect: false }, function(client) {
+			client.on("error", function(e) { errors++; });
+		});
+		if (res.error == "" || errors != 1) { throw new Error("expected a connection error"); }
+		`))
+		assert.NoError(t, err)
+	})
+
+	t.Run("unknown param", func(t *testing.T) {
+		_, err := rt.RunString(sr(`sse.open("HTTPBIN_URL/sse", { foo: 1 }, function(client) {});`))
+		assert.Error(t, err)
+	})
+
+	t.Run("handler error", func(t *testing.T) {
+		_, err := rt.RunString(sr(`
+		sse.open("HTTPBIN_URL/status/500", function(client) {
+			client.on("error", function(e) { throw new Error("boom"); });
+		});
+		`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "boom")
+	})
+}
+
+func TestOpenInitContext(t *testing.T) {
+	t.Parallel()
+	rt := goja.New()
+	rt.SetFieldNameMapper(common.FieldNameMapper{})
+	ctx := common.WithRuntime(context.Background(), rt)
+	rt.Set("sse", common.Bind(rt, New(), &ctx))
+
+	_, err := rt.RunString(`sse.open("http://example.com", function() {})`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrSSEInInitContext.Error())
+}
@@ -39,13 +39,14 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
-	"golang.org/x/net/http2"
 	"golang.org/x/time/rate"
 
 	"github.com/loadimpact/k6/js/common"
 	"github.com/loadimpact/k6/lib"
 	"github.com/loadimpact/k6/lib/consts"
+	"github.com/loadimpact/k6/lib/httpcache"
 	"github.com/loadimpact/k6/lib/netext"
+	"github.com/loadimpact/k6/lib/netext/httpext"
 	"github.com/loadimpact/k6/lib/types"
 	"github.com/loadimpact/k6/loader"
 	"github.com/loadimpact/k6/stats"
@@ -112,6 +113,7 @@ func newFromBundle(logger *logrus.Logger, b *Bundle) (*Runner, error) {
 			net.LookupIP, 0, defDNS.Select.DNSSelect, defDNS.Policy.DNSPolicy),
 		ActualResolver: net.LookupIP,
 	}
+	r.console.mapPositions = b.mapPositions
 
 	err = r.SetOptions(r.Bundle.Options)
 
@@ -171,11 +173,7 @@ func (r *Runner) newVU(id int64, samplesOut chan<- stats.SampleContainer) (*VU,
 		Hosts:            r.Bundle.Options.Hosts,
 	}
 	if r.Bundle.Options.LocalIPs.Valid {
-		var ipIndex uint64
-		if id > 0 {
-			ipIndex = uint64(id - 1)
-		}
-		dialer.Dialer.LocalAddr = &net.TCPAddr{IP: r.Bundle.Options.LocalIPs.Pool.GetIP(ipIndex)}
+		dialer.Dialer.LocalAddr = &net.TCPAddr{IP: r.Bundle.Options.LocalIPs.Pool.GetIP(getPoolIndex(id))}
 	}
 
 	tlsConfig := &tls.Config{
@@ -187,16 +185,30 @@ func (r *Runner) newVU(id int64, samplesOut chan<- stats.SampleContainer) (*VU,
 		NameToCertificate:  nameToCert,
 		Renegotiation:      tls.RenegotiateFreelyAsClient,
 	}
-	transport := &http.Transport{
-		Proxy:               http.ProxyFromEnvironment,
-		TLSClientConfig:     tlsConfig,
-		DialContext:         dialer.DialContext,
-		DisableCompression:  true,
-		DisableKeepAlives:   r.Bundle.Options.NoConnectionReuse.Bool,
-		MaxIdleConns:        int(r.Bundle.Options.Batch.Int64),
-		MaxIdleConnsPerHost: int(r.Bundle.Options.BatchPerHost.Int64),
+	transportOpts := r.Bundle.Options.HTTPTransport
+	httpTransport := &http.Transport{
+		Proxy:                 httpext.ResolveProxy,
+		TLSClientConfig:       tlsConfig,
+		DialContext:           dialer.DialContext,
+		DisableCompression:    true,
+		DisableKeepAlives:     r.Bundle.Options.NoConnectionReuse.Bool,
+		MaxIdleConns:          int(r.Bundle.Options.Batch.Int64),
+		MaxIdleConnsPerHost:   int(r.Bundle.Options.BatchPerHost.Int64),
+		MaxConnsPerHost:       int(transportOpts.MaxConnsPerHost.Int64),
+		IdleConnTimeout:       time.Duration(transportOpts.IdleConnTimeout.Duration),
+		TLSHandshakeTimeout:   time.Duration(transportOpts.TLSHandshakeTimeout.Duration),
+		ResponseHeaderTimeout: time.Duration(transportOpts.ResponseHeaderTimeout.Duration),
+	}
+	if transportOpts.MaxIdleConns.Valid {
+		httpTransport.MaxIdleConns = int(transportOpts.MaxIdleConns.Int64)
+	}
+	if transportOpts.MaxIdleConnsPerHost.Valid {
+		httpTransport.MaxIdleConnsPerHost = int(transportOpts.MaxIdleConnsPerHost.Int64)
+	}
+	transport, err := httpext.ConfigureHTTP2(httpTransport, transportOpts)
+	if err != nil {
+		return nil, err
 	}
-	_ = http2.ConfigureTransport(transport)
 
 	cookieJar, err := cookiejar.New(nil)
 	if err != nil {
@@ -216,6 +228,11 @@ func (r *Runner) newVU(id int64, samplesOut chan<- stats.SampleContainer) (*VU,
 		Samples:        samplesOut,
 	}
 
+	var httpCache *httpcache.Cache
+	if vu.Runner.Bundle.Options.HTTPCache.Bool {
+		httpCache = httpcache.New(vu.Runner.Bundle.Options.HTTPCacheMaxSize.Int64)
+	}
+
 	vu.state = &lib.State{
 		Logger:    vu.Runner.Logger,
 		Options:   vu.Runner.Bundle.Options,
@@ -223,8 +240,10 @@ func (r *Runner) newVU(id int64, samplesOut chan<- stats.SampleContainer) (*VU,
 		Dialer:    vu.Dialer,
 		TLSConfig: vu.TLSConfig,
 		CookieJar: cookieJar,
+		HTTPCache: httpCache,
 		RPSLimit:  vu.Runner.RPSLimit,
 		BPool:     vu.BPool,
+		Proxy:     vu.Runner.Bundle.Options.Proxy.GetProxy(getPoolIndex(id)),
 		Vu:        vu.ID,
 		Samples:   vu.Samples,
 		Iteration: vu.Iteration,
@@ -394,6 +413,7 @@ func (r *Runner) SetOptions(opts lib.Options) error {
 			return err
 		}
 
+		c.mapPositions = r.Bundle.mapPositions
 		r.console = c
 	}
 
@@ -520,11 +540,20 @@ func (r *Runner) getTimeoutFor(stage string) time.Duration {
 	return d
 }
 
+// getPoolIndex returns the index of the VU with the given ID in pools of
+// resources like local IPs and proxies.
+func getPoolIndex(id int64) uint64 {
+	if id > 0 {
+		return uint64(id - 1)
+	}
+	return 0
+}
+
 type VU struct {
 	BundleInstance
 
 	Runner    *Runner
-	Transport *http.Transport
+	Transport httpext.Transport
 	Dialer    *netext.Dialer
 	CookieJar *cookiejar.Jar
 	TLSConfig *tls.Config
@@ -596,6 +625,20 @@ func (u *VU) Activate(params *lib.VUActivationParams) lib.ActiveVU {
 		u.state.Tags["scenario"] = params.Scenario
 	}
 
+	// The scenario proxy overrides the global one
+	proxyPool := opts.Proxy
+	if params.Proxy.Valid {
+		proxyPool = params.Proxy
+	}
+	u.state.Proxy = proxyPool.GetProxy(getPoolIndex(u.ID))
+
+	// The scenario network conditions override the global ones
+	networkConditions := opts.NetworkConditions
+	if params.NetworkConditions.Valid {
+		networkConditions = params.NetworkConditions
+	}
+	u.setNetworkConditions(networkConditions)
+
 	params.RunContext = common.WithRuntime(params.RunContext, u.Runtime)
 	params.RunContext = lib.WithState(params.RunContext, u.state)
 	*u.Context = params.RunContext
@@ -623,6 +666,21 @@ func (u *VU) Activate(params *lib.VUActivationParams) lib.ActiveVU {
 	return avu
 }
 
+// setNetworkConditions changes the network conditions that are emulated for
+// the VU connections, closing any idle connections that were established with
+// different ones.
+func (u *VU) setNetworkConditions(conditions types.NetworkConditions) {
+	if conditions != u.Dialer.GetNetworkConditions() {
+		u.Dialer.SetNetworkConditions(conditions)
+		if t, ok := u.Transport.(interface{ CloseIdleConnections() }); ok {
+			t.CloseIdleConnections()
+		}
+	}
+	if conditions.IsActive() && u.Runner.Bundle.Options.SystemTags.Has(stats.TagNetwork) {
+		u.state.Tags["network"] = conditions.Name()
+	}
+}
+
 // RunOnce runs the configured Exec function once.
 func (u *ActiveVU) RunOnce() error {
 	select {
@@ -649,10 +707,18 @@ func (u *ActiveVU) RunOnce() error {
 		}
 	}
 
-	fn, ok := u.exports[u.Exec]
+	exec := u.Exec
+	if len(u.WeightedExec) > 0 {
+		exec = u.WeightedExec.Pick(u.ExecSeed, u.ID, u.Iteration)
+		if u.Runner.Bundle.Options.SystemTags.Has(stats.TagExec) {
+			u.state.Tags["exec"] = exec
+		}
+	}
+
+	fn, ok := u.exports[exec]
 	if !ok {
 		// Shouldn't happen; this is validated in cmd.validateScenarioConfig()
-		panic(fmt.Sprintf("function '%s' not found in exports", u.Exec))
+		panic(fmt.Sprintf("function '%s' not found in exports", exec))
 	}
 
 	// Call the exported function.
@@ -681,6 +747,10 @@ func (u *VU) runFn(
 	}
 
 	opts := &u.Runner.Bundle.Options
+	if u.state.HTTPCache != nil && !opts.NoHTTPCacheReset.ValueOrZero() {
+		u.state.HTTPCache.Reset()
+	}
+
 	if opts.SystemTags.Has(stats.TagIter) {
 		u.state.Tags["iter"] = strconv.FormatInt(u.Iteration, 10)
 	}
@@ -689,6 +759,7 @@ func (u *VU) runFn(
 	// also this means that teardown and setup have __ITER defined
 	// maybe move it to RunOnce ?
 	u.Runtime.Set("__ITER", u.Iteration)
+	u.state.Iteration = u.Iteration
 	u.Iteration++
 
 	defer func() {
@@ -703,7 +774,8 @@ func (u *VU) runFn(
 			for _, s := range gojaStack {
 				s.Write(b)
 			}
-			u.state.Logger.Log(logrus.ErrorLevel, "panic: ", r, "\n", string(debug.Stack()), "\nGoja stack:\n", b.String())
+			u.state.Logger.Log(logrus.ErrorLevel, "panic: ", r, "\n", string(debug.Stack()),
+				"\nGoja stack:\n", u.Runner.Bundle.mapPositions(b.String()))
 		}
 	}()
 
@@ -724,5 +796,5 @@ func (u *VU) runFn(
 
 	u.state.Samples <- u.Dialer.GetTrail(startTime, endTime, isFullIteration, isDefault, stats.NewSampleTags(u.state.Tags))
 
-	return v, isFullIteration, endTime.Sub(startTime), err
+	return v, isFullIteration, endTime.Sub(startTime), u.Runner.Bundle.mapException(err)
 }
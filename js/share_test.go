@@ -106,3 +106,64 @@ exports.default = function() {
 		})
 	}
 }
+
+func TestPartitionedArrayIntegration(t *testing.T) {
+	t.Parallel()
+	script := `'use strict';
+var data = require("k6/data");
+var array = new data.SharedArray("items", function() {
+	var arr = [];
+	for (var i = 0; i < 10; i++) {
+		arr.push("item" + i);
+	}
+	return arr;
+});
+var perIteration = new data.PartitionedArray(array, "unique-per-iteration", {onExhausted: "undefined"});
+var roundRobin = new data.PartitionedArray(array, "round-robin");
+
+exports.default = function() {
+	var item = perIteration.get();
+	if (perIteration.get() !== item || roundRobin.get() !== roundRobin.get()) {
+		throw new Error("the item changed during the iteration");
+	}
+	console.log(item + " " + roundRobin.get());
+}`
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.InfoLevel)
+	logger.Out = ioutil.Discard
+	hook := testutils.SimpleLogrusHook{HookedLevels: []logrus.Level{logrus.InfoLevel}}
+	logger.AddHook(&hook)
+
+	r, err := getSimpleRunner(t, "/script.js", script, logger)
+	require.NoError(t, err)
+	// only the first 5 of the 10 items belong to the 0:1/2 segment
+	segment, err := lib.NewExecutionSegmentFromString("0:1/2")
+	require.NoError(t, err)
+	require.NoError(t, r.SetOptions(r.GetOptions().Apply(lib.Options{ExecutionSegment: segment})))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	samples := make(chan stats.SampleContainer, 1000)
+	var vus []lib.ActiveVU
+	for id := int64(1); id <= 2; id++ {
+		initVU, err := r.NewVU(id, samples)
+		require.NoError(t, err)
+		vus = append(vus, initVU.Activate(&lib.VUActivationParams{RunContext: ctx}))
+	}
+
+	// the iterations of the two VUs are interleaved
+	for i := 0; i < 3; i++ {
+		for _, vu := range vus {
+			require.NoError(t, vu.RunOnce())
+		}
+	}
+
+	var messages []string
+	for _, entry := range hook.Drain() {
+		messages = append(messages, entry.Message)
+	}
+	assert.Equal(t, []string{
+		"item0 item0", "item1 item1", "item2 item2", "item3 item3", "item4 item4", "undefined item0",
+	}, messages)
+}
@@ -91,6 +91,11 @@ type Archive struct {
 
 	CompatibilityMode string `json:"compatibilityMode"`
 
+	// The URL of the import map and whether node_modules directories are used
+	// for resolving bare module specifiers, see RuntimeOptions.
+	ImportMap   string `json:"importMap,omitempty"`
+	NodeModules bool   `json:"nodeModules,omitempty"`
+
 	K6Version string `json:"k6version"`
 	Goos      string `json:"goos"`
 }
@@ -251,6 +256,14 @@ func (arc *Archive) Write(out io.Writer) error {
 	normalizeAndAnonymizeURL(metaArc.PwdURL)
 	metaArc.Filename = getURLtoString(metaArc.FilenameURL)
 	metaArc.Pwd = getURLtoString(metaArc.PwdURL)
+	if metaArc.ImportMap != "" {
+		importMapURL, err := url.Parse(metaArc.ImportMap)
+		if err != nil {
+			return err
+		}
+		normalizeAndAnonymizeURL(importMapURL)
+		metaArc.ImportMap = importMapURL.String()
+	}
 	var actualDataPath, err = url.PathUnescape(path.Join(getURLPathOnFs(metaArc.FilenameURL)))
 	if err != nil {
 		return err
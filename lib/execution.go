@@ -83,6 +83,18 @@ type ExecutionScheduler interface {
 	// in progress iterations to finish, and it just won't start any new ones
 	// nor will it increment the value returned by GetCurrentTestRunDuration().
 	SetPaused(paused bool) error
+
+	// StopExecutor interrupts a single executor, identified by its scenario
+	// name, without affecting the rest of the test run. If the executor
+	// hasn't started yet, it will be skipped entirely.
+	StopExecutor(name string) error
+
+	// UpdateExecutorConfig validates the supplied new configuration for the
+	// executor with the given scenario name and applies it in real time. Only
+	// executors that implement the LiveUpdatableExecutor interface can be
+	// updated, and only in ways that don't require more planned VUs than the
+	// ones that were initialized in the beginning of the test.
+	UpdateExecutorConfig(ctx context.Context, name string, newConfig ExecutorConfig) error
 }
 
 // MaxTimeToWaitForPlannedVU specifies the maximum allowable time for an executor
@@ -288,6 +288,20 @@ func (es *ExecutionSegment) Scale(value int64) int64 {
 	return roundUp(toValue).Int64()
 }
 
+// ScaleRange returns the [start, end) range of indexes that this execution
+// segment covers from a list with the given length. The ranges of all
+// segments in a sequence are disjoint and together they cover the whole list.
+// The range length is always equal to the result of Scale(length).
+func (es *ExecutionSegment) ScaleRange(length int64) (start, end int64) {
+	if es == nil { // no execution segment, i.e. 100%
+		return 0, length
+	}
+	fromValue := big.NewRat(length, 1)
+	fromValue.Mul(fromValue, es.from)
+	start = roundUp(fromValue).Int64()
+	return start, start + es.Scale(length)
+}
+
 // InPlaceScaleRat scales rational numbers in-place - it changes the passed
 // argument (and also returns it, to allow for chaining, like many other big.Rat
 // methods).
@@ -248,6 +248,26 @@ func TestBigScale(t *testing.T) {
 	require.Equal(t, int64(18), et.ScaleInt64(50))
 }
 
+func TestExecutionSegmentScaleRange(t *testing.T) {
+	t.Parallel()
+	var nilEs *ExecutionSegment
+	start, end := nilEs.ScaleRange(10)
+	assert.Equal(t, []int64{0, 10}, []int64{start, end})
+
+	ess, err := NewExecutionSegmentSequenceFromString("0,1/3,2/3,1")
+	require.NoError(t, err)
+	for _, length := range []int64{0, 1, 2, 7, 10, 100} {
+		var prevEnd int64
+		for _, es := range ess {
+			start, end := es.ScaleRange(length)
+			assert.Equal(t, prevEnd, start)
+			assert.Equal(t, es.Scale(length), end-start)
+			prevEnd = end
+		}
+		assert.Equal(t, length, prevEnd)
+	}
+}
+
 func TestExecutionSegmentCopyScaleRat(t *testing.T) {
 	t.Parallel()
 	es := new(ExecutionSegment)
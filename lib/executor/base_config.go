@@ -28,6 +28,7 @@ import (
 
 	"gopkg.in/guregu/null.v3"
 
+	"github.com/loadimpact/k6/lib"
 	"github.com/loadimpact/k6/lib/consts"
 	"github.com/loadimpact/k6/lib/types"
 )
@@ -42,13 +43,17 @@ const executorNameErr = "the executor name should contain only numbers, latin le
 
 // BaseConfig contains the common config fields for all executors
 type BaseConfig struct {
-	Name         string             `json:"-"` // set via the JS object key
-	Type         string             `json:"executor"`
-	StartTime    types.NullDuration `json:"startTime"`
-	GracefulStop types.NullDuration `json:"gracefulStop"`
-	Env          map[string]string  `json:"env"`
-	Exec         null.String        `json:"exec"` // function name, externally validated
-	Tags         map[string]string  `json:"tags"`
+	Name         string              `json:"-"` // set via the JS object key
+	Type         string              `json:"executor"`
+	StartTime    types.NullDuration  `json:"startTime"`
+	GracefulStop types.NullDuration  `json:"gracefulStop"`
+	Env          map[string]string   `json:"env"`
+	Exec         ExecConfig          `json:"exec"`     // function name(s), externally validated
+	ExecSeed     null.Int            `json:"execSeed"` // for picking weighted exec functions
+	Tags         map[string]string   `json:"tags"`
+	Proxy        types.NullProxyPool `json:"proxy"`
+
+	NetworkConditions types.NetworkConditions `json:"networkConditions"`
 
 	// TODO: future extensions like distribution, others?
 }
@@ -72,8 +77,9 @@ func (bc BaseConfig) Validate() (errors []error) {
 	if !executorNameWhitelist.MatchString(bc.Name) {
 		errors = append(errors, fmt.Errorf(executorNameErr))
 	}
-	if bc.Exec.Valid && bc.Exec.String == "" {
-		errors = append(errors, fmt.Errorf("exec value cannot be empty"))
+	errors = append(errors, bc.Exec.Validate()...)
+	if bc.ExecSeed.Valid && !bc.Exec.IsWeighted() {
+		errors = append(errors, fmt.Errorf("the execSeed can only be used with a weighted exec list"))
 	}
 	if bc.Type == "" {
 		errors = append(errors, fmt.Errorf("missing or empty type field"))
@@ -120,20 +126,35 @@ func (bc BaseConfig) GetEnv() map[string]string {
 	return bc.Env
 }
 
-// GetExec returns the configured custom exec value, if any.
+// GetExec returns the configured custom exec value, if any. It returns an
+// empty string if a weighted exec list was configured.
 func (bc BaseConfig) GetExec() string {
-	exec := bc.Exec.ValueOrZero()
+	if bc.Exec.IsWeighted() {
+		return ""
+	}
+	exec := bc.Exec.Fn.ValueOrZero()
 	if exec == "" {
 		exec = consts.DefaultFn
 	}
 	return exec
 }
 
+// GetWeightedExec returns the configured weighted exec list, if any.
+func (bc BaseConfig) GetWeightedExec() lib.WeightedExecs {
+	return bc.Exec.Weighted
+}
+
 // GetTags returns any custom tags configured for the executor.
 func (bc BaseConfig) GetTags() map[string]string {
 	return bc.Tags
 }
 
+// GetProxy returns the proxies of the executor, which override the global
+// ones if they are valid.
+func (bc BaseConfig) GetProxy() types.NullProxyPool {
+	return bc.Proxy
+}
+
 // IsDistributable returns true since by default all executors could be run in
 // a distributed manner.
 func (bc BaseConfig) IsDistributable() bool {
@@ -142,8 +163,8 @@ func (bc BaseConfig) IsDistributable() bool {
 
 // getBaseInfo is a helper method for the "parent" String methods.
 func (bc BaseConfig) getBaseInfo(facts ...string) string {
-	if bc.Exec.Valid {
-		facts = append(facts, fmt.Sprintf("exec: %s", bc.Exec.String))
+	if bc.Exec.IsSet() {
+		facts = append(facts, fmt.Sprintf("exec: %s", bc.Exec))
 	}
 	if bc.StartTime.Duration > 0 {
 		facts = append(facts, fmt.Sprintf("startTime: %s", bc.StartTime.Duration))
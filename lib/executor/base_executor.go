@@ -23,6 +23,7 @@ package executor
 import (
 	"context"
 	"strconv"
+	"sync"
 
 	"github.com/sirupsen/logrus"
 
@@ -37,6 +38,7 @@ import (
 // code.
 type BaseExecutor struct {
 	config         lib.ExecutorConfig
+	configLock     *sync.RWMutex // guards config, which can be changed by live updates
 	executionState *lib.ExecutionState
 	logger         *logrus.Entry
 	progress       *pb.ProgressBar
@@ -46,6 +48,7 @@ type BaseExecutor struct {
 func NewBaseExecutor(config lib.ExecutorConfig, es *lib.ExecutionState, logger *logrus.Entry) *BaseExecutor {
 	return &BaseExecutor{
 		config:         config,
+		configLock:     &sync.RWMutex{},
 		executionState: es,
 		logger:         logger,
 		progress: pb.New(
@@ -61,27 +64,39 @@ func (bs *BaseExecutor) Init(_ context.Context) error {
 	return nil
 }
 
-// GetConfig returns the configuration with which this executor was launched.
-func (bs BaseExecutor) GetConfig() lib.ExecutorConfig {
+// GetConfig returns the current configuration of this executor. It's the one
+// it was launched with, unless it was subsequently changed by a live update.
+func (bs *BaseExecutor) GetConfig() lib.ExecutorConfig {
+	bs.configLock.RLock()
+	defer bs.configLock.RUnlock()
 	return bs.config
 }
 
+// setConfig replaces the configuration returned by GetConfig(). It should be
+// used by the executors that support live config updates, after the new config
+// has been validated and applied.
+func (bs *BaseExecutor) setConfig(config lib.ExecutorConfig) {
+	bs.configLock.Lock()
+	defer bs.configLock.Unlock()
+	bs.config = config
+}
+
 // GetLogger returns the executor logger entry.
-func (bs BaseExecutor) GetLogger() *logrus.Entry {
+func (bs *BaseExecutor) GetLogger() *logrus.Entry {
 	return bs.logger
 }
 
 // GetProgress just returns the progressbar pointer.
-func (bs BaseExecutor) GetProgress() *pb.ProgressBar {
+func (bs *BaseExecutor) GetProgress() *pb.ProgressBar {
 	return bs.progress
 }
 
 // getMetricTags returns a tag set that can be used to emit metrics by the
 // executor. The VU ID is optional.
-func (bs BaseExecutor) getMetricTags(vuID *int64) *stats.SampleTags {
+func (bs *BaseExecutor) getMetricTags(vuID *int64) *stats.SampleTags {
 	tags := bs.executionState.Options.RunTags.CloneTags()
 	if bs.executionState.Options.SystemTags.Has(stats.TagScenario) {
-		tags["scenario"] = bs.config.GetName()
+		tags["scenario"] = bs.GetConfig().GetName()
 	}
 	if vuID != nil && bs.executionState.Options.SystemTags.Has(stats.TagVU) {
 		tags["vu"] = strconv.FormatInt(*vuID, 10)
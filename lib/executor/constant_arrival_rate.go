@@ -22,6 +22,7 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"math/big"
@@ -171,6 +172,7 @@ func (carc ConstantArrivalRateConfig) NewExecutor(
 	return &ConstantArrivalRate{
 		BaseExecutor: NewBaseExecutor(&carc, es, logger),
 		config:       carc,
+		duration:     newLiveDuration(time.Duration(carc.Duration.Duration), carc.GetGracefulStop()),
 	}, nil
 }
 
@@ -183,12 +185,33 @@ func (carc ConstantArrivalRateConfig) HasWork(et *lib.ExecutionTuple) bool {
 // specific period.
 type ConstantArrivalRate struct {
 	*BaseExecutor
-	config ConstantArrivalRateConfig
-	et     *lib.ExecutionTuple
+	config   ConstantArrivalRateConfig
+	et       *lib.ExecutionTuple
+	duration *liveDuration
 }
 
-// Make sure we implement the lib.Executor interface.
-var _ lib.Executor = &ConstantArrivalRate{}
+// Make sure we implement the lib.Executor and lib.LiveUpdatableExecutor interfaces.
+var (
+	_ lib.Executor              = &ConstantArrivalRate{}
+	_ lib.LiveUpdatableExecutor = &ConstantArrivalRate{}
+)
+
+// UpdateConfig validates the supplied config and applies it in real time. Only
+// the duration can be changed while the executor is running.
+func (car ConstantArrivalRate) UpdateConfig(_ context.Context, newConf interface{}) error {
+	newConfig, ok := newConf.(*ConstantArrivalRateConfig)
+	if !ok {
+		return errors.New("invalid config type")
+	}
+	if err := validateLiveUpdate(car.GetConfig(), newConfig, "duration"); err != nil {
+		return err
+	}
+	if err := car.duration.setRegularDuration(time.Duration(newConfig.Duration.Duration)); err != nil {
+		return err
+	}
+	car.setConfig(newConfig)
+	return nil
+}
 
 // Init values needed for the execution
 func (car *ConstantArrivalRate) Init(ctx context.Context) error {
@@ -211,8 +234,6 @@ func (car *ConstantArrivalRate) Init(ctx context.Context) error {
 // and things like all of the TODOs below in one place only.
 //nolint:funlen
 func (car ConstantArrivalRate) Run(parentCtx context.Context, out chan<- stats.SampleContainer) (err error) {
-	gracefulStop := car.config.GetGracefulStop()
-	duration := time.Duration(car.config.Duration.Duration)
 	preAllocatedVUs := car.config.GetPreAllocatedVUs(car.executionState.ExecutionTuple)
 	maxVUs := car.config.GetMaxVUs(car.executionState.ExecutionTuple)
 	// TODO: refactor and simplify
@@ -222,14 +243,14 @@ func (car ConstantArrivalRate) Run(parentCtx context.Context, out chan<- stats.S
 
 	// Make sure the log and the progress bar have accurate information
 	car.logger.WithFields(logrus.Fields{
-		"maxVUs": maxVUs, "preAllocatedVUs": preAllocatedVUs, "duration": duration,
+		"maxVUs": maxVUs, "preAllocatedVUs": preAllocatedVUs, "duration": car.duration.getRegularDuration(),
 		"tickerPeriod": tickerPeriod, "type": car.config.GetType(),
 	}).Debug("Starting executor run...")
 
 	activeVUsWg := &sync.WaitGroup{}
 
 	returnedVUs := make(chan struct{})
-	startTime, maxDurationCtx, regDurationCtx, cancel := getDurationContexts(parentCtx, duration, gracefulStop)
+	startTime, maxDurationCtx, regDurationCtx, cancel := car.duration.getDurationContexts(parentCtx)
 
 	defer func() {
 		// Make sure all VUs aren't executing iterations anymore, for the cancel()
@@ -293,6 +314,7 @@ func (car ConstantArrivalRate) Run(parentCtx context.Context, out chan<- stats.S
 		pb.GetFixedLengthFloatFormat(arrivalRatePerSec, 0)+" iters/s", arrivalRatePerSec)
 	progressFn := func() (float64, []string) {
 		spent := time.Since(startTime)
+		duration := car.duration.getRegularDuration()
 		currActiveVUs := atomic.LoadUint64(&activeVUsCount)
 		vusInBuffer := uint64(len(activeVUs))
 		progVUs := fmt.Sprintf(vusFmt+"/"+vusFmt+" VUs",
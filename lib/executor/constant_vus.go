@@ -22,6 +22,7 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -30,6 +31,7 @@ import (
 	"gopkg.in/guregu/null.v3"
 
 	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/metrics"
 	"github.com/loadimpact/k6/lib/types"
 	"github.com/loadimpact/k6/stats"
 	"github.com/loadimpact/k6/ui/pb"
@@ -57,6 +59,7 @@ type ConstantVUsConfig struct {
 	BaseConfig
 	VUs      null.Int           `json:"vus"`
 	Duration types.NullDuration `json:"duration"`
+	Pacing   PacingConfig       `json:"pacing"`
 }
 
 // NewConstantVUsConfig returns a ConstantVUsConfig with default values
@@ -78,7 +81,7 @@ func (clvc ConstantVUsConfig) GetVUs(et *lib.ExecutionTuple) int64 {
 // GetDescription returns a human-readable description of the executor options
 func (clvc ConstantVUsConfig) GetDescription(et *lib.ExecutionTuple) string {
 	return fmt.Sprintf("%d looping VUs for %s%s",
-		clvc.GetVUs(et), clvc.Duration.Duration, clvc.getBaseInfo())
+		clvc.GetVUs(et), clvc.Duration.Duration, clvc.getBaseInfo(getPacingInfo(clvc.Pacing)...))
 }
 
 // Validate makes sure all options are configured and valid
@@ -96,7 +99,7 @@ func (clvc ConstantVUsConfig) Validate() []error {
 		))
 	}
 
-	return errors
+	return append(errors, clvc.Pacing.Validate()...)
 }
 
 // GetExecutionRequirements returns the number of required VUs to run the
@@ -127,6 +130,7 @@ func (clvc ConstantVUsConfig) NewExecutor(es *lib.ExecutionState, logger *logrus
 	return ConstantVUs{
 		BaseExecutor: NewBaseExecutor(clvc, es, logger),
 		config:       clvc,
+		duration:     newLiveDuration(time.Duration(clvc.Duration.Duration), clvc.GetGracefulStop()),
 	}, nil
 }
 
@@ -134,29 +138,49 @@ func (clvc ConstantVUsConfig) NewExecutor(es *lib.ExecutionState, logger *logrus
 // specified duration.
 type ConstantVUs struct {
 	*BaseExecutor
-	config ConstantVUsConfig
+	config   ConstantVUsConfig
+	duration *liveDuration
 }
 
-// Make sure we implement the lib.Executor interface.
-var _ lib.Executor = &ConstantVUs{}
+// Make sure we implement the lib.Executor and lib.LiveUpdatableExecutor interfaces.
+var (
+	_ lib.Executor              = &ConstantVUs{}
+	_ lib.LiveUpdatableExecutor = &ConstantVUs{}
+)
+
+// UpdateConfig validates the supplied config and applies it in real time. Only
+// the duration can be changed while the executor is running.
+func (clv ConstantVUs) UpdateConfig(_ context.Context, newConf interface{}) error {
+	newConfig, ok := newConf.(ConstantVUsConfig)
+	if !ok {
+		return errors.New("invalid config type")
+	}
+	if err := validateLiveUpdate(clv.GetConfig(), newConfig, "duration"); err != nil {
+		return err
+	}
+	if err := clv.duration.setRegularDuration(time.Duration(newConfig.Duration.Duration)); err != nil {
+		return err
+	}
+	clv.setConfig(newConfig)
+	return nil
+}
 
 // Run constantly loops through as many iterations as possible on a fixed number
 // of VUs for the specified duration.
 func (clv ConstantVUs) Run(parentCtx context.Context, out chan<- stats.SampleContainer) (err error) {
 	numVUs := clv.config.GetVUs(clv.executionState.ExecutionTuple)
-	duration := time.Duration(clv.config.Duration.Duration)
-	gracefulStop := clv.config.GetGracefulStop()
 
-	startTime, maxDurationCtx, regDurationCtx, cancel := getDurationContexts(parentCtx, duration, gracefulStop)
+	startTime, maxDurationCtx, regDurationCtx, cancel := clv.duration.getDurationContexts(parentCtx)
 	defer cancel()
 
 	// Make sure the log and the progress bar have accurate information
-	clv.logger.WithFields(
-		logrus.Fields{"vus": numVUs, "duration": duration, "type": clv.config.GetType()},
-	).Debug("Starting executor run...")
+	clv.logger.WithFields(logrus.Fields{
+		"vus": numVUs, "duration": clv.duration.getRegularDuration(), "type": clv.config.GetType(),
+	}).Debug("Starting executor run...")
 
 	progressFn := func() (float64, []string) {
 		spent := time.Since(startTime)
+		duration := clv.duration.getRegularDuration()
 		right := []string{fmt.Sprintf("%d VUs", numVUs)}
 		if spent > duration {
 			right = append(right, duration.String())
@@ -188,7 +212,14 @@ func (clv ConstantVUs) Run(parentCtx context.Context, out chan<- stats.SampleCon
 		newParams := *activationParams
 		newParams.RunContext = ctx
 
+		vuID := initVU.GetID()
 		activeVU := initVU.Activate(&newParams)
+		pacer := newPacer(clv.config.Pacing, func(missed int64) {
+			stats.PushIfNotDone(parentCtx, out, stats.Sample{
+				Value: float64(missed), Metric: metrics.MissedPacingSlots,
+				Tags: clv.getMetricTags(&vuID), Time: time.Now(),
+			})
+		})
 
 		for {
 			select {
@@ -197,6 +228,9 @@ func (clv ConstantVUs) Run(parentCtx context.Context, out chan<- stats.SampleCon
 			default:
 				// continue looping
 			}
+			if !pacer.wait(maxDurationCtx, regDurationDone) {
+				return
+			}
 			runIteration(maxDurationCtx, activeVU)
 		}
 	}
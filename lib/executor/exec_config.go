@@ -0,0 +1,111 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package executor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/loadimpact/k6/lib"
+)
+
+// ExecConfig is the value of the exec scenario option. It's either the name of
+// a single exported function, or a weighted list of exported functions, one
+// of which is picked for every iteration, e.g.:
+//
+//   exec: [{ fn: "browse", weight: 70 }, { fn: "search", weight: 25 }, { fn: "checkout", weight: 5 }]
+type ExecConfig struct {
+	Fn       null.String
+	Weighted lib.WeightedExecs
+}
+
+// NewExecConfig returns an ExecConfig for the single exported function with
+// the given name.
+func NewExecConfig(fn string) ExecConfig {
+	return ExecConfig{Fn: null.StringFrom(fn)}
+}
+
+// IsSet returns whether the exec option was specified at all.
+func (ec ExecConfig) IsSet() bool {
+	return ec.Fn.Valid || ec.Weighted != nil
+}
+
+// IsWeighted returns whether a weighted list of functions was specified.
+func (ec ExecConfig) IsWeighted() bool {
+	return ec.Weighted != nil
+}
+
+// Validate makes sure the exec option isn't empty and that any weights are
+// valid. Whether the functions actually exist is externally validated.
+func (ec ExecConfig) Validate() []error {
+	if ec.IsWeighted() {
+		if len(ec.Weighted) == 0 {
+			return []error{fmt.Errorf("the weighted exec list cannot be empty")}
+		}
+		return ec.Weighted.Validate()
+	}
+	if ec.Fn.Valid && ec.Fn.String == "" {
+		return []error{fmt.Errorf("exec value cannot be empty")}
+	}
+	return nil
+}
+
+// String returns the function name or a description of the weighted list.
+func (ec ExecConfig) String() string {
+	if ec.IsWeighted() {
+		return ec.Weighted.String()
+	}
+	return ec.Fn.String
+}
+
+// MarshalJSON returns either a JSON string, an array or null.
+func (ec ExecConfig) MarshalJSON() ([]byte, error) {
+	if ec.IsWeighted() {
+		return json.Marshal(ec.Weighted)
+	}
+	return json.Marshal(ec.Fn)
+}
+
+// UnmarshalJSON accepts either a function name or a list of functions with
+// their weights.
+func (ec *ExecConfig) UnmarshalJSON(data []byte) error {
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '[' {
+		var weighted lib.WeightedExecs
+		if err := lib.StrictJSONUnmarshal(data, &weighted); err != nil {
+			return err
+		}
+		if weighted == nil {
+			weighted = lib.WeightedExecs{}
+		}
+		*ec = ExecConfig{Weighted: weighted}
+		return nil
+	}
+
+	var fn null.String
+	if err := json.Unmarshal(data, &fn); err != nil {
+		return fmt.Errorf("exec should be either a function name or a list of functions with weights: %w", err)
+	}
+	*ec = ExecConfig{Fn: fn}
+	return nil
+}
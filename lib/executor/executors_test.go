@@ -73,7 +73,7 @@ var configMapTestCases = []configMapTestCase{
 			sched.Duration = types.NullDurationFrom(1 * time.Minute)
 			sched.GracefulStop = types.NullDurationFrom(10 * time.Second)
 			sched.StartTime = types.NullDurationFrom(70 * time.Second)
-			sched.Exec = null.StringFrom("someFunc")
+			sched.Exec = NewExecConfig("someFunc")
 			sched.Env = map[string]string{"test": "mest"}
 			require.Equal(t, cm, lib.ScenarioConfigs{"someKey": sched})
 			require.Equal(t, sched.BaseConfig.Name, cm["someKey"].GetName())
@@ -119,6 +119,56 @@ var configMapTestCases = []configMapTestCase{
 	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "startTime": "-10s"}}`, exp{validationError: true}},
 	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "exec": ""}}`, exp{validationError: true}},
 	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "gracefulStop": "-2s"}}`, exp{validationError: true}},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "execSeed": 1,
+		"exec": [{"fn": "browse", "weight": 70}, {"fn": "search", "weight": 25}, {"fn": "checkout", "weight": 5}]}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			assert.Empty(t, cm.Validate())
+			assert.Equal(t, "", cm["aname"].GetExec())
+			assert.Equal(t, lib.WeightedExecs{
+				{Fn: "browse", Weight: 70}, {Fn: "search", Weight: 25}, {Fn: "checkout", Weight: 5},
+			}, cm["aname"].GetWeightedExec())
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "10 looping VUs for 10s (exec: browse (70%), search (25%), checkout (5%), gracefulStop: 30s)",
+				cm["aname"].GetDescription(et))
+		}},
+	},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "exec": []}}`, exp{validationError: true}},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "exec": [{"fn": "a", "weight": 0}]}}`, exp{validationError: true}},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "exec": [{"fn": "a", "weight": 1}, {"fn": "a", "weight": 2}]}}`, exp{validationError: true}},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "exec": [{"fn": "a", "wait": 1}]}}`, exp{parseError: true}},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "exec": 5}}`, exp{parseError: true}},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "exec": "a", "execSeed": 1}}`, exp{validationError: true}},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "proxy": ["socks5://proxy:1080", "proxy2:3128"]}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			assert.Empty(t, cm.Validate())
+			proxy := cm["aname"].(ConstantVUsConfig).Proxy
+			require.Len(t, proxy.Pool, 2)
+			assert.Equal(t, "http://proxy2:3128", proxy.GetProxy(1).String())
+		}},
+	},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "proxy": "ftp://proxy"}}`, exp{parseError: true}},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "networkConditions": {"preset": "3g", "packetLoss": 0.1}}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			assert.Empty(t, cm.Validate())
+			conditions := cm["aname"].(ConstantVUsConfig).NetworkConditions
+			assert.Equal(t, "custom", conditions.Name())
+			assert.Equal(t, 150*time.Millisecond, conditions.GetLatency())
+		}},
+	},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "networkConditions": "5g"}}`, exp{parseError: true}},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "pacing": {"interval": "2s"}}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			assert.Empty(t, cm.Validate())
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "10 looping VUs for 10s (pacing: 2s, gracefulStop: 30s)", cm["aname"].GetDescription(et))
+		}},
+	},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "pacing": {"interval": "0s"}}}`, exp{validationError: true}},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "pacing": {"maxInterval": "2s"}}}`, exp{validationError: true}},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "pacing": {"interval": "3s", "maxInterval": "2s"}}}`, exp{validationError: true}},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "pacing": {"interval": "3s", "foo": "2s"}}}`, exp{parseError: true}},
 	// ramping-vus
 	{`{"varloops": {"executor": "ramping-vus", "startVUs": 20, "gracefulStop": "15s", "gracefulRampDown": "10s",
 		    "startTime": "23s", "stages": [{"duration": "60s", "target": 30}, {"duration": "130s", "target": 10}]}}`,
@@ -156,6 +206,15 @@ var configMapTestCases = []configMapTestCase{
 			assert.Equal(t, uint64(30), lib.GetMaxPossibleVUs(schedReqs))
 		}},
 	},
+	{`{"varloops": {"executor": "ramping-vus", "startVUs": 1, "gracefulStop": "0s", "gracefulRampDown": "10s",
+			"pacing": {"interval": "5s"}, "stages": [{"duration": "10s", "target": 10}]}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			assert.Empty(t, cm.Validate())
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "Up to 10 looping VUs for 10s over 1 stages (gracefulRampDown: 10s, pacing: 5s)", cm["varloops"].GetDescription(et))
+		}},
+	},
 	{`{"varloops": {"executor": "ramping-vus", "startVUs": 1, "gracefulStop": "0s", "gracefulRampDown": "10s",
 			"stages": [{"duration": "10s", "target": 10}]}}`,
 		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
@@ -318,6 +377,15 @@ var configMapTestCases = []configMapTestCase{
 	{`{"ipervu": {"executor": "per-vu-iterations", "iterations": 20, "vus": 10, "maxDuration": "0s"}}`, exp{validationError: true}},
 	{`{"ipervu": {"executor": "per-vu-iterations", "iterations": 20, "vus": -10}}`, exp{validationError: true}},
 	{`{"ipervu": {"executor": "per-vu-iterations", "iterations": -1, "vus": 1}}`, exp{validationError: true}},
+	{`{"ipervu": {"executor": "per-vu-iterations", "iterations": 20, "vus": 10, "pacing": {"interval": "1s", "maxInterval": "3s"}}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			assert.Empty(t, cm.Validate())
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "20 iterations for each of 10 VUs (maxDuration: 10m0s, pacing: 1s-3s, gracefulStop: 30s)", cm["ipervu"].GetDescription(et))
+		}},
+	},
+	{`{"ipervu": {"executor": "per-vu-iterations", "iterations": 20, "vus": 10, "pacing": {"interval": "-1s"}}}`, exp{validationError: true}},
 
 	// constant-arrival-rate
 	{`{"carrival": {"executor": "constant-arrival-rate", "rate": 30, "timeUnit": "1m", "duration": "10m", "preAllocatedVUs": 20, "maxVUs": 30}}`,
@@ -266,8 +266,18 @@ func (mex *ExternallyControlled) SetPaused(paused bool) error {
 // beginning (i.e. when running k6 with --paused) or in the middle of the script
 // execution.
 func (mex *ExternallyControlled) UpdateConfig(ctx context.Context, newConf interface{}) error {
-	newConfigParams, ok := newConf.(ExternallyControlledConfigParams)
-	if !ok {
+	var newConfigParams ExternallyControlledConfigParams
+	switch conf := newConf.(type) {
+	case ExternallyControlledConfigParams:
+		newConfigParams = conf
+	case ExternallyControlledConfig:
+		// A whole executor config, e.g. from the /v1/scenarios endpoint, so we
+		// make sure only the control params were changed.
+		if err := validateLiveUpdate(mex.GetConfig(), conf, "vus", "maxVUs", "duration"); err != nil {
+			return err
+		}
+		newConfigParams = conf.ExternallyControlledConfigParams
+	default:
 		return errors.New("invalid config type")
 	}
 	if errs := newConfigParams.Validate(); len(errs) != 0 {
@@ -24,6 +24,7 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"math/rand"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -144,12 +145,15 @@ func getDurationContexts(parentCtx context.Context, regularDuration, gracefulSto
 	startTime = time.Now()
 	maxEndTime := startTime.Add(regularDuration + gracefulStop)
 
-	maxDurationCtx, maxDurationCancel = context.WithDeadline(parentCtx, maxEndTime)
+	maxDurationCtx, maxCancel := context.WithDeadline(parentCtx, maxEndTime)
 	if gracefulStop == 0 {
-		return startTime, maxDurationCtx, maxDurationCtx, maxDurationCancel
+		return startTime, maxDurationCtx, maxDurationCtx, maxCancel
+	}
+	regDurationCtx, regCancel := context.WithDeadline(maxDurationCtx, startTime.Add(regularDuration))
+	return startTime, maxDurationCtx, regDurationCtx, func() {
+		regCancel()
+		maxCancel()
 	}
-	regDurationCtx, _ = context.WithDeadline(maxDurationCtx, startTime.Add(regularDuration)) //nolint:govet
-	return startTime, maxDurationCtx, regDurationCtx, maxDurationCancel
 }
 
 // trackProgress is a helper function that monitors certain end-events in an
@@ -223,12 +227,22 @@ func getArrivalRatePerSec(scaledArrivalRate *big.Rat) *big.Rat {
 func getVUActivationParams(
 	ctx context.Context, conf BaseConfig, deactivateCallback func(lib.InitializedVU),
 ) *lib.VUActivationParams {
-	return &lib.VUActivationParams{
+	params := &lib.VUActivationParams{
 		RunContext:         ctx,
 		Scenario:           conf.Name,
 		Exec:               conf.GetExec(),
 		Env:                conf.GetEnv(),
 		Tags:               conf.GetTags(),
+		Proxy:              conf.Proxy,
+		NetworkConditions:  conf.NetworkConditions,
 		DeactivateCallback: deactivateCallback,
 	}
+	if conf.Exec.IsWeighted() {
+		params.WeightedExec = conf.GetWeightedExec()
+		params.ExecSeed = conf.ExecSeed.Int64
+		if !conf.ExecSeed.Valid {
+			params.ExecSeed = rand.Int63() //nolint:gosec
+		}
+	}
+	return params
 }
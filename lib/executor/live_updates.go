@@ -0,0 +1,182 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/loadimpact/k6/lib"
+)
+
+// validateLiveUpdate checks that the new config for a running executor is
+// valid and that the only options that differ from the current config are the
+// ones in the supplied updatable list (identified by their JSON keys).
+func validateLiveUpdate(currentConf, newConf lib.ExecutorConfig, updatable ...string) error {
+	if currentConf.GetType() != newConf.GetType() || currentConf.GetName() != newConf.GetName() {
+		return fmt.Errorf("the executor type and name cannot be changed")
+	}
+	if errs := newConf.Validate(); len(errs) != 0 {
+		return fmt.Errorf("invalid configuration supplied: %s", lib.ConcatErrors(errs, ", "))
+	}
+
+	currentOpts, err := getConfigOptions(currentConf)
+	if err != nil {
+		return err
+	}
+	newOpts, err := getConfigOptions(newConf)
+	if err != nil {
+		return err
+	}
+
+	isUpdatable := make(map[string]bool, len(updatable))
+	for _, key := range updatable {
+		isUpdatable[key] = true
+	}
+	var changed []string
+	for key, newValue := range newOpts {
+		if !isUpdatable[key] && !bytes.Equal(currentOpts[key], newValue) {
+			changed = append(changed, key)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	sort.Strings(changed)
+	if len(updatable) == 0 {
+		return fmt.Errorf(
+			"the %s executor doesn't support any live config updates, tried to change %s",
+			currentConf.GetType(), strings.Join(changed, ", "),
+		)
+	}
+	return fmt.Errorf(
+		"only %s can be changed while the %s executor is running, tried to change %s",
+		strings.Join(updatable, ", "), currentConf.GetType(), strings.Join(changed, ", "),
+	)
+}
+
+func getConfigOptions(conf lib.ExecutorConfig) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(conf)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]json.RawMessage)
+	return result, json.Unmarshal(data, &result)
+}
+
+// liveDuration is used by the executors whose regular duration can be changed
+// while they are running. It's a replacement of getDurationContexts() with
+// the same semantics, except that the contexts are cancelled by timers which
+// can be reset by setRegularDuration().
+type liveDuration struct {
+	mutex          sync.Mutex
+	regular        time.Duration
+	gracefulStop   time.Duration
+	startTime      time.Time
+	regDurationCtx context.Context
+	regTimer       *time.Timer
+	maxTimer       *time.Timer
+}
+
+func newLiveDuration(regularDuration, gracefulStop time.Duration) *liveDuration {
+	return &liveDuration{regular: regularDuration, gracefulStop: gracefulStop}
+}
+
+// getDurationContexts starts the timers and returns the executor contexts. See
+// the getDurationContexts() helper function for the details on how to use them.
+func (ld *liveDuration) getDurationContexts(parentCtx context.Context) (
+	startTime time.Time, maxDurationCtx, regDurationCtx context.Context, maxDurationCancel func(),
+) {
+	ld.mutex.Lock()
+	defer ld.mutex.Unlock()
+
+	ld.startTime = time.Now()
+	maxDurationCtx, maxCancel := context.WithCancel(parentCtx)
+	regDurationCtx, regCancel := context.WithCancel(maxDurationCtx)
+	ld.regDurationCtx = regDurationCtx
+	ld.regTimer = time.AfterFunc(ld.regular, regCancel)
+	ld.maxTimer = time.AfterFunc(ld.regular+ld.gracefulStop, maxCancel)
+
+	maxDurationCancel = func() {
+		ld.mutex.Lock()
+		ld.regTimer.Stop()
+		ld.maxTimer.Stop()
+		ld.mutex.Unlock()
+		regCancel()
+		maxCancel()
+	}
+	return ld.startTime, maxDurationCtx, regDurationCtx, maxDurationCancel
+}
+
+// getRegularDuration returns the current regular duration.
+func (ld *liveDuration) getRegularDuration() time.Duration {
+	ld.mutex.Lock()
+	defer ld.mutex.Unlock()
+	return ld.regular
+}
+
+// setRegularDuration changes the regular duration, extending or shortening the
+// executor run. It returns an error if the regular duration has already ended,
+// or if the new duration is shorter than the already elapsed time.
+func (ld *liveDuration) setRegularDuration(newDuration time.Duration) error {
+	ld.mutex.Lock()
+	defer ld.mutex.Unlock()
+	return ld.setDurationsLocked(newDuration, ld.gracefulStop)
+}
+
+// setDurations changes both the regular duration and the graceful stop, with
+// the same restrictions as setRegularDuration().
+func (ld *liveDuration) setDurations(newDuration, newGracefulStop time.Duration) error {
+	ld.mutex.Lock()
+	defer ld.mutex.Unlock()
+	return ld.setDurationsLocked(newDuration, newGracefulStop)
+}
+
+// setDurationsLocked does the work of setDurations(), with the mutex held.
+func (ld *liveDuration) setDurationsLocked(newDuration, newGracefulStop time.Duration) error {
+	if ld.regDurationCtx == nil { // the executor hasn't started yet
+		ld.regular, ld.gracefulStop = newDuration, newGracefulStop
+		return nil
+	}
+	if ld.regDurationCtx.Err() != nil {
+		return fmt.Errorf("the executor has already finished its regular duration")
+	}
+	elapsed := time.Since(ld.startTime)
+	if newDuration < elapsed {
+		return fmt.Errorf(
+			"the new duration %s is shorter than the already elapsed %s", newDuration, elapsed.Truncate(time.Millisecond),
+		)
+	}
+	if !ld.regTimer.Stop() {
+		return fmt.Errorf("the executor has already finished its regular duration")
+	}
+	ld.maxTimer.Stop()
+	ld.regTimer.Reset(newDuration - elapsed)
+	ld.maxTimer.Reset(newDuration + newGracefulStop - elapsed)
+	ld.regular, ld.gracefulStop = newDuration, newGracefulStop
+	return nil
+}
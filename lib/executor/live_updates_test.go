@@ -0,0 +1,230 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package executor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/types"
+)
+
+func getTestLiveConstantVUsConfig() ConstantVUsConfig {
+	config := NewConstantVUsConfig("test")
+	config.GracefulStop = types.NullDurationFrom(100 * time.Millisecond)
+	config.VUs = null.IntFrom(10)
+	config.Duration = types.NullDurationFrom(1 * time.Second)
+	return config
+}
+
+func TestValidateLiveUpdate(t *testing.T) {
+	t.Parallel()
+	current := getTestLiveConstantVUsConfig()
+
+	longer := current
+	longer.Duration = types.NullDurationFrom(2 * time.Second)
+	assert.NoError(t, validateLiveUpdate(current, longer, "duration"))
+
+	moreVUs := longer
+	moreVUs.VUs = null.IntFrom(20)
+	assert.EqualError(t, validateLiveUpdate(current, moreVUs, "duration"),
+		"only duration can be changed while the constant-vus executor is running, tried to change vus")
+	assert.EqualError(t, validateLiveUpdate(current, moreVUs),
+		"the constant-vus executor doesn't support any live config updates, tried to change duration, vus")
+
+	invalid := current
+	invalid.VUs = null.IntFrom(0)
+	assert.Error(t, validateLiveUpdate(current, invalid, "vus"))
+
+	renamed := current
+	renamed.Name = "other"
+	assert.Error(t, validateLiveUpdate(current, renamed, "duration"))
+}
+
+func TestLiveDuration(t *testing.T) {
+	t.Parallel()
+	ld := newLiveDuration(100*time.Millisecond, 50*time.Millisecond)
+	require.NoError(t, ld.setRegularDuration(200*time.Millisecond)) // before the start
+
+	start := time.Now()
+	_, maxDurationCtx, regDurationCtx, cancel := ld.getDurationContexts(context.Background())
+	defer cancel()
+
+	require.NoError(t, ld.setRegularDuration(300*time.Millisecond))
+	assert.Equal(t, 300*time.Millisecond, ld.getRegularDuration())
+	<-regDurationCtx.Done()
+	assert.True(t, time.Since(start) >= 300*time.Millisecond)
+	assert.NoError(t, maxDurationCtx.Err())
+	<-maxDurationCtx.Done()
+	assert.True(t, time.Since(start) >= 350*time.Millisecond)
+
+	assert.Error(t, ld.setRegularDuration(time.Second))
+}
+
+func TestLiveDurationShorterThanElapsed(t *testing.T) {
+	t.Parallel()
+	ld := newLiveDuration(time.Second, 0)
+	_, _, regDurationCtx, cancel := ld.getDurationContexts(context.Background())
+	defer cancel()
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Error(t, ld.setRegularDuration(50*time.Millisecond))
+	start := time.Now()
+	require.NoError(t, ld.setRegularDuration(200*time.Millisecond))
+	<-regDurationCtx.Done()
+	assert.True(t, time.Since(start) < 500*time.Millisecond)
+}
+
+func TestConstantVUsUpdateDuration(t *testing.T) {
+	t.Parallel()
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	es := lib.NewExecutionState(lib.Options{}, et, 10, 50)
+	ctx, cancel, executor, _ := setupExecutor(
+		t, getTestLiveConstantVUsConfig(), es,
+		simpleRunner(func(ctx context.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		}),
+	)
+	defer cancel()
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		newConfig := getTestLiveConstantVUsConfig()
+		newConfig.Duration = types.NullDurationFrom(1500 * time.Millisecond)
+		assert.NoError(t, executor.(lib.LiveUpdatableExecutor).UpdateConfig(ctx, newConfig))
+
+		newConfig.VUs = null.IntFrom(20)
+		assert.Error(t, executor.(lib.LiveUpdatableExecutor).UpdateConfig(ctx, newConfig))
+	}()
+
+	start := time.Now()
+	require.NoError(t, executor.Run(ctx, nil))
+	assert.True(t, time.Since(start) >= 1500*time.Millisecond)
+	assert.Equal(t, types.NullDurationFrom(1500*time.Millisecond),
+		executor.GetConfig().(ConstantVUsConfig).Duration)
+}
+
+func TestLiveStagesUpdate(t *testing.T) {
+	t.Parallel()
+	stage := func(d time.Duration, target int64) Stage {
+		return Stage{Duration: types.NullDurationFrom(d), Target: null.IntFrom(target)}
+	}
+	noop := func() error { return nil }
+	ls := &liveStages{stages: []Stage{stage(time.Second, 10), stage(time.Second, 20)}}
+
+	first, ok := ls.next()
+	require.True(t, ok)
+	assert.Equal(t, stage(time.Second, 10), first)
+
+	assert.Error(t, ls.update([]Stage{stage(2*time.Second, 10), stage(time.Second, 20)}, noop))
+	assert.Error(t, ls.update(nil, noop))
+	assert.NoError(t, ls.update([]Stage{stage(time.Second, 10), stage(time.Second, 50), stage(time.Second, 5)}, noop))
+
+	second, ok := ls.next()
+	require.True(t, ok)
+	assert.Equal(t, stage(time.Second, 50), second)
+	third, ok := ls.next()
+	require.True(t, ok)
+	assert.Equal(t, stage(time.Second, 5), third)
+	_, ok = ls.next()
+	assert.False(t, ok)
+
+	assert.Error(t, ls.update([]Stage{
+		stage(time.Second, 10), stage(time.Second, 50), stage(time.Second, 5), stage(time.Second, 1),
+	}, noop))
+}
+
+func TestRampingVUsUpdateConfig(t *testing.T) {
+	t.Parallel()
+	stage := func(d time.Duration, target int64) Stage {
+		return Stage{Duration: types.NullDurationFrom(d), Target: null.IntFrom(target)}
+	}
+	config := NewRampingVUsConfig("test")
+	config.GracefulStop = types.NullDurationFrom(0)
+	config.GracefulRampDown = types.NullDurationFrom(0)
+	config.StartVUs = null.IntFrom(4) // so that 4 VUs are initialized
+	config.Stages = []Stage{stage(0, 2), stage(500*time.Millisecond, 2)}
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	es := lib.NewExecutionState(lib.Options{}, et, 10, 50)
+	ctx, cancel, executor, _ := setupExecutor(
+		t, config, es,
+		simpleRunner(func(ctx context.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		}),
+	)
+	defer cancel()
+	update := func(newConfig RampingVUsConfig) error {
+		return executor.(lib.LiveUpdatableExecutor).UpdateConfig(ctx, newConfig)
+	}
+
+	result := make(chan []int64)
+	go func() {
+		var activeVUs []int64
+		time.Sleep(200 * time.Millisecond)
+		newConfig := config
+		newConfig.StartVUs = null.IntFrom(5)
+		assert.Error(t, update(newConfig))
+		newConfig = config
+		newConfig.Stages = []Stage{stage(0, 2), stage(100*time.Millisecond, 2)}
+		assert.Error(t, update(newConfig), "the current stage can't be shortened")
+		newConfig.Stages = []Stage{stage(0, 2), stage(500*time.Millisecond, 3)}
+		assert.Error(t, update(newConfig), "the target of the current stage can't be changed")
+
+		// extend the current stage and add new ones
+		newConfig.Stages = []Stage{
+			stage(0, 2), stage(600*time.Millisecond, 2), stage(0, 4), stage(400*time.Millisecond, 4),
+		}
+		newConfig.GracefulRampDown = types.NullDurationFrom(100 * time.Millisecond)
+		assert.NoError(t, update(newConfig))
+
+		time.Sleep(200 * time.Millisecond)
+		activeVUs = append(activeVUs, es.GetCurrentlyActiveVUsCount())
+		time.Sleep(400 * time.Millisecond)
+		activeVUs = append(activeVUs, es.GetCurrentlyActiveVUsCount())
+
+		finished := newConfig
+		finished.Stages = []Stage{
+			stage(0, 2), stage(700*time.Millisecond, 2), stage(0, 4), stage(400*time.Millisecond, 4),
+		}
+		assert.Error(t, update(finished), "the finished stages can't be changed")
+		result <- activeVUs
+	}()
+
+	start := time.Now()
+	require.NoError(t, executor.Run(ctx, nil))
+	assert.True(t, time.Since(start) >= time.Second)
+	assert.Equal(t, []int64{2, 4}, <-result)
+	assert.Len(t, executor.GetConfig().(RampingVUsConfig).Stages, 4)
+	assert.Equal(t, types.NullDurationFrom(100*time.Millisecond),
+		executor.GetConfig().(RampingVUsConfig).GracefulRampDown)
+
+	assert.Error(t, update(executor.GetConfig().(RampingVUsConfig)), "the executor has already finished")
+}
@@ -0,0 +1,194 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package executor
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/types"
+)
+
+// PacingConfig configures how often each VU of a looping executor should start
+// a new iteration. With a fixed interval, every VU starts its iterations on a
+// fixed schedule (i.e. at 0, interval, 2*interval, ...), regardless of how long
+// each iteration took. If maxInterval is also specified, the gap between two
+// consecutive schedule slots is a random duration between interval and
+// maxInterval.
+//
+// If an iteration takes longer than its slot, the next iteration is started on
+// the next free slot and the skipped slots are counted as missed.
+type PacingConfig struct {
+	Interval    types.NullDuration `json:"interval"`
+	MaxInterval types.NullDuration `json:"maxInterval"`
+}
+
+// IsEnabled returns whether pacing was configured at all.
+func (pc PacingConfig) IsEnabled() bool {
+	return pc.Interval.Valid
+}
+
+// Validate makes sure the pacing options are valid.
+func (pc PacingConfig) Validate() (errors []error) {
+	if pc.MaxInterval.Valid && !pc.Interval.Valid {
+		errors = append(errors, fmt.Errorf("the pacing maxInterval can't be specified without an interval"))
+	}
+	if !pc.IsEnabled() {
+		return errors
+	}
+	if pc.Interval.Duration <= 0 {
+		errors = append(errors, fmt.Errorf("the pacing interval should be more than 0"))
+	}
+	if pc.MaxInterval.Valid && pc.MaxInterval.Duration < pc.Interval.Duration {
+		errors = append(errors, fmt.Errorf(
+			"the pacing maxInterval (%s) can't be less than the interval (%s)",
+			pc.MaxInterval.Duration, pc.Interval.Duration,
+		))
+	}
+	return errors
+}
+
+// String returns a short human-readable description of the pacing, or an
+// empty string if pacing is disabled.
+func (pc PacingConfig) String() string {
+	if !pc.IsEnabled() {
+		return ""
+	}
+	if pc.MaxInterval.Valid && pc.MaxInterval.Duration != pc.Interval.Duration {
+		return fmt.Sprintf("pacing: %s-%s", pc.Interval.Duration, pc.MaxInterval.Duration)
+	}
+	return fmt.Sprintf("pacing: %s", pc.Interval.Duration)
+}
+
+// getPacingInfo is a helper for the GetDescription() methods of the executors
+// that support pacing.
+func getPacingInfo(pc PacingConfig, facts ...string) []string {
+	if pc.IsEnabled() {
+		facts = append(facts, pc.String())
+	}
+	return facts
+}
+
+// pacer keeps track of the iteration schedule of a single VU. It's not
+// thread-safe, every VU should have its own pacer.
+type pacer struct {
+	config   PacingConfig
+	rand     *rand.Rand
+	onMissed func(missed int64)
+
+	nextSlot time.Time
+}
+
+// newPacer returns a new pacer for a single VU. The onMissed callback is
+// called every time the VU had to skip some of its schedule slots, because its
+// previous iteration took too long.
+func newPacer(config PacingConfig, onMissed func(missed int64)) *pacer {
+	return &pacer{
+		config:   config,
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())), //nolint:gosec
+		onMissed: onMissed,
+	}
+}
+
+// nextInterval returns the duration between the current slot and the next one.
+func (p *pacer) nextInterval() time.Duration {
+	interval := time.Duration(p.config.Interval.Duration)
+	if !p.config.MaxInterval.Valid {
+		return interval
+	}
+	spread := time.Duration(p.config.MaxInterval.Duration) - interval
+	if spread <= 0 {
+		return interval
+	}
+	return interval + time.Duration(p.rand.Int63n(int64(spread)+1))
+}
+
+// reset makes the next iteration start immediately and restarts the schedule
+// from that point. It should be called when the VU was stopped or interrupted,
+// so the time it wasn't running isn't counted as missed slots.
+func (p *pacer) reset() {
+	p.nextSlot = time.Time{}
+}
+
+// wait blocks until the next schedule slot of the VU. It returns false if the
+// context was done or the stop channel was closed before that, in which case
+// no new iteration should be started.
+func (p *pacer) wait(ctx context.Context, stop <-chan struct{}) bool {
+	if !p.config.IsEnabled() {
+		return true
+	}
+
+	now := time.Now()
+	if p.nextSlot.IsZero() {
+		// This is the first iteration, we start it immediately
+		p.nextSlot = now.Add(p.nextInterval())
+		return true
+	}
+
+	var missed int64
+	for p.nextSlot.Before(now) {
+		// The previous iteration took longer than its slot, so we skip every
+		// slot that has already passed and wait for the next one.
+		p.nextSlot = p.nextSlot.Add(p.nextInterval())
+		missed++
+	}
+	if missed > 0 && p.onMissed != nil {
+		p.onMissed(missed)
+	}
+
+	timer := time.NewTimer(p.nextSlot.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		p.nextSlot = p.nextSlot.Add(p.nextInterval())
+		return true
+	case <-ctx.Done():
+		return false
+	case <-stop:
+		return false
+	}
+}
+
+// wrap returns an iteration runner that waits for the pacing schedule before
+// every iteration. The wait is interrupted when the channel returned by
+// stopSignal is closed, and the isRunning function is checked before and after
+// each iteration, so the schedule is restarted when the VU is stopped.
+func (p *pacer) wrap(
+	runIter func(context.Context, lib.ActiveVU) bool, isRunning func() bool, stopSignal func() <-chan struct{},
+) func(context.Context, lib.ActiveVU) bool {
+	if !p.config.IsEnabled() {
+		return runIter
+	}
+	return func(ctx context.Context, vu lib.ActiveVU) bool {
+		if !p.wait(ctx, stopSignal()) || !isRunning() {
+			p.reset()
+			return false
+		}
+		result := runIter(ctx, vu)
+		if !result || !isRunning() {
+			p.reset()
+		}
+		return result
+	}
+}
@@ -0,0 +1,294 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package executor
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/metrics"
+	"github.com/loadimpact/k6/lib/types"
+	"github.com/loadimpact/k6/stats"
+)
+
+func TestPacingConfigValidate(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		config PacingConfig
+		valid  bool
+		str    string
+	}{
+		{PacingConfig{}, true, ""},
+		{PacingConfig{Interval: types.NullDurationFrom(time.Second)}, true, "pacing: 1s"},
+		{PacingConfig{
+			Interval: types.NullDurationFrom(time.Second), MaxInterval: types.NullDurationFrom(time.Second),
+		}, true, "pacing: 1s"},
+		{PacingConfig{
+			Interval: types.NullDurationFrom(time.Second), MaxInterval: types.NullDurationFrom(2 * time.Second),
+		}, true, "pacing: 1s-2s"},
+		{PacingConfig{Interval: types.NullDurationFrom(0)}, false, "pacing: 0s"},
+		{PacingConfig{MaxInterval: types.NullDurationFrom(time.Second)}, false, ""},
+		{PacingConfig{
+			Interval: types.NullDurationFrom(2 * time.Second), MaxInterval: types.NullDurationFrom(time.Second),
+		}, false, "pacing: 2s-1s"},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.str, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.valid, len(tc.config.Validate()) == 0)
+			assert.Equal(t, tc.str, tc.config.String())
+		})
+	}
+}
+
+func TestPacerWait(t *testing.T) {
+	t.Parallel()
+	var missed int64
+	p := newPacer(
+		PacingConfig{Interval: types.NullDurationFrom(50 * time.Millisecond)},
+		func(m int64) { missed += m },
+	)
+
+	start := time.Now()
+	require.True(t, p.wait(context.Background(), nil)) // the first slot is immediate
+	assert.True(t, time.Since(start) < 50*time.Millisecond)
+
+	require.True(t, p.wait(context.Background(), nil)) // the second one is on schedule
+	assert.True(t, time.Since(start) >= 50*time.Millisecond)
+	assert.Equal(t, int64(0), missed)
+
+	// Simulate an iteration that took more than 2 slots
+	time.Sleep(110 * time.Millisecond)
+	require.True(t, p.wait(context.Background(), nil))
+	assert.Equal(t, int64(2), missed)
+	assert.True(t, time.Since(start) >= 200*time.Millisecond)
+
+	// After a reset, the schedule starts over without any missed slots
+	time.Sleep(110 * time.Millisecond)
+	p.reset()
+	require.True(t, p.wait(context.Background(), nil))
+	assert.Equal(t, int64(2), missed)
+
+	stop := make(chan struct{})
+	close(stop)
+	assert.False(t, p.wait(context.Background(), stop))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.False(t, p.wait(ctx, nil))
+}
+
+func TestPacerRandomInterval(t *testing.T) {
+	t.Parallel()
+	p := newPacer(PacingConfig{
+		Interval:    types.NullDurationFrom(time.Second),
+		MaxInterval: types.NullDurationFrom(2 * time.Second),
+	}, nil)
+	for i := 0; i < 100; i++ {
+		interval := p.nextInterval()
+		assert.True(t, interval >= time.Second && interval <= 2*time.Second, interval)
+	}
+}
+
+func TestConstantVUsRunWithPacing(t *testing.T) {
+	t.Parallel()
+	var result sync.Map
+	config := getTestConstantVUsConfig()
+	config.Pacing = PacingConfig{Interval: types.NullDurationFrom(300 * time.Millisecond)}
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	es := lib.NewExecutionState(lib.Options{}, et, 10, 50)
+	ctx, cancel, executor, _ := setupExecutor(
+		t, config, es,
+		simpleRunner(func(ctx context.Context) error {
+			state := lib.GetState(ctx)
+			currIter, _ := result.LoadOrStore(state.Vu, uint64(0))
+			result.Store(state.Vu, currIter.(uint64)+1)
+			return nil
+		}),
+	)
+	defer cancel()
+	err = executor.Run(ctx, nil)
+	require.NoError(t, err)
+
+	var totalIters uint64
+	result.Range(func(key, value interface{}) bool {
+		vuIters := value.(uint64)
+		assert.Equal(t, uint64(4), vuIters) // at 0ms, 300ms, 600ms and 900ms
+		totalIters += vuIters
+		return true
+	})
+	assert.Equal(t, uint64(40), totalIters)
+}
+
+func TestPerVUIterationsRunWithPacing(t *testing.T) {
+	t.Parallel()
+	var doneIters uint64
+	config := PerVUIterationsConfig{
+		BaseConfig:  BaseConfig{GracefulStop: types.NullDurationFrom(100 * time.Millisecond)},
+		VUs:         null.IntFrom(2),
+		Iterations:  null.IntFrom(10),
+		MaxDuration: types.NullDurationFrom(1 * time.Second),
+		Pacing:      PacingConfig{Interval: types.NullDurationFrom(300 * time.Millisecond)},
+	}
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	es := lib.NewExecutionState(lib.Options{}, et, 2, 2)
+	ctx, cancel, executor, _ := setupExecutor(
+		t, config, es,
+		simpleRunner(func(ctx context.Context) error {
+			atomic.AddUint64(&doneIters, 1)
+			return nil
+		}),
+	)
+	defer cancel()
+	engineOut := make(chan stats.SampleContainer, 100)
+	start := time.Now()
+	err = executor.Run(ctx, engineOut)
+	require.NoError(t, err)
+	// The pacing doesn't allow more than 4 iterations per VU in 1 second, the
+	// rest should be dropped without waiting for the graceful stop.
+	assert.Equal(t, uint64(8), atomic.LoadUint64(&doneIters))
+	assert.True(t, time.Since(start) < 1100*time.Millisecond)
+
+	close(engineOut)
+	var droppedIters float64
+	for sc := range engineOut {
+		for _, s := range sc.GetSamples() {
+			if s.Metric == metrics.DroppedIterations {
+				droppedIters += s.Value
+			}
+		}
+	}
+	assert.Equal(t, float64(12), droppedIters)
+}
+
+func TestConstantVUsRunWithMissedPacingSlots(t *testing.T) {
+	t.Parallel()
+	config := getTestConstantVUsConfig()
+	config.VUs = null.IntFrom(2)
+	config.GracefulStop = types.NullDurationFrom(0)
+	config.Pacing = PacingConfig{Interval: types.NullDurationFrom(200 * time.Millisecond)}
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	es := lib.NewExecutionState(lib.Options{}, et, 2, 2)
+	ctx, cancel, executor, _ := setupExecutor(
+		t, config, es,
+		simpleRunner(func(ctx context.Context) error {
+			time.Sleep(250 * time.Millisecond)
+			return nil
+		}),
+	)
+	defer cancel()
+	engineOut := make(chan stats.SampleContainer, 100)
+	err = executor.Run(ctx, engineOut)
+	require.NoError(t, err)
+
+	close(engineOut)
+	var missedSlots float64
+	for sc := range engineOut {
+		for _, s := range sc.GetSamples() {
+			if s.Metric == metrics.MissedPacingSlots {
+				missedSlots += s.Value
+			}
+		}
+	}
+	// Iterations start at 0ms, 400ms and 800ms, missing the 200ms and 600ms slots
+	assert.Equal(t, float64(4), missedSlots)
+}
+
+func TestRampingVUsRunWithPacing(t *testing.T) {
+	t.Parallel()
+
+	t.Run("GracefulRampDown", func(t *testing.T) {
+		t.Parallel()
+		config := RampingVUsConfig{
+			BaseConfig:       BaseConfig{GracefulStop: types.NullDurationFrom(0)},
+			GracefulRampDown: types.NullDurationFrom(5 * time.Second),
+			StartVUs:         null.IntFrom(2),
+			Stages: []Stage{
+				{Duration: types.NullDurationFrom(300 * time.Millisecond), Target: null.IntFrom(2)},
+				{Duration: types.NullDurationFrom(0), Target: null.IntFrom(1)},
+				{Duration: types.NullDurationFrom(500 * time.Millisecond), Target: null.IntFrom(1)},
+			},
+			Pacing: PacingConfig{Interval: types.NullDurationFrom(10 * time.Second)},
+		}
+		et, err := lib.NewExecutionTuple(nil, nil)
+		require.NoError(t, err)
+		es := lib.NewExecutionState(lib.Options{}, et, 2, 2)
+		ctx, cancel, executor, _ := setupExecutor(t, config, es, simpleRunner(func(ctx context.Context) error {
+			return nil
+		}))
+		defer cancel()
+
+		errCh := make(chan error)
+		go func() { errCh <- executor.Run(ctx, nil) }()
+		// the ramped down VU shouldn't keep waiting for its next pacing slot
+		time.Sleep(500 * time.Millisecond)
+		assert.Equal(t, int64(1), es.GetCurrentlyActiveVUsCount())
+		require.NoError(t, <-errCh)
+	})
+
+	t.Run("MissedSlots", func(t *testing.T) {
+		t.Parallel()
+		config := RampingVUsConfig{
+			BaseConfig:       BaseConfig{GracefulStop: types.NullDurationFrom(0)},
+			GracefulRampDown: types.NullDurationFrom(0),
+			StartVUs:         null.IntFrom(1),
+			Stages: []Stage{
+				{Duration: types.NullDurationFrom(1 * time.Second), Target: null.IntFrom(1)},
+			},
+			Pacing: PacingConfig{Interval: types.NullDurationFrom(200 * time.Millisecond)},
+		}
+		et, err := lib.NewExecutionTuple(nil, nil)
+		require.NoError(t, err)
+		es := lib.NewExecutionState(lib.Options{SystemTags: stats.NewSystemTagSet(stats.TagVU)}, et, 1, 1)
+		ctx, cancel, executor, _ := setupExecutor(t, config, es, simpleRunner(func(ctx context.Context) error {
+			time.Sleep(250 * time.Millisecond)
+			return nil
+		}))
+		defer cancel()
+		engineOut := make(chan stats.SampleContainer, 100)
+		require.NoError(t, executor.Run(ctx, engineOut))
+
+		close(engineOut)
+		var missedSlots float64
+		for sc := range engineOut {
+			for _, s := range sc.GetSamples() {
+				if s.Metric == metrics.MissedPacingSlots {
+					missedSlots += s.Value
+					vu, ok := s.Tags.Get("vu")
+					assert.True(t, ok)
+					assert.NotEqual(t, "0", vu)
+				}
+			}
+		}
+		assert.True(t, missedSlots > 0)
+	})
+}
@@ -22,6 +22,7 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -53,6 +54,7 @@ type PerVUIterationsConfig struct {
 	VUs         null.Int           `json:"vus"`
 	Iterations  null.Int           `json:"iterations"`
 	MaxDuration types.NullDuration `json:"maxDuration"`
+	Pacing      PacingConfig       `json:"pacing"`
 }
 
 // NewPerVUIterationsConfig returns a PerVUIterationsConfig with default values
@@ -85,7 +87,9 @@ func (pvic PerVUIterationsConfig) GetIterations() int64 {
 func (pvic PerVUIterationsConfig) GetDescription(et *lib.ExecutionTuple) string {
 	return fmt.Sprintf("%d iterations for each of %d VUs%s",
 		pvic.GetIterations(), pvic.GetVUs(et),
-		pvic.getBaseInfo(fmt.Sprintf("maxDuration: %s", pvic.MaxDuration.Duration)))
+		pvic.getBaseInfo(getPacingInfo(
+			pvic.Pacing, fmt.Sprintf("maxDuration: %s", pvic.MaxDuration.Duration),
+		)...))
 }
 
 // Validate makes sure all options are configured and valid
@@ -105,7 +109,7 @@ func (pvic PerVUIterationsConfig) Validate() []error {
 		))
 	}
 
-	return errors
+	return append(errors, pvic.Pacing.Validate()...)
 }
 
 // GetExecutionRequirements returns the number of required VUs to run the
@@ -133,6 +137,7 @@ func (pvic PerVUIterationsConfig) NewExecutor(
 	return PerVUIterations{
 		BaseExecutor: NewBaseExecutor(pvic, es, logger),
 		config:       pvic,
+		duration:     newLiveDuration(time.Duration(pvic.MaxDuration.Duration), pvic.GetGracefulStop()),
 	}, nil
 }
 
@@ -144,26 +149,46 @@ func (pvic PerVUIterationsConfig) HasWork(et *lib.ExecutionTuple) bool {
 // PerVUIterations executes a specific number of iterations with each VU.
 type PerVUIterations struct {
 	*BaseExecutor
-	config PerVUIterationsConfig
+	config   PerVUIterationsConfig
+	duration *liveDuration
 }
 
-// Make sure we implement the lib.Executor interface.
-var _ lib.Executor = &PerVUIterations{}
+// Make sure we implement the lib.Executor and lib.LiveUpdatableExecutor interfaces.
+var (
+	_ lib.Executor              = &PerVUIterations{}
+	_ lib.LiveUpdatableExecutor = &PerVUIterations{}
+)
+
+// UpdateConfig validates the supplied config and applies it in real time. Only
+// the maxDuration can be changed while the executor is running.
+func (pvi PerVUIterations) UpdateConfig(_ context.Context, newConf interface{}) error {
+	newConfig, ok := newConf.(PerVUIterationsConfig)
+	if !ok {
+		return errors.New("invalid config type")
+	}
+	if err := validateLiveUpdate(pvi.GetConfig(), newConfig, "maxDuration"); err != nil {
+		return err
+	}
+	if err := pvi.duration.setRegularDuration(time.Duration(newConfig.MaxDuration.Duration)); err != nil {
+		return err
+	}
+	pvi.setConfig(newConfig)
+	return nil
+}
 
 // Run executes a specific number of iterations with each configured VU.
 // nolint:funlen
 func (pvi PerVUIterations) Run(parentCtx context.Context, out chan<- stats.SampleContainer) (err error) {
 	numVUs := pvi.config.GetVUs(pvi.executionState.ExecutionTuple)
 	iterations := pvi.config.GetIterations()
-	duration := time.Duration(pvi.config.MaxDuration.Duration)
-	gracefulStop := pvi.config.GetGracefulStop()
 
-	startTime, maxDurationCtx, regDurationCtx, cancel := getDurationContexts(parentCtx, duration, gracefulStop)
+	startTime, maxDurationCtx, regDurationCtx, cancel := pvi.duration.getDurationContexts(parentCtx)
 	defer cancel()
 
 	// Make sure the log and the progress bar have accurate information
 	pvi.logger.WithFields(logrus.Fields{
-		"vus": numVUs, "iterations": iterations, "maxDuration": duration, "type": pvi.config.GetType(),
+		"vus": numVUs, "iterations": iterations, "maxDuration": pvi.duration.getRegularDuration(),
+		"type": pvi.config.GetType(),
 	}).Debug("Starting executor run...")
 
 	totalIters := uint64(numVUs * iterations)
@@ -173,6 +198,7 @@ func (pvi PerVUIterations) Run(parentCtx context.Context, out chan<- stats.Sampl
 	itersFmt := pb.GetFixedLengthIntFormat(int64(totalIters))
 	progressFn := func() (float64, []string) {
 		spent := time.Since(startTime)
+		duration := pvi.duration.getRegularDuration()
 		progVUs := fmt.Sprintf(vusFmt+" VUs", numVUs)
 		currentDoneIters := atomic.LoadUint64(doneIters)
 		progIters := fmt.Sprintf(itersFmt+"/"+itersFmt+" iters, %d per VU",
@@ -216,18 +242,32 @@ func (pvi PerVUIterations) Run(parentCtx context.Context, out chan<- stats.Sampl
 
 		vuID := initVU.GetID()
 		activeVU := initVU.Activate(&newParams)
+		pacer := newPacer(pvi.config.Pacing, func(missed int64) {
+			stats.PushIfNotDone(parentCtx, out, stats.Sample{
+				Value: float64(missed), Metric: metrics.MissedPacingSlots,
+				Tags: pvi.getMetricTags(&vuID), Time: time.Now(),
+			})
+		})
+
+		dropRemaining := func(i int64) {
+			stats.PushIfNotDone(parentCtx, out, stats.Sample{
+				Value: float64(iterations - i), Metric: metrics.DroppedIterations,
+				Tags: pvi.getMetricTags(&vuID), Time: time.Now(),
+			})
+		}
 
 		for i := int64(0); i < iterations; i++ {
 			select {
 			case <-regDurationDone:
-				stats.PushIfNotDone(parentCtx, out, stats.Sample{
-					Value: float64(iterations - i), Metric: metrics.DroppedIterations,
-					Tags: pvi.getMetricTags(&vuID), Time: time.Now(),
-				})
+				dropRemaining(i)
 				return // don't make more iterations
 			default:
 				// continue looping
 			}
+			if !pacer.wait(maxDurationCtx, regDurationDone) {
+				dropRemaining(i)
+				return
+			}
 			runIteration(maxDurationCtx, activeVU)
 			atomic.AddUint64(doneIters, 1)
 		}
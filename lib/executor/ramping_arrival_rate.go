@@ -22,6 +22,7 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"sync"
@@ -161,6 +162,8 @@ func (varc RampingArrivalRateConfig) NewExecutor(
 	return RampingArrivalRate{
 		BaseExecutor: NewBaseExecutor(&varc, es, logger),
 		config:       varc,
+		duration:     newLiveDuration(sumStagesDuration(varc.Stages), varc.GetGracefulStop()),
+		stages:       &liveStages{stages: varc.Stages},
 	}, nil
 }
 
@@ -174,11 +177,83 @@ func (varc RampingArrivalRateConfig) HasWork(et *lib.ExecutionTuple) bool {
 // TODO: combine with the ConstantArrivalRate?
 type RampingArrivalRate struct {
 	*BaseExecutor
-	config RampingArrivalRateConfig
+	config   RampingArrivalRateConfig
+	duration *liveDuration
+	stages   *liveStages
 }
 
-// Make sure we implement the lib.Executor interface.
-var _ lib.Executor = &RampingArrivalRate{}
+// Make sure we implement the lib.Executor and lib.LiveUpdatableExecutor interfaces.
+var (
+	_ lib.Executor              = &RampingArrivalRate{}
+	_ lib.LiveUpdatableExecutor = &RampingArrivalRate{}
+)
+
+// liveStages keeps track of the stages of a running ramping-arrival-rate
+// executor. Stages can be changed or added, but only until their iterations
+// have started being scheduled.
+type liveStages struct {
+	mutex     sync.Mutex
+	stages    []Stage
+	scheduled int  // how many of the stages were (at least partially) scheduled
+	done      bool // whether all of the stages were scheduled
+}
+
+// next returns the next stage that should be scheduled, if there is one.
+func (ls *liveStages) next() (Stage, bool) {
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+	if ls.scheduled >= len(ls.stages) {
+		ls.done = true
+		return Stage{}, false
+	}
+	ls.scheduled++
+	return ls.stages[ls.scheduled-1], true
+}
+
+// update replaces the stages, as long as none of the already scheduled ones
+// were changed. The supplied callback is called while the lock is held, just
+// before the stages are replaced, and any error it returns aborts the update.
+func (ls *liveStages) update(newStages []Stage, beforeUpdate func() error) error {
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+	if ls.done {
+		return fmt.Errorf("all of the stages have already been scheduled")
+	}
+	if len(newStages) < ls.scheduled {
+		return fmt.Errorf("the first %d stages have already started and can't be removed", ls.scheduled)
+	}
+	for i := 0; i < ls.scheduled; i++ {
+		if newStages[i] != ls.stages[i] {
+			return fmt.Errorf("stage %d has already started and can't be changed", i+1)
+		}
+	}
+	if err := beforeUpdate(); err != nil {
+		return err
+	}
+	ls.stages = newStages
+	return nil
+}
+
+// UpdateConfig validates the supplied config and applies it in real time. Only
+// the stages that haven't started yet can be changed, and new stages can be
+// added, while the executor is running.
+func (varr RampingArrivalRate) UpdateConfig(_ context.Context, newConf interface{}) error {
+	newConfig, ok := newConf.(*RampingArrivalRateConfig)
+	if !ok {
+		return errors.New("invalid config type")
+	}
+	if err := validateLiveUpdate(varr.GetConfig(), newConfig, "stages"); err != nil {
+		return err
+	}
+	err := varr.stages.update(newConfig.Stages, func() error {
+		return varr.duration.setRegularDuration(sumStagesDuration(newConfig.Stages))
+	})
+	if err != nil {
+		return err
+	}
+	varr.setConfig(newConfig)
+	return nil
+}
 
 // cal calculates the  transtitions between stages and gives the next full value produced by the
 // stages. In this explanation we are talking about events and in practice those events are starting
@@ -239,6 +314,16 @@ var _ lib.Executor = &RampingArrivalRate{}
 // the striping algorithm from the lib.ExecutionTuple for additional speed up but this could
 // possibly be refactored if need for this arises.
 func (varc RampingArrivalRateConfig) cal(et *lib.ExecutionTuple, ch chan<- time.Duration) {
+	stages := &liveStages{stages: varc.Stages}
+	varc.calStages(et, ch, stages.next)
+}
+
+// calStages does the actual work of cal(), but it gets the stages one by one
+// from the supplied nextStage function, so they can be changed while the
+// executor is running.
+func (varc RampingArrivalRateConfig) calStages(
+	et *lib.ExecutionTuple, ch chan<- time.Duration, nextStage func() (Stage, bool),
+) {
 	start, offsets, _ := et.GetStripedOffsets()
 	li := -1
 	// TODO: move this to a utility function, or directly what GetStripedOffsets uses once we see everywhere we will use it
@@ -256,7 +341,7 @@ func (varc RampingArrivalRateConfig) cal(et *lib.ExecutionTuple, ch chan<- time.
 		i = float64(start + 1)
 	)
 
-	for _, stage := range varc.Stages {
+	for stage, ok := nextStage(); ok; stage, ok = nextStage() {
 		to = float64(stage.Target.ValueOrZero()) / timeUnit
 		dur = float64(stage.Duration.Duration)
 		if from != to { // ramp up/down
@@ -293,8 +378,6 @@ func (varc RampingArrivalRateConfig) cal(et *lib.ExecutionTuple, ch chan<- time.
 //nolint:funlen,gocognit
 func (varr RampingArrivalRate) Run(parentCtx context.Context, out chan<- stats.SampleContainer) (err error) {
 	segment := varr.executionState.ExecutionTuple.Segment
-	gracefulStop := varr.config.GetGracefulStop()
-	duration := sumStagesDuration(varr.config.Stages)
 	preAllocatedVUs := varr.config.GetPreAllocatedVUs(varr.executionState.ExecutionTuple)
 	maxVUs := varr.config.GetMaxVUs(varr.executionState.ExecutionTuple)
 
@@ -307,14 +390,14 @@ func (varr RampingArrivalRate) Run(parentCtx context.Context, out chan<- stats.S
 
 	// Make sure the log and the progress bar have accurate information
 	varr.logger.WithFields(logrus.Fields{
-		"maxVUs": maxVUs, "preAllocatedVUs": preAllocatedVUs, "duration": duration, "numStages": len(varr.config.Stages),
+		"maxVUs": maxVUs, "preAllocatedVUs": preAllocatedVUs, "duration": varr.duration.getRegularDuration(), "numStages": len(varr.config.Stages),
 		"startTickerPeriod": startTickerPeriod.Duration, "type": varr.config.GetType(),
 	}).Debug("Starting executor run...")
 
 	activeVUsWg := &sync.WaitGroup{}
 
 	returnedVUs := make(chan struct{})
-	startTime, maxDurationCtx, regDurationCtx, cancel := getDurationContexts(parentCtx, duration, gracefulStop)
+	startTime, maxDurationCtx, regDurationCtx, cancel := varr.duration.getDurationContexts(parentCtx)
 
 	defer func() {
 		// Make sure all VUs aren't executing iterations anymore, for the cancel()
@@ -391,6 +474,7 @@ func (varr RampingArrivalRate) Run(parentCtx context.Context, out chan<- stats.S
 		}
 		progIters := fmt.Sprintf(itersFmt, itersPerSec)
 
+		duration := varr.duration.getRegularDuration()
 		right := []string{progVUs, duration.String(), progIters}
 
 		spent := time.Since(startTime)
@@ -421,7 +505,7 @@ func (varr RampingArrivalRate) Run(parentCtx context.Context, out chan<- stats.S
 	var prevTime time.Duration
 	shownWarning := false
 	metricTags := varr.getMetricTags(nil)
-	go varr.config.cal(varr.executionState.ExecutionTuple, ch)
+	go varr.config.calStages(varr.executionState.ExecutionTuple, ch, varr.stages.next)
 	for nextTime := range ch {
 		select {
 		case <-regDurationDone:
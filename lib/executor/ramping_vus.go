@@ -22,6 +22,7 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -31,6 +32,7 @@ import (
 	"gopkg.in/guregu/null.v3"
 
 	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/metrics"
 	"github.com/loadimpact/k6/lib/types"
 	"github.com/loadimpact/k6/stats"
 	"github.com/loadimpact/k6/ui/pb"
@@ -62,6 +64,7 @@ type RampingVUsConfig struct {
 	StartVUs         null.Int           `json:"startVUs"`
 	Stages           []Stage            `json:"stages"`
 	GracefulRampDown types.NullDuration `json:"gracefulRampDown"`
+	Pacing           PacingConfig       `json:"pacing"`
 }
 
 // NewRampingVUsConfig returns a RampingVUsConfig with its default values
@@ -92,7 +95,9 @@ func (vlvc RampingVUsConfig) GetDescription(et *lib.ExecutionTuple) string {
 	maxVUs := et.ScaleInt64(getStagesUnscaledMaxTarget(vlvc.StartVUs.Int64, vlvc.Stages))
 	return fmt.Sprintf("Up to %d looping VUs for %s over %d stages%s",
 		maxVUs, sumStagesDuration(vlvc.Stages), len(vlvc.Stages),
-		vlvc.getBaseInfo(fmt.Sprintf("gracefulRampDown: %s", vlvc.GetGracefulRampDown())))
+		vlvc.getBaseInfo(getPacingInfo(
+			vlvc.Pacing, fmt.Sprintf("gracefulRampDown: %s", vlvc.GetGracefulRampDown()),
+		)...))
 }
 
 // Validate makes sure all options are configured and valid
@@ -102,7 +107,8 @@ func (vlvc RampingVUsConfig) Validate() []error {
 		errors = append(errors, fmt.Errorf("the number of start VUs shouldn't be negative"))
 	}
 
-	return append(errors, validateStages(vlvc.Stages)...)
+	errors = append(errors, validateStages(vlvc.Stages)...)
+	return append(errors, vlvc.Pacing.Validate()...)
 }
 
 // getRawExecutionSteps calculates and returns as execution steps the number of
@@ -528,9 +534,13 @@ func (vlvc RampingVUsConfig) GetExecutionRequirements(et *lib.ExecutionTuple) []
 
 // NewExecutor creates a new RampingVUs executor
 func (vlvc RampingVUsConfig) NewExecutor(es *lib.ExecutionState, logger *logrus.Entry) (lib.Executor, error) {
+	plan := newLiveRampingVUsPlan(vlvc, es.ExecutionTuple)
+	regularDuration, maxDuration := plan.getDurations()
 	return RampingVUs{
 		BaseExecutor: NewBaseExecutor(vlvc, es, logger),
 		config:       vlvc,
+		duration:     newLiveDuration(regularDuration, maxDuration-regularDuration),
+		plan:         plan,
 	}, nil
 }
 
@@ -544,11 +554,158 @@ func (vlvc RampingVUsConfig) HasWork(et *lib.ExecutionTuple) bool {
 // stages' duration.
 type RampingVUs struct {
 	*BaseExecutor
-	config RampingVUsConfig
+	config   RampingVUsConfig
+	duration *liveDuration
+	plan     *liveRampingVUsPlan
 }
 
-// Make sure we implement the lib.Executor interface.
-var _ lib.Executor = &RampingVUs{}
+// Make sure we implement the lib.Executor and lib.LiveUpdatableExecutor interfaces.
+var (
+	_ lib.Executor              = &RampingVUs{}
+	_ lib.LiveUpdatableExecutor = &RampingVUs{}
+)
+
+// liveRampingVUsPlan keeps the execution steps of a running ramping-vus
+// executor, which are recalculated when its stages or its gracefulRampDown
+// are changed. Only the stages that haven't finished yet can be changed.
+type liveRampingVUsPlan struct {
+	mutex     sync.Mutex
+	et        *lib.ExecutionTuple
+	config    RampingVUsConfig
+	raw       []lib.ExecutionStep
+	graceful  []lib.ExecutionStep
+	version   int
+	startTime time.Time // zero until the executor has started
+	done      bool      // whether all of the raw steps were executed
+	// updated wakes up the executor when the steps are changed
+	updated chan struct{}
+}
+
+func newLiveRampingVUsPlan(config RampingVUsConfig, et *lib.ExecutionTuple) *liveRampingVUsPlan {
+	plan := &liveRampingVUsPlan{et: et, updated: make(chan struct{}, 1)}
+	plan.setConfig(config)
+	return plan
+}
+
+func (lp *liveRampingVUsPlan) setConfig(config RampingVUsConfig) {
+	lp.config = config
+	lp.raw = config.getRawExecutionSteps(lp.et, true)
+	lp.graceful = config.GetExecutionRequirements(lp.et)
+}
+
+// getDurations returns the regular and the maximum duration of the plan.
+func (lp *liveRampingVUsPlan) getDurations() (regularDuration, maxDuration time.Duration) {
+	lp.mutex.Lock()
+	defer lp.mutex.Unlock()
+	regularDuration, _ = lib.GetEndOffset(lp.raw)
+	maxDuration, _ = lib.GetEndOffset(lp.graceful)
+	return regularDuration, maxDuration
+}
+
+// start marks the plan as started at the given time, and returns its steps.
+func (lp *liveRampingVUsPlan) start(startTime time.Time) (raw, graceful []lib.ExecutionStep, version int) {
+	lp.mutex.Lock()
+	defer lp.mutex.Unlock()
+	lp.startTime = startTime
+	return lp.raw, lp.graceful, lp.version
+}
+
+// get returns the current steps of the plan, and their version.
+func (lp *liveRampingVUsPlan) get() (raw, graceful []lib.ExecutionStep, version int) {
+	lp.mutex.Lock()
+	defer lp.mutex.Unlock()
+	return lp.raw, lp.graceful, lp.version
+}
+
+func (lp *liveRampingVUsPlan) getVersion() int {
+	lp.mutex.Lock()
+	defer lp.mutex.Unlock()
+	return lp.version
+}
+
+// finish marks all of the raw steps as executed, unless the plan was changed
+// since the given version, in which case it returns false.
+func (lp *liveRampingVUsPlan) finish(version int) bool {
+	lp.mutex.Lock()
+	defer lp.mutex.Unlock()
+	if lp.version != version {
+		return false
+	}
+	lp.done = true
+	return true
+}
+
+// update replaces the config of the plan, as long as none of the already
+// finished stages were changed and the current stage was only extended. The
+// supplied callback is called with the new steps while the lock is held, just
+// before they are replaced, and any error it returns aborts the update.
+func (lp *liveRampingVUsPlan) update(
+	newConfig RampingVUsConfig, beforeUpdate func(raw, graceful []lib.ExecutionStep) error,
+) error {
+	lp.mutex.Lock()
+	defer lp.mutex.Unlock()
+	if lp.done {
+		return fmt.Errorf("the executor has already finished its regular duration")
+	}
+	if !lp.startTime.IsZero() {
+		elapsed := time.Since(lp.startTime)
+		var end time.Duration
+		for i, stage := range lp.config.Stages {
+			start := end
+			end += time.Duration(stage.Duration.Duration)
+			if start > elapsed {
+				break
+			}
+			if i >= len(newConfig.Stages) {
+				return fmt.Errorf("stage %d has already started and can't be removed", i+1)
+			}
+			newStage := newConfig.Stages[i]
+			if end <= elapsed && newStage != stage {
+				return fmt.Errorf("stage %d has already finished and can't be changed", i+1)
+			}
+			if end > elapsed && (newStage.Target != stage.Target || newStage.Duration.Duration < stage.Duration.Duration) {
+				return fmt.Errorf("stage %d is in progress, its duration can only be extended", i+1)
+			}
+		}
+	}
+
+	newPlan := &liveRampingVUsPlan{et: lp.et}
+	newPlan.setConfig(newConfig)
+	if err := beforeUpdate(newPlan.raw, newPlan.graceful); err != nil {
+		return err
+	}
+	lp.config, lp.raw, lp.graceful = newConfig, newPlan.raw, newPlan.graceful
+	lp.version++
+	select {
+	case lp.updated <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// UpdateConfig validates the supplied config and applies it in real time. The
+// stages that haven't started yet can be changed, new stages can be added, the
+// current stage can be extended and the gracefulRampDown can be changed while
+// the executor is running.
+func (vlv RampingVUs) UpdateConfig(_ context.Context, newConf interface{}) error {
+	newConfig, ok := newConf.(RampingVUsConfig)
+	if !ok {
+		return errors.New("invalid config type")
+	}
+	if err := validateLiveUpdate(vlv.GetConfig(), newConfig, "stages", "gracefulRampDown"); err != nil {
+		return err
+	}
+	err := vlv.plan.update(newConfig, func(raw, graceful []lib.ExecutionStep) error {
+		regularDuration, _ := lib.GetEndOffset(raw)
+		maxDuration, _ := lib.GetEndOffset(graceful)
+		return vlv.duration.setDurations(regularDuration, maxDuration-regularDuration)
+	})
+	if err != nil {
+		return err
+	}
+	vlv.setConfig(newConfig)
+	return nil
+}
 
 // Run constantly loops through as many iterations as possible on a variable
 // number of VUs for the specified stages.
@@ -558,46 +715,45 @@ var _ lib.Executor = &RampingVUs{}
 // and see what happens)... :/ so maybe see how it can be split?
 // nolint:funlen,gocognit
 func (vlv RampingVUs) Run(parentCtx context.Context, out chan<- stats.SampleContainer) (err error) {
-	rawExecutionSteps := vlv.config.getRawExecutionSteps(vlv.executionState.ExecutionTuple, true)
+	config := vlv.GetConfig().(RampingVUsConfig)
+	startTime, maxDurationCtx, regDurationCtx, cancel := vlv.duration.getDurationContexts(parentCtx)
+	defer cancel()
+
+	rawExecutionSteps, gracefulExecutionSteps, version := vlv.plan.start(startTime)
 	regularDuration, isFinal := lib.GetEndOffset(rawExecutionSteps)
 	if !isFinal {
-		return fmt.Errorf("%s expected raw end offset at %s to be final", vlv.config.GetName(), regularDuration)
+		return fmt.Errorf("%s expected raw end offset at %s to be final", config.GetName(), regularDuration)
 	}
-
-	gracefulExecutionSteps := vlv.config.GetExecutionRequirements(vlv.executionState.ExecutionTuple)
 	maxDuration, isFinal := lib.GetEndOffset(gracefulExecutionSteps)
 	if !isFinal {
-		return fmt.Errorf("%s expected graceful end offset at %s to be final", vlv.config.GetName(), maxDuration)
+		return fmt.Errorf("%s expected graceful end offset at %s to be final", config.GetName(), maxDuration)
 	}
-	maxVUs := lib.GetMaxPlannedVUs(gracefulExecutionSteps)
-	gracefulStop := maxDuration - regularDuration
-
-	startTime, maxDurationCtx, regDurationCtx, cancel := getDurationContexts(parentCtx, regularDuration, gracefulStop)
-	defer cancel()
+	maxVUs := new(uint64)
+	*maxVUs = lib.GetMaxPlannedVUs(gracefulExecutionSteps)
 
 	activeVUs := &sync.WaitGroup{}
 	defer activeVUs.Wait()
 
 	// Make sure the log and the progress bar have accurate information
 	vlv.logger.WithFields(logrus.Fields{
-		"type": vlv.config.GetType(), "startVUs": vlv.config.GetStartVUs(vlv.executionState.ExecutionTuple), "maxVUs": maxVUs,
-		"duration": regularDuration, "numStages": len(vlv.config.Stages),
+		"type": config.GetType(), "startVUs": config.GetStartVUs(vlv.executionState.ExecutionTuple), "maxVUs": *maxVUs,
+		"duration": regularDuration, "numStages": len(config.Stages),
 	},
 	).Debug("Starting executor run...")
 
 	activeVUsCount := new(int64)
-	vusFmt := pb.GetFixedLengthIntFormat(int64(maxVUs))
-	regularDurationStr := pb.GetFixedLengthDuration(regularDuration, regularDuration)
+	vusFmt := pb.GetFixedLengthIntFormat(int64(*maxVUs))
 	progressFn := func() (float64, []string) {
 		spent := time.Since(startTime)
+		regularDuration := vlv.duration.getRegularDuration()
 		currentlyActiveVUs := atomic.LoadInt64(activeVUsCount)
-		vus := fmt.Sprintf(vusFmt+"/"+vusFmt+" VUs", currentlyActiveVUs, maxVUs)
+		vus := fmt.Sprintf(vusFmt+"/"+vusFmt+" VUs", currentlyActiveVUs, atomic.LoadUint64(maxVUs))
 		if spent > regularDuration {
 			return 1, []string{vus, regularDuration.String()}
 		}
-		progVUs := fmt.Sprintf(vusFmt+"/"+vusFmt+" VUs", currentlyActiveVUs, maxVUs)
-		progDur := pb.GetFixedLengthDuration(spent, regularDuration) + "/" + regularDurationStr
-		return float64(spent) / float64(regularDuration), []string{progVUs, progDur}
+		progDur := pb.GetFixedLengthDuration(spent, regularDuration) + "/" +
+			pb.GetFixedLengthDuration(regularDuration, regularDuration)
+		return float64(spent) / float64(regularDuration), []string{vus, progDur}
 	}
 	vlv.progress.Modify(pb.WithProgress(progressFn))
 	go trackProgress(parentCtx, maxDurationCtx, regDurationCtx, vlv, progressFn)
@@ -624,14 +780,29 @@ func (vlv RampingVUs) Run(parentCtx context.Context, out chan<- stats.SampleCont
 		vlv.executionState.ModCurrentlyActiveVUsCount(-1)
 	}
 
-	vuHandles := make([]*vuHandle, maxVUs)
-	for i := uint64(0); i < maxVUs; i++ {
-		vuHandle := newStoppedVUHandle(
-			maxDurationCtx, getVU, returnVU, &vlv.config.BaseConfig,
-			vlv.logger.WithField("vuNum", i))
-		go vuHandle.runLoopsIfPossible(runIteration)
-		vuHandles[i] = vuHandle
+	var vuHandles []*vuHandle
+	// addVUHandles makes sure there are enough VU handles for the planned
+	// VUs, since live config updates can increase their number
+	addVUHandles := func(plannedVUs uint64) {
+		for i := uint64(len(vuHandles)); i < plannedVUs; i++ {
+			vuHandle := newStoppedVUHandle(
+				maxDurationCtx, getVU, returnVU, &config.BaseConfig,
+				vlv.logger.WithField("vuNum", i))
+			pacer := newPacer(config.Pacing, func(missed int64) {
+				vuID := vuHandle.vuID()
+				stats.PushIfNotDone(parentCtx, out, stats.Sample{
+					Value: float64(missed), Metric: metrics.MissedPacingSlots,
+					Tags: vlv.getMetricTags(&vuID), Time: time.Now(),
+				})
+			})
+			go vuHandle.runLoopsIfPossible(pacer.wrap(runIteration, vuHandle.isRunning, vuHandle.stopSignal))
+			vuHandles = append(vuHandles, vuHandle)
+		}
+		if plannedVUs > atomic.LoadUint64(maxVUs) {
+			atomic.StoreUint64(maxVUs, plannedVUs)
+		}
 	}
+	addVUHandles(*maxVUs)
 
 	// 0 <= currentScheduledVUs <= currentMaxAllowedVUs <= maxVUs
 	var currentScheduledVUs, currentMaxAllowedVUs uint64
@@ -658,29 +829,103 @@ func (vlv RampingVUs) Run(parentCtx context.Context, out chan<- stats.SampleCont
 		currentMaxAllowedVUs = newMaxAllowedVUs
 	}
 
-	wait := waiter(parentCtx, startTime)
+	// resync switches to the current steps of the plan after a live config
+	// update, applying the VU numbers they have planned for this moment
+	var i, j int
+	resync := func() {
+		rawExecutionSteps, gracefulExecutionSteps, version = vlv.plan.get()
+		addVUHandles(lib.GetMaxPlannedVUs(gracefulExecutionSteps))
+		elapsed := time.Since(startTime)
+		i, j = 0, 0
+		for i < len(rawExecutionSteps) && rawExecutionSteps[i].TimeOffset <= elapsed {
+			i++
+		}
+		for j < len(gracefulExecutionSteps) && gracefulExecutionSteps[j].TimeOffset <= elapsed {
+			j++
+		}
+		var newScheduledVUs, newMaxAllowedVUs uint64
+		if i > 0 {
+			newScheduledVUs = rawExecutionSteps[i-1].PlannedVUs
+		}
+		if j > 0 {
+			newMaxAllowedVUs = gracefulExecutionSteps[j-1].PlannedVUs
+		}
+		if newMaxAllowedVUs >= currentMaxAllowedVUs {
+			handleNewMaxAllowedVUs(newMaxAllowedVUs)
+			handleNewScheduledVUs(newScheduledVUs)
+		} else {
+			handleNewScheduledVUs(newScheduledVUs)
+			handleNewMaxAllowedVUs(newMaxAllowedVUs)
+		}
+	}
+
+	// waitFor sleeps until the given offset since the startTime, returning
+	// true if the context was done before that or if the plan was updated
+	// in the meantime, in which case the steps are resynced
+	timer := time.NewTimer(time.Hour * 24)
+	defer timer.Stop()
+	waitFor := func(offset time.Duration) bool {
+		for {
+			if vlv.plan.getVersion() != version {
+				resync()
+				return true
+			}
+			offsetDiff := offset - time.Since(startTime)
+			if offsetDiff <= 0 {
+				return false
+			}
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(offsetDiff)
+			select {
+			case <-parentCtx.Done():
+				return true
+			case <-vlv.plan.updated:
+			case <-timer.C:
+				return false
+			}
+		}
+	}
+
 	// iterate over rawExecutionSteps and gracefulExecutionSteps in order by TimeOffset
 	// giving rawExecutionSteps precedence.
 	// we stop iterating once rawExecutionSteps are over as we need to run the remaining
 	// gracefulExecutionSteps concurrently while waiting for VUs to stop in order to not wait until
 	// the end of gracefulStop timeouts
-	i, j := 0, 0
-	for i != len(rawExecutionSteps) {
-		if rawExecutionSteps[i].TimeOffset > gracefulExecutionSteps[j].TimeOffset {
-			if wait(gracefulExecutionSteps[j].TimeOffset) {
-				return
-			}
-			handleNewMaxAllowedVUs(gracefulExecutionSteps[j].PlannedVUs)
-			j++
-		} else {
-			if wait(rawExecutionSteps[i].TimeOffset) {
-				return
+	for {
+		for i != len(rawExecutionSteps) {
+			if rawExecutionSteps[i].TimeOffset > gracefulExecutionSteps[j].TimeOffset {
+				if waitFor(gracefulExecutionSteps[j].TimeOffset) {
+					if parentCtx.Err() != nil {
+						return
+					}
+					continue
+				}
+				handleNewMaxAllowedVUs(gracefulExecutionSteps[j].PlannedVUs)
+				j++
+			} else {
+				if waitFor(rawExecutionSteps[i].TimeOffset) {
+					if parentCtx.Err() != nil {
+						return
+					}
+					continue
+				}
+				handleNewScheduledVUs(rawExecutionSteps[i].PlannedVUs)
+				i++
 			}
-			handleNewScheduledVUs(rawExecutionSteps[i].PlannedVUs)
-			i++
 		}
+		if vlv.plan.finish(version) {
+			break
+		}
+		resync() // the plan was updated after the last step
 	}
 
+	wait := waiter(parentCtx, startTime)
+
 	go func() { // iterate over the remaining gracefulExecutionSteps
 		for _, step := range gracefulExecutionSteps[j:] {
 			if wait(step.TimeOffset) {
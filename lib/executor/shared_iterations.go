@@ -22,6 +22,7 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -151,6 +152,7 @@ func (sic SharedIterationsConfig) NewExecutor(
 	return &SharedIterations{
 		BaseExecutor: NewBaseExecutor(sic, es, logger),
 		config:       sic,
+		duration:     newLiveDuration(time.Duration(sic.MaxDuration.Duration), sic.GetGracefulStop()),
 	}, nil
 }
 
@@ -158,12 +160,33 @@ func (sic SharedIterationsConfig) NewExecutor(
 // all shared by the configured VUs.
 type SharedIterations struct {
 	*BaseExecutor
-	config SharedIterationsConfig
-	et     *lib.ExecutionTuple
+	config   SharedIterationsConfig
+	et       *lib.ExecutionTuple
+	duration *liveDuration
 }
 
-// Make sure we implement the lib.Executor interface.
-var _ lib.Executor = &SharedIterations{}
+// Make sure we implement the lib.Executor and lib.LiveUpdatableExecutor interfaces.
+var (
+	_ lib.Executor              = &SharedIterations{}
+	_ lib.LiveUpdatableExecutor = &SharedIterations{}
+)
+
+// UpdateConfig validates the supplied config and applies it in real time. Only
+// the maxDuration can be changed while the executor is running.
+func (si SharedIterations) UpdateConfig(_ context.Context, newConf interface{}) error {
+	newConfig, ok := newConf.(SharedIterationsConfig)
+	if !ok {
+		return errors.New("invalid config type")
+	}
+	if err := validateLiveUpdate(si.GetConfig(), newConfig, "maxDuration"); err != nil {
+		return err
+	}
+	if err := si.duration.setRegularDuration(time.Duration(newConfig.MaxDuration.Duration)); err != nil {
+		return err
+	}
+	si.setConfig(newConfig)
+	return nil
+}
 
 // HasWork reports whether there is any work to be done for the given execution segment.
 func (sic SharedIterationsConfig) HasWork(et *lib.ExecutionTuple) bool {
@@ -185,15 +208,14 @@ func (si *SharedIterations) Init(ctx context.Context) error {
 func (si SharedIterations) Run(parentCtx context.Context, out chan<- stats.SampleContainer) (err error) {
 	numVUs := si.config.GetVUs(si.executionState.ExecutionTuple)
 	iterations := si.et.ScaleInt64(si.config.Iterations.Int64)
-	duration := time.Duration(si.config.MaxDuration.Duration)
-	gracefulStop := si.config.GetGracefulStop()
 
-	startTime, maxDurationCtx, regDurationCtx, cancel := getDurationContexts(parentCtx, duration, gracefulStop)
+	startTime, maxDurationCtx, regDurationCtx, cancel := si.duration.getDurationContexts(parentCtx)
 	defer cancel()
 
 	// Make sure the log and the progress bar have accurate information
 	si.logger.WithFields(logrus.Fields{
-		"vus": numVUs, "iterations": iterations, "maxDuration": duration, "type": si.config.GetType(),
+		"vus": numVUs, "iterations": iterations, "maxDuration": si.duration.getRegularDuration(),
+		"type": si.config.GetType(),
 	}).Debug("Starting executor run...")
 
 	totalIters := uint64(iterations)
@@ -202,6 +224,7 @@ func (si SharedIterations) Run(parentCtx context.Context, out chan<- stats.Sampl
 	itersFmt := pb.GetFixedLengthIntFormat(int64(totalIters))
 	progressFn := func() (float64, []string) {
 		spent := time.Since(startTime)
+		duration := si.duration.getRegularDuration()
 		progVUs := fmt.Sprintf(vusFmt+" VUs", numVUs)
 		currentDoneIters := atomic.LoadUint64(doneIters)
 		progIters := fmt.Sprintf(itersFmt+"/"+itersFmt+" shared iters",
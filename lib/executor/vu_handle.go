@@ -97,6 +97,9 @@ type vuHandle struct {
 	initVU       lib.InitializedVU
 	activeVU     lib.ActiveVU
 	canStartIter chan struct{}
+	// stopIter is closed when the VU is asked to stop, so it doesn't have to
+	// wait for things like its pacing schedule before stopping
+	stopIter chan struct{}
 
 	state stateType // see the table above for meanings
 	// stateH []int32 // helper for debugging
@@ -111,6 +114,8 @@ func newStoppedVUHandle(
 	returnVU func(lib.InitializedVU), config *BaseConfig, logger *logrus.Entry,
 ) *vuHandle {
 	ctx, cancel := context.WithCancel(parentCtx)
+	stopIter := make(chan struct{})
+	close(stopIter)
 
 	return &vuHandle{
 		mutex:     &sync.Mutex{},
@@ -119,6 +124,7 @@ func newStoppedVUHandle(
 		config:    config,
 
 		canStartIter: make(chan struct{}),
+		stopIter:     stopIter,
 		state:        stopped,
 
 		ctx:      ctx,
@@ -138,6 +144,7 @@ func (vh *vuHandle) start() (err error) {
 	case toGracefulStop: // we raced with the loop, lets not return the vu just to get it back
 		vh.logger.Debug("Start")
 		close(vh.canStartIter)
+		vh.stopIter = make(chan struct{})
 		vh.changeState(running)
 	case stopped, toHardStop: // we need to reactivate the VU and remake the context for it
 		vh.logger.Debug("Start")
@@ -148,11 +155,44 @@ func (vh *vuHandle) start() (err error) {
 
 		vh.activeVU = vh.initVU.Activate(getVUActivationParams(vh.ctx, *vh.config, vh.returnVU))
 		close(vh.canStartIter)
+		vh.stopIter = make(chan struct{})
 		vh.changeState(starting)
 	}
 	return nil
 }
 
+// isRunning returns whether the VU is currently allowed to run iterations.
+func (vh *vuHandle) isRunning() bool {
+	return stateType(atomic.LoadInt32((*int32)(&vh.state))) == running
+}
+
+// vuID returns the ID of the VU that the handle currently has.
+func (vh *vuHandle) vuID() int64 {
+	vh.mutex.Lock()
+	defer vh.mutex.Unlock()
+	if vh.initVU == nil {
+		return 0
+	}
+	return vh.initVU.GetID()
+}
+
+// stopSignal returns a channel that's closed when the VU is asked to stop.
+func (vh *vuHandle) stopSignal() <-chan struct{} {
+	vh.mutex.Lock()
+	defer vh.mutex.Unlock()
+	return vh.stopIter
+}
+
+// closeStopIter signals the current iteration that the VU is stopping, it must
+// be called with the mutex held
+func (vh *vuHandle) closeStopIter() {
+	select {
+	case <-vh.stopIter:
+	default:
+		close(vh.stopIter)
+	}
+}
+
 // just a helper function for debugging
 func (vh *vuHandle) changeState(newState stateType) {
 	// vh.stateH = append(vh.stateH, newState)
@@ -174,6 +214,7 @@ func (vh *vuHandle) gracefulStop() {
 	}
 
 	vh.logger.Debug("Graceful stop")
+	vh.closeStopIter()
 	vh.canStartIter = make(chan struct{})
 }
 
@@ -190,6 +231,7 @@ func (vh *vuHandle) hardStop() {
 		vh.changeState(toHardStop)
 	}
 	vh.logger.Debug("Hard stop")
+	vh.closeStopIter()
 	vh.cancel()
 	vh.ctx, vh.cancel = context.WithCancel(vh.parentCtx)
 	vh.canStartIter = make(chan struct{})
@@ -93,6 +93,7 @@ type ExecutorConfig interface {
 	//
 	// TODO: use interface{} so plain http requests can be specified?
 	GetExec() string
+	GetWeightedExec() WeightedExecs
 	GetTags() map[string]string
 
 	// Calculates the VU requirements in different stages of the executor's
@@ -131,8 +132,9 @@ type PausableExecutor interface {
 }
 
 // LiveUpdatableExecutor should be implemented for the executors whose
-// configuration can be modified in the middle of the test execution. Currently,
-// only the manual execution executor implements it.
+// configuration can be modified in the middle of the test execution. Each
+// executor decides which of its options can be safely changed while it's
+// running and returns an error for any other changes.
 type LiveUpdatableExecutor interface {
 	UpdateConfig(ctx context.Context, newConfig interface{}) error
 }
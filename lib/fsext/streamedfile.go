@@ -0,0 +1,71 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package fsext
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/afero"
+)
+
+// StreamedFile is a reference to a file that is read from the filesystem every
+// time it's needed, instead of having its contents copied in memory. It's safe
+// to use it from multiple goroutines.
+type StreamedFile struct {
+	fs   afero.Fs
+	path string
+	size int64
+}
+
+// NewStreamedFile checks that the given file can be read and returns a
+// StreamedFile for it. The file is opened once, so filesystems that cache the
+// files on read, like CacheOnReadFs, keep a copy that can later be archived.
+func NewStreamedFile(fs afero.Fs, path string) (*StreamedFile, error) {
+	f, err := fs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%q is a directory", path)
+	}
+	return &StreamedFile{fs: fs, path: path, size: info.Size()}, nil
+}
+
+// Open returns a new reader for the contents of the file.
+func (f *StreamedFile) Open() (io.ReadCloser, error) {
+	return f.fs.Open(f.path)
+}
+
+// Size returns the size of the file, in bytes.
+func (f *StreamedFile) Size() int64 {
+	return f.size
+}
+
+// Path returns the path of the file in its filesystem.
+func (f *StreamedFile) Path() string {
+	return f.path
+}
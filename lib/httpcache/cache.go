@@ -0,0 +1,463 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package httpcache implements a private HTTP cache that emulates the cache
+// of a browser, as described in RFC 7234.
+package httpcache
+
+import (
+	"bytes"
+	"container/list"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Status describes how a response was obtained by the cache.
+type Status string
+
+// The possible cache statuses of a response.
+const (
+	// StatusHit means that the response was served from the cache, without
+	// making a request.
+	StatusHit Status = "hit"
+	// StatusRevalidated means that the cached response was served after the
+	// server confirmed that it's still valid with a 304 Not Modified response.
+	StatusRevalidated Status = "revalidated"
+	// StatusMiss means that the response was fetched from the server.
+	StatusMiss Status = "miss"
+)
+
+// the response statuses that are cacheable by default, see
+// https://tools.ietf.org/html/rfc7231#section-6.1
+//
+//nolint:gochecknoglobals
+var cacheableStatuses = map[int]bool{
+	200: true, 203: true, 204: true, 300: true, 301: true,
+	404: true, 405: true, 410: true, 414: true, 501: true,
+}
+
+// The limits of the size of the cache, which is approximated by the size of the
+// bodies and the headers of the stored responses.
+const (
+	// DefaultMaxSize is the size of the cache of a VU, unless specified
+	DefaultMaxSize = 32 << 20
+	// MaxEntrySize is the size of the largest response that's stored, or the
+	// size of the cache if it's smaller
+	MaxEntrySize = 4 << 20
+)
+
+// entry is a stored response
+type entry struct {
+	status       int
+	proto        string
+	header       http.Header
+	body         []byte
+	vary         map[string]string // the values of the request headers in Vary
+	requestTime  time.Time
+	responseTime time.Time
+}
+
+// size returns the approximate size of the entry in memory
+func (e *entry) size() int64 {
+	size := int64(len(e.body))
+	for name, values := range e.header {
+		for _, value := range values {
+			size += int64(len(name) + len(value))
+		}
+	}
+	return size
+}
+
+// element is an entry in the list of the cache, which is ordered from the most
+// to the least recently used one
+type element struct {
+	key   string
+	entry *entry
+}
+
+// Cache is a private HTTP cache, safe for concurrent use. When it's full, the
+// least recently used responses are evicted.
+type Cache struct {
+	mu           sync.Mutex
+	entries      map[string]*list.Element
+	lru          *list.List
+	size         int64
+	maxSize      int64
+	maxEntrySize int64
+	now          func() time.Time
+}
+
+// New returns a new empty Cache with the given maximum size in bytes, or
+// DefaultMaxSize if it's not positive.
+func New(maxSize int64) *Cache {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	maxEntrySize := int64(MaxEntrySize)
+	if maxEntrySize > maxSize {
+		maxEntrySize = maxSize
+	}
+	return &Cache{
+		entries:      make(map[string]*list.Element),
+		lru:          list.New(),
+		maxSize:      maxSize,
+		maxEntrySize: maxEntrySize,
+		now:          time.Now,
+	}
+}
+
+// Reset removes all the entries from the cache.
+func (c *Cache) Reset() {
+	c.mu.Lock()
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.size = 0
+	c.mu.Unlock()
+}
+
+// RoundTrip serves the request from the cache if there's a fresh stored
+// response for it. Otherwise, it makes the request with the given transport,
+// revalidating any stale stored response, and stores the response if it's
+// cacheable once its body has been read completely.
+func (c *Cache) RoundTrip(req *http.Request, transport http.RoundTripper) (*http.Response, Status, error) {
+	key := req.URL.String()
+	reqCC := parseCacheControl(req.Header)
+
+	if req.Method != http.MethodGet {
+		resp, err := transport.RoundTrip(req)
+		if err == nil && req.Method != http.MethodHead && resp.StatusCode < 400 {
+			// unsafe methods invalidate the stored responses for the URL
+			c.mu.Lock()
+			c.remove(key)
+			c.mu.Unlock()
+		}
+		return resp, StatusMiss, err
+	}
+	if _, noStore := reqCC["no-store"]; noStore {
+		resp, err := transport.RoundTrip(req)
+		return resp, StatusMiss, err
+	}
+
+	stored := c.load(key)
+	if stored != nil && !stored.matchesVary(req) {
+		stored = nil
+	}
+
+	if stored != nil && stored.isFresh(c.now(), reqCC) {
+		return stored.response(req), StatusHit, nil
+	}
+
+	outReq := req
+	if stored != nil {
+		if etag := stored.header.Get("ETag"); etag != "" || stored.header.Get("Last-Modified") != "" {
+			outReq = req.Clone(req.Context())
+			if etag != "" {
+				outReq.Header.Set("If-None-Match", etag)
+			}
+			if lastModified := stored.header.Get("Last-Modified"); lastModified != "" {
+				outReq.Header.Set("If-Modified-Since", lastModified)
+			}
+		} else {
+			stored = nil
+		}
+	}
+
+	requestTime := c.now()
+	resp, err := transport.RoundTrip(outReq)
+	if err != nil {
+		return resp, StatusMiss, err
+	}
+
+	if stored != nil && resp.StatusCode == http.StatusNotModified {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		updated := stored.update(resp.Header, requestTime, c.now())
+		c.store(key, updated)
+		return updated.response(req), StatusRevalidated, nil
+	}
+
+	if c.isCacheable(resp) {
+		resp.Body = &storingBody{
+			ReadCloser: resp.Body,
+			maxSize:    c.maxEntrySize,
+			onEOF: func(body []byte) {
+				c.store(key, &entry{
+					status:       resp.StatusCode,
+					proto:        resp.Proto,
+					header:       resp.Header.Clone(),
+					body:         body,
+					vary:         varyValues(req, resp.Header),
+					requestTime:  requestTime,
+					responseTime: c.now(),
+				})
+			},
+		}
+	}
+	return resp, StatusMiss, nil
+}
+
+// load returns the stored response for the key, if any, and marks it as the
+// most recently used one
+func (c *Cache) load(key string) *entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	c.lru.MoveToFront(el)
+	return el.Value.(*element).entry
+}
+
+// store stores a response, evicting the least recently used ones while the
+// cache is too big. Responses bigger than the entry limit aren't stored.
+func (c *Cache) store(key string, e *entry) {
+	size := e.size()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remove(key)
+	if size > c.maxEntrySize {
+		return
+	}
+	for c.size+size > c.maxSize && c.lru.Len() > 0 {
+		c.remove(c.lru.Back().Value.(*element).key)
+	}
+	c.entries[key] = c.lru.PushFront(&element{key: key, entry: e})
+	c.size += size
+}
+
+// remove removes the stored response for the key, it must be called with the
+// mutex held
+func (c *Cache) remove(key string) {
+	el, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	c.lru.Remove(el)
+	delete(c.entries, key)
+	c.size -= el.Value.(*element).entry.size()
+}
+
+func (c *Cache) isCacheable(resp *http.Response) bool {
+	if !cacheableStatuses[resp.StatusCode] {
+		return false
+	}
+	respCC := parseCacheControl(resp.Header)
+	if _, noStore := respCC["no-store"]; noStore {
+		return false
+	}
+	if strings.TrimSpace(resp.Header.Get("Vary")) == "*" {
+		return false
+	}
+	// Event streams never end, so they can't be buffered
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return false
+	}
+	// Responses with neither freshness information nor validators would
+	// never be used
+	e := &entry{header: resp.Header, responseTime: c.now()}
+	return e.freshnessLifetime() > 0 || resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+}
+
+// storingBody buffers the response body while it's being read and calls
+// onEOF with it once it has been read completely. Bodies bigger than maxSize
+// aren't buffered, and onEOF isn't called for them.
+type storingBody struct {
+	io.ReadCloser
+	buf     bytes.Buffer
+	maxSize int64
+	onEOF   func([]byte)
+	done    bool
+}
+
+func (b *storingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if !b.done {
+		if int64(b.buf.Len()+n) > b.maxSize {
+			// too big to be stored, so there's no point in buffering the rest
+			b.done = true
+			b.buf = bytes.Buffer{}
+		} else {
+			b.buf.Write(p[:n])
+		}
+	}
+	if err == io.EOF && !b.done {
+		b.done = true
+		b.onEOF(b.buf.Bytes())
+	}
+	return n, err
+}
+
+func (e *entry) response(req *http.Request) *http.Response {
+	major, minor, _ := http.ParseHTTPVersion(e.proto)
+	return &http.Response{
+		Status:        strconv.Itoa(e.status) + " " + http.StatusText(e.status),
+		StatusCode:    e.status,
+		Proto:         e.proto,
+		ProtoMajor:    major,
+		ProtoMinor:    minor,
+		Header:        e.header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+// update returns a copy of the entry with the headers of a 304 response
+func (e *entry) update(header http.Header, requestTime, responseTime time.Time) *entry {
+	updated := *e
+	updated.header = e.header.Clone()
+	for k, v := range header {
+		switch k {
+		case "Content-Length", "Content-Encoding", "Transfer-Encoding":
+			continue
+		}
+		updated.header[k] = v
+	}
+	updated.requestTime, updated.responseTime = requestTime, responseTime
+	return &updated
+}
+
+func (e *entry) matchesVary(req *http.Request) bool {
+	for name, value := range e.vary {
+		if req.Header.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+func varyValues(req *http.Request, header http.Header) map[string]string {
+	values := make(map[string]string)
+	for _, vary := range header.Values("Vary") {
+		for _, name := range strings.Split(vary, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				values[http.CanonicalHeaderKey(name)] = req.Header.Get(name)
+			}
+		}
+	}
+	return values
+}
+
+// freshnessLifetime returns for how long the response is fresh, see
+// https://tools.ietf.org/html/rfc7234#section-4.2.1
+func (e *entry) freshnessLifetime() time.Duration {
+	respCC := parseCacheControl(e.header)
+	if _, noCache := respCC["no-cache"]; noCache {
+		return 0
+	}
+	if maxAge, ok := respCC.duration("max-age"); ok {
+		return maxAge
+	}
+
+	date := e.date()
+	if expiresHeader := e.header.Get("Expires"); expiresHeader != "" {
+		expires, err := http.ParseTime(expiresHeader)
+		if err != nil {
+			return 0 // invalid values mean that the response is already expired
+		}
+		return expires.Sub(date)
+	}
+
+	// the heuristic suggested in https://tools.ietf.org/html/rfc7234#section-4.2.2
+	if lastModified, err := http.ParseTime(e.header.Get("Last-Modified")); err == nil && date.After(lastModified) {
+		return date.Sub(lastModified) / 10
+	}
+	return 0
+}
+
+func (e *entry) date() time.Time {
+	if date, err := http.ParseTime(e.header.Get("Date")); err == nil {
+		return date
+	}
+	return e.responseTime
+}
+
+// currentAge returns the age of the response, see
+// https://tools.ietf.org/html/rfc7234#section-4.2.3
+func (e *entry) currentAge(now time.Time) time.Duration {
+	apparentAge := e.responseTime.Sub(e.date())
+	if apparentAge < 0 {
+		apparentAge = 0
+	}
+	responseDelay := e.responseTime.Sub(e.requestTime)
+	correctedAge := responseDelay
+	if age, err := strconv.ParseInt(e.header.Get("Age"), 10, 64); err == nil {
+		correctedAge += time.Duration(age) * time.Second
+	}
+	if apparentAge > correctedAge {
+		correctedAge = apparentAge
+	}
+	return correctedAge + now.Sub(e.responseTime)
+}
+
+func (e *entry) isFresh(now time.Time, reqCC cacheControl) bool {
+	if _, noCache := reqCC["no-cache"]; noCache {
+		return false
+	}
+	lifetime := e.freshnessLifetime()
+	if maxAge, ok := reqCC.duration("max-age"); ok && maxAge < lifetime {
+		lifetime = maxAge
+	}
+	return e.currentAge(now) < lifetime
+}
+
+// cacheControl holds the parsed Cache-Control directives
+type cacheControl map[string]string
+
+func parseCacheControl(header http.Header) cacheControl {
+	cc := cacheControl{}
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+			name, arg := directive, ""
+			if i := strings.IndexByte(directive, '='); i >= 0 {
+				name, arg = directive[:i], strings.Trim(directive[i+1:], `"`)
+			}
+			cc[strings.ToLower(strings.TrimSpace(name))] = arg
+		}
+	}
+	// Pragma: no-cache is the same as Cache-Control: no-cache for requests
+	if _, ok := cc["no-cache"]; !ok && strings.EqualFold(header.Get("Pragma"), "no-cache") {
+		cc["no-cache"] = ""
+	}
+	return cc
+}
+
+func (cc cacheControl) duration(name string) (time.Duration, bool) {
+	arg, ok := cc[name]
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, true
+	}
+	return time.Duration(seconds) * time.Second, true
+}
@@ -0,0 +1,330 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package httpcache
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// testServer returns a transport that serves the responses from the handler,
+// and a pointer to the number of requests it has received
+func testServer(handler http.HandlerFunc) (http.RoundTripper, *int) {
+	requests := 0
+	return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		resp := rec.Result()
+		resp.Request = req
+		return resp, nil
+	}), &requests
+}
+
+func newTestCache(now *time.Time) *Cache {
+	c := New(0)
+	c.now = func() time.Time { return *now }
+	return c
+}
+
+func get(t *testing.T, c *Cache, transport http.RoundTripper, url string, header ...string) (string, Status) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, status, err := c.RoundTrip(req, transport)
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	return string(body), status
+}
+
+func TestCacheMaxAge(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	c := newTestCache(&now)
+	version := "v1"
+	transport, requests := testServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("ETag", `"`+version+`"`)
+		if r.Header.Get("If-None-Match") == `"`+version+`"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte(version))
+	})
+
+	body, status := get(t, c, transport, "http://example.com/")
+	assert.Equal(t, "v1", body)
+	assert.Equal(t, StatusMiss, status)
+
+	body, status = get(t, c, transport, "http://example.com/")
+	assert.Equal(t, "v1", body)
+	assert.Equal(t, StatusHit, status)
+	assert.Equal(t, 1, *requests)
+
+	now = now.Add(2 * time.Minute)
+	body, status = get(t, c, transport, "http://example.com/")
+	assert.Equal(t, "v1", body)
+	assert.Equal(t, StatusRevalidated, status)
+	assert.Equal(t, 2, *requests)
+
+	// The revalidation refreshed the stored response
+	body, status = get(t, c, transport, "http://example.com/")
+	assert.Equal(t, "v1", body)
+	assert.Equal(t, StatusHit, status)
+	assert.Equal(t, 2, *requests)
+
+	// The request directives are respected
+	_, status = get(t, c, transport, "http://example.com/", "Cache-Control", "no-cache")
+	assert.Equal(t, StatusRevalidated, status)
+	_, status = get(t, c, transport, "http://example.com/", "Pragma", "no-cache")
+	assert.Equal(t, StatusRevalidated, status)
+	_, status = get(t, c, transport, "http://example.com/", "Cache-Control", "no-store")
+	assert.Equal(t, StatusMiss, status)
+	assert.Equal(t, 5, *requests)
+
+	version = "v2"
+	now = now.Add(2 * time.Minute)
+	body, status = get(t, c, transport, "http://example.com/")
+	assert.Equal(t, "v2", body)
+	assert.Equal(t, StatusMiss, status)
+
+	c.Reset()
+	_, status = get(t, c, transport, "http://example.com/")
+	assert.Equal(t, StatusMiss, status)
+}
+
+func TestCacheLastModified(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	c := newTestCache(&now)
+	lastModified := now.Add(-100 * time.Minute).UTC().Format(http.TimeFormat)
+	transport, requests := testServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", now.UTC().Format(http.TimeFormat))
+		w.Header().Set("Last-Modified", lastModified)
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("content"))
+	})
+
+	_, status := get(t, c, transport, "http://example.com/")
+	assert.Equal(t, StatusMiss, status)
+
+	// The heuristic freshness is 10% of the time since the last modification
+	now = now.Add(5 * time.Minute)
+	_, status = get(t, c, transport, "http://example.com/")
+	assert.Equal(t, StatusHit, status)
+
+	now = now.Add(10 * time.Minute)
+	body, status := get(t, c, transport, "http://example.com/")
+	assert.Equal(t, "content", body)
+	assert.Equal(t, StatusRevalidated, status)
+	assert.Equal(t, 2, *requests)
+}
+
+func TestCacheExpires(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	c := newTestCache(&now)
+	transport, requests := testServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", now.UTC().Format(http.TimeFormat))
+		w.Header().Set("Expires", now.Add(time.Hour).UTC().Format(http.TimeFormat))
+		_, _ = w.Write([]byte("content"))
+	})
+
+	get(t, c, transport, "http://example.com/")
+	_, status := get(t, c, transport, "http://example.com/")
+	assert.Equal(t, StatusHit, status)
+
+	// Without validators, stale responses are fetched again
+	now = now.Add(2 * time.Hour)
+	_, status = get(t, c, transport, "http://example.com/")
+	assert.Equal(t, StatusMiss, status)
+	assert.Equal(t, 2, *requests)
+}
+
+func TestCacheNotStored(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]http.HandlerFunc{
+		"no-store": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "no-store, max-age=60")
+		},
+		"no freshness or validators": func(w http.ResponseWriter, r *http.Request) {},
+		"uncacheable status": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.WriteHeader(http.StatusInternalServerError)
+		},
+		"vary all": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Vary", "*")
+		},
+		"event stream": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Content-Type", "text/event-stream")
+		},
+	}
+	for name, handler := range testCases {
+		handler := handler
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			now := time.Now()
+			c := newTestCache(&now)
+			transport, requests := testServer(handler)
+			get(t, c, transport, "http://example.com/")
+			_, status := get(t, c, transport, "http://example.com/")
+			assert.Equal(t, StatusMiss, status)
+			assert.Equal(t, 2, *requests)
+		})
+	}
+
+	t.Run("body not read", func(t *testing.T) {
+		t.Parallel()
+		now := time.Now()
+		c := newTestCache(&now)
+		transport, requests := testServer(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "max-age=60")
+			_, _ = w.Write([]byte("content"))
+		})
+		req, err := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+		require.NoError(t, err)
+		resp, _, err := c.RoundTrip(req, transport)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+
+		_, status := get(t, c, transport, "http://example.com/")
+		assert.Equal(t, StatusMiss, status)
+		assert.Equal(t, 2, *requests)
+	})
+}
+
+func TestCacheVary(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	c := newTestCache(&now)
+	transport, requests := testServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		_, _ = w.Write([]byte(r.Header.Get("Accept-Language")))
+	})
+
+	body, _ := get(t, c, transport, "http://example.com/", "Accept-Language", "en")
+	assert.Equal(t, "en", body)
+	body, status := get(t, c, transport, "http://example.com/", "Accept-Language", "en")
+	assert.Equal(t, "en", body)
+	assert.Equal(t, StatusHit, status)
+	body, status = get(t, c, transport, "http://example.com/", "Accept-Language", "de")
+	assert.Equal(t, "de", body)
+	assert.Equal(t, StatusMiss, status)
+	assert.Equal(t, 2, *requests)
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	c := newTestCache(&now)
+	transport, requests := testServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+	})
+
+	get(t, c, transport, "http://example.com/")
+	_, status := get(t, c, transport, "http://example.com/")
+	assert.Equal(t, StatusHit, status)
+
+	req, err := http.NewRequest(http.MethodPost, "http://example.com/", nil)
+	require.NoError(t, err)
+	_, status, err = c.RoundTrip(req, transport)
+	require.NoError(t, err)
+	assert.Equal(t, StatusMiss, status)
+
+	_, status = get(t, c, transport, "http://example.com/")
+	assert.Equal(t, StatusMiss, status)
+	assert.Equal(t, 3, *requests)
+}
+
+func TestCacheEviction(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	c := New(400)
+	c.now = func() time.Time { return now }
+	transport, requests := testServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+	})
+
+	get(t, c, transport, "http://example.com/1")
+	get(t, c, transport, "http://example.com/2")
+	// using the first response makes the second one the least recently used
+	_, status := get(t, c, transport, "http://example.com/1")
+	assert.Equal(t, StatusHit, status)
+	get(t, c, transport, "http://example.com/3")
+	assert.Equal(t, 3, *requests)
+	assert.True(t, c.size <= c.maxSize)
+
+	_, status = get(t, c, transport, "http://example.com/1")
+	assert.Equal(t, StatusHit, status)
+	_, status = get(t, c, transport, "http://example.com/3")
+	assert.Equal(t, StatusHit, status)
+	_, status = get(t, c, transport, "http://example.com/2")
+	assert.Equal(t, StatusMiss, status)
+	assert.Equal(t, 4, *requests)
+
+	c.Reset()
+	assert.Equal(t, int64(0), c.size)
+	assert.Equal(t, 0, c.lru.Len())
+}
+
+func TestCacheEntryTooBig(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	c := New(100)
+	c.now = func() time.Time { return now }
+	transport, requests := testServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte(strings.Repeat("x", 200)))
+	})
+
+	body, status := get(t, c, transport, "http://example.com/")
+	assert.Len(t, body, 200)
+	assert.Equal(t, StatusMiss, status)
+	body, status = get(t, c, transport, "http://example.com/")
+	assert.Len(t, body, 200)
+	assert.Equal(t, StatusMiss, status)
+	assert.Equal(t, 2, *requests)
+	assert.Equal(t, int64(0), c.size)
+}
@@ -34,6 +34,7 @@ var (
 	Iterations        = stats.New("iterations", stats.Counter)
 	IterationDuration = stats.New("iteration_duration", stats.Trend, stats.Time)
 	DroppedIterations = stats.New("dropped_iterations", stats.Counter)
+	MissedPacingSlots = stats.New("missed_pacing_slots", stats.Counter)
 	Errors            = stats.New("errors", stats.Counter)
 
 	// Runner-emitted.
@@ -41,15 +42,18 @@ var (
 	GroupDuration = stats.New("group_duration", stats.Trend, stats.Time)
 
 	// HTTP-related.
-	HTTPReqs              = stats.New("http_reqs", stats.Counter)
-	HTTPReqFailed         = stats.New("http_req_failed", stats.Rate)
-	HTTPReqDuration       = stats.New("http_req_duration", stats.Trend, stats.Time)
-	HTTPReqBlocked        = stats.New("http_req_blocked", stats.Trend, stats.Time)
-	HTTPReqConnecting     = stats.New("http_req_connecting", stats.Trend, stats.Time)
-	HTTPReqTLSHandshaking = stats.New("http_req_tls_handshaking", stats.Trend, stats.Time)
-	HTTPReqSending        = stats.New("http_req_sending", stats.Trend, stats.Time)
-	HTTPReqWaiting        = stats.New("http_req_waiting", stats.Trend, stats.Time)
-	HTTPReqReceiving      = stats.New("http_req_receiving", stats.Trend, stats.Time)
+	HTTPReqs               = stats.New("http_reqs", stats.Counter)
+	HTTPReqFailed          = stats.New("http_req_failed", stats.Rate)
+	HTTPReqDuration        = stats.New("http_req_duration", stats.Trend, stats.Time)
+	HTTPReqBlocked         = stats.New("http_req_blocked", stats.Trend, stats.Time)
+	HTTPReqConnecting      = stats.New("http_req_connecting", stats.Trend, stats.Time)
+	HTTPReqTLSHandshaking  = stats.New("http_req_tls_handshaking", stats.Trend, stats.Time)
+	HTTPReqProxyConnecting = stats.New("http_req_proxy_connecting", stats.Trend, stats.Time)
+	HTTPReqSending         = stats.New("http_req_sending", stats.Trend, stats.Time)
+	HTTPReqWaiting         = stats.New("http_req_waiting", stats.Trend, stats.Time)
+	HTTPReqReceiving       = stats.New("http_req_receiving", stats.Trend, stats.Time)
+	HTTPReqConnReused      = stats.New("http_req_conn_reused", stats.Rate)
+	HTTPConnsOpened        = stats.New("http_conns_opened", stats.Counter)
 
 	// Websocket-related
 	WSSessions         = stats.New("ws_sessions", stats.Counter)
@@ -59,6 +63,18 @@ var (
 	WSSessionDuration  = stats.New("ws_session_duration", stats.Trend, stats.Time)
 	WSConnecting       = stats.New("ws_connecting", stats.Trend, stats.Time)
 
+	// Server-Sent Events related
+	SSEEventsReceived   = stats.New("sse_events_received", stats.Counter)
+	SSETimeToFirstEvent = stats.New("sse_time_to_first_event", stats.Trend, stats.Time)
+	SSESessionDuration  = stats.New("sse_session_duration", stats.Trend, stats.Time)
+
+	// Raw socket related
+	NetConnecting     = stats.New("net_connecting", stats.Trend, stats.Time)
+	NetTLSHandshaking = stats.New("net_tls_handshaking", stats.Trend, stats.Time)
+	NetBytesSent      = stats.New("net_bytes_sent", stats.Counter, stats.Data)
+	NetBytesReceived  = stats.New("net_bytes_received", stats.Counter, stats.Data)
+	NetErrors         = stats.New("net_errors", stats.Counter)
+
 	// gRPC-related
 	GRPCReqDuration = stats.New("grpc_req_duration", stats.Trend, stats.Time)
 
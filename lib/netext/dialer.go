@@ -25,7 +25,9 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"strings"
 	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/loadimpact/k6/lib"
@@ -46,6 +48,8 @@ type Dialer struct {
 
 	BytesRead    int64
 	BytesWritten int64
+
+	networkConditions atomic.Value
 }
 
 // NewDialer constructs a new Dialer with the given DNS resolver.
@@ -82,12 +86,52 @@ func (d *Dialer) DialContext(ctx context.Context, proto, addr string) (net.Conn,
 	if err != nil {
 		return nil, err
 	}
-	conn, err := d.Dialer.DialContext(ctx, proto, dialAddr)
+	dialer := d.Dialer
+	// The local address is a TCP one when localIPs is set, so it needs to be
+	// converted for UDP connections
+	if tcpAddr, ok := dialer.LocalAddr.(*net.TCPAddr); ok && strings.HasPrefix(proto, "udp") {
+		dialer.LocalAddr = &net.UDPAddr{IP: tcpAddr.IP, Port: tcpAddr.Port, Zone: tcpAddr.Zone}
+	}
+	conditions := d.GetNetworkConditions()
+	if conditions.IsActive() && strings.HasPrefix(proto, "tcp") {
+		// Emulate the latency of the TCP handshake
+		emulator := newNetworkEmulator(conditions, false)
+		control := dialer.Control
+		dialer.Control = func(network, address string, c syscall.RawConn) error {
+			select {
+			case <-time.After(emulator.roundTripDelay()):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			if control != nil {
+				return control(network, address, c)
+			}
+			return nil
+		}
+	}
+	conn, err := dialer.DialContext(ctx, proto, dialAddr)
 	if err != nil {
 		return nil, err
 	}
-	conn = &Conn{conn, &d.BytesRead, &d.BytesWritten}
-	return conn, err
+	trackedConn := &Conn{Conn: conn, BytesRead: &d.BytesRead, BytesWritten: &d.BytesWritten}
+	if conditions.IsActive() {
+		_, isPacketConn := conn.(net.PacketConn)
+		trackedConn.network = newNetworkEmulator(conditions, isPacketConn)
+	}
+	return trackedConn, nil
+}
+
+// SetNetworkConditions sets the network conditions that are emulated for any
+// new connections.
+func (d *Dialer) SetNetworkConditions(conditions types.NetworkConditions) {
+	d.networkConditions.Store(conditions)
+}
+
+// GetNetworkConditions returns the network conditions that are emulated for
+// new connections.
+func (d *Dialer) GetNetworkConditions() types.NetworkConditions {
+	conditions, _ := d.networkConditions.Load().(types.NetworkConditions)
+	return conditions
 }
 
 // GetTrail creates a new NetTrail instance with the Dialer
@@ -244,23 +288,34 @@ func (ntr *NetTrail) GetTime() time.Time {
 	return ntr.EndTime
 }
 
-// Conn wraps net.Conn and keeps track of sent and received data size
+// Conn wraps net.Conn and keeps track of sent and received data size, and
+// emulates the network conditions, if there are any.
 type Conn struct {
 	net.Conn
 
 	BytesRead, BytesWritten *int64
+
+	network *networkEmulator
 }
 
-func (c *Conn) Read(b []byte) (int, error) {
-	n, err := c.Conn.Read(b)
+func (c *Conn) Read(b []byte) (n int, err error) {
+	if c.network != nil {
+		n, err = c.network.read(c.Conn, b)
+	} else {
+		n, err = c.Conn.Read(b)
+	}
 	if n > 0 {
 		atomic.AddInt64(c.BytesRead, int64(n))
 	}
 	return n, err
 }
 
-func (c *Conn) Write(b []byte) (int, error) {
-	n, err := c.Conn.Write(b)
+func (c *Conn) Write(b []byte) (n int, err error) {
+	if c.network != nil {
+		n, err = c.network.write(c.Conn, b)
+	} else {
+		n, err = c.Conn.Write(b)
+	}
 	if n > 0 {
 		atomic.AddInt64(c.BytesWritten, int64(n))
 	}
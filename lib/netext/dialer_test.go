@@ -21,6 +21,7 @@
 package netext
 
 import (
+	"context"
 	"net"
 	"testing"
 
@@ -134,3 +135,23 @@ func newResolver() *mockresolver.MockResolver {
 		}, nil,
 	)
 }
+
+func TestDialerUDPLocalAddr(t *testing.T) {
+	t.Parallel()
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = server.Close() }()
+
+	dialer := NewDialer(net.Dialer{LocalAddr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1")}}, newResolver())
+	conn, err := dialer.DialContext(context.Background(), "udp", server.LocalAddr().String())
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	localAddr, ok := conn.LocalAddr().(*net.UDPAddr)
+	require.True(t, ok)
+	require.Equal(t, "127.0.0.1", localAddr.IP.String())
+
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+	require.Equal(t, int64(4), dialer.BytesWritten)
+}
@@ -0,0 +1,175 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package httpext
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+
+	"github.com/loadimpact/k6/lib/types"
+)
+
+// Transport is a round tripper that keeps idle connections, like http.Transport.
+type Transport interface {
+	http.RoundTripper
+	CloseIdleConnections()
+}
+
+// ConfigureHTTP2 sets up the HTTP/2 support of the given transport according
+// to the http2 settings in the httpTransport options, and returns the round
+// tripper that should be used for the requests.
+func ConfigureHTTP2(t *http.Transport, cfg types.HTTPTransportConfig) (Transport, error) {
+	switch cfg.HTTP2.String {
+	case types.HTTP2Disabled:
+		// A non-nil empty map disables the automatic HTTP/2 support
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		return t, nil
+	case types.HTTP2H2C:
+		if err := configureTLSHTTP2(t, cfg); err != nil {
+			return nil, err
+		}
+		return &h2cTransport{Transport: t, h2c: newH2CTransport(t, cfg)}, nil
+	default:
+		return t, configureTLSHTTP2(t, cfg)
+	}
+}
+
+func configureTLSHTTP2(t *http.Transport, cfg types.HTTPTransportConfig) error {
+	if !cfg.HTTP2ReadIdleTimeout.Valid && !cfg.HTTP2PingTimeout.Valid {
+		return http2.ConfigureTransport(t)
+	}
+
+	// http2.ConfigureTransport() doesn't allow us to change the settings of
+	// the HTTP/2 transport it creates, so we do the same thing it does, with a
+	// connection pool that only contains the connections that the HTTP/1.1
+	// transport upgraded after the ALPN negotiation.
+	pool := &h2ConnPool{conns: make(map[string][]*http2.ClientConn)}
+	t2 := &http2.Transport{
+		ConnPool:           pool,
+		TLSClientConfig:    t.TLSClientConfig,
+		DisableCompression: t.DisableCompression,
+		ReadIdleTimeout:    time.Duration(cfg.HTTP2ReadIdleTimeout.Duration),
+		PingTimeout:        time.Duration(cfg.HTTP2PingTimeout.Duration),
+	}
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{} //nolint:gosec
+	}
+	t.TLSClientConfig.NextProtos = append([]string{http2.NextProtoTLS, "http/1.1"}, t.TLSClientConfig.NextProtos...)
+	if t.TLSNextProto == nil {
+		t.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	t.TLSNextProto[http2.NextProtoTLS] = func(authority string, c *tls.Conn) http.RoundTripper {
+		cc, err := t2.NewClientConn(c)
+		if err != nil {
+			_ = c.Close()
+			return erringRoundTripper{err}
+		}
+		pool.add(authority, cc)
+		return t2
+	}
+	return nil
+}
+
+func newH2CTransport(t *http.Transport, cfg types.HTTPTransportConfig) *http2.Transport {
+	return &http2.Transport{
+		AllowHTTP:          true,
+		DisableCompression: t.DisableCompression,
+		ReadIdleTimeout:    time.Duration(cfg.HTTP2ReadIdleTimeout.Duration),
+		PingTimeout:        time.Duration(cfg.HTTP2PingTimeout.Duration),
+		// The transport uses DialTLS for all connections, even the cleartext ones
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			return t.DialContext(context.Background(), network, addr)
+		},
+	}
+}
+
+// h2cTransport uses cleartext HTTP/2 (with prior knowledge) for plain HTTP
+// requests, and the regular transport for everything else. Proxies aren't
+// supported for the cleartext HTTP/2 requests.
+type h2cTransport struct {
+	*http.Transport
+	h2c *http2.Transport
+}
+
+func (t *h2cTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "http" {
+		return t.h2c.RoundTrip(req)
+	}
+	return t.Transport.RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of both transports.
+func (t *h2cTransport) CloseIdleConnections() {
+	t.Transport.CloseIdleConnections()
+	t.h2c.CloseIdleConnections()
+}
+
+// h2ConnPool implements http2.ClientConnPool for the connections that were
+// upgraded by the HTTP/1.1 transport. Like the pool of
+// http2.ConfigureTransport(), it never dials new connections, it just tells
+// the HTTP/1.1 transport to do so.
+type h2ConnPool struct {
+	mu    sync.Mutex
+	conns map[string][]*http2.ClientConn
+}
+
+func (p *h2ConnPool) add(addr string, cc *http2.ClientConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.conns[addr] = append(p.conns[addr], cc)
+}
+
+func (p *h2ConnPool) GetClientConn(_ *http.Request, addr string) (*http2.ClientConn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, cc := range p.conns[addr] {
+		if cc.CanTakeNewRequest() {
+			return cc, nil
+		}
+	}
+	return nil, http2.ErrNoCachedConn
+}
+
+func (p *h2ConnPool) MarkDead(deadCC *http2.ClientConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for addr, conns := range p.conns {
+		for i, cc := range conns {
+			if cc == deadCC {
+				p.conns[addr] = append(conns[:i], conns[i+1:]...)
+				if len(p.conns[addr]) == 0 {
+					delete(p.conns, addr)
+				}
+				return
+			}
+		}
+	}
+}
+
+type erringRoundTripper struct{ err error }
+
+func (rt erringRoundTripper) RoundTrip(*http.Request) (*http.Response, error) { return nil, rt.err }
@@ -0,0 +1,145 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package httpext
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/loadimpact/k6/lib/types"
+)
+
+func protoHandler(w http.ResponseWriter, r *http.Request) {
+	_, _ = w.Write([]byte(r.Proto))
+}
+
+func getProto(t *testing.T, rt http.RoundTripper, url string) string {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	res, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+	defer func() { _ = res.Body.Close() }()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, res.Proto, string(body))
+	return string(body)
+}
+
+func newTestTransport(srv *httptest.Server) *http.Transport {
+	dialer := &net.Dialer{}
+	return &http.Transport{
+		TLSClientConfig: srv.Client().Transport.(*http.Transport).TLSClientConfig.Clone(),
+		DialContext:     dialer.DialContext,
+	}
+}
+
+func TestConfigureHTTP2(t *testing.T) {
+	t.Parallel()
+	tlsSrv := httptest.NewUnstartedServer(http.HandlerFunc(protoHandler))
+	tlsSrv.EnableHTTP2 = true
+	tlsSrv.StartTLS()
+	defer tlsSrv.Close()
+
+	// A cleartext HTTP/2 server with prior knowledge
+	h2cListener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = h2cListener.Close() }()
+	go func() {
+		for {
+			conn, err := h2cListener.Accept()
+			if err != nil {
+				return
+			}
+			go (&http2.Server{}).ServeConn(conn, &http2.ServeConnOpts{Handler: http.HandlerFunc(protoHandler)})
+		}
+	}()
+	h2cURL := "http://" + h2cListener.Addr().String() + "/"
+
+	t.Run("enabled", func(t *testing.T) {
+		rt, err := ConfigureHTTP2(newTestTransport(tlsSrv), types.HTTPTransportConfig{})
+		require.NoError(t, err)
+		assert.Equal(t, "HTTP/2.0", getProto(t, rt, tlsSrv.URL))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		rt, err := ConfigureHTTP2(newTestTransport(tlsSrv), types.HTTPTransportConfig{
+			HTTP2: null.StringFrom(types.HTTP2Disabled),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "HTTP/1.1", getProto(t, rt, tlsSrv.URL))
+	})
+
+	t.Run("health checks", func(t *testing.T) {
+		rt, err := ConfigureHTTP2(newTestTransport(tlsSrv), types.HTTPTransportConfig{
+			HTTP2ReadIdleTimeout: types.NullDurationFrom(time.Second),
+			HTTP2PingTimeout:     types.NullDurationFrom(time.Second),
+		})
+		require.NoError(t, err)
+
+		var newConns int
+		trace := &httptrace.ClientTrace{GotConn: func(info httptrace.GotConnInfo) {
+			if !info.Reused {
+				newConns++
+			}
+		}}
+		for i := 0; i < 3; i++ {
+			req, err := http.NewRequest(http.MethodGet, tlsSrv.URL, nil)
+			require.NoError(t, err)
+			res, err := rt.RoundTrip(req.WithContext(httptrace.WithClientTrace(context.Background(), trace)))
+			require.NoError(t, err)
+			assert.Equal(t, "HTTP/2.0", res.Proto)
+			_, _ = ioutil.ReadAll(res.Body)
+			_ = res.Body.Close()
+		}
+		assert.Equal(t, 1, newConns)
+	})
+
+	t.Run("h2c", func(t *testing.T) {
+		rt, err := ConfigureHTTP2(newTestTransport(tlsSrv), types.HTTPTransportConfig{
+			HTTP2: null.StringFrom(types.HTTP2H2C),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "HTTP/2.0", getProto(t, rt, h2cURL))
+		assert.Equal(t, "HTTP/2.0", getProto(t, rt, tlsSrv.URL))
+		rt.CloseIdleConnections()
+	})
+}
+
+func TestConfigureHTTP2WithTLS(t *testing.T) {
+	t.Parallel()
+	tr := &http.Transport{}
+	_, err := ConfigureHTTP2(tr, types.HTTPTransportConfig{HTTP2PingTimeout: types.NullDurationFrom(time.Second)})
+	require.NoError(t, err)
+	require.NotNil(t, tr.TLSClientConfig)
+	assert.Equal(t, []string{http2.NextProtoTLS, "http/1.1"}, tr.TLSClientConfig.NextProtos)
+	assert.Contains(t, tr.TLSNextProto, http2.NextProtoTLS)
+}
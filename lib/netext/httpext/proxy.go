@@ -0,0 +1,59 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package httpext
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+type proxyKey struct{}
+
+type tracerKey struct{}
+
+// WithProxy returns a new context that makes the HTTP requests with it go
+// through the given proxy, if the transport uses ResolveProxy. A nil proxy
+// means that the proxy environment variables should be used.
+func WithProxy(ctx context.Context, proxy *url.URL) context.Context {
+	if proxy == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, proxyKey{}, proxy)
+}
+
+// ResolveProxy is meant to be used as the Proxy function of the VU transports.
+// It returns the proxy from the request context, if one was set with
+// WithProxy(), or otherwise falls back to http.ProxyFromEnvironment.
+func ResolveProxy(req *http.Request) (*url.URL, error) {
+	ctx := req.Context()
+	proxy, _ := ctx.Value(proxyKey{}).(*url.URL)
+	if proxy == nil {
+		var err error
+		if proxy, err = http.ProxyFromEnvironment(req); err != nil {
+			return nil, err
+		}
+	}
+	if tracer, ok := ctx.Value(tracerKey{}).(*Tracer); ok && proxy != nil {
+		tracer.setProxied()
+	}
+	return proxy, nil
+}
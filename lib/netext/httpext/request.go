@@ -23,6 +23,7 @@ package httpext
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -39,6 +40,7 @@ import (
 	"gopkg.in/guregu/null.v3"
 
 	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/types"
 	"github.com/loadimpact/k6/stats"
 )
 
@@ -105,6 +107,8 @@ type Request struct {
 type ParsedHTTPRequest struct {
 	URL              *URL
 	Body             *bytes.Buffer
+	StreamBody       *StreamBody // used instead of Body for big bodies that shouldn't be kept in memory
+	Chunked          bool        // send the body with chunked transfer encoding
 	Req              *http.Request
 	Timeout          time.Duration
 	Auth             string
@@ -116,6 +120,8 @@ type ParsedHTTPRequest struct {
 	ActiveJar        *cookiejar.Jar
 	Cookies          map[string]*HTTPRequestCookie
 	Tags             map[string]string
+	Proxy            *url.URL // overrides the proxy of the VU, if set
+	Retry            types.RetryConfig
 }
 
 // Matches non-compliant io.Closer implementations (e.g. zstd.Decoder)
@@ -161,13 +167,14 @@ func updateK6Response(k6Response *Response, finishedReq *finishedRequest) {
 		k6Response.RemotePort = remotePort
 	}
 	k6Response.Timings = ResponseTimings{
-		Duration:       stats.D(trail.Duration),
-		Blocked:        stats.D(trail.Blocked),
-		Connecting:     stats.D(trail.Connecting),
-		TLSHandshaking: stats.D(trail.TLSHandshaking),
-		Sending:        stats.D(trail.Sending),
-		Waiting:        stats.D(trail.Waiting),
-		Receiving:      stats.D(trail.Receiving),
+		Duration:        stats.D(trail.Duration),
+		Blocked:         stats.D(trail.Blocked),
+		Connecting:      stats.D(trail.Connecting),
+		TLSHandshaking:  stats.D(trail.TLSHandshaking),
+		ProxyConnecting: stats.D(trail.ProxyConnecting),
+		Sending:         stats.D(trail.Sending),
+		Waiting:         stats.D(trail.Waiting),
+		Receiving:       stats.D(trail.Receiving),
 	}
 }
 
@@ -216,6 +223,24 @@ func MakeRequest(ctx context.Context, preq *ParsedHTTPRequest) (*Response, error
 		preq.Req.Body, _ = preq.Req.GetBody()
 	}
 
+	if preq.StreamBody != nil {
+		if len(preq.Compressions) > 0 {
+			return nil, errors.New("compression isn't supported for streamed request bodies")
+		}
+		preq.Req.ContentLength = preq.StreamBody.Size()
+		preq.Req.GetBody = preq.StreamBody.Open
+		body, err := preq.Req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("couldn't open the streamed request body: %w", err)
+		}
+		preq.Req.Body = body
+	}
+
+	if preq.Chunked && preq.Req.Body != nil {
+		preq.Req.ContentLength = -1 // unknown, so Go will use chunked transfer encoding
+		preq.Req.Header.Del("Content-Length")
+	}
+
 	if contentLengthHeader := preq.Req.Header.Get("Content-Length"); contentLengthHeader != "" {
 		// The content-length header was set by the user, delete it (since Go
 		// will set it automatically) and warn if there were differences
@@ -243,10 +268,79 @@ func MakeRequest(ctx context.Context, preq *ParsedHTTPRequest) (*Response, error
 		tags["name"] = preq.URL.Name
 	}
 
+	retry := preq.Retry
+	attempts := retry.GetAttempts()
+	retries := []RetryAttempt{}
+	var resp *Response
+	var resErr error
+	for attempt := 1; ; attempt++ {
+		attemptTags := tags
+		if attempts > 1 && state.Options.SystemTags.Has(stats.TagAttempt) {
+			attemptTags = make(map[string]string, len(tags)+1)
+			for k, v := range tags {
+				attemptTags[k] = v
+			}
+			attemptTags["attempt"] = strconv.Itoa(attempt)
+		}
+
+		var err error
+		resp, resErr, err = doRequest(ctx, state, preq, respReq, attemptTags)
+		if err != nil {
+			return nil, err
+		}
+		if attempt >= attempts || !shouldRetry(retry, resp) {
+			break
+		}
+		if preq.Req.Body != nil && preq.Req.GetBody == nil {
+			break // the body can't be sent again
+		}
+
+		delay := getRetryDelay(retry, attempt)
+		retries = append(retries, RetryAttempt{
+			Attempt:   attempt,
+			Status:    resp.Status,
+			Error:     resp.Error,
+			ErrorCode: resp.ErrorCode,
+			Timings:   resp.Timings,
+			Delay:     stats.D(delay),
+		})
+		if !waitForRetry(ctx, delay) {
+			break
+		}
+		if preq.Req.GetBody != nil {
+			if preq.Req.Body, err = preq.Req.GetBody(); err != nil {
+				return nil, fmt.Errorf("couldn't rewind the request body to retry the request: %w", err)
+			}
+		}
+	}
+	resp.Retries = retries
+
+	if resErr != nil {
+		if preq.Throw { // if we are going to throw, we shouldn't log it
+			return nil, resErr
+		}
+
+		// Do *not* log errors about the context being cancelled.
+		select {
+		case <-ctx.Done():
+		default:
+			state.Logger.WithField("error", resErr).Warn("Request Failed")
+		}
+	}
+
+	return resp, nil
+}
+
+// doRequest makes a single attempt of the given request. Besides the response,
+// it returns the error of the request itself (if any) and an error if the
+// request couldn't be made at all.
+func doRequest(
+	ctx context.Context, state *lib.State, preq *ParsedHTTPRequest, respReq *Request, tags map[string]string,
+) (resp *Response, resErr error, err error) {
 	// Check rate limit *after* we've prepared a request; no need to wait with that part.
 	if rpsLimit := state.RPSLimit; rpsLimit != nil {
 		if err := rpsLimit.Wait(ctx); err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
@@ -293,7 +387,7 @@ func MakeRequest(ctx context.Context, preq *ParsedHTTPRequest) (*Response, error
 		transport = ntlmssp.Negotiator{RoundTripper: transport}
 	}
 
-	resp := &Response{ctx: ctx, URL: preq.URL.URL, Request: *respReq}
+	resp = &Response{ctx: ctx, URL: preq.URL.URL, Request: *respReq}
 	client := http.Client{
 		Transport: transport,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
@@ -324,7 +418,11 @@ func MakeRequest(ctx context.Context, preq *ParsedHTTPRequest) (*Response, error
 		},
 	}
 
-	reqCtx, cancelFunc := context.WithTimeout(ctx, preq.Timeout)
+	proxy := preq.Proxy
+	if proxy == nil {
+		proxy = state.Proxy
+	}
+	reqCtx, cancelFunc := context.WithTimeout(WithProxy(ctx, proxy), preq.Timeout)
 	defer cancelFunc()
 	mreq := preq.Req.WithContext(reqCtx)
 	res, resErr := client.Do(mreq)
@@ -335,10 +433,18 @@ func MakeRequest(ctx context.Context, preq *ParsedHTTPRequest) (*Response, error
 	// unusable until https://github.com/golang/go/issues/31391 is fixed.
 	if res != nil && res.StatusCode == http.StatusSwitchingProtocols {
 		_ = res.Body.Close()
-		return nil, fmt.Errorf("unsupported response status: %s", res.Status)
+		return nil, nil, fmt.Errorf("unsupported response status: %s", res.Status)
 	}
 
+	var bodyCounter *countingReadCloser
+	if res != nil && res.Body != nil {
+		bodyCounter = &countingReadCloser{ReadCloser: res.Body}
+		res.Body = bodyCounter
+	}
 	resp.Body, resErr = readResponseBody(state, preq.ResponseType, res, resErr)
+	if bodyCounter != nil {
+		resp.BodySize = bodyCounter.count
+	}
 	finishedReq := tracerTransport.processLastSavedRequest(wrapDecompressionError(resErr))
 	if finishedReq != nil {
 		updateK6Response(resp, finishedReq)
@@ -381,20 +487,7 @@ func MakeRequest(ctx context.Context, preq *ParsedHTTPRequest) (*Response, error
 		}
 	}
 
-	if resErr != nil {
-		if preq.Throw { // if we are going to throw, we shouldn't log it
-			return nil, resErr
-		}
-
-		// Do *not* log errors about the context being cancelled.
-		select {
-		case <-ctx.Done():
-		default:
-			state.Logger.WithField("error", resErr).Warn("Request Failed")
-		}
-	}
-
-	return resp, nil
+	return resp, resErr, nil
 }
 
 // SetRequestCookies sets the cookies of the requests getting those cookies both from the jar and
@@ -25,6 +25,7 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
 
 	"github.com/pkg/errors"
 	"github.com/tidwall/gjson"
@@ -34,7 +35,8 @@ import (
 
 // ResponseType is used in the request to specify how the response body should be treated
 // The conversion and validation methods are auto-generated with https://github.com/alvaroloes/enumer:
-//nolint: lll
+// nolint: lll
+//
 //go:generate enumer -type=ResponseType -transform=snake -json -text -trimprefix ResponseType -output response_type_gen.go
 type ResponseType uint
 
@@ -54,6 +56,7 @@ const (
 	// to null. This saves CPU and memory and is suitable for HTTP requests that we just
 	// want to  measure, but we don't care about their responses' contents. This is the
 	// default value for all requests if the global discardResponseBodies is enablled.
+	// Only the number of received bytes is kept, in the body_size of the response.
 	ResponseTypeNone
 )
 
@@ -70,14 +73,37 @@ func (j jsonError) Error() string {
 
 // ResponseTimings is a struct to put all timings for a given HTTP response/request
 type ResponseTimings struct {
-	Duration       float64 `json:"duration"`
-	Blocked        float64 `json:"blocked"`
-	LookingUp      float64 `json:"looking_up"`
-	Connecting     float64 `json:"connecting"`
-	TLSHandshaking float64 `json:"tls_handshaking"`
-	Sending        float64 `json:"sending"`
-	Waiting        float64 `json:"waiting"`
-	Receiving      float64 `json:"receiving"`
+	Duration        float64 `json:"duration"`
+	Blocked         float64 `json:"blocked"`
+	LookingUp       float64 `json:"looking_up"`
+	Connecting      float64 `json:"connecting"`
+	TLSHandshaking  float64 `json:"tls_handshaking"`
+	ProxyConnecting float64 `json:"proxy_connecting"`
+	Sending         float64 `json:"sending"`
+	Waiting         float64 `json:"waiting"`
+	Receiving       float64 `json:"receiving"`
+}
+
+// countingReadCloser counts the bytes that were read through it
+type countingReadCloser struct {
+	io.ReadCloser
+	count int64
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.count += int64(n)
+	return n, err
+}
+
+// RetryAttempt describes a failed attempt of a request that was retried
+type RetryAttempt struct {
+	Attempt   int             `json:"attempt"`
+	Status    int             `json:"status"`
+	Error     string          `json:"error"`
+	ErrorCode int             `json:"error_code"`
+	Timings   ResponseTimings `json:"timings"`
+	Delay     float64         `json:"delay"` // before the next attempt
 }
 
 // HTTPCookie is a representation of an http cookies used in the Response object
@@ -108,6 +134,8 @@ type Response struct {
 	Error          string                   `json:"error"`
 	ErrorCode      int                      `json:"error_code"`
 	Request        Request                  `json:"request"`
+	Retries        []RetryAttempt           `json:"retries"`
+	BodySize       int64                    `json:"body_size"` // as received, before any decompression
 
 	cachedJSON    interface{}
 	validatedJSON bool
@@ -0,0 +1,80 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package httpext
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/loadimpact/k6/lib"
+)
+
+// StreamingResponse is an HTTP response whose body is consumed incrementally by
+// the caller, e.g. for long-lived event streams. The HTTP metrics for the
+// request are emitted once the response is finished.
+type StreamingResponse struct {
+	*http.Response
+
+	transport  *transport
+	finishOnce sync.Once
+	trail      *Trail
+}
+
+// OpenStream sends the given request through the VU transport and returns as
+// soon as the response headers have been received, without reading the body.
+// Redirects are followed, with the limit set by the maxRedirects option. The
+// request context controls the connection, while the metrics are emitted as
+// long as the given ctx isn't done.
+func OpenStream(
+	ctx context.Context, state *lib.State, req *http.Request, tags map[string]string,
+) (*StreamingResponse, error) {
+	tracerTransport := newTransport(ctx, state, tags, nil)
+	client := http.Client{
+		Transport: tracerTransport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if int64(len(via)) > state.Options.MaxRedirects.Int64 {
+				return http.ErrUseLastResponse
+			}
+			return nil
+		},
+	}
+
+	res, err := client.Do(req.WithContext(WithProxy(req.Context(), state.Proxy)))
+	if err != nil {
+		tracerTransport.processLastSavedRequest(err)
+		return nil, err
+	}
+	return &StreamingResponse{Response: res, transport: tracerTransport}, nil
+}
+
+// Finish closes the response body and emits the HTTP metrics for the request.
+// The given error, if any, is recorded as the request error. It's safe to call
+// Finish multiple times, only the first call has any effect.
+func (r *StreamingResponse) Finish(err error) *Trail {
+	r.finishOnce.Do(func() {
+		_ = r.Body.Close()
+		if finished := r.transport.processLastSavedRequest(err); finished != nil {
+			r.trail = finished.trail
+		}
+	})
+	return r.trail
+}
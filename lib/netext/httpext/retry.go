@@ -0,0 +1,103 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package httpext
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/loadimpact/k6/lib/types"
+)
+
+//nolint:gochecknoglobals
+var defaultRetryStatuses = []int{
+	http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout,
+}
+
+// isRetriableErrCode returns true for the error codes of network errors that
+// may not happen again if the request is retried.
+func isRetriableErrCode(code errCode) bool {
+	switch {
+	case code == blackListedIPErrorCode, code == blockedHostnameErrorCode:
+		return false // these are our own errors, they won't go away
+	case code == x509UnknownAuthorityErrorCode, code == x509HostnameErrorCode:
+		return false
+	case code >= defaultErrorCode && code < 1400:
+		return true
+	case code >= 1600 && code < 1700: // HTTP/2 errors
+		return true
+	default:
+		return false
+	}
+}
+
+// shouldRetry checks if the given response matches the statuses or error
+// codes that should be retried. If neither of them were specified, network
+// errors and 502, 503 and 504 responses are retried.
+func shouldRetry(cfg types.RetryConfig, resp *Response) bool {
+	statuses := cfg.Statuses
+	if cfg.Statuses == nil && cfg.ErrorCodes == nil {
+		if isRetriableErrCode(errCode(resp.ErrorCode)) {
+			return true
+		}
+		statuses = defaultRetryStatuses
+	}
+	if resp.Status != 0 {
+		for _, status := range statuses {
+			if resp.Status == status {
+				return true
+			}
+		}
+	}
+	if resp.ErrorCode != 0 {
+		for _, code := range cfg.ErrorCodes {
+			if resp.ErrorCode == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getRetryDelay returns how long to wait before the given retry, with the
+// configured jitter applied.
+func getRetryDelay(cfg types.RetryConfig, retry int) time.Duration {
+	delay := cfg.GetDelay(retry)
+	if jitter := cfg.Jitter.Float64; jitter > 0 {
+		delay += time.Duration((rand.Float64()*2 - 1) * jitter * float64(delay)) //nolint:gosec
+	}
+	return delay
+}
+
+// waitForRetry blocks for the given delay and returns false if the context
+// was done in the meantime.
+func waitForRetry(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,115 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package httpext
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+)
+
+// StreamSource is a source of request body data that can be read multiple
+// times, e.g. for redirects and retries.
+type StreamSource interface {
+	Open() (io.ReadCloser, error)
+	Size() int64
+}
+
+// BytesSource is a StreamSource for data that's already in memory.
+type BytesSource []byte
+
+// Open returns a reader for the data.
+func (b BytesSource) Open() (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(b)), nil
+}
+
+// Size returns the length of the data.
+func (b BytesSource) Size() int64 {
+	return int64(len(b))
+}
+
+// StreamBody is a request body that's read from its sources every time the
+// request is sent, so that big files don't have to be kept in memory.
+type StreamBody struct {
+	sources []StreamSource
+	size    int64
+}
+
+// NewStreamBody returns a StreamBody that consists of the given sources, sent
+// one after the other.
+func NewStreamBody(sources ...StreamSource) *StreamBody {
+	body := &StreamBody{sources: sources}
+	for _, s := range sources {
+		body.size += s.Size()
+	}
+	return body
+}
+
+// Size returns the total size of the body, in bytes.
+func (b *StreamBody) Size() int64 {
+	return b.size
+}
+
+// Open returns a reader for the whole body. Every source is opened only when
+// the previous ones were read completely.
+func (b *StreamBody) Open() (io.ReadCloser, error) {
+	return &streamBodyReader{sources: b.sources}, nil
+}
+
+type streamBodyReader struct {
+	sources []StreamSource
+	current io.ReadCloser
+}
+
+func (r *streamBodyReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.sources) == 0 {
+				return 0, io.EOF
+			}
+			rc, err := r.sources[0].Open()
+			if err != nil {
+				return 0, err
+			}
+			r.current, r.sources = rc, r.sources[1:]
+		}
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			_ = r.current.Close()
+			r.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (r *streamBodyReader) Close() error {
+	r.sources = nil
+	if r.current == nil {
+		return nil
+	}
+	err := r.current.Close()
+	r.current = nil
+	return err
+}
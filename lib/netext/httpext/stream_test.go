@@ -0,0 +1,56 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package httpext
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamBody(t *testing.T) {
+	t.Parallel()
+	body := NewStreamBody(BytesSource("foo"), BytesSource(""), BytesSource("bar"), BytesSource("baz"))
+	assert.Equal(t, int64(9), body.Size())
+
+	// The body can be read multiple times, e.g. for redirects and retries
+	for i := 0; i < 2; i++ {
+		rc, err := body.Open()
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(rc)
+		require.NoError(t, err)
+		assert.Equal(t, "foobarbaz", string(data))
+		require.NoError(t, rc.Close())
+	}
+
+	rc, err := body.Open()
+	require.NoError(t, err)
+	buf := make([]byte, 4)
+	n, err := rc.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(buf[:n]))
+	require.NoError(t, rc.Close())
+	n, err = rc.Read(buf)
+	assert.Equal(t, 0, n)
+	assert.Error(t, err)
+}
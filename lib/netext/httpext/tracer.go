@@ -37,22 +37,24 @@ import (
 type Trail struct {
 	EndTime time.Time
 
-	// Total connect time (Connecting + TLSHandshaking)
+	// Total connect time (Connecting + ProxyConnecting + TLSHandshaking)
 	ConnDuration time.Duration
 
 	// Total request duration, excluding DNS lookup and connect time.
 	Duration time.Duration
 
-	Blocked        time.Duration // Waiting to acquire a connection.
-	Connecting     time.Duration // Connecting to remote host.
-	TLSHandshaking time.Duration // Executing TLS handshake.
-	Sending        time.Duration // Writing request.
-	Waiting        time.Duration // Waiting for first byte.
-	Receiving      time.Duration // Receiving response.
+	Blocked         time.Duration // Waiting to acquire a connection.
+	Connecting      time.Duration // Connecting to remote host (or to the proxy).
+	ProxyConnecting time.Duration // Establishing the tunnel through the proxy.
+	TLSHandshaking  time.Duration // Executing TLS handshake.
+	Sending         time.Duration // Writing request.
+	Waiting         time.Duration // Waiting for first byte.
+	Receiving       time.Duration // Receiving response.
 
 	// Detailed connection information.
 	ConnReused     bool
 	ConnRemoteAddr net.Addr
+	Proxied        bool // Whether the request was made through a proxy.
 
 	Failed null.Bool
 	// Populated by SaveSamples()
@@ -63,7 +65,7 @@ type Trail struct {
 // SaveSamples populates the Trail's sample slice so they're accesible via GetSamples()
 func (tr *Trail) SaveSamples(tags *stats.SampleTags) {
 	tr.Tags = tags
-	tr.Samples = make([]stats.Sample, 0, 9) // this is with 1 more for a possible HTTPReqFailed
+	tr.Samples = make([]stats.Sample, 0, 10) // this is with 2 more for a possible HTTPReqFailed and proxy
 	tr.Samples = append(tr.Samples, []stats.Sample{
 		{Metric: metrics.HTTPReqs, Time: tr.EndTime, Tags: tags, Value: 1},
 		{Metric: metrics.HTTPReqDuration, Time: tr.EndTime, Tags: tags, Value: stats.D(tr.Duration)},
@@ -74,6 +76,11 @@ func (tr *Trail) SaveSamples(tags *stats.SampleTags) {
 		{Metric: metrics.HTTPReqWaiting, Time: tr.EndTime, Tags: tags, Value: stats.D(tr.Waiting)},
 		{Metric: metrics.HTTPReqReceiving, Time: tr.EndTime, Tags: tags, Value: stats.D(tr.Receiving)},
 	}...)
+	if tr.Proxied {
+		tr.Samples = append(tr.Samples, stats.Sample{
+			Metric: metrics.HTTPReqProxyConnecting, Time: tr.EndTime, Tags: tags, Value: stats.D(tr.ProxyConnecting),
+		})
+	}
 }
 
 // GetSamples implements the stats.SampleContainer interface.
@@ -108,6 +115,7 @@ type Tracer struct {
 	gotConn              int64
 	wroteRequest         int64
 	gotFirstResponseByte int64
+	proxied              int32
 
 	connReused     bool
 	connRemoteAddr net.Addr
@@ -266,6 +274,14 @@ func (t *Tracer) GotFirstResponseByte() {
 	atomic.CompareAndSwapInt64(&t.gotFirstResponseByte, 0, now())
 }
 
+// setProxied marks that the request is made through a proxy. The time between
+// the connection to the proxy and the start of the TLS handshake (or getting
+// the connection, for plain HTTP) is then measured as ProxyConnecting, since
+// that's when the CONNECT request or the SOCKS5 handshake happen.
+func (t *Tracer) setProxied() {
+	atomic.StoreInt32(&t.proxied, 1)
+}
+
 // Done calculates all metrics and should be called when the request is finished.
 func (t *Tracer) Done() *Trail {
 	done := time.Now()
@@ -273,6 +289,7 @@ func (t *Tracer) Done() *Trail {
 	trail := Trail{
 		ConnReused:     t.connReused,
 		ConnRemoteAddr: t.connRemoteAddr,
+		Proxied:        atomic.LoadInt32(&t.proxied) == 1,
 	}
 
 	if t.gotConn != 0 && t.getConn != 0 && t.gotConn > t.getConn {
@@ -298,14 +315,27 @@ func (t *Tracer) Done() *Trail {
 	if tlsHandshakeDone != 0 && tlsHandshakeStart != 0 {
 		trail.TLSHandshaking = time.Duration(tlsHandshakeDone - tlsHandshakeStart)
 	}
+	proxyConnectDone := connectDone
+	if trail.Proxied && connectDone != 0 {
+		if tlsHandshakeStart != 0 {
+			proxyConnectDone = tlsHandshakeStart
+		} else if gotConn != 0 {
+			proxyConnectDone = gotConn
+		}
+		if proxyConnectDone > connectDone {
+			trail.ProxyConnecting = time.Duration(proxyConnectDone - connectDone)
+		} else {
+			proxyConnectDone = connectDone
+		}
+	}
 	if wroteRequest != 0 {
 		if tlsHandshakeDone != 0 {
 			// If the request was sent over TLS, we need to use
 			// TLS Handshake Done time to calculate sending duration
 			trail.Sending = time.Duration(wroteRequest - tlsHandshakeDone)
 		} else if connectDone != 0 {
-			// Otherwise, use the end of the normal connection
-			trail.Sending = time.Duration(wroteRequest - connectDone)
+			// Otherwise, use the end of the normal (or proxy) connection
+			trail.Sending = time.Duration(wroteRequest - proxyConnectDone)
 		} else {
 			// Finally, this handles the strange HTTP/2 case where the GotConn() hook
 			// gets called first, but with Reused=false
@@ -331,7 +361,7 @@ func (t *Tracer) Done() *Trail {
 
 	// Calculate total times using adjusted values.
 	trail.EndTime = done
-	trail.ConnDuration = trail.Connecting + trail.TLSHandshaking
+	trail.ConnDuration = trail.Connecting + trail.ProxyConnecting + trail.TLSHandshaking
 	trail.Duration = trail.Sending + trail.Waiting + trail.Receiving
 
 	return &trail
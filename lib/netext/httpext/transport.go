@@ -29,6 +29,7 @@ import (
 	"sync"
 
 	"github.com/loadimpact/k6/lib"
+	"github.com/loadimpact/k6/lib/httpcache"
 	"github.com/loadimpact/k6/lib/metrics"
 	"github.com/loadimpact/k6/lib/netext"
 	"github.com/loadimpact/k6/stats"
@@ -49,11 +50,12 @@ type transport struct {
 // unfinishedRequest stores the request and the raw result returned from the
 // underlying http.RoundTripper, but before its body has been read
 type unfinishedRequest struct {
-	ctx      context.Context
-	tracer   *Tracer
-	request  *http.Request
-	response *http.Response
-	err      error
+	ctx         context.Context
+	tracer      *Tracer
+	request     *http.Request
+	response    *http.Response
+	err         error
+	cacheStatus httpcache.Status
 }
 
 // finishedRequest is produced once the request has been finalized; it is
@@ -91,6 +93,7 @@ func newTransport(
 
 // Helper method to finish the tracer trail, assemble the tag values and emits
 // the metric samples for the supplied unfinished request.
+//
 //nolint:nestif,funlen
 func (t *transport) measureAndEmitMetrics(unfReq *unfinishedRequest) *finishedRequest {
 	trail := unfReq.tracer.Done()
@@ -163,6 +166,9 @@ func (t *transport) measureAndEmitMetrics(unfReq *unfinishedRequest) *finishedRe
 			result.tlsInfo = tlsInfo
 		}
 	}
+	if unfReq.cacheStatus != "" && enabledTags.Has(stats.TagCache) {
+		tags["cache"] = string(unfReq.cacheStatus)
+	}
 	if enabledTags.Has(stats.TagIP) && trail.ConnRemoteAddr != nil {
 		if ip, _, err := net.SplitHostPort(trail.ConnRemoteAddr.String()); err == nil {
 			tags["ip"] = ip
@@ -197,6 +203,20 @@ func (t *transport) measureAndEmitMetrics(unfReq *unfinishedRequest) *finishedRe
 			},
 		)
 	}
+	// Only requests that actually used a connection are counted
+	if t.state.Options.HTTPTransport.ConnectionMetrics.Bool && trail.ConnRemoteAddr != nil {
+		var reused float64
+		if trail.ConnReused {
+			reused = 1
+		} else {
+			trail.Samples = append(trail.Samples, stats.Sample{
+				Metric: metrics.HTTPConnsOpened, Time: trail.EndTime, Tags: finalTags, Value: 1,
+			})
+		}
+		trail.Samples = append(trail.Samples, stats.Sample{
+			Metric: metrics.HTTPReqConnReused, Time: trail.EndTime, Tags: finalTags, Value: reused,
+		})
+	}
 	stats.PushIfNotDone(t.ctx, t.state.Samples, trail)
 
 	return result
@@ -240,15 +260,27 @@ func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
 
 	ctx := req.Context()
 	tracer := &Tracer{}
-	reqWithTracer := req.WithContext(httptrace.WithClientTrace(ctx, tracer.Trace()))
-	resp, err := t.state.Transport.RoundTrip(reqWithTracer)
+	tracerCtx := context.WithValue(httptrace.WithClientTrace(ctx, tracer.Trace()), tracerKey{}, tracer)
+	reqWithTracer := req.WithContext(tracerCtx)
+
+	var (
+		resp        *http.Response
+		err         error
+		cacheStatus httpcache.Status
+	)
+	if t.state.HTTPCache != nil {
+		resp, cacheStatus, err = t.state.HTTPCache.RoundTrip(reqWithTracer, t.state.Transport)
+	} else {
+		resp, err = t.state.Transport.RoundTrip(reqWithTracer)
+	}
 
 	t.saveCurrentRequest(&unfinishedRequest{
-		ctx:      ctx,
-		tracer:   tracer,
-		request:  req,
-		response: resp,
-		err:      err,
+		ctx:         ctx,
+		tracer:      tracer,
+		request:     req,
+		response:    resp,
+		err:         err,
+		cacheStatus: cacheStatus,
 	})
 
 	return resp, err
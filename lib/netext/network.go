@@ -0,0 +1,175 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package netext
+
+import (
+	"math/rand"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/loadimpact/k6/lib/types"
+)
+
+const (
+	// the minimum TCP retransmission timeout, used to emulate lost segments
+	minRetransmissionTimeout = 200 * time.Millisecond
+	// bandwidth limits are applied in chunks of data that take this long
+	throttleInterval = 50 * time.Millisecond
+)
+
+// networkEmulator adds latency, bandwidth limits and errors to the reads and
+// writes of a connection, according to the network conditions at the time it
+// was established.
+type networkEmulator struct {
+	mu               sync.Mutex
+	conditions       types.NetworkConditions
+	latency          time.Duration
+	download, upload *throttle
+	// set after writes, so that the latency is added once per round trip
+	awaitingResponse bool
+	packetConn       bool
+	rand             *rand.Rand
+}
+
+func newNetworkEmulator(conditions types.NetworkConditions, packetConn bool) *networkEmulator {
+	return &networkEmulator{
+		conditions: conditions,
+		latency:    conditions.GetLatency(),
+		download:   newThrottle(conditions.GetDownload()),
+		upload:     newThrottle(conditions.GetUpload()),
+		packetConn: packetConn,
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())), //nolint:gosec
+	}
+}
+
+// roundTripDelay returns the latency with a random jitter
+func (e *networkEmulator) roundTripDelay() time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delay := e.latency
+	if jitter := int64(e.conditions.Jitter.Duration); jitter > 0 {
+		delay += time.Duration(e.rand.Int63n(2*jitter+1) - jitter)
+	}
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+func (e *networkEmulator) chance(probability float64) bool {
+	if probability <= 0 {
+		return false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.rand.Float64() < probability
+}
+
+func (e *networkEmulator) read(conn net.Conn, b []byte) (int, error) {
+	e.mu.Lock()
+	awaitingResponse := e.awaitingResponse
+	e.awaitingResponse = false
+	e.mu.Unlock()
+	if awaitingResponse {
+		time.Sleep(e.roundTripDelay())
+	}
+
+	n, err := conn.Read(e.download.limit(b))
+	e.download.wait(n)
+	return n, err
+}
+
+func (e *networkEmulator) write(conn net.Conn, b []byte) (int, error) {
+	if e.chance(e.conditions.ConnectionReset.Float64) {
+		_ = conn.Close()
+		return 0, &net.OpError{
+			Op: "write", Net: conn.LocalAddr().Network(), Source: conn.LocalAddr(), Addr: conn.RemoteAddr(),
+			Err: os.NewSyscallError("write", syscall.ECONNRESET),
+		}
+	}
+	if e.chance(e.conditions.PacketLoss.Float64) {
+		if e.packetConn {
+			return len(b), nil
+		}
+		time.Sleep(minRetransmissionTimeout + e.latency)
+	}
+
+	var written int
+	for written < len(b) {
+		n, err := conn.Write(e.upload.limit(b[written:]))
+		written += n
+		e.upload.wait(n)
+		if err != nil {
+			return written, err
+		}
+	}
+
+	e.mu.Lock()
+	e.awaitingResponse = true
+	e.mu.Unlock()
+	return written, nil
+}
+
+// throttle limits the rate of data transfer in one direction
+type throttle struct {
+	mu        sync.Mutex
+	rate      float64 // in bytes per second, unlimited if 0
+	chunkSize int
+	next      time.Time
+}
+
+func newThrottle(kbps float64) *throttle {
+	t := &throttle{rate: kbps * 1000 / 8}
+	if t.rate > 0 {
+		t.chunkSize = int(t.rate * throttleInterval.Seconds())
+		if t.chunkSize < 1 {
+			t.chunkSize = 1
+		}
+	}
+	return t
+}
+
+// limit returns the part of the buffer that can be transferred at once
+func (t *throttle) limit(b []byte) []byte {
+	if t.rate > 0 && len(b) > t.chunkSize {
+		return b[:t.chunkSize]
+	}
+	return b
+}
+
+// wait blocks until the transfer of n bytes would have been finished
+func (t *throttle) wait(n int) {
+	if t.rate <= 0 || n <= 0 {
+		return
+	}
+	t.mu.Lock()
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	t.next = t.next.Add(time.Duration(float64(n) / t.rate * float64(time.Second)))
+	delay := t.next.Sub(now)
+	t.mu.Unlock()
+	time.Sleep(delay)
+}
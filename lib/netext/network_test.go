@@ -0,0 +1,169 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package netext
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/loadimpact/k6/lib/types"
+)
+
+// startEchoServer starts a TCP server that echoes back everything it reads
+func startEchoServer(t *testing.T) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener
+}
+
+func TestNetworkEmulationLatency(t *testing.T) {
+	t.Parallel()
+	listener := startEchoServer(t)
+	defer func() { _ = listener.Close() }()
+
+	dialer := NewDialer(net.Dialer{}, newResolver())
+	dialer.SetNetworkConditions(types.NetworkConditions{
+		Latency: types.NullDurationFrom(100 * time.Millisecond), Valid: true,
+	})
+
+	start := time.Now()
+	conn, err := dialer.DialContext(context.Background(), "tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	assert.True(t, time.Since(start) >= 100*time.Millisecond, "the connection latency wasn't emulated")
+
+	for i := 0; i < 2; i++ {
+		start = time.Now()
+		_, err = conn.Write([]byte("ping"))
+		require.NoError(t, err)
+		buf := make([]byte, 4)
+		_, err = io.ReadFull(conn, buf)
+		require.NoError(t, err)
+		assert.Equal(t, "ping", string(buf))
+		elapsed := time.Since(start)
+		assert.True(t, elapsed >= 100*time.Millisecond, "the round trip took only %s", elapsed)
+		assert.True(t, elapsed < 200*time.Millisecond, "the latency was added more than once: %s", elapsed)
+	}
+	assert.Equal(t, int64(8), dialer.BytesWritten)
+	assert.Equal(t, int64(8), dialer.BytesRead)
+}
+
+func TestNetworkEmulationBandwidth(t *testing.T) {
+	t.Parallel()
+	listener := startEchoServer(t)
+	defer func() { _ = listener.Close() }()
+
+	// 80kbps is 10kB/s, so sending and receiving 2kB should take at least 400ms
+	dialer := NewDialer(net.Dialer{}, newResolver())
+	dialer.SetNetworkConditions(types.NetworkConditions{
+		Download: null.FloatFrom(80), Upload: null.FloatFrom(80), Valid: true,
+	})
+	conn, err := dialer.DialContext(context.Background(), "tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	start := time.Now()
+	data := make([]byte, 2000)
+	n, err := conn.Write(data)
+	require.NoError(t, err)
+	assert.Equal(t, len(data), n)
+	_, err = io.ReadFull(conn, data)
+	require.NoError(t, err)
+	elapsed := time.Since(start)
+	assert.True(t, elapsed >= 350*time.Millisecond, "the transfer took only %s", elapsed)
+}
+
+func TestNetworkEmulationErrors(t *testing.T) {
+	t.Parallel()
+	t.Run("reset", func(t *testing.T) {
+		t.Parallel()
+		listener := startEchoServer(t)
+		defer func() { _ = listener.Close() }()
+
+		dialer := NewDialer(net.Dialer{}, newResolver())
+		dialer.SetNetworkConditions(types.NetworkConditions{ConnectionReset: null.FloatFrom(1), Valid: true})
+		conn, err := dialer.DialContext(context.Background(), "tcp", listener.Addr().String())
+		require.NoError(t, err)
+
+		_, err = conn.Write([]byte("ping"))
+		require.Error(t, err)
+		opErr, ok := err.(*net.OpError)
+		require.True(t, ok)
+		assert.Equal(t, "write", opErr.Op)
+		assert.Equal(t, syscall.ECONNRESET, opErr.Err.(*os.SyscallError).Err)
+		_, err = conn.Read(make([]byte, 1))
+		require.Error(t, err)
+	})
+
+	t.Run("udp packet loss", func(t *testing.T) {
+		t.Parallel()
+		server, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer func() { _ = server.Close() }()
+
+		dialer := NewDialer(net.Dialer{}, newResolver())
+		dialer.SetNetworkConditions(types.NetworkConditions{PacketLoss: null.FloatFrom(1), Valid: true})
+		conn, err := dialer.DialContext(context.Background(), "udp", server.LocalAddr().String())
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+
+		n, err := conn.Write([]byte("ping"))
+		require.NoError(t, err)
+		assert.Equal(t, 4, n)
+
+		require.NoError(t, server.SetReadDeadline(time.Now().Add(100*time.Millisecond)))
+		_, _, err = server.ReadFrom(make([]byte, 4))
+		require.Error(t, err, "the datagram wasn't dropped")
+	})
+
+	t.Run("inactive", func(t *testing.T) {
+		t.Parallel()
+		dialer := NewDialer(net.Dialer{}, newResolver())
+		dialer.SetNetworkConditions(types.NetworkConditions{Preset: null.StringFrom(types.NetworkPresetNone), Valid: true})
+		listener := startEchoServer(t)
+		defer func() { _ = listener.Close() }()
+		conn, err := dialer.DialContext(context.Background(), "tcp", listener.Addr().String())
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+		assert.Nil(t, conn.(*Conn).network)
+	})
+}
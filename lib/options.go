@@ -383,6 +383,15 @@ type Options struct {
 	// Do not reset cookies after a VU iteration
 	NoCookiesReset null.Bool `json:"noCookiesReset" envconfig:"K6_NO_COOKIES_RESET"`
 
+	// Emulate a browser HTTP cache for each VU
+	HTTPCache null.Bool `json:"httpCache" envconfig:"K6_HTTP_CACHE"`
+
+	// Maximum size in bytes of the HTTP cache of each VU
+	HTTPCacheMaxSize null.Int `json:"httpCacheMaxSize" envconfig:"K6_HTTP_CACHE_MAX_SIZE"`
+
+	// Do not reset the HTTP cache after a VU iteration
+	NoHTTPCacheReset null.Bool `json:"noHTTPCacheReset" envconfig:"K6_NO_HTTP_CACHE_RESET"`
+
 	// Discard Http Responses Body
 	DiscardResponseBodies null.Bool `json:"discardResponseBodies" envconfig:"K6_DISCARD_RESPONSE_BODIES"`
 
@@ -391,6 +400,18 @@ type Options struct {
 
 	// Specify client IP ranges and/or CIDR from which VUs will make requests
 	LocalIPs types.NullIPPool `json:"-" envconfig:"K6_LOCAL_IPS"`
+
+	// HTTP, HTTPS or SOCKS5 proxies for the HTTP requests, assigned to VUs round-robin
+	Proxy types.NullProxyPool `json:"proxy" envconfig:"K6_PROXY"`
+
+	// Emulated latency, bandwidth and packet loss for the connections of VUs
+	NetworkConditions types.NetworkConditions `json:"networkConditions" envconfig:"K6_NETWORK_CONDITIONS"`
+
+	// Whether and how failed HTTP requests should be retried
+	Retry types.RetryConfig `json:"retry" envconfig:"K6_RETRY"`
+
+	// Connection pool, HTTP/2 and timeout settings of the HTTP transport
+	HTTPTransport types.HTTPTransportConfig `json:"httpTransport" envconfig:"K6_HTTP_TRANSPORT"`
 }
 
 // Returns the result of overwriting any fields with any that are set on the argument.
@@ -521,6 +542,15 @@ func (o Options) Apply(opts Options) Options {
 	if opts.NoCookiesReset.Valid {
 		o.NoCookiesReset = opts.NoCookiesReset
 	}
+	if opts.HTTPCache.Valid {
+		o.HTTPCache = opts.HTTPCache
+	}
+	if opts.HTTPCacheMaxSize.Valid {
+		o.HTTPCacheMaxSize = opts.HTTPCacheMaxSize
+	}
+	if opts.NoHTTPCacheReset.Valid {
+		o.NoHTTPCacheReset = opts.NoHTTPCacheReset
+	}
 	if opts.External != nil {
 		o.External = opts.External
 	}
@@ -548,6 +578,18 @@ func (o Options) Apply(opts Options) Options {
 	if opts.LocalIPs.Valid {
 		o.LocalIPs = opts.LocalIPs
 	}
+	if opts.Proxy.Valid {
+		o.Proxy = opts.Proxy
+	}
+	if opts.NetworkConditions.Valid {
+		o.NetworkConditions = o.NetworkConditions.Apply(opts.NetworkConditions)
+	}
+	if opts.Retry.Valid {
+		o.Retry = o.Retry.Apply(opts.Retry)
+	}
+	if opts.HTTPTransport.Valid {
+		o.HTTPTransport = o.HTTPTransport.Apply(opts.HTTPTransport)
+	}
 	if opts.DNS.TTL.Valid {
 		o.DNS.TTL = opts.DNS.TTL
 	}
@@ -580,6 +622,15 @@ func (o Options) Validate() []error {
 					o.ExecutionSegment, o.ExecutionSegmentSequence))
 		}
 	}
+	if err := o.NetworkConditions.Validate(); err != nil {
+		errors = append(errors, err)
+	}
+	if err := o.Retry.Validate(); err != nil {
+		errors = append(errors, err)
+	}
+	if err := o.HTTPTransport.Validate(); err != nil {
+		errors = append(errors, err)
+	}
 	return append(errors, o.Scenarios.Validate()...)
 }
 
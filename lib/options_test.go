@@ -300,6 +300,18 @@ func TestOptions(t *testing.T) {
 		assert.True(t, opts.NoCookiesReset.Valid)
 		assert.True(t, opts.NoCookiesReset.Bool)
 	})
+	t.Run("HTTPCache", func(t *testing.T) {
+		opts := Options{}.Apply(Options{
+			HTTPCache:        null.BoolFrom(true),
+			HTTPCacheMaxSize: null.IntFrom(1 << 20),
+			NoHTTPCacheReset: null.BoolFrom(true),
+		})
+		assert.True(t, opts.HTTPCache.Valid)
+		assert.True(t, opts.HTTPCache.Bool)
+		assert.Equal(t, null.IntFrom(1<<20), opts.HTTPCacheMaxSize)
+		assert.True(t, opts.NoHTTPCacheReset.Valid)
+		assert.True(t, opts.NoHTTPCacheReset.Bool)
+	})
 	t.Run("BlacklistIPs", func(t *testing.T) {
 		opts := Options{}.Apply(Options{
 			BlacklistIPs: []*IPNet{{
@@ -413,9 +425,49 @@ func TestOptions(t *testing.T) {
 		opts := Options{}.Apply(Options{LocalIPs: types.NullIPPool{Pool: clientIPRanges, Valid: true}})
 		assert.NotNil(t, opts.LocalIPs)
 	})
+	t.Run("Proxy", func(t *testing.T) {
+		proxy, err := types.NewNullProxyPool("socks5://proxy:1080")
+		require.NoError(t, err)
+		opts := Options{}.Apply(Options{Proxy: proxy})
+		assert.Equal(t, proxy, opts.Proxy)
+	})
+	t.Run("Retry", func(t *testing.T) {
+		opts := Options{}.Apply(Options{Retry: types.NewRetryConfig(3)})
+		opts = opts.Apply(Options{Retry: types.RetryConfig{Backoff: null.StringFrom("linear"), Valid: true}})
+		assert.True(t, opts.Retry.Valid)
+		assert.Equal(t, null.IntFrom(3), opts.Retry.Attempts)
+		assert.Equal(t, null.StringFrom("linear"), opts.Retry.Backoff)
+	})
+	t.Run("NetworkConditions", func(t *testing.T) {
+		preset, err := types.NewNetworkConditions("3g")
+		require.NoError(t, err)
+		opts := Options{}.Apply(Options{NetworkConditions: preset})
+		opts = opts.Apply(Options{NetworkConditions: types.NetworkConditions{
+			PacketLoss: null.FloatFrom(0.1), Valid: true,
+		}})
+		assert.True(t, opts.NetworkConditions.Valid)
+		assert.Equal(t, null.StringFrom("3g"), opts.NetworkConditions.Preset)
+		assert.Equal(t, null.FloatFrom(0.1), opts.NetworkConditions.PacketLoss)
+	})
+	t.Run("HTTPTransport", func(t *testing.T) {
+		opts := Options{}.Apply(Options{HTTPTransport: types.HTTPTransportConfig{
+			MaxConnsPerHost: null.IntFrom(5), Valid: true,
+		}})
+		opts = opts.Apply(Options{HTTPTransport: types.HTTPTransportConfig{
+			HTTP2: null.StringFrom("disabled"), Valid: true,
+		}})
+		assert.True(t, opts.HTTPTransport.Valid)
+		assert.Equal(t, null.IntFrom(5), opts.HTTPTransport.MaxConnsPerHost)
+		assert.Equal(t, null.StringFrom("disabled"), opts.HTTPTransport.HTTP2)
+	})
 }
 
 func TestOptionsEnv(t *testing.T) {
+	mustProxyPool := func(proxies ...string) types.NullProxyPool {
+		p, err := types.NewNullProxyPool(proxies...)
+		require.NoError(t, err)
+		return p
+	}
 	mustIPPool := func(s string) *types.IPPool {
 		p, err := types.NewIPPool(s)
 		require.NoError(t, err)
@@ -486,6 +538,30 @@ func TestOptionsEnv(t *testing.T) {
 			"192.168.220.2":    types.NullIPPool{Pool: mustIPPool("192.168.220.2"), Valid: true},
 			"192.168.220.2/24": types.NullIPPool{Pool: mustIPPool("192.168.220.0/24"), Valid: true},
 		},
+		{"Proxy", "K6_PROXY"}: {
+			"":                  types.NullProxyPool{},
+			"proxy:3128,proxy2": mustProxyPool("http://proxy:3128", "http://proxy2"),
+		},
+		{"HTTPTransport", "K6_HTTP_TRANSPORT"}: {
+			"": types.HTTPTransportConfig{},
+			"maxConnsPerHost=5,http2=h2c": types.HTTPTransportConfig{
+				MaxConnsPerHost: null.IntFrom(5), HTTP2: null.StringFrom("h2c"), Valid: true,
+			},
+		},
+		{"NetworkConditions", "K6_NETWORK_CONDITIONS"}: {
+			"":        types.NetworkConditions{},
+			"slow-3g": types.NetworkConditions{Preset: null.StringFrom("slow-3g"), Valid: true},
+			"latency=100ms,upload=500": types.NetworkConditions{
+				Latency: types.NullDurationFrom(100 * time.Millisecond), Upload: null.FloatFrom(500), Valid: true,
+			},
+		},
+		{"Retry", "K6_RETRY"}: {
+			"":  types.RetryConfig{},
+			"3": types.NewRetryConfig(3),
+			"attempts=2,statuses={502,503}": types.RetryConfig{
+				Attempts: null.IntFrom(2), Statuses: []int{502, 503}, Valid: true,
+			},
+		},
 		{"Throw", "K6_THROW"}: {
 			"":      null.Bool{},
 			"true":  null.BoolFrom(true),
@@ -496,6 +572,20 @@ func TestOptionsEnv(t *testing.T) {
 			"true":  null.BoolFrom(true),
 			"false": null.BoolFrom(false),
 		},
+		{"HTTPCache", "K6_HTTP_CACHE"}: {
+			"":      null.Bool{},
+			"true":  null.BoolFrom(true),
+			"false": null.BoolFrom(false),
+		},
+		{"HTTPCacheMaxSize", "K6_HTTP_CACHE_MAX_SIZE"}: {
+			"":        null.Int{},
+			"1048576": null.IntFrom(1048576),
+		},
+		{"NoHTTPCacheReset", "K6_NO_HTTP_CACHE_RESET"}: {
+			"":      null.Bool{},
+			"true":  null.BoolFrom(true),
+			"false": null.BoolFrom(false),
+		},
 		// Thresholds
 		// External
 	}
@@ -25,6 +25,7 @@ import (
 	"io"
 	"time"
 
+	"github.com/loadimpact/k6/lib/types"
 	"github.com/loadimpact/k6/stats"
 )
 
@@ -55,6 +56,17 @@ type VUActivationParams struct {
 	DeactivateCallback func(InitializedVU)
 	Env, Tags          map[string]string
 	Exec, Scenario     string
+
+	// If specified, one of these functions is picked for every iteration,
+	// instead of Exec, deterministically based on the ExecSeed.
+	WeightedExec WeightedExecs
+	ExecSeed     int64
+
+	// If valid, overrides the global proxy option for the scenario.
+	Proxy types.NullProxyPool
+
+	// If valid, overrides the global network conditions for the scenario.
+	NetworkConditions types.NetworkConditions
 }
 
 // A Runner is a factory for VUs. It should precompute as much as possible upon
@@ -55,6 +55,24 @@ type RuntimeOptions struct {
 	// Environment variables passed onto the runner
 	Env map[string]string `json:"env"`
 
+	// Path or URL of an import map, which maps bare module specifiers and
+	// prefixes to local paths or URLs
+	ImportMap null.String `json:"importMap"`
+
+	// Whether to resolve bare module specifiers from node_modules directories,
+	// the same way Node.js does
+	NodeModules null.Bool `json:"nodeModules"`
+
+	// Whether to load remote modules only from the module cache, without
+	// any network access
+	Offline null.Bool `json:"offline"`
+
+	// Path of the lockfile with the integrity hashes of the remote modules
+	Lockfile null.String `json:"lockfile"`
+
+	// Directory of the on-disk cache of remote modules
+	ModuleCache null.String `json:"moduleCache"`
+
 	NoThresholds  null.Bool   `json:"noThresholds"`
 	NoSummary     null.Bool   `json:"noSummary"`
 	SummaryExport null.String `json:"summaryExport"`
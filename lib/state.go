@@ -26,11 +26,13 @@ import (
 	"net"
 	"net/http"
 	"net/http/cookiejar"
+	"net/url"
 
 	"github.com/oxtoacart/bpool"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/time/rate"
 
+	"github.com/loadimpact/k6/lib/httpcache"
 	"github.com/loadimpact/k6/stats"
 )
 
@@ -57,6 +59,12 @@ type State struct {
 	CookieJar *cookiejar.Jar
 	TLSConfig *tls.Config
 
+	// The HTTP cache of the VU, nil unless the httpCache option is enabled.
+	HTTPCache *httpcache.Cache
+
+	// The proxy assigned to the VU for the current scenario, nil if none.
+	Proxy *url.URL
+
 	// Rate limits.
 	RPSLimit *rate.Limiter
 
@@ -0,0 +1,185 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kubernetes/helm/pkg/strvals"
+	"gopkg.in/guregu/null.v3"
+)
+
+// The supported HTTP/2 modes of the HTTP transport.
+const (
+	// HTTP2Enabled negotiates HTTP/2 via ALPN for HTTPS requests, the default.
+	HTTP2Enabled = "enabled"
+	// HTTP2Disabled only uses HTTP/1.1.
+	HTTP2Disabled = "disabled"
+	// HTTP2H2C additionally uses cleartext HTTP/2 (with prior knowledge) for
+	// plain HTTP requests.
+	HTTP2H2C = "h2c"
+)
+
+// HTTPTransportConfig contains the connection pool, HTTP/2 and timeout
+// settings of the HTTP transport of every VU.
+type HTTPTransportConfig struct {
+	// The maximum number of idle connections across all hosts, the batch
+	// option by default.
+	MaxIdleConns null.Int `json:"maxIdleConns"`
+	// The maximum number of idle connections per host, the batchPerHost
+	// option by default.
+	MaxIdleConnsPerHost null.Int `json:"maxIdleConnsPerHost"`
+	// The maximum number of connections per host, unlimited by default.
+	MaxConnsPerHost null.Int `json:"maxConnsPerHost"`
+	// How long an idle connection is kept open, forever by default.
+	IdleConnTimeout NullDuration `json:"idleConnTimeout"`
+	// How long to wait for a TLS handshake, forever by default.
+	TLSHandshakeTimeout NullDuration `json:"tlsHandshakeTimeout"`
+	// How long to wait for the response headers after the request was
+	// written, forever by default.
+	ResponseHeaderTimeout NullDuration `json:"responseHeaderTimeout"`
+	// One of enabled, disabled or h2c.
+	HTTP2 null.String `json:"http2"`
+	// If positive, an HTTP/2 ping health check is done for connections that
+	// haven't received any frames for that long.
+	HTTP2ReadIdleTimeout NullDuration `json:"http2ReadIdleTimeout"`
+	// How long to wait for a response to the health check ping, before
+	// closing the connection.
+	HTTP2PingTimeout NullDuration `json:"http2PingTimeout"`
+	// Whether to emit the http_req_conn_reused and http_conns_opened metrics.
+	ConnectionMetrics null.Bool `json:"connectionMetrics"`
+	// FIXME: Valid is only added to satisfy some logic in
+	// lib.Options.ForEachSpecified(), same as in DNSConfig.
+	Valid bool `json:"-"`
+}
+
+// Apply returns the result of overwriting any fields with any that are set on
+// the argument.
+func (c HTTPTransportConfig) Apply(cfg HTTPTransportConfig) HTTPTransportConfig {
+	if cfg.MaxIdleConns.Valid {
+		c.MaxIdleConns = cfg.MaxIdleConns
+	}
+	if cfg.MaxIdleConnsPerHost.Valid {
+		c.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	}
+	if cfg.MaxConnsPerHost.Valid {
+		c.MaxConnsPerHost = cfg.MaxConnsPerHost
+	}
+	if cfg.IdleConnTimeout.Valid {
+		c.IdleConnTimeout = cfg.IdleConnTimeout
+	}
+	if cfg.TLSHandshakeTimeout.Valid {
+		c.TLSHandshakeTimeout = cfg.TLSHandshakeTimeout
+	}
+	if cfg.ResponseHeaderTimeout.Valid {
+		c.ResponseHeaderTimeout = cfg.ResponseHeaderTimeout
+	}
+	if cfg.HTTP2.Valid {
+		c.HTTP2 = cfg.HTTP2
+	}
+	if cfg.HTTP2ReadIdleTimeout.Valid {
+		c.HTTP2ReadIdleTimeout = cfg.HTTP2ReadIdleTimeout
+	}
+	if cfg.HTTP2PingTimeout.Valid {
+		c.HTTP2PingTimeout = cfg.HTTP2PingTimeout
+	}
+	if cfg.ConnectionMetrics.Valid {
+		c.ConnectionMetrics = cfg.ConnectionMetrics
+	}
+	c.Valid = c.Valid || cfg.Valid
+	return c
+}
+
+// Validate checks if the specified values make sense.
+func (c HTTPTransportConfig) Validate() error {
+	for name, v := range map[string]null.Int{
+		"maxIdleConns": c.MaxIdleConns, "maxIdleConnsPerHost": c.MaxIdleConnsPerHost,
+		"maxConnsPerHost": c.MaxConnsPerHost,
+	} {
+		if v.Int64 < 0 {
+			return fmt.Errorf("the httpTransport %s can't be negative", name)
+		}
+	}
+	for name, v := range map[string]NullDuration{
+		"idleConnTimeout": c.IdleConnTimeout, "tlsHandshakeTimeout": c.TLSHandshakeTimeout,
+		"responseHeaderTimeout": c.ResponseHeaderTimeout, "http2ReadIdleTimeout": c.HTTP2ReadIdleTimeout,
+		"http2PingTimeout": c.HTTP2PingTimeout,
+	} {
+		if v.Duration < 0 {
+			return fmt.Errorf("the httpTransport %s can't be negative", name)
+		}
+	}
+	switch c.HTTP2.String {
+	case "", HTTP2Enabled, HTTP2Disabled, HTTP2H2C:
+	default:
+		return fmt.Errorf("unknown httpTransport http2 mode '%s', it should be %s, %s or %s",
+			c.HTTP2.String, HTTP2Enabled, HTTP2Disabled, HTTP2H2C)
+	}
+	return nil
+}
+
+// MarshalJSON returns the settings as a JSON object, or null if they weren't
+// specified.
+func (c HTTPTransportConfig) MarshalJSON() ([]byte, error) {
+	if !c.Valid {
+		return []byte("null"), nil
+	}
+	type rawHTTPTransportConfig HTTPTransportConfig
+	return json.Marshal(rawHTTPTransportConfig(c))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *HTTPTransportConfig) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*c = HTTPTransportConfig{}
+		return nil
+	}
+	type rawHTTPTransportConfig HTTPTransportConfig
+	var raw rawHTTPTransportConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&raw); err != nil {
+		return fmt.Errorf("invalid httpTransport options: %w", err)
+	}
+	*c = HTTPTransportConfig(raw)
+	c.Valid = true
+	return c.Validate()
+}
+
+// UnmarshalText parses a comma-separated list of key=value pairs, e.g.
+// maxConnsPerHost=10,http2=disabled.
+func (c *HTTPTransportConfig) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*c = HTTPTransportConfig{}
+		return nil
+	}
+	params, err := strvals.Parse(string(text))
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return c.UnmarshalJSON(data)
+}
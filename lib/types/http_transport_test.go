@@ -0,0 +1,93 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package types
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+)
+
+func TestHTTPTransportConfigUnmarshal(t *testing.T) {
+	t.Parallel()
+	expected := HTTPTransportConfig{
+		MaxConnsPerHost:   null.IntFrom(10),
+		IdleConnTimeout:   NullDurationFrom(30 * time.Second),
+		HTTP2:             null.StringFrom(HTTP2H2C),
+		HTTP2PingTimeout:  NullDurationFrom(5 * time.Second),
+		ConnectionMetrics: null.BoolFrom(true),
+		Valid:             true,
+	}
+
+	var fromJSON HTTPTransportConfig
+	require.NoError(t, json.Unmarshal([]byte(`{"maxConnsPerHost": 10, "idleConnTimeout": "30s",
+		"http2": "h2c", "http2PingTimeout": 5000, "connectionMetrics": true}`), &fromJSON))
+	assert.Equal(t, expected, fromJSON)
+
+	var fromText HTTPTransportConfig
+	require.NoError(t, fromText.UnmarshalText(
+		[]byte("maxConnsPerHost=10,idleConnTimeout=30s,http2=h2c,http2PingTimeout=5s,connectionMetrics=true")))
+	assert.Equal(t, expected, fromText)
+
+	data, err := json.Marshal(fromJSON)
+	require.NoError(t, err)
+	var roundTrip HTTPTransportConfig
+	require.NoError(t, json.Unmarshal(data, &roundTrip))
+	assert.Equal(t, expected, roundTrip)
+
+	data, err = json.Marshal(HTTPTransportConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, "null", string(data))
+}
+
+func TestHTTPTransportConfigErrors(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]string{
+		`{"http2": "h3"}`:             "unknown httpTransport http2 mode 'h3'",
+		`{"maxConnsPerHost": -1}`:     "the httpTransport maxConnsPerHost can't be negative",
+		`{"idleConnTimeout": "-1s"}`:  "the httpTransport idleConnTimeout can't be negative",
+		`{"maxConnections": 1}`:       `unknown field "maxConnections"`,
+		`{"tlsHandshakeTimeout": {}}`: "invalid httpTransport options",
+	}
+	for data, expErr := range testCases {
+		data, expErr := data, expErr
+		t.Run(data, func(t *testing.T) {
+			t.Parallel()
+			var cfg HTTPTransportConfig
+			err := json.Unmarshal([]byte(data), &cfg)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), expErr)
+		})
+	}
+}
+
+func TestHTTPTransportConfigApply(t *testing.T) {
+	t.Parallel()
+	cfg := HTTPTransportConfig{MaxIdleConns: null.IntFrom(5), HTTP2: null.StringFrom(HTTP2Disabled), Valid: true}
+	cfg = cfg.Apply(HTTPTransportConfig{HTTP2: null.StringFrom(HTTP2Enabled), Valid: true})
+	assert.Equal(t, HTTPTransportConfig{
+		MaxIdleConns: null.IntFrom(5), HTTP2: null.StringFrom(HTTP2Enabled), Valid: true,
+	}, cfg)
+}
@@ -0,0 +1,303 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubernetes/helm/pkg/strvals"
+	"gopkg.in/guregu/null.v3"
+)
+
+// NetworkPresetNone is the preset that disables the network emulation, e.g.
+// for a scenario when the global option enables it.
+const NetworkPresetNone = "none"
+
+// NetworkPreset contains the values of a predefined network profile.
+type NetworkPreset struct {
+	Latency  time.Duration
+	Download float64 // in kbps
+	Upload   float64 // in kbps
+}
+
+// NetworkPresets are the predefined network profiles, roughly matching the
+// ones in the browser developer tools.
+//
+//nolint:gochecknoglobals
+var NetworkPresets = map[string]NetworkPreset{
+	NetworkPresetNone: {},
+	"gprs":            {Latency: 500 * time.Millisecond, Download: 50, Upload: 20},
+	"2g":              {Latency: 300 * time.Millisecond, Download: 250, Upload: 50},
+	"slow-3g":         {Latency: 400 * time.Millisecond, Download: 400, Upload: 400},
+	"3g":              {Latency: 150 * time.Millisecond, Download: 1600, Upload: 750},
+	"slow-4g":         {Latency: 100 * time.Millisecond, Download: 4000, Upload: 3000},
+	"4g":              {Latency: 40 * time.Millisecond, Download: 20000, Upload: 10000},
+	"wifi":            {Latency: 5 * time.Millisecond, Download: 30000, Upload: 15000},
+}
+
+// NetworkConditions specifies the emulated network conditions for the
+// connections of VUs. Any values that are specified override the ones of the
+// preset.
+type NetworkConditions struct {
+	// The name of one of the NetworkPresets.
+	Preset null.String `json:"preset"`
+	// The round-trip latency that is added to every request and new connection.
+	Latency NullDuration `json:"latency"`
+	// The maximum random variation of the latency.
+	Jitter NullDuration `json:"jitter"`
+	// The download bandwidth in kbps, or 0 for unlimited.
+	Download null.Float `json:"download"`
+	// The upload bandwidth in kbps, or 0 for unlimited.
+	Upload null.Float `json:"upload"`
+	// The probability (between 0 and 1) of a write being lost. Lost UDP
+	// datagrams are dropped, while lost TCP segments are delayed as if they
+	// were retransmitted.
+	PacketLoss null.Float `json:"packetLoss"`
+	// The probability (between 0 and 1) of a connection being reset when
+	// data is written to it.
+	ConnectionReset null.Float `json:"connectionReset"`
+	// FIXME: Valid is only added to satisfy some logic in
+	// lib.Options.ForEachSpecified(), same as in DNSConfig.
+	Valid bool `json:"-"`
+}
+
+// NewNetworkConditions returns valid NetworkConditions for the given preset.
+func NewNetworkConditions(preset string) (NetworkConditions, error) {
+	c := NetworkConditions{Preset: null.StringFrom(strings.ToLower(preset)), Valid: true}
+	return c, c.Validate()
+}
+
+// Apply returns the result of overwriting any fields with any that are set on
+// the argument.
+func (c NetworkConditions) Apply(cfg NetworkConditions) NetworkConditions {
+	if cfg.Preset.Valid {
+		c.Preset = cfg.Preset
+	}
+	if cfg.Latency.Valid {
+		c.Latency = cfg.Latency
+	}
+	if cfg.Jitter.Valid {
+		c.Jitter = cfg.Jitter
+	}
+	if cfg.Download.Valid {
+		c.Download = cfg.Download
+	}
+	if cfg.Upload.Valid {
+		c.Upload = cfg.Upload
+	}
+	if cfg.PacketLoss.Valid {
+		c.PacketLoss = cfg.PacketLoss
+	}
+	if cfg.ConnectionReset.Valid {
+		c.ConnectionReset = cfg.ConnectionReset
+	}
+	c.Valid = c.Valid || cfg.Valid
+	return c
+}
+
+// Validate checks if the specified values make sense.
+func (c NetworkConditions) Validate() error {
+	if c.Preset.Valid {
+		if _, ok := NetworkPresets[c.Preset.String]; !ok {
+			return fmt.Errorf("unknown network preset '%s', it should be one of %s",
+				c.Preset.String, strings.Join(networkPresetNames(), ", "))
+		}
+	}
+	if c.Latency.Duration < 0 || c.Jitter.Duration < 0 {
+		return fmt.Errorf("the network latency and jitter can't be negative")
+	}
+	if c.Download.Float64 < 0 || c.Upload.Float64 < 0 {
+		return fmt.Errorf("the network bandwidth can't be negative")
+	}
+	if c.PacketLoss.Float64 < 0 || c.PacketLoss.Float64 > 1 {
+		return fmt.Errorf("the packet loss should be between 0 and 1, but is %g", c.PacketLoss.Float64)
+	}
+	if c.ConnectionReset.Float64 < 0 || c.ConnectionReset.Float64 > 1 {
+		return fmt.Errorf("the connection reset probability should be between 0 and 1, but is %g",
+			c.ConnectionReset.Float64)
+	}
+	return nil
+}
+
+func networkPresetNames() []string {
+	names := make([]string, 0, len(NetworkPresets))
+	for name := range NetworkPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Name returns the name of the preset if the conditions match it exactly, or
+// "custom" otherwise.
+func (c NetworkConditions) Name() string {
+	if c.Preset.Valid && !c.Latency.Valid && !c.Jitter.Valid && !c.Download.Valid && !c.Upload.Valid &&
+		c.PacketLoss.Float64 == 0 && c.ConnectionReset.Float64 == 0 {
+		return c.Preset.String
+	}
+	return "custom"
+}
+
+// GetLatency returns the latency, falling back to the one of the preset.
+func (c NetworkConditions) GetLatency() time.Duration {
+	if c.Latency.Valid {
+		return time.Duration(c.Latency.Duration)
+	}
+	return NetworkPresets[c.Preset.String].Latency
+}
+
+// GetDownload returns the download bandwidth in kbps, falling back to the one
+// of the preset.
+func (c NetworkConditions) GetDownload() float64 {
+	if c.Download.Valid {
+		return c.Download.Float64
+	}
+	return NetworkPresets[c.Preset.String].Download
+}
+
+// GetUpload returns the upload bandwidth in kbps, falling back to the one of
+// the preset.
+func (c NetworkConditions) GetUpload() float64 {
+	if c.Upload.Valid {
+		return c.Upload.Float64
+	}
+	return NetworkPresets[c.Preset.String].Upload
+}
+
+// IsActive returns whether the conditions actually change anything.
+func (c NetworkConditions) IsActive() bool {
+	return c.Valid && (c.GetLatency() > 0 || c.Jitter.Duration > 0 || c.GetDownload() > 0 || c.GetUpload() > 0 ||
+		c.PacketLoss.Float64 > 0 || c.ConnectionReset.Float64 > 0)
+}
+
+// String implements fmt.Stringer.
+func (c NetworkConditions) String() string {
+	return fmt.Sprintf("%s(latency=%s,jitter=%s,download=%gkbps,upload=%gkbps,packetLoss=%g,connectionReset=%g)",
+		c.Name(), c.GetLatency(), time.Duration(c.Jitter.Duration), c.GetDownload(), c.GetUpload(),
+		c.PacketLoss.Float64, c.ConnectionReset.Float64)
+}
+
+// UnmarshalJSON accepts either the name of a preset, or an object with all of
+// the network options.
+func (c *NetworkConditions) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*c = NetworkConditions{}
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var preset string
+		if err := json.Unmarshal(data, &preset); err != nil {
+			return err
+		}
+		cfg, err := NewNetworkConditions(preset)
+		if err != nil {
+			return err
+		}
+		*c = cfg
+		return nil
+	}
+
+	type rawNetworkConditions NetworkConditions
+	var raw rawNetworkConditions
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("the network conditions should be a preset name or an object: %w", err)
+	}
+	*c = NetworkConditions(raw)
+	c.Valid = true
+	if c.Preset.Valid {
+		c.Preset.String = strings.ToLower(c.Preset.String)
+	}
+	return c.Validate()
+}
+
+// MarshalJSON returns the network conditions as a JSON object, or null if they
+// weren't specified.
+func (c NetworkConditions) MarshalJSON() ([]byte, error) {
+	if !c.Valid {
+		return []byte("null"), nil
+	}
+	type rawNetworkConditions NetworkConditions
+	return json.Marshal(rawNetworkConditions(c))
+}
+
+// UnmarshalText parses either the name of a preset, or a comma-separated list
+// of key=value pairs, e.g. preset=3g,latency=200ms,packetLoss=0.01.
+func (c *NetworkConditions) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*c = NetworkConditions{}
+		return nil
+	}
+	if !bytes.Contains(text, []byte("=")) {
+		cfg, err := NewNetworkConditions(string(text))
+		if err != nil {
+			return err
+		}
+		*c = cfg
+		return nil
+	}
+	params, err := strvals.Parse(string(text))
+	if err != nil {
+		return err
+	}
+	cfg := NetworkConditions{Valid: true}
+	if err := cfg.unmarshal(params); err != nil {
+		return err
+	}
+	*c = cfg
+	return c.Validate()
+}
+
+func (c *NetworkConditions) unmarshal(params map[string]interface{}) (err error) {
+	parseFloat := func(v interface{}) (null.Float, error) {
+		f, err := strconv.ParseFloat(fmt.Sprint(v), 64)
+		return null.FloatFrom(f), err
+	}
+	for k, v := range params {
+		switch k {
+		case "preset":
+			c.Preset = null.StringFrom(strings.ToLower(fmt.Sprint(v)))
+		case "latency":
+			err = c.Latency.UnmarshalText([]byte(fmt.Sprint(v)))
+		case "jitter":
+			err = c.Jitter.UnmarshalText([]byte(fmt.Sprint(v)))
+		case "download":
+			c.Download, err = parseFloat(v)
+		case "upload":
+			c.Upload, err = parseFloat(v)
+		case "packetLoss":
+			c.PacketLoss, err = parseFloat(v)
+		case "connectionReset":
+			c.ConnectionReset, err = parseFloat(v)
+		default:
+			return fmt.Errorf("unknown network conditions field: %s", k)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid network %s value: %w", k, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package types
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+)
+
+func TestNetworkConditionsUnmarshal(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		json, text string
+		expected   NetworkConditions
+		err        string
+	}{
+		{json: `"3G"`, text: `3G`, expected: NetworkConditions{Preset: null.StringFrom("3g"), Valid: true}},
+		{
+			json: `{"preset": "slow-4g", "latency": "200ms", "jitter": 20, "packetLoss": 0.01}`,
+			text: `preset=slow-4g,latency=200ms,jitter=20,packetLoss=0.01`,
+			expected: NetworkConditions{
+				Preset: null.StringFrom("slow-4g"), Latency: NullDurationFrom(200 * time.Millisecond),
+				Jitter: NullDurationFrom(20 * time.Millisecond), PacketLoss: null.FloatFrom(0.01), Valid: true,
+			},
+		},
+		{
+			json: `{"download": 750, "upload": 250.5, "connectionReset": 0.5}`,
+			text: `download=750,upload=250.5,connectionReset=0.5`,
+			expected: NetworkConditions{
+				Download: null.FloatFrom(750), Upload: null.FloatFrom(250.5),
+				ConnectionReset: null.FloatFrom(0.5), Valid: true,
+			},
+		},
+		{json: `"5g"`, text: `5g`, err: "unknown network preset '5g'"},
+		{json: `{"latency": "-1s"}`, text: `latency=-1s`, err: "the network latency and jitter can't be negative"},
+		{json: `{"download": -1}`, text: `download=-1`, err: "the network bandwidth can't be negative"},
+		{json: `{"packetLoss": 2}`, text: `packetLoss=2`, err: "the packet loss should be between 0 and 1"},
+		{
+			json: `{"connectionReset": 1.5}`, text: `connectionReset=1.5`,
+			err: "the connection reset probability should be between 0 and 1",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.json, func(t *testing.T) {
+			t.Parallel()
+			var fromJSON, fromText NetworkConditions
+			jsonErr := json.Unmarshal([]byte(tc.json), &fromJSON)
+			textErr := fromText.UnmarshalText([]byte(tc.text))
+			if tc.err != "" {
+				require.Error(t, jsonErr)
+				require.Error(t, textErr)
+				assert.Contains(t, jsonErr.Error(), tc.err)
+				assert.Contains(t, textErr.Error(), tc.err)
+				return
+			}
+			require.NoError(t, jsonErr)
+			require.NoError(t, textErr)
+			assert.Equal(t, tc.expected, fromJSON)
+			assert.Equal(t, tc.expected, fromText)
+
+			data, err := json.Marshal(fromJSON)
+			require.NoError(t, err)
+			var roundTrip NetworkConditions
+			require.NoError(t, json.Unmarshal(data, &roundTrip))
+			assert.Equal(t, tc.expected, roundTrip)
+		})
+	}
+}
+
+func TestNetworkConditionsUnmarshalInvalid(t *testing.T) {
+	t.Parallel()
+	var c NetworkConditions
+	err := json.Unmarshal([]byte(`3`), &c)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the network conditions should be a preset name or an object")
+	err = c.UnmarshalText([]byte("wat=1"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown network conditions field: wat")
+}
+
+func TestNetworkConditionsValues(t *testing.T) {
+	t.Parallel()
+	preset, err := NewNetworkConditions("3g")
+	require.NoError(t, err)
+	assert.True(t, preset.IsActive())
+	assert.Equal(t, "3g", preset.Name())
+	assert.Equal(t, 150*time.Millisecond, preset.GetLatency())
+	assert.Equal(t, 1600.0, preset.GetDownload())
+	assert.Equal(t, 750.0, preset.GetUpload())
+
+	custom := preset.Apply(NetworkConditions{Latency: NullDurationFrom(time.Second), Valid: true})
+	assert.Equal(t, "custom", custom.Name())
+	assert.Equal(t, time.Second, custom.GetLatency())
+	assert.Equal(t, 1600.0, custom.GetDownload())
+
+	none, err := NewNetworkConditions(NetworkPresetNone)
+	require.NoError(t, err)
+	assert.False(t, none.IsActive())
+	assert.False(t, NetworkConditions{}.IsActive())
+	assert.True(t, NetworkConditions{PacketLoss: null.FloatFrom(0.1), Valid: true}.IsActive())
+}
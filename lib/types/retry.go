@@ -0,0 +1,267 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/kubernetes/helm/pkg/strvals"
+	"gopkg.in/guregu/null.v3"
+)
+
+// The supported backoff policies for retried requests.
+const (
+	RetryBackoffConstant    = "constant"
+	RetryBackoffLinear      = "linear"
+	RetryBackoffExponential = "exponential"
+)
+
+// DefaultRetryDelay is the delay before the first retry, if none was specified.
+const DefaultRetryDelay = time.Second
+
+// MaxRetryDelay is the upper limit for the delay between retries, if no
+// maxDelay was specified.
+const MaxRetryDelay = time.Minute
+
+// RetryConfig specifies if and how failed requests should be retried.
+type RetryConfig struct {
+	// The maximum number of attempts, including the first one.
+	Attempts null.Int `json:"attempts"`
+	// How the delay between the attempts grows, one of constant, linear or exponential.
+	Backoff null.String `json:"backoff"`
+	// The delay before the first retry.
+	Delay NullDuration `json:"delay"`
+	// The upper limit for the delay between attempts, if positive.
+	MaxDelay NullDuration `json:"maxDelay"`
+	// The fraction (between 0 and 1) of every delay that is randomized.
+	Jitter null.Float `json:"jitter"`
+	// The response statuses that should be retried.
+	Statuses []int `json:"statuses"`
+	// The error codes that should be retried.
+	ErrorCodes []int `json:"errorCodes"`
+	// FIXME: Valid is only added to satisfy some logic in
+	// lib.Options.ForEachSpecified(), same as in DNSConfig.
+	Valid bool `json:"-"`
+}
+
+// NewRetryConfig returns a valid RetryConfig with the given maximum number of
+// attempts and the default values for everything else.
+func NewRetryConfig(attempts int64) RetryConfig {
+	return RetryConfig{Attempts: null.IntFrom(attempts), Valid: true}
+}
+
+// Apply returns the result of overwriting any fields with any that are set on
+// the argument.
+func (c RetryConfig) Apply(cfg RetryConfig) RetryConfig {
+	if cfg.Attempts.Valid {
+		c.Attempts = cfg.Attempts
+	}
+	if cfg.Backoff.Valid {
+		c.Backoff = cfg.Backoff
+	}
+	if cfg.Delay.Valid {
+		c.Delay = cfg.Delay
+	}
+	if cfg.MaxDelay.Valid {
+		c.MaxDelay = cfg.MaxDelay
+	}
+	if cfg.Jitter.Valid {
+		c.Jitter = cfg.Jitter
+	}
+	if cfg.Statuses != nil {
+		c.Statuses = cfg.Statuses
+	}
+	if cfg.ErrorCodes != nil {
+		c.ErrorCodes = cfg.ErrorCodes
+	}
+	c.Valid = c.Valid || cfg.Valid
+	return c
+}
+
+// Validate checks if the specified values make sense.
+func (c RetryConfig) Validate() error {
+	if c.Attempts.Valid && c.Attempts.Int64 < 1 {
+		return fmt.Errorf("the retry attempts should be at least 1, but are %d", c.Attempts.Int64)
+	}
+	if c.Backoff.Valid {
+		switch c.Backoff.String {
+		case RetryBackoffConstant, RetryBackoffLinear, RetryBackoffExponential:
+		default:
+			return fmt.Errorf("unknown retry backoff '%s', it should be %s, %s or %s", c.Backoff.String,
+				RetryBackoffConstant, RetryBackoffLinear, RetryBackoffExponential)
+		}
+	}
+	if c.Delay.Duration < 0 || c.MaxDelay.Duration < 0 {
+		return fmt.Errorf("the retry delays can't be negative")
+	}
+	if c.Jitter.Float64 < 0 || c.Jitter.Float64 > 1 {
+		return fmt.Errorf("the retry jitter should be between 0 and 1, but is %g", c.Jitter.Float64)
+	}
+	return nil
+}
+
+// GetAttempts returns the maximum number of attempts, 1 if retries weren't
+// configured.
+func (c RetryConfig) GetAttempts() int {
+	if !c.Attempts.Valid || c.Attempts.Int64 < 1 {
+		return 1
+	}
+	return int(c.Attempts.Int64)
+}
+
+// GetDelay returns the delay before the given retry (1 for the first one),
+// without any jitter, according to the backoff policy.
+func (c RetryConfig) GetDelay(retry int) time.Duration {
+	delay := time.Duration(c.Delay.Duration)
+	if !c.Delay.Valid {
+		delay = DefaultRetryDelay
+	}
+	maxDelay := time.Duration(c.MaxDelay.Duration)
+	if maxDelay <= 0 {
+		maxDelay = MaxRetryDelay
+	}
+	switch c.Backoff.String {
+	case RetryBackoffConstant:
+	case RetryBackoffLinear:
+		delay *= time.Duration(retry)
+	default: // exponential
+		for i := 1; i < retry && delay < maxDelay; i++ {
+			delay *= 2
+		}
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// String implements fmt.Stringer.
+func (c RetryConfig) String() string {
+	return fmt.Sprintf("attempts=%d,backoff=%s,delay=%s,jitter=%g",
+		c.GetAttempts(), c.Backoff.String, c.GetDelay(1), c.Jitter.Float64)
+}
+
+// UnmarshalJSON accepts either the maximum number of attempts, or an object
+// with all of the retry options.
+func (c *RetryConfig) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*c = RetryConfig{}
+		return nil
+	}
+	if attempts, err := strconv.ParseInt(string(data), 10, 64); err == nil {
+		*c = NewRetryConfig(attempts)
+		return c.Validate()
+	}
+
+	type rawRetryConfig RetryConfig
+	var raw rawRetryConfig
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("the retry option should be a number of attempts or an object: %w", err)
+	}
+	*c = RetryConfig(raw)
+	c.Valid = true
+	return c.Validate()
+}
+
+// MarshalJSON returns the retry options as a JSON object, or null if they
+// weren't specified.
+func (c RetryConfig) MarshalJSON() ([]byte, error) {
+	if !c.Valid {
+		return []byte("null"), nil
+	}
+	type rawRetryConfig RetryConfig
+	return json.Marshal(rawRetryConfig(c))
+}
+
+// UnmarshalText parses a comma-separated list of key=value pairs, e.g.
+// attempts=3,backoff=linear,statuses={502,503}. A plain number is treated
+// as the maximum number of attempts.
+func (c *RetryConfig) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*c = RetryConfig{}
+		return nil
+	}
+	if attempts, err := strconv.ParseInt(string(text), 10, 64); err == nil {
+		*c = NewRetryConfig(attempts)
+		return c.Validate()
+	}
+	params, err := strvals.Parse(string(text))
+	if err != nil {
+		return err
+	}
+	cfg := RetryConfig{Valid: true}
+	if err := cfg.unmarshal(params); err != nil {
+		return err
+	}
+	*c = cfg
+	return c.Validate()
+}
+
+func (c *RetryConfig) unmarshal(params map[string]interface{}) (err error) {
+	for k, v := range params {
+		switch k {
+		case "attempts":
+			var attempts int64
+			attempts, err = strconv.ParseInt(fmt.Sprint(v), 10, 64)
+			c.Attempts = null.IntFrom(attempts)
+		case "backoff":
+			c.Backoff = null.StringFrom(fmt.Sprint(v))
+		case "delay":
+			err = c.Delay.UnmarshalText([]byte(fmt.Sprint(v)))
+		case "maxDelay":
+			err = c.MaxDelay.UnmarshalText([]byte(fmt.Sprint(v)))
+		case "jitter":
+			var jitter float64
+			jitter, err = strconv.ParseFloat(fmt.Sprint(v), 64)
+			c.Jitter = null.FloatFrom(jitter)
+		case "statuses":
+			c.Statuses, err = parseIntList(v)
+		case "errorCodes":
+			c.ErrorCodes, err = parseIntList(v)
+		default:
+			return fmt.Errorf("unknown retry configuration field: %s", k)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid retry %s value: %w", k, err)
+		}
+	}
+	return nil
+}
+
+func parseIntList(v interface{}) ([]int, error) {
+	values, ok := v.([]interface{})
+	if !ok {
+		values = []interface{}{v}
+	}
+	result := make([]int, len(values))
+	for i, value := range values {
+		n, err := strconv.Atoi(fmt.Sprint(value))
+		if err != nil {
+			return nil, err
+		}
+		result[i] = n
+	}
+	return result, nil
+}
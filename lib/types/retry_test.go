@@ -0,0 +1,125 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package types
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+)
+
+func TestRetryConfigUnmarshal(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		json, text string
+		expected   RetryConfig
+		err        string
+	}{
+		{json: `3`, text: `3`, expected: NewRetryConfig(3)},
+		{
+			json: `{"attempts": 4, "backoff": "linear", "delay": "100ms", "statuses": [500, 502]}`,
+			text: `attempts=4,backoff=linear,delay=100ms,statuses={500,502}`,
+			expected: RetryConfig{
+				Attempts: null.IntFrom(4), Backoff: null.StringFrom(RetryBackoffLinear),
+				Delay: NullDurationFrom(100 * time.Millisecond), Statuses: []int{500, 502}, Valid: true,
+			},
+		},
+		{
+			json: `{"jitter": 0.5, "errorCodes": [1211], "maxDelay": "2s"}`,
+			text: `jitter=0.5,errorCodes=1211,maxDelay=2s`,
+			expected: RetryConfig{
+				Jitter: null.FloatFrom(0.5), ErrorCodes: []int{1211},
+				MaxDelay: NullDurationFrom(2 * time.Second), Valid: true,
+			},
+		},
+		{json: `0`, text: `0`, err: "the retry attempts should be at least 1"},
+		{json: `{"backoff": "random"}`, text: `backoff=random`, err: "unknown retry backoff 'random'"},
+		{json: `{"jitter": 2}`, text: `jitter=2`, err: "the retry jitter should be between 0 and 1"},
+		{json: `{"delay": "-1s"}`, text: `delay=-1s`, err: "the retry delays can't be negative"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.json, func(t *testing.T) {
+			t.Parallel()
+			var fromJSON, fromText RetryConfig
+			jsonErr := json.Unmarshal([]byte(tc.json), &fromJSON)
+			textErr := fromText.UnmarshalText([]byte(tc.text))
+			if tc.err != "" {
+				require.Error(t, jsonErr)
+				require.Error(t, textErr)
+				assert.Contains(t, jsonErr.Error(), tc.err)
+				assert.Contains(t, textErr.Error(), tc.err)
+				return
+			}
+			require.NoError(t, jsonErr)
+			require.NoError(t, textErr)
+			assert.Equal(t, tc.expected, fromJSON)
+			assert.Equal(t, tc.expected, fromText)
+
+			data, err := json.Marshal(fromJSON)
+			require.NoError(t, err)
+			var roundTrip RetryConfig
+			require.NoError(t, json.Unmarshal(data, &roundTrip))
+			assert.Equal(t, tc.expected, roundTrip)
+		})
+	}
+}
+
+func TestRetryConfigUnmarshalInvalid(t *testing.T) {
+	t.Parallel()
+	var cfg RetryConfig
+	err := json.Unmarshal([]byte(`"3"`), &cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the retry option should be a number of attempts or an object")
+	err = cfg.UnmarshalText([]byte("wat=1"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown retry configuration field: wat")
+}
+
+func TestRetryConfigGetDelay(t *testing.T) {
+	t.Parallel()
+	delays := func(cfg RetryConfig) (result []time.Duration) {
+		for i := 1; i <= 4; i++ {
+			result = append(result, cfg.GetDelay(i))
+		}
+		return result
+	}
+	ms := time.Millisecond
+
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second},
+		delays(RetryConfig{}))
+	assert.Equal(t, []time.Duration{100 * ms, 100 * ms, 100 * ms, 100 * ms}, delays(RetryConfig{
+		Backoff: null.StringFrom(RetryBackoffConstant), Delay: NullDurationFrom(100 * ms),
+	}))
+	assert.Equal(t, []time.Duration{100 * ms, 200 * ms, 300 * ms, 400 * ms}, delays(RetryConfig{
+		Backoff: null.StringFrom(RetryBackoffLinear), Delay: NullDurationFrom(100 * ms),
+	}))
+	assert.Equal(t, []time.Duration{100 * ms, 200 * ms, 250 * ms, 250 * ms}, delays(RetryConfig{
+		Delay: NullDurationFrom(100 * ms), MaxDelay: NullDurationFrom(250 * ms),
+	}))
+	assert.Equal(t, MaxRetryDelay, RetryConfig{}.GetDelay(100))
+	assert.Equal(t, 1, RetryConfig{}.GetAttempts())
+}
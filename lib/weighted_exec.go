@@ -0,0 +1,106 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package lib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WeightedExec is a single exported function in the weighted exec list of a
+// scenario, together with its relative weight.
+type WeightedExec struct {
+	Fn     string `json:"fn"`
+	Weight int64  `json:"weight"`
+}
+
+// WeightedExecs is a list of exported functions, one of which is picked for
+// every iteration of a scenario, proportionally to their weights.
+type WeightedExecs []WeightedExec
+
+// Validate makes sure that all functions have names and positive weights, and
+// that no function is specified more than once.
+func (we WeightedExecs) Validate() (errors []error) {
+	seen := make(map[string]bool, len(we))
+	for i, e := range we {
+		if e.Fn == "" {
+			errors = append(errors, fmt.Errorf("the exec function %d doesn't have a name", i+1))
+		} else if seen[e.Fn] {
+			errors = append(errors, fmt.Errorf("the exec function '%s' is specified more than once", e.Fn))
+		}
+		seen[e.Fn] = true
+		if e.Weight <= 0 {
+			errors = append(errors, fmt.Errorf("the weight of the exec function '%s' should be more than 0", e.Fn))
+		}
+	}
+	return errors
+}
+
+// TotalWeight returns the sum of all weights.
+func (we WeightedExecs) TotalWeight() (result int64) {
+	for _, e := range we {
+		result += e.Weight
+	}
+	return result
+}
+
+// String returns a short human-readable description of the list, with the
+// share of every function as a percentage.
+func (we WeightedExecs) String() string {
+	total := we.TotalWeight()
+	parts := make([]string, len(we))
+	for i, e := range we {
+		parts[i] = fmt.Sprintf("%s (%.4g%%)", e.Fn, float64(e.Weight)*100/float64(total))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Pick deterministically chooses one of the functions for the given VU
+// iteration. The same seed, VU ID and iteration number always result in the
+// same function, while the choices over many iterations are distributed
+// proportionally to the weights.
+func (we WeightedExecs) Pick(seed, vuID, iteration int64) string {
+	total := we.TotalWeight()
+	if total <= 0 {
+		return ""
+	}
+	x := splitMix64(uint64(seed))
+	x = splitMix64(x ^ uint64(vuID))
+	x = splitMix64(x ^ uint64(iteration))
+
+	point := int64(x % uint64(total))
+	for _, e := range we {
+		if point < e.Weight {
+			return e.Fn
+		}
+		point -= e.Weight
+	}
+	return we[len(we)-1].Fn // shouldn't happen
+}
+
+// splitMix64 is the finalizer of the SplitMix64 PRNG, used as a fast hash
+// function with a good distribution.
+func splitMix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
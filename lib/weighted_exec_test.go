@@ -0,0 +1,61 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeightedExecsValidate(t *testing.T) {
+	t.Parallel()
+	assert.Empty(t, WeightedExecs{{"a", 1}, {"b", 2}}.Validate())
+	assert.Len(t, WeightedExecs{{"", 1}}.Validate(), 1)
+	assert.Len(t, WeightedExecs{{"a", 0}, {"b", -1}}.Validate(), 2)
+	assert.Len(t, WeightedExecs{{"a", 1}, {"a", 1}}.Validate(), 1)
+}
+
+func TestWeightedExecsPick(t *testing.T) {
+	t.Parallel()
+	execs := WeightedExecs{{"browse", 70}, {"search", 25}, {"checkout", 5}}
+	assert.Equal(t, "browse (70%), search (25%), checkout (5%)", execs.String())
+
+	counts := map[string]int{}
+	const iterations = 100000
+	for i := int64(0); i < iterations; i++ {
+		fn := execs.Pick(42, i%10, i/10)
+		assert.Equal(t, fn, execs.Pick(42, i%10, i/10)) // deterministic
+		counts[fn]++
+	}
+	assert.InDelta(t, 0.70, float64(counts["browse"])/iterations, 0.01)
+	assert.InDelta(t, 0.25, float64(counts["search"])/iterations, 0.01)
+	assert.InDelta(t, 0.05, float64(counts["checkout"])/iterations, 0.01)
+
+	var different bool
+	for i := int64(0); i < 100; i++ {
+		if execs.Pick(1, 1, i) != execs.Pick(2, 1, i) {
+			different = true
+			break
+		}
+	}
+	assert.True(t, different, "different seeds should result in different picks")
+}
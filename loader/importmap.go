@@ -0,0 +1,175 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// An ImportMap maps module specifiers to the URLs they are loaded from, so
+// bare specifiers like `lodash` can be used without a bundler. It follows the
+// import maps proposal, see https://github.com/WICG/import-maps, with keys
+// that end in a slash mapping all of the specifiers that start with them.
+type ImportMap struct {
+	// The URL of the import map itself, which the addresses are relative to.
+	URL *url.URL
+
+	imports specifierMap
+	scopes  []importMapScope
+}
+
+type importMapScope struct {
+	prefix  string
+	imports specifierMap
+}
+
+// specifierMap maps the normalized specifiers to the resolved addresses,
+// sorted with the longest keys first, so the most specific prefix matches
+type specifierMap []specifierMapping
+
+type specifierMapping struct {
+	key     string
+	address *url.URL
+}
+
+// ParseImportMap parses the JSON import map that was loaded from the given URL.
+func ParseImportMap(data []byte, mapURL *url.URL) (*ImportMap, error) {
+	var raw struct {
+		Imports map[string]string            `json:"imports"`
+		Scopes  map[string]map[string]string `json:"scopes"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("couldn't parse the import map %s: %w", mapURL, err)
+	}
+
+	importMap := &ImportMap{URL: mapURL}
+	var err error
+	if importMap.imports, err = parseSpecifierMap(raw.Imports, mapURL); err != nil {
+		return nil, fmt.Errorf("invalid import map %s: %w", mapURL, err)
+	}
+	for prefix, imports := range raw.Scopes {
+		scopeURL, err := mapURL.Parse(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid import map %s: invalid scope %q: %w", mapURL, prefix, err)
+		}
+		scope := importMapScope{prefix: scopeURL.String()}
+		if scope.imports, err = parseSpecifierMap(imports, mapURL); err != nil {
+			return nil, fmt.Errorf("invalid import map %s: scope %q: %w", mapURL, prefix, err)
+		}
+		importMap.scopes = append(importMap.scopes, scope)
+	}
+	sort.Slice(importMap.scopes, func(i, j int) bool {
+		return len(importMap.scopes[i].prefix) > len(importMap.scopes[j].prefix)
+	})
+	return importMap, nil
+}
+
+func parseSpecifierMap(imports map[string]string, mapURL *url.URL) (specifierMap, error) {
+	result := make(specifierMap, 0, len(imports))
+	for key, address := range imports {
+		if key == "" {
+			return nil, fmt.Errorf("empty specifier key")
+		}
+		if strings.HasSuffix(key, "/") != strings.HasSuffix(address, "/") {
+			return nil, fmt.Errorf("the address of %q must end with a slash only if the specifier does", key)
+		}
+		addressURL, err := mapURL.Parse(address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q for %q: %w", address, key, err)
+		}
+		if addressURL.Scheme != "file" && addressURL.Scheme != "https" {
+			return nil, fmt.Errorf("the address %q for %q must be a local path or an https URL", address, key)
+		}
+		if u := normalizeSpecifier(key, Dir(mapURL)); u != nil {
+			key = u.String()
+		}
+		result = append(result, specifierMapping{key: key, address: addressURL})
+	}
+	sort.Slice(result, func(i, j int) bool { return len(result[i].key) > len(result[j].key) })
+	return result, nil
+}
+
+// IsBareSpecifier returns whether the given module specifier is neither a
+// relative or absolute path, nor a URL.
+func IsBareSpecifier(specifier string) bool {
+	return specifier != "" && specifier[0] != '.' && specifier[0] != '/' &&
+		!filepath.IsAbs(specifier) && !strings.Contains(specifier, "://")
+}
+
+// normalizeSpecifier resolves the specifiers that are paths or URLs, and
+// returns nil for bare specifiers
+func normalizeSpecifier(specifier string, base *url.URL) *url.URL {
+	if IsBareSpecifier(specifier) {
+		return nil
+	}
+	if strings.Contains(specifier, "://") {
+		u, err := url.Parse(specifier)
+		if err != nil {
+			return nil
+		}
+		return u
+	}
+	u, err := Resolve(base, specifier)
+	if err != nil {
+		return nil
+	}
+	return u
+}
+
+// Resolve returns the URL that the given specifier, imported from a module
+// in the given directory, is mapped to, or nil if it isn't in the map.
+func (m *ImportMap) Resolve(specifier string, pwd *url.URL) *url.URL {
+	normalized := specifier
+	if u := normalizeSpecifier(specifier, pwd); u != nil {
+		normalized = u.String()
+	}
+	referrer := pwd.String()
+	for _, scope := range m.scopes {
+		if !strings.HasPrefix(referrer, scope.prefix) {
+			continue
+		}
+		if u := scope.imports.resolve(normalized); u != nil {
+			return u
+		}
+	}
+	return m.imports.resolve(normalized)
+}
+
+func (sm specifierMap) resolve(specifier string) *url.URL {
+	for _, mapping := range sm {
+		if mapping.key == specifier {
+			return mapping.address
+		}
+		if strings.HasSuffix(mapping.key, "/") && strings.HasPrefix(specifier, mapping.key) {
+			u, err := mapping.address.Parse(strings.TrimPrefix(specifier, mapping.key))
+			if err != nil || !strings.HasPrefix(u.String(), mapping.address.String()) {
+				continue // don't allow going back out of the mapped prefix
+			}
+			return u
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package loader_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loadimpact/k6/loader"
+)
+
+func TestImportMap(t *testing.T) {
+	t.Parallel()
+	mapURL := &url.URL{Scheme: "file", Path: "/project/importmap.json"}
+	importMap, err := loader.ParseImportMap([]byte(`{
+		"imports": {
+			"lodash": "./node_modules/lodash-es/lodash.js",
+			"lodash/": "./node_modules/lodash-es/",
+			"utils": "https://jslib.k6.io/k6-utils/1.0.0/index.js",
+			"jslib/": "https://jslib.k6.io/",
+			"/project/lib/old.js": "./lib/new.js"
+		},
+		"scopes": {
+			"./legacy/": {
+				"lodash": "./node_modules/lodash3/index.js"
+			}
+		}
+	}`), mapURL)
+	require.NoError(t, err)
+	assert.Equal(t, mapURL, importMap.URL)
+
+	pwd := &url.URL{Scheme: "file", Path: "/project/tests/"}
+	testCases := map[string]string{
+		"lodash":                  "file:///project/node_modules/lodash-es/lodash.js",
+		"lodash/fp/map.js":        "file:///project/node_modules/lodash-es/fp/map.js",
+		"utils":                   "https://jslib.k6.io/k6-utils/1.0.0/index.js",
+		"jslib/k6-utils/index.js": "https://jslib.k6.io/k6-utils/index.js",
+		"../lib/old.js":           "file:///project/lib/new.js",
+		"lodash/../../secret.js":  "",
+		"lodash-es":               "",
+		"./lodash":                "",
+		"k6-utils":                "",
+	}
+	for specifier, expected := range testCases {
+		u := importMap.Resolve(specifier, pwd)
+		if expected == "" {
+			assert.Nil(t, u, specifier)
+			continue
+		}
+		if assert.NotNil(t, u, specifier) {
+			assert.Equal(t, expected, u.String(), specifier)
+		}
+	}
+
+	t.Run("scopes", func(t *testing.T) {
+		t.Parallel()
+		legacyPwd := &url.URL{Scheme: "file", Path: "/project/legacy/tests/"}
+		assert.Equal(t, "file:///project/node_modules/lodash3/index.js",
+			importMap.Resolve("lodash", legacyPwd).String())
+		assert.Equal(t, "file:///project/node_modules/lodash-es/fp.js",
+			importMap.Resolve("lodash/fp.js", legacyPwd).String())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		invalidMaps := map[string]string{
+			`{"imports": []}`:                         "couldn't parse the import map",
+			`{"imports": {"": "./a.js"}}`:             "empty specifier key",
+			`{"imports": {"a/": "./a.js"}}`:           "must end with a slash only if the specifier does",
+			`{"imports": {"a": "ftp://host/a.js"}}`:   "must be a local path or an https URL",
+			`{"scopes": {"./": {"a/": "./a.js"}}}`:    "scope \"./\"",
+			`{"imports": {"a": "https://host/%zz"}}`:  "invalid address",
+			`{"imports": {"a": "./a.js"}, "x": true}`: "",
+		}
+		for data, expErr := range invalidMaps {
+			_, err := loader.ParseImportMap([]byte(data), mapURL)
+			if expErr == "" {
+				assert.NoError(t, err, data)
+			} else if assert.Error(t, err, data) {
+				assert.Contains(t, err.Error(), expErr, data)
+			}
+		}
+	})
+}
+
+func TestIsBareSpecifier(t *testing.T) {
+	t.Parallel()
+	for specifier, expected := range map[string]bool{
+		"lodash":             true,
+		"@scope/pkg/sub":     true,
+		"k6-utils/index.js":  true,
+		"./lib.js":           false,
+		"../lib.js":          false,
+		"/abs/lib.js":        false,
+		"https://host/a.js":  false,
+		"file:///path/a.js":  false,
+		"":                   false,
+		"github.com/a/b/c":   true,
+		"jslib.k6.io/a/b.js": true,
+	} {
+		assert.Equal(t, expected, loader.IsBareSpecifier(specifier), specifier)
+	}
+}
@@ -203,44 +203,61 @@ func Load(
 		return nil, err
 	}
 	if scheme == "https" {
-		var finalModuleSpecifierURL = &url.URL{}
-
-		switch {
-		case moduleSpecifier.Opaque != "": // This is loader
-			finalModuleSpecifierURL, err = resolveUsingLoaders(logger, moduleSpecifier.Opaque)
-			if err != nil {
-				return nil, err
-			}
-		case moduleSpecifier.Scheme == "":
-			logger.Warningf(`The moduleSpecifier "%s" has no scheme but we will try to resolve it as remote module. `+
-				`This will be deprecated in the future and all remote modules will `+
-				`need to explicitly use "https" as scheme.`, originalModuleSpecifier)
-			*finalModuleSpecifierURL = *moduleSpecifier
-			finalModuleSpecifierURL.Scheme = scheme
-		default:
-			finalModuleSpecifierURL = moduleSpecifier
-		}
 		var result *SourceData
-		result, err = loadRemoteURL(logger, finalModuleSpecifierURL)
-		if err == nil {
-			result.URL = moduleSpecifier
-			// TODO maybe make an afero.Fs which makes request directly and than use CacheOnReadFs
-			// on top of as with the `file` scheme fs
-			_ = afero.WriteFile(filesystems[scheme], pathOnFs, result.Data, 0644)
-			return result, nil
+		if remoteFs, ok := filesystems[scheme].(*RemoteFs); ok {
+			result, err = remoteFs.load(logger, moduleSpecifier, pathOnFs, originalModuleSpecifier)
+		} else {
+			result, err = loadRemoteModule(logger, moduleSpecifier, originalModuleSpecifier)
 		}
-
-		if moduleSpecifier.Scheme == "" || moduleSpecifier.Opaque == "" {
-			// we have an error and we did remote module resolution without a scheme
-			// let's write the coolest error message to try to help the lost soul who got to here
-			return nil, noSchemeRemoteModuleResolutionError{err: err, moduleSpecifier: originalModuleSpecifier}
+		if err != nil {
+			return nil, err
 		}
-		return nil, errors.Errorf(httpsSchemeCouldntBeLoadedMsg, originalModuleSpecifier, finalModuleSpecifierURL, err)
+		// TODO maybe make an afero.Fs which makes request directly and than use CacheOnReadFs
+		// on top of as with the `file` scheme fs
+		_ = afero.WriteFile(filesystems[scheme], pathOnFs, result.Data, 0644)
+		return result, nil
 	}
 
 	return nil, errors.Errorf(fileSchemeCouldntBeLoadedMsg, originalModuleSpecifier)
 }
 
+// loadRemoteModule fetches the remote module from the network.
+func loadRemoteModule(
+	logger logrus.FieldLogger, moduleSpecifier *url.URL, originalModuleSpecifier string,
+) (*SourceData, error) {
+	var (
+		finalModuleSpecifierURL = &url.URL{}
+		err                     error
+	)
+	switch {
+	case moduleSpecifier.Opaque != "": // This is loader
+		finalModuleSpecifierURL, err = resolveUsingLoaders(logger, moduleSpecifier.Opaque)
+		if err != nil {
+			return nil, err
+		}
+	case moduleSpecifier.Scheme == "":
+		logger.Warningf(`The moduleSpecifier "%s" has no scheme but we will try to resolve it as remote module. `+
+			`This will be deprecated in the future and all remote modules will `+
+			`need to explicitly use "https" as scheme.`, originalModuleSpecifier)
+		*finalModuleSpecifierURL = *moduleSpecifier
+		finalModuleSpecifierURL.Scheme = "https"
+	default:
+		finalModuleSpecifierURL = moduleSpecifier
+	}
+	result, err := loadRemoteURL(logger, finalModuleSpecifierURL)
+	if err == nil {
+		result.URL = moduleSpecifier
+		return result, nil
+	}
+
+	if moduleSpecifier.Scheme == "" || moduleSpecifier.Opaque == "" {
+		// we have an error and we did remote module resolution without a scheme
+		// let's write the coolest error message to try to help the lost soul who got to here
+		return nil, noSchemeRemoteModuleResolutionError{err: err, moduleSpecifier: originalModuleSpecifier}
+	}
+	return nil, errors.Errorf(httpsSchemeCouldntBeLoadedMsg, originalModuleSpecifier, finalModuleSpecifierURL, err)
+}
+
 func resolveUsingLoaders(logger logrus.FieldLogger, name string) (*url.URL, error) {
 	_, loader, loaderArgs := pickLoader(name)
 	if loader != nil {
@@ -0,0 +1,113 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package loader
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/spf13/afero"
+)
+
+const lockfileVersion = 1
+
+// Lockfile records the integrity hashes of the remote modules used by a
+// script, so that any change in their contents is detected when they're loaded.
+type Lockfile struct {
+	Version int `json:"version"`
+	// Modules maps the URLs of the remote modules to their integrity hashes,
+	// in the same format as the Subresource Integrity hashes in browsers.
+	Modules map[string]string `json:"modules"`
+
+	mu sync.Mutex
+}
+
+// NewLockfile returns a new empty lockfile.
+func NewLockfile() *Lockfile {
+	return &Lockfile{Version: lockfileVersion, Modules: make(map[string]string)}
+}
+
+// ReadLockfile reads the lockfile with the given filename from fs.
+func ReadLockfile(fs afero.Fs, filename string) (*Lockfile, error) {
+	data, err := afero.ReadFile(fs, filename)
+	if err != nil {
+		return nil, err
+	}
+	lockfile := NewLockfile()
+	if err = json.Unmarshal(data, lockfile); err != nil {
+		return nil, fmt.Errorf("couldn't parse the lockfile %s: %w", filename, err)
+	}
+	if lockfile.Version != lockfileVersion {
+		return nil, fmt.Errorf("unsupported version %d of the lockfile %s", lockfile.Version, filename)
+	}
+	if lockfile.Modules == nil {
+		lockfile.Modules = make(map[string]string)
+	}
+	return lockfile, nil
+}
+
+// Write writes the lockfile to fs, with the modules sorted by their URLs.
+func (l *Lockfile) Write(fs afero.Fs, filename string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(l); err != nil {
+		return err
+	}
+	return afero.WriteFile(fs, filename, buf.Bytes(), 0o644)
+}
+
+// Record records the integrity hash of the given data for module.
+func (l *Lockfile) Record(module string, data []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Modules[module] = Integrity(data)
+}
+
+// Verify returns an error if module isn't in the lockfile or if the
+// integrity hash of data doesn't match the recorded one.
+func (l *Lockfile) Verify(module string, data []byte) error {
+	l.mu.Lock()
+	expected, ok := l.Modules[module]
+	l.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("the remote module %s isn't in the lockfile, run `k6 vendor` to add it", module)
+	}
+	if actual := Integrity(data); actual != expected {
+		return fmt.Errorf("the integrity check of the remote module %s failed, expected %s but got %s",
+			module, expected, actual)
+	}
+	return nil
+}
+
+// Integrity returns the integrity hash of the given data.
+func Integrity(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256-" + base64.StdEncoding.EncodeToString(sum[:])
+}
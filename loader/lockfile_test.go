@@ -0,0 +1,78 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package loader_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loadimpact/k6/loader"
+)
+
+func TestLockfile(t *testing.T) {
+	t.Parallel()
+	fs := afero.NewMemMapFs()
+	lockfile := loader.NewLockfile()
+	lockfile.Record("https://example.com/b.js", []byte("b"))
+	lockfile.Record("https://example.com/a.js", []byte("a"))
+	require.NoError(t, lockfile.Write(fs, "/k6.lock"))
+
+	data, err := afero.ReadFile(fs, "/k6.lock")
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "version": 1,
+  "modules": {
+    "https://example.com/a.js": "sha256-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha/uSLs=",
+    "https://example.com/b.js": "sha256-PiPoFgA5WUoziU9lZOGxNIu9egCI1CxKy3PurtWcAJ0="
+  }
+}
+`, string(data))
+
+	lockfile, err = loader.ReadLockfile(fs, "/k6.lock")
+	require.NoError(t, err)
+	assert.NoError(t, lockfile.Verify("https://example.com/a.js", []byte("a")))
+	err = lockfile.Verify("https://example.com/a.js", []byte("changed"))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "the integrity check of the remote module https://example.com/a.js failed")
+	}
+	err = lockfile.Verify("https://example.com/c.js", []byte("c"))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "https://example.com/c.js isn't in the lockfile")
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+		_, err := loader.ReadLockfile(fs, "/missing.lock")
+		assert.True(t, os.IsNotExist(err))
+
+		require.NoError(t, afero.WriteFile(fs, "/invalid.lock", []byte(`{"modules": []}`), 0o644))
+		_, err = loader.ReadLockfile(fs, "/invalid.lock")
+		assert.Error(t, err)
+
+		require.NoError(t, afero.WriteFile(fs, "/future.lock", []byte(`{"version": 2}`), 0o644))
+		_, err = loader.ReadLockfile(fs, "/future.lock")
+		assert.EqualError(t, err, "unsupported version 2 of the lockfile /future.lock")
+	})
+}
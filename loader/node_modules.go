@@ -0,0 +1,156 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// nodeModulesExtensions are the extensions that are tried, in order, for
+// files in packages that are referenced without one
+//nolint:gochecknoglobals
+var nodeModulesExtensions = []string{"", ".js", ".mjs", ".cjs", ".ts", ".json"}
+
+// ResolveNodeModule resolves a bare module specifier, e.g. `lodash` or
+// `@scope/package/sub/path`, the same way Node.js does: by looking for the
+// package in the node_modules directories of the given directory and all of
+// its parents, and using the `module` or `main` field of its package.json.
+// It returns nil if the package isn't found.
+func ResolveNodeModule(fs afero.Fs, pwd *url.URL, specifier string) (*url.URL, error) {
+	if pwd.Scheme != "file" || !IsBareSpecifier(specifier) {
+		return nil, nil
+	}
+	name, subpath := splitPackageSpecifier(specifier)
+	if name == "" {
+		return nil, fmt.Errorf("invalid package name in %q", specifier)
+	}
+
+	dir := path.Clean(pwd.Path)
+	for {
+		if path.Base(dir) != "node_modules" {
+			pkgDir := path.Join(dir, "node_modules", name)
+			var (
+				resolved string
+				err      error
+			)
+			if subpath != "" {
+				resolved, err = resolvePackagePath(fs, pkgDir, path.Join(pkgDir, subpath))
+			} else {
+				resolved, err = resolvePackageMain(fs, pkgDir)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("couldn't resolve %q from %s: %w", specifier, pkgDir, err)
+			}
+			if resolved != "" {
+				return &url.URL{Scheme: "file", Path: resolved}, nil
+			}
+		}
+		parent := path.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// splitPackageSpecifier splits a specifier into the package name, which is
+// scoped if it starts with @, and the path inside of the package
+func splitPackageSpecifier(specifier string) (name, subpath string) {
+	if strings.HasPrefix(specifier, "@") {
+		parts := strings.SplitN(specifier, "/", 3)
+		if len(parts) < 2 || parts[1] == "" {
+			return "", ""
+		}
+		name = parts[0] + "/" + parts[1]
+		if len(parts) == 3 {
+			subpath = parts[2]
+		}
+		return name, subpath
+	}
+	if i := strings.IndexByte(specifier, '/'); i >= 0 {
+		return specifier[:i], specifier[i+1:]
+	}
+	return specifier, ""
+}
+
+type packageJSON struct {
+	Module string `json:"module"`
+	Main   string `json:"main"`
+}
+
+// resolvePackageMain returns the entry point of the package in the given
+// directory, or an empty string if there is no package there
+func resolvePackageMain(fs afero.Fs, pkgDir string) (string, error) {
+	data, err := afero.ReadFile(fs, filepath.FromSlash(path.Join(pkgDir, "package.json")))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return resolvePackagePath(fs, pkgDir, path.Join(pkgDir, "index"))
+		}
+		return "", err
+	}
+	var pkg packageJSON
+	if err = json.Unmarshal(data, &pkg); err != nil {
+		return "", fmt.Errorf("invalid package.json: %w", err)
+	}
+	for _, entry := range []string{pkg.Module, pkg.Main} {
+		if entry == "" {
+			continue
+		}
+		resolved, err := resolvePackagePath(fs, pkgDir, path.Join(pkgDir, entry))
+		if err != nil || resolved != "" {
+			return resolved, err
+		}
+	}
+	resolved, err := resolvePackagePath(fs, pkgDir, path.Join(pkgDir, "index"))
+	if err == nil && resolved == "" {
+		err = fmt.Errorf("the package has no entry point")
+	}
+	return resolved, err
+}
+
+// resolvePackagePath returns the file with the given path inside of a
+// package, trying the usual extensions and index files
+func resolvePackagePath(fs afero.Fs, pkgDir, filePath string) (string, error) {
+	if filePath != pkgDir && !strings.HasPrefix(filePath, pkgDir+"/") {
+		return "", fmt.Errorf("the path %s is outside of the package", filePath)
+	}
+	for _, ext := range nodeModulesExtensions {
+		info, err := fs.Stat(filepath.FromSlash(filePath + ext))
+		if err == nil && !info.IsDir() {
+			return filePath + ext, nil
+		}
+	}
+	for _, ext := range nodeModulesExtensions[1:] {
+		indexPath := path.Join(filePath, "index"+ext)
+		if info, err := fs.Stat(filepath.FromSlash(indexPath)); err == nil && !info.IsDir() {
+			return indexPath, nil
+		}
+	}
+	return "", nil
+}
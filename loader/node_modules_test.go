@@ -0,0 +1,111 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package loader_test
+
+import (
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loadimpact/k6/loader"
+)
+
+func TestResolveNodeModule(t *testing.T) {
+	t.Parallel()
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"/project/node_modules/main-pkg/package.json":          `{"main": "lib/main"}`,
+		"/project/node_modules/main-pkg/lib/main.js":           ``,
+		"/project/node_modules/main-pkg/lib/util.js":           ``,
+		"/project/node_modules/module-pkg/package.json":        `{"main": "cjs/index.js", "module": "esm/index.js"}`,
+		"/project/node_modules/module-pkg/esm/index.js":        ``,
+		"/project/node_modules/module-pkg/cjs/index.js":        ``,
+		"/project/node_modules/@scope/pkg/package.json":        `{"main": "./dist"}`,
+		"/project/node_modules/@scope/pkg/dist/index.js":       ``,
+		"/project/node_modules/@scope/pkg/other/file.mjs":      ``,
+		"/project/node_modules/no-manifest/index.js":           ``,
+		"/project/node_modules/broken/package.json":            `{"main": 5}`,
+		"/project/node_modules/empty/package.json":             `{}`,
+		"/project/tests/node_modules/main-pkg/package.json":    `{"main": "nested.js"}`,
+		"/project/tests/node_modules/main-pkg/nested.js":       ``,
+		"/project/tests/node_modules/.bin/node_modules/x/a.js": ``,
+	}
+	for name, data := range files {
+		require.NoError(t, afero.WriteFile(fs, filepath.FromSlash(name), []byte(data), 0o644))
+	}
+
+	pwd := &url.URL{Scheme: "file", Path: "/project/src/"}
+	testCases := map[string]string{
+		"main-pkg":              "file:///project/node_modules/main-pkg/lib/main.js",
+		"main-pkg/lib/util":     "file:///project/node_modules/main-pkg/lib/util.js",
+		"main-pkg/lib/util.js":  "file:///project/node_modules/main-pkg/lib/util.js",
+		"module-pkg":            "file:///project/node_modules/module-pkg/esm/index.js",
+		"@scope/pkg":            "file:///project/node_modules/@scope/pkg/dist/index.js",
+		"@scope/pkg/other/file": "file:///project/node_modules/@scope/pkg/other/file.mjs",
+		"no-manifest":           "file:///project/node_modules/no-manifest/index.js",
+		"missing":               "",
+		"main-pkg/missing.js":   "",
+		"./main-pkg":            "",
+		"https://host/a.js":     "",
+	}
+	for specifier, expected := range testCases {
+		u, err := loader.ResolveNodeModule(fs, pwd, specifier)
+		require.NoError(t, err, specifier)
+		if expected == "" {
+			assert.Nil(t, u, specifier)
+		} else if assert.NotNil(t, u, specifier) {
+			assert.Equal(t, expected, u.String(), specifier)
+		}
+	}
+
+	t.Run("nested", func(t *testing.T) {
+		t.Parallel()
+		u, err := loader.ResolveNodeModule(fs, &url.URL{Scheme: "file", Path: "/project/tests/load/"}, "main-pkg")
+		require.NoError(t, err)
+		assert.Equal(t, "file:///project/tests/node_modules/main-pkg/nested.js", u.String())
+	})
+
+	t.Run("remote", func(t *testing.T) {
+		t.Parallel()
+		u, err := loader.ResolveNodeModule(fs, &url.URL{Scheme: "https", Host: "example.com", Path: "/"}, "main-pkg")
+		require.NoError(t, err)
+		assert.Nil(t, u)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		for specifier, expErr := range map[string]string{
+			"broken":           "invalid package.json",
+			"empty":            "the package has no entry point",
+			"@scope":           "invalid package name",
+			"main-pkg/../../a": "outside of the package",
+		} {
+			_, err := loader.ResolveNodeModule(fs, pwd, specifier)
+			if assert.Error(t, err, specifier) {
+				assert.Contains(t, err.Error(), expErr, specifier)
+			}
+		}
+	})
+}
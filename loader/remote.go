@@ -0,0 +1,100 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package loader
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// RemoteFs is the filesystem for the "https" scheme that also controls how
+// Load fetches the remote modules which aren't in it yet. Fetched modules are
+// verified against the lockfile and kept in the on-disk module cache, so later
+// test runs can load them without network access.
+type RemoteFs struct {
+	afero.Fs
+
+	// Lockfile with the integrity hashes of the remote modules, if any.
+	Lockfile *Lockfile
+	// Cache is the on-disk module cache, if any.
+	Cache afero.Fs
+	// Offline disables all network requests, so remote modules are only
+	// loaded from the module cache.
+	Offline bool
+	// Refresh fetches all remote modules from the network, even the cached
+	// ones, and records their hashes in the lockfile instead of verifying them.
+	Refresh bool
+}
+
+// load returns the remote module from the module cache or from the network.
+func (fs *RemoteFs) load(
+	logger logrus.FieldLogger, moduleSpecifier *url.URL, pathOnFs, originalModuleSpecifier string,
+) (*SourceData, error) {
+	module := moduleKey(moduleSpecifier)
+	// Modules are only loaded from the cache when their contents can't change
+	// because of it, otherwise they are always fetched, as they were without a cache
+	if fs.Cache != nil && !fs.Refresh && (fs.Offline || fs.Lockfile != nil) {
+		data, err := afero.ReadFile(fs.Cache, pathOnFs)
+		if err == nil && (fs.Lockfile == nil || fs.Lockfile.Verify(module, data) == nil) {
+			logger.WithField("module", module).Debug("Loaded from the module cache")
+			return &SourceData{URL: moduleSpecifier, Data: data}, nil
+		}
+	}
+	if fs.Offline {
+		return nil, fmt.Errorf("the remote module %s isn't in the module cache and fetching it "+
+			"isn't possible in offline mode", module)
+	}
+
+	result, err := loadRemoteModule(logger, moduleSpecifier, originalModuleSpecifier)
+	if err != nil {
+		return nil, err
+	}
+	if fs.Lockfile != nil {
+		if fs.Refresh {
+			fs.Lockfile.Record(module, result.Data)
+		} else if err = fs.Lockfile.Verify(module, result.Data); err != nil {
+			return nil, err
+		}
+	}
+	if fs.Cache != nil {
+		if err = afero.WriteReader(fs.Cache, pathOnFs, bytes.NewReader(result.Data)); err != nil {
+			logger.WithError(err).WithField("module", module).Warn("Couldn't write to the module cache")
+		}
+	}
+	return result, nil
+}
+
+// moduleKey returns the name of a remote module in the lockfile.
+func moduleKey(moduleSpecifier *url.URL) string {
+	if moduleSpecifier.Opaque != "" { // This is loader
+		return moduleSpecifier.Opaque
+	}
+	if moduleSpecifier.Scheme == "" {
+		u := *moduleSpecifier
+		u.Scheme = "https"
+		return u.String()
+	}
+	return moduleSpecifier.String()
+}
@@ -0,0 +1,118 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package loader_test
+
+import (
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loadimpact/k6/lib/testutils"
+	"github.com/loadimpact/k6/lib/testutils/httpmultibin"
+	"github.com/loadimpact/k6/loader"
+)
+
+func TestRemoteFs(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(testutils.NewTestOutput(t))
+	tb := httpmultibin.NewHTTPMultiBin(t)
+	sr := tb.Replacer.Replace
+
+	oldHTTPTransport := http.DefaultTransport
+	http.DefaultTransport = tb.HTTPTransport
+
+	defer func() {
+		tb.Cleanup()
+		http.DefaultTransport = oldHTTPTransport
+	}()
+
+	var (
+		moduleSrc = "export default 1;"
+		requests  int64
+	)
+	tb.Mux.HandleFunc("/module.js", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		_, _ = w.Write([]byte(moduleSrc))
+	})
+	moduleSpecifier := sr("HTTPSBIN_URL/module.js")
+	moduleURL, err := url.Parse(moduleSpecifier)
+	require.NoError(t, err)
+
+	load := func(remoteFs *loader.RemoteFs) (*loader.SourceData, error) {
+		remoteFs.Fs = afero.NewMemMapFs()
+		return loader.Load(logger, map[string]afero.Fs{"https": remoteFs}, moduleURL, moduleSpecifier)
+	}
+
+	cache := afero.NewMemMapFs()
+	lockfile := loader.NewLockfile()
+
+	t.Run("Refresh", func(t *testing.T) {
+		src, err := load(&loader.RemoteFs{Lockfile: lockfile, Cache: cache, Refresh: true})
+		require.NoError(t, err)
+		assert.Equal(t, moduleSrc, string(src.Data))
+		assert.Equal(t, map[string]string{moduleSpecifier: loader.Integrity([]byte(moduleSrc))}, lockfile.Modules)
+		assert.Equal(t, int64(1), atomic.LoadInt64(&requests))
+	})
+
+	t.Run("Locked", func(t *testing.T) {
+		src, err := load(&loader.RemoteFs{Lockfile: lockfile, Cache: cache})
+		require.NoError(t, err)
+		assert.Equal(t, moduleSrc, string(src.Data))
+		assert.Equal(t, int64(1), atomic.LoadInt64(&requests), "the module should be loaded from the cache")
+	})
+
+	t.Run("Offline", func(t *testing.T) {
+		src, err := load(&loader.RemoteFs{Cache: cache, Offline: true})
+		require.NoError(t, err)
+		assert.Equal(t, moduleSrc, string(src.Data))
+
+		_, err = load(&loader.RemoteFs{Cache: afero.NewMemMapFs(), Offline: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "isn't in the module cache")
+		assert.Equal(t, int64(1), atomic.LoadInt64(&requests))
+	})
+
+	t.Run("Changed", func(t *testing.T) {
+		moduleSrc = "export default 2;"
+
+		src, err := load(&loader.RemoteFs{Cache: cache})
+		require.NoError(t, err)
+		assert.Equal(t, moduleSrc, string(src.Data), "unlocked modules should always be fetched")
+
+		_, err = load(&loader.RemoteFs{Lockfile: lockfile, Cache: cache})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the integrity check of the remote module "+moduleSpecifier+" failed")
+
+		_, err = load(&loader.RemoteFs{Lockfile: lockfile, Cache: cache, Offline: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "isn't in the module cache")
+
+		_, err = load(&loader.RemoteFs{Lockfile: loader.NewLockfile()})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "isn't in the lockfile")
+	})
+}
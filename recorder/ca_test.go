@@ -0,0 +1,73 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package recorder
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCA(t *testing.T) *CA {
+	t.Helper()
+	certPEM, keyPEM, err := GenerateCA("k6 test CA", time.Hour)
+	require.NoError(t, err)
+	ca, err := LoadCA(certPEM, keyPEM)
+	require.NoError(t, err)
+	return ca
+}
+
+func TestCA(t *testing.T) {
+	t.Parallel()
+	ca := newTestCA(t)
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(ca.CertificatePEM()))
+
+	for _, host := range []string{"example.com", "127.0.0.1"} {
+		cert, err := ca.Certificate(host)
+		require.NoError(t, err)
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+		_, err = leaf.Verify(x509.VerifyOptions{DNSName: host, Roots: roots})
+		assert.NoError(t, err, host)
+		assert.False(t, leaf.NotAfter.After(ca.cert.NotAfter))
+
+		cached, err := ca.Certificate(host)
+		require.NoError(t, err)
+		assert.True(t, cert == cached, "the certificate should be cached")
+	}
+}
+
+func TestLoadCA(t *testing.T) {
+	t.Parallel()
+	certPEM, keyPEM, err := GenerateCA("k6 test CA", time.Hour)
+	require.NoError(t, err)
+	_, otherKeyPEM, err := GenerateCA("k6 test CA", time.Hour)
+	require.NoError(t, err)
+
+	_, err = LoadCA(certPEM, otherKeyPEM)
+	assert.Error(t, err)
+	_, err = LoadCA(keyPEM, certPEM)
+	assert.Error(t, err)
+}
@@ -0,0 +1,257 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package recorder
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/loadimpact/k6/converter/har"
+	"github.com/loadimpact/k6/lib/consts"
+)
+
+// newEntry returns the HAR entry of a recorded request and its response.
+func newEntry(
+	req *http.Request, reqBody []byte, res *http.Response, resBody []byte, started time.Time, elapsed time.Duration,
+) *har.Entry {
+	ms := float32(elapsed) / float32(time.Millisecond)
+	entry := &har.Entry{
+		StartedDateTime: started,
+		Time:            ms,
+		Request: &har.Request{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Cookies:     harCookies(req.Cookies()),
+			Headers:     harHeaders(req.Header),
+			QueryString: harQueryString(req.URL.RawQuery),
+			HeadersSize: -1,
+			BodySize:    int64(len(reqBody)),
+		},
+		Response: &har.Response{
+			Status:      res.StatusCode,
+			StatusText:  strings.TrimSpace(strings.TrimPrefix(res.Status, fmt.Sprint(res.StatusCode))),
+			HTTPVersion: res.Proto,
+			Cookies:     harCookies(res.Cookies()),
+			Headers:     harHeaders(res.Header),
+			Content:     harContent(res.Header, resBody),
+			RedirectURL: res.Header.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    int64(len(resBody)),
+		},
+		Cache: &har.Cache{},
+		// the time to send the request and to receive the response can't be
+		// told apart from the waiting time by a proxy
+		Timings: &har.Timings{Wait: ms},
+	}
+	if len(reqBody) > 0 {
+		entry.Request.PostData = &har.PostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     string(reqBody),
+		}
+	}
+	return entry
+}
+
+// harCookies returns the HAR cookies of a request or a response
+func harCookies(cookies []*http.Cookie) []har.Cookie {
+	result := make([]har.Cookie, len(cookies))
+	for i, c := range cookies {
+		result[i] = har.Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		}
+		if !c.Expires.IsZero() {
+			result[i].Expires = c.Expires
+			result[i].Expires8601 = c.Expires.Format(time.RFC3339)
+		}
+	}
+	return result
+}
+
+// harHeaders returns the HAR headers of a request or a response, sorted by name
+func harHeaders(header http.Header) []har.Header {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := make([]har.Header, 0, len(header))
+	for _, name := range names {
+		for _, value := range header[name] {
+			result = append(result, har.Header{Name: name, Value: value})
+		}
+	}
+	return result
+}
+
+// harQueryString returns the HAR query string parameters of a URL, in the order
+// they're in the URL
+func harQueryString(rawQuery string) []har.QueryString {
+	result := []har.QueryString{}
+	for _, param := range strings.Split(rawQuery, "&") {
+		if param == "" {
+			continue
+		}
+		kv := strings.SplitN(param, "=", 2)
+		name, err := url.QueryUnescape(kv[0])
+		if err != nil {
+			name = kv[0]
+		}
+		var value string
+		if len(kv) == 2 {
+			if value, err = url.QueryUnescape(kv[1]); err != nil {
+				value = kv[1]
+			}
+		}
+		result = append(result, har.QueryString{Name: name, Value: value})
+	}
+	return result
+}
+
+// harContent returns the HAR content of a response body, which is decoded and
+// saved as text if possible, or as base64 otherwise
+func harContent(header http.Header, body []byte) *har.Content {
+	content := &har.Content{Size: int64(len(body)), MimeType: header.Get("Content-Type")}
+	if decoded, err := decodeBody(header.Get("Content-Encoding"), body); err == nil {
+		body = decoded
+		content.Size = int64(len(body))
+	}
+	if len(body) == 0 {
+		return content
+	}
+	if isText(content.MimeType) && utf8.Valid(body) {
+		content.Text = string(body)
+	} else {
+		content.Text = base64.StdEncoding.EncodeToString(body)
+		content.Encoding = "base64"
+	}
+	return content
+}
+
+// isText returns whether a media type is textual, assuming that it is when unknown
+func isText(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return true
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "image/"), strings.HasPrefix(mediaType, "audio/"),
+		strings.HasPrefix(mediaType, "video/"), strings.HasPrefix(mediaType, "font/"):
+		return false
+	case mediaType == "application/octet-stream", mediaType == "application/pdf",
+		mediaType == "application/zip", mediaType == "application/protobuf",
+		mediaType == "application/x-protobuf", mediaType == "application/grpc":
+		return false
+	default:
+		return true
+	}
+}
+
+// decodeBody decompresses a body with the content codings of its response
+func decodeBody(contentEncoding string, body []byte) ([]byte, error) {
+	codings := strings.Split(contentEncoding, ",")
+	// the codings are listed in the order they were applied
+	for i := len(codings) - 1; i >= 0; i-- {
+		var r io.Reader
+		var err error
+		switch coding := strings.ToLower(strings.TrimSpace(codings[i])); coding {
+		case "", "identity":
+			continue
+		case "gzip", "x-gzip":
+			r, err = gzip.NewReader(bytes.NewReader(body))
+		case "deflate":
+			r = flate.NewReader(bytes.NewReader(body))
+		case "br":
+			r = brotli.NewReader(bytes.NewReader(body))
+		case "zstd":
+			var d *zstd.Decoder
+			if d, err = zstd.NewReader(bytes.NewReader(body)); err == nil {
+				defer d.Close()
+				r = d
+			}
+		default:
+			return nil, fmt.Errorf("unsupported content encoding %q", coding)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if body, err = ioutil.ReadAll(r); err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
+
+// groupPages groups the entries, sorted by their start, in pages that start
+// after the given idle time without requests. All the entries are in the same
+// page if the idle time isn't positive.
+func groupPages(entries []*har.Entry, idleTime time.Duration) []har.Page {
+	var pages []har.Page
+	var lastEnd time.Time
+	for _, e := range entries {
+		if len(pages) == 0 || (idleTime > 0 && e.StartedDateTime.Sub(lastEnd) > idleTime) {
+			pages = append(pages, har.Page{
+				StartedDateTime: e.StartedDateTime,
+				ID:              fmt.Sprintf("page_%d", len(pages)+1),
+				Title:           e.Request.URL,
+			})
+		}
+		e.Pageref = pages[len(pages)-1].ID
+		end := e.StartedDateTime.Add(time.Duration(e.Time * float32(time.Millisecond)))
+		if end.After(lastEnd) {
+			lastEnd = end
+		}
+	}
+	return pages
+}
+
+// newHAR returns a HAR with the entries, grouped in pages by the idle time
+func newHAR(entries []*har.Entry, idleTime time.Duration) har.HAR {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime.Before(entries[j].StartedDateTime)
+	})
+	return har.HAR{Log: &har.Log{
+		Version: "1.2",
+		Creator: &har.Creator{Name: "k6", Version: consts.Version},
+		Pages:   groupPages(entries, idleTime),
+		Entries: entries,
+	}}
+}
@@ -0,0 +1,98 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package recorder
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loadimpact/k6/converter/har"
+)
+
+func TestHARContent(t *testing.T) {
+	t.Parallel()
+
+	var compressed bytes.Buffer
+	w := gzip.NewWriter(&compressed)
+	_, err := w.Write([]byte(`{"id": 1}`))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	content := harContent(http.Header{
+		"Content-Type":     {"application/json"},
+		"Content-Encoding": {"gzip"},
+	}, compressed.Bytes())
+	assert.Equal(t, `{"id": 1}`, content.Text)
+	assert.Equal(t, "", content.Encoding)
+	assert.Equal(t, int64(9), content.Size)
+
+	content = harContent(http.Header{"Content-Type": {"image/png"}}, []byte{0x89, 'P', 'N', 'G'})
+	assert.Equal(t, "iVBORw==", content.Text)
+	assert.Equal(t, "base64", content.Encoding)
+
+	content = harContent(http.Header{"Content-Encoding": {"unknown"}}, []byte("text"))
+	assert.Equal(t, "text", content.Text)
+}
+
+func TestHARQueryString(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, []har.QueryString{
+		{Name: "b", Value: "1 2"}, {Name: "a", Value: ""}, {Name: "b", Value: "3"},
+	}, harQueryString("b=1+2&a&b=3"))
+	assert.Equal(t, []har.QueryString{}, harQueryString(""))
+}
+
+func TestNewHAR(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	entry := func(offset time.Duration, duration float32, url string) *har.Entry {
+		return &har.Entry{StartedDateTime: start.Add(offset), Time: duration, Request: &har.Request{URL: url}}
+	}
+	entries := []*har.Entry{
+		entry(0, 100, "https://example.com/"),
+		entry(3*time.Second, 100, "https://example.com/login"),
+		entry(50*time.Millisecond, 4000, "https://example.com/slow"),
+		entry(10*time.Second, 100, "https://example.com/home"),
+	}
+
+	h := newHAR(entries, 2*time.Second)
+	assert.Equal(t, "1.2", h.Log.Version)
+	assert.Equal(t, "k6", h.Log.Creator.Name)
+	// the slow request keeps the first page busy until the login one starts
+	require.Len(t, h.Log.Pages, 2)
+	assert.Equal(t, har.Page{StartedDateTime: start, ID: "page_1", Title: "https://example.com/"}, h.Log.Pages[0])
+	assert.Equal(t, "https://example.com/home", h.Log.Pages[1].Title)
+	var pagerefs []string
+	for _, e := range h.Log.Entries {
+		pagerefs = append(pagerefs, e.Pageref)
+	}
+	assert.Equal(t, []string{"page_1", "page_1", "page_1", "page_2"}, pagerefs)
+	assert.Equal(t, "https://example.com/slow", h.Log.Entries[1].Request.URL)
+
+	h = newHAR(entries, 0)
+	assert.Len(t, h.Log.Pages, 1)
+}
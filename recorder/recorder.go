@@ -0,0 +1,262 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package recorder implements an HTTP and HTTPS forward proxy that records the
+// traffic going through it as a HAR.
+package recorder
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/loadimpact/k6/converter/har"
+)
+
+// CAPath is the path where the proxy serves the certificate of its CA to the
+// clients that request it directly, to make installing it on devices easier.
+const CAPath = "/k6-ca.pem"
+
+// hopHeaders are the hop-by-hop headers, which aren't forwarded by proxies
+//
+//nolint:gochecknoglobals
+var hopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization", "Proxy-Connection",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// Recorder is an HTTP forward proxy that records the requests that go through
+// it and their responses. The TLS connections of the CONNECT requests are
+// intercepted with certificates issued by its CA.
+type Recorder struct {
+	// Transport makes the requests to the servers
+	Transport http.RoundTripper
+
+	ca     *CA
+	logger logrus.FieldLogger
+
+	mu      sync.Mutex
+	entries []*har.Entry
+}
+
+// New returns a recorder that intercepts TLS connections with the given CA.
+func New(ca *CA, logger logrus.FieldLogger) *Recorder {
+	return &Recorder{
+		Transport: &http.Transport{
+			Proxy:             http.ProxyFromEnvironment,
+			ForceAttemptHTTP2: true,
+			// the bodies are sent to the clients as they come from the servers
+			DisableCompression: true,
+			IdleConnTimeout:    90 * time.Second,
+		},
+		ca:     ca,
+		logger: logger,
+	}
+}
+
+// HAR returns the recorded requests, grouped in pages that start after the
+// given idle time without requests.
+func (r *Recorder) HAR(pageIdleTime time.Duration) har.HAR {
+	r.mu.Lock()
+	entries := make([]*har.Entry, len(r.entries))
+	copy(entries, r.entries)
+	r.mu.Unlock()
+
+	return newHAR(entries, pageIdleTime)
+}
+
+// ServeHTTP handles a request sent to the proxy.
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch {
+	case req.Method == http.MethodConnect:
+		r.intercept(w, req)
+	case !req.URL.IsAbs() && req.URL.Path == CAPath:
+		w.Header().Set("Content-Type", "application/x-x509-ca-cert")
+		_, _ = w.Write(r.ca.CertificatePEM())
+	case !req.URL.IsAbs():
+		http.Error(w, "this is a recording proxy, configure it as the HTTP proxy of the client", http.StatusBadRequest)
+	default:
+		res, err := r.roundTrip(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		for name, values := range res.Header {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(res.StatusCode)
+		_, _ = io.Copy(w, res.Body)
+	}
+}
+
+// intercept handles a CONNECT request, serving the requests sent through the
+// tunnel with a certificate for the host issued by the CA
+func (r *Recorder) intercept(w http.ResponseWriter, req *http.Request) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "the connection can't be intercepted", http.StatusInternalServerError)
+		return
+	}
+	host := req.URL.Hostname()
+	if host == "" {
+		host, _, _ = net.SplitHostPort(req.Host)
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		r.logger.WithError(err).Debug("Couldn't hijack the connection")
+		return
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err = io.WriteString(conn, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+		return
+	}
+
+	tlsConn := tls.Server(conn, &tls.Config{
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			name := hello.ServerName
+			if name == "" {
+				name = host
+			}
+			return r.ca.Certificate(name)
+		},
+		// the responses are written with HTTP/1.1
+		NextProtos: []string{"http/1.1"},
+	})
+	if err = tlsConn.Handshake(); err != nil {
+		r.logger.WithError(err).WithField("host", req.Host).
+			Warn("The TLS handshake failed, the client probably doesn't trust the CA")
+		return
+	}
+
+	reader := bufio.NewReader(tlsConn)
+	for {
+		tunneled, err := http.ReadRequest(reader)
+		if err != nil {
+			if err != io.EOF {
+				r.logger.WithError(err).Debug("Couldn't read a request")
+			}
+			return
+		}
+		tunneled.URL.Scheme = "https"
+		tunneled.URL.Host = tunneled.Host
+		if tunneled.URL.Host == "" {
+			tunneled.URL.Host = req.Host
+		}
+
+		res, err := r.roundTrip(tunneled)
+		if err != nil {
+			res = &http.Response{
+				StatusCode: http.StatusBadGateway,
+				Header:     http.Header{"Content-Type": {"text/plain; charset=utf-8"}},
+				Body:       ioutil.NopCloser(strings.NewReader(err.Error())),
+			}
+		}
+		res.Proto, res.ProtoMajor, res.ProtoMinor = "HTTP/1.1", 1, 1
+		if err = res.Write(tlsConn); err != nil || tunneled.Close || res.Close {
+			return
+		}
+	}
+}
+
+// roundTrip makes a request to its server and records it. The body of the
+// returned response is already read, and its headers are the ones that
+// have to be sent to the client.
+func (r *Recorder) roundTrip(req *http.Request) (*http.Response, error) {
+	started := time.Now()
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	out := req.Clone(req.Context())
+	out.RequestURI = ""
+	out.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	out.ContentLength = int64(len(reqBody))
+	if len(reqBody) == 0 && req.ContentLength <= 0 {
+		out.Body = nil
+	}
+	removeHopHeaders(out.Header)
+
+	res, err := r.Transport.RoundTrip(out)
+	if err != nil {
+		r.logger.WithError(err).WithField("url", req.URL.String()).Warn("The request failed")
+		return nil, err
+	}
+	resBody, err := ioutil.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	elapsed := time.Since(started)
+
+	removeHopHeaders(res.Header)
+	res.Header.Del("Content-Length")
+	r.record(newEntry(out, reqBody, res, resBody, started, elapsed))
+
+	res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+	res.ContentLength = int64(len(resBody))
+	res.TransferEncoding = nil
+	if bodyAllowed(res.StatusCode) {
+		res.Header.Set("Content-Length", strconv.Itoa(len(resBody)))
+	}
+	return res, nil
+}
+
+// record adds an entry to the recording
+func (r *Recorder) record(entry *har.Entry) {
+	r.logger.WithField("status", entry.Response.Status).Infof("%s %s", entry.Request.Method, entry.Request.URL)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+}
+
+// bodyAllowed returns whether a response with the status can have a body
+func bodyAllowed(status int) bool {
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// removeHopHeaders removes the hop-by-hop headers, including the ones listed
+// in the Connection header
+func removeHopHeaders(header http.Header) {
+	for _, value := range header["Connection"] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				header.Del(name)
+			}
+		}
+	}
+	for _, name := range hopHeaders {
+		header.Del(name)
+	}
+}
@@ -0,0 +1,140 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package recorder
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loadimpact/k6/lib/testutils"
+)
+
+func echoHandler(w http.ResponseWriter, req *http.Request) {
+	body, _ := ioutil.ReadAll(req.Body)
+	http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc123"})
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = fmt.Fprintf(w, `{"method": %q, "path": %q, "body": %q}`, req.Method, req.URL.Path, body)
+}
+
+func proxyClient(t *testing.T, rec *Recorder) *http.Client {
+	t.Helper()
+	proxy := httptest.NewServer(rec)
+	t.Cleanup(proxy.Close)
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(rec.ca.CertificatePEM()))
+	return &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyURL(proxyURL),
+		TLSClientConfig: &tls.Config{RootCAs: roots}, //nolint:gosec
+	}}
+}
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	t.Run("HTTP", func(t *testing.T) {
+		t.Parallel()
+		srv := httptest.NewServer(http.HandlerFunc(echoHandler))
+		defer srv.Close()
+		rec := New(newTestCA(t), testutils.NewLogger(t))
+		client := proxyClient(t, rec)
+
+		res, err := client.Post(srv.URL+"/items?tag=a", "text/plain", strings.NewReader("hello"))
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		assert.JSONEq(t, `{"method": "POST", "path": "/items", "body": "hello"}`, string(body))
+
+		h := rec.HAR(0)
+		require.Len(t, h.Log.Entries, 1)
+		e := h.Log.Entries[0]
+		assert.Equal(t, "POST", e.Request.Method)
+		assert.Equal(t, srv.URL+"/items?tag=a", e.Request.URL)
+		assert.Equal(t, "hello", e.Request.PostData.Text)
+		assert.Equal(t, "text/plain", e.Request.PostData.MimeType)
+		assert.Equal(t, "tag", e.Request.QueryString[0].Name)
+		assert.Equal(t, 200, e.Response.Status)
+		assert.Equal(t, "OK", e.Response.StatusText)
+		assert.Equal(t, string(body), e.Response.Content.Text)
+		assert.Equal(t, "abc123", e.Response.Cookies[0].Value)
+		assert.Equal(t, "page_1", e.Pageref)
+	})
+
+	t.Run("HTTPS", func(t *testing.T) {
+		t.Parallel()
+		srv := httptest.NewTLSServer(http.HandlerFunc(echoHandler))
+		defer srv.Close()
+		rec := New(newTestCA(t), testutils.NewLogger(t))
+		rec.Transport = srv.Client().Transport
+		client := proxyClient(t, rec)
+
+		// the requests reuse the intercepted connection
+		for _, path := range []string{"/first", "/second"} {
+			res, err := client.Get(srv.URL + path)
+			require.NoError(t, err)
+			body, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+			assert.JSONEq(t, fmt.Sprintf(`{"method": "GET", "path": %q, "body": ""}`, path), string(body))
+			// the certificate is issued by the CA of the recorder
+			assert.Equal(t, "k6 test CA", res.TLS.PeerCertificates[0].Issuer.CommonName)
+		}
+
+		h := rec.HAR(0)
+		require.Len(t, h.Log.Entries, 2)
+		assert.Equal(t, srv.URL+"/first", h.Log.Entries[0].Request.URL)
+		assert.Equal(t, srv.URL+"/second", h.Log.Entries[1].Request.URL)
+		assert.Nil(t, h.Log.Entries[1].Request.PostData)
+	})
+
+	t.Run("CA", func(t *testing.T) {
+		t.Parallel()
+		rec := New(newTestCA(t), testutils.NewLogger(t))
+		proxy := httptest.NewServer(rec)
+		defer proxy.Close()
+
+		res, err := http.Get(proxy.URL + CAPath)
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		assert.Equal(t, rec.ca.CertificatePEM(), body)
+
+		res, err = http.Get(proxy.URL + "/other")
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Empty(t, rec.HAR(0).Log.Entries)
+	})
+}
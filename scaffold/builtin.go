@@ -0,0 +1,300 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package scaffold
+
+// handleSummarySkeleton is appended to all built-in script templates
+const handleSummarySkeleton = `
+// handleSummary is called at the end of the test with all the metrics and
+// returns where the end-of-test summary should be written to, while
+// defaultSummary() returns the usual text summary.
+export function handleSummary(data, defaultSummary) {
+	return {
+		stdout: defaultSummary(),
+		"{{.Name}}-summary.json": JSON.stringify(data, null, 2),
+	};
+}
+`
+
+const basicTemplate = `import http from "k6/http";
+import { check, sleep } from "k6";
+
+export const options = {
+	vus: 10,
+	duration: "30s",
+	thresholds: {
+		// less than 1% of the requests should fail
+		http_req_failed: ["rate<0.01"],
+		// 95% of the requests should be faster than 500ms
+		http_req_duration: ["p(95)<500"],
+	},
+};
+
+export default function () {
+	const res = http.get("{{.URL}}");
+	check(res, {
+		"status is 200": (r) => r.status === 200,
+	});
+	sleep(1);
+}
+` + handleSummarySkeleton
+
+const scenariosTemplate = `import http from "k6/http";
+import { check, group, sleep } from "k6";
+
+const baseURL = "{{.URL}}";
+
+export const options = {
+	scenarios: {
+		// users browsing the site, ramping up and down
+		browsing: {
+			executor: "ramping-vus",
+			exec: "browse",
+			startVUs: 0,
+			stages: [
+				{ duration: "30s", target: 10 },
+				{ duration: "1m", target: 10 },
+				{ duration: "30s", target: 0 },
+			],
+			gracefulRampDown: "10s",
+		},
+		// a constant rate of API calls, regardless of the response times
+		api: {
+			executor: "constant-arrival-rate",
+			exec: "api",
+			rate: 5,
+			timeUnit: "1s",
+			duration: "2m",
+			preAllocatedVUs: 5,
+			maxVUs: 20,
+		},
+	},
+	thresholds: {
+		http_req_failed: ["rate<0.01"],
+		"http_req_duration{scenario:browsing}": ["p(95)<800"],
+		"http_req_duration{scenario:api}": ["p(95)<300"],
+		checks: ["rate>0.99"],
+	},
+};
+
+export function browse() {
+	group("home page", () => {
+		const res = http.get(baseURL);
+		check(res, {
+			"status is 200": (r) => r.status === 200,
+		});
+	});
+	sleep(Math.random() * 2 + 1);
+}
+
+export function api() {
+	const res = http.get(baseURL, { headers: { Accept: "application/json" } });
+	check(res, {
+		"status is 200": (r) => r.status === 200,
+	});
+}
+` + handleSummarySkeleton
+
+const grpcTemplate = `import grpc from "k6/net/grpc";
+import { check, sleep } from "k6";
+
+const client = new grpc.Client();
+client.load([], "hello.proto");
+
+export const options = {
+	vus: 5,
+	duration: "30s",
+	thresholds: {
+		grpc_req_duration: ["p(95)<300"],
+		checks: ["rate>0.99"],
+	},
+};
+
+export default function () {
+	// use { plaintext: true } for servers without TLS
+	client.connect("{{.URL}}", {});
+	const res = client.invoke("hello.HelloService/SayHello", { greeting: "k6" });
+	check(res, {
+		"status is OK": (r) => r && r.status === grpc.StatusOK,
+	});
+	client.close();
+	sleep(1);
+}
+` + handleSummarySkeleton
+
+const grpcProto = `syntax = "proto2";
+
+package hello;
+
+service HelloService {
+  rpc SayHello(HelloRequest) returns (HelloResponse);
+}
+
+message HelloRequest {
+  optional string greeting = 1;
+}
+
+message HelloResponse {
+  required string reply = 1;
+}
+`
+
+const grpcReadme = `# {{.Name}}
+
+A k6 load test of the HelloService gRPC service at {{.URL}}, defined in
+hello.proto.
+
+    k6 run {{.ScriptName}}
+`
+
+const websocketTemplate = `import ws from "k6/ws";
+import { check } from "k6";
+
+export const options = {
+	vus: 10,
+	duration: "30s",
+	thresholds: {
+		ws_connecting: ["p(95)<1000"],
+		checks: ["rate>0.99"],
+	},
+};
+
+export default function () {
+	const res = ws.connect("{{.URL}}", {}, (socket) => {
+		socket.on("open", () => {
+			socket.send("hello");
+			socket.setInterval(() => socket.send(Date.now().toString()), 1000);
+		});
+		socket.on("message", (message) => {
+			check(message, {
+				"message isn't empty": (m) => m.length > 0,
+			});
+		});
+		socket.on("error", (e) => {
+			if (e.error() !== "websocket: close sent") {
+				console.error("unexpected error: ", e.error());
+			}
+		});
+		// every VU keeps its connection open for 10s
+		socket.setTimeout(() => socket.close(), 10000);
+	});
+	check(res, {
+		"status is 101": (r) => r && r.status === 101,
+	});
+}
+` + handleSummarySkeleton
+
+const browserTemplate = `import http from "k6/http";
+import { check, group, sleep } from "k6";
+import { parseHTML } from "k6/html";
+
+const pageURL = "{{.URL}}";
+
+export const options = {
+	vus: 10,
+	duration: "1m",
+	thresholds: {
+		"http_req_duration{resource:document}": ["p(95)<1000"],
+		"http_req_duration{resource:static}": ["p(95)<500"],
+		http_req_failed: ["rate<0.01"],
+	},
+};
+
+// resolve returns the absolute URL of a resource referenced by the page
+function resolve(ref) {
+	if (/^https?:\/\//.test(ref)) {
+		return ref;
+	}
+	const origin = pageURL.match(/^https?:\/\/[^/]+/)[0];
+	if (ref.indexOf("//") === 0) {
+		return origin.split("//")[0] + ref;
+	}
+	if (ref.indexOf("/") === 0) {
+		return origin + ref;
+	}
+	const base = pageURL.length > origin.length ? pageURL.replace(/[^/]*$/, "") : origin + "/";
+	return base + ref;
+}
+
+export default function () {
+	group("page load", () => {
+		const page = http.get(pageURL, { tags: { resource: "document" } });
+		check(page, {
+			"page status is 200": (r) => r.status === 200,
+		});
+
+		// fetch the scripts, stylesheets and images of the page in parallel, like a browser
+		const requests = [];
+		parseHTML(page.body)
+			.find("script[src], link[rel=stylesheet][href], img[src]")
+			.each((i, el) => {
+				const ref = el.getAttribute("src") || el.getAttribute("href");
+				if (ref.indexOf("data:") !== 0) {
+					requests.push(["GET", resolve(ref), null, { tags: { resource: "static" } }]);
+				}
+			});
+		const responses = http.batch(requests);
+		check(responses, {
+			"all resources loaded": (rs) => rs.every((r) => r.status === 200),
+		});
+	});
+	// the time that a user spends on the page
+	sleep(Math.random() * 3 + 2);
+}
+` + handleSummarySkeleton
+
+func builtinTemplates() []*Template {
+	return []*Template{
+		{
+			Name:        "basic",
+			Description: "HTTP requests with checks and thresholds",
+			URL:         DefaultURL,
+			Script:      basicTemplate,
+		},
+		{
+			Name:        "scenarios",
+			Description: "several scenarios with different executors",
+			URL:         DefaultURL,
+			Script:      scenariosTemplate,
+		},
+		{
+			Name:        "grpc",
+			Description: "project with a gRPC script and its .proto file",
+			URL:         "grpcb.in:9001",
+			Files: map[string]string{
+				"script.js":   grpcTemplate,
+				"hello.proto": grpcProto,
+				"README.md":   grpcReadme,
+			},
+		},
+		{
+			Name:        "websocket",
+			Description: "WebSocket connections sending and receiving messages",
+			URL:         "wss://echo.websocket.org",
+			Script:      websocketTemplate,
+		},
+		{
+			Name:        "browser",
+			Description: "page loads fetching all the resources of the page, like a browser",
+			URL:         DefaultURL,
+			Script:      browserTemplate,
+		},
+	}
+}
@@ -0,0 +1,262 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package scaffold
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// Registry holds the available templates by name.
+type Registry struct {
+	templates map[string]*Template
+}
+
+// NewRegistry returns a registry with the built-in templates.
+func NewRegistry() *Registry {
+	r := &Registry{templates: make(map[string]*Template)}
+	for _, t := range builtinTemplates() {
+		r.Register(t)
+	}
+	return r
+}
+
+// Register adds the template, replacing any template with the same name.
+func (r *Registry) Register(t *Template) {
+	r.templates[t.Name] = t
+}
+
+// Get returns the template with the given name.
+func (r *Registry) Get(name string) (*Template, error) {
+	t, ok := r.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown template %q, available templates are: %s",
+			name, strings.Join(r.Names(), ", "))
+	}
+	return t, nil
+}
+
+// Names returns the sorted names of all templates.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.templates))
+	for name := range r.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// List returns all templates, sorted by name.
+func (r *Registry) List() []*Template {
+	names := r.Names()
+	templates := make([]*Template, len(names))
+	for i, name := range names {
+		templates[i] = r.templates[name]
+	}
+	return templates
+}
+
+// Load registers the templates in a directory or in a .tar, .tar.gz, .tgz or
+// .zip archive, see LoadDir.
+func (r *Registry) Load(fs afero.Fs, filename string) error {
+	fi, err := fs.Stat(filename)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		return r.LoadDir(fs, filename)
+	}
+	return r.LoadArchive(fs, filename)
+}
+
+// LoadArchive registers the templates in a .tar, .tar.gz, .tgz or .zip
+// archive, which has the same layout as the directories of LoadDir.
+func (r *Registry) LoadArchive(fs afero.Fs, filename string) error {
+	data, err := afero.ReadFile(fs, filename)
+	if err != nil {
+		return err
+	}
+	memfs := afero.NewMemMapFs()
+	switch name := strings.ToLower(filename); {
+	case strings.HasSuffix(name, ".zip"):
+		err = extractZip(memfs, data)
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		var gz *gzip.Reader
+		if gz, err = gzip.NewReader(bytes.NewReader(data)); err == nil {
+			err = extractTar(memfs, gz)
+		}
+	case strings.HasSuffix(name, ".tar"):
+		err = extractTar(memfs, bytes.NewReader(data))
+	default:
+		return fmt.Errorf("%s isn't a directory or a .tar, .tar.gz, .tgz or .zip archive", filename)
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't extract the templates in %s: %w", filename, err)
+	}
+	return r.LoadDir(memfs, "/")
+}
+
+// LoadDir registers the templates in a directory. Every .js file is a script
+// template named after the file, and every subdirectory is a project template
+// named after the directory, with all the files in it.
+func (r *Registry) LoadDir(fs afero.Fs, dir string) error {
+	infos, err := afero.ReadDir(fs, dir)
+	if err != nil {
+		return err
+	}
+	for _, fi := range infos {
+		filename := filepath.Join(dir, fi.Name())
+		switch {
+		case fi.IsDir():
+			files, err := readProject(fs, filename)
+			if err != nil {
+				return err
+			}
+			r.Register(&Template{
+				Name: fi.Name(), Description: "custom project from " + filename, Files: files,
+			})
+		case filepath.Ext(fi.Name()) == ".js":
+			data, err := afero.ReadFile(fs, filename)
+			if err != nil {
+				return err
+			}
+			r.Register(&Template{
+				Name:        strings.TrimSuffix(fi.Name(), ".js"),
+				Description: "custom script from " + filename,
+				Script:      string(data),
+			})
+		}
+	}
+	return nil
+}
+
+func readProject(fs afero.Fs, dir string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := afero.Walk(fs, dir, func(filename string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() {
+			return err
+		}
+		data, err := afero.ReadFile(fs, filename)
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(dir, filename)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(name)] = string(data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("the project template %s is empty", dir)
+	}
+	return files, nil
+}
+
+// archivePath returns the cleaned path of an archive entry, making sure that
+// it can't point outside of the archive
+func archivePath(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	for _, segment := range strings.Split(name, "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("invalid path %s in the archive", name)
+		}
+	}
+	return path.Clean("/" + name), nil
+}
+
+func extractTar(fs afero.Fs, r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			continue
+		}
+		name, err := archivePath(hdr.Name)
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(fs, name, data); err != nil {
+			return err
+		}
+	}
+}
+
+func extractZip(fs afero.Fs, data []byte) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		name, err := archivePath(f.Name)
+		if err != nil {
+			return err
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return err
+		}
+		if err := writeFile(fs, name, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(fs afero.Fs, name string, data []byte) error {
+	if err := fs.MkdirAll(path.Dir(name), 0o755); err != nil {
+		return err
+	}
+	return afero.WriteFile(fs, name, data, 0o644)
+}
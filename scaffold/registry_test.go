@@ -0,0 +1,161 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package scaffold
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testTemplateFiles = map[string]string{
+	"smoke.js":               `export default function () {}`,
+	"README.md":              `not a template`,
+	"service/script.js":      `import "./lib/helpers.js";`,
+	"service/lib/helpers.js": `export function helper() {}`,
+}
+
+func makeTar(t *testing.T, files map[string]string) []byte {
+	var b bytes.Buffer
+	tw := tar.NewWriter(&b)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return b.Bytes()
+}
+
+func makeZip(t *testing.T, files map[string]string) []byte {
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return b.Bytes()
+}
+
+func checkTestTemplates(t *testing.T, r *Registry) {
+	assert.Equal(t, []string{"basic", "browser", "grpc", "scenarios", "service", "smoke", "websocket"}, r.Names())
+
+	smoke, err := r.Get("smoke")
+	require.NoError(t, err)
+	assert.False(t, smoke.IsProject())
+	assert.Equal(t, testTemplateFiles["smoke.js"], smoke.Script)
+
+	service, err := r.Get("service")
+	require.NoError(t, err)
+	assert.True(t, service.IsProject())
+	assert.Equal(t, map[string]string{
+		"script.js":      testTemplateFiles["service/script.js"],
+		"lib/helpers.js": testTemplateFiles["service/lib/helpers.js"],
+	}, service.Files)
+}
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+	t.Run("Builtin", func(t *testing.T) {
+		t.Parallel()
+		r := NewRegistry()
+		assert.Equal(t, []string{"basic", "browser", "grpc", "scenarios", "websocket"}, r.Names())
+		for _, tmpl := range r.List() {
+			assert.NotEmpty(t, tmpl.Description, tmpl.Name)
+			assert.NotEmpty(t, tmpl.URL, tmpl.Name)
+		}
+		_, err := r.Get("nope")
+		assert.EqualError(t, err, `unknown template "nope", available templates are: basic, browser, grpc, scenarios, websocket`)
+	})
+	t.Run("Override", func(t *testing.T) {
+		t.Parallel()
+		r := NewRegistry()
+		r.Register(&Template{Name: "basic", Script: "custom"})
+		basic, err := r.Get("basic")
+		require.NoError(t, err)
+		assert.Equal(t, "custom", basic.Script)
+	})
+	t.Run("Dir", func(t *testing.T) {
+		t.Parallel()
+		fs := afero.NewMemMapFs()
+		for name, content := range testTemplateFiles {
+			require.NoError(t, afero.WriteFile(fs, "/templates/"+name, []byte(content), 0o644))
+		}
+		r := NewRegistry()
+		require.NoError(t, r.Load(fs, "/templates"))
+		checkTestTemplates(t, r)
+	})
+
+	archives := map[string]func(t *testing.T, files map[string]string) []byte{
+		"templates.tar": makeTar,
+		"templates.tar.gz": func(t *testing.T, files map[string]string) []byte {
+			var b bytes.Buffer
+			gz := gzip.NewWriter(&b)
+			_, err := gz.Write(makeTar(t, files))
+			require.NoError(t, err)
+			require.NoError(t, gz.Close())
+			return b.Bytes()
+		},
+		"templates.zip": makeZip,
+	}
+	for filename, makeArchive := range archives {
+		filename, makeArchive := filename, makeArchive
+		t.Run(filename, func(t *testing.T) {
+			t.Parallel()
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, "/"+filename, makeArchive(t, testTemplateFiles), 0o644))
+			r := NewRegistry()
+			require.NoError(t, r.Load(fs, "/"+filename))
+			checkTestTemplates(t, r)
+
+			require.NoError(t, afero.WriteFile(fs, "/evil-"+filename,
+				makeArchive(t, map[string]string{"../evil.js": "evil"}), 0o644))
+			err := r.Load(fs, "/evil-"+filename)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid path ../evil.js in the archive")
+		})
+	}
+
+	t.Run("Errors", func(t *testing.T) {
+		t.Parallel()
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/templates.rar", []byte("rar"), 0o644))
+		require.NoError(t, afero.WriteFile(fs, "/broken.zip", []byte("zip"), 0o644))
+		r := NewRegistry()
+		assert.EqualError(t, r.Load(fs, "/templates.rar"),
+			"/templates.rar isn't a directory or a .tar, .tar.gz, .tgz or .zip archive")
+		err := r.Load(fs, "/broken.zip")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "couldn't extract the templates in /broken.zip")
+		assert.Error(t, r.Load(fs, "/missing"))
+	})
+}
@@ -0,0 +1,153 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package scaffold generates new k6 scripts and projects from templates.
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/afero"
+)
+
+// DefaultURL is the URL of the system under test in the generated scripts,
+// unless a template or the user specify another one.
+const DefaultURL = "https://test.k6.io"
+
+// Template is a script or a project template. The files are text/template
+// templates, executed with Data.
+type Template struct {
+	Name        string
+	Description string
+	// URL is the default URL of the system under test
+	URL string
+	// Script is the content of a single script template
+	Script string
+	// Files maps the slash-separated paths of the files of a project template,
+	// relative to the project directory, to their contents
+	Files map[string]string
+}
+
+// Data is what the templates are executed with.
+type Data struct {
+	// Name is the name of the script or project, i.e. the target without
+	// its directory and its extension
+	Name string
+	// ScriptName is the filename of the generated script
+	ScriptName string
+	// URL is the URL of the system under test
+	URL string
+}
+
+// IsProject returns whether the template generates a directory instead of a
+// single script.
+func (t *Template) IsProject() bool {
+	return t.Files != nil
+}
+
+// DefaultTarget returns the path that the template is generated at if none is given.
+func (t *Template) DefaultTarget() string {
+	if t.IsProject() {
+		return t.Name
+	}
+	return "script.js"
+}
+
+// NewData returns the data to generate the template at the given target with.
+// The template's URL is used if url is empty.
+func (t *Template) NewData(target, url string) Data {
+	if url == "" {
+		url = t.URL
+	}
+	if url == "" {
+		url = DefaultURL
+	}
+	name := filepath.Base(target)
+	data := Data{Name: strings.TrimSuffix(name, filepath.Ext(name)), ScriptName: name, URL: url}
+	if t.IsProject() {
+		data.Name = name
+		data.ScriptName = "script.js"
+	}
+	return data
+}
+
+// Render executes the templates and returns the generated files, keyed by their
+// slash-separated paths relative to the project directory, or by an empty path
+// for a script template.
+func (t *Template) Render(data Data) (map[string][]byte, error) {
+	files := t.Files
+	if !t.IsProject() {
+		files = map[string]string{"": t.Script}
+	}
+	result := make(map[string][]byte, len(files))
+	for name, text := range files {
+		tmpl, err := template.New(path.Join(t.Name, name)).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template %s: %w", t.Name, err)
+		}
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, data); err != nil {
+			return nil, fmt.Errorf("couldn't execute the template %s: %w", t.Name, err)
+		}
+		result[name] = b.Bytes()
+	}
+	return result, nil
+}
+
+// Generate renders the template and writes the script, or the project
+// directory, at target. Existing files are only overwritten if force is true.
+// It returns the paths of the written files.
+func (t *Template) Generate(fs afero.Fs, target string, data Data, force bool) ([]string, error) {
+	files, err := t.Render(data)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = filepath.Join(target, filepath.FromSlash(name))
+		if force {
+			continue
+		}
+		if _, err := fs.Stat(paths[i]); err == nil {
+			return nil, fmt.Errorf("%s already exists, use --force to overwrite it", paths[i])
+		}
+	}
+	for i, name := range names {
+		if err := fs.MkdirAll(filepath.Dir(paths[i]), 0o755); err != nil {
+			return nil, err
+		}
+		if err := afero.WriteFile(fs, paths[i], files[name], 0o644); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,101 @@
+/*
+ *
+ * k6 - a next-generation load testing tool
+ * Copyright (C) 2021 Load Impact
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package scaffold
+
+import (
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateNewData(t *testing.T) {
+	t.Parallel()
+	script := &Template{Name: "script", URL: "https://example.com", Script: ""}
+	assert.Equal(t, "script.js", script.DefaultTarget())
+	assert.Equal(t, Data{Name: "load-test", ScriptName: "load-test.js", URL: "https://example.com"},
+		script.NewData(filepath.Join("tests", "load-test.js"), ""))
+	assert.Equal(t, Data{Name: "load-test", ScriptName: "load-test.js", URL: "https://k6.io"},
+		script.NewData("load-test.js", "https://k6.io"))
+
+	project := &Template{Name: "project", Files: map[string]string{}}
+	assert.Equal(t, "project", project.DefaultTarget())
+	assert.Equal(t, Data{Name: "my-service", ScriptName: "script.js", URL: DefaultURL},
+		project.NewData(filepath.Join("tests", "my-service"), ""))
+}
+
+func TestTemplateGenerate(t *testing.T) {
+	t.Parallel()
+	t.Run("Script", func(t *testing.T) {
+		t.Parallel()
+		fs := afero.NewMemMapFs()
+		tmpl := &Template{Name: "script", Script: `http.get("{{.URL}}"); // {{.Name}} {{.ScriptName}}`}
+		paths, err := tmpl.Generate(fs, "/test.js", tmpl.NewData("/test.js", ""), false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/test.js"}, paths)
+		data, err := afero.ReadFile(fs, "/test.js")
+		require.NoError(t, err)
+		assert.Equal(t, `http.get("https://test.k6.io"); // test test.js`, string(data))
+
+		_, err = tmpl.Generate(fs, "/test.js", tmpl.NewData("/test.js", ""), false)
+		assert.EqualError(t, err, "/test.js already exists, use --force to overwrite it")
+		_, err = tmpl.Generate(fs, "/test.js", tmpl.NewData("/test.js", "https://k6.io"), true)
+		require.NoError(t, err)
+		data, err = afero.ReadFile(fs, "/test.js")
+		require.NoError(t, err)
+		assert.Equal(t, `http.get("https://k6.io"); // test test.js`, string(data))
+	})
+	t.Run("Project", func(t *testing.T) {
+		t.Parallel()
+		fs := afero.NewMemMapFs()
+		tmpl := &Template{Name: "project", Files: map[string]string{
+			"script.js":      `import { helper } from "./lib/helpers.js";`,
+			"lib/helpers.js": `export function helper() { return "{{.Name}}"; }`,
+		}}
+		require.NoError(t, afero.WriteFile(fs, "/svc/lib/helpers.js", []byte("old"), 0o644))
+		_, err := tmpl.Generate(fs, "/svc", tmpl.NewData("/svc", ""), false)
+		assert.EqualError(t, err, filepath.Join("/svc", "lib", "helpers.js")+" already exists, use --force to overwrite it")
+		exists, err := afero.Exists(fs, "/svc/script.js")
+		require.NoError(t, err)
+		assert.False(t, exists, "nothing should be written if any file exists")
+
+		paths, err := tmpl.Generate(fs, "/svc", tmpl.NewData("/svc", ""), true)
+		require.NoError(t, err)
+		sort.Strings(paths)
+		assert.Equal(t, []string{filepath.Join("/svc", "lib", "helpers.js"), filepath.Join("/svc", "script.js")}, paths)
+		data, err := afero.ReadFile(fs, "/svc/lib/helpers.js")
+		require.NoError(t, err)
+		assert.Equal(t, `export function helper() { return "svc"; }`, string(data))
+	})
+	t.Run("Errors", func(t *testing.T) {
+		t.Parallel()
+		fs := afero.NewMemMapFs()
+		_, err := (&Template{Name: "invalid", Script: "{{.Name"}).Generate(fs, "/a.js", Data{}, false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid template invalid")
+		_, err = (&Template{Name: "unknown", Script: "{{.Host}}"}).Generate(fs, "/a.js", Data{}, false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "couldn't execute the template unknown")
+	})
+}
@@ -104,13 +104,26 @@ const (
 	TagVU
 	TagOCSPStatus
 	TagIP
+
+	// Enabled by default, but only emitted by scenarios with a weighted exec.
+	TagExec
+
+	// Enabled by default, but only emitted by HTTP requests that can be retried.
+	TagAttempt
+
+	// Enabled by default, but only emitted by HTTP requests when the VU HTTP cache is enabled.
+	TagCache
+
+	// Enabled by default, but only emitted when network conditions are emulated.
+	TagNetwork
 )
 
 // DefaultSystemTagSet includes all of the system tags emitted with metrics by default.
 // Other tags that are not enabled by default include: iter, vu, ocsp_status, ip
 //nolint:gochecknoglobals
 var DefaultSystemTagSet = TagProto | TagSubproto | TagStatus | TagMethod | TagURL | TagName | TagGroup |
-	TagCheck | TagCheck | TagError | TagErrorCode | TagTLSVersion | TagScenario | TagService | TagExpectedResponse
+	TagCheck | TagCheck | TagError | TagErrorCode | TagTLSVersion | TagScenario | TagService | TagExpectedResponse | TagExec |
+	TagAttempt | TagCache | TagNetwork
 
 // Add adds a tag to tag set.
 func (i *SystemTagSet) Add(tag SystemTagSet) {
@@ -148,6 +148,19 @@ func (pb *ProgressBar) renderLeft(maxLen int) string {
 	return left
 }
 
+// GetStatus returns the current status and the clamped progress value of the
+// progressbar in a thread-safe way.
+func (pb *ProgressBar) GetStatus() (Status, float64) {
+	pb.mutex.RLock()
+	defer pb.mutex.RUnlock()
+
+	var progress float64
+	if pb.progress != nil {
+		progress, _ = pb.progress()
+	}
+	return pb.status, Clampf(progress, 0, 1)
+}
+
 // Modify changes the progressbar options in a thread-safe way.
 func (pb *ProgressBar) Modify(options ...ProgressBarOption) {
 	pb.mutex.Lock()